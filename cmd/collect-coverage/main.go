@@ -0,0 +1,240 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/collect"
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+	"github.com/konflux-ci/coverage-dashboard/internal/console"
+	"github.com/konflux-ci/coverage-dashboard/internal/display"
+	"github.com/konflux-ci/coverage-dashboard/internal/features"
+	"github.com/konflux-ci/coverage-dashboard/internal/ghauth"
+	"github.com/konflux-ci/coverage-dashboard/internal/interrupt"
+	"github.com/konflux-ci/coverage-dashboard/internal/issues"
+	"github.com/konflux-ci/coverage-dashboard/internal/locale"
+	"github.com/konflux-ci/coverage-dashboard/internal/policy"
+	"github.com/konflux-ci/coverage-dashboard/internal/webhook"
+)
+
+func main() {
+	var (
+		reposDir       = flag.String("repos-dir", "repos", "Directory containing repository configurations")
+		reposFile      = flag.String("repos-file", "repos.yaml", "Single file listing repository configurations, merged with --repos-dir")
+		codeownersFile = flag.String("codeowners", "CODEOWNERS", "Path to CODEOWNERS file")
+		groupsFile     = flag.String("groups", "groups.yaml", "Named groups of repositories, aggregated on the dashboard and matched by alert routes")
+		workspaceDir   = flag.String("workspace", "workspace", "Directory to clone repositories into")
+		reportsDir     = flag.String("reports-dir", "gh-pages/coverage", "Directory to write HTML coverage reports to")
+		output         = flag.String("output", "coverage.json", "Path to write the dashboard coverage data to")
+		manifest       = flag.String("manifest", "run-manifest.json", "Path to write the run manifest to")
+		runURL         = flag.String("run-url", "", "URL of the CI run producing this data")
+		retryFailed    = flag.Bool("retry-failed", false, "Only re-attempt repositories that failed in the manifest given by --from-manifest")
+		fromManifest   = flag.String("from-manifest", "", "Run manifest of a previous run to retry failed repositories from")
+		previous       = flag.String("previous-manifest", "", "Run manifest of the last run, whose timings decide the collection order")
+		deadline       = flag.Duration("deadline", 0, "Maximum duration of the whole run (e.g. 5h); remaining repositories are marked as timed out")
+		repoTimeout    = flag.Duration("repo-timeout", 0, "Maximum duration per repository (e.g. 30m), overridable with timeout in the repository configuration")
+		publishDir     = flag.String("publish-dir", "", "Git checkout of the published site (e.g. gh-pages) to push results to as each repository finishes")
+		regression     = flag.Float64("regression-delta", 5, "Coverage drop in percentage points since --previous-manifest reported as a regression, overridable with regression_delta in the repository configuration (0 disables)")
+		openIssues     = flag.Bool("regression-issues", false, "Open or update an issue in each regressed repository, and in repositories failing collection for --failure-alert-after runs, using GITHUB_WRITE_TOKEN or GITHUB_TOKEN")
+		issueLabel     = flag.String("regression-label", issues.DefaultLabel, "Label marking regression issues, used to update an open issue instead of opening another")
+		alertCooldown  = flag.Duration("alert-cooldown", 7*24*time.Hour, "Minimum time between reminders about a repository that stays regressed")
+		escalateAfter  = flag.Int("escalate-after", 3, "Consecutive regressed runs after which a regression is escalated to its owners (0 never escalates)")
+		failureAlerts  = flag.Int("failure-alert-after", collect.DefaultFailureAlertAfter, "Consecutive runs without coverage after which a repository's owners are alerted (0 never alerts)")
+		snapshotDir    = flag.String("snapshot-dir", "", "Git checkout of the data branch to commit the final dashboard.json and per-repository summaries to")
+		vulnCheck      = flag.Bool("vulncheck", false, "Run govulncheck on every repository and report whether tests cover its vulnerable call paths")
+		configSource   = flag.String("config-source", "", "Read repository configurations, repos.yaml, CODEOWNERS, groups.yaml and policy.yaml (unless --policy is given) from GitHub instead of the local files (e.g. github://konflux-ci/coverage-dashboard@main/repos)")
+		policyFile     = flag.String("policy", "policy.yaml", "Organization policy with default thresholds, exclusion caps, alert settings and the fields repositories may override")
+		retention      = flag.Duration("report-retention", 90*24*time.Hour, "Age after which commit-stamped reports are pruned (0 keeps them forever)")
+		keepReports    = flag.Int("report-keep", 10, "Number of most recent commit-stamped reports kept per repository regardless of --report-retention")
+		reportWorkers  = flag.Int("report-workers", 0, "Number of report files linked concurrently (0 uses one per CPU)")
+		maxAge         = flag.Duration("max-age", collect.DefaultMaxAge, "Age of the last successful collection after which a repository's coverage is stale, overridable with max_age in the repository configuration (0 disables)")
+		reportMemory   = flag.Int64("report-memory", collect.DefaultReportMemoryBudget>>20, "Memory budget, in MiB, for the report files held at once while rendering a report")
+		siteLocale     = flag.String("locale", "", "Locale the site, reports and widgets format numbers and dates in (e.g. de-DE), overridable on the site with ?locale= (default: "+locale.DefaultTag+" numbers with ISO dates)")
+		progress       = flag.Bool("progress", false, "Draw a progress bar over the repositories instead of printing their lines, keeping warnings and failures")
+		quiet          = flag.Bool("quiet", false, "Print only the final summary, and errors")
+		freshness      = flag.Bool("dependency-freshness", false, "Count the direct dependencies of every repository with newer versions on the module proxy, shown next to its coverage")
+		dockerHost     = flag.String("docker-host", "", "Docker API socket the tests of repositories with needs_docker run against (e.g. unix:///var/run/docker.sock); without it, their packages whose tests start containers are left out and the repositories marked partial")
+		webhookURL     = flag.String("webhook-url", "", "Post regression and collection failure alerts as JSON signed with $"+webhook.SecretEnv+" to this URL")
+		offboarded     = flag.String("offboarded", config.DefaultOffboardedFile, "Offboarded repositories, whose reports and imported history are deleted once past the retention recorded by offboard")
+		ascii          = flag.Bool("ascii", false, "Print only ASCII, without emoji, for terminals and log viewers without Unicode (default: detected from TERM and the locale)")
+		writesPerMin   = flag.Int("writes-per-minute", ghauth.DefaultWritesPerMinute, "Write requests of --regression-issues sent to GitHub per minute at most, with jitter, so GitHub's abuse detection does not trip when many repositories regress (0 does not pace them)")
+		githubBaseURL  = flag.String("github-base-url", "", "GitHub API of --config-source and --regression-issues, whose host repositories are cloned from and reports link to, e.g. https://github.example.com/api/v3 on GitHub Enterprise Server (default: $"+ghauth.APIURLEnv+", or api.github.com)")
+		imageDigest    = flag.String("image-digest", collect.ImageDigestFromEnv(), "Digest of the container image the collector runs in, recorded in the provenance of the run (defaults to $"+collect.ImageDigestEnv+")")
+	)
+
+	flag.Parse()
+
+	mode, err := display.ModeOf(*progress, *quiet)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	mode = mode.On(os.Stdout)
+
+	if _, err := locale.Parse(*siteLocale); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	tokens, err := ghauth.TokensFromEnv().WithBaseURL(*githubBaseURL)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	tokens.Writes = ghauth.NewWriteLimiter(*writesPerMin)
+
+	// Flags given on the command line take precedence over the policy
+	explicit := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+	// Without --ascii, terminals and locales without Unicode get ASCII output
+	if !explicit["ascii"] {
+		*ascii = console.DetectASCII(os.Getenv)
+	}
+	style := console.StyleOf(*ascii, false)
+	// Lines printed before the run renders its output
+	early := console.NewWriter(os.Stdout, style)
+
+	ctx, stop := interrupt.Context()
+	defer stop()
+	if *configSource != "" {
+		source, err := config.ParseSource(*configSource)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		client := tokens.ReadClient(ctx)
+		local, err := source.Fetch(ctx, client, filepath.Join(*workspaceDir, "configs"))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(early, "📥 Loaded configurations from %s\n", source)
+		*reposDir, *reposFile, *codeownersFile, *groupsFile = local.ReposDir, local.ReposFile, local.CodeownersFile, local.GroupsFile
+		// The policy of the source applies to its configurations, unless one is given on the command line
+		if !explicit["policy"] {
+			*policyFile = local.PolicyFile
+		}
+	}
+
+	orgPolicy, err := policy.Load(*policyFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Risky behaviors are switched per environment, by the policy or the environment variable
+	flags, err := features.Resolve(orgPolicy.Features, os.Getenv(features.Env))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Alert settings of the policy apply unless given on the command line
+	if cooldown, _ := orgPolicy.Cooldown(); cooldown > 0 && !explicit["alert-cooldown"] {
+		*alertCooldown = cooldown
+	}
+	if orgPolicy.Alerts.EscalateAfter != nil && !explicit["escalate-after"] {
+		*escalateAfter = *orgPolicy.Alerts.EscalateAfter
+	}
+	if orgPolicy.Alerts.FailureAlertAfter != nil && !explicit["failure-alert-after"] {
+		*failureAlerts = *orgPolicy.Alerts.FailureAlertAfter
+	}
+	if policyRetention, _ := orgPolicy.Retention(); policyRetention > 0 && !explicit["report-retention"] {
+		*retention = policyRetention
+	}
+	if orgPolicy.Reports.Keep != nil && !explicit["report-keep"] {
+		*keepReports = *orgPolicy.Reports.Keep
+	}
+
+	config := collect.Config{
+		ReposDir:         *reposDir,
+		ReposFile:        *reposFile,
+		CodeownersFile:   *codeownersFile,
+		WorkspaceDir:     *workspaceDir,
+		ReportsDir:       *reportsDir,
+		OutputFile:       *output,
+		ManifestFile:     *manifest,
+		RunURL:           *runURL,
+		RetryFailed:      *retryFailed,
+		FromManifest:     *fromManifest,
+		PreviousManifest: *previous,
+		Deadline:         *deadline,
+		RepoTimeout:      *repoTimeout,
+		PublishDir:       *publishDir,
+		SnapshotDir:      *snapshotDir,
+		VulnCheck:        *vulnCheck,
+		GroupsFile:       *groupsFile,
+		RegressionDelta:  *regression,
+		Alerts: collect.AlertPolicy{
+			Cooldown:      *alertCooldown,
+			EscalateAfter: *escalateAfter,
+		},
+		Policy: orgPolicy,
+		ReportRetention: collect.ReportRetention{
+			MaxAge: *retention,
+			Keep:   *keepReports,
+		},
+		ReportWorkers:       *reportWorkers,
+		ReportMemoryBudget:  *reportMemory << 20,
+		FailureAlertAfter:   *failureAlerts,
+		MaxAge:              *maxAge,
+		Locale:              *siteLocale,
+		ImageDigest:         *imageDigest,
+		Output:              mode,
+		Features:            flags,
+		DockerHost:          *dockerHost,
+		DependencyFreshness: *freshness,
+		OffboardedFile:      *offboarded,
+		GitHubURL:           tokens.WebURL(),
+	}
+
+	var notifiers collect.Notifiers
+	if *openIssues && !flags.Enabled(features.IssueCreation) {
+		fmt.Fprintf(early, "⏸️  Not opening issues for --regression-issues, %s is off\n", features.IssueCreation)
+	} else if *openIssues {
+		if tokens.WriteSource == "" {
+			fmt.Fprintf(os.Stderr, "Error: %s, %s or a GitHub App (%s) is required for --regression-issues\n", ghauth.WriteTokenEnv, ghauth.SharedTokenEnv, ghauth.AppIDEnv)
+			os.Exit(1)
+		}
+		notifiers = append(notifiers, issues.NewTracker(tokens.WriteClient(ctx), *issueLabel, orgPolicy))
+	}
+	if *webhookURL != "" {
+		notifier, err := webhook.NewNotifier(nil, *webhookURL, os.Getenv(webhook.SecretEnv))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		notifiers = append(notifiers, notifier)
+	}
+	if len(notifiers) > 0 {
+		config.Notifier, config.FailureNotifier = notifiers, notifiers
+	}
+
+	runner, err := collect.NewRunner(config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error initializing: %v\n", err)
+		os.Exit(1)
+	}
+
+	// With --progress or --quiet, the output of the run is shaped as it goes by, and rendered in ASCII when asked
+	shaped := display.NewWriter(os.Stdout, mode, style)
+	restore := func() error { return nil }
+	if mode != display.Lines || style != console.Unicode {
+		if restore, err = console.Capture(&os.Stdout, console.NewWriter(shaped, console.Unicode)); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	runErr := runner.Run(ctx)
+	if err := errors.Join(restore(), shaped.Flush()); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to write output: %v\n", err)
+	}
+	if runErr != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", runErr)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/collect"
+	"github.com/konflux-ci/coverage-dashboard/internal/interrupt"
+)
+
+// maxPackagesShown bounds the package drops printed on violations
+const maxPackagesShown = 10
+
+func main() {
+	var (
+		repo    = flag.String("repo", "", "Repository to compare, in org/name form (required)")
+		base    = flag.String("base", "", "Git ref of the baseline, e.g. the previous release tag (required)")
+		head    = flag.String("head", "HEAD", "Git ref of the candidate, e.g. the release tag")
+		maxDrop = flag.Float64("max-drop", 0, "Coverage drop from --base to --head, in percentage points, that fails the comparison")
+		repoDir = flag.String("repo-dir", ".", "Checkout of the repository with the history of both refs, to resolve them")
+		dataDir = flag.String("data-dir", "data", "Git checkout of the data branch of the coverage dashboard")
+		dataRef = flag.String("data-ref", "HEAD", "Ref of the data branch in --data-dir, e.g. origin/data")
+	)
+
+	flag.Parse()
+
+	if *repo == "" || *base == "" {
+		fmt.Fprintln(os.Stderr, "Error: --repo and --base are required")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := interrupt.Context()
+	defer stop()
+
+	summaries, err := collect.NewSnapshotHistory(*dataDir, *dataRef).Summaries(ctx, *repo)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+	baseSummary, err := collect.SummaryAt(ctx, summaries, *repoDir, *base)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+	headSummary, err := collect.SummaryAt(ctx, summaries, *repoDir, *head)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+
+	comparison := collect.CompareSummaries(baseSummary, headSummary)
+	fmt.Printf("📊 %s: %.1f%% at %s (%s) → %.1f%% at %s (%s)\n", *repo,
+		*baseSummary.Coverage, *base, baseSummary.GeneratedAt.Format("2006-01-02"),
+		*headSummary.Coverage, *head, headSummary.GeneratedAt.Format("2006-01-02"))
+
+	if comparison.Drop <= *maxDrop {
+		fmt.Printf("✅ Coverage change of %+.1f points is within the allowed drop of %.1f\n", -comparison.Drop, *maxDrop)
+		return
+	}
+
+	fmt.Printf("❌ Coverage dropped by %.1f points, more than the allowed %.1f\n", comparison.Drop, *maxDrop)
+	shown := 0
+	for _, change := range comparison.Packages {
+		if change.Change() >= 0 || shown == maxPackagesShown {
+			break
+		}
+		fmt.Printf("  → %s: %+.1f\n", change.Package, change.Change())
+		shown++
+	}
+	os.Exit(1)
+}
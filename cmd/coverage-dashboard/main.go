@@ -0,0 +1,1195 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/admin"
+	"github.com/konflux-ci/coverage-dashboard/internal/codecov"
+	"github.com/konflux-ci/coverage-dashboard/internal/collect"
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+	"github.com/konflux-ci/coverage-dashboard/internal/deployments"
+	"github.com/konflux-ci/coverage-dashboard/internal/doctor"
+	"github.com/konflux-ci/coverage-dashboard/internal/events"
+	"github.com/konflux-ci/coverage-dashboard/internal/ghauth"
+	"github.com/konflux-ci/coverage-dashboard/internal/interrupt"
+	"github.com/konflux-ci/coverage-dashboard/internal/locale"
+	"github.com/konflux-ci/coverage-dashboard/internal/policy"
+	"github.com/konflux-ci/coverage-dashboard/internal/pr"
+	"github.com/konflux-ci/coverage-dashboard/internal/site"
+	"github.com/konflux-ci/coverage-dashboard/internal/sonarqube"
+	"github.com/konflux-ci/coverage-dashboard/internal/store"
+)
+
+// commands maps subcommand names to their entry points
+var commands = map[string]func(ctx context.Context, args []string) int{
+	"doctor":         runDoctor,
+	"convert-repos":  runConvertRepos,
+	"uncovered":      runUncovered,
+	"profile":        runProfile,
+	"check-policy":   runCheckPolicy,
+	"deploy-start":   runDeployStart,
+	"deploy-finish":  runDeployFinish,
+	"velocity":       runVelocity,
+	"pr-upload":      runPRUpload,
+	"pr-coverage":    runPRCoverage,
+	"pr-expire":      runPRExpire,
+	"import-codecov": runImportCodecov,
+	"reconcile":      runReconcile,
+	"site-tables":    runSiteTables,
+	"headline":       runHeadline,
+	"go-versions":    runGoVersions,
+	"store-backup":   runStoreBackup,
+	"store-restore":  runStoreRestore,
+	"edit-config":    runEditConfig,
+	"admin-serve":    runAdminServe,
+	"events-serve":   runEventsServe,
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	command, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+
+	// Commands return instead of exiting, so their deferred cleanup runs when interrupted
+	ctx, stop := interrupt.Context()
+	code := command(ctx, os.Args[2:])
+	stop()
+	os.Exit(code)
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: coverage-dashboard <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+	fmt.Fprintln(os.Stderr, "  doctor           Check tokens, tools, configurations and publish credentials")
+	fmt.Fprintln(os.Stderr, "  convert-repos    Convert repository configurations between repos.yaml and per-repo files")
+	fmt.Fprintln(os.Stderr, "  uncovered        Print the largest uncovered regions of a repository as Markdown with GitHub permalinks")
+	fmt.Fprintln(os.Stderr, "  profile          Download a coverage profile archived with the report of a repository's commit")
+	fmt.Fprintln(os.Stderr, "  check-policy     Evaluate the Rego gates of the policy against coverage.json")
+	fmt.Fprintln(os.Stderr, "  deploy-start     Create a GitHub Deployment for a publish of the dashboard site and print its ID")
+	fmt.Fprintln(os.Stderr, "  deploy-finish    Set the final state of a deployment created by deploy-start")
+	fmt.Fprintln(os.Stderr, "  velocity         Compute coverage velocity per repository and team from the data branch history")
+	fmt.Fprintln(os.Stderr, "  pr-upload        Stage the coverage of a pull request in the site's pulls directory")
+	fmt.Fprintln(os.Stderr, "  pr-coverage      Print the latest coverage uploaded for a pull request as JSON")
+	fmt.Fprintln(os.Stderr, "  pr-expire        Publish the coverage of sharded uploads past their timeout and remove that of pull requests closed longer than the grace period")
+	fmt.Fprintln(os.Stderr, "  import-codecov   Backfill the data branch with the coverage history of repositories migrating off Codecov")
+	fmt.Fprintln(os.Stderr, "  reconcile        Report repositories whose coverage on Codecov or SonarQube differs from the dashboard's")
+	fmt.Fprintln(os.Stderr, "  site-tables      Write the repository table sorted by every column as static pages of the site")
+	fmt.Fprintln(os.Stderr, "  headline         Write the organization's combined coverage as a JSON endpoint and badge of the site")
+	fmt.Fprintln(os.Stderr, "  go-versions      Write the page of each repository's Go version and whether the collector supports it")
+	fmt.Fprintln(os.Stderr, "  store-backup     Back up the data branch history and run manifest to a directory or s3://bucket/prefix")
+	fmt.Fprintln(os.Stderr, "  store-restore    Restore the data branch history and run manifest from a backup")
+	fmt.Fprintln(os.Stderr, "  edit-config      Open a pull request changing the thresholds or excludes of a repository's configuration")
+	fmt.Fprintln(os.Stderr, "  admin-serve      Serve the authenticated admin API and page opening edit-config pull requests")
+	fmt.Fprintln(os.Stderr, "  events-serve     Stream the runs, snapshots, threshold violations and repository changes of the dashboard as server-sent events")
+}
+
+func runDoctor(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	var (
+		org            = fs.String("org", "konflux-ci", "GitHub organization to scan")
+		dashboardRepo  = fs.String("repo", "konflux-ci/coverage-dashboard", "Dashboard repository discovery opens pull requests on")
+		reposDir       = fs.String("repos-dir", "repos", "Directory containing repository configurations")
+		reposFile      = fs.String("repos-file", "repos.yaml", "Single file listing repository configurations, merged with --repos-dir")
+		codeownersFile = fs.String("codeowners", "CODEOWNERS", "Path to CODEOWNERS file")
+		publishDir     = fs.String("publish-dir", "", "Git checkout of the published site (e.g. gh-pages) to check")
+		policyFile     = fs.String("policy", "policy.yaml", "Organization policy the repository configurations must comply with")
+		groupsFile     = fs.String("groups", "groups.yaml", "Named groups of repositories to check against the configurations")
+		configSource   = fs.String("config-source", "", "Check the repository configurations, repos.yaml, CODEOWNERS, groups.yaml and policy.yaml (unless --policy is given) on GitHub instead of the local files (e.g. github://konflux-ci/coverage-dashboard@main/repos)")
+		githubBaseURL  = addGitHubBaseURLFlag(fs)
+	)
+	fs.Parse(args)
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+
+	if *configSource != "" {
+		source, err := config.ParseSource(*configSource)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		dir, err := os.MkdirTemp("", "coverage-configs-")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		defer os.RemoveAll(dir)
+
+		tokens, err := ghauth.TokensFromEnv().WithBaseURL(*githubBaseURL)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		local, err := source.Fetch(ctx, tokens.ReadClient(ctx), dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		*reposDir, *reposFile, *codeownersFile, *groupsFile = local.ReposDir, local.ReposFile, local.CodeownersFile, local.GroupsFile
+		if !explicit["policy"] {
+			*policyFile = local.PolicyFile
+		}
+	}
+
+	d, err := doctor.NewDoctor(doctor.Config{
+		Organization:   *org,
+		DashboardRepo:  *dashboardRepo,
+		ReposDir:       *reposDir,
+		ReposFile:      *reposFile,
+		CodeownersFile: *codeownersFile,
+		PublishDir:     *publishDir,
+		PolicyFile:     *policyFile,
+		GroupsFile:     *groupsFile,
+		GitHubBaseURL:  *githubBaseURL,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error initializing: %v\n", err)
+		return 1
+	}
+
+	results := d.Run(ctx)
+	doctor.Print(results)
+	if doctor.Failed(results) {
+		return 1
+	}
+	return 0
+}
+
+func runConvertRepos(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("convert-repos", flag.ExitOnError)
+	var (
+		to             = fs.String("to", "", "Target layout: \"single\" (one repos.yaml) or \"split\" (one file per repository under --repos-dir)")
+		reposDir       = fs.String("repos-dir", "repos", "Directory containing repository configurations")
+		reposFile      = fs.String("repos-file", "repos.yaml", "Single file listing repository configurations")
+		codeownersFile = fs.String("codeowners", "CODEOWNERS", "Path to CODEOWNERS file")
+	)
+	fs.Parse(args)
+
+	writer := config.NewWriter(*reposDir, *codeownersFile)
+
+	var (
+		converted int
+		err       error
+	)
+	switch *to {
+	case "single":
+		converted, err = writer.MergeIntoReposFile(*reposFile)
+	case "split":
+		converted, err = writer.SplitReposFile(*reposFile)
+	default:
+		fmt.Fprintf(os.Stderr, "Error: --to must be \"single\" or \"split\", got %q\n", *to)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	if *to == "single" {
+		fmt.Printf("✅ Moved %d configurations from %s into %s\n", converted, *reposDir, *reposFile)
+	} else {
+		fmt.Printf("✅ Moved %d configurations from %s into %s\n", converted, *reposFile, *reposDir)
+	}
+	fmt.Printf("   Review the changes to %s before committing\n", *codeownersFile)
+	return 0
+}
+
+func runUncovered(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("uncovered", flag.ExitOnError)
+	var (
+		repo    = fs.String("repo", "", "Repository to export, in org/name form (required)")
+		top     = fs.Int("top", 10, "Number of regions to list, largest first")
+		from    = fs.String("from", "https://konflux-ci.dev/coverage-dashboard/coverage", "Published reports URL or local reports directory to read the export from")
+		profile = fs.String("profile", "", "Coverage profile to compute regions from instead of the published export")
+		local   = fs.String("local", ".", "Checkout of --repo the --profile was measured on, used to resolve the commit for permalinks")
+		// Permalinks go to the web host of the GitHub API
+		githubBaseURL = addGitHubBaseURLFlag(fs)
+	)
+	fs.Parse(args)
+
+	if *repo == "" {
+		fmt.Fprintln(os.Stderr, "Error: --repo is required")
+		return 2
+	}
+
+	var report *collect.UncoveredReport
+	if *profile != "" {
+		tokens, err := ghauth.TokensFromEnv().WithBaseURL(*githubBaseURL)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		ref, err := collect.ResolveSourceRef(ctx, *local, *repo)
+		ref.WebURL = tokens.WebURL()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  Warning: regions will not link to GitHub: %v\n", err)
+		}
+		regions, err := collect.UncoveredRegions(*profile, ref, *top)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		report = &collect.UncoveredReport{Repo: *repo, Commit: ref.Commit, Regions: regions}
+	} else {
+		var err error
+		report, err = collect.LoadUncoveredReport(ctx, *from, *repo)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
+
+	fmt.Print(report.Top(*top).Markdown())
+	return 0
+}
+
+func runProfile(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("profile", flag.ExitOnError)
+	var (
+		repo     = fs.String("repo", "", "Repository to download the profile of, in org/name form (required)")
+		snapshot = fs.String("snapshot", "latest", "Commit of the archived report, or a prefix of it")
+		kind     = fs.String("kind", collect.ProfileFiltered, "Profile to download: "+collect.ProfileFiltered+" after the excludes, or "+collect.ProfileRaw+" before exclude_files with the untested packages")
+		from     = fs.String("from", "https://konflux-ci.dev/coverage-dashboard/coverage", "Published reports URL or local reports directory to download the profile from")
+		output   = fs.String("output", "", "File to write the profile to instead of stdout")
+	)
+	fs.Parse(args)
+
+	if *repo == "" {
+		fmt.Fprintln(os.Stderr, "Error: --repo is required")
+		return 2
+	}
+
+	archive, err := collect.FetchReportArchive(ctx, *from, *repo)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	report, err := archive.Find(*snapshot)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	profile, err := collect.LoadProfile(ctx, *from, *repo, report, *kind)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	if *output == "" {
+		os.Stdout.Write(profile)
+		return 0
+	}
+	if err := os.WriteFile(*output, profile, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "📥 Wrote the %s profile of %s at %s to %s\n", *kind, *repo, report.Commit, *output)
+	return 0
+}
+
+func runCheckPolicy(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("check-policy", flag.ExitOnError)
+	var (
+		policyFile = fs.String("policy", "policy.yaml", "Organization policy whose rego gates to evaluate")
+		coverage   = fs.String("coverage", "https://konflux-ci.dev/coverage-dashboard/coverage.json", "Published URL or local path of the coverage.json to evaluate")
+	)
+	fs.Parse(args)
+
+	orgPolicy, err := policy.Load(*policyFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if orgPolicy.Rego == nil || len(orgPolicy.Rego.Files) == 0 {
+		fmt.Fprintf(os.Stderr, "Error: %s has no rego files\n", *policyFile)
+		return 2
+	}
+
+	dashboard, err := collect.LoadDashboard(ctx, *coverage)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	violations, err := orgPolicy.Evaluate(ctx, dashboard)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if len(violations) == 0 {
+		fmt.Printf("✅ %d repositories pass the Rego policy\n", len(dashboard.Data))
+		return 0
+	}
+
+	fmt.Printf("❌ %d Rego policy violations:\n", len(violations))
+	for _, violation := range violations {
+		fmt.Printf("  → %s\n", violation)
+	}
+	return 1
+}
+
+func runVelocity(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("velocity", flag.ExitOnError)
+	var (
+		dataDir = fs.String("data-dir", "data", "Git checkout of the data branch holding the snapshots, with enough history for the longest window")
+		dataRef = fs.String("data-ref", "HEAD", "Ref of the data branch to read the snapshots from, e.g. origin/data")
+		windows = fs.String("windows", "30,90,180", "Comma-separated windows, in days, to compute velocity over")
+		decline = fs.Float64("decline", 0.5, "Coverage loss, in percentage points per month, from which a repository is flagged as declining")
+		output  = fs.String("output", "", "Path to write the report to as JSON, e.g. velocity.json next to coverage.json")
+	)
+	fs.Parse(args)
+
+	var days []int
+	for _, field := range strings.Split(*windows, ",") {
+		window, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || window <= 0 {
+			fmt.Fprintf(os.Stderr, "Error: --windows must list positive numbers of days, got %q\n", *windows)
+			return 2
+		}
+		days = append(days, window)
+	}
+
+	history := collect.NewSnapshotHistory(*dataDir, *dataRef)
+	snapshot, err := history.Snapshot(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	trends := make(map[string][]collect.TrendPoint)
+	for _, result := range snapshot.Data {
+		trend, err := history.Trend(ctx, result.Repo)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  Warning: %v\n", err)
+			continue
+		}
+		trends[result.Repo] = trend
+	}
+
+	report := collect.NewVelocityReport(snapshot.Data, trends, days, *decline, snapshot.GeneratedAt)
+	if *output != "" {
+		if err := collect.WriteVelocityReport(*output, report); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
+	fmt.Print(report.Markdown())
+	return 0
+}
+
+func runPRUpload(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("pr-upload", flag.ExitOnError)
+	var (
+		repo    = fs.String("repo", "", "Repository of the pull request, in org/name form (required)")
+		number  = fs.Int("pr", 0, "Number of the pull request (required)")
+		branch  = fs.String("branch", "", "Head branch of the pull request")
+		commit  = fs.String("commit", "", "Head commit the profile was measured on (required)")
+		profile = fs.String("profile", "coverage.out", "Coverage profile measured on the pull request")
+		siteDir = fs.String("site-dir", "gh-pages", "Checkout of the published site to stage the coverage in")
+		shard   = fs.String("shard", "", "Shard of a sharded test run the profile covers, as index/total (e.g. 2/5); the coverage is published once every shard of the commit is uploaded")
+		// The site is public, so only the coverage of repositories configured as public is staged
+		reposDir  = fs.String("repos-dir", "repos", "Directory containing repository configurations, telling whether the repository is public")
+		reposFile = fs.String("repos-file", "repos.yaml", "Single file listing repository configurations, merged with --repos-dir")
+		// Raw profiles must not be published, so shards are staged outside the site, in a directory shared by every
+		// shard job and pr-expire; it is not committed
+		shardsDir = fs.String("shards-dir", "", "Directory outside the site staging the shards of --shard until every shard of the commit is uploaded (required with --shard)")
+	)
+	fs.Parse(args)
+
+	if *repo == "" || *number == 0 || *commit == "" {
+		fmt.Fprintln(os.Stderr, "Error: --repo, --pr and --commit are required")
+		return 2
+	}
+	if *shard != "" && *shardsDir == "" {
+		fmt.Fprintln(os.Stderr, "Error: --shards-dir is required with --shard")
+		return 2
+	}
+	if err := checkPublic(*reposDir, *reposFile, *repo); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	if *shard != "" {
+		index, total, err := collect.ParseShard(*shard)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --shard: %v\n", err)
+			return 2
+		}
+		progress, upload, err := collect.NewPullStore(*siteDir, *shardsDir).PutShard(collect.ShardUpload{
+			Repo:    *repo,
+			Number:  *number,
+			Branch:  *branch,
+			Commit:  *commit,
+			Shard:   index,
+			Total:   total,
+			Profile: *profile,
+		}, time.Now())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		if upload == nil {
+			fmt.Printf("📥 Staged shard %d/%d of %s#%d at %s, waiting for shards %v\n", index, total, *repo, *number, *commit, progress.Missing())
+			return 0
+		}
+		fmt.Printf("📥 Staged coverage of %s#%d at %s from %d shards: %s\n", *repo, *number, *commit, total, formatPullCoverage(*upload))
+		return 0
+	}
+
+	upload, err := collect.NewPullCoverage(*repo, *number, *branch, *commit, *profile, time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if err := collect.NewPullStore(*siteDir, "").Put(upload); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("📥 Staged coverage of %s#%d at %s: %s\n", *repo, *number, *commit, formatPullCoverage(upload))
+	return 0
+}
+
+// checkPublic refuses repositories whose coverage may not be published: those configured as private or internal,
+// and those not configured at all, whose visibility is unknown
+func checkPublic(reposDir, reposFile, repo string) error {
+	repositories, err := config.LoadRepositories(reposDir, reposFile)
+	if err != nil {
+		return err
+	}
+	for _, entry := range repositories.Entries {
+		if entry.Config.Name != repo {
+			continue
+		}
+		if !entry.Config.IsPublic() {
+			return fmt.Errorf("%s is a %s repository, its coverage is not published", repo, entry.Config.Visibility)
+		}
+		return nil
+	}
+	return fmt.Errorf("%s is not configured, so its coverage is not published", repo)
+}
+
+// formatPullCoverage formats the total coverage of a pull request's upload
+func formatPullCoverage(upload collect.PullCoverage) string {
+	if upload.Coverage == nil {
+		return "no statements"
+	}
+	return fmt.Sprintf("%.1f%%", *upload.Coverage)
+}
+
+func runPRCoverage(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("pr-coverage", flag.ExitOnError)
+	var (
+		repo   = fs.String("repo", "", "Repository of the pull request, in org/name form (required)")
+		number = fs.Int("pr", 0, "Number of the pull request (required)")
+		from   = fs.String("from", "https://konflux-ci.dev/coverage-dashboard/pulls", "Published pulls URL or local pulls directory to read the coverage from")
+	)
+	fs.Parse(args)
+
+	if *repo == "" || *number == 0 {
+		fmt.Fprintln(os.Stderr, "Error: --repo and --pr are required")
+		return 2
+	}
+
+	upload, err := collect.LoadPullCoverage(ctx, *from, *repo, *number)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	data, err := json.MarshalIndent(upload, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Println(string(data))
+	return 0
+}
+
+func runPRExpire(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("pr-expire", flag.ExitOnError)
+	var (
+		siteDir       = fs.String("site-dir", "gh-pages", "Checkout of the published site holding the staged coverage")
+		grace         = fs.Duration("grace", collect.DefaultPullGrace, "How long the coverage of a closed pull request stays published")
+		timeout       = fs.Duration("shard-timeout", collect.DefaultShardTimeout, "How long the shards of a commit uploaded with pr-upload --shard are awaited before its coverage is published from the shards received, marked partial")
+		shardsDir     = fs.String("shards-dir", "", "Directory the shards of pr-upload --shard are staged in; without it, no shards are finalized")
+		githubBaseURL = addGitHubBaseURLFlag(fs)
+	)
+	fs.Parse(args)
+
+	tokens, err := ghauth.TokensFromEnv().WithBaseURL(*githubBaseURL)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	store := collect.NewPullStore(*siteDir, *shardsDir)
+	partial, err := store.FinalizeShards(*timeout, time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	for _, upload := range partial {
+		fmt.Printf("⏱️  Published partial coverage of %s#%d at %s without shards %v: %s\n", upload.Repo, upload.Number, upload.Commit, upload.Shards.Missing(), formatPullCoverage(upload))
+	}
+
+	client := tokens.ReadClient(ctx)
+	state := func(ctx context.Context, repo string, number int) (*time.Time, error) {
+		org, name, _ := strings.Cut(repo, "/")
+		pull, _, err := client.PullRequests.Get(ctx, org, name, number)
+		if err != nil {
+			return nil, err
+		}
+		if pull.GetState() != "closed" {
+			return nil, nil
+		}
+		closedAt := pull.GetClosedAt().Time
+		return &closedAt, nil
+	}
+
+	expired, err := store.Expire(ctx, state, *grace, time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	for _, upload := range expired {
+		fmt.Printf("🗑️  Removed coverage of %s#%d, closed %s\n", upload.Repo, upload.Number, upload.ClosedAt.Format(time.DateOnly))
+	}
+	fmt.Printf("✅ Expired coverage of %d closed pull requests\n", len(expired))
+	return 0
+}
+
+func runImportCodecov(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("import-codecov", flag.ExitOnError)
+	var (
+		repos      = fs.String("repos", "", "Comma-separated repositories to import, in org/name form (required)")
+		branch     = fs.String("branch", "", "Branch whose coverage is imported (default: the branch Codecov considers the default)")
+		since      = fs.String("since", "", "Date from which coverage is imported, e.g. 2025-01-01 (default: all of it)")
+		dataDir    = fs.String("data-dir", "data", "Git checkout of the data branch to commit the imported history to")
+		codecovURL = fs.String("codecov-url", codecov.DefaultURL, "Codecov API, e.g. of a self-hosted Codecov")
+		dryRun     = fs.Bool("dry-run", false, "Print the imported history without committing it")
+	)
+	fs.Parse(args)
+
+	if *repos == "" {
+		fmt.Fprintln(os.Stderr, "Error: --repos is required")
+		return 2
+	}
+	var from time.Time
+	if *since != "" {
+		parsed, err := time.Parse(time.DateOnly, *since)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --since must be a date such as 2025-01-01, got %q\n", *since)
+			return 2
+		}
+		from = parsed
+	}
+
+	client := codecov.NewClient(nil, *codecovURL, os.Getenv(codecov.TokenEnv))
+	now := time.Now().UTC()
+	var histories []collect.ImportedHistory
+	for _, repo := range strings.Split(*repos, ",") {
+		repo = strings.TrimSpace(repo)
+		if !config.ValidRepoName(repo) {
+			fmt.Fprintf(os.Stderr, "Error: invalid repository %q, expected org/name\n", repo)
+			return 2
+		}
+
+		repoBranch := *branch
+		if repoBranch == "" {
+			var err error
+			if repoBranch, err = client.DefaultBranch(ctx, repo); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				return 1
+			}
+		}
+		commits, err := client.Commits(ctx, repo, repoBranch, from)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		if len(commits) == 0 {
+			fmt.Printf("⚠️  Warning: Codecov has no coverage of %s on %s\n", repo, repoBranch)
+			continue
+		}
+
+		points := make([]collect.ImportedPoint, 0, len(commits))
+		for _, commit := range commits {
+			points = append(points, collect.ImportedPoint{Date: commit.Date, Commit: commit.Commit, Coverage: commit.Coverage})
+		}
+		history := collect.NewImportedHistory(repo, "codecov", points, now)
+		first, last := history.Points[0], history.Points[len(history.Points)-1]
+		fmt.Printf("📥 %s: %d daily measurements on %s, %.1f%% on %s → %.1f%% on %s\n", repo, len(history.Points), repoBranch,
+			first.Coverage, first.Date.Format(time.DateOnly), last.Coverage, last.Date.Format(time.DateOnly))
+		histories = append(histories, history)
+	}
+
+	if *dryRun {
+		fmt.Printf("🔍 Dry run: not committing the history of %d repositories\n", len(histories))
+		return 0
+	}
+	if err := collect.NewSnapshotPublisher(*dataDir).PublishImported(ctx, histories, "Codecov"); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Printf("✅ Imported the coverage history of %d repositories into %s\n", len(histories), *dataDir)
+	return 0
+}
+
+func runReconcile(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("reconcile", flag.ExitOnError)
+	var (
+		coverage     = fs.String("coverage", "https://konflux-ci.dev/coverage-dashboard/coverage.json", "Published URL or local path of the coverage.json to compare")
+		repos        = fs.String("repos", "", "Comma-separated repositories to compare (default: every repository of --coverage)")
+		threshold    = fs.Float64("threshold", collect.DefaultDiscrepancy, "Difference, in percentage points, from which a provider's coverage is reported")
+		useCodecov   = fs.Bool("codecov", false, "Compare with Codecov, using "+codecov.TokenEnv+" for private repositories")
+		codecovURL   = fs.String("codecov-url", codecov.DefaultURL, "Codecov API, e.g. of a self-hosted Codecov")
+		useSonarQube = fs.Bool("sonarqube", false, "Compare with SonarQube, using "+sonarqube.TokenEnv+" for private projects")
+		sonarURL     = fs.String("sonarqube-url", sonarqube.DefaultURL, "SonarQube server, e.g. of a self-hosted SonarQube")
+		sonarKeys    = fs.String("sonarqube-key-format", sonarqube.DefaultKeyFormat, "Project key of a repository on SonarQube, replacing {org} and {name}")
+		output       = fs.String("output", "", "Path to write the report to as JSON, e.g. reconciliation.json next to coverage.json")
+	)
+	fs.Parse(args)
+
+	providers := make(map[string]collect.CoverageProvider)
+	if *useCodecov {
+		providers["Codecov"] = codecov.NewClient(nil, *codecovURL, os.Getenv(codecov.TokenEnv))
+	}
+	if *useSonarQube {
+		providers["SonarQube"] = sonarqube.NewClient(nil, *sonarURL, os.Getenv(sonarqube.TokenEnv), *sonarKeys)
+	}
+	if len(providers) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: at least one of --codecov and --sonarqube is required")
+		return 2
+	}
+
+	dashboard, err := collect.LoadDashboard(ctx, *coverage)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	results := dashboard.Data
+	if *repos != "" {
+		selected := make(map[string]bool)
+		for _, repo := range strings.Split(*repos, ",") {
+			selected[strings.TrimSpace(repo)] = true
+		}
+		results = nil
+		for _, result := range dashboard.Data {
+			if selected[result.Repo] {
+				results = append(results, result)
+			}
+		}
+	}
+
+	report := collect.Reconcile(ctx, results, providers, *threshold, time.Now().UTC())
+	if *output != "" {
+		if err := collect.WriteReconciliationReport(*output, report); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
+	fmt.Print(report.Markdown())
+	return 0
+}
+
+func runSiteTables(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("site-tables", flag.ExitOnError)
+	var (
+		coverage = fs.String("coverage", "coverage.json", "Published URL or local path of the coverage.json to tabulate")
+		manifest = fs.String("manifest", "run-manifest.json", "Run manifest whose trends give the change of coverage; without it the change is left empty")
+		siteDir  = fs.String("site-dir", ".", "Directory of the site, e.g. the gh-pages checkout; the pages are written to its "+site.TableDir+" directory")
+	)
+	fs.Parse(args)
+
+	dashboard, err := collect.LoadDashboard(ctx, *coverage)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	deltas := map[string]float64{}
+	if m, err := collect.LoadManifest(*manifest); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Warning: no coverage change in the tables: %v\n", err)
+	} else {
+		deltas = site.Deltas(m)
+	}
+
+	paths, err := site.Generate(*siteDir, *dashboard, deltas)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Printf("🗂️  Wrote %d sorted tables of %d repositories to %s\n", len(paths), len(dashboard.Data), filepath.Join(*siteDir, site.TableDir))
+	return 0
+}
+
+func runHeadline(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("headline", flag.ExitOnError)
+	var (
+		coverage   = fs.String("coverage", "coverage.json", "Published URL or local path of the coverage.json to combine")
+		policyFile = fs.String("policy", "policy.yaml", "Organization policy whose headline section decides which repositories are combined, and how")
+		siteDir    = fs.String("site-dir", ".", "Directory of the site, e.g. the gh-pages checkout, to write "+site.HeadlineFile+" and "+site.HeadlineBadgeFile+" to")
+	)
+	fs.Parse(args)
+
+	dashboard, err := collect.LoadDashboard(ctx, *coverage)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	orgPolicy, err := policy.Load(*policyFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	headline := site.NewHeadline(*dashboard, orgPolicy.Headline)
+	if _, err := site.WriteHeadline(*siteDir, headline, locale.Lookup(dashboard.Locale)); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Printf("📣 %s: %s over %d repositories, weighted by %s (%d left out by the policy, %d unmeasured)\n",
+		headline.Label, formatHeadline(headline.Coverage), headline.Repos, headline.Weight, len(headline.Excluded), headline.Unmeasured)
+	return 0
+}
+
+// formatHeadline formats the headline coverage for the command's output
+func formatHeadline(coverage *float64) string {
+	if coverage == nil {
+		return "N/A"
+	}
+	return fmt.Sprintf("%.1f%%", *coverage)
+}
+
+func runGoVersions(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("go-versions", flag.ExitOnError)
+	var (
+		coverage = fs.String("coverage", "coverage.json", "Published URL or local path of the coverage.json whose Go versions to list")
+		siteDir  = fs.String("site-dir", ".", "Directory of the site, e.g. the gh-pages checkout, to write "+site.GoVersionsFile+" to")
+	)
+	fs.Parse(args)
+
+	dashboard, err := collect.LoadDashboard(ctx, *coverage)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	versions := site.NewGoVersions(*dashboard)
+	path, err := site.WriteGoVersions(*siteDir, *dashboard, versions)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Printf("🐹 Wrote the Go versions of %d repositories to %s", len(versions.Rows), path)
+	if versions.Collector != "" {
+		fmt.Printf(" (%d need a newer Go than the collector's %s)", versions.Unsupported, versions.Collector)
+	}
+	fmt.Println()
+	return 0
+}
+
+func runStoreBackup(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("store-backup", flag.ExitOnError)
+	var (
+		dataDir  = fs.String("data-dir", "data", "Checkout of the data branch with its full history")
+		manifest = fs.String("manifest", "run-manifest.json", "Run manifest carrying trends, timings, ratchets and alerts across runs")
+		to       = fs.String("to", "", "Directory or s3://bucket/prefix to write the backup to (required); S3 is configured by the AWS_* environment variables")
+	)
+	fs.Parse(args)
+
+	if *to == "" {
+		fmt.Fprintln(os.Stderr, "Error: --to is required")
+		return 2
+	}
+	target, err := store.Open(*to)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	name, err := store.Backup(ctx, *dataDir, *manifest, target, time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Printf("💾 Backed up %s and %s to %s/%s\n", *dataDir, *manifest, strings.TrimSuffix(target.String(), "/"), name)
+	return 0
+}
+
+func runStoreRestore(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("store-restore", flag.ExitOnError)
+	var (
+		dataDir  = fs.String("data-dir", "data", "Directory to clone the data branch to; it must not exist or be empty")
+		manifest = fs.String("manifest", "run-manifest.json", "Path to write the run manifest to")
+		from     = fs.String("from", "", "Directory or s3://bucket/prefix holding the backups (required)")
+		backup   = fs.String("backup", "", "Name of the backup to restore (default: the latest)")
+	)
+	fs.Parse(args)
+
+	if *from == "" {
+		fmt.Fprintln(os.Stderr, "Error: --from is required")
+		return 2
+	}
+	target, err := store.Open(*from)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	name := *backup
+	if name == "" {
+		if name, err = store.Latest(ctx, target); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
+	index, err := store.Restore(ctx, target, name, *dataDir, *manifest)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Printf("♻️  Restored %s of %s: branch %s at %s to %s, manifest of the run finished %s to %s\n",
+		name, index.CreatedAt.Format(time.RFC3339), index.Branch, index.Head[:12], *dataDir, index.ManifestFinishedAt.Format(time.RFC3339), *manifest)
+	return 0
+}
+
+func runDeployStart(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("deploy-start", flag.ExitOnError)
+	var (
+		repo          = fs.String("repo", "konflux-ci/coverage-dashboard", "Repository the site is published from")
+		environment   = fs.String("environment", deployments.DefaultEnvironment, "Environment of the published site")
+		ref           = fs.String("ref", deployments.DefaultRef, "Branch holding the published site")
+		description   = fs.String("description", "Publish coverage data", "Description of the deployment")
+		runURL        = fs.String("run-url", "", "URL of the workflow run publishing the site, linked from the deployment")
+		githubBaseURL = addGitHubBaseURLFlag(fs)
+		writesPerMin  = addWritesPerMinuteFlag(fs)
+	)
+	fs.Parse(args)
+
+	tracker, code := newDeploymentTracker(ctx, *repo, *environment, *githubBaseURL, *writesPerMin)
+	if tracker == nil {
+		return code
+	}
+	id, err := tracker.Start(ctx, *ref, *description, *runURL)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	// The ID alone on stdout, so workflows can capture it
+	fmt.Println(id)
+	return 0
+}
+
+func runDeployFinish(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("deploy-finish", flag.ExitOnError)
+	var (
+		repo          = fs.String("repo", "konflux-ci/coverage-dashboard", "Repository the site is published from")
+		environment   = fs.String("environment", deployments.DefaultEnvironment, "Environment of the published site")
+		id            = fs.Int64("id", 0, "Deployment ID printed by deploy-start (required)")
+		state         = fs.String("state", deployments.StateSuccess, "Final state: success, failure or error")
+		siteURL       = fs.String("url", "https://konflux-ci.dev/coverage-dashboard/", "URL of the published site, linked from successful deployments")
+		description   = fs.String("description", "", "Description of the final state")
+		runURL        = fs.String("run-url", "", "URL of the workflow run publishing the site, linked from the deployment")
+		githubBaseURL = addGitHubBaseURLFlag(fs)
+		writesPerMin  = addWritesPerMinuteFlag(fs)
+	)
+	fs.Parse(args)
+
+	if *id == 0 {
+		fmt.Fprintln(os.Stderr, "Error: --id is required")
+		return 2
+	}
+	tracker, code := newDeploymentTracker(ctx, *repo, *environment, *githubBaseURL, *writesPerMin)
+	if tracker == nil {
+		return code
+	}
+	if err := tracker.Finish(ctx, *id, *state, *siteURL, *runURL, *description); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "🚀 Deployment %d: %s\n", *id, *state)
+	return 0
+}
+
+// newDeploymentTracker creates a deployment tracker authenticated with the write token, sending at most writesPerMin
+// write requests a minute, or returns the exit code
+func newDeploymentTracker(ctx context.Context, repo, environment, baseURL string, writesPerMin int) (*deployments.Tracker, int) {
+	tokens, err := ghauth.TokensFromEnv().WithBaseURL(baseURL)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return nil, 1
+	}
+	tokens.Writes = ghauth.NewWriteLimiter(writesPerMin)
+	if tokens.WriteSource == "" {
+		fmt.Fprintf(os.Stderr, "Error: %s, %s or a GitHub App (%s) is required to record deployments\n", ghauth.WriteTokenEnv, ghauth.SharedTokenEnv, ghauth.AppIDEnv)
+		return nil, 1
+	}
+	tracker, err := deployments.NewTracker(tokens.WriteClient(ctx), repo, environment)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return nil, 2
+	}
+	return tracker, 0
+}
+
+// configFlags are the flags locating the configurations of the dashboard repository checked out in the working
+// directory, and where pull requests editing them are opened
+type configFlags struct {
+	reposDir, reposFile, codeownersFile, dashboardRepo, baseBranch, githubBaseURL *string
+}
+
+// addConfigFlags defines the flags of configFlags on fs
+func addConfigFlags(fs *flag.FlagSet) configFlags {
+	return configFlags{
+		reposDir:       fs.String("repos-dir", "repos", "Directory containing repository configurations"),
+		reposFile:      fs.String("repos-file", "repos.yaml", "Single file listing repository configurations, merged with --repos-dir"),
+		codeownersFile: fs.String("codeowners", "CODEOWNERS", "Path to CODEOWNERS file"),
+		dashboardRepo:  fs.String("dashboard-repo", "konflux-ci/coverage-dashboard", "Dashboard repository to open pull requests on, checked out in the working directory"),
+		baseBranch:     fs.String("base", "", "Branch to open pull requests against (default: the default branch of --dashboard-repo)"),
+		githubBaseURL:  addGitHubBaseURLFlag(fs),
+	}
+}
+
+// addGitHubBaseURLFlag defines the flag of the GitHub API the command talks to on fs
+func addGitHubBaseURLFlag(fs *flag.FlagSet) *string {
+	return fs.String("github-base-url", "", "GitHub API, e.g. https://github.example.com/api/v3 on GitHub Enterprise Server (default: $"+ghauth.APIURLEnv+", or api.github.com)")
+}
+
+// addWritesPerMinuteFlag defines the flag pacing the write requests of the command on fs
+func addWritesPerMinuteFlag(fs *flag.FlagSet) *int {
+	return fs.Int("writes-per-minute", ghauth.DefaultWritesPerMinute, "Write requests sent to GitHub per minute at most, with jitter, so GitHub's abuse detection does not trip (0 does not pace them)")
+}
+
+// editor returns the function opening the pull request of an edit on the dashboard repository
+func (f configFlags) editor(ctx context.Context) (admin.EditorFunc, error) {
+	org, name, ok := strings.Cut(*f.dashboardRepo, "/")
+	if !ok {
+		return nil, fmt.Errorf("--dashboard-repo must be in owner/name form, got %q", *f.dashboardRepo)
+	}
+	tokens, err := ghauth.TokensFromEnv().WithBaseURL(*f.githubBaseURL)
+	if err != nil {
+		return nil, err
+	}
+	if tokens.WriteSource == "" {
+		return nil, fmt.Errorf("%s, %s or a GitHub App (%s) is required to open pull requests", ghauth.WriteTokenEnv, ghauth.SharedTokenEnv, ghauth.AppIDEnv)
+	}
+	client := tokens.WriteClient(ctx)
+	baseBranch := *f.baseBranch
+	if baseBranch == "" {
+		if baseBranch, err = pr.DefaultBranch(ctx, client, org, name); err != nil {
+			return nil, fmt.Errorf("failed to get the default branch of %s, pass --base: %w", *f.dashboardRepo, err)
+		}
+	}
+	creator := pr.NewCreator(client, ".", org, name, baseBranch)
+	writer := config.NewWriter(*f.reposDir, *f.codeownersFile)
+	return func(ctx context.Context, repo string, edit config.Edit, requester string) (string, error) {
+		return creator.EditRepository(ctx, writer, *f.reposFile, repo, edit, requester)
+	}, nil
+}
+
+// parseThreshold parses a threshold flag, a number or "none" to remove the threshold
+func parseThreshold(name, s string) (value *float64, clear bool, err error) {
+	switch s {
+	case "":
+		return nil, false, nil
+	case "none":
+		return nil, true, nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, false, fmt.Errorf("--%s must be a number or none, got %q", name, s)
+	}
+	return &v, false, nil
+}
+
+// splitList splits a comma-separated flag, dropping empty entries
+func splitList(s string) []string {
+	var entries []string
+	for _, entry := range strings.Split(s, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+func runEditConfig(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("edit-config", flag.ExitOnError)
+	var (
+		repo               = fs.String("repo", "", "Repository whose configuration is edited, in org/name form (required)")
+		minCoverage        = fs.String("min-coverage", "", "New min_coverage, or none to remove it")
+		regressionDelta    = fs.String("regression-delta", "", "New regression_delta, or none to remove it")
+		addExcludeDirs     = fs.String("add-exclude-dirs", "", "Comma-separated directories to exclude")
+		removeExcludeDirs  = fs.String("remove-exclude-dirs", "", "Comma-separated directories to stop excluding")
+		addExcludeFiles    = fs.String("add-exclude-files", "", "Comma-separated file patterns to exclude")
+		removeExcludeFiles = fs.String("remove-exclude-files", "", "Comma-separated file patterns to stop excluding")
+		requester          = fs.String("requester", os.Getenv("GITHUB_ACTOR"), "Who requested the edit, credited in the pull request")
+		local              = fs.Bool("local", false, "Only edit the local files, without opening a pull request")
+		flags              = addConfigFlags(fs)
+	)
+	fs.Parse(args)
+
+	if *repo == "" {
+		fmt.Fprintln(os.Stderr, "Error: --repo is required")
+		return 2
+	}
+	edit := config.Edit{
+		AddExcludeDirs:     splitList(*addExcludeDirs),
+		RemoveExcludeDirs:  splitList(*removeExcludeDirs),
+		AddExcludeFiles:    splitList(*addExcludeFiles),
+		RemoveExcludeFiles: splitList(*removeExcludeFiles),
+	}
+	var err error
+	if edit.MinCoverage, edit.ClearMinCoverage, err = parseThreshold("min-coverage", *minCoverage); err == nil {
+		edit.RegressionDelta, edit.ClearRegressionDelta, err = parseThreshold("regression-delta", *regressionDelta)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	if *local {
+		result, err := config.NewWriter(*flags.reposDir, *flags.codeownersFile).EditRepository(*flags.reposFile, *repo, edit)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Printf("✅ Edited %s (%s), updated %s\n", result.Repo, strings.Join(result.Changes, "; "), strings.Join(result.Files, ", "))
+		return 0
+	}
+
+	editor, err := flags.editor(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	url, err := editor(ctx, *repo, edit, *requester)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Printf("✅ Opened %s\n", url)
+	return 0
+}
+
+func runAdminServe(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("admin-serve", flag.ExitOnError)
+	var (
+		listen = fs.String("listen", ":8080", "Address to serve the admin API and page on")
+		flags  = addConfigFlags(fs)
+	)
+	fs.Parse(args)
+
+	tokens, err := admin.ParseTokens(os.Getenv(admin.TokensEnv))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	editor, err := flags.editor(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	server := &http.Server{Addr: *listen, Handler: admin.NewServer(tokens, editor), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+	fmt.Printf("🔐 Serving the admin API for %d administrators on %s, opening pull requests on %s\n", len(tokens), *listen, *flags.dashboardRepo)
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func runEventsServe(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("events-serve", flag.ExitOnError)
+	var (
+		listen      = fs.String("listen", ":8080", "Address to serve the event stream on, at /api/events")
+		manifest    = fs.String("manifest", "run-manifest.json", "Run manifest collect-coverage writes, telling runs starting and finishing and their threshold violations")
+		snapshotDir = fs.String("snapshot-dir", "", "Git checkout of the data branch, telling new snapshots")
+		reposDir    = fs.String("repos-dir", "repos", "Directory containing repository configurations, telling added and removed repositories")
+		reposFile   = fs.String("repos-file", "repos.yaml", "Single file listing repository configurations, merged with --repos-dir")
+		interval    = fs.Duration("interval", 10*time.Second, "Interval between checks of the files for changes")
+		backupTo    = fs.String("backup-to", "", "Back up --snapshot-dir and --manifest to this directory or s3://bucket/prefix every --backup-interval, like store-backup")
+		backupEvery = fs.Duration("backup-interval", 24*time.Hour, "Interval between the backups of --backup-to")
+	)
+	fs.Parse(args)
+
+	if *interval <= 0 {
+		fmt.Fprintln(os.Stderr, "Error: --interval must be positive")
+		return 2
+	}
+	var backupTarget store.Target
+	if *backupTo != "" {
+		if *snapshotDir == "" || *backupEvery <= 0 {
+			fmt.Fprintln(os.Stderr, "Error: --backup-to needs --snapshot-dir and a positive --backup-interval")
+			return 2
+		}
+		var err error
+		if backupTarget, err = store.Open(*backupTo); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+	}
+
+	broker := events.NewBroker()
+	watcher := events.NewWatcher(events.Sources{
+		ManifestFile: *manifest,
+		SnapshotDir:  *snapshotDir,
+		ReposDir:     *reposDir,
+		ReposFile:    *reposFile,
+	})
+	// The first check records the current state, so that only later changes become events
+	if _, err := watcher.Check(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	go watcher.Run(ctx, broker, *interval)
+	if backupTarget != nil {
+		go store.Periodic(ctx, *snapshotDir, *manifest, backupTarget, *backupEvery)
+		fmt.Printf("💾 Backing up %s and %s to %s every %s\n", *snapshotDir, *manifest, backupTarget, *backupEvery)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /api/events", broker)
+	// Streams are open until the client leaves, so they end with the process' context instead of on shutdown
+	server := &http.Server{
+		Addr:              *listen,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+	fmt.Printf("📡 Streaming dashboard events on %s/api/events, checking for changes every %s\n", *listen, *interval)
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
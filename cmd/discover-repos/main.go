@@ -2,39 +2,431 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io/fs"
+	"net/http"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+	"github.com/konflux-ci/coverage-dashboard/internal/console"
 	"github.com/konflux-ci/coverage-dashboard/internal/discover"
+	"github.com/konflux-ci/coverage-dashboard/internal/display"
+	"github.com/konflux-ci/coverage-dashboard/internal/ghauth"
+	"github.com/konflux-ci/coverage-dashboard/internal/httpcache"
+	"github.com/konflux-ci/coverage-dashboard/internal/interrupt"
+	"github.com/konflux-ci/coverage-dashboard/internal/locale"
+	"github.com/konflux-ci/coverage-dashboard/internal/ownership"
+	"github.com/konflux-ci/coverage-dashboard/internal/policy"
+	"github.com/konflux-ci/coverage-dashboard/internal/pr"
+	"github.com/konflux-ci/coverage-dashboard/internal/schedule"
+)
+
+// Exit codes of discover-repos, documented in the README for automation deciding from them alone
+const (
+	exitOK = 0
+	// exitFailure is any failure of no other class
+	exitFailure = 1
+	// exitUsage is an invalid flag, filters file or policy
+	exitUsage = 2
+	// exitCredentials is a missing token, or one lacking scopes or permissions
+	exitCredentials = 3
+	// exitFetch is a failure listing the organization's repositories
+	exitFetch = 4
+	// exitPullRequests is a --ci run some pull requests could not be opened in
+	exitPullRequests = 5
+	// exitWarnings is a --strict run that printed warnings
+	exitWarnings = 6
+	// exitInterrupted is a run stopped by SIGINT or SIGTERM
+	exitInterrupted = 130
+)
+
+// Formats of the result printed to stdout
+const (
+	outputText = "text"
+	// outputJSON prints the report of the run as JSON, and the progress output to stderr
+	outputJSON = "json"
 )
 
 func main() {
+	os.Exit(run())
+}
+
+// run runs discovery with the command line's flags and returns the exit code
+func run() int {
 	var (
-		apply          = flag.Bool("apply", false, "Create configuration files, update CODEOWNERS, and create PRs")
-		org            = flag.String("org", "konflux-ci", "GitHub organization to scan")
+		apply          = flag.Bool("apply", false, "Create configuration files, update CODEOWNERS, and create PRs, including PRs removing archived repositories")
+		org            = flag.String("org", "konflux-ci", "GitHub organization, or GitLab group with --provider gitlab, to scan")
 		reposDir       = flag.String("repos-dir", "repos", "Directory containing repository configurations")
+		reposFile      = flag.String("repos-file", "repos.yaml", "Single file listing repository configurations, merged with --repos-dir")
 		codeownersFile = flag.String("codeowners", "CODEOWNERS", "Path to CODEOWNERS file")
+		fixturesDir    = flag.String("fixtures", "", "Directory of recorded GitHub API responses for --offline and --record")
+		offline        = flag.Bool("offline", false, "Replay GitHub API responses from --fixtures without network access (implies dry run)")
+		record         = flag.Bool("record", false, "Record GitHub API responses to --fixtures")
+		defaultOwner   = flag.String("default-owner", "", "Owner of repositories whose owners cannot be detected (default: discovery.default_owner of --policy, or "+ownership.DefaultOwner+")")
+		policyFile     = flag.String("policy", "policy.yaml", "Organization policy that may set the default owner and the teams whose review requests rotate among their members")
+		keep           = flag.Bool("keep", false, "Keep the configurations earlier dry runs wrote to discovered-repos/ instead of clearing them")
+		filtersFile    = flag.String("filters", "discovery-filters.yaml", "Include and exclude lists of org/name glob patterns of the repositories to discover")
+		excludeRepos   = flag.String("exclude-repos", "", "Comma-separated regular expressions of repository names discovery never proposes, each matching the whole name (e.g. 'sandbox-.*,.*-poc'), added to exclude_names of --filters")
+		languageList   = flag.String("languages", discover.DefaultLanguages, "Comma-separated languages of the repositories to discover: "+strings.Join(discover.LanguageKeys(), ", "))
+		graphQL        = flag.Bool("graphql", false, "List repositories and their CODEOWNERS files with GraphQL queries of 100 repositories instead of REST calls per repository")
+		concurrency    = flag.Int("concurrency", 8, "Number of new repositories analyzed and their owners detected at once")
+		cacheDir       = flag.String("cache-dir", httpcache.DefaultDir(), "Directory caching GitHub API responses with their ETags, revalidated with conditional requests by later runs")
+		noCache        = flag.Bool("no-cache", false, "Send every GitHub API request without the cache")
+		untested       = flag.String("untested", discover.UntestedFlag, "What to do with Go repositories without test files: "+discover.UntestedFlag+" them no_tests, "+discover.UntestedSkip+" them, or "+discover.UntestedIgnore+" it without looking for test files")
+		detectModules  = flag.Bool("detect-modules", true, "List the modules of Go repositories with nested go.mod files in their configurations, so coverage is collected per module")
+		includeForks   = flag.Bool("include-forks", false, "Discover forked repositories too, marked fork: true in their configurations")
+		visibility     = flag.String("visibility", discover.VisibilityAll, "Visibility of the repositories to discover: "+discover.VisibilityPublic+", "+discover.VisibilityPrivate+" (including internal) or "+discover.VisibilityAll)
+		provider       = flag.String("provider", discover.ProviderGitHub, "Forge hosting the organization and the dashboard repository: "+discover.ProviderGitHub+" or "+discover.ProviderGitLab+" (authenticated with "+discover.GitLabTokenEnv+", opening merge requests)")
+		gitLabURL      = flag.String("gitlab-url", discover.DefaultGitLabURL, "GitLab instance of --provider "+discover.ProviderGitLab)
+		writesPerMin   = flag.Int("writes-per-minute", ghauth.DefaultWritesPerMinute, "Write requests sent to GitHub per minute at most, with jitter, across opening pull requests, requesting reviews and labeling, so GitHub's abuse detection does not trip on large runs (0 does not pace them)")
+		githubBaseURL  = flag.String("github-base-url", "", "GitHub API of --provider "+discover.ProviderGitHub+", e.g. https://github.example.com/api/v3 on GitHub Enterprise Server (default: "+ghauth.APIURLEnv+", or api.github.com)")
+		ci             = flag.Bool("ci", false, "Non-interactive mode for CI jobs: output without emoji, never prompts, and exits with a distinct code when pull requests could not be opened")
+		scheduleSpec   = flag.String("schedule", "", "Cron schedule of the dashboard runs in UTC, telling owners in pull requests when their repository appears (default: the schedule of "+schedule.DefaultWorkflow+")")
+		thirdParty     = flag.Bool("detect-third-party", true, "Exclude upstream projects embedded in Go repositories outside vendor/, detected by their license files and module paths")
+		detectBy       = flag.String("detect-by", discover.DetectByLanguage, "How Go repositories are told: "+discover.DetectByLanguage+" by GitHub's primary language, or "+discover.DetectByGoMod+" by go.mod files in their default branch, also finding those where Go is not the largest language")
+		deepScan       = flag.Bool("deep-scan", false, "List the files of repositories whose primary language is not Go, discovering the Go modules inside them (e.g. tools/) with configurations scoped to those modules")
+		localeTag      = flag.String("locale", "", "Locale the time of the next dashboard run is formatted in (e.g. de-DE) (default: "+locale.DefaultTag+" with an ISO date)")
+		minActivity    = flag.String("min-activity", "", "Skip repositories without a push in this window (e.g. 180d, 26w or 720h), listing them as stale")
+		progress       = flag.Bool("progress", false, "Draw a progress bar over the repositories instead of printing their lines, keeping warnings")
+		quiet          = flag.Bool("quiet", false, "Print only the final summary, and errors")
+		strict         = flag.Bool("strict", false, "Exit with a distinct code when warnings were printed, e.g. unparsable configurations or failed reviewer requests")
+		interactive    = flag.Bool("interactive", false, "With --apply, show each new repository with its owners and excludes, and ask to add it, edit its owners or skip it before its pull request is opened")
+		outputFormat   = flag.String("output", outputText, "Format of stdout: "+outputText+", or "+outputJSON+" for a report of the new, skipped and archived repositories and their pull requests, printing the progress to stderr")
+		stateFile      = flag.String("state", filepath.Join(httpcache.DefaultDir(), discover.StateFile), "File checkpointing the analyses and pull requests of the run, removed once it completes")
+		resume         = flag.Bool("resume", false, "Continue the run --state checkpointed, without analyzing its repositories or opening its pull requests again")
+		webhook        = flag.Bool("webhook", false, "With serve, discover the repositories GitHub repository webhooks announce as created or transferred, from deliveries signed with $"+discover.WebhookSecretEnv)
+		listen         = flag.String("listen", ":8080", "Address serve listens on, receiving webhook deliveries on /webhook and health checks on /healthz")
+		discoveryCron  = flag.String("discovery-schedule", "", "With serve, discover the repositories of the organization at the times of this cron expression in UTC, e.g. \"0 3 * * *\"")
+		lockFile       = flag.String("lock-file", filepath.Join(httpcache.DefaultDir(), discover.LockFile), "Lock file of the runs of serve --discovery-schedule; runs are skipped while another process holds it")
+		baseBranch     = flag.String("base-branch", "", "Branch of the dashboard repository to open pull requests against (default: its default branch)")
+		ascii          = flag.Bool("ascii", false, "Print only ASCII, without emoji, for terminals and log viewers without Unicode (default: detected from TERM and the locale, except with --interactive)")
+		only           = flag.String("only", "", "Comma-separated repositories of --org, as name or org/name, to discover instead of the whole organization, e.g. to open a pull request that failed again")
+		checkDrift     = flag.Bool("check-drift", false, "Compare the configurations of tracked repositories with those discovery generates today, reporting missing default excludes, modules and visibility changes; with --apply, open PRs adding the missing excludes")
 	)
 
-	flag.Parse()
+	// serve keeps discovering the repositories webhooks announce, or the organization on a schedule, instead of
+	// scanning the organization once
+	args := os.Args[1:]
+	serve := len(args) > 0 && args[0] == "serve"
+	if serve {
+		args = args[1:]
+	}
+	flag.CommandLine.Parse(args)
+
+	mode, err := display.ModeOf(*progress, *quiet)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitUsage
+	}
+	switch {
+	case *interactive && *ci:
+		fmt.Fprintln(os.Stderr, "Error: --interactive cannot be combined with --ci, which never prompts")
+		return exitUsage
+	case *interactive && *ascii:
+		fmt.Fprintln(os.Stderr, "Error: --interactive cannot be combined with --ascii, which holds back its prompts")
+		return exitUsage
+	case *interactive && mode != display.Lines:
+		fmt.Fprintln(os.Stderr, "Error: --interactive cannot be combined with --progress or --quiet, which hide its prompts")
+		return exitUsage
+	case serve && !*webhook && *discoveryCron == "":
+		fmt.Fprintln(os.Stderr, "Error: serve needs --webhook, --discovery-schedule or both to know when to discover repositories")
+		return exitUsage
+	case !serve && (*webhook || *discoveryCron != ""):
+		fmt.Fprintln(os.Stderr, "Error: --webhook and --discovery-schedule need the serve command, e.g. discover-repos serve --webhook --apply")
+		return exitUsage
+	case serve && (*offline || *record || *interactive || *resume || *outputFormat != outputText || mode != display.Lines):
+		fmt.Fprintln(os.Stderr, "Error: serve cannot be combined with --offline, --record, --interactive, --resume, --output json, --progress or --quiet")
+		return exitUsage
+	case *checkDrift && (serve || *interactive || *resume):
+		fmt.Fprintln(os.Stderr, "Error: --check-drift cannot be combined with serve, --interactive or --resume")
+		return exitUsage
+	case *only != "" && (serve || *checkDrift):
+		fmt.Fprintln(os.Stderr, "Error: --only cannot be combined with serve or --check-drift")
+		return exitUsage
+	case *webhook && *provider != discover.ProviderGitHub:
+		fmt.Fprintf(os.Stderr, "Error: serve --webhook receives GitHub webhooks, not those of --provider %s\n", *provider)
+		return exitUsage
+	}
+	secret := os.Getenv(discover.WebhookSecretEnv)
+	if *webhook && secret == "" {
+		fmt.Fprintf(os.Stderr, "Error: %s is required to verify webhook deliveries\n", discover.WebhookSecretEnv)
+		return exitUsage
+	}
+	if *outputFormat != outputText && *outputFormat != outputJSON {
+		fmt.Fprintf(os.Stderr, "Error: --output must be %s or %s, got %q\n", outputText, outputJSON, *outputFormat)
+		return exitUsage
+	}
+
+	// The report alone goes to stdout as JSON, so the progress output moves to stderr
+	progressOut := os.Stdout
+	if *outputFormat == outputJSON {
+		progressOut = os.Stderr
+	}
+	mode = mode.On(progressOut)
+	// Without --ascii, terminals and locales without Unicode get ASCII output, unless prompted interactively
+	asciiSet := false
+	flag.Visit(func(f *flag.Flag) { asciiSet = asciiSet || f.Name == "ascii" })
+	if !asciiSet {
+		*ascii = console.DetectASCII(os.Getenv) && !*interactive
+	}
+	style := console.StyleOf(*ascii, *ci)
 
-	config := discover.Config{
-		Organization:   *org,
-		ReposDir:       *reposDir,
-		CodeownersFile: *codeownersFile,
-		DryRun:         !*apply,
+	// Warnings are counted, and lines rendered in the style, as the output goes by; with --progress or --quiet the
+	// output is shaped after they are counted
+	writers := map[**os.File]*console.Writer{
+		&os.Stdout: console.NewWriter(progressOut, style),
+		&os.Stderr: console.NewWriter(os.Stderr, style),
+	}
+	shaped := display.NewWriter(progressOut, mode, style)
+	if mode != display.Lines {
+		writers[&os.Stdout] = console.NewWriter(shaped, console.Unicode)
+	}
+	var restores []func() error
+	if style != console.Unicode || *strict || mode != display.Lines || *outputFormat == outputJSON {
+		for file, writer := range writers {
+			restore, err := console.Capture(file, writer)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				return exitFailure
+			}
+			restores = append(restores, restore)
+		}
+	}
+	// runner and runErr are reported as JSON once the output is restored
+	var (
+		runner *discover.Runner
+		runErr error
+	)
+	// finish restores the output, prints the report of JSON runs and turns the warnings of strict runs into failures
+	finish := func(code int) int {
+		for _, restore := range restores {
+			if err := restore(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: failed to write output: %v\n", err)
+			}
+		}
+		if err := shaped.Flush(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to write output: %v\n", err)
+		}
+		if *outputFormat == outputJSON && runner != nil {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(runner.Report(runErr)); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: failed to write the report: %v\n", err)
+				return exitFailure
+			}
+		}
+		warnings := 0
+		for _, writer := range writers {
+			warnings += writer.Warnings()
+		}
+		if *strict && code == exitOK && warnings > 0 {
+			fmt.Fprintf(os.Stderr, "Error: %d warnings printed, failing because of --strict\n", warnings)
+			return exitWarnings
+		}
+		return code
+	}
+
+	languages, err := discover.ParseLanguages(*languageList)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return finish(exitUsage)
+	}
+
+	filters, err := config.LoadDiscoveryFilters(*filtersFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return finish(exitUsage)
+	}
+	if *excludeRepos != "" {
+		var patterns []string
+		for _, pattern := range strings.Split(*excludeRepos, ",") {
+			if pattern = strings.TrimSpace(pattern); pattern != "" {
+				patterns = append(patterns, pattern)
+			}
+		}
+		if err := filters.AddExcludeNames(patterns...); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --exclude-repos: %v\n", err)
+			return finish(exitUsage)
+		}
+	}
+
+	onlyRepos, err := discover.ParseOnly(*only, *org)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return finish(exitUsage)
 	}
 
-	ctx := context.Background()
-	runner, err := discover.NewRunner(config)
+	// The default owner of the policy applies unless given on the command line
+	orgPolicy, err := policy.Load(*policyFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return finish(exitUsage)
+	}
+	if *defaultOwner == "" {
+		*defaultOwner = orgPolicy.Discovery.DefaultOwner
+	}
+	rotations := make(map[string]pr.Rotation)
+	for team, rotation := range orgPolicy.Discovery.ReviewRotation {
+		rotations[team] = pr.Rotation{Reviewers: rotation.Reviewers, Skip: rotation.Skip}
+	}
+
+	if _, err := locale.Parse(*localeTag); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return finish(exitUsage)
+	}
+
+	// Without --schedule, the schedule of the dashboard workflow applies when it is checked out
+	var runs *schedule.Schedule
+	if *scheduleSpec != "" {
+		runs, err = schedule.Parse(*scheduleSpec)
+	} else if runs, err = schedule.FromWorkflow(schedule.DefaultWorkflow); errors.Is(err, fs.ErrNotExist) {
+		err = nil
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return finish(exitUsage)
+	}
+
+	var discoveryRuns *schedule.Schedule
+	if *discoveryCron != "" {
+		if discoveryRuns, err = schedule.Parse(*discoveryCron); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --discovery-schedule: %v\n", err)
+			return finish(exitUsage)
+		}
+	}
+
+	var activity time.Duration
+	if *minActivity != "" {
+		if activity, err = discover.ParseActivity(*minActivity); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return finish(exitUsage)
+		}
+	}
+
+	discoverConfig := discover.Config{
+		Organization:     *org,
+		ReposDir:         *reposDir,
+		ReposFile:        *reposFile,
+		CodeownersFile:   *codeownersFile,
+		DryRun:           !*apply,
+		FixturesDir:      *fixturesDir,
+		Offline:          *offline,
+		RecordFixtures:   *record,
+		DefaultOwner:     *defaultOwner,
+		KeepDiscovered:   *keep,
+		Languages:        languages,
+		Filters:          filters,
+		GraphQL:          *graphQL,
+		Concurrency:      *concurrency,
+		Untested:         *untested,
+		DetectModules:    *detectModules,
+		IncludeForks:     *includeForks,
+		Visibility:       *visibility,
+		Provider:         *provider,
+		GitLabURL:        *gitLabURL,
+		GitHubBaseURL:    *githubBaseURL,
+		WritesPerMinute:  *writesPerMin,
+		Only:             onlyRepos,
+		Schedule:         runs,
+		Locale:           *localeTag,
+		DetectThirdParty: *thirdParty,
+		DetectBy:         *detectBy,
+		DeepScan:         *deepScan,
+		MinActivity:      activity,
+		Output:           mode,
+		Interactive:      *interactive,
+		Resume:           *resume,
+		ReviewRotation:   rotations,
+		BaseBranch:       *baseBranch,
+	}
+	// Replays are quick and have nothing to resume, and served discoveries and drift checks start over each time
+	if !*offline && !serve && !*checkDrift {
+		discoverConfig.StateFile = *stateFile
+	}
+	if !*noCache {
+		discoverConfig.CacheDir = *cacheDir
+	}
+
+	runner, err = discover.NewRunner(discoverConfig)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error initializing: %v\n", err)
-		os.Exit(1)
+		if errors.Is(err, discover.ErrCredentials) {
+			return finish(exitCredentials)
+		}
+		return finish(exitUsage)
 	}
 
-	if err := runner.Run(ctx); err != nil {
+	ctx, stop := interrupt.Context()
+	defer stop()
+	if serve {
+		if !*webhook {
+			secret = ""
+		}
+		return finish(serveDiscovery(ctx, runner, *listen, secret, discoveryRuns, *lockFile))
+	}
+	discovery := runner.Run
+	if *checkDrift {
+		discovery = runner.CheckDrift
+	}
+	if runErr = discovery(ctx); runErr != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", runErr)
+		return finish(exitCode(ctx, runErr))
+	}
+	if failed := runner.FailedPullRequests(); *ci && failed > 0 {
+		fmt.Fprintf(os.Stderr, "Error: %d pull requests could not be opened\n", failed)
+		return finish(exitPullRequests)
+	}
+	return finish(exitOK)
+}
+
+// serveDiscovery discovers the repositories webhook deliveries signed with secret announce, when set, and the
+// organization at the times of runs, when set, until interrupted, returning the exit code
+func serveDiscovery(ctx context.Context, runner *discover.Runner, addr, secret string, runs *schedule.Schedule, lockFile string) int {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	if secret != "" {
+		webhook := discover.NewWebhook(runner, []byte(secret))
+		mux.Handle("/webhook", webhook)
+		go webhook.Run(ctx)
+		fmt.Printf("🔔 Listening for GitHub webhooks on %s/webhook\n", addr)
+	}
+	if runs != nil {
+		fmt.Printf("⏰ Discovering on the schedule %q (UTC), locking %s\n", strings.Join(runs.Expressions, ", "), lockFile)
+		go discover.NewScheduler(runner, runs, lockFile).Run(ctx)
+	}
+
+	server := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		return exitFailure
+	}
+	return exitOK
+}
+
+// exitCode returns the exit code of the class of a failed run
+func exitCode(ctx context.Context, err error) int {
+	switch {
+	case ctx.Err() != nil:
+		return exitInterrupted
+	case errors.Is(err, discover.ErrCredentials):
+		return exitCredentials
+	case errors.Is(err, discover.ErrFetch):
+		return exitFetch
+	default:
+		return exitFailure
 	}
 }
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+	"github.com/konflux-ci/coverage-dashboard/internal/ghauth"
+	"github.com/konflux-ci/coverage-dashboard/internal/interrupt"
+	"github.com/konflux-ci/coverage-dashboard/internal/issues"
+	"github.com/konflux-ci/coverage-dashboard/internal/pr"
+)
+
+func main() {
+	var (
+		repo           = flag.String("repo", "", "Repository to offboard, in org/name form (required)")
+		retainHistory  = flag.String("retain-history", "90d", "How long the reports and imported history are kept before collection deletes them (e.g. 90d, 13w, 2160h, 0 or "+config.RetainForever+")")
+		reposDir       = flag.String("repos-dir", "repos", "Directory containing repository configurations")
+		reposFile      = flag.String("repos-file", "repos.yaml", "Single file listing repository configurations, merged with --repos-dir")
+		codeownersFile = flag.String("codeowners", "CODEOWNERS", "Path to CODEOWNERS file")
+		offboarded     = flag.String("offboarded", config.DefaultOffboardedFile, "File recording the offboarded repositories and when their data is deleted")
+		dashboardRepo  = flag.String("dashboard-repo", "konflux-ci/coverage-dashboard", "Dashboard repository to open the pull request on, checked out in the working directory")
+		baseBranch     = flag.String("base", "", "Branch to open the pull request against (default: the default branch of --dashboard-repo)")
+		githubBaseURL  = flag.String("github-base-url", "", "GitHub API of --dashboard-repo, e.g. https://github.example.com/api/v3 on GitHub Enterprise Server (default: $"+ghauth.APIURLEnv+", or api.github.com)")
+		noFarewell     = flag.Bool("no-farewell", false, "Do not open the issue telling the owners in the offboarded repository")
+		local          = flag.Bool("local", false, "Only update the local files, without opening a pull request or telling the owners")
+	)
+
+	flag.Parse()
+
+	if *repo == "" {
+		fmt.Fprintln(os.Stderr, "Error: --repo is required")
+		flag.Usage()
+		os.Exit(2)
+	}
+	retention, err := config.ParseRetention(*retainHistory)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --retain-history: %v\n", err)
+		os.Exit(2)
+	}
+	writer := config.NewWriter(*reposDir, *codeownersFile)
+
+	if *local {
+		offboarding, err := writer.Offboard(*reposFile, *offboarded, *repo, time.Now(), retention)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✅ Offboarded %s, data %s, updated %s\n", offboarding.Repo, dataNote(offboarding), strings.Join(offboarding.Files, ", "))
+		return
+	}
+
+	org, name, ok := strings.Cut(*dashboardRepo, "/")
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Error: --dashboard-repo must be in owner/name form, got %q\n", *dashboardRepo)
+		os.Exit(2)
+	}
+	tokens, err := ghauth.TokensFromEnv().WithBaseURL(*githubBaseURL)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if tokens.WriteSource == "" {
+		fmt.Fprintf(os.Stderr, "Error: %s, %s or a GitHub App (%s) is required to open the pull request, or pass --local\n", ghauth.WriteTokenEnv, ghauth.SharedTokenEnv, ghauth.AppIDEnv)
+		os.Exit(1)
+	}
+
+	ctx, stop := interrupt.Context()
+	defer stop()
+	client := tokens.WriteClient(ctx)
+	if *baseBranch == "" {
+		if *baseBranch, err = pr.DefaultBranch(ctx, client, org, name); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to get the default branch of %s, pass --base: %v\n", *dashboardRepo, err)
+			stop()
+			os.Exit(1)
+		}
+	}
+	creator := pr.NewCreator(client, ".", org, name, *baseBranch)
+	offboarding, url, err := creator.Offboard(ctx, writer, *reposFile, *offboarded, *repo, retention)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		stop()
+		os.Exit(1)
+	}
+	fmt.Printf("✅ Opened %s, data %s\n", url, dataNote(offboarding))
+
+	if *noFarewell {
+		return
+	}
+	// The pull request is open either way; an archived repository, for one, takes no issues
+	if err := issues.NewTracker(client, "", nil).Farewell(ctx, offboarding, url); err != nil {
+		fmt.Printf("⚠️  Warning: failed to open the farewell issue in %s: %v\n", *repo, err)
+	}
+}
+
+// dataNote tells when the data of an offboarded repository is deleted, for messages
+func dataNote(offboarding config.Offboarding) string {
+	if offboarding.DeleteAfter == nil {
+		return "kept forever"
+	}
+	return "deleted after " + offboarding.DeleteAfter.Format(time.DateOnly)
+}
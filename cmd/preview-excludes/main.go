@@ -0,0 +1,73 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/collect"
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+	"github.com/konflux-ci/coverage-dashboard/internal/ghauth"
+	"github.com/konflux-ci/coverage-dashboard/internal/interrupt"
+)
+
+func main() {
+	var (
+		repo          = flag.String("repo", "", "Repository to preview, in org/name form (required)")
+		local         = flag.String("local", "", "Existing checkout of the repository to use instead of cloning it")
+		reposDir      = flag.String("repos-dir", "repos", "Directory containing repository configurations")
+		reposFile     = flag.String("repos-file", "repos.yaml", "Single file listing repository configurations, merged with --repos-dir")
+		workspaceDir  = flag.String("workspace", "workspace", "Directory to clone the repository into")
+		githubBaseURL = flag.String("github-base-url", "", "GitHub API whose host the repository is cloned from, e.g. https://github.example.com/api/v3 on GitHub Enterprise Server (default: $"+ghauth.APIURLEnv+", or api.github.com)")
+	)
+
+	flag.Parse()
+
+	if *repo == "" {
+		fmt.Fprintln(os.Stderr, "Error: --repo is required")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	repositories, err := config.LoadRepositories(*reposDir, *reposFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	var cfg *config.RepositoryConfig
+	for _, entry := range repositories.Entries {
+		if entry.Config.Name == *repo {
+			cfg = &entry.Config
+			break
+		}
+	}
+	if cfg == nil {
+		fmt.Fprintf(os.Stderr, "Error: %s is not configured in %s or %s\n", *repo, *reposDir, *reposFile)
+		os.Exit(1)
+	}
+
+	tokens, err := ghauth.TokensFromEnv().WithBaseURL(*githubBaseURL)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	ctx, stop := interrupt.Context()
+	defer stop()
+	repoDir := *local
+	if repoDir == "" {
+		repoDir, err = collect.CloneRepository(ctx, *workspaceDir, tokens.WebURL(), *repo)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	preview, err := collect.PreviewExcludes(ctx, repoDir, *cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	preview.Print()
+}
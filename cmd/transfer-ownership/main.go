@@ -0,0 +1,89 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+	"github.com/konflux-ci/coverage-dashboard/internal/ghauth"
+	"github.com/konflux-ci/coverage-dashboard/internal/interrupt"
+	"github.com/konflux-ci/coverage-dashboard/internal/pr"
+)
+
+func main() {
+	var (
+		repo           = flag.String("repo", "", "Repository to transfer, in org/name form (required)")
+		to             = flag.String("to", "", "New owners, comma-separated (e.g. @konflux-ci/new-team) (required)")
+		reposDir       = flag.String("repos-dir", "repos", "Directory containing repository configurations")
+		reposFile      = flag.String("repos-file", "repos.yaml", "Single file listing repository configurations, merged with --repos-dir")
+		codeownersFile = flag.String("codeowners", "CODEOWNERS", "Path to CODEOWNERS file")
+		dashboardRepo  = flag.String("dashboard-repo", "konflux-ci/coverage-dashboard", "Dashboard repository to open the pull request on, checked out in the working directory")
+		baseBranch     = flag.String("base", "", "Branch to open the pull request against (default: the default branch of --dashboard-repo)")
+		githubBaseURL  = flag.String("github-base-url", "", "GitHub API of --dashboard-repo, e.g. https://github.example.com/api/v3 on GitHub Enterprise Server (default: $"+ghauth.APIURLEnv+", or api.github.com)")
+		local          = flag.Bool("local", false, "Only update the local files, without opening a pull request")
+	)
+
+	flag.Parse()
+
+	if *repo == "" || *to == "" {
+		fmt.Fprintln(os.Stderr, "Error: --repo and --to are required")
+		flag.Usage()
+		os.Exit(2)
+	}
+	owners := strings.Split(*to, ",")
+	writer := config.NewWriter(*reposDir, *codeownersFile)
+
+	if *local {
+		transfer, err := writer.TransferOwnership(*reposFile, *repo, owners)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✅ Transferred %s from %s to %s, updated %s\n", transfer.Repo, formatOwners(transfer.From), formatOwners(transfer.To), strings.Join(transfer.Files, ", "))
+		return
+	}
+
+	org, name, ok := strings.Cut(*dashboardRepo, "/")
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Error: --dashboard-repo must be in owner/name form, got %q\n", *dashboardRepo)
+		os.Exit(2)
+	}
+	tokens, err := ghauth.TokensFromEnv().WithBaseURL(*githubBaseURL)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if tokens.WriteSource == "" {
+		fmt.Fprintf(os.Stderr, "Error: %s, %s or a GitHub App (%s) is required to open the pull request, or pass --local\n", ghauth.WriteTokenEnv, ghauth.SharedTokenEnv, ghauth.AppIDEnv)
+		os.Exit(1)
+	}
+
+	ctx, stop := interrupt.Context()
+	defer stop()
+	client := tokens.WriteClient(ctx)
+	if *baseBranch == "" {
+		if *baseBranch, err = pr.DefaultBranch(ctx, client, org, name); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to get the default branch of %s, pass --base: %v\n", *dashboardRepo, err)
+			stop()
+			os.Exit(1)
+		}
+	}
+	creator := pr.NewCreator(client, ".", org, name, *baseBranch)
+	url, err := creator.TransferOwnership(ctx, writer, *reposFile, *repo, owners)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		stop()
+		os.Exit(1)
+	}
+	fmt.Printf("✅ Opened %s\n", url)
+}
+
+// formatOwners joins owners for messages
+func formatOwners(owners []string) string {
+	if len(owners) == 0 {
+		return "no owners"
+	}
+	return strings.Join(owners, " ")
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/collect"
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+	"github.com/konflux-ci/coverage-dashboard/internal/interrupt"
+)
+
+func main() {
+	var (
+		repo      = flag.String("repo", "", "Repository to replay, in org/name form (required)")
+		modified  = flag.String("config", "", "Modified configuration of the repository to evaluate, e.g. an edited repos/{repo-name}.yaml (required)")
+		snapshot  = flag.String("snapshot", "latest", "Commit of the archived report whose raw profile is replayed, or a prefix of it")
+		from      = flag.String("from", "https://konflux-ci.dev/coverage-dashboard/coverage", "Reports directory or URL of the published reports")
+		reposDir  = flag.String("repos-dir", "repos", "Directory containing repository configurations")
+		reposFile = flag.String("repos-file", "repos.yaml", "Single file listing repository configurations, merged with --repos-dir")
+	)
+
+	flag.Parse()
+
+	if *repo == "" || *modified == "" {
+		fmt.Fprintln(os.Stderr, "Error: --repo and --config are required")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	repositories, err := config.LoadRepositories(*reposDir, *reposFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	var current *config.RepositoryConfig
+	for _, entry := range repositories.Entries {
+		if entry.Config.Name == *repo {
+			current = &entry.Config
+			break
+		}
+	}
+	if current == nil {
+		fmt.Fprintf(os.Stderr, "Error: %s is not configured in %s or %s\n", *repo, *reposDir, *reposFile)
+		os.Exit(1)
+	}
+	cfg, err := config.LoadRepositoryConfig(filepath.Dir(*modified), filepath.Base(*modified))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to load %s: %v\n", *modified, err)
+		os.Exit(1)
+	}
+
+	ctx, stop := interrupt.Context()
+	defer stop()
+
+	archive, err := collect.FetchReportArchive(ctx, *from, *repo)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	report, err := archive.Find(*snapshot)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	profile, err := collect.LoadProfile(ctx, *from, *repo, report, collect.ProfileRaw)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	whatIf, err := collect.NewWhatIf(bytes.NewReader(profile), *current, cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	whatIf.Commit, whatIf.Recorded = report.Commit, report.Coverage
+	whatIf.Print()
+}
@@ -0,0 +1,158 @@
+// Package admin serves an authenticated API, and a page using it, to edit the thresholds and excludes of repository
+// configurations. Edits are never applied to the dashboard directly: each opens a pull request on the dashboard
+// repository, so its configurations in git stay the source of truth
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+)
+
+// TokensEnv holds the administrators allowed to edit, as comma-separated name=token pairs; the name of the token a
+// request is authenticated with is credited with its pull request
+const TokensEnv = "COVERAGE_ADMIN_TOKENS"
+
+// maxRequestSize bounds the body of edit requests
+const maxRequestSize = 64 << 10
+
+//go:embed admin.html
+var page []byte
+
+// Editor opens the pull request of an edit of a repository's configuration on behalf of requester, returning its URL
+type Editor interface {
+	Edit(ctx context.Context, repo string, edit config.Edit, requester string) (string, error)
+}
+
+// EditorFunc adapts a function to an Editor
+type EditorFunc func(ctx context.Context, repo string, edit config.Edit, requester string) (string, error)
+
+// Edit calls f
+func (f EditorFunc) Edit(ctx context.Context, repo string, edit config.Edit, requester string) (string, error) {
+	return f(ctx, repo, edit, requester)
+}
+
+// EditResponse is the response of an edit accepted as a pull request
+type EditResponse struct {
+	Repo        string `json:"repo"`
+	PullRequest string `json:"pull_request"`
+}
+
+// errorResponse is the response of a refused or failed edit
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// ParseTokens parses the name=token pairs of TokensEnv, by token
+func ParseTokens(s string) (map[string]string, error) {
+	tokens := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		name, token, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || name == "" || token == "" {
+			return nil, fmt.Errorf("invalid %s entry %q, expected name=token", TokensEnv, pair)
+		}
+		tokens[token] = name
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("%s names no administrators", TokensEnv)
+	}
+	return tokens, nil
+}
+
+// Server serves the admin API and page
+type Server struct {
+	// tokens are the names of the administrators, by token
+	tokens map[string]string
+	editor Editor
+	// mu serializes edits, which share the checkout of the dashboard repository
+	mu  sync.Mutex
+	mux *http.ServeMux
+}
+
+// NewServer creates a server authenticating requests with tokens, by token, and opening pull requests with editor
+func NewServer(tokens map[string]string, editor Editor) *Server {
+	s := &Server{tokens: tokens, editor: editor, mux: http.NewServeMux()}
+	s.mux.HandleFunc("GET /{$}", s.servePage)
+	s.mux.HandleFunc("POST /api/repos/{org}/{name}/config", s.serveEdit)
+	return s
+}
+
+// ServeHTTP serves a request of the API or the page
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// servePage serves the page editing configurations through the API
+func (s *Server) servePage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(page)
+}
+
+// serveEdit opens the pull request of an edit of a repository's configuration
+func (s *Server) serveEdit(w http.ResponseWriter, r *http.Request) {
+	requester, ok := s.authenticate(r)
+	if !ok {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="coverage-dashboard"`)
+		writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "a valid bearer token is required"})
+		return
+	}
+
+	repo := r.PathValue("org") + "/" + r.PathValue("name")
+	if !config.ValidRepoName(repo) {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: fmt.Sprintf("invalid repository name %q", repo)})
+		return
+	}
+	var edit config.Edit
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&edit); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: fmt.Sprintf("invalid edit: %v", err)})
+		return
+	}
+
+	s.mu.Lock()
+	url, err := s.editor.Edit(r.Context(), repo, edit, requester)
+	s.mu.Unlock()
+	switch {
+	case errors.Is(err, config.ErrInvalidEdit):
+		writeJSON(w, http.StatusUnprocessableEntity, errorResponse{Error: err.Error()})
+	case err != nil:
+		writeJSON(w, http.StatusBadGateway, errorResponse{Error: fmt.Sprintf("failed to open the pull request: %v", err)})
+	default:
+		writeJSON(w, http.StatusCreated, EditResponse{Repo: repo, PullRequest: url})
+	}
+}
+
+// authenticate returns the administrator of the request's bearer token
+func (s *Server) authenticate(r *http.Request) (string, bool) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return "", false
+	}
+	// Every token is compared, so the time taken does not tell how close a guess was
+	var requester string
+	for candidate, name := range s.tokens {
+		if subtle.ConstantTimeCompare([]byte(candidate), []byte(token)) == 1 {
+			requester = name
+		}
+	}
+	return requester, requester != ""
+}
+
+// writeJSON writes a response as JSON
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,108 @@
+package admin_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/admin"
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+)
+
+var _ = Describe("Server", func() {
+	var (
+		server *httptest.Server
+		edits  []string
+		failed error
+	)
+
+	BeforeEach(func() {
+		edits, failed = nil, nil
+		editor := admin.EditorFunc(func(_ context.Context, repo string, edit config.Edit, requester string) (string, error) {
+			if failed != nil {
+				return "", failed
+			}
+			edits = append(edits, fmt.Sprintf("%s by %s: min_coverage %v, exclude_dirs +%v", repo, requester, *edit.MinCoverage, edit.AddExcludeDirs))
+			return "https://github.com/konflux-ci/coverage-dashboard/pull/7", nil
+		})
+		server = httptest.NewServer(admin.NewServer(map[string]string{"secret": "alice"}, editor))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	post := func(path, token, body string) (*http.Response, map[string]string) {
+		req, err := http.NewRequest(http.MethodPost, server.URL+path, strings.NewReader(body))
+		Expect(err).NotTo(HaveOccurred())
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		Expect(err).NotTo(HaveOccurred())
+		defer resp.Body.Close()
+		var decoded map[string]string
+		Expect(json.NewDecoder(resp.Body).Decode(&decoded)).To(Succeed())
+		return resp, decoded
+	}
+
+	It("should open a pull request for the edits of administrators", func() {
+		resp, body := post("/api/repos/konflux-ci/api/config", "secret", `{"min_coverage": 70, "add_exclude_dirs": ["hack/"]}`)
+		Expect(resp.StatusCode).To(Equal(http.StatusCreated))
+		Expect(body).To(Equal(map[string]string{"repo": "konflux-ci/api", "pull_request": "https://github.com/konflux-ci/coverage-dashboard/pull/7"}))
+		Expect(edits).To(Equal([]string{"konflux-ci/api by alice: min_coverage 70, exclude_dirs +[hack/]"}))
+	})
+
+	It("should refuse requests without a valid token", func() {
+		resp, body := post("/api/repos/konflux-ci/api/config", "", `{"min_coverage": 70}`)
+		Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
+		Expect(resp.Header.Get("WWW-Authenticate")).To(HavePrefix("Bearer"))
+		Expect(body["error"]).To(Equal("a valid bearer token is required"))
+
+		resp, _ = post("/api/repos/konflux-ci/api/config", "guess", `{"min_coverage": 70}`)
+		Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
+		Expect(edits).To(BeEmpty())
+	})
+
+	It("should tell invalid requests and edits from failures to open the pull request", func() {
+		resp, body := post("/api/repos/konflux-ci/api/config", "secret", `{"threshold": 70}`)
+		Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+		Expect(body["error"]).To(ContainSubstring(`unknown field "threshold"`))
+
+		failed = fmt.Errorf("%w: min_coverage must be between 0 and 100, got 170", config.ErrInvalidEdit)
+		resp, _ = post("/api/repos/konflux-ci/api/config", "secret", `{"min_coverage": 170}`)
+		Expect(resp.StatusCode).To(Equal(http.StatusUnprocessableEntity))
+
+		failed = fmt.Errorf("failed to push branch: rejected")
+		resp, body = post("/api/repos/konflux-ci/api/config", "secret", `{"min_coverage": 70}`)
+		Expect(resp.StatusCode).To(Equal(http.StatusBadGateway))
+		Expect(body["error"]).To(Equal("failed to open the pull request: failed to push branch: rejected"))
+	})
+
+	It("should serve the page editing configurations", func() {
+		resp, err := http.Get(server.URL + "/")
+		Expect(err).NotTo(HaveOccurred())
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(resp.Header.Get("Content-Type")).To(HavePrefix("text/html"))
+	})
+})
+
+var _ = Describe("ParseTokens", func() {
+	It("should index the administrators by token", func() {
+		Expect(admin.ParseTokens("alice=secret, bob=other")).To(Equal(map[string]string{"secret": "alice", "other": "bob"}))
+	})
+
+	It("should refuse entries without a name or token, and empty lists", func() {
+		_, err := admin.ParseTokens("secret")
+		Expect(err).To(MatchError(`invalid COVERAGE_ADMIN_TOKENS entry "secret", expected name=token`))
+		_, err = admin.ParseTokens(" , ")
+		Expect(err).To(MatchError("COVERAGE_ADMIN_TOKENS names no administrators"))
+	})
+})
@@ -0,0 +1,181 @@
+package codecov
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultURL is the Codecov API of codecov.io
+	DefaultURL = "https://api.codecov.io"
+	// TokenEnv names the environment variable holding the Codecov API token
+	TokenEnv = "CODECOV_TOKEN"
+
+	// service is the git host of the repositories in Codecov API paths
+	service = "github"
+)
+
+// errNotFound is returned for repositories Codecov does not know
+var errNotFound = errors.New("not found")
+
+// Commit is the coverage Codecov measured at a commit
+type Commit struct {
+	Commit   string
+	Date     time.Time
+	Coverage float64
+}
+
+// Client reads the coverage and coverage history of repositories from the Codecov API
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+	token      string
+}
+
+// NewClient creates a Client for the Codecov API at baseURL, authenticated with token
+// Public repositories can be read without a token
+func NewClient(httpClient *http.Client, baseURL, token string) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	if baseURL == "" {
+		baseURL = DefaultURL
+	}
+	return &Client{httpClient: httpClient, baseURL: strings.TrimSuffix(baseURL, "/"), token: token}
+}
+
+// repository is the part of a Codecov repository used by the client
+type repository struct {
+	Branch string `json:"branch"`
+	// Totals are the coverage of the latest commit of the default branch
+	Totals *struct {
+		Coverage *float64 `json:"coverage"`
+	} `json:"totals"`
+}
+
+// commitsPage is a page of commits of a Codecov repository, newest first
+type commitsPage struct {
+	Next    *string `json:"next"`
+	Results []struct {
+		CommitID  string    `json:"commitid"`
+		Timestamp time.Time `json:"timestamp"`
+		State     string    `json:"state"`
+		Totals    *struct {
+			Coverage *float64 `json:"coverage"`
+		} `json:"totals"`
+	} `json:"results"`
+}
+
+// DefaultBranch returns the branch Codecov considers the default of a repository, in org/name form
+func (c *Client) DefaultBranch(ctx context.Context, repo string) (string, error) {
+	r, err := c.repository(ctx, repo)
+	if err != nil {
+		return "", err
+	}
+	if r.Branch == "" {
+		return "", fmt.Errorf("Codecov has no default branch for %s", repo)
+	}
+	return r.Branch, nil
+}
+
+// Coverage returns the latest coverage Codecov measured on the default branch of a repository,
+// or nil when Codecov does not know the repository or has no coverage of it
+func (c *Client) Coverage(ctx context.Context, repo string) (*float64, error) {
+	r, err := c.repository(ctx, repo)
+	if errors.Is(err, errNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if r.Totals == nil {
+		return nil, nil
+	}
+	return r.Totals.Coverage, nil
+}
+
+// repository reads a Codecov repository, in org/name form
+func (c *Client) repository(ctx context.Context, repo string) (*repository, error) {
+	owner, name, ok := strings.Cut(repo, "/")
+	if !ok {
+		return nil, fmt.Errorf("repository name must be in owner/name form, got %q", repo)
+	}
+
+	var r repository
+	if err := c.get(ctx, fmt.Sprintf("%s/api/v2/%s/%s/repos/%s/", c.baseURL, service, owner, name), &r); err != nil {
+		return nil, fmt.Errorf("failed to read Codecov repository %s: %w", repo, err)
+	}
+	return &r, nil
+}
+
+// Commits returns the coverage measured on a branch of a repository since a time, oldest first
+// Commits Codecov did not finish processing, or without coverage, are skipped
+func (c *Client) Commits(ctx context.Context, repo, branch string, since time.Time) ([]Commit, error) {
+	owner, name, ok := strings.Cut(repo, "/")
+	if !ok {
+		return nil, fmt.Errorf("repository name must be in owner/name form, got %q", repo)
+	}
+
+	query := url.Values{"branch": {branch}, "page_size": {"100"}}
+	next := fmt.Sprintf("%s/api/v2/%s/%s/repos/%s/commits/?%s", c.baseURL, service, owner, name, query.Encode())
+	var commits []Commit
+	for next != "" {
+		var page commitsPage
+		if err := c.get(ctx, next, &page); err != nil {
+			return nil, fmt.Errorf("failed to read Codecov commits of %s: %w", repo, err)
+		}
+
+		reachedSince := false
+		for _, result := range page.Results {
+			if !since.IsZero() && result.Timestamp.Before(since) {
+				reachedSince = true
+				continue
+			}
+			if result.State != "complete" || result.Totals == nil || result.Totals.Coverage == nil {
+				continue
+			}
+			commits = append(commits, Commit{Commit: result.CommitID, Date: result.Timestamp.UTC(), Coverage: *result.Totals.Coverage})
+		}
+
+		next = ""
+		if page.Next != nil && !reachedSince {
+			next = *page.Next
+		}
+	}
+
+	sort.SliceStable(commits, func(i, j int) bool { return commits[i].Date.Before(commits[j].Date) })
+	return commits, nil
+}
+
+// get decodes the JSON document at url into v
+func (c *Client) get(ctx context.Context, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("GET %s: %w", url, errNotFound)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
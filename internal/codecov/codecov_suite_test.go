@@ -0,0 +1,13 @@
+package codecov_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestCodecov(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Codecov Suite")
+}
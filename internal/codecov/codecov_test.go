@@ -0,0 +1,87 @@
+package codecov_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/codecov"
+)
+
+var _ = Describe("Client", func() {
+	var (
+		server        *httptest.Server
+		client        *codecov.Client
+		authorization string
+		pages         []string
+	)
+
+	BeforeEach(func() {
+		authorization = ""
+		pages = nil
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authorization = r.Header.Get("Authorization")
+			switch r.URL.Path {
+			case "/api/v2/github/konflux-ci/repos/api/":
+				fmt.Fprint(w, `{"name": "api", "branch": "main", "totals": {"coverage": 71.5}}`)
+			case "/api/v2/github/konflux-ci/repos/api/commits/":
+				Expect(r.URL.Query().Get("branch")).To(Equal("main"))
+				page := r.URL.Query().Get("page")
+				pages = append(pages, page)
+				if page == "" {
+					fmt.Fprintf(w, `{"next": "%s/api/v2/github/konflux-ci/repos/api/commits/?branch=main&page=2", "results": [
+						{"commitid": "c3", "timestamp": "2025-03-01T10:00:00Z", "state": "complete", "totals": {"coverage": 72.25}},
+						{"commitid": "c2", "timestamp": "2025-02-01T10:00:00Z", "state": "pending", "totals": null}
+					]}`, server.URL)
+					return
+				}
+				fmt.Fprintf(w, `{"next": "%s/api/v2/github/konflux-ci/repos/api/commits/?branch=main&page=3", "results": [
+					{"commitid": "c1", "timestamp": "2025-01-01T10:00:00Z", "state": "complete", "totals": {"coverage": 65}},
+					{"commitid": "c0", "timestamp": "2024-12-01T10:00:00Z", "state": "complete", "totals": {"coverage": 60}}
+				]}`, server.URL)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		DeferCleanup(server.Close)
+		client = codecov.NewClient(server.Client(), server.URL, "secret")
+	})
+
+	It("should read the default branch of a repository", func() {
+		branch, err := client.DefaultBranch(context.Background(), "konflux-ci/api")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(branch).To(Equal("main"))
+		Expect(authorization).To(Equal("Bearer secret"))
+	})
+
+	It("should read the latest coverage of a repository, or none for unknown repositories", func() {
+		coverage, err := client.Coverage(context.Background(), "konflux-ci/api")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*coverage).To(Equal(71.5))
+
+		coverage, err = client.Coverage(context.Background(), "konflux-ci/unknown")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(coverage).To(BeNil())
+	})
+
+	It("should page through the processed commits since a date, oldest first", func() {
+		commits, err := client.Commits(context.Background(), "konflux-ci/api", "main", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(commits).To(Equal([]codecov.Commit{
+			{Commit: "c1", Date: time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC), Coverage: 65},
+			{Commit: "c3", Date: time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC), Coverage: 72.25},
+		}))
+		// Paging stops once commits older than the date show up
+		Expect(pages).To(Equal([]string{"", "2"}))
+	})
+
+	It("should report API errors", func() {
+		_, err := client.Commits(context.Background(), "konflux-ci/unknown", "main", time.Time{})
+		Expect(err).To(MatchError(ContainSubstring("failed to read Codecov commits of konflux-ci/unknown")))
+	})
+})
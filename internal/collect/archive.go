@@ -0,0 +1,158 @@
+package collect
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// ReportArchiveFile lists the archived reports of a repository, published next to its latest report
+	ReportArchiveFile = "reports.json"
+	// archiveDir holds the commit-stamped copies of a repository's reports
+	archiveDir = "commits"
+)
+
+// dashboardFromReport is the path from a repository's latest report back to the dashboard
+const dashboardFromReport = "../../../index.html"
+
+// archivedFiles are the files of the latest report copied into its commit-stamped directory
+var archivedFiles = []string{"index.html", UncoveredFile, uncoveredMarkdownFile, VulnerablePathsFile, ProfileFile, RawProfileFile}
+
+// ReportRetention decides how long commit-stamped reports are kept
+type ReportRetention struct {
+	// MaxAge prunes reports archived longer ago than it; zero keeps every report
+	MaxAge time.Duration
+	// Keep is the number of most recent reports kept regardless of their age, at least one
+	Keep int
+}
+
+// ArchivedReport is an immutable copy of the report of one commit
+type ArchivedReport struct {
+	Commit   string    `json:"commit"`
+	Date     time.Time `json:"date"`
+	Coverage *float64  `json:"coverage"`
+	// URL is the report's directory, relative to the repository's latest report
+	URL string `json:"url"`
+	// Profiles are the URLs of the coverage profiles archived with the report, relative to the repository's
+	// latest report, by kind
+	Profiles map[string]string `json:"profiles,omitempty"`
+}
+
+// ReportArchive lists the archived reports of a repository, newest first
+type ReportArchive struct {
+	Repo    string           `json:"repo"`
+	Reports []ArchivedReport `json:"reports"`
+}
+
+// ArchiveReport copies the latest report of a repository into a directory stamped with its commit, so that
+// links to it keep working after later runs, and prunes the reports outside the retention. A commit that is
+// already archived is left untouched. Returns the commits of the pruned reports
+func ArchiveReport(reportsDir string, result Result, at time.Time, retention ReportRetention) ([]string, error) {
+	if result.Commit == "" {
+		return nil, fmt.Errorf("no commit to archive the report of %s under", result.Repo)
+	}
+	repoDir := filepath.Join(reportsDir, result.Repo)
+	archive, err := loadReportArchive(repoDir, result.Repo)
+	if err != nil {
+		return nil, err
+	}
+
+	if !archive.has(result.Commit) {
+		targetDir := filepath.Join(repoDir, archiveDir, result.Commit)
+		if err := copyReport(repoDir, targetDir); err != nil {
+			return nil, err
+		}
+		url := archiveDir + "/" + result.Commit + "/"
+		archive.Reports = append([]ArchivedReport{{
+			Commit:   result.Commit,
+			Date:     at,
+			Coverage: result.Coverage,
+			URL:      url,
+			Profiles: archivedProfiles(targetDir, url),
+		}}, archive.Reports...)
+	}
+
+	pruned := archive.prune(at, retention)
+	for _, commit := range pruned {
+		if err := os.RemoveAll(filepath.Join(repoDir, archiveDir, commit)); err != nil {
+			return nil, fmt.Errorf("failed to prune report of %s: %w", commit, err)
+		}
+	}
+	return pruned, writeJSON(filepath.Join(repoDir, ReportArchiveFile), archive)
+}
+
+// loadReportArchive reads the archive of a repository, empty when it has none yet
+func loadReportArchive(repoDir, repo string) (*ReportArchive, error) {
+	archive := &ReportArchive{Repo: repo, Reports: []ArchivedReport{}}
+	path := filepath.Join(repoDir, ReportArchiveFile)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return archive, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, archive); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return archive, nil
+}
+
+// has reports whether the report of a commit is archived
+func (a *ReportArchive) has(commit string) bool {
+	for _, report := range a.Reports {
+		if report.Commit == commit {
+			return true
+		}
+	}
+	return false
+}
+
+// prune drops the reports archived before the retention's maximum age, beyond the most recent ones it keeps
+func (a *ReportArchive) prune(now time.Time, retention ReportRetention) []string {
+	if retention.MaxAge <= 0 {
+		return nil
+	}
+	cutoff := now.Add(-retention.MaxAge)
+	keep := max(retention.Keep, 1)
+
+	var kept []ArchivedReport
+	var pruned []string
+	for i, report := range a.Reports {
+		if i >= keep && report.Date.Before(cutoff) {
+			pruned = append(pruned, report.Commit)
+			continue
+		}
+		kept = append(kept, report)
+	}
+	a.Reports = kept
+	return pruned
+}
+
+// copyReport copies the files of the latest report into targetDir, pointing its dashboard links two levels further up
+func copyReport(repoDir, targetDir string) error {
+	if err := os.MkdirAll(targetDir, 0755); err != nil {
+		return fmt.Errorf("failed to create archive directory: %w", err)
+	}
+	for _, name := range archivedFiles {
+		data, err := os.ReadFile(filepath.Join(repoDir, name))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		if name == "index.html" {
+			data = []byte(strings.ReplaceAll(string(data), `href="`+dashboardFromReport, `href="../../`+dashboardFromReport))
+		}
+		if err := os.WriteFile(filepath.Join(targetDir, name), data, 0644); err != nil {
+			return fmt.Errorf("failed to archive %s: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package collect_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/collect"
+)
+
+var _ = Describe("ArchiveReport", func() {
+	var (
+		reportsDir string
+		repoDir    string
+		now        time.Time
+	)
+
+	writeLatest := func(html string) {
+		Expect(os.WriteFile(filepath.Join(repoDir, "index.html"), []byte(html), 0644)).To(Succeed())
+	}
+	result := func(commit string, coverage float64) collect.Result {
+		return collect.Result{Repo: "konflux-ci/alpha", Commit: commit, Coverage: &coverage}
+	}
+	readArchive := func() collect.ReportArchive {
+		data, err := os.ReadFile(filepath.Join(repoDir, collect.ReportArchiveFile))
+		Expect(err).NotTo(HaveOccurred())
+		var archive collect.ReportArchive
+		Expect(json.Unmarshal(data, &archive)).To(Succeed())
+		return archive
+	}
+
+	BeforeEach(func() {
+		reportsDir = GinkgoT().TempDir()
+		repoDir = filepath.Join(reportsDir, "konflux-ci/alpha")
+		Expect(os.MkdirAll(repoDir, 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(repoDir, collect.UncoveredFile), []byte("{}\n"), 0644)).To(Succeed())
+		now = time.Date(2025, 1, 15, 6, 0, 0, 0, time.UTC)
+	})
+
+	It("should copy the latest report under its commit, with dashboard links from the deeper directory", func() {
+		writeLatest(`<a href="../../../index.html?owner=konflux-ci/vanguard">@konflux-ci/vanguard</a>`)
+
+		pruned, err := collect.ArchiveReport(reportsDir, result("abc1234", 42), now, collect.ReportRetention{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pruned).To(BeEmpty())
+
+		html, err := os.ReadFile(filepath.Join(repoDir, "commits/abc1234/index.html"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(html)).To(Equal(`<a href="../../../../../index.html?owner=konflux-ci/vanguard">@konflux-ci/vanguard</a>`))
+		Expect(filepath.Join(repoDir, "commits/abc1234", collect.UncoveredFile)).To(BeAnExistingFile())
+
+		archive := readArchive()
+		Expect(archive.Repo).To(Equal("konflux-ci/alpha"))
+		Expect(archive.Reports).To(HaveLen(1))
+		Expect(archive.Reports[0].URL).To(Equal("commits/abc1234/"))
+		Expect(*archive.Reports[0].Coverage).To(Equal(42.0))
+	})
+
+	It("should not overwrite the report of an archived commit", func() {
+		writeLatest("first")
+		_, err := collect.ArchiveReport(reportsDir, result("abc1234", 42), now, collect.ReportRetention{})
+		Expect(err).NotTo(HaveOccurred())
+
+		writeLatest("second")
+		_, err = collect.ArchiveReport(reportsDir, result("abc1234", 43), now.Add(time.Hour), collect.ReportRetention{})
+		Expect(err).NotTo(HaveOccurred())
+
+		html, err := os.ReadFile(filepath.Join(repoDir, "commits/abc1234/index.html"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(html)).To(Equal("first"))
+		Expect(readArchive().Reports).To(HaveLen(1))
+		Expect(*readArchive().Reports[0].Coverage).To(Equal(42.0))
+	})
+
+	It("should prune reports past the retention, keeping the most recent ones", func() {
+		writeLatest("report")
+		retention := collect.ReportRetention{MaxAge: 30 * 24 * time.Hour, Keep: 3}
+		for i, commit := range []string{"c1", "c2", "c3", "c4"} {
+			_, err := collect.ArchiveReport(reportsDir, result(commit, 40), now.AddDate(0, 0, -60+i), collect.ReportRetention{})
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		pruned, err := collect.ArchiveReport(reportsDir, result("c5", 41), now, retention)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pruned).To(Equal([]string{"c2", "c1"}))
+
+		var commits []string
+		for _, report := range readArchive().Reports {
+			commits = append(commits, report.Commit)
+		}
+		Expect(commits).To(Equal([]string{"c5", "c4", "c3"}))
+		Expect(filepath.Join(repoDir, "commits/c1")).NotTo(BeADirectory())
+		Expect(filepath.Join(repoDir, "commits/c3")).To(BeADirectory())
+	})
+
+	It("should require a commit", func() {
+		_, err := collect.ArchiveReport(reportsDir, result("", 40), now, collect.ReportRetention{})
+		Expect(err).To(MatchError(ContainSubstring("no commit")))
+	})
+})
+
+var _ = Describe("DeleteOffboarded", func() {
+	It("should delete the reports and imported history of the repository only", func() {
+		reportsDir, snapshotDir := GinkgoT().TempDir(), GinkgoT().TempDir()
+		for _, dir := range []string{"konflux-ci/alpha/archive/abc", "konflux-ci/bravo"} {
+			Expect(os.MkdirAll(filepath.Join(reportsDir, dir), 0755)).To(Succeed())
+		}
+		imported := filepath.Join(snapshotDir, "imported", "konflux-ci", "alpha.json")
+		Expect(os.MkdirAll(filepath.Dir(imported), 0755)).To(Succeed())
+		Expect(os.WriteFile(imported, []byte("{}\n"), 0644)).To(Succeed())
+
+		deleted, err := collect.DeleteOffboarded(reportsDir, snapshotDir, "konflux-ci/alpha")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(deleted).To(BeTrue())
+		Expect(filepath.Join(reportsDir, "konflux-ci/alpha")).NotTo(BeADirectory())
+		Expect(filepath.Join(reportsDir, "konflux-ci/bravo")).To(BeADirectory())
+		Expect(imported).NotTo(BeAnExistingFile())
+
+		deleted, err = collect.DeleteOffboarded(reportsDir, snapshotDir, "konflux-ci/alpha")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(deleted).To(BeFalse())
+
+		_, err = collect.DeleteOffboarded(reportsDir, snapshotDir, "../alpha")
+		Expect(err).To(MatchError(ContainSubstring("not in org/repo form")))
+	})
+})
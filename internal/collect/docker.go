@@ -0,0 +1,56 @@
+package collect
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+// dockerImports are the libraries tests start containers with, by import path prefix
+var dockerImports = []string{
+	"github.com/testcontainers/testcontainers-go",
+	"github.com/ory/dockertest",
+	"github.com/docker/docker/client",
+}
+
+// dockerPackages returns the packages among pkgs of the module in dir whose tests depend on a library starting
+// containers, directly or through test helpers, sorted
+func dockerPackages(ctx context.Context, dir string, pkgs []string) []string {
+	if len(pkgs) == 0 {
+		return nil
+	}
+	// Test variants of packages, and the dependencies recompiled for them, name the package under test in ForTest
+	args := append([]string{"-e", "-test", "-f", "{{.ForTest}}:{{join .Deps \" \"}}"}, pkgs...)
+	found := make(map[string]bool)
+	for _, line := range goList(ctx, dir, args...) {
+		forTest, deps, _ := strings.Cut(line, ":")
+		if forTest == "" || found[forTest] {
+			continue
+		}
+		for _, dep := range strings.Fields(deps) {
+			if startsContainers(dep) {
+				found[forTest] = true
+				break
+			}
+		}
+	}
+
+	var docker []string
+	for _, pkg := range pkgs {
+		if found[pkg] {
+			docker = append(docker, pkg)
+		}
+	}
+	sort.Strings(docker)
+	return docker
+}
+
+// startsContainers reports whether an import path belongs to a library starting containers
+func startsContainers(importPath string) bool {
+	for _, prefix := range dockerImports {
+		if importPath == prefix || strings.HasPrefix(importPath, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
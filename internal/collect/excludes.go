@@ -0,0 +1,78 @@
+package collect
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// BuildExcludePattern converts exclude_dirs entries into a single regular expression
+// matched against package import paths:
+//   - entries starting with "/" are already regex patterns and are used as-is
+//   - entries ending with "/" match that directory as a path component
+//   - other entries containing "/" match the literal path
+//   - simple names match as a path component
+func BuildExcludePattern(dirs []string) string {
+	var patterns []string
+	for _, dir := range dirs {
+		var pattern string
+		switch {
+		case !strings.Contains(dir, "/"):
+			pattern = "/" + dir + "(/|$)"
+		case strings.HasPrefix(dir, "/"):
+			pattern = dir
+		case strings.HasSuffix(dir, "/"):
+			pattern = "/" + strings.TrimSuffix(dir, "/") + "(/|$)"
+		default:
+			pattern = "/" + regexp.QuoteMeta(dir)
+		}
+		patterns = append(patterns, pattern)
+	}
+	return strings.Join(patterns, "|")
+}
+
+// FilterPackages removes packages matching the exclude_dirs patterns
+func FilterPackages(packages, excludeDirs []string) ([]string, error) {
+	pattern := BuildExcludePattern(excludeDirs)
+	if pattern == "" {
+		return packages, nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exclude_dirs pattern %q: %w", pattern, err)
+	}
+
+	var included []string
+	for _, pkg := range packages {
+		if !re.MatchString(pkg) {
+			included = append(included, pkg)
+		}
+	}
+	return included, nil
+}
+
+// CompileFileExcludes converts exclude_files globs into regular expressions
+// "*" matches any sequence of characters, e.g. "*.pb.go" matches "api/v1/types.pb.go"
+func CompileFileExcludes(globs []string) ([]*regexp.Regexp, error) {
+	var patterns []*regexp.Regexp
+	for _, glob := range globs {
+		expr := strings.ReplaceAll(regexp.QuoteMeta(glob), `\*`, ".*")
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exclude_files pattern %q: %w", glob, err)
+		}
+		patterns = append(patterns, re)
+	}
+	return patterns, nil
+}
+
+// matchesAny reports whether the file name matches any of the exclude patterns
+func matchesAny(fileName string, patterns []*regexp.Regexp) bool {
+	for _, re := range patterns {
+		if re.MatchString(fileName) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package collect_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/collect"
+)
+
+var _ = Describe("Excludes", func() {
+	Describe("BuildExcludePattern", func() {
+		It("should convert each pattern type like the original workflow", func() {
+			pattern := collect.BuildExcludePattern([]string{"vendor/", "test", "/fake(/|$)", "pkg/gen.v1"})
+			Expect(pattern).To(Equal(`/vendor(/|$)|/test(/|$)|/fake(/|$)|/pkg/gen\.v1`))
+		})
+
+		It("should return an empty pattern when there are no excludes", func() {
+			Expect(collect.BuildExcludePattern(nil)).To(BeEmpty())
+		})
+	})
+
+	Describe("FilterPackages", func() {
+		packages := []string{
+			"github.com/konflux-ci/caching",
+			"github.com/konflux-ci/caching/internal/cache",
+			"github.com/konflux-ci/caching/internal/cache/fake",
+			"github.com/konflux-ci/caching/hack/tools",
+			"github.com/konflux-ci/caching/tests/e2e",
+			"github.com/konflux-ci/caching/pkg/mocks",
+			"github.com/konflux-ci/caching/pkg/testing",
+		}
+
+		It("should drop packages matching directory and regex excludes", func() {
+			included, err := collect.FilterPackages(packages, []string{"hack/", "tests/", "/fake(/|$)", "/mock(s)?(/|$)"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(included).To(Equal([]string{
+				"github.com/konflux-ci/caching",
+				"github.com/konflux-ci/caching/internal/cache",
+				"github.com/konflux-ci/caching/pkg/testing",
+			}))
+		})
+
+		It("should keep all packages when there are no excludes", func() {
+			included, err := collect.FilterPackages(packages, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(included).To(Equal(packages))
+		})
+
+		It("should reject invalid regex patterns", func() {
+			_, err := collect.FilterPackages(packages, []string{"/broken(("})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("CompileFileExcludes", func() {
+		It("should treat * as a wildcard and dots literally", func() {
+			patterns, err := collect.CompileFileExcludes([]string{"*.pb.go"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(patterns).To(HaveLen(1))
+			Expect(patterns[0].MatchString("github.com/org/repo/api/types.pb.go")).To(BeTrue())
+			Expect(patterns[0].MatchString("github.com/org/repo/api/typesXpbXgo")).To(BeFalse())
+		})
+	})
+})
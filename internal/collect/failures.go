@@ -0,0 +1,205 @@
+package collect
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultFailureAlertAfter is the number of consecutive failed collections after which owners are alerted
+	DefaultFailureAlertAfter = 3
+
+	// maxErrorSummary bounds the last error of a broken collection published on the dashboard
+	maxErrorSummary = 300
+)
+
+// FailureState tracks a repository whose coverage could not be collected in consecutive runs,
+// leaving the dashboard with stale data
+type FailureState struct {
+	Repo        string    `json:"repo"`
+	Since       time.Time `json:"since"`
+	Consecutive int       `json:"consecutive_failures"`
+	Status      string    `json:"status"`
+	LastError   string    `json:"last_error,omitempty"`
+	RunURL      string    `json:"run_url,omitempty"`
+	// Alerted is set once the owners were told about the failures, so that they are told once
+	Alerted bool `json:"alerted,omitempty"`
+	// StaleAlerted is set once the owners were told the repository's data got older than its max age
+	StaleAlerted bool `json:"stale_alerted,omitempty"`
+}
+
+// BrokenCollection is a repository of coverage.json whose coverage could not be collected in its latest runs
+type BrokenCollection struct {
+	Repo        string    `json:"repo"`
+	Owners      []string  `json:"owners"`
+	Status      string    `json:"status"`
+	Consecutive int       `json:"consecutive_failures"`
+	Since       time.Time `json:"since"`
+	LastError   string    `json:"last_error,omitempty"`
+	RunURL      string    `json:"run_url,omitempty"`
+}
+
+// FailureAlert tells the owners of a repository that its collection has been failing, or works again
+type FailureAlert struct {
+	// Event is AlertOpened once the failures reach the alert threshold, AlertEscalated once the repository's
+	// data gets older than its max age, and AlertResolved on the next success
+	Event       string
+	Repo        string
+	ConfigFile  string
+	Owners      []string
+	Groups      []string
+	Consecutive int
+	Since       time.Time
+	Status      string
+	LastError   string
+	RunURL      string
+	// LastCollected and MaxAge are set on AlertEscalated
+	LastCollected *time.Time
+	MaxAge        time.Duration
+}
+
+// FailureNotifier is told about the collection failure alerts raised at the end of a run
+type FailureNotifier interface {
+	NotifyFailure(ctx context.Context, alert FailureAlert) error
+}
+
+// collectionFailed reports whether a run left the repository without coverage
+// Failing tests still measure coverage, so only runs without any count
+func collectionFailed(run RepoRun) bool {
+	return run.Failed() && run.Result.Coverage == nil
+}
+
+// carryFailures copies the failure states of configured repositories from a previous manifest
+func (m *Manifest) carryFailures(previous *Manifest, files []string) {
+	if previous == nil || len(previous.Failures) == 0 {
+		return
+	}
+
+	m.Failures = make(map[string]FailureState)
+	for _, file := range files {
+		if state, ok := previous.Failures[file]; ok {
+			m.Failures[file] = state
+		}
+	}
+}
+
+// evaluateFailures counts the consecutive failed collections of the repositories collected in this run and
+// returns the alerts to notify about: repositories reaching alertAfter failures, and alerted repositories
+// collected again. A zero alertAfter tracks failures without alerting
+func (m *Manifest) evaluateFailures(collected []RepoRun, alertAfter int, now time.Time) []FailureAlert {
+	var alerts []FailureAlert
+	for _, run := range collected {
+		state, failing := m.Failures[run.ConfigFile]
+		if !collectionFailed(run) {
+			if failing {
+				delete(m.Failures, run.ConfigFile)
+				if state.Alerted || state.StaleAlerted {
+					alerts = append(alerts, failureAlert(AlertResolved, run, state))
+				}
+			}
+			continue
+		}
+
+		if !failing {
+			state = FailureState{Repo: run.Result.Repo, Since: now}
+		}
+		state.Consecutive++
+		state.Status = run.Result.Status
+		state.LastError = summarizeError(run.Error)
+		state.RunURL = run.RunURL
+		if alertAfter > 0 && !state.Alerted && state.Consecutive >= alertAfter {
+			state.Alerted = true
+			alerts = append(alerts, failureAlert(AlertOpened, run, state))
+		}
+
+		if m.Failures == nil {
+			m.Failures = make(map[string]FailureState)
+		}
+		m.Failures[run.ConfigFile] = state
+	}
+	return alerts
+}
+
+// failureAlert builds the alert about a repository's failure state
+func failureAlert(event string, run RepoRun, state FailureState) FailureAlert {
+	return FailureAlert{
+		Event:       event,
+		Repo:        run.Result.Repo,
+		ConfigFile:  run.ConfigFile,
+		Owners:      run.Result.Owners,
+		Groups:      run.Result.Groups,
+		Consecutive: state.Consecutive,
+		Since:       state.Since,
+		Status:      state.Status,
+		LastError:   state.LastError,
+		RunURL:      run.RunURL,
+	}
+}
+
+// brokenCollections lists the failing repositories of the manifest, longest failing first
+func (m *Manifest) brokenCollections(results []Result) []BrokenCollection {
+	owners := make(map[string][]string)
+	for _, result := range results {
+		owners[result.Repo] = result.Owners
+	}
+
+	var broken []BrokenCollection
+	for _, state := range m.Failures {
+		broken = append(broken, BrokenCollection{
+			Repo:        state.Repo,
+			Owners:      owners[state.Repo],
+			Status:      state.Status,
+			Consecutive: state.Consecutive,
+			Since:       state.Since,
+			LastError:   state.LastError,
+			RunURL:      state.RunURL,
+		})
+	}
+	sort.Slice(broken, func(i, j int) bool {
+		if broken[i].Consecutive != broken[j].Consecutive {
+			return broken[i].Consecutive > broken[j].Consecutive
+		}
+		return broken[i].Repo < broken[j].Repo
+	})
+	return broken
+}
+
+// summarizeError keeps the first line of an error, short enough for the dashboard
+func summarizeError(err string) string {
+	summary, _, _ := strings.Cut(strings.TrimSpace(err), "\n")
+	if len(summary) > maxErrorSummary {
+		summary = strings.ToValidUTF8(summary[:maxErrorSummary], "") + "…"
+	}
+	return summary
+}
+
+// notifyFailures reports the failing repositories of the run and passes the alerts to the configured notifier
+// Notification failures only produce warnings
+func (r *Runner) notifyFailures(ctx context.Context, manifest *Manifest, alerts []FailureAlert) {
+	if len(manifest.Failures) > 0 {
+		fmt.Printf("🧯 %d repositories without fresh coverage:\n", len(manifest.Failures))
+		for _, broken := range manifest.brokenCollections(nil) {
+			fmt.Printf("    → %s: %d consecutive failed runs since %s\n", broken.Repo, broken.Consecutive, broken.Since.Format("2006-01-02"))
+		}
+	}
+
+	for _, alert := range alerts {
+		switch alert.Event {
+		case AlertResolved:
+			fmt.Printf("    ✅ %s collected again after %d failed runs\n", alert.Repo, alert.Consecutive)
+		case AlertEscalated:
+			fmt.Printf("    ⏰ Alerting the owners of %s: data older than %s\n", alert.Repo, alert.MaxAge)
+		default:
+			fmt.Printf("    🚨 Alerting the owners of %s after %d failed runs\n", alert.Repo, alert.Consecutive)
+		}
+		if r.config.FailureNotifier == nil {
+			continue
+		}
+		if err := r.config.FailureNotifier.NotifyFailure(ctx, alert); err != nil {
+			fmt.Printf("    ⚠️  Warning: failed to report failures of %s: %v\n", alert.Repo, err)
+		}
+	}
+}
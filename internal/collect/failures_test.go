@@ -0,0 +1,136 @@
+package collect
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+)
+
+var _ = Describe("Collection failures", func() {
+	var start time.Time
+
+	runWith := func(status, err string) []RepoRun {
+		result := Result{Repo: "konflux-ci/alpha", Status: status, Owners: []string{"@konflux-ci/alpha-team"}}
+		if status == StatusOK || err == "" {
+			coverage := 42.0
+			result.Coverage = &coverage
+		}
+		return []RepoRun{{ConfigFile: "alpha.yaml", Result: result, Error: err, RunURL: "https://example.com/runs/1"}}
+	}
+
+	BeforeEach(func() {
+		start = time.Date(2026, 10, 1, 3, 0, 0, 0, time.UTC)
+	})
+
+	It("should alert once after consecutive failures and resolve on the next collection", func() {
+		var previous *Manifest
+		var events []string
+		runs := [][]RepoRun{
+			runWith(StatusFailed, "clone failed"),
+			runWith(StatusTimeout, "tests timed out\nafter 30m"),
+			runWith(StatusFailed, "go: module not found"),
+			runWith(StatusFailed, "go: module not found"),
+			runWith(StatusOK, ""),
+		}
+		for day, collected := range runs {
+			current := &Manifest{Repos: collected}
+			current.carryFailures(previous, []string{"alpha.yaml"})
+			for _, alert := range current.evaluateFailures(current.Repos, 3, start.AddDate(0, 0, day)) {
+				events = append(events, fmt.Sprintf("%d:%s:%d", day, alert.Event, alert.Consecutive))
+				Expect(alert.Since).To(Equal(start))
+				Expect(alert.Owners).To(Equal([]string{"@konflux-ci/alpha-team"}))
+			}
+			if day == 1 {
+				Expect(current.Failures["alpha.yaml"].LastError).To(Equal("tests timed out"))
+				Expect(current.Failures["alpha.yaml"].Status).To(Equal(StatusTimeout))
+			}
+			previous = current
+		}
+
+		Expect(events).To(Equal([]string{"2:opened:3", "4:resolved:4"}))
+		Expect(previous.Failures).To(BeEmpty())
+	})
+
+	It("should not count failing tests that still measured coverage", func() {
+		manifest := &Manifest{Repos: runWith(StatusFailed, "")}
+		Expect(manifest.evaluateFailures(manifest.Repos, 1, start)).To(BeEmpty())
+		Expect(manifest.Failures).To(BeEmpty())
+	})
+
+	It("should track failures without alerting when alerts are disabled", func() {
+		manifest := &Manifest{Repos: runWith(StatusFailed, "boom")}
+		Expect(manifest.evaluateFailures(manifest.Repos, 0, start)).To(BeEmpty())
+		Expect(manifest.Failures["alpha.yaml"].Consecutive).To(Equal(1))
+	})
+
+	It("should forget the failures of repositories no longer configured", func() {
+		previous := &Manifest{Failures: map[string]FailureState{"gone.yaml": {Repo: "konflux-ci/gone", Consecutive: 5}}}
+		current := &Manifest{}
+		current.carryFailures(previous, []string{"alpha.yaml"})
+		Expect(current.Failures).To(BeEmpty())
+	})
+
+	It("should list broken collections on the dashboard, longest failing first", func() {
+		manifest := &Manifest{Failures: map[string]FailureState{
+			"alpha.yaml": {Repo: "konflux-ci/alpha", Consecutive: 2, LastError: "boom"},
+			"beta.yaml":  {Repo: "konflux-ci/beta", Consecutive: 5},
+		}}
+		manifest.Record(RepoRun{ConfigFile: "alpha.yaml", Result: Result{Repo: "konflux-ci/alpha", Owners: []string{"@alice"}}})
+
+		broken := manifest.Dashboard().Broken
+		Expect(broken).To(HaveLen(2))
+		Expect(broken[0].Repo).To(Equal("konflux-ci/beta"))
+		Expect(broken[1].Owners).To(Equal([]string{"@alice"}))
+		Expect(broken[1].LastError).To(Equal("boom"))
+	})
+
+	It("should keep the error summary short", func() {
+		summary := summarizeError(strings.Repeat("é", maxErrorSummary))
+		Expect(len(summary)).To(BeNumerically("<=", maxErrorSummary+len("…")))
+		Expect(summary).To(HaveSuffix("…"))
+	})
+
+	It("should notify the owners of repositories failing across runs", func() {
+		tempDir := GinkgoT().TempDir()
+		reposDir := filepath.Join(tempDir, "repos")
+		Expect(os.MkdirAll(reposDir, 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(reposDir, "alpha.yaml"), []byte("name: konflux-ci/alpha\n"), 0644)).To(Succeed())
+		previous := &Manifest{Failures: map[string]FailureState{"alpha.yaml": {Repo: "konflux-ci/alpha", Since: start, Consecutive: 2}}}
+		cfg := Config{
+			ReposDir:          reposDir,
+			CodeownersFile:    filepath.Join(tempDir, "CODEOWNERS"),
+			OutputFile:        filepath.Join(tempDir, "coverage.json"),
+			ManifestFile:      filepath.Join(tempDir, "run-manifest.json"),
+			PreviousManifest:  filepath.Join(tempDir, "previous-manifest.json"),
+			FailureAlertAfter: 3,
+		}
+		Expect(writeJSON(cfg.PreviousManifest, previous)).To(Succeed())
+		notifier := &recordingNotifier{}
+		cfg.FailureNotifier = notifier
+
+		runner, err := NewRunner(cfg)
+		Expect(err).NotTo(HaveOccurred())
+		runner.collectRepo = func(_ context.Context, repoCfg config.RepositoryConfig, owners []string) (Result, error) {
+			return newResult(repoCfg.Name, StatusFailed, owners), fmt.Errorf("failed to clone repository")
+		}
+		Expect(runner.Run(context.Background())).To(Succeed())
+
+		Expect(notifier.failures).To(HaveLen(1))
+		Expect(notifier.failures[0].Event).To(Equal(AlertOpened))
+		Expect(notifier.failures[0].Consecutive).To(Equal(3))
+		Expect(notifier.failures[0].Since).To(Equal(start))
+
+		manifest, err := LoadManifest(cfg.ManifestFile)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(manifest.Failures["alpha.yaml"].Alerted).To(BeTrue())
+		Expect(manifest.Failures["alpha.yaml"].LastError).To(ContainSubstring("failed to clone repository"))
+	})
+})
@@ -0,0 +1,100 @@
+package collect
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/semver"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+)
+
+// Freshness counts the direct dependencies of a repository with newer versions on the module proxy, a signal of
+// code health shown next to its coverage
+type Freshness struct {
+	// Direct counts the direct dependencies of the repository's modules
+	Direct int `json:"direct"`
+	// Outdated counts the direct dependencies with a newer version
+	Outdated int `json:"outdated"`
+	// Updates lists the outdated dependencies, by module path; private repositories do not publish them
+	Updates []DependencyUpdate `json:"updates,omitempty"`
+}
+
+// DependencyUpdate is a direct dependency with a newer version than the required one
+type DependencyUpdate struct {
+	Path    string `json:"path"`
+	Version string `json:"version"`
+	Latest  string `json:"latest"`
+}
+
+// listedModule is a module of go list -m -u -json output
+type listedModule struct {
+	Path     string
+	Version  string
+	Main     bool
+	Indirect bool
+	Update   *struct{ Version string }
+	Replace  *struct{ Path, Version string }
+}
+
+// dependencyFreshness asks the module proxy for newer versions of the direct dependencies of a repository's modules
+// Dependencies required by several modules count once, with their oldest requirement
+func dependencyFreshness(ctx context.Context, repoDir string, modules []config.ModuleConfig) (*Freshness, error) {
+	updates := make(map[string]DependencyUpdate)
+	direct := make(map[string]bool)
+	for _, module := range modules {
+		dir := filepath.Join(repoDir, filepath.FromSlash(module.Path))
+		var stdout, stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, "go", "list", "-m", "-u", "-json", "all")
+		cmd.Dir = dir
+		cmd.WaitDelay = commandWaitDelay
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return nil, fmt.Errorf("go list -m -u in %s failed: %w: %s", module.Path, err, strings.TrimSpace(stderr.String()))
+		}
+		if err := parseFreshness(&stdout, direct, updates); err != nil {
+			return nil, err
+		}
+	}
+
+	freshness := &Freshness{Direct: len(direct), Outdated: len(updates)}
+	for _, update := range updates {
+		freshness.Updates = append(freshness.Updates, update)
+	}
+	sort.Slice(freshness.Updates, func(i, j int) bool { return freshness.Updates[i].Path < freshness.Updates[j].Path })
+	return freshness, nil
+}
+
+// parseFreshness adds the direct dependencies of go list -m -u -json output to direct, and the outdated ones to
+// updates. Modules replaced by directories, e.g. the other modules of the repository, have no versions to compare
+func parseFreshness(r io.Reader, direct map[string]bool, updates map[string]DependencyUpdate) error {
+	decoder := json.NewDecoder(r)
+	for {
+		var module listedModule
+		if err := decoder.Decode(&module); errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("failed to parse go list -m output: %w", err)
+		}
+		if module.Main || module.Indirect || (module.Replace != nil && module.Replace.Version == "") {
+			continue
+		}
+		direct[module.Path] = true
+		if module.Update == nil {
+			continue
+		}
+		if known, ok := updates[module.Path]; ok && semver.Compare(known.Version, module.Version) <= 0 {
+			continue
+		}
+		updates[module.Path] = DependencyUpdate{Path: module.Path, Version: module.Version, Latest: module.Update.Version}
+	}
+}
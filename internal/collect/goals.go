@@ -0,0 +1,186 @@
+package collect
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+	"github.com/konflux-ci/coverage-dashboard/internal/locale"
+)
+
+// Statuses of coverage goals
+const (
+	// GoalMet is a milestone whose coverage is reached
+	GoalMet = "met"
+	// GoalOpen is a milestone not reached yet whose last day is not over
+	GoalOpen = "open"
+	// GoalMissed is a milestone not reached by its last day
+	GoalMissed = "missed"
+)
+
+// GoalProgress is the progress of a repository towards one of its coverage milestones
+type GoalProgress struct {
+	Target float64 `json:"target"`
+	// By is the last day of the milestone, as YYYY-MM-DD
+	By     string `json:"by"`
+	Status string `json:"status"`
+	// Progress is the coverage as a percentage of the target, at most 100; nil without coverage
+	Progress *float64 `json:"progress"`
+	// MetAt is when coverage first reached the target, carried across runs
+	MetAt *time.Time `json:"met_at,omitempty"`
+	// MissedAt is when the milestone was first found missed, carried across runs
+	MissedAt *time.Time `json:"missed_at,omitempty"`
+}
+
+// MissedGoal is a milestone a repository did not reach by its last day, listed in the team digest
+type MissedGoal struct {
+	Repo     string    `json:"repo"`
+	Owners   []string  `json:"owners"`
+	Target   float64   `json:"target"`
+	By       string    `json:"by"`
+	Coverage *float64  `json:"coverage"`
+	MissedAt time.Time `json:"missed_at"`
+}
+
+// NewGoalProgress computes the progress of a coverage towards milestones
+// Milestones not reached are missed once their last day is over, until coverage reaches them
+func NewGoalProgress(milestones config.Milestones, coverage *float64, now time.Time) []GoalProgress {
+	var goals []GoalProgress
+	for _, milestone := range milestones {
+		goal := GoalProgress{Target: milestone.Coverage, By: milestone.By.Format("2006-01-02"), Status: GoalOpen}
+		if coverage != nil {
+			progress := round1(min(*coverage/milestone.Coverage*100, 100))
+			goal.Progress = &progress
+		}
+		switch {
+		case coverage != nil && *coverage >= milestone.Coverage:
+			goal.Status = GoalMet
+		case milestone.Due(now):
+			goal.Status = GoalMissed
+		}
+		goals = append(goals, goal)
+	}
+	return goals
+}
+
+// trackGoals sets the progress of a result towards its repository's milestones, keeping when earlier runs found
+// them met or missed: milestones met by their last day stay met, and results without coverage keep the progress
+// of the last run that measured it
+func (m *Manifest) trackGoals(previous *Manifest, file string, milestones config.Milestones, result *Result, now time.Time) {
+	if len(milestones) == 0 {
+		result.Goals = nil
+		return
+	}
+
+	var earlier []GoalProgress
+	for _, manifest := range []*Manifest{m, previous} {
+		if manifest == nil {
+			continue
+		}
+		if run, found := manifest.find(file); found && run.Result.Goals != nil {
+			earlier = run.Result.Goals
+			break
+		}
+	}
+
+	goals := NewGoalProgress(milestones, result.Coverage, now)
+	for i, milestone := range milestones {
+		goal := &goals[i]
+		for _, before := range earlier {
+			if before.Target != goal.Target || before.By != goal.By {
+				continue
+			}
+			goal.MetAt, goal.MissedAt = before.MetAt, before.MissedAt
+			if result.Coverage == nil {
+				goal.Progress = before.Progress
+			}
+		}
+		if goal.Status != GoalMet && goal.MetAt != nil && !milestone.Due(*goal.MetAt) {
+			goal.Status = GoalMet
+		}
+		switch {
+		case goal.Status == GoalMet && goal.MetAt == nil:
+			goal.MetAt = &now
+		case goal.Status == GoalMissed && goal.MissedAt == nil:
+			goal.MissedAt = &now
+		}
+	}
+	result.Goals = goals
+}
+
+// newlyMissedGoals returns the milestones the collected runs were the first to find missed, since the run started,
+// so each missed milestone is alerted on once
+func newlyMissedGoals(collected []RepoRun, started time.Time) []MissedGoal {
+	var missed []MissedGoal
+	for _, run := range collected {
+		for _, goal := range run.Result.Goals {
+			if goal.Status == GoalMissed && goal.MissedAt != nil && !goal.MissedAt.Before(started) {
+				missed = append(missed, missedGoal(run.Result, goal))
+			}
+		}
+	}
+	return missed
+}
+
+// reportMissedGoals prints the milestones missed in this run, for the owners to find in the run's output
+// and, with every missed milestone, in the team digest of the velocity report
+func reportMissedGoals(missed []MissedGoal) {
+	if len(missed) == 0 {
+		return
+	}
+	fmt.Printf("🎯 %d coverage milestones missed in this run:\n", len(missed))
+	for _, goal := range missed {
+		fmt.Printf("    → %s (owners: %s)\n", goal.Summary(), strings.Join(goal.Owners, ", "))
+	}
+}
+
+// MissedGoals lists the missed milestones of results, by repository
+func MissedGoals(results []Result) []MissedGoal {
+	missed := []MissedGoal{}
+	for _, result := range results {
+		for _, goal := range result.Goals {
+			if goal.Status == GoalMissed {
+				missed = append(missed, missedGoal(result, goal))
+			}
+		}
+	}
+	sort.SliceStable(missed, func(i, j int) bool { return missed[i].Repo < missed[j].Repo })
+	return missed
+}
+
+// missedGoal describes a missed milestone of a result
+func missedGoal(result Result, goal GoalProgress) MissedGoal {
+	missed := MissedGoal{Repo: result.Repo, Owners: result.Owners, Target: goal.Target, By: goal.By, Coverage: result.Coverage}
+	if goal.MissedAt != nil {
+		missed.MissedAt = *goal.MissedAt
+	}
+	return missed
+}
+
+// Summary describes the missed milestone in one line
+func (g MissedGoal) Summary() string {
+	return fmt.Sprintf("%s missed its goal of %.1f%% by %s, at %s", g.Repo, g.Target, g.By, formatCoverage(g.Coverage))
+}
+
+// addGoalProgress adds a progress bar per milestone to a report's header, next to the owners
+func addGoalProgress(report string, goals []GoalProgress, loc locale.Locale) string {
+	if len(goals) == 0 {
+		return report
+	}
+
+	var bars strings.Builder
+	for _, goal := range goals {
+		progress := 0.0
+		if goal.Progress != nil {
+			progress = *goal.Progress
+		}
+		by, _ := time.Parse("2006-01-02", goal.By)
+		label := fmt.Sprintf("Goal %s by %s: %s", loc.Percent(goal.Target), loc.Date(by), goal.Status)
+		fmt.Fprintf(&bars, `<label class="goal goal-%s">🎯 %s <progress max="100" value="%.1f">%s</progress></label>`,
+			goal.Status, label, progress, loc.Percent(progress))
+	}
+	header := fmt.Sprintf(`<div id="goals" style="float: right; margin: 12px 10px 0 0;">%s</div>`, bars.String())
+	return strings.Replace(report, `<div id="legend">`, header+`<div id="legend">`, 1)
+}
@@ -0,0 +1,87 @@
+package collect
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+	"github.com/konflux-ci/coverage-dashboard/internal/locale"
+)
+
+var _ = Describe("Coverage goals", func() {
+	var (
+		milestones config.Milestones
+		start      time.Time
+	)
+
+	BeforeEach(func() {
+		first, err := config.ParseMilestone("60% by 2026-10-31")
+		Expect(err).NotTo(HaveOccurred())
+		second, err := config.ParseMilestone("80% by 2026-12-31")
+		Expect(err).NotTo(HaveOccurred())
+		milestones = config.Milestones{first, second}
+		start = time.Date(2026, 10, 1, 3, 0, 0, 0, time.UTC)
+	})
+
+	It("should compute the progress towards each milestone", func() {
+		coverage := 48.0
+		goals := NewGoalProgress(milestones, &coverage, start)
+		Expect(goals).To(HaveLen(2))
+		Expect(goals[0].By).To(Equal("2026-10-31"))
+		Expect(goals[0].Status).To(Equal(GoalOpen))
+		Expect(*goals[0].Progress).To(Equal(80.0))
+		Expect(*goals[1].Progress).To(Equal(60.0))
+
+		Expect(NewGoalProgress(milestones, nil, start)[0].Progress).To(BeNil())
+		Expect(NewGoalProgress(milestones, &coverage, start.AddDate(0, 1, 0))[0].Status).To(Equal(GoalMissed))
+	})
+
+	It("should keep milestones met by their last day met and report missed ones once", func() {
+		reached := 65.0
+		met := Result{Repo: "konflux-ci/alpha", Status: StatusOK, Coverage: &reached}
+		previous := &Manifest{}
+		previous.trackGoals(nil, "alpha.yaml", milestones, &met, start)
+		previous.Record(RepoRun{ConfigFile: "alpha.yaml", Result: met})
+		Expect(met.Goals[0].Status).To(Equal(GoalMet))
+		Expect(met.Goals[0].MetAt).To(Equal(&start))
+
+		later := start.AddDate(0, 3, 0)
+		dropped := 55.0
+		result := Result{Repo: "konflux-ci/alpha", Status: StatusOK, Coverage: &dropped, Owners: []string{"@konflux-ci/build"}}
+		current := &Manifest{}
+		current.trackGoals(previous, "alpha.yaml", milestones, &result, later)
+		Expect(result.Goals[0].Status).To(Equal(GoalMet))
+		Expect(result.Goals[1].Status).To(Equal(GoalMissed))
+		Expect(result.Goals[1].MissedAt).To(Equal(&later))
+
+		collected := []RepoRun{{ConfigFile: "alpha.yaml", Result: result}}
+		missed := newlyMissedGoals(collected, later)
+		Expect(missed).To(HaveLen(1))
+		Expect(missed[0].Summary()).To(Equal("konflux-ci/alpha missed its goal of 80.0% by 2026-12-31, at 55.0%"))
+		Expect(newlyMissedGoals(collected, later.Add(time.Hour))).To(BeEmpty())
+		Expect(MissedGoals([]Result{result})).To(HaveLen(1))
+	})
+
+	It("should keep the last progress of results without coverage", func() {
+		coverage := 30.0
+		collected := Result{Repo: "konflux-ci/alpha", Status: StatusOK, Coverage: &coverage}
+		previous := &Manifest{}
+		previous.trackGoals(nil, "alpha.yaml", milestones, &collected, start)
+		previous.Record(RepoRun{ConfigFile: "alpha.yaml", Result: collected})
+
+		failed := Result{Repo: "konflux-ci/alpha", Status: StatusFailed}
+		(&Manifest{}).trackGoals(previous, "alpha.yaml", milestones, &failed, start.Add(time.Hour))
+		Expect(*failed.Goals[0].Progress).To(Equal(50.0))
+	})
+
+	It("should add a progress bar per milestone to the report header", func() {
+		coverage := 48.0
+		report := addGoalProgress(`<div id="legend">`, NewGoalProgress(milestones, &coverage, start), locale.Lookup(""))
+		Expect(report).To(ContainSubstring(`<div id="goals"`))
+		Expect(report).To(ContainSubstring(`class="goal goal-open"`))
+		Expect(report).To(ContainSubstring(`<progress max="100" value="80.0">`))
+		Expect(addGoalProgress(`<div id="legend">`, nil, locale.Lookup(""))).To(Equal(`<div id="legend">`))
+	})
+})
@@ -0,0 +1,48 @@
+package collect
+
+import (
+	"fmt"
+	"go/version"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+)
+
+// goDirectives returns the newest go and toolchain directives of the go.mod files of a repository's modules,
+// which decide the oldest Go able to build all of them; modules without a go.mod or a directive are skipped
+func goDirectives(repoDir string, modules []config.ModuleConfig) (goVersion, toolchain string) {
+	for _, module := range modules {
+		path := filepath.Join(repoDir, filepath.FromSlash(module.Path), "go.mod")
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		file, err := modfile.Parse(path, data, nil)
+		if err != nil {
+			fmt.Printf("    ⚠️  Warning: failed to parse %s: %v\n", path, err)
+			continue
+		}
+		if file.Go != nil && version.Compare("go"+file.Go.Version, "go"+goVersion) > 0 {
+			goVersion = file.Go.Version
+		}
+		if file.Toolchain != nil && version.Compare(file.Toolchain.Name, toolchain) > 0 {
+			toolchain = file.Toolchain.Name
+		}
+	}
+	return goVersion, toolchain
+}
+
+// GoSupported reports whether the collector's Go, e.g. "go1.23.4", builds modules declaring goVersion, e.g. "1.22"
+// Newer go directives make the go command download a newer toolchain, or fail when it may not
+// Unknown versions are supported, as nothing says otherwise
+func GoSupported(collector, goVersion string) bool {
+	collector, _, _ = strings.Cut(collector, " ")
+	if goVersion == "" || !version.IsValid(collector) {
+		return true
+	}
+	return version.Compare(collector, "go"+goVersion) >= 0
+}
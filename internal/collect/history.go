@@ -0,0 +1,111 @@
+package collect
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/pr"
+)
+
+// SnapshotHistory reads the summaries of a repository from the history of a data branch checkout
+type SnapshotHistory struct {
+	dir string
+	ref string
+}
+
+// NewSnapshotHistory creates a SnapshotHistory for the git checkout at dir, reading the history of ref
+// ref defaults to HEAD, e.g. origin/data in a checkout of another branch
+func NewSnapshotHistory(dir, ref string) *SnapshotHistory {
+	if ref == "" {
+		ref = "HEAD"
+	}
+	return &SnapshotHistory{dir: dir, ref: ref}
+}
+
+// Summaries returns every committed summary of a repository, newest first, followed by the measurements
+// imported from before its first snapshot
+func (h *SnapshotHistory) Summaries(ctx context.Context, repo string) ([]RepoSummary, error) {
+	file := path.Join(snapshotReposDir, repo+".json")
+	output, err := pr.RunGitCommand(ctx, h.dir, "log", "--format=%H", h.ref, "--", file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history of %s: %w", file, err)
+	}
+
+	var summaries []RepoSummary
+	for _, commit := range strings.Fields(output) {
+		// Commits removing the file, e.g. while the repository was not configured, have nothing to show
+		content, err := pr.RunGitCommand(ctx, h.dir, "show", commit+":"+file)
+		if err != nil {
+			continue
+		}
+		var summary RepoSummary
+		if err := json.Unmarshal([]byte(content), &summary); err != nil {
+			return nil, fmt.Errorf("failed to parse %s at %s: %w", file, commit, err)
+		}
+		summaries = append(summaries, summary)
+	}
+
+	// History imported from before the repository was tracked continues the snapshots
+	var oldest time.Time
+	if len(summaries) > 0 {
+		oldest = summaries[len(summaries)-1].GeneratedAt
+	}
+	imported, err := h.importedSummaries(ctx, repo, oldest)
+	if err != nil {
+		return nil, err
+	}
+	summaries = append(summaries, imported...)
+	if len(summaries) == 0 {
+		return nil, fmt.Errorf("no snapshots of %s in %s", repo, h.ref)
+	}
+	return summaries, nil
+}
+
+// SummaryAt returns the newest successful summary measured at the commit ref points to in repoDir,
+// or at one of its ancestors when that commit was never measured
+// repoDir must be a checkout of the repository with enough history to contain the measured commits
+func SummaryAt(ctx context.Context, summaries []RepoSummary, repoDir, ref string) (*RepoSummary, error) {
+	output, err := pr.RunGitCommand(ctx, repoDir, "rev-parse", "--verify", ref+"^{commit}")
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", ref, err)
+	}
+	commit := strings.TrimSpace(output)
+
+	for i, summary := range summaries {
+		if summary.Status != StatusOK || summary.Coverage == nil || summary.Commit == "" {
+			continue
+		}
+		if summary.Commit == commit {
+			return &summaries[i], nil
+		}
+		// A failing merge-base also means the measured commit is not in the checkout
+		if _, err := pr.RunGitCommand(ctx, repoDir, "merge-base", "--is-ancestor", summary.Commit, commit); err == nil {
+			return &summaries[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no coverage measured at %s (%s) or its ancestors", ref, shortCommit(commit))
+}
+
+// SnapshotComparison is the coverage change of a repository between two summaries
+type SnapshotComparison struct {
+	Base *RepoSummary
+	Head *RepoSummary
+	// Drop is the coverage lost from base to head in percentage points, negative when coverage rose
+	Drop float64
+	// Packages lists the changed packages, largest drop first
+	Packages []PackageChange
+}
+
+// CompareSummaries compares two successful summaries of a repository
+func CompareSummaries(base, head *RepoSummary) SnapshotComparison {
+	return SnapshotComparison{
+		Base:     base,
+		Head:     head,
+		Drop:     round1(*base.Coverage - *head.Coverage),
+		Packages: packageChanges(base.Packages, head.Packages),
+	}
+}
@@ -0,0 +1,156 @@
+package collect_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/collect"
+)
+
+var _ = Describe("SnapshotHistory", func() {
+	var (
+		repoDir string
+		dataDir string
+		commits []string
+		ctx     context.Context
+	)
+
+	git := func(dir string, args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		Expect(err).NotTo(HaveOccurred(), string(out))
+		return strings.TrimSpace(string(out))
+	}
+
+	initRepo := func(dir string) {
+		Expect(os.MkdirAll(dir, 0755)).To(Succeed())
+		git(dir, "init", "--initial-branch", "main")
+		git(dir, "config", "user.name", "test")
+		git(dir, "config", "user.email", "test@example.com")
+	}
+
+	// publish commits a snapshot of the repository measured at commit with the given coverage
+	publish := func(day int, commit string, coverage float64, packages ...collect.PackageCoverage) {
+		dashboard := collect.Dashboard{Data: []collect.Result{
+			{Repo: "konflux-ci/api", Coverage: &coverage, Status: collect.StatusOK, Commit: commit, Packages: packages},
+		}}
+		now := time.Date(2026, 10, day, 5, 0, 0, 0, time.UTC)
+		// Publish pushes to origin, which the history does not need
+		Expect(collect.NewSnapshotPublisher(dataDir).Publish(ctx, dashboard, now)).To(MatchError(ContainSubstring("failed to push")))
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		tempDir := GinkgoT().TempDir()
+		repoDir = filepath.Join(tempDir, "api")
+		dataDir = filepath.Join(tempDir, "data")
+		initRepo(repoDir)
+		initRepo(dataDir)
+
+		commits = nil
+		for _, tag := range []string{"v1.0.0", "", "v1.1.0"} {
+			git(repoDir, "commit", "--allow-empty", "-m", "change")
+			commits = append(commits, git(repoDir, "rev-parse", "HEAD"))
+			if tag != "" {
+				git(repoDir, "tag", tag)
+			}
+		}
+	})
+
+	It("should read the trend of a repository and the latest snapshot", func() {
+		publish(1, commits[0], 70)
+		publish(2, commits[1], 68)
+
+		history := collect.NewSnapshotHistory(dataDir, "")
+		trend, err := history.Trend(ctx, "konflux-ci/api")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(trend).To(Equal([]collect.TrendPoint{
+			{Date: time.Date(2026, 10, 1, 5, 0, 0, 0, time.UTC), Coverage: 70},
+			{Date: time.Date(2026, 10, 2, 5, 0, 0, 0, time.UTC), Coverage: 68},
+		}))
+
+		snapshot, err := history.Snapshot(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(snapshot.GeneratedAt).To(Equal(time.Date(2026, 10, 2, 5, 0, 0, 0, time.UTC)))
+		Expect(*snapshot.Data[0].Coverage).To(Equal(68.0))
+	})
+
+	It("should compare the coverage measured at two tags", func() {
+		publish(1, commits[0], 70, collect.PackageCoverage{Package: "api/a", Coverage: 80}, collect.PackageCoverage{Package: "api/b", Coverage: 60})
+		publish(2, commits[2], 67.5, collect.PackageCoverage{Package: "api/a", Coverage: 80}, collect.PackageCoverage{Package: "api/b", Coverage: 55})
+
+		summaries, err := collect.NewSnapshotHistory(dataDir, "").Summaries(ctx, "konflux-ci/api")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(summaries).To(HaveLen(2))
+
+		base, err := collect.SummaryAt(ctx, summaries, repoDir, "v1.0.0")
+		Expect(err).NotTo(HaveOccurred())
+		head, err := collect.SummaryAt(ctx, summaries, repoDir, "v1.1.0")
+		Expect(err).NotTo(HaveOccurred())
+
+		comparison := collect.CompareSummaries(base, head)
+		Expect(comparison.Drop).To(Equal(2.5))
+		Expect(comparison.Packages).To(HaveLen(1))
+		Expect(comparison.Packages[0].Package).To(Equal("api/b"))
+	})
+
+	It("should fall back to the newest measurement of an ancestor", func() {
+		publish(1, commits[0], 70)
+		publish(2, commits[1], 72)
+
+		summaries, err := collect.NewSnapshotHistory(dataDir, "").Summaries(ctx, "konflux-ci/api")
+		Expect(err).NotTo(HaveOccurred())
+
+		head, err := collect.SummaryAt(ctx, summaries, repoDir, "v1.1.0")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(head.Commit).To(Equal(commits[1]))
+		Expect(*head.Coverage).To(Equal(72.0))
+	})
+
+	It("should fail when no ancestor was measured", func() {
+		publish(1, commits[2], 70)
+
+		summaries, err := collect.NewSnapshotHistory(dataDir, "").Summaries(ctx, "konflux-ci/api")
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = collect.SummaryAt(ctx, summaries, repoDir, "v1.0.0")
+		Expect(err).To(MatchError(ContainSubstring("no coverage measured at v1.0.0")))
+	})
+
+	It("should fail for repositories without snapshots", func() {
+		publish(1, commits[0], 70)
+
+		_, err := collect.NewSnapshotHistory(dataDir, "").Summaries(ctx, "konflux-ci/cli")
+		Expect(err).To(MatchError(ContainSubstring("no snapshots of konflux-ci/cli")))
+	})
+
+	It("should continue the snapshots with the history imported from before them", func() {
+		history := collect.NewImportedHistory("konflux-ci/api", "codecov", []collect.ImportedPoint{
+			{Date: time.Date(2026, 9, 29, 8, 0, 0, 0, time.UTC), Commit: "a", Coverage: 60},
+			{Date: time.Date(2026, 9, 29, 17, 0, 0, 0, time.UTC), Commit: "b", Coverage: 61.04},
+			{Date: time.Date(2026, 9, 30, 8, 0, 0, 0, time.UTC), Commit: "c", Coverage: 64},
+			{Date: time.Date(2026, 10, 2, 8, 0, 0, 0, time.UTC), Commit: "d", Coverage: 99},
+		}, time.Date(2026, 10, 3, 0, 0, 0, 0, time.UTC))
+		Expect(history.Points).To(HaveLen(3))
+		Expect(collect.NewSnapshotPublisher(dataDir).PublishImported(ctx, []collect.ImportedHistory{history}, "Codecov")).To(MatchError(ContainSubstring("failed to push")))
+		Expect(git(dataDir, "log", "-1", "--format=%s")).To(Equal("Import coverage history of 1 repositories from Codecov"))
+		publish(1, commits[0], 70)
+
+		trend, err := collect.NewSnapshotHistory(dataDir, "").Trend(ctx, "konflux-ci/api")
+		Expect(err).NotTo(HaveOccurred())
+		// The import after the first snapshot is left out, the dashboard's own measurement wins
+		Expect(trend).To(Equal([]collect.TrendPoint{
+			{Date: time.Date(2026, 9, 29, 17, 0, 0, 0, time.UTC), Coverage: 61},
+			{Date: time.Date(2026, 9, 30, 8, 0, 0, 0, time.UTC), Coverage: 64},
+			{Date: time.Date(2026, 10, 1, 5, 0, 0, 0, time.UTC), Coverage: 70},
+		}))
+	})
+})
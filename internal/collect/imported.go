@@ -0,0 +1,114 @@
+package collect
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/pr"
+)
+
+// snapshotImportedDir holds the coverage history imported from other services, next to the snapshots
+const snapshotImportedDir = "imported"
+
+// ImportedHistory is the coverage history of a repository measured before it was tracked by the dashboard,
+// committed as imported/{org}/{name}.json to the data branch
+type ImportedHistory struct {
+	SchemaVersion int    `json:"schema_version"`
+	Repo          string `json:"repo"`
+	// Source names the service the history was imported from, e.g. "codecov"
+	Source     string    `json:"source"`
+	ImportedAt time.Time `json:"imported_at"`
+	// Points lists the imported measurements, oldest first
+	Points []ImportedPoint `json:"points"`
+}
+
+// ImportedPoint is a coverage measurement of an imported history
+type ImportedPoint struct {
+	Date     time.Time `json:"date"`
+	Commit   string    `json:"commit,omitempty"`
+	Coverage float64   `json:"coverage"`
+}
+
+// NewImportedHistory builds the imported history of a repository from measurements sorted oldest first,
+// keeping the last measurement of each day like the daily snapshots
+func NewImportedHistory(repo, source string, points []ImportedPoint, now time.Time) ImportedHistory {
+	daily := []ImportedPoint{}
+	for _, point := range points {
+		point.Coverage = round1(point.Coverage)
+		if n := len(daily); n > 0 && daily[n-1].Date.Format("2006-01-02") == point.Date.Format("2006-01-02") {
+			daily[n-1] = point
+			continue
+		}
+		daily = append(daily, point)
+	}
+	return ImportedHistory{SchemaVersion: SnapshotSchemaVersion, Repo: repo, Source: source, ImportedAt: now, Points: daily}
+}
+
+// PublishImported writes imported histories to the checkout, then commits and pushes them
+// Imported files are left alone by Publish, so they are written once per repository
+func (s *SnapshotPublisher) PublishImported(ctx context.Context, histories []ImportedHistory, source string) error {
+	if len(histories) == 0 {
+		return nil
+	}
+	for _, history := range histories {
+		file := filepath.Join(s.dir, snapshotImportedDir, filepath.FromSlash(history.Repo)+".json")
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", history.Repo, err)
+		}
+		if err := writeJSON(file, history); err != nil {
+			return fmt.Errorf("failed to write imported history of %s: %w", history.Repo, err)
+		}
+	}
+
+	if _, err := pr.RunGitCommand(ctx, s.dir, "add", "--all", snapshotImportedDir); err != nil {
+		return fmt.Errorf("failed to stage imported histories: %w", err)
+	}
+	if _, err := pr.RunGitCommand(ctx, s.dir, "diff", "--cached", "--quiet"); err == nil {
+		return nil
+	}
+
+	message := fmt.Sprintf("Import coverage history of %d repositories from %s", len(histories), source)
+	if _, err := pr.RunGitCommand(ctx, s.dir, "commit", "-m", message); err != nil {
+		return fmt.Errorf("failed to commit imported histories: %w", err)
+	}
+	if _, err := pr.RunGitCommand(ctx, s.dir, "push", "origin", "HEAD"); err != nil {
+		return fmt.Errorf("failed to push imported histories: %w", err)
+	}
+	return nil
+}
+
+// importedSummaries returns the imported measurements of a repository older than before as summaries,
+// newest first, or nothing when no history was imported
+func (h *SnapshotHistory) importedSummaries(ctx context.Context, repo string, before time.Time) ([]RepoSummary, error) {
+	file := path.Join(snapshotImportedDir, repo+".json")
+	content, err := pr.RunGitCommand(ctx, h.dir, "show", h.ref+":"+file)
+	if err != nil {
+		// Most repositories have no imported history
+		return nil, nil
+	}
+	var history ImportedHistory
+	if err := json.Unmarshal([]byte(content), &history); err != nil {
+		return nil, fmt.Errorf("failed to parse %s at %s: %w", file, h.ref, err)
+	}
+
+	var summaries []RepoSummary
+	for i := len(history.Points) - 1; i >= 0; i-- {
+		point := history.Points[i]
+		// The dashboard's own measurements win where both exist
+		if !before.IsZero() && !point.Date.Before(before) {
+			continue
+		}
+		coverage := point.Coverage
+		summaries = append(summaries, RepoSummary{
+			SchemaVersion: history.SchemaVersion,
+			GeneratedAt:   point.Date,
+			Result:        Result{Repo: repo, Status: StatusOK, Coverage: &coverage, Commit: point.Commit},
+		})
+	}
+	return summaries, nil
+}
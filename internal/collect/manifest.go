@@ -0,0 +1,288 @@
+package collect
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/features"
+	"github.com/konflux-ci/coverage-dashboard/internal/policy"
+)
+
+// Repository collection statuses, as displayed on the dashboard
+const (
+	StatusOK      = "ok"
+	StatusFailed  = "failed"
+	StatusNoTests = "no_tests"
+	StatusTimeout = "timeout"
+	// StatusUnsupported marks repositories in languages whose coverage is not collected yet
+	StatusUnsupported = "unsupported"
+	// StatusPartial marks repositories collected without the packages whose tests need Docker, listed in Uncollected
+	StatusPartial = "partial"
+)
+
+// PackageCoverage is the statement coverage of a single package
+type PackageCoverage struct {
+	Package  string  `json:"package"`
+	Coverage float64 `json:"coverage"`
+}
+
+// ModuleCoverage is the statement coverage of a module of a repository configured with several
+type ModuleCoverage struct {
+	Path string `json:"path"`
+	// Coverage is nil for modules without statements
+	Coverage   *float64 `json:"coverage"`
+	Status     string   `json:"status"`
+	Statements int      `json:"statements,omitempty"`
+}
+
+// Result is the coverage of a single repository, one entry of coverage.json
+type Result struct {
+	Repo     string            `json:"repo"`
+	Coverage *float64          `json:"coverage"`
+	Status   string            `json:"status"`
+	Packages []PackageCoverage `json:"packages"`
+	Owners   []string          `json:"owners"`
+	// Groups are the names of the groups.yaml groups the repository belongs to
+	Groups []string `json:"groups,omitempty"`
+	// Commit is the SHA the coverage was measured at
+	Commit    string     `json:"commit,omitempty"`
+	Threshold *Threshold `json:"threshold,omitempty"`
+	// LastCollected is when the repository was last collected successfully, carried across failed runs
+	LastCollected *time.Time `json:"last_collected,omitempty"`
+	// Stale is set when LastCollected is older than the repository's max age
+	Stale bool `json:"stale,omitempty"`
+	// Goals is the progress towards the repository's coverage milestones, in date order
+	Goals []GoalProgress `json:"goals,omitempty"`
+	// Statements is the number of statements the coverage was measured over, weighing the repository in the headline
+	Statements int `json:"statements,omitempty"`
+	// Modules is the coverage of each module of repositories configured with modules, in configuration order
+	Modules []ModuleCoverage `json:"modules,omitempty"`
+	// Private is set for repositories that are not public, whose detailed reports are not published
+	Private bool `json:"private,omitempty"`
+	// Uncollected lists the packages of partial collections left out of the coverage, whose tests need Docker
+	Uncollected []string `json:"uncollected,omitempty"`
+	// GoVersion and Toolchain are the newest go and toolchain directives of the repository's go.mod files,
+	// e.g. "1.22" and "go1.22.4", planning upgrades of the organization's Go
+	GoVersion string `json:"go_version,omitempty"`
+	Toolchain string `json:"toolchain,omitempty"`
+	// Freshness counts the direct dependencies with newer versions, when collected with dependency freshness
+	Freshness *Freshness `json:"freshness,omitempty"`
+}
+
+// Dashboard is the coverage.json document consumed by index.html
+type Dashboard struct {
+	RunURL string `json:"run_url"`
+	// InProgress is set on partial publishes; Data then mixes fresh results with the previous run's
+	InProgress bool      `json:"in_progress,omitempty"`
+	Progress   *Progress `json:"progress,omitempty"`
+	Data       []Result  `json:"data"`
+	// Groups aggregates the results of every repository group, sorted by name
+	Groups []GroupSummary `json:"groups,omitempty"`
+	// Broken lists the repositories whose coverage could not be collected in their latest runs, longest failing first
+	Broken []BrokenCollection `json:"broken,omitempty"`
+	// Locale is the BCP 47 tag of the locale the site formats numbers and dates in, e.g. "de-DE"
+	Locale string `json:"locale,omitempty"`
+	// Provenance records how the results of the run were produced
+	Provenance *Provenance `json:"provenance,omitempty"`
+}
+
+// GroupSummary aggregates the results of the repositories of a group
+type GroupSummary struct {
+	Name  string `json:"name"`
+	Repos int    `json:"repos"`
+	// Measured counts the repositories with coverage; Coverage is their average
+	Measured int      `json:"measured"`
+	Coverage *float64 `json:"coverage"`
+	Failed   int      `json:"failed"`
+	// Stale counts the repositories whose data is older than their max age; their coverage is left out of the average
+	Stale int `json:"stale"`
+}
+
+// Progress counts the repositories collected so far in a run
+type Progress struct {
+	Completed int `json:"completed"`
+	Total     int `json:"total"`
+}
+
+// RepoRun records how a repository's result was produced during a run
+type RepoRun struct {
+	ConfigFile string  `json:"config_file"`
+	Result     Result  `json:"result"`
+	Duration   float64 `json:"duration_seconds"`
+	Attempts   int     `json:"attempts"`
+	RunURL     string  `json:"run_url,omitempty"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// Failed reports whether the repository should be re-attempted by --retry-failed
+func (r RepoRun) Failed() bool {
+	return r.Result.Status == StatusFailed || r.Result.Status == StatusTimeout
+}
+
+// Manifest records the outcome of a collection run so that failed repositories
+// can be retried later without re-running the whole set
+type Manifest struct {
+	RunURL     string        `json:"run_url"`
+	StartedAt  time.Time     `json:"started_at"`
+	FinishedAt time.Time     `json:"finished_at"`
+	Plan       []PlannedRepo `json:"plan"`
+	Repos      []RepoRun     `json:"repos"`
+	// Timings holds the recent collection history per config file, carried across runs
+	Timings map[string][]TimingSample `json:"timings,omitempty"`
+	// Trends holds the recent coverage measurements per config file, carried across runs
+	Trends map[string][]TrendPoint `json:"trends,omitempty"`
+	// Regressions lists repositories whose coverage is more than their delta below their baseline
+	Regressions []Regression `json:"regressions,omitempty"`
+	// Alerts holds the open regression alerts per config file, carried across runs
+	Alerts map[string]AlertState `json:"alerts,omitempty"`
+	// Ratchets holds the ratchet thresholds per config file, carried across runs
+	Ratchets map[string]RatchetState `json:"ratchets,omitempty"`
+	// PolicyViolations lists the messages of the policy's Rego gates for this run's results
+	PolicyViolations []policy.Violation `json:"policy_violations,omitempty"`
+	// Failures holds the repositories whose coverage could not be collected in consecutive runs, carried across runs
+	Failures map[string]FailureState `json:"failures,omitempty"`
+	// Locale is the locale of the site published from the run
+	Locale string `json:"locale,omitempty"`
+	// Provenance records the collector, toolchain and configurations of the run
+	Provenance *Provenance `json:"provenance,omitempty"`
+	// Features are the flags the run had, deciding its risky behaviors
+	Features []features.Flag `json:"features,omitempty"`
+}
+
+// LoadDashboard reads a coverage.json document from disk or, for an http(s) URL, from the published site
+func LoadDashboard(ctx context.Context, from string) (*Dashboard, error) {
+	var (
+		data []byte
+		err  error
+	)
+	if strings.HasPrefix(from, "http://") || strings.HasPrefix(from, "https://") {
+		data, err = fetch(ctx, from)
+	} else {
+		data, err = os.ReadFile(from)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", from, err)
+	}
+
+	var dashboard Dashboard
+	if err := json.Unmarshal(data, &dashboard); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", from, err)
+	}
+	return &dashboard, nil
+}
+
+// LoadManifest reads a run manifest from disk
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest %s: %w", path, err)
+	}
+	return &m, nil
+}
+
+// Record adds a repository run to the manifest, replacing any earlier run of the same config
+func (m *Manifest) Record(run RepoRun) {
+	for i, existing := range m.Repos {
+		if existing.ConfigFile == run.ConfigFile {
+			run.Attempts += existing.Attempts
+			m.Repos[i] = run
+			return
+		}
+	}
+	m.Repos = append(m.Repos, run)
+}
+
+// Dashboard builds the coverage.json document from the manifest's results, sorted by repository
+// so runs over the same state produce identical files whatever their schedule
+func (m *Manifest) Dashboard() Dashboard {
+	results := make([]Result, 0, len(m.Repos))
+	for _, run := range m.Repos {
+		results = append(results, run.Result)
+	}
+	sortResults(results)
+	return Dashboard{RunURL: m.RunURL, Data: results, Groups: summarizeGroups(results), Broken: m.brokenCollections(results), Locale: m.Locale, Provenance: m.Provenance}
+}
+
+// progressDashboard builds the coverage.json document of a run still in progress
+// Planned repositories not collected yet keep their result from the previous run
+func (m *Manifest) progressDashboard(previous *Manifest, completed int) Dashboard {
+	dashboard := m.Dashboard()
+	dashboard.InProgress = true
+	dashboard.Progress = &Progress{Completed: completed, Total: len(m.Plan)}
+
+	if previous == nil {
+		return dashboard
+	}
+	for _, planned := range m.Plan {
+		if _, done := m.find(planned.ConfigFile); done {
+			continue
+		}
+		if run, found := previous.find(planned.ConfigFile); found {
+			dashboard.Data = append(dashboard.Data, run.Result)
+		}
+	}
+	sortResults(dashboard.Data)
+	dashboard.Groups = summarizeGroups(dashboard.Data)
+	dashboard.Broken = m.brokenCollections(dashboard.Data)
+	return dashboard
+}
+
+// summarizeGroups aggregates results per group, sorted by group name
+func summarizeGroups(results []Result) []GroupSummary {
+	byName := make(map[string]*GroupSummary)
+	totals := make(map[string]float64)
+	for _, result := range results {
+		for _, name := range result.Groups {
+			summary, ok := byName[name]
+			if !ok {
+				summary = &GroupSummary{Name: name}
+				byName[name] = summary
+			}
+			summary.Repos++
+			if result.Stale {
+				summary.Stale++
+			} else if result.Coverage != nil {
+				summary.Measured++
+				totals[name] += *result.Coverage
+			}
+			if result.Status == StatusFailed || result.Status == StatusTimeout {
+				summary.Failed++
+			}
+		}
+	}
+
+	summaries := make([]GroupSummary, 0, len(byName))
+	for name, summary := range byName {
+		if summary.Measured > 0 {
+			coverage := round1(totals[name] / float64(summary.Measured))
+			summary.Coverage = &coverage
+		}
+		summaries = append(summaries, *summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Name < summaries[j].Name })
+	return summaries
+}
+
+// sortResults orders results by repository
+func sortResults(results []Result) {
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Repo < results[j].Repo })
+}
+
+// writeJSON writes v to path as indented JSON
+func writeJSON(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", path, err)
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
@@ -0,0 +1,189 @@
+package collect
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+)
+
+// moduleProfile is the coverage profile of a module, written in its directory before the profiles of a
+// repository's modules are merged into coverage.out
+const moduleProfile = "coverage_module.out"
+
+// moduleRun is the coverage collected in a module of a repository
+type moduleRun struct {
+	path string
+	dir  string
+	// status is StatusOK, StatusFailed when tests failed, StatusNoTests, or StatusPartial when packages were left out
+	status string
+	// included are the packages measured, after excludes and test helpers
+	included []string
+	stats    map[string]*PackageStats
+	// untested counts the statements of included packages missing from the profile
+	untested int
+	// profile is the module's filtered coverage profile, empty when no tests ran
+	profile string
+	// replay is the module's raw profile, before exclude_files, with the blocks of untested packages
+	replay string
+	// uncollected are the packages left out because their tests need Docker, which the collector lacks
+	uncollected []string
+}
+
+// collectModule runs the tests of a module of the repository cloned in repoDir and measures their coverage
+func (r *Runner) collectModule(ctx context.Context, repoDir string, module config.ModuleConfig, cfg config.RepositoryConfig) (moduleRun, error) {
+	dir := filepath.Join(repoDir, filepath.FromSlash(module.Path))
+	run := moduleRun{path: module.Path, dir: dir, status: StatusOK, replay: filepath.Join(dir, replayProfile)}
+
+	allPackages := goList(ctx, dir, "./...")
+	included, err := FilterPackages(allPackages, module.ExcludeDirs)
+	if err != nil {
+		return run, err
+	}
+	fmt.Printf("    Original packages: %d\n", len(allPackages))
+
+	// Test helpers only serve the tests; counting them would penalize repositories for their test tooling
+	testHelpers := make(map[string]bool)
+	if !cfg.IncludeTestHelpers {
+		for _, pkg := range detectTestHelpers(ctx, dir) {
+			testHelpers[pkg] = true
+		}
+		if withoutHelpers := withoutPackages(included, testHelpers); len(withoutHelpers) < len(included) {
+			fmt.Printf("    Test helper packages: %d (set include_test_helpers to count them)\n", len(included)-len(withoutHelpers))
+			included = withoutHelpers
+		}
+	}
+	fmt.Printf("    Included packages: %d\n", len(included))
+	run.included = included
+
+	if len(included) == 0 {
+		fmt.Println("    ⚠️  No testable packages found")
+		run.status = StatusNoTests
+		return run, nil
+	}
+
+	// Filter out packages with no test files to avoid covdata errors
+	args := append([]string{"-e", "-f", "{{if or .TestGoFiles .XTestGoFiles}}{{.ImportPath}}{{end}}"}, included...)
+	testable := goList(ctx, dir, args...)
+
+	// Tests starting containers cannot pass without Docker, so their packages are left out instead of failing the run
+	var env []string
+	switch {
+	case !cfg.NeedsDocker:
+	case r.config.DockerHost != "":
+		fmt.Printf("    🐳 Running tests against Docker at %s\n", r.config.DockerHost)
+		env = []string{"DOCKER_HOST=" + r.config.DockerHost}
+	default:
+		if docker := dockerPackages(ctx, dir, testable); len(docker) > 0 {
+			fmt.Printf("    🐳 No Docker socket, leaving out %d packages whose tests start containers\n", len(docker))
+			left := make(map[string]bool, len(docker))
+			for _, pkg := range docker {
+				fmt.Printf("      → %s\n", pkg)
+				left[pkg] = true
+			}
+			testable, included = withoutPackages(testable, left), withoutPackages(included, left)
+			run.included, run.uncollected = included, docker
+		}
+	}
+
+	rawProfile := filepath.Join(dir, "coverage_raw.out")
+	if len(testable) == 0 && len(run.uncollected) > 0 {
+		run.status = StatusPartial
+		return run, nil
+	} else if len(testable) == 0 {
+		fmt.Println("    ⚠️  No packages with test files found")
+		run.status = StatusNoTests
+	} else {
+		// Run tests normally (matching CodeCov/Makefile behavior)
+		// Continue even if tests fail - capture partial coverage data
+		fmt.Println("    Running tests with coverage...")
+		testArgs := append([]string{"test", "-coverprofile=" + rawProfile}, testable...)
+		if err := runCommandEnv(ctx, dir, env, "go", testArgs...); err != nil {
+			fmt.Printf("    ⚠️  Tests failed: %v\n", err)
+			fmt.Println("    Continuing with partial coverage data if available...")
+			run.status = StatusFailed
+		} else {
+			fmt.Println("    ✅ Tests passed")
+		}
+	}
+
+	if _, err := os.Stat(rawProfile); err != nil {
+		if run.status != StatusNoTests {
+			run.status = StatusFailed
+			return run, fmt.Errorf("no coverage file generated - tests may not have run properly")
+		}
+		fmt.Println("    ℹ️  No coverage file (no test files found)")
+		return run, nil
+	}
+
+	// Remove excluded files from coverage
+	fileExcludes, err := CompileFileExcludes(module.ExcludeFiles)
+	if err != nil {
+		return run, err
+	}
+	profile := filepath.Join(dir, moduleProfile)
+	if err := FilterProfile(rawProfile, profile, fileExcludes); err != nil {
+		return run, fmt.Errorf("failed to filter coverage profile: %w", err)
+	}
+
+	isTestHelper := func(fileName string) bool { return inPackages(fileName, testHelpers) }
+	run.stats, err = ProfileStats(profile, isTestHelper)
+	if err != nil {
+		return run, err
+	}
+	run.profile = profile
+	if err := filterProfile(rawProfile, run.replay, isTestHelper); err != nil {
+		return run, fmt.Errorf("failed to record raw coverage profile: %w", err)
+	}
+	run.untested = r.countUntestedStatements(ctx, dir, included, run.stats, run.replay)
+	if run.status == StatusOK && len(run.uncollected) > 0 {
+		run.status = StatusPartial
+	}
+	return run, nil
+}
+
+// moduleCoverage summarizes the coverage of each module of a repository
+func moduleCoverage(runs []moduleRun) []ModuleCoverage {
+	modules := make([]ModuleCoverage, 0, len(runs))
+	for _, run := range runs {
+		tested := totalStats(run.stats)
+		module := ModuleCoverage{Path: run.path, Status: run.status, Statements: tested.Total + run.untested}
+		if module.Statements > 0 {
+			coverage := round1(float64(tested.Covered) / float64(module.Statements) * 100)
+			module.Coverage = &coverage
+		}
+		fmt.Printf("    📦 %s coverage: %s (status: %s)\n", run.path, formatCoverage(module.Coverage), run.status)
+		modules = append(modules, module)
+	}
+	return modules
+}
+
+// writeWorkspace writes a go.work using every module of a repository, so that the go commands run at its root,
+// e.g. go tool cover for the report, resolve the packages of all of them
+// Repositories with their own go.work keep it; tests have already run in each module on its own
+func writeWorkspace(ctx context.Context, repoDir string, runs []moduleRun) error {
+	path := filepath.Join(repoDir, "go.work")
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+
+	// A workspace's go version must not be older than any of its modules'
+	version, _, _ := strings.Cut(strings.TrimPrefix(toolchainVersion(ctx), "go"), " ")
+	var work strings.Builder
+	fmt.Fprintf(&work, "go %s\n\nuse (\n", version)
+	for _, run := range runs {
+		use := "./" + run.path
+		if run.path == config.RootModule {
+			use = "."
+		}
+		fmt.Fprintf(&work, "\t%s\n", use)
+	}
+	work.WriteString(")\n")
+	if err := os.WriteFile(path, []byte(work.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write go.work: %w", err)
+	}
+	return nil
+}
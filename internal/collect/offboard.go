@@ -0,0 +1,57 @@
+package collect
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+)
+
+// DeleteOffboarded deletes the coverage data kept of an offboarded repository: its reports, with the archived
+// commit-stamped ones, under reportsDir and its imported history in the snapshotDir checkout of the data branch.
+// Empty directories are skipped. Reports whether anything was deleted
+func DeleteOffboarded(reportsDir, snapshotDir, repo string) (bool, error) {
+	if !config.ValidRepoName(repo) {
+		return false, fmt.Errorf("repository %q is not in org/repo form", repo)
+	}
+	var paths []string
+	if reportsDir != "" {
+		paths = append(paths, filepath.Join(reportsDir, filepath.FromSlash(repo)))
+	}
+	if snapshotDir != "" {
+		paths = append(paths, filepath.Join(snapshotDir, snapshotImportedDir, filepath.FromSlash(repo)+".json"))
+	}
+
+	deleted := false
+	for _, path := range paths {
+		if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err := os.RemoveAll(path); err != nil {
+			return deleted, fmt.Errorf("failed to delete %s: %w", path, err)
+		}
+		deleted = true
+	}
+	return deleted, nil
+}
+
+// deleteOffboarded deletes the data of the offboarded repositories due for deletion, except those configured again
+// Failures only produce warnings
+func (r *Runner) deleteOffboarded(due []config.OffboardedRepo, configured map[string]bool) {
+	for _, repo := range due {
+		if configured[repo.Name] {
+			fmt.Printf("⚠️  Warning: %s is configured again, keeping its data; remove it from %s\n", repo.Name, r.config.OffboardedFile)
+			continue
+		}
+		deleted, err := DeleteOffboarded(r.config.ReportsDir, r.config.SnapshotDir, repo.Name)
+		switch {
+		case err != nil:
+			fmt.Printf("⚠️  Warning: failed to delete the data of offboarded %s: %v\n", repo.Name, err)
+		case deleted:
+			fmt.Printf("🗑️  Deleted the data of %s, offboarded on %s\n", repo.Name, repo.OffboardedAt.Format("2006-01-02"))
+		}
+	}
+}
@@ -0,0 +1,302 @@
+package collect
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+	"github.com/konflux-ci/coverage-dashboard/internal/thirdparty"
+)
+
+// ExcludedItem is a package or file removed by the excludes, with its statement count
+type ExcludedItem struct {
+	Path       string
+	Statements int
+}
+
+// ExcludePreview reports what a repository's excludes remove from its coverage
+type ExcludePreview struct {
+	Repo            string
+	Packages        int
+	Files           int
+	TotalStatements int
+	// ExcludedPackages are removed by exclude_dirs
+	ExcludedPackages []ExcludedItem
+	// TestHelpers are test helper packages, removed unless include_test_helpers is set
+	TestHelpers []ExcludedItem
+	// ExcludedFiles are removed by exclude_files from the remaining packages
+	ExcludedFiles []ExcludedItem
+	// UnusedPatterns are exclude_dirs and exclude_files entries matching nothing
+	UnusedPatterns []string
+	// ThirdPartyTrees are upstream projects embedded in the repository that are still counted, with why they were
+	// detected, e.g. "third_party/kube (LICENSE and module k8s.io/kube)"
+	ThirdPartyTrees []string
+	// countedDirs are the directories of the packages counted
+	countedDirs []string
+}
+
+// ExcludedStatements returns the number of statements removed by the excludes
+func (p *ExcludePreview) ExcludedStatements() int {
+	excluded := 0
+	for _, item := range p.ExcludedPackages {
+		excluded += item.Statements
+	}
+	for _, item := range p.TestHelpers {
+		excluded += item.Statements
+	}
+	for _, item := range p.ExcludedFiles {
+		excluded += item.Statements
+	}
+	return excluded
+}
+
+// PreviewExcludes lists which packages and files of the repository checked out in repoDir the
+// configured excludes would remove. Statements are counted from the source, approximately as
+// "go test -cover" counts them, so no tests need to run
+// Repositories configured with modules are previewed module by module, with the excludes of each
+func PreviewExcludes(ctx context.Context, repoDir string, cfg config.RepositoryConfig) (*ExcludePreview, error) {
+	preview := &ExcludePreview{Repo: cfg.Name}
+	for _, module := range cfg.EffectiveModules() {
+		prefix := ""
+		if len(cfg.Modules) > 0 {
+			prefix = "module " + module.Path + " "
+		}
+		dir := filepath.Join(repoDir, filepath.FromSlash(module.Path))
+		if err := previewModule(ctx, preview, dir, module, cfg.IncludeTestHelpers, prefix); err != nil {
+			return nil, err
+		}
+	}
+	if err := preview.flagThirdParty(repoDir, cfg); err != nil {
+		return nil, err
+	}
+	return preview, nil
+}
+
+// flagThirdParty lists the upstream projects embedded in the repository checked out in repoDir that are configured
+// as modules, or whose packages the excludes keep
+func (p *ExcludePreview) flagThirdParty(repoDir string, cfg config.RepositoryConfig) error {
+	var files []string
+	err := filepath.WalkDir(repoDir, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() && entry.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if !entry.IsDir() {
+			rel, err := filepath.Rel(repoDir, file)
+			if err != nil {
+				return err
+			}
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list the files of %s: %w", repoDir, err)
+	}
+	trees, err := thirdparty.Detect(files, func(name string) ([]byte, error) {
+		return os.ReadFile(filepath.Join(repoDir, filepath.FromSlash(name)))
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, tree := range trees {
+		counted := false
+		for _, module := range cfg.Modules {
+			counted = counted || tree.Contains(module.Path)
+		}
+		for _, dir := range p.countedDirs {
+			rel, err := filepath.Rel(repoDir, dir)
+			counted = counted || (err == nil && tree.Contains(filepath.ToSlash(rel)))
+		}
+		if counted {
+			p.ThirdPartyTrees = append(p.ThirdPartyTrees, fmt.Sprintf("%s (%s)", tree.Dir, tree.Reason()))
+		}
+	}
+	return nil
+}
+
+// previewModule adds what the excludes of a module checked out in dir remove to preview, prefixing its unused
+// patterns with prefix
+func previewModule(ctx context.Context, preview *ExcludePreview, dir string, module config.ModuleConfig, includeTestHelpers bool, prefix string) error {
+	dirPattern := BuildExcludePattern(module.ExcludeDirs)
+	var dirExclude *regexp.Regexp
+	if dirPattern != "" {
+		re, err := regexp.Compile(dirPattern)
+		if err != nil {
+			return fmt.Errorf("invalid exclude_dirs pattern %q: %w", dirPattern, err)
+		}
+		dirExclude = re
+	}
+	fileExcludes, err := CompileFileExcludes(module.ExcludeFiles)
+	if err != nil {
+		return err
+	}
+
+	testHelpers := make(map[string]bool)
+	if !includeTestHelpers {
+		for _, pkg := range detectTestHelpers(ctx, dir) {
+			testHelpers[pkg] = true
+		}
+	}
+
+	matchedDirs := make([]bool, len(module.ExcludeDirs))
+	matchedFiles := make([]bool, len(module.ExcludeFiles))
+
+	packages := 0
+	listing := goList(ctx, dir, "-e", "-f", "{{.ImportPath}}\t{{.Dir}}\t{{join .GoFiles \" \"}}", "./...")
+	for _, line := range listing {
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		pkg, pkgDir, files := fields[0], fields[1], strings.Fields(fields[2])
+		packages++
+		preview.Packages++
+		preview.Files += len(files)
+
+		pkgStatements := 0
+		fileStatements := make(map[string]int, len(files))
+		for _, file := range files {
+			count, err := countFileStatements(filepath.Join(pkgDir, file))
+			if err != nil {
+				return err
+			}
+			fileStatements[file] = count
+			pkgStatements += count
+		}
+		preview.TotalStatements += pkgStatements
+
+		if dirExclude != nil && dirExclude.MatchString(pkg) {
+			preview.ExcludedPackages = append(preview.ExcludedPackages, ExcludedItem{Path: pkg, Statements: pkgStatements})
+			for i, exclude := range module.ExcludeDirs {
+				if re, err := regexp.Compile(BuildExcludePattern([]string{exclude})); err == nil && re.MatchString(pkg) {
+					matchedDirs[i] = true
+				}
+			}
+			continue
+		}
+		if testHelpers[pkg] {
+			preview.TestHelpers = append(preview.TestHelpers, ExcludedItem{Path: pkg, Statements: pkgStatements})
+			continue
+		}
+		preview.countedDirs = append(preview.countedDirs, pkgDir)
+
+		for _, file := range files {
+			// Coverage profiles name files by import path, which is what exclude_files match against
+			name := path.Join(pkg, file)
+			excluded := false
+			for i, re := range fileExcludes {
+				if re.MatchString(name) {
+					matchedFiles[i] = true
+					excluded = true
+				}
+			}
+			if excluded {
+				preview.ExcludedFiles = append(preview.ExcludedFiles, ExcludedItem{Path: name, Statements: fileStatements[file]})
+			}
+		}
+	}
+
+	if packages == 0 {
+		return fmt.Errorf("no Go packages found in %s", dir)
+	}
+
+	for i, matched := range matchedDirs {
+		if !matched {
+			preview.UnusedPatterns = append(preview.UnusedPatterns, prefix+"exclude_dirs: "+module.ExcludeDirs[i])
+		}
+	}
+	for i, matched := range matchedFiles {
+		if !matched {
+			preview.UnusedPatterns = append(preview.UnusedPatterns, prefix+"exclude_files: "+module.ExcludeFiles[i])
+		}
+	}
+	return nil
+}
+
+// Print writes the preview to stdout
+func (p *ExcludePreview) Print() {
+	fmt.Printf("🔎 Exclude preview for %s\n", p.Repo)
+	fmt.Printf("   Packages: %d (%d excluded)\n", p.Packages, len(p.ExcludedPackages)+len(p.TestHelpers))
+	fmt.Printf("   Files: %d (%d excluded by exclude_files)\n", p.Files, len(p.ExcludedFiles))
+	fmt.Println()
+
+	if len(p.ExcludedPackages) > 0 {
+		fmt.Println("📦 Packages removed by exclude_dirs:")
+		for _, item := range p.ExcludedPackages {
+			fmt.Printf("   - %s (%d statements)\n", item.Path, item.Statements)
+		}
+		fmt.Println()
+	}
+	if len(p.TestHelpers) > 0 {
+		fmt.Println("🧪 Test helper packages (set include_test_helpers: true to count them):")
+		for _, item := range p.TestHelpers {
+			fmt.Printf("   - %s (%d statements)\n", item.Path, item.Statements)
+		}
+		fmt.Println()
+	}
+	if len(p.ExcludedFiles) > 0 {
+		fmt.Println("📄 Files removed by exclude_files:")
+		for _, item := range p.ExcludedFiles {
+			fmt.Printf("   - %s (%d statements)\n", item.Path, item.Statements)
+		}
+		fmt.Println()
+	}
+	if len(p.UnusedPatterns) > 0 {
+		fmt.Println("⚠️  Patterns matching nothing:")
+		for _, pattern := range p.UnusedPatterns {
+			fmt.Printf("   - %s\n", pattern)
+		}
+		fmt.Println()
+	}
+
+	if len(p.ThirdPartyTrees) > 0 {
+		fmt.Println("⚠️  Embedded third-party trees still counted (add them to exclude_dirs, or remove their modules):")
+		for _, tree := range p.ThirdPartyTrees {
+			fmt.Printf("   - %s\n", tree)
+		}
+		fmt.Println()
+	}
+
+	excluded := p.ExcludedStatements()
+	reduction := 0.0
+	if p.TotalStatements > 0 {
+		reduction = float64(excluded) / float64(p.TotalStatements) * 100
+	}
+	fmt.Printf("📉 Statements: %d → %d (-%d, -%.1f%%)\n", p.TotalStatements, p.TotalStatements-excluded, excluded, reduction)
+}
+
+// countFileStatements counts the statements of a Go file the way coverage blocks do:
+// every statement of every block, case and select clause
+func countFileStatements(filename string) (int, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), filename, nil, parser.SkipObjectResolution)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+
+	count := 0
+	ast.Inspect(file, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.BlockStmt:
+			count += len(n.List)
+		case *ast.CaseClause:
+			count += len(n.Body)
+		case *ast.CommClause:
+			count += len(n.Body)
+		}
+		return true
+	})
+	return count, nil
+}
@@ -0,0 +1,102 @@
+package collect_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/collect"
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+)
+
+var _ = Describe("PreviewExcludes", func() {
+	var repoDir string
+
+	writeFile := func(name, content string) {
+		path := filepath.Join(repoDir, name)
+		Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+		Expect(os.WriteFile(path, []byte(content), 0644)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		repoDir = GinkgoT().TempDir()
+		writeFile("go.mod", "module example.com/demo\n\ngo 1.21\n")
+		writeFile("api/types.go", "package api\n\nfunc Add(a, b int) int {\n\tif a > b {\n\t\treturn a + b\n\t}\n\treturn b + a\n}\n")
+		writeFile("api/zz_generated.deepcopy.go", "package api\n\nfunc Copy(a int) int {\n\tb := a\n\treturn b\n}\n")
+		writeFile("hack/tool/main.go", "package main\n\nfunc main() {\n\tprintln(\"tool\")\n}\n")
+	})
+
+	It("should list the packages and files removed by the excludes", func() {
+		cfg := config.RepositoryConfig{
+			Name:         "example/demo",
+			ExcludeDirs:  []string{"hack/", "mocks/"},
+			ExcludeFiles: []string{"zz_generated.deepcopy.go", "*.pb.go"},
+		}
+
+		preview, err := collect.PreviewExcludes(context.Background(), repoDir, cfg)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(preview.Packages).To(Equal(2))
+		Expect(preview.Files).To(Equal(3))
+		// types.go: 2 statements in Add + 1 in the if body; deepcopy: 2; tool: 1
+		Expect(preview.TotalStatements).To(Equal(6))
+		Expect(preview.ExcludedPackages).To(Equal([]collect.ExcludedItem{{Path: "example.com/demo/hack/tool", Statements: 1}}))
+		Expect(preview.ExcludedFiles).To(Equal([]collect.ExcludedItem{{Path: "example.com/demo/api/zz_generated.deepcopy.go", Statements: 2}}))
+		Expect(preview.ExcludedStatements()).To(Equal(3))
+		Expect(preview.UnusedPatterns).To(Equal([]string{"exclude_dirs: mocks/", "exclude_files: *.pb.go"}))
+	})
+
+	It("should remove test helper packages unless they are included", func() {
+		writeFile("internal/testutil/testutil.go", "package testutil\n\nfunc Value() int {\n\treturn 1\n}\n")
+		writeFile("api/types_test.go", "package api\n\nimport (\n\t\"testing\"\n\n\t\"example.com/demo/internal/testutil\"\n)\n\nfunc TestAdd(t *testing.T) {\n\t_ = Add(testutil.Value(), 1)\n}\n")
+
+		preview, err := collect.PreviewExcludes(context.Background(), repoDir, config.RepositoryConfig{Name: "example/demo"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(preview.TestHelpers).To(Equal([]collect.ExcludedItem{{Path: "example.com/demo/internal/testutil", Statements: 1}}))
+
+		preview, err = collect.PreviewExcludes(context.Background(), repoDir, config.RepositoryConfig{Name: "example/demo", IncludeTestHelpers: true})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(preview.TestHelpers).To(BeEmpty())
+	})
+
+	It("should preview every module with its excludes", func() {
+		writeFile("tools/gen/go.mod", "module example.com/demo/tools/gen\n\ngo 1.21\n")
+		writeFile("tools/gen/main.go", "package main\n\nfunc main() {\n\tprintln(\"gen\")\n}\n")
+		writeFile("tools/gen/mocks/mock.go", "package mocks\n\nfunc Mock() int {\n\treturn 1\n}\n")
+
+		preview, err := collect.PreviewExcludes(context.Background(), repoDir, config.RepositoryConfig{
+			Name:        "example/demo",
+			ExcludeDirs: []string{"hack/"},
+			Modules:     []config.ModuleConfig{{Path: "."}, {Path: "tools/gen", ExcludeDirs: []string{"mocks/"}}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(preview.Packages).To(Equal(4))
+		Expect(preview.ExcludedPackages).To(Equal([]collect.ExcludedItem{
+			{Path: "example.com/demo/hack/tool", Statements: 1},
+			{Path: "example.com/demo/tools/gen/mocks", Statements: 1},
+		}))
+		Expect(preview.UnusedPatterns).To(Equal([]string{"module tools/gen exclude_dirs: hack/"}))
+	})
+
+	It("should flag embedded third-party trees the excludes keep", func() {
+		writeFile("third_party/forked/LICENSE", "Apache License\n")
+		writeFile("third_party/forked/fork.go", "package forked\n\nfunc Fork() int {\n\treturn 1\n}\n")
+		writeFile("upstream/lib/LICENSE", "MIT License\n")
+		writeFile("upstream/lib/lib.go", "package lib\n\nfunc Lib() int {\n\treturn 1\n}\n")
+
+		preview, err := collect.PreviewExcludes(context.Background(), repoDir, config.RepositoryConfig{
+			Name:        "example/demo",
+			ExcludeDirs: []string{"hack/", "upstream/"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(preview.ThirdPartyTrees).To(Equal([]string{"third_party/forked (LICENSE outside any module of its own)"}))
+	})
+
+	It("should fail when the checkout has no Go packages", func() {
+		_, err := collect.PreviewExcludes(context.Background(), GinkgoT().TempDir(), config.RepositoryConfig{})
+		Expect(err).To(MatchError(ContainSubstring("no Go packages found")))
+	})
+})
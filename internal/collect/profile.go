@@ -0,0 +1,223 @@
+package collect
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/coverage"
+)
+
+// PackageStats holds the covered and total statement counts of a package
+type PackageStats struct {
+	Covered int
+	Total   int
+}
+
+// Percent returns the statement coverage of the package
+func (s PackageStats) Percent() float64 {
+	if s.Total == 0 {
+		return 0
+	}
+	return float64(s.Covered) / float64(s.Total) * 100
+}
+
+// FilterProfile copies a coverage profile from src to dst, dropping blocks of
+// files matching any of the exclude_files patterns
+func FilterProfile(src, dst string, excludes []*regexp.Regexp) error {
+	return filterProfile(src, dst, func(fileName string) bool { return matchesAny(fileName, excludes) })
+}
+
+// filterProfile copies a coverage profile from src to dst, dropping blocks of files matching drop
+func filterProfile(src, dst string, drop func(fileName string) bool) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	w := bufio.NewWriter(out)
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	first := true
+	for scanner.Scan() {
+		line := scanner.Text()
+		// Always keep the "mode:" header
+		if !first {
+			fileName, _, _ := strings.Cut(line, ":")
+			if drop(fileName) {
+				continue
+			}
+		}
+		first = false
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read %s: %w", src, err)
+	}
+
+	return w.Flush()
+}
+
+// MergeProfiles writes the blocks of coverage profiles of the same mode, e.g. of the modules of a repository,
+// to a single profile at dst
+func MergeProfiles(dst string, srcs []string) error {
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	w := bufio.NewWriter(out)
+	mode := ""
+	for _, src := range srcs {
+		if err := appendProfile(w, src, &mode); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// appendProfile copies the blocks of a profile to w, writing its "mode:" header only when mode is not set yet
+func appendProfile(w *bufio.Writer, src string, mode *string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	first := true
+	for scanner.Scan() {
+		line := scanner.Text()
+		if first && strings.HasPrefix(line, "mode: ") {
+			switch {
+			case *mode == "":
+				*mode = line
+			case line != *mode:
+				return fmt.Errorf("%s has %s, expected %s", src, line, *mode)
+			default:
+				first = false
+				continue
+			}
+		}
+		first = false
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read %s: %w", src, err)
+	}
+	return nil
+}
+
+// appendToProfile appends the blocks of the profile src not matching skip to the profile dst, creating it with
+// the header of src when it does not exist yet
+func appendToProfile(dst, src string, skip func(fileName string) bool) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	header, blocks, _ := strings.Cut(string(data), "\n")
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	info, err := out.Stat()
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(out)
+	if info.Size() == 0 {
+		fmt.Fprintln(w, header)
+	}
+	for _, line := range strings.Split(blocks, "\n") {
+		fileName, _, _ := strings.Cut(line, ":")
+		if line == "" || skip(fileName) {
+			continue
+		}
+		fmt.Fprintln(w, line)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// ProfileStats aggregates statement counts per package from a coverage profile
+// Files matching skip are ignored. The profile is streamed, so merged profiles of any size fit in memory
+func ProfileStats(profilePath string, skip func(fileName string) bool) (map[string]*PackageStats, error) {
+	in, err := os.Open(profilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse coverage profile %s: %w", profilePath, err)
+	}
+	defer in.Close()
+
+	files, err := coverage.Summarize(in, skip)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse coverage profile %s: %w", profilePath, err)
+	}
+
+	stats := make(map[string]*PackageStats)
+	for fileName, counts := range files {
+		pkg := path.Dir(fileName)
+		s, ok := stats[pkg]
+		if !ok {
+			s = &PackageStats{}
+			stats[pkg] = s
+		}
+		s.Covered += counts.Covered
+		s.Total += counts.Total
+	}
+
+	return stats, nil
+}
+
+// totalStats sums the statement counts of all packages
+func totalStats(stats map[string]*PackageStats) PackageStats {
+	var total PackageStats
+	for _, s := range stats {
+		total.Covered += s.Covered
+		total.Total += s.Total
+	}
+	return total
+}
+
+// packageCoverage converts per-package statistics into a sorted package breakdown
+func packageCoverage(stats map[string]*PackageStats) []PackageCoverage {
+	packages := make([]PackageCoverage, 0, len(stats))
+	for pkg, s := range stats {
+		packages = append(packages, PackageCoverage{
+			Package:  pkg,
+			Coverage: round1(s.Percent()),
+		})
+	}
+	sort.Slice(packages, func(i, j int) bool {
+		return packages[i].Package < packages[j].Package
+	})
+	return packages
+}
+
+// round1 rounds a percentage to one decimal place, as displayed on the dashboard
+func round1(v float64) float64 {
+	return math.Round(v*10) / 10
+}
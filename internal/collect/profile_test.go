@@ -0,0 +1,97 @@
+package collect_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/collect"
+)
+
+const sampleProfile = `mode: set
+github.com/org/repo/pkg/a/a.go:3.20,5.2 2 1
+github.com/org/repo/pkg/a/a.go:7.20,9.2 2 0
+github.com/org/repo/pkg/b/b.go:3.20,6.2 3 1
+github.com/org/repo/api/types.pb.go:3.20,20.2 10 0
+`
+
+var _ = Describe("Profile", func() {
+	var (
+		tempDir string
+		rawPath string
+	)
+
+	BeforeEach(func() {
+		tempDir = GinkgoT().TempDir()
+		rawPath = filepath.Join(tempDir, "coverage_raw.out")
+		Expect(os.WriteFile(rawPath, []byte(sampleProfile), 0644)).To(Succeed())
+	})
+
+	Describe("FilterProfile", func() {
+		It("should drop excluded files and keep the mode header", func() {
+			excludes, err := collect.CompileFileExcludes([]string{"*.pb.go"})
+			Expect(err).NotTo(HaveOccurred())
+
+			filtered := filepath.Join(tempDir, "coverage.out")
+			Expect(collect.FilterProfile(rawPath, filtered, excludes)).To(Succeed())
+
+			content, err := os.ReadFile(filtered)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(strings.HasPrefix(string(content), "mode: set\n")).To(BeTrue())
+			Expect(string(content)).NotTo(ContainSubstring("types.pb.go"))
+			Expect(string(content)).To(ContainSubstring("pkg/b/b.go"))
+		})
+	})
+
+	Describe("ProfileStats", func() {
+		It("should aggregate statements per package", func() {
+			stats, err := collect.ProfileStats(rawPath, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stats).To(HaveLen(3))
+			Expect(*stats["github.com/org/repo/pkg/a"]).To(Equal(collect.PackageStats{Covered: 2, Total: 4}))
+			Expect(stats["github.com/org/repo/pkg/a"].Percent()).To(Equal(50.0))
+			Expect(*stats["github.com/org/repo/pkg/b"]).To(Equal(collect.PackageStats{Covered: 3, Total: 3}))
+		})
+
+		It("should skip files rejected by the skip function", func() {
+			stats, err := collect.ProfileStats(rawPath, func(fileName string) bool {
+				return strings.HasSuffix(fileName, ".pb.go")
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stats).NotTo(HaveKey("github.com/org/repo/api"))
+		})
+
+		It("should fail on malformed profiles", func() {
+			Expect(os.WriteFile(rawPath, []byte("mode: set\nnot a profile line\n"), 0644)).To(Succeed())
+			_, err := collect.ProfileStats(rawPath, nil)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("MergeProfiles", func() {
+		It("should keep a single mode header", func() {
+			toolsPath := filepath.Join(tempDir, "tools.out")
+			Expect(os.WriteFile(toolsPath, []byte("mode: set\ngithub.com/org/repo/tools/gen/gen.go:3.20,5.2 4 1\n"), 0644)).To(Succeed())
+
+			merged := filepath.Join(tempDir, "coverage.out")
+			Expect(collect.MergeProfiles(merged, []string{rawPath, toolsPath})).To(Succeed())
+			content, err := os.ReadFile(merged)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(Equal(sampleProfile + "github.com/org/repo/tools/gen/gen.go:3.20,5.2 4 1\n"))
+
+			stats, err := collect.ProfileStats(merged, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stats).To(HaveLen(4))
+		})
+
+		It("should refuse profiles of different modes", func() {
+			atomicPath := filepath.Join(tempDir, "atomic.out")
+			Expect(os.WriteFile(atomicPath, []byte("mode: atomic\n"), 0644)).To(Succeed())
+			err := collect.MergeProfiles(filepath.Join(tempDir, "coverage.out"), []string{rawPath, atomicPath})
+			Expect(err).To(MatchError(ContainSubstring("has mode: atomic, expected mode: set")))
+		})
+	})
+})
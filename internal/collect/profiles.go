@@ -0,0 +1,140 @@
+package collect
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	// ProfileFile is the coverage profile the coverage of a repository is computed from, after its excludes,
+	// published next to its report
+	ProfileFile = "coverage.out"
+	// RawProfileFile is the coverage profile of a repository before its exclude_files, with the blocks of its
+	// untested packages, published next to its report so that changes to the excludes can be replayed against it
+	RawProfileFile = "coverage.raw.out"
+)
+
+// Kinds of published coverage profiles
+const (
+	// ProfileFiltered is the profile of ProfileFile
+	ProfileFiltered = "filtered"
+	// ProfileRaw is the profile of RawProfileFile
+	ProfileRaw = "raw"
+)
+
+// profileFiles are the published files of the kinds of profiles
+var profileFiles = map[string]string{
+	ProfileFiltered: ProfileFile,
+	ProfileRaw:      RawProfileFile,
+}
+
+// ProfileKinds lists the kinds of published profiles
+func ProfileKinds() []string {
+	kinds := make([]string, 0, len(profileFiles))
+	for kind := range profileFiles {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// publishProfiles copies the profiles of a repository next to its report, removing those of an earlier run
+// the repository has none of anymore
+func publishProfiles(repoDir, targetDir string) error {
+	var errs []error
+	for _, kind := range ProfileKinds() {
+		file := profileFiles[kind]
+		target := filepath.Join(targetDir, file)
+		data, err := os.ReadFile(filepath.Join(repoDir, file))
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			if err := os.Remove(target); err != nil && !errors.Is(err, os.ErrNotExist) {
+				errs = append(errs, err)
+			}
+		case err != nil:
+			errs = append(errs, err)
+		default:
+			if err := os.WriteFile(target, data, 0644); err != nil {
+				errs = append(errs, fmt.Errorf("failed to publish %s: %w", file, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// archivedProfiles lists the profiles archived in a report's directory, by kind, with their URL relative to
+// the repository's latest report
+func archivedProfiles(targetDir, url string) map[string]string {
+	profiles := make(map[string]string)
+	for kind, file := range profileFiles {
+		if _, err := os.Stat(filepath.Join(targetDir, file)); err == nil {
+			profiles[kind] = url + file
+		}
+	}
+	if len(profiles) == 0 {
+		return nil
+	}
+	return profiles
+}
+
+// FetchReportArchive reads the archived reports of a repository from a reports directory or a published site
+func FetchReportArchive(ctx context.Context, from, repo string) (*ReportArchive, error) {
+	data, err := readPublished(ctx, from, repo+"/"+ReportArchiveFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archived reports of %s: %w", repo, err)
+	}
+	var archive ReportArchive
+	if err := json.Unmarshal(data, &archive); err != nil {
+		return nil, fmt.Errorf("failed to parse archived reports of %s: %w", repo, err)
+	}
+	return &archive, nil
+}
+
+// Find returns the archived report of a commit, given in full or as a prefix
+// "latest" or an empty commit finds the newest report
+func (a *ReportArchive) Find(commit string) (ArchivedReport, error) {
+	if len(a.Reports) == 0 {
+		return ArchivedReport{}, fmt.Errorf("%s has no archived reports", a.Repo)
+	}
+	if commit == "" || commit == "latest" {
+		return a.Reports[0], nil
+	}
+
+	var found []ArchivedReport
+	for _, report := range a.Reports {
+		if strings.HasPrefix(report.Commit, commit) {
+			found = append(found, report)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return ArchivedReport{}, fmt.Errorf("no archived report of %s at %s", a.Repo, commit)
+	case 1:
+		return found[0], nil
+	default:
+		return ArchivedReport{}, fmt.Errorf("%s matches %d archived reports of %s, give more of the commit", commit, len(found), a.Repo)
+	}
+}
+
+// LoadProfile reads a profile of a kind archived with a report of a repository, from a reports directory or a
+// published site
+func LoadProfile(ctx context.Context, from, repo string, report ArchivedReport, kind string) ([]byte, error) {
+	if _, ok := profileFiles[kind]; !ok {
+		return nil, fmt.Errorf("profile kind must be one of %s, got %q", strings.Join(ProfileKinds(), ", "), kind)
+	}
+	url, ok := report.Profiles[kind]
+	if !ok {
+		return nil, fmt.Errorf("no %s profile archived with the report of %s at %s", kind, repo, report.Commit)
+	}
+	data, err := readPublished(ctx, from, repo+"/"+url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s profile of %s at %s: %w", kind, repo, report.Commit, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,73 @@
+package collect_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/collect"
+)
+
+var _ = Describe("Profiles", func() {
+	const profile = "mode: set\nexample.com/demo/api/types.go:3.1,5.2 4 1\n"
+
+	var reportsDir string
+
+	archive := func(commit string) collect.ArchivedReport {
+		coverage := 100.0
+		now := time.Date(2025, 1, 15, 6, 0, 0, 0, time.UTC)
+		_, err := collect.ArchiveReport(reportsDir, collect.Result{Repo: "example/demo", Commit: commit, Coverage: &coverage}, now, collect.ReportRetention{})
+		Expect(err).NotTo(HaveOccurred())
+		archive, err := collect.FetchReportArchive(context.Background(), reportsDir, "example/demo")
+		Expect(err).NotTo(HaveOccurred())
+		report, err := archive.Find(commit)
+		Expect(err).NotTo(HaveOccurred())
+		return report
+	}
+
+	BeforeEach(func() {
+		reportsDir = GinkgoT().TempDir()
+		repoDir := filepath.Join(reportsDir, "example/demo")
+		Expect(os.MkdirAll(repoDir, 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(repoDir, "index.html"), []byte("<html></html>"), 0644)).To(Succeed())
+	})
+
+	It("should list the profiles archived with a report and download them by kind", func() {
+		Expect(os.WriteFile(filepath.Join(reportsDir, "example/demo", collect.ProfileFile), []byte(profile), 0644)).To(Succeed())
+
+		report := archive("abc1234")
+		Expect(report.Profiles).To(Equal(map[string]string{collect.ProfileFiltered: "commits/abc1234/coverage.out"}))
+
+		data, err := collect.LoadProfile(context.Background(), reportsDir, "example/demo", report, collect.ProfileFiltered)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal(profile))
+
+		_, err = collect.LoadProfile(context.Background(), reportsDir, "example/demo", report, collect.ProfileRaw)
+		Expect(err).To(MatchError(ContainSubstring("no raw profile archived with the report of example/demo at abc1234")))
+		_, err = collect.LoadProfile(context.Background(), reportsDir, "example/demo", report, "merged")
+		Expect(err).To(MatchError(ContainSubstring("profile kind must be one of filtered, raw")))
+	})
+
+	Describe("Find", func() {
+		archive := &collect.ReportArchive{Repo: "example/demo", Reports: []collect.ArchivedReport{
+			{Commit: "abc1234"}, {Commit: "abd5678"},
+		}}
+
+		It("should find the newest report by default", func() {
+			report, err := archive.Find("latest")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(report.Commit).To(Equal("abc1234"))
+		})
+
+		It("should refuse ambiguous and unknown commits", func() {
+			_, err := archive.Find("ab")
+			Expect(err).To(MatchError(ContainSubstring("matches 2 archived reports")))
+			_, err = archive.Find("fff")
+			Expect(err).To(MatchError(ContainSubstring("no archived report")))
+		})
+	})
+})
@@ -0,0 +1,142 @@
+package collect
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"os"
+	"os/exec"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+)
+
+// ImageDigestEnv is the environment variable the collector's container image sets to its own digest
+const ImageDigestEnv = "COVERAGE_IMAGE_DIGEST"
+
+// Provenance records how the numbers of a run were produced, so that changes of methodology shifting them
+// can be traced back to a new collector, toolchain, image or configuration
+type Provenance struct {
+	// Version is the version of the dashboard that collected the run, from its build info
+	Version string `json:"version"`
+	// GoVersion is the Go toolchain that ran the tests, e.g. "go1.23.4"
+	GoVersion string `json:"go_version"`
+	// ImageDigest is the digest of the container image the collector ran in, when known
+	ImageDigest string `json:"image_digest,omitempty"`
+	// ConfigHash is the SHA-256 of the effective repository configurations, after the policy's defaults
+	ConfigHash string `json:"config_hash"`
+}
+
+// NewProvenance describes the current collector running with the effective configurations of the repositories,
+// by key, in the container image of digest
+func NewProvenance(ctx context.Context, configs map[string]config.RepositoryConfig, digest string) (Provenance, error) {
+	hash, err := ConfigHash(configs)
+	if err != nil {
+		return Provenance{}, err
+	}
+	return Provenance{
+		Version:     BuildVersion(),
+		GoVersion:   toolchainVersion(ctx),
+		ImageDigest: digest,
+		ConfigHash:  hash,
+	}, nil
+}
+
+// BuildVersion returns the module version of the running binary, or its VCS revision for development builds
+func BuildVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	if info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+
+	var revision string
+	var modified bool
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.modified":
+			modified = setting.Value == "true"
+		}
+	}
+	if revision == "" {
+		return "unknown"
+	}
+	if len(revision) > 12 {
+		revision = revision[:12]
+	}
+	if modified {
+		revision += "-dirty"
+	}
+	return revision
+}
+
+// ConfigHash hashes repository configurations by key, independently of their order
+func ConfigHash(configs map[string]config.RepositoryConfig) (string, error) {
+	keys := make([]string, 0, len(configs))
+	for key := range configs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	hash := sha256.New()
+	for _, key := range keys {
+		data, err := yaml.Marshal(configs[key])
+		if err != nil {
+			return "", fmt.Errorf("failed to encode configuration of %s: %w", key, err)
+		}
+		fmt.Fprintf(hash, "%s\n%s\n", key, data)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// toolchainVersion returns the version of the go command running the tests, which may differ from the toolchain
+// the dashboard was built with
+func toolchainVersion(ctx context.Context) string {
+	cmd := exec.CommandContext(ctx, "go", "env", "GOVERSION")
+	cmd.WaitDelay = commandWaitDelay
+	if output, err := cmd.Output(); err == nil {
+		if version := strings.TrimSpace(string(output)); version != "" {
+			return version
+		}
+	}
+	return runtime.Version()
+}
+
+// ImageDigestFromEnv returns the digest of the collector's container image, empty outside of one
+func ImageDigestFromEnv() string {
+	return os.Getenv(ImageDigestEnv)
+}
+
+// String describes the provenance in one line
+func (p Provenance) String() string {
+	parts := []string{"coverage-dashboard " + p.Version, p.GoVersion}
+	if p.ImageDigest != "" {
+		parts = append(parts, "image "+p.ImageDigest)
+	}
+	return strings.Join(append(parts, "config "+shortHash(p.ConfigHash)), ", ")
+}
+
+// footer renders the provenance as the footer of a report
+func (p Provenance) footer() string {
+	return fmt.Sprintf(`<div id="provenance" style="clear: both; padding: 10px; font-size: 12px; color: rgb(128, 128, 128);">Collected by %s</div>`,
+		html.EscapeString(p.String()))
+}
+
+// shortHash abbreviates a hash for display
+func shortHash(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
@@ -0,0 +1,41 @@
+package collect_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/collect"
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+)
+
+var _ = Describe("Provenance", func() {
+	configs := map[string]config.RepositoryConfig{
+		"api.yaml": {Name: "konflux-ci/api", ExcludeDirs: []string{"vendor"}},
+		"cli.yaml": {Name: "konflux-ci/cli"},
+	}
+
+	It("should hash the effective configurations whatever their order", func() {
+		hash, err := collect.ConfigHash(configs)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(hash).To(HaveLen(64))
+
+		again, err := collect.ConfigHash(map[string]config.RepositoryConfig{"cli.yaml": configs["cli.yaml"], "api.yaml": configs["api.yaml"]})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(again).To(Equal(hash))
+
+		changed, err := collect.ConfigHash(map[string]config.RepositoryConfig{"api.yaml": configs["api.yaml"], "cli.yaml": {Name: "konflux-ci/cli", ExcludeDirs: []string{"e2e"}}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changed).NotTo(Equal(hash))
+	})
+
+	It("should describe the collector, toolchain, image and configuration", func() {
+		provenance, err := collect.NewProvenance(context.Background(), configs, "sha256:0123abcd")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(provenance.Version).NotTo(BeEmpty())
+		Expect(provenance.GoVersion).To(HavePrefix("go"))
+		Expect(provenance.ImageDigest).To(Equal("sha256:0123abcd"))
+		Expect(provenance.String()).To(MatchRegexp(`^coverage-dashboard \S+, go\S+, image sha256:0123abcd, config [0-9a-f]{12}$`))
+	})
+})
@@ -0,0 +1,61 @@
+package collect
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/pr"
+)
+
+// Publisher pushes intermediate results to a checkout of the published site
+// (e.g. gh-pages) while a run is still in progress
+type Publisher struct {
+	dir        string
+	reportsDir string
+}
+
+// NewPublisher creates a Publisher for the git checkout at dir
+// Reports are only published when reportsDir lies inside dir
+func NewPublisher(dir, reportsDir string) *Publisher {
+	p := &Publisher{dir: dir}
+	if rel, err := filepath.Rel(dir, reportsDir); err == nil && !strings.HasPrefix(rel, "..") {
+		p.reportsDir = rel
+	}
+	return p
+}
+
+// Publish writes the manifest and dashboard data into the checkout, then commits and pushes them
+func (p *Publisher) Publish(ctx context.Context, manifest *Manifest, dashboard Dashboard, message string) error {
+	if err := writeJSON(filepath.Join(p.dir, "coverage.json"), dashboard); err != nil {
+		return fmt.Errorf("failed to write coverage data: %w", err)
+	}
+	if err := writeJSON(filepath.Join(p.dir, "run-manifest.json"), manifest); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	paths := []string{"add", "coverage.json", "run-manifest.json"}
+	if p.reportsDir != "" {
+		if _, err := os.Stat(filepath.Join(p.dir, p.reportsDir)); err == nil {
+			paths = append(paths, p.reportsDir)
+		}
+	}
+	if _, err := pr.RunGitCommand(ctx, p.dir, paths...); err != nil {
+		return fmt.Errorf("failed to stage published files: %w", err)
+	}
+
+	// Nothing changed since the last publish
+	if _, err := pr.RunGitCommand(ctx, p.dir, "diff", "--cached", "--quiet"); err == nil {
+		return nil
+	}
+
+	if _, err := pr.RunGitCommand(ctx, p.dir, "commit", "-m", message); err != nil {
+		return fmt.Errorf("failed to commit published files: %w", err)
+	}
+	if _, err := pr.RunGitCommand(ctx, p.dir, "push", "origin", "HEAD"); err != nil {
+		return fmt.Errorf("failed to push published files: %w", err)
+	}
+	return nil
+}
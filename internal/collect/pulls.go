@@ -0,0 +1,293 @@
+package collect
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+)
+
+const (
+	// PullsDir holds the coverage uploaded for pull requests, apart from the default branch reports and history
+	PullsDir = "pulls"
+	// PullIndexFile lists the pull requests with uploaded coverage, published at the root of PullsDir
+	PullIndexFile = "index.json"
+	// DefaultPullGrace is how long the coverage of a closed pull request stays published
+	DefaultPullGrace = 7 * 24 * time.Hour
+)
+
+// PullCoverage is the latest coverage uploaded for a pull request
+type PullCoverage struct {
+	Repo       string            `json:"repo"`
+	Number     int               `json:"number"`
+	Branch     string            `json:"branch,omitempty"`
+	Commit     string            `json:"commit"`
+	Coverage   *float64          `json:"coverage"`
+	Packages   []PackageCoverage `json:"packages"`
+	UploadedAt time.Time         `json:"uploaded_at"`
+	// ClosedAt is set once the pull request is seen closed; its coverage expires a grace period later
+	ClosedAt *time.Time `json:"closed_at,omitempty"`
+	// Shards are the shards the coverage was merged from, for sharded test runs
+	Shards *ShardProgress `json:"shards,omitempty"`
+}
+
+// PullIndex lists the latest upload of every pull request, by repository then number
+type PullIndex struct {
+	Pulls []PullSummary `json:"pulls"`
+}
+
+// PullSummary is the entry of a pull request in the index
+type PullSummary struct {
+	Repo       string     `json:"repo"`
+	Number     int        `json:"number"`
+	Commit     string     `json:"commit"`
+	Coverage   *float64   `json:"coverage"`
+	UploadedAt time.Time  `json:"uploaded_at"`
+	ClosedAt   *time.Time `json:"closed_at,omitempty"`
+	// Partial is set when the coverage lacks shards of a sharded test run
+	Partial bool `json:"partial,omitempty"`
+}
+
+// PullState returns when a pull request was closed, or nil while it is open
+type PullState func(ctx context.Context, repo string, number int) (*time.Time, error)
+
+// NewPullCoverage computes the coverage of a pull request from the profile measured on its head commit
+func NewPullCoverage(repo string, number int, branch, commit, profilePath string, at time.Time) (PullCoverage, error) {
+	if err := validatePull(repo, number); err != nil {
+		return PullCoverage{}, err
+	}
+	stats, err := ProfileStats(profilePath, nil)
+	if err != nil {
+		return PullCoverage{}, err
+	}
+
+	upload := PullCoverage{
+		Repo:       repo,
+		Number:     number,
+		Branch:     branch,
+		Commit:     commit,
+		Packages:   packageCoverage(stats),
+		UploadedAt: at.UTC(),
+	}
+	if total := totalStats(stats); total.Total > 0 {
+		coverage := round1(total.Percent())
+		upload.Coverage = &coverage
+	}
+	return upload, nil
+}
+
+// PullStore keeps the uploads of pull requests under the PullsDir of a site, one file per pull request
+type PullStore struct {
+	dir string
+	// shardsDir stages the profiles of sharded uploads, outside the site so raw profiles are never published
+	shardsDir string
+}
+
+// NewPullStore creates a store of the pull request uploads of the site published from siteDir, staging the shards of
+// sharded uploads in shardsDir; without it, sharded uploads are refused
+func NewPullStore(siteDir, shardsDir string) *PullStore {
+	return &PullStore{dir: filepath.Join(siteDir, PullsDir), shardsDir: shardsDir}
+}
+
+// Put stores the upload of a pull request, replacing its previous upload unless that one is newer
+// An upload to a closed pull request keeps its closing date, so a late upload does not delay expiry
+func (s *PullStore) Put(upload PullCoverage) error {
+	if err := validatePull(upload.Repo, upload.Number); err != nil {
+		return err
+	}
+	path := s.path(upload.Repo, upload.Number)
+	previous, err := readPullCoverage(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return err
+	case previous.UploadedAt.After(upload.UploadedAt):
+		return fmt.Errorf("%s#%d already has coverage uploaded at %s, after this upload", upload.Repo, upload.Number, previous.UploadedAt.Format(time.RFC3339))
+	case upload.ClosedAt == nil:
+		upload.ClosedAt = previous.ClosedAt
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s#%d: %w", upload.Repo, upload.Number, err)
+	}
+	if err := writeJSON(path, upload); err != nil {
+		return err
+	}
+	return s.writeIndex()
+}
+
+// Latest returns the latest upload of a pull request
+func (s *PullStore) Latest(repo string, number int) (*PullCoverage, error) {
+	if err := validatePull(repo, number); err != nil {
+		return nil, err
+	}
+	upload, err := readPullCoverage(s.path(repo, number))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read coverage of %s#%d: %w", repo, number, err)
+	}
+	return upload, nil
+}
+
+// Expire records the closing of the stored pull requests and removes the uploads of those closed longer
+// than grace ago. Pull requests whose state cannot be read are kept. Returns the removed uploads
+func (s *PullStore) Expire(ctx context.Context, state PullState, grace time.Duration, now time.Time) ([]PullCoverage, error) {
+	uploads, err := s.list()
+	if err != nil {
+		return nil, err
+	}
+
+	var expired []PullCoverage
+	for _, upload := range uploads {
+		path := s.path(upload.Repo, upload.Number)
+		if upload.ClosedAt == nil {
+			closedAt, err := state(ctx, upload.Repo, upload.Number)
+			if err != nil {
+				if ctx.Err() != nil {
+					return expired, ctx.Err()
+				}
+				fmt.Printf("⚠️  Warning: keeping coverage of %s#%d: %v\n", upload.Repo, upload.Number, err)
+				continue
+			}
+			if closedAt == nil {
+				continue
+			}
+			closed := closedAt.UTC()
+			upload.ClosedAt = &closed
+			if err := writeJSON(path, upload); err != nil {
+				return expired, err
+			}
+		}
+		if now.Sub(*upload.ClosedAt) < grace {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			return expired, fmt.Errorf("failed to remove coverage of %s#%d: %w", upload.Repo, upload.Number, err)
+		}
+		expired = append(expired, upload)
+	}
+	return expired, s.writeIndex()
+}
+
+// list reads every stored upload, sorted by repository then number
+func (s *PullStore) list() ([]PullCoverage, error) {
+	var uploads []PullCoverage
+	err := filepath.WalkDir(s.dir, func(path string, entry fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) && path == s.dir {
+			return filepath.SkipDir
+		}
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || filepath.Dir(path) == s.dir || !strings.HasSuffix(path, ".json") {
+			return nil
+		}
+		upload, err := readPullCoverage(path)
+		if err != nil {
+			return err
+		}
+		uploads = append(uploads, *upload)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pull request coverage: %w", err)
+	}
+	sort.Slice(uploads, func(i, j int) bool {
+		if uploads[i].Repo != uploads[j].Repo {
+			return uploads[i].Repo < uploads[j].Repo
+		}
+		return uploads[i].Number < uploads[j].Number
+	})
+	return uploads, nil
+}
+
+// writeIndex rewrites the index from the stored uploads
+func (s *PullStore) writeIndex() error {
+	uploads, err := s.list()
+	if err != nil {
+		return err
+	}
+	index := PullIndex{Pulls: make([]PullSummary, 0, len(uploads))}
+	for _, upload := range uploads {
+		index.Pulls = append(index.Pulls, PullSummary{
+			Repo:       upload.Repo,
+			Number:     upload.Number,
+			Commit:     upload.Commit,
+			Coverage:   upload.Coverage,
+			UploadedAt: upload.UploadedAt,
+			ClosedAt:   upload.ClosedAt,
+			Partial:    upload.Shards != nil && upload.Shards.Partial,
+		})
+	}
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", s.dir, err)
+	}
+	return writeJSON(filepath.Join(s.dir, PullIndexFile), index)
+}
+
+// path is the file of a pull request's upload
+func (s *PullStore) path(repo string, number int) string {
+	return filepath.Join(s.dir, repo, pullFile(number))
+}
+
+// LoadPullCoverage reads the latest coverage of a pull request from a site directory or a published site
+// from is either a local pulls directory or an http(s) URL of the published one, e.g. https://konflux-ci.dev/coverage-dashboard/pulls
+func LoadPullCoverage(ctx context.Context, from, repo string, number int) (*PullCoverage, error) {
+	if err := validatePull(repo, number); err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(from, "http://") && !strings.HasPrefix(from, "https://") {
+		upload, err := readPullCoverage(filepath.Join(from, repo, pullFile(number)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read coverage of %s#%d: %w", repo, number, err)
+		}
+		return upload, nil
+	}
+
+	data, err := fetch(ctx, strings.TrimSuffix(from, "/")+"/"+repo+"/"+pullFile(number))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read coverage of %s#%d: %w", repo, number, err)
+	}
+	var upload PullCoverage
+	if err := json.Unmarshal(data, &upload); err != nil {
+		return nil, fmt.Errorf("failed to parse coverage of %s#%d: %w", repo, number, err)
+	}
+	return &upload, nil
+}
+
+// readPullCoverage reads a stored upload; errors reading the file are returned unwrapped
+func readPullCoverage(path string) (*PullCoverage, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var upload PullCoverage
+	if err := json.Unmarshal(data, &upload); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return &upload, nil
+}
+
+// validatePull rejects repositories and numbers that would escape the pulls directory
+func validatePull(repo string, number int) error {
+	if !config.ValidRepoName(repo) {
+		return fmt.Errorf("repository %q is not in org/repo form", repo)
+	}
+	if number <= 0 {
+		return fmt.Errorf("pull request number must be positive, got %d", number)
+	}
+	return nil
+}
+
+// pullFile is the file name of a pull request's upload
+func pullFile(number int) string {
+	return strconv.Itoa(number) + ".json"
+}
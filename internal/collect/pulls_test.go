@@ -0,0 +1,249 @@
+package collect_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/collect"
+)
+
+var _ = Describe("PullStore", func() {
+	var (
+		siteDir   string
+		shardsDir string
+		store     *collect.PullStore
+		now       time.Time
+	)
+
+	upload := func(repo string, number int, commit string, at time.Time) collect.PullCoverage {
+		coverage := 50.0
+		return collect.PullCoverage{Repo: repo, Number: number, Commit: commit, Coverage: &coverage, UploadedAt: at}
+	}
+	readIndex := func() collect.PullIndex {
+		data, err := os.ReadFile(filepath.Join(siteDir, collect.PullsDir, collect.PullIndexFile))
+		Expect(err).NotTo(HaveOccurred())
+		var index collect.PullIndex
+		Expect(json.Unmarshal(data, &index)).To(Succeed())
+		return index
+	}
+
+	BeforeEach(func() {
+		siteDir = GinkgoT().TempDir()
+		shardsDir = GinkgoT().TempDir()
+		store = collect.NewPullStore(siteDir, shardsDir)
+		now = time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	})
+
+	It("should compute the coverage of a pull request from its profile", func() {
+		profile := filepath.Join(GinkgoT().TempDir(), "coverage.out")
+		Expect(os.WriteFile(profile, []byte(sampleProfile), 0644)).To(Succeed())
+
+		pull, err := collect.NewPullCoverage("org/repo", 7, "feature", "abc1234", profile, now)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*pull.Coverage).To(Equal(29.4))
+		Expect(pull.Packages).To(HaveLen(3))
+		Expect(pull.Branch).To(Equal("feature"))
+	})
+
+	It("should keep uploads apart from the reports and index them", func() {
+		Expect(store.Put(upload("org/repo", 12, "aaa", now))).To(Succeed())
+		Expect(store.Put(upload("org/other", 3, "bbb", now))).To(Succeed())
+		Expect(store.Put(upload("org/repo", 12, "ccc", now.Add(time.Hour)))).To(Succeed())
+
+		Expect(filepath.Join(siteDir, "pulls/org/repo/12.json")).To(BeAnExistingFile())
+		latest, err := store.Latest("org/repo", 12)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(latest.Commit).To(Equal("ccc"))
+
+		index := readIndex()
+		Expect(index.Pulls).To(HaveLen(2))
+		Expect(index.Pulls[0].Repo).To(Equal("org/other"))
+		Expect(index.Pulls[1].Commit).To(Equal("ccc"))
+	})
+
+	It("should not replace an upload with an older one", func() {
+		Expect(store.Put(upload("org/repo", 12, "new", now))).To(Succeed())
+		Expect(store.Put(upload("org/repo", 12, "old", now.Add(-time.Hour)))).To(MatchError(ContainSubstring("already has coverage")))
+
+		latest, err := store.Latest("org/repo", 12)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(latest.Commit).To(Equal("new"))
+	})
+
+	It("should reject repositories and numbers escaping the pulls directory", func() {
+		Expect(store.Put(upload("../../etc", 1, "aaa", now))).To(MatchError(ContainSubstring("org/repo form")))
+		Expect(store.Put(upload("org/repo", 0, "aaa", now))).To(MatchError(ContainSubstring("must be positive")))
+		_, err := store.Latest("org/repo/..", 1)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should expire the uploads of pull requests closed longer than the grace period", func() {
+		for number := 1; number <= 4; number++ {
+			Expect(store.Put(upload("org/repo", number, "aaa", now.Add(-30*24*time.Hour)))).To(Succeed())
+		}
+		longAgo, recently := now.Add(-10*24*time.Hour), now.Add(-time.Hour)
+		state := func(_ context.Context, repo string, number int) (*time.Time, error) {
+			switch number {
+			case 1:
+				return &longAgo, nil
+			case 2:
+				return &recently, nil
+			case 3:
+				return nil, errors.New("rate limited")
+			}
+			return nil, nil
+		}
+
+		expired, err := store.Expire(context.Background(), state, collect.DefaultPullGrace, now)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(expired).To(HaveLen(1))
+		Expect(expired[0].Number).To(Equal(1))
+		Expect(filepath.Join(siteDir, "pulls/org/repo/1.json")).NotTo(BeAnExistingFile())
+
+		// The closing is recorded, so the upload expires later without asking again
+		closed, err := store.Latest("org/repo", 2)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*closed.ClosedAt).To(Equal(recently))
+		Expect(readIndex().Pulls).To(HaveLen(3))
+
+		unreachable := func(context.Context, string, int) (*time.Time, error) { return nil, errors.New("unreachable") }
+		expired, err = store.Expire(context.Background(), unreachable, collect.DefaultPullGrace, now.Add(collect.DefaultPullGrace))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(expired).To(HaveLen(1))
+		Expect(expired[0].Number).To(Equal(2))
+	})
+
+	It("should expire nothing from an empty site", func() {
+		expired, err := store.Expire(context.Background(), nil, collect.DefaultPullGrace, now)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(expired).To(BeEmpty())
+		Expect(readIndex().Pulls).To(BeEmpty())
+	})
+
+	Describe("LoadPullCoverage", func() {
+		BeforeEach(func() {
+			Expect(store.Put(upload("org/repo", 12, "aaa", now))).To(Succeed())
+		})
+
+		It("should read a pull request's coverage from a local pulls directory", func() {
+			pull, err := collect.LoadPullCoverage(context.Background(), filepath.Join(siteDir, collect.PullsDir), "org/repo", 12)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pull.Commit).To(Equal("aaa"))
+		})
+
+		It("should fetch a pull request's coverage from the published site", func() {
+			server := httptest.NewServer(http.FileServer(http.Dir(siteDir)))
+			defer server.Close()
+
+			pull, err := collect.LoadPullCoverage(context.Background(), server.URL+"/pulls/", "org/repo", 12)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*pull.Coverage).To(Equal(50.0))
+
+			_, err = collect.LoadPullCoverage(context.Background(), server.URL+"/pulls", "org/repo", 13)
+			Expect(err).To(MatchError(ContainSubstring("404")))
+		})
+	})
+
+	Describe("sharded uploads", func() {
+		var shards []string
+
+		shardUpload := func(commit string, shard, total int) collect.ShardUpload {
+			return collect.ShardUpload{Repo: "org/repo", Number: 12, Commit: commit, Shard: shard, Total: total, Profile: shards[shard-1]}
+		}
+
+		BeforeEach(func() {
+			dir := GinkgoT().TempDir()
+			shards = []string{filepath.Join(dir, "1.out"), filepath.Join(dir, "2.out"), filepath.Join(dir, "3.out")}
+			Expect(os.WriteFile(shards[0], []byte("mode: set\ngithub.com/org/repo/pkg/a/a.go:3.20,5.2 2 1\ngithub.com/org/repo/pkg/a/a.go:7.20,9.2 2 0\n"), 0644)).To(Succeed())
+			Expect(os.WriteFile(shards[1], []byte("mode: set\ngithub.com/org/repo/pkg/a/a.go:7.20,9.2 2 1\ngithub.com/org/repo/pkg/b/b.go:3.20,6.2 3 0\n"), 0644)).To(Succeed())
+			Expect(os.WriteFile(shards[2], []byte("mode: set\ngithub.com/org/repo/pkg/b/b.go:3.20,6.2 3 0\n"), 0644)).To(Succeed())
+		})
+
+		It("should publish the coverage of the merged shards once every shard is uploaded", func() {
+			progress, pull, err := store.PutShard(shardUpload("aaa", 2, 2), now)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pull).To(BeNil())
+			Expect(progress.Missing()).To(Equal([]int{1}))
+			_, err = store.Latest("org/repo", 12)
+			Expect(err).To(HaveOccurred())
+			Expect(filepath.Join(siteDir, collect.PullsDir)).NotTo(BeADirectory())
+			Expect(filepath.Join(shardsDir, "org", "repo", "12", "aaa", "2.out")).To(BeAnExistingFile())
+
+			_, _, err = store.PutShard(shardUpload("aaa", 1, 3), now)
+			Expect(err).To(MatchError(ContainSubstring("expects 2 shards")))
+
+			progress, pull, err = store.PutShard(shardUpload("aaa", 1, 2), now.Add(time.Minute))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(progress.Complete()).To(BeTrue())
+			Expect(*pull.Coverage).To(Equal(57.1))
+			Expect(*pull.Shards).To(Equal(collect.ShardProgress{Expected: 2, Received: []int{1, 2}}))
+
+			latest, err := store.Latest("org/repo", 12)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(latest.Commit).To(Equal("aaa"))
+			Expect(latest.UploadedAt).To(Equal(now.Add(time.Minute)))
+			index := readIndex()
+			Expect(index.Pulls).To(HaveLen(1))
+			Expect(index.Pulls[0].Partial).To(BeFalse())
+			Expect(filepath.Join(shardsDir, "org", "repo", "12", "aaa")).NotTo(BeADirectory())
+		})
+
+		It("should publish the shards received as partial coverage after the timeout", func() {
+			_, _, err := store.PutShard(shardUpload("aaa", 1, 3), now)
+			Expect(err).NotTo(HaveOccurred())
+
+			finalized, err := store.FinalizeShards(time.Hour, now.Add(30*time.Minute))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(finalized).To(BeEmpty())
+
+			finalized, err = store.FinalizeShards(time.Hour, now.Add(time.Hour))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(finalized).To(HaveLen(1))
+			Expect(*finalized[0].Coverage).To(Equal(50.0))
+			Expect(finalized[0].Shards.Partial).To(BeTrue())
+			Expect(finalized[0].Shards.Missing()).To(Equal([]int{2, 3}))
+			Expect(readIndex().Pulls[0].Partial).To(BeTrue())
+
+			finalized, err = store.FinalizeShards(time.Hour, now.Add(2*time.Hour))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(finalized).To(BeEmpty())
+		})
+
+		It("should drop the shards of a commit superseded by a later upload", func() {
+			_, _, err := store.PutShard(shardUpload("aaa", 1, 2), now)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(store.Put(upload("org/repo", 12, "bbb", now.Add(time.Minute)))).To(Succeed())
+
+			finalized, err := store.FinalizeShards(time.Hour, now.Add(time.Hour))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(finalized).To(BeEmpty())
+			latest, err := store.Latest("org/repo", 12)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(latest.Commit).To(Equal("bbb"))
+		})
+
+		It("should refuse shards without a directory to stage them in", func() {
+			_, _, err := collect.NewPullStore(siteDir, "").PutShard(shardUpload("aaa", 1, 2), now)
+			Expect(err).To(MatchError(ContainSubstring("no directory to stage the shards in")))
+		})
+
+		It("should parse shards given as index/total", func() {
+			shard, total, err := collect.ParseShard("2/5")
+			Expect(err).NotTo(HaveOccurred())
+			Expect([]int{shard, total}).To(Equal([]int{2, 5}))
+			for _, invalid := range []string{"2", "0/5", "6/5", "a/5", "1/0"} {
+				_, _, err := collect.ParseShard(invalid)
+				Expect(err).To(HaveOccurred(), invalid)
+			}
+		})
+	})
+})
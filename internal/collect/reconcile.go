@@ -0,0 +1,169 @@
+package collect
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultDiscrepancy is the difference, in percentage points, from which a provider's coverage disagrees with the dashboard
+const DefaultDiscrepancy = 5.0
+
+// CoverageProvider is an external service measuring the coverage of repositories, e.g. Codecov or SonarQube
+type CoverageProvider interface {
+	// Coverage returns the latest coverage of a repository's default branch, or nil when the provider does not measure it
+	Coverage(ctx context.Context, repo string) (*float64, error)
+}
+
+// ProviderMeasurement is the coverage a provider measured on a repository, compared with the dashboard's
+type ProviderMeasurement struct {
+	Provider string   `json:"provider"`
+	Coverage *float64 `json:"coverage"`
+	// Difference is the provider's coverage minus the dashboard's, in percentage points
+	Difference *float64 `json:"difference,omitempty"`
+	// Significant is set when the difference reaches the report's threshold either way
+	Significant bool   `json:"significant,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// Reconciliation compares the coverage of a repository on the dashboard with that of the providers measuring it
+type Reconciliation struct {
+	Repo      string                `json:"repo"`
+	Owners    []string              `json:"owners"`
+	Dashboard *float64              `json:"dashboard"`
+	Providers []ProviderMeasurement `json:"providers"`
+}
+
+// Discrepant reports whether a provider's coverage significantly differs from the dashboard's
+func (r Reconciliation) Discrepant() bool {
+	for _, measurement := range r.Providers {
+		if measurement.Significant {
+			return true
+		}
+	}
+	return false
+}
+
+// ReconciliationReport compares the dashboard with external providers for the repositories they both measure
+type ReconciliationReport struct {
+	GeneratedAt time.Time `json:"generated_at"`
+	// Threshold is the difference, in percentage points, from which coverage disagrees
+	Threshold float64          `json:"threshold"`
+	Repos     []Reconciliation `json:"repos"`
+}
+
+// Reconcile asks every provider for the coverage of the dashboard's results, keeping the repositories at least one
+// provider measures. Provider errors are recorded per repository instead of failing the report
+func Reconcile(ctx context.Context, results []Result, providers map[string]CoverageProvider, threshold float64, now time.Time) ReconciliationReport {
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	report := ReconciliationReport{GeneratedAt: now, Threshold: threshold, Repos: []Reconciliation{}}
+	for _, result := range results {
+		reconciliation := Reconciliation{Repo: result.Repo, Owners: result.Owners, Dashboard: result.Coverage}
+		measured := false
+		for _, name := range names {
+			measurement := ProviderMeasurement{Provider: name}
+			coverage, err := providers[name].Coverage(ctx, result.Repo)
+			switch {
+			case err != nil:
+				measurement.Error = err.Error()
+			case coverage != nil:
+				measured = true
+				value := round1(*coverage)
+				measurement.Coverage = &value
+				if result.Coverage != nil {
+					difference := round1(value - *result.Coverage)
+					measurement.Difference = &difference
+					measurement.Significant = math.Abs(difference) >= threshold
+				}
+			}
+			reconciliation.Providers = append(reconciliation.Providers, measurement)
+		}
+		if measured || hasErrors(reconciliation.Providers) {
+			report.Repos = append(report.Repos, reconciliation)
+		}
+	}
+	sort.Slice(report.Repos, func(i, j int) bool { return report.Repos[i].Repo < report.Repos[j].Repo })
+	return report
+}
+
+// hasErrors reports whether reading any of the measurements failed
+func hasErrors(measurements []ProviderMeasurement) bool {
+	for _, measurement := range measurements {
+		if measurement.Error != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Markdown renders the repositories whose coverage disagrees with a provider as a table, with a hint on the usual cause
+func (r ReconciliationReport) Markdown() string {
+	var out strings.Builder
+	fmt.Fprintf(&out, "## Coverage discrepancies with external providers (%s)\n\n", r.GeneratedAt.Format("2006-01-02"))
+
+	var discrepant []Reconciliation
+	var failed []string
+	for _, repo := range r.Repos {
+		if repo.Discrepant() {
+			discrepant = append(discrepant, repo)
+		}
+		for _, measurement := range repo.Providers {
+			if measurement.Error != "" {
+				failed = append(failed, fmt.Sprintf("- %s (%s): %s", repo.Repo, measurement.Provider, measurement.Error))
+			}
+		}
+	}
+
+	fmt.Fprintf(&out, "%d repositories measured by a provider, %d differing by %.1f points or more.\n\n", len(r.Repos), len(discrepant), r.Threshold)
+	if len(discrepant) > 0 {
+		out.WriteString("| Repository | Dashboard |")
+		for _, measurement := range discrepant[0].Providers {
+			fmt.Fprintf(&out, " %s |", measurement.Provider)
+		}
+		out.WriteString("\n|---|---:|")
+		out.WriteString(strings.Repeat("---:|", len(discrepant[0].Providers)))
+		out.WriteString("\n")
+		for _, repo := range discrepant {
+			fmt.Fprintf(&out, "| %s | %s |", repo.Repo, formatCoverage(repo.Dashboard))
+			for _, measurement := range repo.Providers {
+				out.WriteString(" " + formatMeasurement(measurement) + " |")
+			}
+			out.WriteString("\n")
+		}
+		out.WriteString("\nDifferences usually come from exclusions: compare the repository's `exclude_dirs` and `exclude_files` " +
+			"with the provider's ignored paths, e.g. `ignore` in `codecov.yml` or `sonar.coverage.exclusions`.\n")
+	}
+
+	if len(failed) > 0 {
+		out.WriteString("\n### Providers that could not be read\n\n")
+		out.WriteString(strings.Join(failed, "\n") + "\n")
+	}
+	return out.String()
+}
+
+// formatMeasurement formats a provider's coverage with its difference to the dashboard, flagging significant ones
+func formatMeasurement(measurement ProviderMeasurement) string {
+	switch {
+	case measurement.Coverage == nil:
+		return "—"
+	case measurement.Difference == nil:
+		return fmt.Sprintf("%.1f%%", *measurement.Coverage)
+	case measurement.Significant:
+		return fmt.Sprintf("**%.1f%% (%+.1f)**", *measurement.Coverage, *measurement.Difference)
+	default:
+		return fmt.Sprintf("%.1f%% (%+.1f)", *measurement.Coverage, *measurement.Difference)
+	}
+}
+
+// WriteReconciliationReport writes the report as JSON, e.g. reconciliation.json next to coverage.json
+func WriteReconciliationReport(path string, report ReconciliationReport) error {
+	return writeJSON(path, report)
+}
@@ -0,0 +1,77 @@
+package collect_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/collect"
+)
+
+// staticProvider measures the coverage of the repositories it knows
+type staticProvider struct {
+	coverage map[string]float64
+	err      error
+}
+
+func (p staticProvider) Coverage(_ context.Context, repo string) (*float64, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	coverage, ok := p.coverage[repo]
+	if !ok {
+		return nil, nil
+	}
+	return &coverage, nil
+}
+
+var _ = Describe("Reconcile", func() {
+	now := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	coverage := func(value float64) *float64 { return &value }
+	results := []collect.Result{
+		{Repo: "konflux-ci/api", Coverage: coverage(70)},
+		{Repo: "konflux-ci/build-service", Coverage: coverage(60)},
+		{Repo: "konflux-ci/caching", Coverage: coverage(50)},
+	}
+
+	It("should flag the providers differing from the dashboard by the threshold either way", func() {
+		report := collect.Reconcile(context.Background(), results, map[string]collect.CoverageProvider{
+			"SonarQube": staticProvider{coverage: map[string]float64{"konflux-ci/api": 62.04}},
+			"Codecov":   staticProvider{coverage: map[string]float64{"konflux-ci/api": 71, "konflux-ci/build-service": 66}},
+		}, 5, now)
+
+		Expect(report.Repos).To(HaveLen(2))
+		api := report.Repos[0]
+		Expect(api.Repo).To(Equal("konflux-ci/api"))
+		Expect(api.Providers[0].Provider).To(Equal("Codecov"))
+		Expect(*api.Providers[0].Difference).To(Equal(1.0))
+		Expect(api.Providers[0].Significant).To(BeFalse())
+		Expect(*api.Providers[1].Coverage).To(Equal(62.0))
+		Expect(*api.Providers[1].Difference).To(Equal(-8.0))
+		Expect(api.Providers[1].Significant).To(BeTrue())
+
+		buildService := report.Repos[1]
+		Expect(buildService.Discrepant()).To(BeTrue())
+		Expect(buildService.Providers[1].Coverage).To(BeNil())
+
+		markdown := report.Markdown()
+		Expect(markdown).To(ContainSubstring("2 repositories measured by a provider, 2 differing by 5.0 points or more."))
+		Expect(markdown).To(ContainSubstring("| Repository | Dashboard | Codecov | SonarQube |\n|---|---:|---:|---:|\n"))
+		Expect(markdown).To(ContainSubstring("| konflux-ci/api | 70.0% | 71.0% (+1.0) | **62.0% (-8.0)** |\n"))
+		Expect(markdown).To(ContainSubstring("| konflux-ci/build-service | 60.0% | **66.0% (+6.0)** | — |\n"))
+		Expect(markdown).To(ContainSubstring("`sonar.coverage.exclusions`"))
+	})
+
+	It("should record provider errors instead of failing", func() {
+		report := collect.Reconcile(context.Background(), results[:1], map[string]collect.CoverageProvider{
+			"Codecov": staticProvider{err: errors.New("GET https://api.codecov.io: 503 Service Unavailable")},
+		}, 5, now)
+
+		Expect(report.Repos).To(HaveLen(1))
+		Expect(report.Repos[0].Discrepant()).To(BeFalse())
+		Expect(report.Markdown()).To(ContainSubstring("### Providers that could not be read\n\n- konflux-ci/api (Codecov): GET https://api.codecov.io: 503 Service Unavailable\n"))
+	})
+})
@@ -0,0 +1,337 @@
+package collect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Alert events, in the order an alert goes through them
+const (
+	AlertOpened    = "opened"
+	AlertReminder  = "reminder"
+	AlertEscalated = "escalated"
+	AlertResolved  = "resolved"
+)
+
+// Kinds of regressions
+const (
+	// KindDrop is a drop of more than the regression delta below the baseline
+	KindDrop = "drop"
+	// KindBelowThreshold is coverage below the repository's min_coverage or ratchet
+	KindBelowThreshold = "below_threshold"
+)
+
+// Regression is a repository whose coverage dropped by more than its configured delta below its
+// baseline, or fell below its threshold
+type Regression struct {
+	Repo       string `json:"repo"`
+	ConfigFile string `json:"config_file"`
+	Kind       string `json:"kind"`
+	// Previous is the baseline coverage and Current the coverage of this run; Delta is the drop in percentage points
+	// below the baseline, or below the threshold for KindBelowThreshold
+	Previous       float64         `json:"previous"`
+	Current        float64         `json:"current"`
+	Delta          float64         `json:"delta"`
+	Threshold      float64         `json:"threshold"`
+	PreviousCommit string          `json:"previous_commit,omitempty"`
+	Commit         string          `json:"commit,omitempty"`
+	Owners         []string        `json:"owners"`
+	Groups         []string        `json:"groups,omitempty"`
+	Packages       []PackageChange `json:"packages"`
+	RunURL         string          `json:"run_url,omitempty"`
+	DetectedAt     time.Time       `json:"detected_at"`
+}
+
+// Summary describes the regression in one line
+func (r Regression) Summary() string {
+	if r.Kind == KindBelowThreshold {
+		return fmt.Sprintf("%.1f%%, %.1f below threshold %.1f%%", r.Current, r.Delta, r.Threshold)
+	}
+	return fmt.Sprintf("%.1f%% → %.1f%% (-%.1f, delta %.1f)", r.Previous, r.Current, r.Delta, r.Threshold)
+}
+
+// PackageChange is the coverage of a package before and after a regression
+// A nil side means the package did not exist in that run
+type PackageChange struct {
+	Package  string   `json:"package"`
+	Previous *float64 `json:"previous"`
+	Current  *float64 `json:"current"`
+}
+
+// AlertState tracks an open regression of a repository across runs, so that a repository
+// staying below its baseline is not reported as new every day
+type AlertState struct {
+	Kind string `json:"kind"`
+	// Baseline is the coverage before a drop; the alert resolves once coverage is back within the delta
+	// of it and above the repository's threshold
+	Baseline       float64   `json:"baseline"`
+	BaselineCommit string    `json:"baseline_commit,omitempty"`
+	Since          time.Time `json:"since"`
+	Violations     int       `json:"consecutive_violations"`
+	LastNotified   time.Time `json:"last_notified"`
+	Escalated      bool      `json:"escalated,omitempty"`
+}
+
+// AlertPolicy decides how often an open alert is repeated and when it escalates
+type AlertPolicy struct {
+	// Cooldown is the minimum time between reminders of the same open alert
+	Cooldown time.Duration
+	// EscalateAfter is the number of consecutive violating runs after which an alert escalates once; zero never escalates
+	EscalateAfter int
+}
+
+// Alert is a notification about a repository's regression
+type Alert struct {
+	Event      string
+	Regression Regression
+	// Violations counts the consecutive runs the repository has been regressed, including this one
+	Violations int
+	Since      time.Time
+}
+
+// AlertNotifier is told about every alert raised at the end of a run
+type AlertNotifier interface {
+	NotifyAlert(ctx context.Context, alert Alert) error
+}
+
+// Notifiers tells several notifiers, e.g. the issue tracker and a webhook, about every alert
+// A failing notifier does not keep the next ones from being told
+type Notifiers []interface {
+	AlertNotifier
+	FailureNotifier
+}
+
+// NotifyAlert tells every notifier about a regression alert, joining their errors
+func (n Notifiers) NotifyAlert(ctx context.Context, alert Alert) error {
+	var errs []error
+	for _, notifier := range n {
+		errs = append(errs, notifier.NotifyAlert(ctx, alert))
+	}
+	return errors.Join(errs...)
+}
+
+// NotifyFailure tells every notifier about a collection failure alert, joining their errors
+func (n Notifiers) NotifyFailure(ctx context.Context, alert FailureAlert) error {
+	var errs []error
+	for _, notifier := range n {
+		errs = append(errs, notifier.NotifyFailure(ctx, alert))
+	}
+	return errors.Join(errs...)
+}
+
+// carryAlerts copies the open alerts of configured repositories from a previous manifest
+func (m *Manifest) carryAlerts(previous *Manifest, files []string) {
+	if previous == nil || len(previous.Alerts) == 0 {
+		return
+	}
+
+	m.Alerts = make(map[string]AlertState)
+	for _, file := range files {
+		if state, ok := previous.Alerts[file]; ok {
+			m.Alerts[file] = state
+		}
+	}
+}
+
+// evaluateAlerts compares the successful runs collected in this run against their baseline and threshold,
+// updates the open alerts and returns the ones to notify about
+// The baseline of a repository without an open drop alert is its result in the previous run; deltas maps
+// config files to their regression delta in percentage points, files without a positive delta are not
+// checked for drops
+func (m *Manifest) evaluateAlerts(collected []RepoRun, previous *Manifest, deltas map[string]float64, policy AlertPolicy, now time.Time) []Alert {
+	var alerts []Alert
+	evaluated := make(map[string]bool)
+	for _, run := range collected {
+		evaluated[run.ConfigFile] = true
+	}
+	var kept []Regression
+	for _, regression := range m.Regressions {
+		if !evaluated[regression.ConfigFile] {
+			kept = append(kept, regression)
+		}
+	}
+	m.Regressions = kept
+
+	for _, run := range collected {
+		if run.Result.Status != StatusOK || run.Result.Coverage == nil {
+			continue
+		}
+
+		state, open := m.Alerts[run.ConfigFile]
+		var before *Result
+		if previous != nil {
+			if previousRun, found := previous.find(run.ConfigFile); found && previousRun.Result.Status == StatusOK && previousRun.Result.Coverage != nil {
+				before = &previousRun.Result
+			}
+		}
+
+		regression := checkRegression(run, before, state, open, deltas[run.ConfigFile])
+		regression.DetectedAt = now
+		if before != nil {
+			regression.Packages = packageChanges(before.Packages, run.Result.Packages)
+		}
+
+		if regression.Kind == "" {
+			if open {
+				delete(m.Alerts, run.ConfigFile)
+				regression.Kind = state.Kind
+				alerts = append(alerts, Alert{Event: AlertResolved, Regression: regression, Violations: state.Violations, Since: state.Since})
+			}
+			continue
+		}
+
+		if !open {
+			state = AlertState{Kind: regression.Kind, Baseline: regression.Previous, BaselineCommit: regression.PreviousCommit, Since: now}
+		}
+		m.Regressions = append(m.Regressions, regression)
+		state.Violations++
+		event := ""
+		switch {
+		case !open:
+			event = AlertOpened
+		case !state.Escalated && policy.EscalateAfter > 0 && state.Violations >= policy.EscalateAfter:
+			event = AlertEscalated
+			state.Escalated = true
+		case now.Sub(state.LastNotified) >= policy.Cooldown:
+			event = AlertReminder
+		}
+		if event != "" {
+			state.LastNotified = now
+			alerts = append(alerts, Alert{Event: event, Regression: regression, Violations: state.Violations, Since: state.Since})
+		}
+
+		if m.Alerts == nil {
+			m.Alerts = make(map[string]AlertState)
+		}
+		m.Alerts[run.ConfigFile] = state
+	}
+	return alerts
+}
+
+// checkRegression compares a successful run against its baseline and threshold
+// The kind of the returned regression is empty if the run is fine; a drop is reported before a threshold breach
+func checkRegression(run RepoRun, before *Result, state AlertState, open bool, delta float64) Regression {
+	coverage := *run.Result.Coverage
+	regression := Regression{
+		Repo:       run.Result.Repo,
+		ConfigFile: run.ConfigFile,
+		Current:    coverage,
+		Commit:     run.Result.Commit,
+		Owners:     run.Result.Owners,
+		Groups:     run.Result.Groups,
+		Packages:   []PackageChange{},
+		RunURL:     run.RunURL,
+	}
+
+	// An open drop alert keeps comparing against the coverage before the drop
+	switch {
+	case open && state.Kind == KindDrop:
+		regression.Previous, regression.PreviousCommit = state.Baseline, state.BaselineCommit
+	case before != nil:
+		regression.Previous, regression.PreviousCommit = *before.Coverage, before.Commit
+	default:
+		regression.Previous = coverage
+	}
+
+	if drop := round1(regression.Previous - coverage); delta > 0 && drop > delta {
+		regression.Kind = KindDrop
+		regression.Delta = drop
+		regression.Threshold = delta
+		return regression
+	}
+	if threshold := run.Result.Threshold; threshold != nil && coverage < threshold.Effective {
+		regression.Kind = KindBelowThreshold
+		regression.Delta = round1(threshold.Effective - coverage)
+		regression.Threshold = threshold.Effective
+		return regression
+	}
+
+	// Fine: describe the recovery against the alert's baseline
+	if open && state.Kind == KindDrop {
+		regression.Threshold = delta
+	} else if threshold := run.Result.Threshold; threshold != nil {
+		regression.Threshold = threshold.Effective
+	}
+	return regression
+}
+
+// packageChanges lists packages whose coverage changed, appeared or disappeared, largest drop first
+func packageChanges(before, after []PackageCoverage) []PackageChange {
+	previous := make(map[string]float64)
+	for _, pkg := range before {
+		previous[pkg.Package] = pkg.Coverage
+	}
+
+	changes := []PackageChange{}
+	for _, pkg := range after {
+		current := pkg.Coverage
+		old, found := previous[pkg.Package]
+		delete(previous, pkg.Package)
+		switch {
+		case !found:
+			changes = append(changes, PackageChange{Package: pkg.Package, Current: &current})
+		case old != current:
+			changes = append(changes, PackageChange{Package: pkg.Package, Previous: &old, Current: &current})
+		}
+	}
+	for pkg, coverage := range previous {
+		old := coverage
+		changes = append(changes, PackageChange{Package: pkg, Previous: &old})
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		if a, b := changes[i].Change(), changes[j].Change(); a != b {
+			return a < b
+		}
+		return changes[i].Package < changes[j].Package
+	})
+	return changes
+}
+
+// Change returns the difference in percentage points, counting a missing side as 0%
+func (c PackageChange) Change() float64 {
+	var before, after float64
+	if c.Previous != nil {
+		before = *c.Previous
+	}
+	if c.Current != nil {
+		after = *c.Current
+	}
+	return round1(after - before)
+}
+
+// notifyAlerts reports the alerts of the run and passes them to the configured notifier
+// Notification failures only produce warnings
+func (r *Runner) notifyAlerts(ctx context.Context, manifest *Manifest, alerts []Alert) {
+	if len(manifest.Regressions) > 0 {
+		fmt.Printf("📉 %d repositories regressed:\n", len(manifest.Regressions))
+	}
+
+	notified := make(map[string]bool)
+	for _, alert := range alerts {
+		notified[alert.Regression.ConfigFile] = true
+		regression := alert.Regression
+		if alert.Event == AlertResolved {
+			fmt.Printf("    ✅ %s recovered: %.1f%% after %d runs\n", regression.Repo, regression.Current, alert.Violations)
+		} else {
+			fmt.Printf("    → %s: %s, %s after %d runs\n", regression.Repo, regression.Summary(), alert.Event, alert.Violations)
+		}
+		if r.config.Notifier == nil {
+			continue
+		}
+		if err := r.config.Notifier.NotifyAlert(ctx, alert); err != nil {
+			fmt.Printf("    ⚠️  Warning: failed to report %s alert of %s: %v\n", alert.Event, regression.Repo, err)
+		}
+	}
+
+	for _, regression := range manifest.Regressions {
+		if !notified[regression.ConfigFile] {
+			state := manifest.Alerts[regression.ConfigFile]
+			fmt.Printf("    🔕 %s: %s, still regressed after %d runs (last notified %s)\n",
+				regression.Repo, regression.Summary(), state.Violations, state.LastNotified.Format("2006-01-02"))
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package collect
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// DefaultReportMemoryBudget bounds the bytes of report file sections held at once while rendering
+const DefaultReportMemoryBudget = 256 << 20
+
+const (
+	reportFileStart = `<pre class="file"`
+	reportFileEnd   = `</pre>`
+)
+
+// ReportRenderer streams the HTML report of go tool cover into the published report, linking the file
+// sections of the report in parallel while bounding the memory they hold
+type ReportRenderer struct {
+	// Workers is the number of file sections linked concurrently
+	Workers int
+	// MemoryBudget bounds the bytes of file sections read but not yet written; a section larger than
+	// the budget is rendered alone
+	MemoryBudget int64
+	// Footer, when set, is HTML added at the end of the report's body
+	Footer string
+}
+
+// NewReportRenderer creates a renderer, using a worker per CPU and the default budget for non-positive values
+func NewReportRenderer(workers int, memoryBudget int64) *ReportRenderer {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if memoryBudget <= 0 {
+		memoryBudget = DefaultReportMemoryBudget
+	}
+	return &ReportRenderer{Workers: workers, MemoryBudget: memoryBudget}
+}
+
+// renderJob is a file section of the report, rendered by a worker and written in report order
+type renderJob struct {
+	section string
+	// after is the text between the section and the next one
+	after string
+	cost  int64
+	done  chan string
+}
+
+// Render copies the report from src to dst, linking its files and blocks to GitHub at the commit of ref
+// decorate, when set, edits the report's header, which holds the file list and the legend
+func (r *ReportRenderer) Render(dst io.Writer, src io.Reader, ref SourceRef, decorate func(header string) string) error {
+	in := bufio.NewReaderSize(src, 64<<10)
+	out := bufio.NewWriterSize(dst, 64<<10)
+
+	header, more, err := readThrough(in, reportFileStart)
+	if err != nil {
+		return fmt.Errorf("failed to read report: %w", err)
+	}
+	header = strings.TrimSuffix(header, reportFileStart)
+
+	var files map[string]string
+	if decorate != nil {
+		header = decorate(header)
+	}
+	header = strings.Replace(header, `</head>`, reportTheme+`</head>`, 1)
+	if ref.Commit != "" {
+		header, files = linkOptions(header, ref)
+	}
+	linked := len(files) > 0
+	if linked {
+		header = addSourceHeader(header, ref, files)
+	}
+
+	finish := func(tail string) error {
+		if r.Footer != "" {
+			tail = strings.Replace(tail, `</body>`, r.Footer+`</body>`, 1)
+		}
+		if linked {
+			tail = strings.Replace(tail, `</html>`, sourceLinkScript+`</html>`, 1)
+		}
+		if _, err := io.WriteString(out, tail); err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
+		}
+		if err := out.Flush(); err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
+		}
+		return nil
+	}
+	if !more {
+		return finish(header)
+	}
+	if _, err := io.WriteString(out, header); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+
+	budget := newMemoryBudget(max(r.MemoryBudget, 1))
+	jobs := make(chan *renderJob)
+	ordered := make(chan *renderJob, max(r.Workers, 1))
+
+	var workers sync.WaitGroup
+	for range max(r.Workers, 1) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for job := range jobs {
+				if linked {
+					job.done <- linkFile(job.section, ref, files)
+				} else {
+					job.done <- job.section
+				}
+			}
+		}()
+	}
+
+	written := make(chan error, 1)
+	go func() {
+		var err error
+		for job := range ordered {
+			section := <-job.done
+			if err == nil {
+				if _, err = io.WriteString(out, section); err == nil {
+					_, err = io.WriteString(out, job.after)
+				}
+			}
+			budget.release(job.cost)
+		}
+		written <- err
+	}()
+
+	var tail string
+	var readErr error
+	for {
+		body, _, err := readThrough(in, reportFileEnd)
+		if err != nil {
+			readErr = fmt.Errorf("failed to read report: %w", err)
+			break
+		}
+		job := &renderJob{section: reportFileStart + body, done: make(chan string, 1)}
+		after, next, err := readThrough(in, reportFileStart)
+		if err != nil {
+			readErr = fmt.Errorf("failed to read report: %w", err)
+			break
+		}
+		if next {
+			job.after = strings.TrimSuffix(after, reportFileStart)
+		} else {
+			tail = after
+		}
+
+		// The section and its linked copy are both held until the section is written
+		job.cost = budget.acquire(2 * int64(len(job.section)))
+		ordered <- job
+		jobs <- job
+		if !next {
+			break
+		}
+	}
+	close(jobs)
+	close(ordered)
+	workers.Wait()
+
+	if err := <-written; err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	if readErr != nil {
+		return readErr
+	}
+	return finish(tail)
+}
+
+// readThrough reads up to and including the next occurrence of marker
+// found is false when the input ends first, in which case the rest of the input is returned
+func readThrough(in *bufio.Reader, marker string) (text string, found bool, err error) {
+	var buf bytes.Buffer
+	last := marker[len(marker)-1]
+	for {
+		chunk, err := in.ReadSlice(last)
+		buf.Write(chunk)
+		switch {
+		case err == nil:
+			if bytes.HasSuffix(buf.Bytes(), []byte(marker)) {
+				return buf.String(), true, nil
+			}
+		case errors.Is(err, bufio.ErrBufferFull):
+		case errors.Is(err, io.EOF):
+			return buf.String(), false, nil
+		default:
+			return buf.String(), false, err
+		}
+	}
+}
+
+// memoryBudget is a counting semaphore of bytes
+type memoryBudget struct {
+	mu    sync.Mutex
+	freed *sync.Cond
+	total int64
+	free  int64
+}
+
+// newMemoryBudget creates a budget of total bytes
+func newMemoryBudget(total int64) *memoryBudget {
+	b := &memoryBudget{total: total, free: total}
+	b.freed = sync.NewCond(&b.mu)
+	return b
+}
+
+// acquire waits until n bytes are free and takes them; more than the total waits for the whole budget
+// Returns the bytes taken, to be released
+func (b *memoryBudget) acquire(n int64) int64 {
+	n = min(n, b.total)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for b.free < n {
+		b.freed.Wait()
+	}
+	b.free -= n
+	return n
+}
+
+// release returns n bytes to the budget
+func (b *memoryBudget) release(n int64) {
+	b.mu.Lock()
+	b.free += n
+	b.mu.Unlock()
+	b.freed.Broadcast()
+}
@@ -0,0 +1,126 @@
+package collect_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/collect"
+)
+
+var renderRef = collect.SourceRef{
+	Repo:    "org/repo",
+	Commit:  "0123456789abcdef",
+	Modules: map[string]string{"github.com/org/repo": "."},
+}
+
+// syntheticReport builds a go tool cover HTML report of files with blocks coverage spans each
+func syntheticReport(files, blocks int) string {
+	var report strings.Builder
+	report.WriteString("<html><body>\n<div id=\"topbar\"><div id=\"nav\"><select id=\"files\">\n")
+	for i := range files {
+		fmt.Fprintf(&report, "<option value=\"file%d\">github.com/org/repo/pkg/file%d.go (50.0%%)</option>\n", i, i)
+	}
+	report.WriteString("</select></div><div id=\"legend\"></div></div>\n<div id=\"content\">\n")
+	for i := range files {
+		fmt.Fprintf(&report, "<pre class=\"file\" id=\"file%d\" style=\"display: none\">package pkg\n", i)
+		for j := range blocks {
+			fmt.Fprintf(&report, "\nfunc F%d() int <span class=\"cov%d\" title=\"%d\">{\n\treturn %d &gt; 1\n}</span>\n", j, j%2*8, j%2, j)
+		}
+		report.WriteString("</pre>\n")
+	}
+	report.WriteString("</div>\n</body>\n</html>\n")
+	return report.String()
+}
+
+// render renders a report to a string
+func render(renderer *collect.ReportRenderer, report string, decorate func(string) string) (string, error) {
+	var out strings.Builder
+	err := renderer.Render(&out, strings.NewReader(report), renderRef, decorate)
+	return out.String(), err
+}
+
+var _ = Describe("ReportRenderer", func() {
+	report := syntheticReport(40, 20)
+
+	It("should render the same report whatever the parallelism and memory budget", func() {
+		expected, err := render(collect.NewReportRenderer(1, 0), report, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(expected).To(ContainSubstring(`<option value="file39" data-source="https://github.com/org/repo/blob/0123456789abcdef/pkg/file39.go">`))
+		Expect(expected).To(ContainSubstring(`href="https://github.com/org/repo/blob/0123456789abcdef/pkg/file39.go#L39-L41"`))
+		Expect(expected).NotTo(ContainSubstring(`<span class="cov`))
+		Expect(expected).To(HaveSuffix("</script>\n</html>\n"))
+
+		for _, renderer := range []*collect.ReportRenderer{
+			collect.NewReportRenderer(8, 0),
+			collect.NewReportRenderer(4, 1),
+			{Workers: 3, MemoryBudget: 4096},
+		} {
+			rendered, err := render(renderer, report, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rendered).To(Equal(expected))
+		}
+	})
+
+	It("should only let decorate edit the header", func() {
+		rendered, err := render(collect.NewReportRenderer(2, 0), report, func(header string) string {
+			Expect(header).To(ContainSubstring(`<div id="legend">`))
+			Expect(header).NotTo(ContainSubstring(`<pre`))
+			return strings.Replace(header, `<div id="legend">`, `<div id="owners"></div><div id="legend">`, 1)
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rendered).To(ContainSubstring(`<div id="owners"></div><div id="source"`))
+	})
+
+	It("should add the themes and print stylesheet to the report's head", func() {
+		themed := strings.Replace(report, "<html>", "<html><head><style>body { background: black; }</style></head>", 1)
+		rendered, err := render(collect.NewReportRenderer(2, 0), themed, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rendered).To(MatchRegexp(`(?s)background: black; }</style>\n?<style>.*:root\[data-theme="dark"\].*@media print.*</style>\n<script>.*coverage-theme.*</script>\n</head>`))
+	})
+
+	It("should add the footer at the end of the body", func() {
+		renderer := collect.NewReportRenderer(2, 0)
+		renderer.Footer = `<div id="provenance"></div>`
+		rendered, err := render(renderer, report, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rendered).To(ContainSubstring("</div>\n<div id=\"provenance\"></div></body>"))
+	})
+
+	It("should copy a report without files as decorated", func() {
+		rendered, err := render(collect.NewReportRenderer(2, 0), "<html><div id=\"legend\"></div></html>\n", strings.ToUpper)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rendered).To(Equal("<HTML><DIV ID=\"LEGEND\"></DIV></HTML>\n"))
+	})
+
+	It("should fail on read errors", func() {
+		failing := io.MultiReader(strings.NewReader(report[:len(report)/2]), iotest.ErrReader(errors.New("disk failure")))
+		err := collect.NewReportRenderer(2, 0).Render(io.Discard, failing, renderRef, nil)
+		Expect(err).To(MatchError(ContainSubstring("disk failure")))
+	})
+})
+
+func BenchmarkReportRenderer(b *testing.B) {
+	report := syntheticReport(300, 200)
+	for _, renderer := range []*collect.ReportRenderer{
+		collect.NewReportRenderer(1, 0),
+		collect.NewReportRenderer(4, 0),
+		collect.NewReportRenderer(4, 1<<20),
+	} {
+		b.Run(fmt.Sprintf("workers=%d/budget=%dMiB", renderer.Workers, renderer.MemoryBudget>>20), func(b *testing.B) {
+			b.SetBytes(int64(len(report)))
+			b.ReportAllocs()
+			for range b.N {
+				if err := renderer.Render(io.Discard, strings.NewReader(report), renderRef, nil); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,998 @@
+package collect
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+	"github.com/konflux-ci/coverage-dashboard/internal/display"
+	"github.com/konflux-ci/coverage-dashboard/internal/features"
+	"github.com/konflux-ci/coverage-dashboard/internal/ghauth"
+	"github.com/konflux-ci/coverage-dashboard/internal/locale"
+	"github.com/konflux-ci/coverage-dashboard/internal/policy"
+	"github.com/konflux-ci/coverage-dashboard/internal/pr"
+)
+
+const (
+	// untestedStubFile is the placeholder test file used to measure packages without tests
+	untestedStubFile = "zzz_coverage_test.go"
+
+	// commandWaitDelay bounds how long a cancelled command may keep its output pipes open,
+	// e.g. when test binaries outlive a killed "go test"
+	commandWaitDelay = 10 * time.Second
+)
+
+// Config holds the configuration for a coverage collection run
+type Config struct {
+	ReposDir string
+	// ReposFile is the single-file layout listing repositories, merged with the files in ReposDir
+	ReposFile      string
+	CodeownersFile string
+	WorkspaceDir   string
+	ReportsDir     string
+	OutputFile     string
+	ManifestFile   string
+	RunURL         string
+	RetryFailed    bool
+	FromManifest   string
+	// PreviousManifest is the manifest of the last run, used to order repositories by past duration
+	PreviousManifest string
+	// Deadline bounds the whole run; repositories not started before it are marked as timed out
+	Deadline time.Duration
+	// RepoTimeout bounds each repository unless its configuration sets its own timeout
+	RepoTimeout time.Duration
+	// PublishDir is a git checkout of the published site; when set, results are pushed
+	// there as soon as each repository finishes
+	PublishDir string
+	// RegressionDelta is the coverage drop, in percentage points, reported as a regression
+	// unless a repository configures its own; zero disables regression detection
+	RegressionDelta float64
+	// Alerts decides how often open regressions are repeated and when they escalate
+	Alerts AlertPolicy
+	// Notifier, when set, is told about every alert at the end of the run
+	Notifier AlertNotifier
+	// SnapshotDir is a git checkout of the data branch; when set, the final results of the run are committed
+	// there as dashboard.json and one summary per repository
+	SnapshotDir string
+	// Policy, when set, supplies the defaults of repository configurations and restricts their overrides
+	Policy *policy.Policy
+	// VulnCheck runs govulncheck on every repository and reports whether tests cover its vulnerable call paths
+	VulnCheck bool
+	// GroupsFile defines named groups of repositories, aggregated and alerted on together
+	GroupsFile string
+	// ReportRetention decides how long the commit-stamped copies of reports are kept
+	ReportRetention ReportRetention
+	// ReportWorkers is the number of report files linked concurrently, one per CPU when zero
+	ReportWorkers int
+	// ReportMemoryBudget bounds the bytes of report files held at once, DefaultReportMemoryBudget when zero
+	ReportMemoryBudget int64
+	// FailureAlertAfter is the number of consecutive failed collections after which owners are alerted; zero never alerts
+	FailureAlertAfter int
+	// FailureNotifier, when set, is told about every collection failure alert at the end of the run
+	FailureNotifier FailureNotifier
+	// MaxAge is how old coverage may get before it is stale, unless a repository configures its own; zero never marks it stale
+	MaxAge time.Duration
+	// Locale is the BCP 47 tag of the locale the site and widgets format numbers and dates in; empty is locale.Default
+	Locale string
+	// ImageDigest is the digest of the container image the collector runs in, recorded in the provenance of the run
+	ImageDigest string
+	// Output draws a progress bar over the repositories, or prints only the summary; each line by default
+	Output display.Mode
+	// Features are the flags of the run, recorded in its manifest; unset flags have their defaults
+	Features features.Flags
+	// DockerHost is the Docker API socket the tests of repositories with needs_docker run against, e.g.
+	// unix:///var/run/docker.sock; without it, their packages whose tests start containers are left out
+	DockerHost string
+	// DependencyFreshness asks the module proxy for newer versions of the direct dependencies of every repository
+	DependencyFreshness bool
+	// OffboardedFile lists the offboarded repositories; the data of those past their retention is deleted
+	OffboardedFile string
+	// GitHubURL is the GitHub web host repositories are cloned from and reports link their sources to, e.g.
+	// https://github.example.com/ on GitHub Enterprise Server; ghauth.DefaultWebURL when empty
+	GitHubURL string
+}
+
+// Runner orchestrates coverage collection across all configured repositories
+type Runner struct {
+	config      Config
+	publisher   *Publisher
+	renderer    *ReportRenderer
+	collectRepo func(ctx context.Context, cfg config.RepositoryConfig, owners []string) (Result, error)
+}
+
+// NewRunner creates a new Runner instance
+func NewRunner(cfg Config) (*Runner, error) {
+	if cfg.RetryFailed && cfg.FromManifest == "" {
+		return nil, fmt.Errorf("--retry-failed requires --from-manifest")
+	}
+
+	r := &Runner{config: cfg, renderer: NewReportRenderer(cfg.ReportWorkers, cfg.ReportMemoryBudget)}
+	r.collectRepo = r.collectRepository
+	if cfg.PublishDir != "" && cfg.Features.Enabled(features.StatusPublishing) {
+		r.publisher = NewPublisher(cfg.PublishDir, cfg.ReportsDir)
+	}
+	return r, nil
+}
+
+// Run collects coverage for every configured repository (or only previously
+// failed ones with RetryFailed) and writes the manifest and coverage.json
+func (r *Runner) Run(ctx context.Context) error {
+	started := time.Now().UTC()
+	manifest, err := r.startManifest()
+	if err != nil {
+		return err
+	}
+
+	runCtx := ctx
+	if r.config.Deadline > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, r.config.Deadline)
+		defer cancel()
+		fmt.Printf("⏱️  Run deadline: %s\n", r.config.Deadline)
+	}
+
+	owners, err := config.LoadCodeowners(r.config.CodeownersFile)
+	if err != nil {
+		fmt.Printf("⚠️  Warning: failed to read %s, owners will be empty: %v\n", r.config.CodeownersFile, err)
+	}
+
+	repositories, err := config.LoadRepositories(r.config.ReposDir, r.config.ReposFile)
+	if err != nil {
+		return fmt.Errorf("failed to load repository configurations: %w", err)
+	}
+	for _, file := range repositories.InvalidFiles() {
+		fmt.Printf("⚠️  Warning: failed to parse %s: %v\n", file, repositories.Invalid[file])
+	}
+
+	groups, err := config.LoadGroups(r.config.GroupsFile)
+	if err != nil {
+		return fmt.Errorf("failed to load repository groups: %w", err)
+	}
+	var names []string
+	for _, entry := range repositories.Entries {
+		names = append(names, entry.Config.Name)
+	}
+	for _, group := range groups.Unmatched(names) {
+		fmt.Printf("⚠️  Warning: group %s matches no configured repository\n", group)
+	}
+
+	offboarded, err := config.LoadOffboarded(r.config.OffboardedFile)
+	if err != nil {
+		return fmt.Errorf("failed to load offboarded repositories: %w", err)
+	}
+	configured := make(map[string]bool, len(names))
+	for _, name := range names {
+		configured[name] = true
+	}
+	r.deleteOffboarded(offboarded.Due(started), configured)
+
+	// Repositories are identified by their config file, or repos file entry, across runs
+	var files []string
+	byKey := make(map[string]config.RepositoryEntry)
+	thresholds := make(map[string]float64)
+	maxAges := make(map[string]time.Duration)
+	for _, entry := range repositories.Entries {
+		if r.config.Policy != nil {
+			var problems []string
+			entry.Config, problems = r.config.Policy.Apply(entry.Config)
+			for _, problem := range problems {
+				fmt.Printf("⚠️  Warning: %s breaks the policy: %s\n", entry.Source(), problem)
+			}
+		}
+		files = append(files, entry.Key())
+		byKey[entry.Key()] = entry
+		thresholds[entry.Key()] = r.config.RegressionDelta
+		if entry.Config.RegressionDelta != nil {
+			thresholds[entry.Key()] = *entry.Config.RegressionDelta
+		}
+		maxAges[entry.Key()] = r.config.MaxAge
+		if entry.Config.MaxAge != "" {
+			maxAge, err := time.ParseDuration(entry.Config.MaxAge)
+			if err != nil {
+				fmt.Printf("⚠️  Warning: %s has an invalid max_age %q, using %s: %v\n", entry.Source(), entry.Config.MaxAge, r.config.MaxAge, err)
+			} else {
+				maxAges[entry.Key()] = maxAge
+			}
+		}
+	}
+
+	effective := make(map[string]config.RepositoryConfig, len(byKey))
+	for key, entry := range byKey {
+		effective[key] = entry.Config
+	}
+	provenance, err := NewProvenance(ctx, effective, r.config.ImageDigest)
+	if err != nil {
+		fmt.Printf("⚠️  Warning: failed to record the provenance of the run: %v\n", err)
+	} else {
+		fmt.Printf("🧾 Provenance: %s\n", provenance)
+		manifest.Provenance = &provenance
+		r.renderer.Footer = provenance.footer()
+	}
+	if len(r.config.Features) > 0 {
+		fmt.Printf("🚩 Features: %s\n", r.config.Features)
+		manifest.Features = r.config.Features.List()
+	}
+	if r.config.PublishDir != "" && r.publisher == nil {
+		fmt.Printf("⏸️  Not publishing results as repositories finish, %s is off\n", features.StatusPublishing)
+	}
+
+	var previous *Manifest
+	if !r.config.RetryFailed {
+		previous = r.previousManifest()
+		manifest.carryTimings(previous, files)
+		manifest.carryTrends(previous, files)
+		manifest.carryAlerts(previous, files)
+		manifest.carryRatchets(previous, files)
+		manifest.carryFailures(previous, files)
+	}
+
+	var pending []string
+	for _, file := range files {
+		if r.config.RetryFailed {
+			previous, found := manifest.find(file)
+			if !found || !previous.Failed() {
+				continue
+			}
+		}
+		pending = append(pending, file)
+	}
+
+	// Quick, reliable repositories first, based on the timings of previous runs
+	manifest.Plan = planSchedule(pending, manifest.Timings)
+	fmt.Printf("📋 Collection plan: %d repositories\n", len(manifest.Plan))
+
+	var collected []RepoRun
+	r.config.Output.Start(len(manifest.Plan))
+	for _, planned := range manifest.Plan {
+		file := planned.ConfigFile
+		entry := byKey[file]
+		cfg := entry.Config
+		repoOwners := entry.Owners(owners)
+		repoGroups := groups.Of(cfg.Name)
+
+		if runCtx.Err() != nil {
+			reason := "run deadline reached"
+			if ctx.Err() != nil {
+				reason = "run interrupted"
+			}
+			fmt.Printf("⏭️  Skipped %s: %s\n", cfg.Name, reason)
+			result := newResult(cfg.Name, StatusTimeout, repoOwners)
+			result.Groups = repoGroups
+			manifest.trackFreshness(previous, file, &result, maxAges[file], time.Now().UTC())
+			manifest.trackGoals(previous, file, cfg.Goals, &result, time.Now().UTC())
+			manifest.Record(RepoRun{
+				ConfigFile: file,
+				Result:     result,
+				Attempts:   1,
+				RunURL:     r.config.RunURL,
+				Error:      reason + " before collection started",
+			})
+			r.config.Output.Done(cfg.Name)
+			continue
+		}
+
+		fmt.Printf("→ Processing %s (from %s)...\n", cfg.Name, entry.Source())
+		run := r.collectOne(runCtx, file, cfg, repoOwners)
+		if ctx.Err() != nil {
+			// The result of an interrupted repository is incomplete; it is retried like a timeout
+			run.Result = newResult(cfg.Name, StatusTimeout, repoOwners)
+			run.Result.Groups = repoGroups
+			run.Error = "run interrupted during collection"
+			manifest.trackFreshness(previous, file, &run.Result, maxAges[file], time.Now().UTC())
+			manifest.trackGoals(previous, file, cfg.Goals, &run.Result, time.Now().UTC())
+			manifest.Record(run)
+			r.config.Output.Done(cfg.Name)
+			continue
+		}
+		run.Result.Groups = repoGroups
+		run.Result.Threshold = manifest.applyThreshold(file, cfg, run.Result, time.Now().UTC())
+		manifest.trackFreshness(previous, file, &run.Result, maxAges[file], time.Now().UTC())
+		manifest.trackGoals(previous, file, cfg.Goals, &run.Result, time.Now().UTC())
+		fmt.Printf("    → %s coverage: %s (status: %s)\n", cfg.Name, formatCoverage(run.Result.Coverage), run.Result.Status)
+		manifest.Record(run)
+		manifest.recordTiming(run)
+		manifest.recordTrend(run, time.Now().UTC())
+		if !cfg.IsPublic() {
+			r.unpublishReports(cfg.Name)
+		}
+		r.writeWidget(manifest, run)
+		r.archiveReport(run)
+		collected = append(collected, run)
+
+		r.checkpoint(ctx, manifest, manifest.progressDashboard(previous, len(collected)), cfg.Name)
+		r.config.Output.Done(cfg.Name)
+	}
+	r.config.Output.Finish()
+
+	manifest.FinishedAt = time.Now().UTC()
+
+	// Alerts, policies and snapshots need a complete run; the partial results are kept for --retry-failed
+	if ctx.Err() != nil {
+		fmt.Println("⏹️  Run interrupted, writing partial results")
+		if err := r.writeResults(manifest); err != nil {
+			return err
+		}
+		return fmt.Errorf("run interrupted: %w", ctx.Err())
+	}
+
+	alerts := manifest.evaluateAlerts(collected, previous, thresholds, r.config.Alerts, manifest.FinishedAt)
+	r.notifyAlerts(ctx, manifest, alerts)
+	failures := manifest.evaluateFailures(collected, r.config.FailureAlertAfter, manifest.FinishedAt)
+	failures = append(failures, manifest.evaluateStaleness(collected, maxAges)...)
+	r.notifyFailures(ctx, manifest, failures)
+	reportMissedGoals(newlyMissedGoals(collected, started))
+	r.evaluatePolicy(ctx, manifest)
+
+	if err := r.writeResults(manifest); err != nil {
+		return err
+	}
+
+	if r.config.SnapshotDir != "" {
+		if err := NewSnapshotPublisher(r.config.SnapshotDir).Publish(ctx, manifest.Dashboard(), manifest.FinishedAt); err != nil {
+			fmt.Printf("⚠️  Warning: failed to publish snapshot: %v\n", err)
+		} else {
+			fmt.Printf("🗄️  Committed snapshot to %s\n", r.config.SnapshotDir)
+		}
+	}
+
+	r.config.Output.Summary()
+	printSummary(manifest)
+	return nil
+}
+
+// printSummary prints the outcome of the repositories planned in a run, all --quiet prints
+func printSummary(manifest *Manifest) {
+	statuses := make(map[string]int)
+	for _, planned := range manifest.Plan {
+		if run, found := manifest.find(planned.ConfigFile); found {
+			statuses[run.Result.Status]++
+		}
+	}
+
+	fmt.Println()
+	fmt.Println("📊 Collection Summary:")
+	fmt.Printf("  • Repositories: %d in %s\n", len(manifest.Plan), manifest.FinishedAt.Sub(manifest.StartedAt).Round(time.Second))
+	for _, status := range []string{StatusOK, StatusPartial, StatusNoTests, StatusUnsupported, StatusFailed, StatusTimeout} {
+		if statuses[status] > 0 {
+			fmt.Printf("  • %s: %d\n", status, statuses[status])
+		}
+	}
+	if len(manifest.Regressions) > 0 {
+		fmt.Printf("  • Regressions: %d\n", len(manifest.Regressions))
+	}
+	if len(manifest.PolicyViolations) > 0 {
+		fmt.Printf("  • Rego policy violations: %d\n", len(manifest.PolicyViolations))
+	}
+}
+
+// writeResults writes the manifest and coverage.json of a finished run
+func (r *Runner) writeResults(manifest *Manifest) error {
+	if err := writeJSON(r.config.ManifestFile, manifest); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	if err := writeJSON(r.config.OutputFile, manifest.Dashboard()); err != nil {
+		return fmt.Errorf("failed to write coverage data: %w", err)
+	}
+	return nil
+}
+
+// checkpoint writes the partial results of a run in progress and publishes them when configured
+// Failures only produce warnings; the final results are written at the end of the run
+func (r *Runner) checkpoint(ctx context.Context, manifest *Manifest, dashboard Dashboard, repo string) {
+	if err := writeJSON(r.config.ManifestFile, manifest); err != nil {
+		fmt.Printf("    ⚠️  Warning: failed to write manifest: %v\n", err)
+	}
+	if err := writeJSON(r.config.OutputFile, dashboard); err != nil {
+		fmt.Printf("    ⚠️  Warning: failed to write coverage data: %v\n", err)
+	}
+
+	if r.publisher == nil {
+		return
+	}
+	progress := dashboard.Progress
+	message := fmt.Sprintf("Update coverage of %s (%d/%d)", repo, progress.Completed, progress.Total)
+	if err := r.publisher.Publish(ctx, manifest, dashboard, message); err != nil {
+		fmt.Printf("    ⚠️  Warning: failed to publish partial results: %v\n", err)
+		return
+	}
+	fmt.Printf("    📤 Published %s (%d/%d)\n", repo, progress.Completed, progress.Total)
+}
+
+// startManifest loads the manifest to retry from, or starts a fresh one
+func (r *Runner) startManifest() (*Manifest, error) {
+	if !r.config.RetryFailed {
+		return &Manifest{
+			RunURL:    r.config.RunURL,
+			StartedAt: time.Now().UTC(),
+			Repos:     []RepoRun{},
+			Locale:    r.config.Locale,
+		}, nil
+	}
+
+	manifest, err := LoadManifest(r.config.FromManifest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load manifest to retry from: %w", err)
+	}
+
+	failed := 0
+	for _, run := range manifest.Repos {
+		if run.Failed() {
+			failed++
+		}
+	}
+	fmt.Printf("🔁 Retrying %d failed repositories from %s\n", failed, r.config.FromManifest)
+
+	manifest.Locale = r.config.Locale
+	return manifest, nil
+}
+
+// previousManifest returns the manifest of the last run, if available
+func (r *Runner) previousManifest() *Manifest {
+	if r.config.PreviousManifest == "" {
+		return nil
+	}
+
+	previous, err := LoadManifest(r.config.PreviousManifest)
+	if err != nil {
+		fmt.Printf("⚠️  Warning: failed to load previous manifest, scheduling without history: %v\n", err)
+		return nil
+	}
+	return previous
+}
+
+// find returns the recorded run for a config file
+func (m *Manifest) find(configFile string) (RepoRun, bool) {
+	for _, run := range m.Repos {
+		if run.ConfigFile == configFile {
+			return run, true
+		}
+	}
+	return RepoRun{}, false
+}
+
+// collectOne collects a single repository within its timeout and records how long it took
+func (r *Runner) collectOne(ctx context.Context, configFile string, cfg config.RepositoryConfig, owners []string) RepoRun {
+	timeout := r.config.RepoTimeout
+	if cfg.Timeout != "" {
+		parsed, err := time.ParseDuration(cfg.Timeout)
+		if err != nil {
+			fmt.Printf("    ⚠️  Warning: invalid timeout %q, using %s: %v\n", cfg.Timeout, timeout, err)
+		} else {
+			timeout = parsed
+		}
+	}
+
+	repoCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		repoCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	result, err := r.collectRepo(repoCtx, cfg, owners)
+	elapsed := time.Since(start)
+
+	// Timed out repositories are reported explicitly rather than with partial coverage
+	if repoCtx.Err() == context.DeadlineExceeded {
+		result = newResult(cfg.Name, StatusTimeout, owners)
+		if ctx.Err() == context.DeadlineExceeded {
+			err = fmt.Errorf("run deadline reached after %s", elapsed.Round(time.Second))
+		} else {
+			err = fmt.Errorf("timed out after %s", timeout)
+		}
+	}
+
+	run := RepoRun{
+		ConfigFile: configFile,
+		Result:     result,
+		Duration:   round1(elapsed.Seconds()),
+		Attempts:   1,
+		RunURL:     r.config.RunURL,
+	}
+	if err != nil {
+		fmt.Printf("    ❌ %v\n", err)
+		run.Error = err.Error()
+	}
+	return run
+}
+
+// collectRepository clones a repository, runs its tests and computes coverage
+func (r *Runner) collectRepository(ctx context.Context, cfg config.RepositoryConfig, owners []string) (Result, error) {
+	result := newResult(cfg.Name, StatusOK, owners)
+	result.Private = !cfg.IsPublic()
+
+	// Discovery tracks repositories of other languages before their coverage can be collected
+	if !cfg.IsGo() {
+		fmt.Printf("    ⏭️  Skipped: coverage of %s repositories is not collected yet\n", cfg.Language)
+		result.Status = StatusUnsupported
+		return result, nil
+	}
+
+	repoDir, err := r.cloneRepository(ctx, cfg.Name)
+	if err != nil {
+		result.Status = StatusFailed
+		return result, err
+	}
+
+	ref, err := ResolveSourceRef(ctx, repoDir, cfg.Name)
+	ref.WebURL = r.config.GitHubURL
+	if err != nil {
+		fmt.Printf("    ⚠️  Warning: report will not link to source: %v\n", err)
+	}
+	result.Commit = ref.Commit
+
+	// Check if repo needs CRDs downloaded for testing (e.g., integration-service)
+	if makefile, err := os.ReadFile(filepath.Join(repoDir, "Makefile")); err == nil && bytes.Contains(makefile, []byte("download-crds:")) {
+		fmt.Println("    Downloading CRDs for testing...")
+		if err := runCommand(ctx, repoDir, "make", "download-crds"); err != nil {
+			fmt.Println("    ⚠️  CRD download failed, continuing anyway")
+		}
+	}
+
+	result.GoVersion, result.Toolchain = goDirectives(repoDir, cfg.EffectiveModules())
+	if r.config.DependencyFreshness {
+		if result.Freshness, err = dependencyFreshness(ctx, repoDir, cfg.EffectiveModules()); err != nil {
+			fmt.Printf("    ⚠️  Warning: dependency freshness unknown: %v\n", err)
+		} else {
+			fmt.Printf("    🌱 Dependencies: %d of %d direct dependencies have newer versions\n", result.Freshness.Outdated, result.Freshness.Direct)
+			// The dependencies of private repositories are not published
+			if result.Private {
+				result.Freshness.Updates = nil
+			}
+		}
+	}
+
+	// Repositories configured with modules are collected module by module, then as a whole
+	multiModule := len(cfg.Modules) > 0
+	var runs []moduleRun
+	for _, module := range cfg.EffectiveModules() {
+		if multiModule {
+			fmt.Printf("    📦 Module %s\n", module.Path)
+		}
+		run, err := r.collectModule(ctx, repoDir, module, cfg)
+		if err != nil {
+			result.Status = StatusFailed
+			if multiModule {
+				err = fmt.Errorf("module %s: %w", module.Path, err)
+			}
+			return result, err
+		}
+		runs = append(runs, run)
+	}
+
+	var profiles []string
+	for _, run := range runs {
+		if run.profile != "" {
+			profiles = append(profiles, run.profile)
+		}
+		if run.status == StatusFailed {
+			result.Status = StatusFailed
+		}
+		result.Uncollected = append(result.Uncollected, run.uncollected...)
+	}
+	// Failures take precedence, as packages left out do not explain them
+	if len(result.Uncollected) > 0 && result.Status == StatusOK {
+		result.Status = StatusPartial
+	}
+	if len(profiles) == 0 {
+		if result.Status != StatusPartial {
+			result.Status = StatusNoTests
+		}
+		return result, nil
+	}
+
+	// Modules without tests count as untested, like packages without tests
+	stats := make(map[string]*PackageStats)
+	untested := 0
+	for i := range runs {
+		run := &runs[i]
+		if run.profile == "" && multiModule {
+			run.untested = r.countUntestedStatements(ctx, run.dir, run.included, nil, run.replay)
+		}
+		for pkg, s := range run.stats {
+			stats[pkg] = s
+		}
+		untested += run.untested
+	}
+
+	profile := filepath.Join(repoDir, "coverage.out")
+	if err := MergeProfiles(profile, profiles); err != nil {
+		result.Status = StatusFailed
+		return result, fmt.Errorf("failed to merge coverage profiles: %w", err)
+	}
+	if err := mergeReplayProfiles(repoDir, runs); err != nil {
+		fmt.Printf("    ⚠️  Warning: raw profile will not be published: %v\n", err)
+	}
+	if multiModule {
+		result.Modules = moduleCoverage(runs)
+		if err := writeWorkspace(ctx, repoDir, runs); err != nil {
+			fmt.Printf("    ⚠️  Warning: report may miss nested modules: %v\n", err)
+		} else if resolved, err := ResolveSourceRef(ctx, repoDir, cfg.Name); err == nil {
+			ref = resolved
+			ref.WebURL = r.config.GitHubURL
+		}
+	}
+
+	// Calculate total coverage including packages without coverage data
+	tested := totalStats(stats)
+	total := tested.Total + untested
+
+	coverage := 0.0
+	if total > 0 {
+		coverage = round1(float64(tested.Covered) / float64(total) * 100)
+		fmt.Printf("    Coverage: %d covered / %d total = %.1f%%\n", tested.Covered, total, coverage)
+		if untested > 0 {
+			fmt.Printf("    (Including %d statements from untested packages)\n", untested)
+		}
+	}
+	result.Coverage = &coverage
+	result.Statements = total
+	result.Packages = packageCoverage(stats)
+
+	// The dashboard site is public, so only the coverage of private repositories is published
+	if result.Private {
+		fmt.Printf("    🔒 %s repository, its detailed reports are not published\n", cfg.Visibility)
+		result.Packages = []PackageCoverage{}
+		result.Modules = nil
+		return result, nil
+	}
+
+	var vulns *VulnerabilityReport
+	if r.config.VulnCheck {
+		fmt.Println("    Checking vulnerable call paths...")
+		if vulns, err = analyzeVulnerabilities(ctx, repoDir, profile, ref); err != nil {
+			fmt.Printf("    ⚠️  Warning: vulnerability analysis failed: %v\n", err)
+		} else if len(vulns.Paths) > 0 {
+			fmt.Printf("    🛡️  %d vulnerable call paths, %d not covered by tests\n", len(vulns.Paths), vulns.Uncovered())
+		}
+	}
+
+	goals := NewGoalProgress(cfg.Goals, result.Coverage, time.Now().UTC())
+	// Without its report the repository is collected again by --retry-failed
+	if err := r.writeReport(ctx, repoDir, ref, owners, vulns, goals); err != nil {
+		result.Status = StatusFailed
+		return result, err
+	}
+
+	return result, nil
+}
+
+// newResult creates a result without coverage data
+func newResult(repo, status string, owners []string) Result {
+	if owners == nil {
+		owners = []string{}
+	}
+	return Result{
+		Repo:     repo,
+		Status:   status,
+		Packages: []PackageCoverage{},
+		Owners:   owners,
+	}
+}
+
+// cloneRepository shallow-clones a repository into the workspace
+func (r *Runner) cloneRepository(ctx context.Context, repoName string) (string, error) {
+	return CloneRepository(ctx, r.config.WorkspaceDir, r.config.GitHubURL, repoName)
+}
+
+// CloneRepository shallow-clones a repository from the GitHub web host webURL, github.com when empty, into
+// workspaceDir, replacing any earlier clone
+func CloneRepository(ctx context.Context, workspaceDir, webURL, repoName string) (string, error) {
+	cloneURL, err := CloneURL(webURL, repoName)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(workspaceDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create workspace: %w", err)
+	}
+
+	workspace, err := filepath.Abs(workspaceDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve workspace: %w", err)
+	}
+
+	repoDir := filepath.Join(workspace, filepath.Base(repoName))
+	if err := os.RemoveAll(repoDir); err != nil {
+		return "", fmt.Errorf("failed to clean %s: %w", repoDir, err)
+	}
+
+	fmt.Printf("→ Cloning %s...\n", repoName)
+	if _, err := pr.RunGitCommand(ctx, workspace, "clone", "--depth", "1", cloneURL, filepath.Base(repoDir)); err != nil {
+		return "", fmt.Errorf("failed to clone %s: %w", repoName, err)
+	}
+
+	return repoDir, nil
+}
+
+// CloneURL returns the clone URL of a configured repository on the GitHub web host webURL, github.com when empty
+// Names that could make git clone another host, or clone outside the workspace, and hosts not served over https
+// are refused
+func CloneURL(webURL, repoName string) (string, error) {
+	if !config.ValidRepoName(repoName) {
+		return "", fmt.Errorf("refusing to clone %q: not %s", repoName, config.RepoNameRule)
+	}
+	if webURL == "" {
+		webURL = ghauth.DefaultWebURL
+	}
+	web, err := url.Parse(webURL)
+	if err != nil || web.Host == "" || web.User != nil {
+		return "", fmt.Errorf("refusing to clone %q: %q is not a GitHub host URL", repoName, webURL)
+	}
+	if web.Scheme != "https" {
+		return "", fmt.Errorf("refusing to clone %q: %s is not served over https", repoName, webURL)
+	}
+	cloneURL := fmt.Sprintf("https://%s/%s.git", web.Host, repoName)
+	parsed, err := url.Parse(cloneURL)
+	if err != nil || parsed.Scheme != "https" || parsed.Host != web.Host || parsed.User != nil ||
+		parsed.RawQuery != "" || parsed.Fragment != "" || parsed.Path != "/"+repoName+".git" {
+		return "", fmt.Errorf("refusing to clone %q: %s is not a %s repository URL", repoName, cloneURL, web.Host)
+	}
+	return cloneURL, nil
+}
+
+// countUntestedStatements counts statements of included packages missing from the coverage profile
+// Their blocks, all uncovered, are appended to the raw profile at replay
+func (r *Runner) countUntestedStatements(ctx context.Context, repoDir string, packages []string, covered map[string]*PackageStats, replay string) int {
+	untested := 0
+	fmt.Println("    Counting statements in untested packages...")
+
+	for _, pkg := range packages {
+		if ctx.Err() != nil {
+			break
+		}
+		if _, ok := covered[pkg]; ok {
+			continue
+		}
+		fmt.Printf("      Analyzing %s...\n", pkg)
+
+		info := goList(ctx, repoDir, "-f", "{{.Dir}}|{{.Name}}|{{if or .TestGoFiles .XTestGoFiles}}yes{{end}}", pkg)
+		if len(info) != 1 {
+			continue
+		}
+		parts := strings.Split(info[0], "|")
+		if len(parts) != 3 || parts[0] == "" || parts[1] == "" {
+			continue
+		}
+		pkgDir, pkgName, hasTests := parts[0], parts[1], parts[2] == "yes"
+
+		stub := filepath.Join(pkgDir, untestedStubFile)
+		if !hasTests {
+			// No test files - create minimal internal test (same package to see unexported code)
+			content := fmt.Sprintf("package %s\nimport \"testing\"\nfunc TestCoverage(t *testing.T) {}\n", pkgName)
+			if err := os.WriteFile(stub, []byte(content), 0644); err != nil {
+				fmt.Printf("        ⚠️  Failed to generate coverage for %s\n", pkg)
+				continue
+			}
+		}
+
+		// Run with -run=^$ to match no real tests
+		pkgProfile := filepath.Join(repoDir, "pkg_coverage.out")
+		if err := runQuiet(ctx, repoDir, "go", "test", "-run=^$", "-coverprofile="+pkgProfile, pkg); err == nil {
+			isStub := func(fileName string) bool { return filepath.Base(fileName) == untestedStubFile }
+			stats, err := ProfileStats(pkgProfile, isStub)
+			if err == nil {
+				stmts := totalStats(stats).Total
+				untested += stmts
+				fmt.Printf("        → %d statements (0%% coverage)\n", stmts)
+				if err := appendToProfile(replay, pkgProfile, isStub); err != nil {
+					fmt.Printf("        ⚠️  Failed to record raw profile of %s: %v\n", pkg, err)
+				}
+			}
+		} else {
+			fmt.Printf("        ⚠️  Failed to generate coverage for %s\n", pkg)
+		}
+
+		// Cleanup
+		os.Remove(pkgProfile)
+		if !hasTests {
+			os.Remove(stub)
+		}
+	}
+
+	return untested
+}
+
+// writeReport generates the HTML coverage report, uncovered regions and vulnerable call paths exports in the reports directory
+func (r *Runner) writeReport(ctx context.Context, repoDir string, ref SourceRef, owners []string, vulns *VulnerabilityReport, goals []GoalProgress) error {
+	if err := runQuiet(ctx, repoDir, "go", "tool", "cover", "-html=coverage.out", "-o", "coverage.html"); err != nil {
+		return fmt.Errorf("failed to generate HTML report: %w", err)
+	}
+
+	targetDir := filepath.Join(r.config.ReportsDir, ref.Repo)
+	if err := os.MkdirAll(targetDir, 0755); err != nil {
+		return fmt.Errorf("failed to create report directory: %w", err)
+	}
+
+	report, err := os.Open(filepath.Join(repoDir, "coverage.html"))
+	if err != nil {
+		return fmt.Errorf("failed to read HTML report: %w", err)
+	}
+	defer report.Close()
+	index, err := os.Create(filepath.Join(targetDir, "index.html"))
+	if err != nil {
+		return fmt.Errorf("failed to create HTML report: %w", err)
+	}
+	loc := locale.Lookup(r.config.Locale)
+	err = r.renderer.Render(index, report, ref, func(header string) string {
+		return localizeFileCoverage(addGoalProgress(addVulnerablePaths(addOwnerLinks(header, owners), vulns), goals, loc), loc)
+	})
+	if closeErr := index.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := publishProfiles(repoDir, targetDir); err != nil {
+		fmt.Printf("    ⚠️  Warning: failed to publish coverage profiles: %v\n", err)
+	}
+	if err := writeUncovered(targetDir, filepath.Join(repoDir, "coverage.out"), ref); err != nil {
+		fmt.Printf("    ⚠️  Warning: failed to export uncovered regions: %v\n", err)
+	}
+	if vulns != nil {
+		if err := writeJSON(filepath.Join(targetDir, VulnerablePathsFile), vulns); err != nil {
+			fmt.Printf("    ⚠️  Warning: failed to export vulnerable call paths: %v\n", err)
+		}
+	}
+	return nil
+}
+
+// writeWidget writes the embeddable widgets of a collected repository next to its report, except for private ones
+// Failures only produce warnings
+func (r *Runner) writeWidget(manifest *Manifest, run RepoRun) {
+	if r.config.ReportsDir == "" || run.Result.Private {
+		return
+	}
+	widget := NewWidget(run.Result, manifest.Trends[run.ConfigFile], time.Now().UTC())
+	widget.Locale = r.config.Locale
+	if err := writeWidget(r.config.ReportsDir, widget); err != nil {
+		fmt.Printf("    ⚠️  Warning: failed to write widgets: %v\n", err)
+	}
+}
+
+// unpublishReports deletes the reports, with the archived ones, published while a repository was public
+// Failures only produce warnings
+func (r *Runner) unpublishReports(repo string) {
+	if r.config.ReportsDir == "" {
+		return
+	}
+	deleted, err := DeleteOffboarded(r.config.ReportsDir, "", repo)
+	switch {
+	case err != nil:
+		fmt.Printf("    ⚠️  Warning: failed to delete the published reports of %s: %v\n", repo, err)
+	case deleted:
+		fmt.Printf("    🔒 Deleted the reports published while %s was public\n", repo)
+	}
+}
+
+// archiveReport keeps an immutable, commit-stamped copy of the report written by a successful collection
+// Failures only produce warnings
+func (r *Runner) archiveReport(run RepoRun) {
+	if r.config.ReportsDir == "" || run.Error != "" || run.Result.Coverage == nil || run.Result.Commit == "" || run.Result.Private {
+		return
+	}
+	pruned, err := ArchiveReport(r.config.ReportsDir, run.Result, time.Now().UTC(), r.config.ReportRetention)
+	if err != nil {
+		fmt.Printf("    ⚠️  Warning: failed to archive report: %v\n", err)
+		return
+	}
+	if len(pruned) > 0 {
+		fmt.Printf("    🗑️  Pruned %d archived reports past retention\n", len(pruned))
+	}
+}
+
+// addOwnerLinks links owning teams/users from the report back to their dashboard views
+func addOwnerLinks(report string, owners []string) string {
+	if len(owners) == 0 {
+		return report
+	}
+
+	var links strings.Builder
+	for _, owner := range owners {
+		fmt.Fprintf(&links, ` <a href="%s?owner=%s" style="color: rgb(168, 198, 255);">%s</a>`, dashboardFromReport, strings.TrimPrefix(owner, "@"), owner)
+	}
+	header := fmt.Sprintf(`<div id="owners" style="float: right; margin: 12px 10px 0 0;">Owners:%s</div>`, links.String())
+
+	return strings.Replace(report, `<div id="legend">`, header+`<div id="legend">`, 1)
+}
+
+// fileCoveragePattern matches the coverage go tool cover lists next to each file of a report, e.g. "(72.5%)"
+var fileCoveragePattern = regexp.MustCompile(`\((\d+\.\d)%\)</option>`)
+
+// localizeFileCoverage formats the coverage of each file listed in a report's header in the site's locale
+func localizeFileCoverage(header string, loc locale.Locale) string {
+	return fileCoveragePattern.ReplaceAllStringFunc(header, func(match string) string {
+		value, err := strconv.ParseFloat(fileCoveragePattern.FindStringSubmatch(match)[1], 64)
+		if err != nil {
+			return match
+		}
+		return "(" + loc.Percent(value) + ")</option>"
+	})
+}
+
+// formatCoverage formats a coverage percentage for log output
+func formatCoverage(coverage *float64) string {
+	if coverage == nil {
+		return "N/A"
+	}
+	return fmt.Sprintf("%.1f%%", *coverage)
+}
+
+// goList runs go list in a repository and returns the non-empty output lines
+// Errors are ignored so that partially broken modules still report what they can
+func goList(ctx context.Context, dir string, args ...string) []string {
+	cmd := exec.CommandContext(ctx, "go", append([]string{"list"}, args...)...)
+	cmd.Dir = dir
+	cmd.WaitDelay = commandWaitDelay
+	output, _ := cmd.Output()
+
+	var lines []string
+	for _, line := range strings.Split(string(output), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// runCommand runs a command in dir, streaming its output to the run log
+func runCommand(ctx context.Context, dir, name string, args ...string) error {
+	return runCommandEnv(ctx, dir, nil, name, args...)
+}
+
+// runCommandEnv runs a command in dir like runCommand, with env added to the environment
+func runCommandEnv(ctx context.Context, dir string, env []string, name string, args ...string) error {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	cmd.WaitDelay = commandWaitDelay
+	if env != nil {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// runQuiet runs a command in dir, including its output in the error on failure
+func runQuiet(ctx context.Context, dir, name string, args ...string) error {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	cmd.WaitDelay = commandWaitDelay
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s %s failed: %w\n%s", name, strings.Join(args, " "), err, string(output))
+	}
+	return nil
+}
+
+// evaluatePolicy records the violations of the policy's Rego gates for the run's results
+// Evaluation failures only produce warnings
+func (r *Runner) evaluatePolicy(ctx context.Context, manifest *Manifest) {
+	if r.config.Policy == nil || r.config.Policy.Rego == nil {
+		return
+	}
+
+	violations, err := r.config.Policy.Evaluate(ctx, manifest.Dashboard())
+	if err != nil {
+		fmt.Printf("⚠️  Warning: failed to evaluate the Rego policy: %v\n", err)
+		return
+	}
+	manifest.PolicyViolations = violations
+	if len(violations) == 0 {
+		fmt.Println("🚦 Rego policy passed")
+		return
+	}
+	fmt.Printf("🚦 %d Rego policy violations:\n", len(violations))
+	for _, violation := range violations {
+		fmt.Printf("    → %s\n", violation)
+	}
+}
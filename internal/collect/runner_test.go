@@ -0,0 +1,886 @@
+package collect
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+	"github.com/konflux-ci/coverage-dashboard/internal/features"
+)
+
+var _ = Describe("Runner", func() {
+	var (
+		tempDir  string
+		reposDir string
+		cfg      Config
+		attempts map[string]int
+	)
+
+	// stubCollect fails every repository whose name is in failing
+	stubCollect := func(failing ...string) func(context.Context, config.RepositoryConfig, []string) (Result, error) {
+		return func(_ context.Context, repoCfg config.RepositoryConfig, owners []string) (Result, error) {
+			attempts[repoCfg.Name]++
+			coverage := 42.0
+			result := Result{Repo: repoCfg.Name, Coverage: &coverage, Status: StatusOK, Packages: []PackageCoverage{}, Owners: owners, Private: !repoCfg.IsPublic()}
+			for _, name := range failing {
+				if name == repoCfg.Name {
+					result.Status = StatusFailed
+					result.Coverage = nil
+				}
+			}
+			return result, nil
+		}
+	}
+
+	BeforeEach(func() {
+		tempDir = GinkgoT().TempDir()
+		reposDir = filepath.Join(tempDir, "repos")
+		Expect(os.MkdirAll(reposDir, 0755)).To(Succeed())
+		for _, name := range []string{"alpha", "beta"} {
+			content := "name: konflux-ci/" + name + "\n"
+			Expect(os.WriteFile(filepath.Join(reposDir, name+".yaml"), []byte(content), 0644)).To(Succeed())
+		}
+		codeowners := "/repos/alpha.yaml @konflux-ci/alpha-team\n"
+		Expect(os.WriteFile(filepath.Join(tempDir, "CODEOWNERS"), []byte(codeowners), 0644)).To(Succeed())
+
+		cfg = Config{
+			ReposDir:       reposDir,
+			CodeownersFile: filepath.Join(tempDir, "CODEOWNERS"),
+			OutputFile:     filepath.Join(tempDir, "coverage.json"),
+			ManifestFile:   filepath.Join(tempDir, "run-manifest.json"),
+			RunURL:         "https://example.com/runs/1",
+		}
+		attempts = make(map[string]int)
+	})
+
+	It("should collect repositories listed in the single repos file", func() {
+		cfg.ReposFile = filepath.Join(tempDir, "repos.yaml")
+		Expect(os.WriteFile(cfg.ReposFile, []byte("- name: konflux-ci/delta\n"), 0644)).To(Succeed())
+		codeowners := "/repos/alpha.yaml @konflux-ci/alpha-team\n/repos.yaml @konflux-ci/vanguard\n"
+		Expect(os.WriteFile(cfg.CodeownersFile, []byte(codeowners), 0644)).To(Succeed())
+
+		runner, err := NewRunner(cfg)
+		Expect(err).NotTo(HaveOccurred())
+		runner.collectRepo = stubCollect()
+		Expect(runner.Run(context.Background())).To(Succeed())
+
+		manifest, err := LoadManifest(cfg.ManifestFile)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(manifest.Repos).To(HaveLen(3))
+		delta, found := manifest.find("repos.yaml#konflux-ci/delta")
+		Expect(found).To(BeTrue())
+		Expect(delta.Result.Owners).To(Equal([]string{"@konflux-ci/vanguard"}))
+	})
+
+	It("should write widgets with the coverage trend carried from the previous run", func() {
+		cfg.ReportsDir = filepath.Join(tempDir, "reports")
+		cfg.PreviousManifest = filepath.Join(tempDir, "previous-manifest.json")
+		previous := Manifest{Trends: map[string][]TrendPoint{"alpha.yaml": {{Coverage: 40}}}}
+		data, err := json.Marshal(previous)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.WriteFile(cfg.PreviousManifest, data, 0644)).To(Succeed())
+
+		runner, err := NewRunner(cfg)
+		Expect(err).NotTo(HaveOccurred())
+		runner.collectRepo = stubCollect("konflux-ci/beta")
+		Expect(runner.Run(context.Background())).To(Succeed())
+
+		data, err = os.ReadFile(filepath.Join(cfg.ReportsDir, "konflux-ci/alpha", WidgetFile))
+		Expect(err).NotTo(HaveOccurred())
+		var widget Widget
+		Expect(json.Unmarshal(data, &widget)).To(Succeed())
+		Expect(widget.Trend).To(HaveLen(2))
+		Expect(widget.Trend[1].Coverage).To(Equal(42.0))
+		Expect(filepath.Join(cfg.ReportsDir, "konflux-ci/alpha", WidgetFragmentFile)).To(BeAnExistingFile())
+
+		data, err = os.ReadFile(filepath.Join(cfg.ReportsDir, "konflux-ci/beta", WidgetFile))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(json.Unmarshal(data, &widget)).To(Succeed())
+		Expect(widget.Status).To(Equal(StatusFailed))
+		Expect(widget.Trend).To(BeEmpty())
+	})
+
+	It("should delete the reports published before a repository turned private", func() {
+		cfg.ReportsDir = filepath.Join(tempDir, "reports")
+		Expect(os.WriteFile(filepath.Join(reposDir, "beta.yaml"), []byte("name: konflux-ci/beta\nvisibility: private\n"), 0644)).To(Succeed())
+		for _, repo := range []string{"konflux-ci/alpha", "konflux-ci/beta"} {
+			Expect(os.MkdirAll(filepath.Join(cfg.ReportsDir, repo), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(cfg.ReportsDir, repo, "index.html"), []byte("report"), 0644)).To(Succeed())
+		}
+
+		runner, err := NewRunner(cfg)
+		Expect(err).NotTo(HaveOccurred())
+		runner.collectRepo = stubCollect()
+		Expect(runner.Run(context.Background())).To(Succeed())
+
+		Expect(filepath.Join(cfg.ReportsDir, "konflux-ci/alpha", "index.html")).To(BeAnExistingFile())
+		Expect(filepath.Join(cfg.ReportsDir, "konflux-ci/alpha", WidgetFile)).To(BeAnExistingFile())
+		Expect(filepath.Join(cfg.ReportsDir, "konflux-ci/beta")).NotTo(BeAnExistingFile())
+	})
+
+	It("should publish no files of a private repository", func() {
+		cfg.ReportsDir = filepath.Join(tempDir, "reports")
+		Expect(os.WriteFile(filepath.Join(reposDir, "beta.yaml"), []byte("name: konflux-ci/beta\nvisibility: private\n"), 0644)).To(Succeed())
+
+		runner, err := NewRunner(cfg)
+		Expect(err).NotTo(HaveOccurred())
+		runner.collectRepo = stubCollect()
+		Expect(runner.Run(context.Background())).To(Succeed())
+
+		var published []string
+		Expect(filepath.WalkDir(cfg.ReportsDir, func(path string, entry fs.DirEntry, err error) error {
+			if err == nil && !entry.IsDir() {
+				published = append(published, path)
+			}
+			return err
+		})).To(Succeed())
+		Expect(published).NotTo(BeEmpty())
+		Expect(published).NotTo(ContainElement(ContainSubstring("beta")))
+	})
+
+	It("should tag results with their groups and aggregate each group", func() {
+		cfg.GroupsFile = filepath.Join(tempDir, "groups.yaml")
+		groups := "- name: stack\n  repos: [konflux-ci/*]\n- name: core\n  repos: [konflux-ci/alpha]\n"
+		Expect(os.WriteFile(cfg.GroupsFile, []byte(groups), 0644)).To(Succeed())
+
+		runner, err := NewRunner(cfg)
+		Expect(err).NotTo(HaveOccurred())
+		runner.collectRepo = stubCollect("konflux-ci/beta")
+		Expect(runner.Run(context.Background())).To(Succeed())
+
+		data, err := os.ReadFile(cfg.OutputFile)
+		Expect(err).NotTo(HaveOccurred())
+		var dashboard Dashboard
+		Expect(json.Unmarshal(data, &dashboard)).To(Succeed())
+		Expect(dashboard.Data[0].Groups).To(Equal([]string{"core", "stack"}))
+		Expect(dashboard.Data[1].Groups).To(Equal([]string{"stack"}))
+
+		coverage := 42.0
+		Expect(dashboard.Groups).To(Equal([]GroupSummary{
+			{Name: "core", Repos: 1, Measured: 1, Coverage: &coverage},
+			{Name: "stack", Repos: 2, Measured: 1, Coverage: &coverage, Failed: 1},
+		}))
+	})
+
+	It("should require a manifest when retrying failed repositories", func() {
+		cfg.RetryFailed = true
+		_, err := NewRunner(cfg)
+		Expect(err).To(MatchError(ContainSubstring("--from-manifest")))
+	})
+
+	It("should record every repository in the manifest and coverage data", func() {
+		runner, err := NewRunner(cfg)
+		Expect(err).NotTo(HaveOccurred())
+		runner.collectRepo = stubCollect("konflux-ci/beta")
+
+		Expect(runner.Run(context.Background())).To(Succeed())
+
+		manifest, err := LoadManifest(cfg.ManifestFile)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(manifest.RunURL).To(Equal("https://example.com/runs/1"))
+		Expect(manifest.Repos).To(HaveLen(2))
+		Expect(manifest.Repos[0].Result.Owners).To(Equal([]string{"@konflux-ci/alpha-team"}))
+		Expect(manifest.Repos[1].Failed()).To(BeTrue())
+		Expect(cfg.OutputFile).To(BeAnExistingFile())
+	})
+
+	It("should only re-attempt failed repositories and merge the results", func() {
+		runner, err := NewRunner(cfg)
+		Expect(err).NotTo(HaveOccurred())
+		runner.collectRepo = stubCollect("konflux-ci/beta")
+		Expect(runner.Run(context.Background())).To(Succeed())
+
+		retryCfg := cfg
+		retryCfg.RetryFailed = true
+		retryCfg.FromManifest = cfg.ManifestFile
+		retryCfg.RunURL = "https://example.com/runs/2"
+		retry, err := NewRunner(retryCfg)
+		Expect(err).NotTo(HaveOccurred())
+		retry.collectRepo = stubCollect()
+		Expect(retry.Run(context.Background())).To(Succeed())
+
+		Expect(attempts).To(Equal(map[string]int{"konflux-ci/alpha": 1, "konflux-ci/beta": 2}))
+
+		manifest, err := LoadManifest(cfg.ManifestFile)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(manifest.RunURL).To(Equal("https://example.com/runs/1"))
+		Expect(manifest.Repos).To(HaveLen(2))
+		Expect(manifest.Repos[1].Result.Status).To(Equal(StatusOK))
+		Expect(manifest.Repos[1].Attempts).To(Equal(2))
+		Expect(manifest.Repos[1].RunURL).To(Equal("https://example.com/runs/2"))
+	})
+
+	Context("with timeouts", func() {
+		// blockUntilDone simulates a repository whose tests never finish
+		blockUntilDone := func(ctx context.Context, repoCfg config.RepositoryConfig, owners []string) (Result, error) {
+			attempts[repoCfg.Name]++
+			<-ctx.Done()
+			coverage := 10.0
+			return Result{Repo: repoCfg.Name, Coverage: &coverage, Status: StatusFailed}, ctx.Err()
+		}
+
+		It("should mark repositories exceeding their timeout explicitly", func() {
+			cfg.RepoTimeout = 10 * time.Millisecond
+			runner, err := NewRunner(cfg)
+			Expect(err).NotTo(HaveOccurred())
+			runner.collectRepo = blockUntilDone
+
+			Expect(runner.Run(context.Background())).To(Succeed())
+
+			manifest, err := LoadManifest(cfg.ManifestFile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(manifest.Repos).To(HaveLen(2))
+			for _, run := range manifest.Repos {
+				Expect(run.Result.Status).To(Equal(StatusTimeout))
+				Expect(run.Result.Coverage).To(BeNil())
+				Expect(run.Error).To(ContainSubstring("timed out after 10ms"))
+				Expect(run.Failed()).To(BeTrue())
+			}
+		})
+
+		It("should prefer the timeout from the repository configuration", func() {
+			content := "name: konflux-ci/alpha\ntimeout: 1h\n"
+			Expect(os.WriteFile(filepath.Join(reposDir, "alpha.yaml"), []byte(content), 0644)).To(Succeed())
+			Expect(os.Remove(filepath.Join(reposDir, "beta.yaml"))).To(Succeed())
+
+			cfg.RepoTimeout = time.Millisecond
+			runner, err := NewRunner(cfg)
+			Expect(err).NotTo(HaveOccurred())
+			runner.collectRepo = stubCollect()
+
+			Expect(runner.Run(context.Background())).To(Succeed())
+
+			manifest, err := LoadManifest(cfg.ManifestFile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(manifest.Repos[0].Result.Status).To(Equal(StatusOK))
+		})
+
+		It("should skip remaining repositories once the run deadline is reached", func() {
+			cfg.Deadline = 10 * time.Millisecond
+			runner, err := NewRunner(cfg)
+			Expect(err).NotTo(HaveOccurred())
+			runner.collectRepo = blockUntilDone
+
+			Expect(runner.Run(context.Background())).To(Succeed())
+
+			Expect(attempts).To(HaveLen(1))
+			manifest, err := LoadManifest(cfg.ManifestFile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(manifest.Repos).To(HaveLen(2))
+			Expect(manifest.Repos[0].Error).To(ContainSubstring("run deadline reached"))
+			Expect(manifest.Repos[1].Result.Status).To(Equal(StatusTimeout))
+			Expect(manifest.Repos[1].Error).To(Equal("run deadline reached before collection started"))
+		})
+
+		It("should write partial results and fail when interrupted", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			runner, err := NewRunner(cfg)
+			Expect(err).NotTo(HaveOccurred())
+			runner.collectRepo = func(ctx context.Context, repoCfg config.RepositoryConfig, owners []string) (Result, error) {
+				cancel()
+				return blockUntilDone(ctx, repoCfg, owners)
+			}
+
+			err = runner.Run(ctx)
+			Expect(err).To(MatchError(ContainSubstring("run interrupted")))
+			Expect(err).To(MatchError(context.Canceled))
+
+			Expect(attempts).To(HaveLen(1))
+			manifest, err := LoadManifest(cfg.ManifestFile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(manifest.Repos).To(HaveLen(2))
+			Expect(manifest.Repos[0].Error).To(Equal("run interrupted during collection"))
+			Expect(manifest.Repos[1].Error).To(Equal("run interrupted before collection started"))
+			for _, run := range manifest.Repos {
+				Expect(run.Failed()).To(BeTrue())
+			}
+			Expect(cfg.OutputFile).To(BeAnExistingFile())
+		})
+	})
+
+	It("should plan quick reliable repositories first using the previous run's timings", func() {
+		for _, name := range []string{"gamma"} {
+			content := "name: konflux-ci/" + name + "\n"
+			Expect(os.WriteFile(filepath.Join(reposDir, name+".yaml"), []byte(content), 0644)).To(Succeed())
+		}
+		previous := &Manifest{Timings: map[string][]TimingSample{
+			"alpha.yaml": {{Duration: 600}, {Duration: 800}},
+			"beta.yaml":  {{Duration: 60}, {Duration: 40}},
+			"gone.yaml":  {{Duration: 5}},
+		}}
+		cfg.PreviousManifest = filepath.Join(tempDir, "previous-manifest.json")
+		Expect(writeJSON(cfg.PreviousManifest, previous)).To(Succeed())
+
+		runner, err := NewRunner(cfg)
+		Expect(err).NotTo(HaveOccurred())
+		runner.collectRepo = stubCollect()
+		Expect(runner.Run(context.Background())).To(Succeed())
+
+		manifest, err := LoadManifest(cfg.ManifestFile)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(manifest.Plan).To(HaveLen(3))
+		Expect(manifest.Plan[0].ConfigFile).To(Equal("beta.yaml"))
+		Expect(manifest.Plan[0].ExpectedSeconds).To(Equal(50.0))
+		Expect(manifest.Plan[1].ConfigFile).To(Equal("gamma.yaml"))
+		Expect(manifest.Plan[1].ExpectedSeconds).To(Equal(375.0))
+		Expect(manifest.Plan[2].ConfigFile).To(Equal("alpha.yaml"))
+		Expect(manifest.Repos[0].ConfigFile).To(Equal("beta.yaml"))
+
+		Expect(manifest.Timings).NotTo(HaveKey("gone.yaml"))
+		Expect(manifest.Timings["alpha.yaml"]).To(HaveLen(3))
+		Expect(manifest.Timings["gamma.yaml"]).To(HaveLen(1))
+	})
+
+	It("should publish partial results with previous data for repositories not collected yet", func() {
+		previous := &Manifest{Repos: []RepoRun{
+			{ConfigFile: "alpha.yaml", Result: Result{Repo: "konflux-ci/alpha", Status: StatusOK}},
+			{ConfigFile: "beta.yaml", Result: Result{Repo: "konflux-ci/beta", Status: StatusFailed}},
+		}}
+		cfg.PreviousManifest = filepath.Join(tempDir, "previous-manifest.json")
+		Expect(writeJSON(cfg.PreviousManifest, previous)).To(Succeed())
+
+		remote := filepath.Join(tempDir, "remote.git")
+		cfg.PublishDir = filepath.Join(tempDir, "gh-pages")
+		cfg.ReportsDir = filepath.Join(cfg.PublishDir, "coverage")
+		for _, args := range [][]string{
+			{"init", "--bare", remote},
+			{"clone", remote, cfg.PublishDir},
+			{"-C", cfg.PublishDir, "config", "user.name", "test"},
+			{"-C", cfg.PublishDir, "config", "user.email", "test@example.com"},
+		} {
+			Expect(exec.Command("git", args...).Run()).To(Succeed())
+		}
+
+		runner, err := NewRunner(cfg)
+		Expect(err).NotTo(HaveOccurred())
+		var partial Dashboard
+		collect := stubCollect()
+		runner.collectRepo = func(ctx context.Context, repoCfg config.RepositoryConfig, owners []string) (Result, error) {
+			if repoCfg.Name == "konflux-ci/beta" {
+				data, err := os.ReadFile(filepath.Join(cfg.PublishDir, "coverage.json"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(json.Unmarshal(data, &partial)).To(Succeed())
+			}
+			return collect(ctx, repoCfg, owners)
+		}
+		Expect(runner.Run(context.Background())).To(Succeed())
+
+		Expect(partial.InProgress).To(BeTrue())
+		Expect(partial.Progress).To(Equal(&Progress{Completed: 1, Total: 2}))
+		Expect(partial.Data).To(HaveLen(2))
+		Expect(partial.Data[0].Coverage).NotTo(BeNil())
+		Expect(partial.Data[1].Status).To(Equal(StatusFailed))
+
+		log, err := exec.Command("git", "--git-dir", remote, "log", "--format=%s").Output()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(log)).To(ContainSubstring("Update coverage of konflux-ci/alpha (1/2)"))
+		Expect(string(log)).To(ContainSubstring("Update coverage of konflux-ci/beta (2/2)"))
+
+		var final Dashboard
+		data, err := os.ReadFile(cfg.OutputFile)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(json.Unmarshal(data, &final)).To(Succeed())
+		Expect(final.InProgress).To(BeFalse())
+		Expect(final.Progress).To(BeNil())
+	})
+
+	It("should not publish partial results with status publishing off, recording the features in the manifest", func() {
+		cfg.PublishDir = filepath.Join(tempDir, "gh-pages")
+		cfg.Features = features.Flags{features.StatusPublishing: {Name: features.StatusPublishing, Enabled: false, Source: features.SourceEnv}}
+		runner, err := NewRunner(cfg)
+		Expect(err).NotTo(HaveOccurred())
+		runner.collectRepo = stubCollect()
+		Expect(runner.Run(context.Background())).To(Succeed())
+
+		Expect(cfg.PublishDir).NotTo(BeADirectory())
+		manifest, err := LoadManifest(cfg.ManifestFile)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(manifest.Features).To(Equal([]features.Flag{{Name: features.StatusPublishing, Enabled: false, Source: features.SourceEnv}}))
+	})
+
+	It("should skip repositories in languages whose coverage is not collected", func() {
+		Expect(os.WriteFile(filepath.Join(reposDir, "beta.yaml"), []byte("name: konflux-ci/beta\nlanguage: python\n"), 0644)).To(Succeed())
+
+		runner, err := NewRunner(cfg)
+		Expect(err).NotTo(HaveOccurred())
+		collect := runner.collectRepo
+		runner.collectRepo = func(ctx context.Context, repoCfg config.RepositoryConfig, owners []string) (Result, error) {
+			if repoCfg.IsGo() {
+				return stubCollect()(ctx, repoCfg, owners)
+			}
+			return collect(ctx, repoCfg, owners)
+		}
+		Expect(runner.Run(context.Background())).To(Succeed())
+
+		manifest, err := LoadManifest(cfg.ManifestFile)
+		Expect(err).NotTo(HaveOccurred())
+		beta, found := manifest.find("beta.yaml")
+		Expect(found).To(BeTrue())
+		Expect(beta.Result.Status).To(Equal(StatusUnsupported))
+		Expect(beta.Error).To(BeEmpty())
+		Expect(manifest.Failures).To(BeEmpty())
+	})
+
+	It("should report repositories whose coverage dropped beyond their delta", func() {
+		Expect(os.WriteFile(filepath.Join(reposDir, "beta.yaml"), []byte("name: konflux-ci/beta\nregression_delta: 2\n"), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(reposDir, "gamma.yaml"), []byte("name: konflux-ci/gamma\n"), 0644)).To(Succeed())
+		coverage := func(v float64) *float64 { return &v }
+		previous := &Manifest{Repos: []RepoRun{
+			{ConfigFile: "alpha.yaml", Result: Result{Repo: "konflux-ci/alpha", Status: StatusOK, Coverage: coverage(50), Commit: "aaa",
+				Packages: []PackageCoverage{{Package: "github.com/konflux-ci/alpha/pkg", Coverage: 50}}}},
+			{ConfigFile: "beta.yaml", Result: Result{Repo: "konflux-ci/beta", Status: StatusOK, Coverage: coverage(45)}},
+			{ConfigFile: "gamma.yaml", Result: Result{Repo: "konflux-ci/gamma", Status: StatusOK, Coverage: coverage(44)}},
+		}}
+		cfg.PreviousManifest = filepath.Join(tempDir, "previous-manifest.json")
+		Expect(writeJSON(cfg.PreviousManifest, previous)).To(Succeed())
+		cfg.RegressionDelta = 5
+		notifier := &recordingNotifier{}
+		cfg.Notifier = notifier
+
+		runner, err := NewRunner(cfg)
+		Expect(err).NotTo(HaveOccurred())
+		runner.collectRepo = stubCollect()
+		Expect(runner.Run(context.Background())).To(Succeed())
+
+		Expect(notifier.alerts).To(HaveLen(2))
+		Expect(notifier.alerts[0].Event).To(Equal(AlertOpened))
+		alpha := notifier.alerts[0].Regression
+		if alpha.Repo != "konflux-ci/alpha" {
+			alpha = notifier.alerts[1].Regression
+		}
+		Expect(alpha.Repo).To(Equal("konflux-ci/alpha"))
+		Expect(alpha.Kind).To(Equal(KindDrop))
+		Expect(alpha.Delta).To(Equal(8.0))
+		Expect(alpha.Threshold).To(Equal(5.0))
+		Expect(alpha.PreviousCommit).To(Equal("aaa"))
+		Expect(alpha.Owners).To(Equal([]string{"@konflux-ci/alpha-team"}))
+		Expect(alpha.Packages).To(HaveLen(1))
+		Expect(alpha.Packages[0].Current).To(BeNil())
+		Expect(alpha.Packages[0].Change()).To(Equal(-50.0))
+
+		manifest, err := LoadManifest(cfg.ManifestFile)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(manifest.Regressions).To(HaveLen(2))
+		for _, regression := range manifest.Regressions {
+			Expect(regression.Repo).NotTo(Equal("konflux-ci/gamma"))
+		}
+		Expect(manifest.Alerts).To(HaveKey("alpha.yaml"))
+		Expect(manifest.Alerts["alpha.yaml"].Baseline).To(Equal(50.0))
+	})
+
+	Describe("evaluateAlerts", func() {
+		var (
+			policy     AlertPolicy
+			thresholds map[string]float64
+			start      time.Time
+		)
+
+		runAt := func(coverage float64) []RepoRun {
+			return []RepoRun{{ConfigFile: "alpha.yaml", Result: Result{Repo: "konflux-ci/alpha", Status: StatusOK, Coverage: &coverage}}}
+		}
+
+		BeforeEach(func() {
+			policy = AlertPolicy{Cooldown: 72 * time.Hour, EscalateAfter: 4}
+			thresholds = map[string]float64{"alpha.yaml": 5}
+			start = time.Date(2026, 10, 1, 3, 0, 0, 0, time.UTC)
+		})
+
+		It("should remind after the cool-down, escalate once and resolve on recovery", func() {
+			previous := &Manifest{Repos: runAt(60)}
+			var events []string
+			for day, coverage := range []float64{50, 51, 50, 52, 50, 50, 56} {
+				current := &Manifest{Repos: runAt(coverage)}
+				current.carryAlerts(previous, []string{"alpha.yaml"})
+				for _, alert := range current.evaluateAlerts(current.Repos, previous, thresholds, policy, start.AddDate(0, 0, day)) {
+					events = append(events, fmt.Sprintf("%d:%s", day, alert.Event))
+				}
+				previous = current
+			}
+
+			Expect(events).To(Equal([]string{"0:opened", "3:escalated", "6:resolved"}))
+			Expect(previous.Alerts).To(BeEmpty())
+			Expect(previous.Regressions).To(BeEmpty())
+		})
+
+		It("should send reminders once per cool-down while regressed", func() {
+			policy.EscalateAfter = 0
+			previous := &Manifest{Repos: runAt(60)}
+			var events []string
+			for day := range 8 {
+				current := &Manifest{Repos: runAt(50)}
+				current.carryAlerts(previous, []string{"alpha.yaml"})
+				for _, alert := range current.evaluateAlerts(current.Repos, previous, thresholds, policy, start.AddDate(0, 0, day)) {
+					events = append(events, fmt.Sprintf("%d:%s", day, alert.Event))
+				}
+				Expect(current.Regressions).To(HaveLen(1))
+				Expect(current.Regressions[0].Previous).To(Equal(60.0))
+				previous = current
+			}
+
+			Expect(events).To(Equal([]string{"0:opened", "3:reminder", "6:reminder"}))
+			Expect(previous.Alerts["alpha.yaml"].Violations).To(Equal(8))
+		})
+
+		It("should alert while coverage is below the threshold", func() {
+			thresholds["alpha.yaml"] = 0
+			belowAt := func(coverage float64) *Manifest {
+				runs := runAt(coverage)
+				runs[0].Result.Threshold = &Threshold{Effective: 60}
+				return &Manifest{Repos: runs}
+			}
+
+			previous := belowAt(62)
+			var events []string
+			for day, coverage := range []float64{59.5, 59, 61} {
+				current := belowAt(coverage)
+				current.carryAlerts(previous, []string{"alpha.yaml"})
+				for _, alert := range current.evaluateAlerts(current.Repos, previous, thresholds, policy, start.AddDate(0, 0, day)) {
+					events = append(events, fmt.Sprintf("%d:%s:%s", day, alert.Event, alert.Regression.Kind))
+					if alert.Event == AlertOpened {
+						Expect(alert.Regression.Delta).To(Equal(0.5))
+						Expect(alert.Regression.Threshold).To(Equal(60.0))
+					}
+				}
+				previous = current
+			}
+			Expect(events).To(Equal([]string{"0:opened:below_threshold", "2:resolved:below_threshold"}))
+		})
+
+		It("should keep open alerts through failed runs", func() {
+			previous := &Manifest{Repos: runAt(60)}
+			current := &Manifest{Repos: runAt(50)}
+			current.evaluateAlerts(current.Repos, previous, thresholds, policy, start)
+
+			failed := &Manifest{Repos: []RepoRun{{ConfigFile: "alpha.yaml", Result: Result{Status: StatusFailed}}}}
+			failed.carryAlerts(current, []string{"alpha.yaml"})
+			Expect(failed.evaluateAlerts(failed.Repos, current, thresholds, policy, start.AddDate(0, 0, 1))).To(BeEmpty())
+			Expect(failed.Alerts["alpha.yaml"].Violations).To(Equal(1))
+		})
+	})
+
+	Describe("applyThreshold", func() {
+		var now time.Time
+
+		result := func(coverage float64) Result {
+			return Result{Status: StatusOK, Coverage: &coverage}
+		}
+
+		BeforeEach(func() {
+			now = time.Date(2026, 10, 1, 3, 0, 0, 0, time.UTC)
+		})
+
+		It("should only use min_coverage without a ratchet", func() {
+			minimum := 60.0
+			manifest := &Manifest{}
+			threshold := manifest.applyThreshold("alpha.yaml", config.RepositoryConfig{MinCoverage: &minimum}, result(70), now)
+			Expect(threshold).To(Equal(&Threshold{Minimum: &minimum, Effective: 60}))
+			Expect(manifest.Ratchets).To(BeEmpty())
+			Expect(manifest.applyThreshold("alpha.yaml", config.RepositoryConfig{}, result(70), now)).To(BeNil())
+		})
+
+		It("should raise the ratchet with coverage but never lower it", func() {
+			minimum := 50.0
+			cfg := config.RepositoryConfig{MinCoverage: &minimum, Ratchet: &config.RatchetConfig{Tolerance: 2}}
+			manifest := &Manifest{}
+			var effective []float64
+			for _, coverage := range []float64{40, 61.5, 58, 64} {
+				effective = append(effective, manifest.applyThreshold("alpha.yaml", cfg, result(coverage), now).Effective)
+			}
+			Expect(effective).To(Equal([]float64{50, 59.5, 59.5, 62}))
+			Expect(manifest.Ratchets["alpha.yaml"].MaxCoverage).To(Equal(64.0))
+
+			failed := Result{Status: StatusFailed, Coverage: result(10).Coverage}
+			Expect(*manifest.applyThreshold("alpha.yaml", cfg, failed, now).Ratchet).To(Equal(62.0))
+		})
+
+		It("should apply a reset from the configuration once", func() {
+			cfg := config.RepositoryConfig{Ratchet: &config.RatchetConfig{Tolerance: 1}}
+			manifest := &Manifest{}
+			manifest.applyThreshold("alpha.yaml", cfg, result(80), now)
+
+			cfg.Ratchet.Reset = &config.RatchetReset{Value: 60, Reason: "moved the e2e helpers into this repository"}
+			threshold := manifest.applyThreshold("alpha.yaml", cfg, result(65), now)
+			Expect(threshold.Effective).To(Equal(64.0))
+			Expect(threshold.ResetReason).To(Equal("moved the e2e helpers into this repository"))
+
+			threshold = manifest.applyThreshold("alpha.yaml", cfg, result(62), now.AddDate(0, 0, 1))
+			Expect(threshold.Effective).To(Equal(64.0))
+			Expect(manifest.Ratchets["alpha.yaml"].ResetAt).To(Equal(now))
+		})
+	})
+
+	Describe("packageChanges", func() {
+		It("should list changed, added and removed packages, largest drop first", func() {
+			changes := packageChanges(
+				[]PackageCoverage{{Package: "a", Coverage: 80}, {Package: "b", Coverage: 50}, {Package: "c", Coverage: 10}},
+				[]PackageCoverage{{Package: "a", Coverage: 60}, {Package: "b", Coverage: 50}, {Package: "d", Coverage: 30}},
+			)
+			Expect(changes).To(HaveLen(3))
+			Expect(changes[0].Package).To(Equal("a"))
+			Expect(changes[0].Change()).To(Equal(-20.0))
+			Expect(changes[1].Package).To(Equal("c"))
+			Expect(changes[1].Current).To(BeNil())
+			Expect(changes[2].Package).To(Equal("d"))
+			Expect(changes[2].Previous).To(BeNil())
+		})
+	})
+
+	Describe("planSchedule", func() {
+		It("should push repositories that usually fail towards the end", func() {
+			timings := map[string][]TimingSample{
+				"flaky.yaml":  {{Duration: 10, Failed: true}, {Duration: 10, Failed: true}, {Duration: 10}},
+				"steady.yaml": {{Duration: 25}, {Duration: 25}},
+			}
+			plan := planSchedule([]string{"flaky.yaml", "steady.yaml"}, timings)
+			Expect(plan[0].ConfigFile).To(Equal("steady.yaml"))
+			Expect(plan[1].ConfigFile).To(Equal("flaky.yaml"))
+			Expect(plan[1].FailureRate).To(Equal(0.67))
+		})
+
+		It("should keep the original order without history", func() {
+			plan := planSchedule([]string{"b.yaml", "a.yaml"}, nil)
+			Expect(plan[0].ConfigFile).To(Equal("b.yaml"))
+			Expect(plan[1].ConfigFile).To(Equal("a.yaml"))
+		})
+	})
+
+	Describe("TestHelperPackages", func() {
+		It("should only return conventional helpers that production code does not import", func() {
+			graph := []PackageImports{
+				{ImportPath: "demo/cmd", Imports: []string{"demo/api", "demo/pkg/testing"}},
+				{ImportPath: "demo/api", Imports: []string{"fmt"}},
+				{ImportPath: "demo/pkg/testing", Imports: []string{"demo/pkg/fixtures"}},
+				{ImportPath: "demo/pkg/fixtures"},
+				{ImportPath: "demo/internal/testutil", Imports: []string{"demo/internal/testutils"}},
+				{ImportPath: "demo/internal/testutils"},
+			}
+			// pkg/testing ships in the binary, and fixtures with it
+			Expect(TestHelperPackages(graph)).To(Equal([]string{"demo/internal/testutil", "demo/internal/testutils"}))
+		})
+	})
+
+	Describe("Dashboard", func() {
+		It("should sort results by repository whatever the collection order", func() {
+			manifest := &Manifest{}
+			for _, repo := range []string{"org/charlie", "org/alpha", "org/bravo"} {
+				manifest.Record(RepoRun{ConfigFile: repo, Result: Result{Repo: repo}})
+			}
+			var repos []string
+			for _, result := range manifest.Dashboard().Data {
+				repos = append(repos, result.Repo)
+			}
+			Expect(repos).To(Equal([]string{"org/alpha", "org/bravo", "org/charlie"}))
+		})
+	})
+
+	Describe("recordTiming", func() {
+		It("should keep only the most recent samples", func() {
+			manifest := &Manifest{}
+			for i := range timingHistorySize + 2 {
+				manifest.recordTiming(RepoRun{ConfigFile: "a.yaml", Duration: float64(i)})
+			}
+			Expect(manifest.Timings["a.yaml"]).To(HaveLen(timingHistorySize))
+			Expect(manifest.Timings["a.yaml"][0].Duration).To(Equal(2.0))
+		})
+	})
+
+	Describe("recordTrend", func() {
+		It("should keep only the most recent measurements and skip runs without coverage", func() {
+			manifest := &Manifest{}
+			start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+			for i := range trendHistorySize + 2 {
+				coverage := float64(i)
+				manifest.recordTrend(RepoRun{ConfigFile: "a.yaml", Result: Result{Coverage: &coverage}}, start.AddDate(0, 0, i))
+			}
+			manifest.recordTrend(RepoRun{ConfigFile: "a.yaml", Result: Result{Status: StatusFailed}}, start)
+			Expect(manifest.Trends["a.yaml"]).To(HaveLen(trendHistorySize))
+			Expect(manifest.Trends["a.yaml"][0].Coverage).To(Equal(2.0))
+		})
+	})
+
+	Describe("CloneURL", func() {
+		It("should build the github.com URL of a configured repository", func() {
+			Expect(CloneURL("", "konflux-ci/build-service")).To(Equal("https://github.com/konflux-ci/build-service.git"))
+			Expect(CloneURL("", "konflux-ci/konflux-ci.github.io")).To(Equal("https://github.com/konflux-ci/konflux-ci.github.io.git"))
+		})
+
+		It("should build the URL on the GitHub host of the API", func() {
+			Expect(CloneURL("https://github.example.com/", "konflux/build-service")).To(Equal("https://github.example.com/konflux/build-service.git"))
+		})
+
+		It("should refuse hosts not served over https", func() {
+			_, err := CloneURL("http://github.example.com/", "org/repo")
+			Expect(err).To(MatchError(ContainSubstring("is not served over https")))
+		})
+
+		It("should refuse hosts that are not URLs", func() {
+			_, err := CloneURL("git@github.example.com:", "org/repo")
+			Expect(err).To(MatchError(ContainSubstring("is not a GitHub host URL")))
+		})
+
+		DescribeTable("should refuse names that could clone another host or outside the workspace",
+			func(name string) {
+				_, err := CloneURL("", name)
+				Expect(err).To(MatchError(ContainSubstring("refusing to clone")))
+			},
+			Entry("another host", "evil.example.com/org/repo"),
+			Entry("URL", "https://evil.example.com/org/repo"),
+			Entry("credentials", "user@evil.example.com:org/repo"),
+			Entry("SSH host", "git@evil.example.com:org"),
+			Entry("parent directory", "org/.."),
+			Entry("path traversal", "org/repo/../../other"),
+			Entry("extra path", "org/repo/extra"),
+			Entry("query", "org/repo?ref=evil"),
+			Entry("fragment", "org/repo#evil"),
+			Entry("option", "-uorg/--upload-pack=touch"),
+			Entry("whitespace", "org/repo --config=core.sshCommand=evil"),
+			Entry("newline", "org/repo\nevil"),
+			Entry("empty", ""),
+			Entry("lookalike", "оrg/repo"),
+		)
+
+		It("should refuse before touching the workspace", func() {
+			workspace := filepath.Join(tempDir, "workspace")
+			_, err := CloneRepository(context.Background(), workspace, "", "org/..")
+			Expect(err).To(HaveOccurred())
+			Expect(workspace).NotTo(BeADirectory())
+			Expect(tempDir).To(BeADirectory())
+		})
+	})
+
+	Describe("writeWorkspace", func() {
+		It("should let go commands at the root resolve the packages of every module", func() {
+			// Workspaces refuse -mod=mod
+			GinkgoT().Setenv("GOFLAGS", "")
+			for file, content := range map[string]string{
+				"go.mod":           "module github.com/org/repo\n\ngo 1.21\n",
+				"tools/gen/go.mod": "module github.com/org/repo/tools/gen\n\ngo 1.21\n",
+				"tools/gen/gen.go": "package gen\n",
+				"pkg/api/api.go":   "package api\n",
+			} {
+				Expect(os.MkdirAll(filepath.Dir(filepath.Join(tempDir, file)), 0755)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(tempDir, file), []byte(content), 0644)).To(Succeed())
+			}
+			ctx := context.Background()
+			Expect(goList(ctx, tempDir, "github.com/org/repo/tools/gen")).To(BeEmpty())
+
+			Expect(writeWorkspace(ctx, tempDir, []moduleRun{{path: "."}, {path: "tools/gen"}})).To(Succeed())
+			work, err := os.ReadFile(filepath.Join(tempDir, "go.work"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(work)).To(MatchRegexp(`^go 1\.\d+\S*\n\nuse \(\n\t\.\n\t\./tools/gen\n\)\n$`))
+			Expect(goList(ctx, tempDir, "-f", "{{.Dir}}", "github.com/org/repo/tools/gen")).To(Equal([]string{filepath.Join(tempDir, "tools", "gen")}))
+
+			// The repository's own workspace is kept
+			Expect(os.WriteFile(filepath.Join(tempDir, "go.work"), []byte("go 1.21\n\nuse .\n"), 0644)).To(Succeed())
+			Expect(writeWorkspace(ctx, tempDir, []moduleRun{{path: "."}, {path: "tools/gen"}})).To(Succeed())
+			work, err = os.ReadFile(filepath.Join(tempDir, "go.work"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(work)).To(Equal("go 1.21\n\nuse .\n"))
+		})
+	})
+
+	Describe("dockerPackages", func() {
+		It("should find the packages whose tests start containers, directly or through test helpers", func() {
+			GinkgoT().Setenv("GOFLAGS", "")
+			for file, content := range map[string]string{
+				"go.mod": "module github.com/org/repo\n\ngo 1.21\n\nrequire github.com/testcontainers/testcontainers-go v0.0.0\n\n" +
+					"replace github.com/testcontainers/testcontainers-go => ./tc\n",
+				"tc/go.mod":                       "module github.com/testcontainers/testcontainers-go\n\ngo 1.21\n",
+				"tc/modules/postgres/postgres.go": "package postgres\n",
+				"internal/testutil/db.go":         "package testutil\n\nimport _ \"github.com/testcontainers/testcontainers-go/modules/postgres\"\n",
+				"pkg/store/store.go":              "package store\n",
+				"pkg/store/store_test.go":         "package store_test\n\nimport _ \"github.com/org/repo/internal/testutil\"\n",
+				"pkg/api/api.go":                  "package api\n\nimport _ \"github.com/org/repo/pkg/store\"\n",
+				"pkg/api/api_test.go":             "package api\n\nimport \"testing\"\n\nfunc TestAPI(t *testing.T) {}\n",
+			} {
+				Expect(os.MkdirAll(filepath.Dir(filepath.Join(tempDir, file)), 0755)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(tempDir, file), []byte(content), 0644)).To(Succeed())
+			}
+			packages := []string{"github.com/org/repo/pkg/api", "github.com/org/repo/pkg/store"}
+			Expect(dockerPackages(context.Background(), tempDir, packages)).To(Equal([]string{"github.com/org/repo/pkg/store"}))
+		})
+	})
+
+	Describe("goDirectives", func() {
+		It("should return the newest go and toolchain directives of the modules", func() {
+			for file, content := range map[string]string{
+				"go.mod":           "module github.com/org/repo\n\ngo 1.21\n\ntoolchain go1.22.4\n",
+				"tools/gen/go.mod": "module github.com/org/repo/tools/gen\n\ngo 1.22.1\n",
+			} {
+				Expect(os.MkdirAll(filepath.Dir(filepath.Join(tempDir, file)), 0755)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(tempDir, file), []byte(content), 0644)).To(Succeed())
+			}
+			modules := []config.ModuleConfig{{Path: "."}, {Path: "tools/gen"}, {Path: "missing"}}
+			goVersion, toolchain := goDirectives(tempDir, modules)
+			Expect(goVersion).To(Equal("1.22.1"))
+			Expect(toolchain).To(Equal("go1.22.4"))
+		})
+
+		It("should support the go directives up to the collector's Go", func() {
+			Expect(GoSupported("go1.22.4", "1.22")).To(BeTrue())
+			Expect(GoSupported("go1.22.4", "1.22.4")).To(BeTrue())
+			Expect(GoSupported("go1.22.4 X:nocoverageredesign", "1.21")).To(BeTrue())
+			Expect(GoSupported("go1.22.4", "1.22.5")).To(BeFalse())
+			Expect(GoSupported("go1.22.4", "1.23")).To(BeFalse())
+			Expect(GoSupported("", "1.23")).To(BeTrue())
+		})
+	})
+
+	Describe("parseFreshness", func() {
+		It("should count the direct dependencies with newer versions once, with their oldest requirement", func() {
+			direct := make(map[string]bool)
+			updates := make(map[string]DependencyUpdate)
+			root := `{"Path": "github.com/org/repo", "Main": true}
+{"Path": "github.com/google/go-github/v66", "Version": "v66.0.0"}
+{"Path": "github.com/onsi/gomega", "Version": "v1.30.0", "Update": {"Version": "v1.34.1"}}
+{"Path": "golang.org/x/sys", "Version": "v0.20.0", "Indirect": true, "Update": {"Version": "v0.25.0"}}
+{"Path": "github.com/org/repo/api", "Version": "v0.0.0", "Replace": {"Path": "./api"}}`
+			tools := `{"Path": "github.com/org/repo/tools", "Main": true}
+{"Path": "github.com/onsi/gomega", "Version": "v1.27.0", "Update": {"Version": "v1.34.1"}}
+{"Path": "github.com/spf13/cobra", "Version": "v1.7.0", "Update": {"Version": "v1.8.1"}}`
+			Expect(parseFreshness(strings.NewReader(root), direct, updates)).To(Succeed())
+			Expect(parseFreshness(strings.NewReader(tools), direct, updates)).To(Succeed())
+
+			Expect(direct).To(HaveLen(3))
+			Expect(updates).To(Equal(map[string]DependencyUpdate{
+				"github.com/onsi/gomega": {Path: "github.com/onsi/gomega", Version: "v1.27.0", Latest: "v1.34.1"},
+				"github.com/spf13/cobra": {Path: "github.com/spf13/cobra", Version: "v1.7.0", Latest: "v1.8.1"},
+			}))
+			Expect(parseFreshness(strings.NewReader("{"), direct, updates)).To(MatchError(ContainSubstring("failed to parse")))
+		})
+	})
+
+	Describe("addOwnerLinks", func() {
+		It("should link owners to their dashboard pages", func() {
+			report := addOwnerLinks(`<div id="topbar"><div id="legend"></div></div>`, []string{"@konflux-ci/Vanguard"})
+			Expect(report).To(ContainSubstring(`href="../../../index.html?owner=konflux-ci/Vanguard"`))
+			Expect(report).To(ContainSubstring(`<div id="legend">`))
+		})
+	})
+})
+
+// recordingNotifier records the alerts it is told about
+type recordingNotifier struct {
+	alerts   []Alert
+	failures []FailureAlert
+}
+
+func (n *recordingNotifier) NotifyAlert(_ context.Context, alert Alert) error {
+	n.alerts = append(n.alerts, alert)
+	return nil
+}
+
+func (n *recordingNotifier) NotifyFailure(_ context.Context, alert FailureAlert) error {
+	n.failures = append(n.failures, alert)
+	return nil
+}
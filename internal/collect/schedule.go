@@ -0,0 +1,112 @@
+package collect
+
+import (
+	"math"
+	"sort"
+)
+
+// timingHistorySize is the number of recent runs kept per repository for scheduling
+const timingHistorySize = 10
+
+// TimingSample is the outcome of one past collection of a repository
+type TimingSample struct {
+	Duration float64 `json:"duration_seconds"`
+	Failed   bool    `json:"failed"`
+}
+
+// PlannedRepo is a repository's position in the collection plan and the estimates behind it
+type PlannedRepo struct {
+	ConfigFile      string  `json:"config_file"`
+	ExpectedSeconds float64 `json:"expected_seconds"`
+	FailureRate     float64 `json:"failure_rate"`
+	History         int     `json:"history"`
+}
+
+// planSchedule orders config files so that quick, reliable repositories are collected first,
+// making the data available during a run as useful as possible
+// Repositories are ranked by expected seconds per successful collection; those without
+// history are estimated with the average of the known repositories
+func planSchedule(files []string, timings map[string][]TimingSample) []PlannedRepo {
+	plan := make([]PlannedRepo, 0, len(files))
+	var knownTotal float64
+	known := 0
+
+	for _, file := range files {
+		planned := PlannedRepo{ConfigFile: file}
+		if samples := timings[file]; len(samples) > 0 {
+			var duration float64
+			failures := 0
+			for _, sample := range samples {
+				duration += sample.Duration
+				if sample.Failed {
+					failures++
+				}
+			}
+			planned.ExpectedSeconds = round1(duration / float64(len(samples)))
+			planned.FailureRate = math.Round(float64(failures)/float64(len(samples))*100) / 100
+			planned.History = len(samples)
+
+			knownTotal += planned.ExpectedSeconds
+			known++
+		}
+		plan = append(plan, planned)
+	}
+
+	if known > 0 {
+		average := round1(knownTotal / float64(known))
+		for i := range plan {
+			if plan[i].History == 0 {
+				plan[i].ExpectedSeconds = average
+			}
+		}
+	}
+
+	sort.SliceStable(plan, func(i, j int) bool {
+		return plan[i].cost() < plan[j].cost()
+	})
+	return plan
+}
+
+// cost estimates the seconds spent per successful collection of the repository
+func (p PlannedRepo) cost() float64 {
+	return p.ExpectedSeconds / math.Max(1-p.FailureRate, 0.1)
+}
+
+// recordTiming appends the outcome of a collection to the repository's recent history
+func (m *Manifest) recordTiming(run RepoRun) {
+	if m.Timings == nil {
+		m.Timings = make(map[string][]TimingSample)
+	}
+
+	samples := append(m.Timings[run.ConfigFile], TimingSample{
+		Duration: run.Duration,
+		Failed:   run.Failed(),
+	})
+	if len(samples) > timingHistorySize {
+		samples = samples[len(samples)-timingHistorySize:]
+	}
+	m.Timings[run.ConfigFile] = samples
+}
+
+// carryTimings copies the timing history of configured repositories from a previous manifest
+// Manifests written before timings were tracked are seeded from their recorded runs
+func (m *Manifest) carryTimings(previous *Manifest, files []string) {
+	if previous == nil {
+		return
+	}
+
+	history := previous.Timings
+	if history == nil {
+		history = make(map[string][]TimingSample)
+		for _, run := range previous.Repos {
+			history[run.ConfigFile] = []TimingSample{{Duration: run.Duration, Failed: run.Failed()}}
+		}
+	}
+
+	m.Timings = make(map[string][]TimingSample)
+	for _, file := range files {
+		if samples, ok := history[file]; ok {
+			m.Timings[file] = append([]TimingSample(nil), samples...)
+		}
+	}
+}
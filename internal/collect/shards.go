@@ -0,0 +1,244 @@
+package collect
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultShardTimeout is how long the shards of a commit are awaited before its coverage is finalized without them
+	DefaultShardTimeout = 2 * time.Hour
+
+	shardsStateFile = "shards.json"
+)
+
+// ShardProgress tracks the shards of a sharded test run uploaded for a commit
+type ShardProgress struct {
+	Expected int   `json:"expected"`
+	Received []int `json:"received"`
+	// Partial is set on coverage finalized after the shard timeout without every shard, undercounting coverage
+	Partial bool `json:"partial,omitempty"`
+}
+
+// Complete reports whether every expected shard was received
+func (p ShardProgress) Complete() bool {
+	return len(p.Received) == p.Expected
+}
+
+// Missing returns the shards not received, in order
+func (p ShardProgress) Missing() []int {
+	var missing []int
+	for shard := 1; shard <= p.Expected; shard++ {
+		if !slices.Contains(p.Received, shard) {
+			missing = append(missing, shard)
+		}
+	}
+	return missing
+}
+
+// ShardUpload is the profile of one shard of the tests of a pull request's commit
+type ShardUpload struct {
+	Repo    string
+	Number  int
+	Branch  string
+	Commit  string
+	Shard   int
+	Total   int
+	Profile string
+}
+
+// stagedShards is the state of the shards of a commit received so far
+type stagedShards struct {
+	Repo      string        `json:"repo"`
+	Number    int           `json:"number"`
+	Branch    string        `json:"branch,omitempty"`
+	Commit    string        `json:"commit"`
+	Shards    ShardProgress `json:"shards"`
+	StartedAt time.Time     `json:"started_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+// ParseShard parses a shard of a sharded test run given as index/total, e.g. 2/5
+func ParseShard(s string) (shard, total int, err error) {
+	index, count, ok := strings.Cut(s, "/")
+	if ok {
+		shard, err = strconv.Atoi(index)
+		if err == nil {
+			total, err = strconv.Atoi(count)
+		}
+	}
+	if !ok || err != nil || total < 1 || shard < 1 || shard > total {
+		return 0, 0, fmt.Errorf("shard must be index/total with 1 <= index <= total, e.g. 2/5, got %q", s)
+	}
+	return shard, total, nil
+}
+
+// PutShard stages the profile of a shard and, once every shard of its commit is received, stores the coverage of
+// their merged profiles as the pull request's upload. Returns the shards of the commit received so far, and the stored
+// upload, or nil while shards are missing
+// Shards of another total than the first of their commit are rejected
+func (s *PullStore) PutShard(upload ShardUpload, at time.Time) (ShardProgress, *PullCoverage, error) {
+	if err := validatePull(upload.Repo, upload.Number); err != nil {
+		return ShardProgress{}, nil, err
+	}
+	if upload.Total < 1 || upload.Shard < 1 || upload.Shard > upload.Total {
+		return ShardProgress{}, nil, fmt.Errorf("shard %d/%d is out of range", upload.Shard, upload.Total)
+	}
+	if upload.Commit == "" || strings.ContainsAny(upload.Commit, `/\.`) {
+		return ShardProgress{}, nil, fmt.Errorf("commit %q is not a commit SHA", upload.Commit)
+	}
+	if s.shardsDir == "" {
+		return ShardProgress{}, nil, errors.New("no directory to stage the shards in")
+	}
+
+	dir := s.shardDir(upload.Repo, upload.Number, upload.Commit)
+	stage, err := readStagedShards(dir)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		stage = &stagedShards{
+			Repo:      upload.Repo,
+			Number:    upload.Number,
+			Commit:    upload.Commit,
+			Shards:    ShardProgress{Expected: upload.Total},
+			StartedAt: at.UTC(),
+		}
+	case err != nil:
+		return ShardProgress{}, nil, err
+	case stage.Shards.Expected != upload.Total:
+		return ShardProgress{}, nil, fmt.Errorf("%s#%d at %s expects %d shards, got shard %d/%d", upload.Repo, upload.Number, upload.Commit, stage.Shards.Expected, upload.Shard, upload.Total)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return ShardProgress{}, nil, fmt.Errorf("failed to create directory for the shards of %s#%d: %w", upload.Repo, upload.Number, err)
+	}
+	// A shard uploaded again, e.g. by a retried job, replaces its earlier profile
+	if err := copyFile(upload.Profile, filepath.Join(dir, shardFile(upload.Shard))); err != nil {
+		return ShardProgress{}, nil, fmt.Errorf("failed to stage shard %d/%d of %s#%d: %w", upload.Shard, upload.Total, upload.Repo, upload.Number, err)
+	}
+	if !slices.Contains(stage.Shards.Received, upload.Shard) {
+		stage.Shards.Received = append(stage.Shards.Received, upload.Shard)
+		slices.Sort(stage.Shards.Received)
+	}
+	if upload.Branch != "" {
+		stage.Branch = upload.Branch
+	}
+	stage.UpdatedAt = at.UTC()
+
+	if !stage.Shards.Complete() {
+		if err := writeJSON(filepath.Join(dir, shardsStateFile), stage); err != nil {
+			return ShardProgress{}, nil, err
+		}
+		return stage.Shards, nil, nil
+	}
+	stored, err := s.finalizeShards(dir, stage)
+	return stage.Shards, stored, err
+}
+
+// FinalizeShards stores the coverage of the commits whose shards have been awaited longer than timeout, from the
+// shards received, marked partial. Staged commits older than the pull request's upload are dropped
+// Returns the stored uploads; none without a directory staging the shards
+func (s *PullStore) FinalizeShards(timeout time.Duration, now time.Time) ([]PullCoverage, error) {
+	if s.shardsDir == "" {
+		return nil, nil
+	}
+	states, err := filepath.Glob(filepath.Join(s.shardsDir, "*", "*", "*", "*", shardsStateFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list staged shards: %w", err)
+	}
+	slices.Sort(states)
+
+	var finalized []PullCoverage
+	for _, state := range states {
+		dir := filepath.Dir(state)
+		stage, err := readStagedShards(dir)
+		if err != nil {
+			return finalized, err
+		}
+		if now.Sub(stage.StartedAt) < timeout {
+			continue
+		}
+		latest, err := readPullCoverage(s.path(stage.Repo, stage.Number))
+		if err == nil && latest.UploadedAt.After(stage.UpdatedAt) {
+			fmt.Printf("⚠️  Dropping shards %v/%d of %s#%d at %s, superseded by the upload of %s\n",
+				stage.Shards.Received, stage.Shards.Expected, stage.Repo, stage.Number, stage.Commit, latest.Commit)
+			if err := os.RemoveAll(dir); err != nil {
+				return finalized, fmt.Errorf("failed to remove the shards of %s#%d: %w", stage.Repo, stage.Number, err)
+			}
+			continue
+		}
+		stage.Shards.Partial = true
+		upload, err := s.finalizeShards(dir, stage)
+		if err != nil {
+			return finalized, err
+		}
+		finalized = append(finalized, *upload)
+	}
+	return finalized, nil
+}
+
+// finalizeShards stores the coverage of the merged profiles of a commit's staged shards, then removes them
+func (s *PullStore) finalizeShards(dir string, stage *stagedShards) (*PullCoverage, error) {
+	profiles := make([]string, 0, len(stage.Shards.Received))
+	for _, shard := range stage.Shards.Received {
+		profiles = append(profiles, filepath.Join(dir, shardFile(shard)))
+	}
+	merged := filepath.Join(dir, "merged.out")
+	if err := MergeProfiles(merged, profiles); err != nil {
+		return nil, fmt.Errorf("failed to merge the shards of %s#%d: %w", stage.Repo, stage.Number, err)
+	}
+
+	upload, err := NewPullCoverage(stage.Repo, stage.Number, stage.Branch, stage.Commit, merged, stage.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	shards := stage.Shards
+	upload.Shards = &shards
+	if err := s.Put(upload); err != nil {
+		return nil, err
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, fmt.Errorf("failed to remove the shards of %s#%d: %w", stage.Repo, stage.Number, err)
+	}
+	return &upload, nil
+}
+
+// shardDir is the directory staging the shards of a pull request's commit
+func (s *PullStore) shardDir(repo string, number int, commit string) string {
+	return filepath.Join(s.shardsDir, repo, strconv.Itoa(number), commit)
+}
+
+// readStagedShards reads the state of the shards staged in dir; errors reading the file are returned unwrapped
+func readStagedShards(dir string) (*stagedShards, error) {
+	path := filepath.Join(dir, shardsStateFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var stage stagedShards
+	if err := json.Unmarshal(data, &stage); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return &stage, nil
+}
+
+// shardFile is the file name of a staged shard's profile
+func shardFile(shard int) string {
+	return strconv.Itoa(shard) + ".out"
+}
+
+// copyFile copies the file src to dst, replacing it
+func copyFile(src, dst string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dst, data, 0644)
+}
@@ -0,0 +1,118 @@
+package collect
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/pr"
+)
+
+const (
+	// SnapshotSchemaVersion is increased on incompatible changes to the snapshot files
+	SnapshotSchemaVersion = 1
+
+	snapshotDashboardFile = "dashboard.json"
+	snapshotReposDir      = "repos"
+)
+
+// Snapshot is the dashboard.json committed to the data branch after every run
+type Snapshot struct {
+	SchemaVersion int       `json:"schema_version"`
+	GeneratedAt   time.Time `json:"generated_at"`
+	RunURL        string    `json:"run_url"`
+	Data          []Result  `json:"data"`
+	// Provenance records how the results were produced
+	Provenance *Provenance `json:"provenance,omitempty"`
+}
+
+// RepoSummary is the snapshot of a single repository, committed as repos/{org}/{name}.json
+type RepoSummary struct {
+	SchemaVersion int       `json:"schema_version"`
+	GeneratedAt   time.Time `json:"generated_at"`
+	RunURL        string    `json:"run_url"`
+	Result
+	// Provenance records how the result was produced
+	Provenance *Provenance `json:"provenance,omitempty"`
+}
+
+// SnapshotPublisher commits the results of every run to a git checkout of a data branch,
+// so their history can be consumed with plain git
+type SnapshotPublisher struct {
+	dir string
+}
+
+// NewSnapshotPublisher creates a SnapshotPublisher for the git checkout at dir
+func NewSnapshotPublisher(dir string) *SnapshotPublisher {
+	return &SnapshotPublisher{dir: dir}
+}
+
+// Publish replaces the snapshot files in the checkout with the dashboard's results, then commits and pushes them
+// Repositories no longer in the dashboard lose their summary file
+func (s *SnapshotPublisher) Publish(ctx context.Context, dashboard Dashboard, now time.Time) error {
+	snapshot := Snapshot{
+		SchemaVersion: SnapshotSchemaVersion,
+		GeneratedAt:   now,
+		RunURL:        dashboard.RunURL,
+		Data:          dashboard.Data,
+		Provenance:    dashboard.Provenance,
+	}
+	if err := writeJSON(filepath.Join(s.dir, snapshotDashboardFile), snapshot); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	reposDir := filepath.Join(s.dir, snapshotReposDir)
+	if err := os.RemoveAll(reposDir); err != nil {
+		return fmt.Errorf("failed to clear repository summaries: %w", err)
+	}
+	for _, result := range dashboard.Data {
+		summary := RepoSummary{
+			SchemaVersion: SnapshotSchemaVersion,
+			GeneratedAt:   now,
+			RunURL:        dashboard.RunURL,
+			Result:        result,
+			Provenance:    dashboard.Provenance,
+		}
+		path := filepath.Join(reposDir, filepath.FromSlash(result.Repo)+".json")
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", result.Repo, err)
+		}
+		if err := writeJSON(path, summary); err != nil {
+			return fmt.Errorf("failed to write summary of %s: %w", result.Repo, err)
+		}
+	}
+
+	if _, err := pr.RunGitCommand(ctx, s.dir, "add", "--all", "."); err != nil {
+		return fmt.Errorf("failed to stage snapshot: %w", err)
+	}
+	if _, err := pr.RunGitCommand(ctx, s.dir, "diff", "--cached", "--quiet"); err == nil {
+		return nil
+	}
+
+	message := "Coverage snapshot of " + now.Format("2006-01-02")
+	if _, err := pr.RunGitCommand(ctx, s.dir, "commit", "-m", message); err != nil {
+		return fmt.Errorf("failed to commit snapshot: %w", err)
+	}
+	if _, err := pr.RunGitCommand(ctx, s.dir, "push", "origin", "HEAD"); err != nil {
+		return fmt.Errorf("failed to push snapshot: %w", err)
+	}
+	return nil
+}
+
+// LoadSnapshot reads the dashboard.json of a checkout of the data branch
+func LoadSnapshot(dir string) (*Snapshot, error) {
+	path := filepath.Join(dir, snapshotDashboardFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot %s: %w", path, err)
+	}
+	return &snapshot, nil
+}
@@ -0,0 +1,80 @@
+package collect_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/collect"
+)
+
+var _ = Describe("SnapshotPublisher", func() {
+	var (
+		remote   string
+		checkout string
+	)
+
+	git := func(args ...string) string {
+		out, err := exec.Command("git", args...).CombinedOutput()
+		Expect(err).NotTo(HaveOccurred(), string(out))
+		return string(out)
+	}
+
+	BeforeEach(func() {
+		tempDir := GinkgoT().TempDir()
+		remote = filepath.Join(tempDir, "remote.git")
+		checkout = filepath.Join(tempDir, "data")
+		git("init", "--bare", remote)
+		git("clone", remote, checkout)
+		git("-C", checkout, "checkout", "--orphan", "data")
+		git("-C", checkout, "config", "user.name", "test")
+		git("-C", checkout, "config", "user.email", "test@example.com")
+	})
+
+	It("should commit the dashboard and one summary per repository", func() {
+		coverage := 61.5
+		dashboard := collect.Dashboard{
+			RunURL:     "https://example.com/runs/1",
+			Provenance: &collect.Provenance{Version: "v1.4.0", GoVersion: "go1.23.4", ConfigHash: "d41d8cd98f00"},
+			Data: []collect.Result{
+				{Repo: "konflux-ci/api", Coverage: &coverage, Status: collect.StatusOK, Commit: "abc"},
+				{Repo: "konflux-ci/cli", Status: collect.StatusFailed},
+			},
+		}
+		publisher := collect.NewSnapshotPublisher(checkout)
+		first := time.Date(2026, 10, 15, 5, 0, 0, 0, time.UTC)
+		Expect(publisher.Publish(context.Background(), dashboard, first)).To(Succeed())
+
+		var summary map[string]any
+		data, err := os.ReadFile(filepath.Join(checkout, "repos", "konflux-ci", "api.json"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(json.Unmarshal(data, &summary)).To(Succeed())
+		Expect(summary).To(HaveKeyWithValue("schema_version", 1.0))
+		Expect(summary).To(HaveKeyWithValue("repo", "konflux-ci/api"))
+		Expect(summary).To(HaveKeyWithValue("coverage", 61.5))
+		Expect(summary).To(HaveKeyWithValue("commit", "abc"))
+		Expect(summary).To(HaveKeyWithValue("provenance", HaveKeyWithValue("go_version", "go1.23.4")))
+
+		var snapshot collect.Snapshot
+		data, err = os.ReadFile(filepath.Join(checkout, "dashboard.json"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(json.Unmarshal(data, &snapshot)).To(Succeed())
+		Expect(snapshot.GeneratedAt).To(Equal(first))
+		Expect(snapshot.Data).To(HaveLen(2))
+		Expect(snapshot.Provenance).To(Equal(dashboard.Provenance))
+
+		// A repository removed from the dashboard loses its summary
+		dashboard.Data = dashboard.Data[:1]
+		Expect(publisher.Publish(context.Background(), dashboard, first.Add(24*time.Hour))).To(Succeed())
+		Expect(filepath.Join(checkout, "repos", "konflux-ci", "cli.json")).NotTo(BeAnExistingFile())
+
+		Expect(git("--git-dir", remote, "log", "data", "--format=%s")).To(Equal("Coverage snapshot of 2026-10-16\nCoverage snapshot of 2026-10-15\n"))
+		Expect(git("--git-dir", remote, "show", "data~1:repos/konflux-ci/cli.json")).To(ContainSubstring(`"status": "failed"`))
+	})
+})
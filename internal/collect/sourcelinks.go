@@ -0,0 +1,215 @@
+package collect
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/ghauth"
+	"github.com/konflux-ci/coverage-dashboard/internal/pr"
+)
+
+var (
+	reportOptionPattern  = regexp.MustCompile(`<option value="(file\d+)">(\S+) \(`)
+	reportFileTagPattern = regexp.MustCompile(`^<pre class="file" id="(file\d+)"[^>]*>`)
+)
+
+// SourceRef identifies the exact source a coverage profile was measured on
+type SourceRef struct {
+	Repo   string
+	Commit string
+	// Modules maps each module path of the repository to its directory relative to the repository root
+	Modules map[string]string
+	// WebURL is the GitHub web host the sources are linked to, ghauth.DefaultWebURL when empty
+	WebURL string
+}
+
+// ResolveSourceRef reads the checked out commit and module layout of a repository clone
+func ResolveSourceRef(ctx context.Context, repoDir, repoName string) (SourceRef, error) {
+	ref := SourceRef{Repo: repoName, Modules: make(map[string]string)}
+
+	commit, err := pr.RunGitCommand(ctx, repoDir, "rev-parse", "HEAD")
+	if err != nil {
+		return ref, fmt.Errorf("failed to read commit of %s: %w", repoName, err)
+	}
+	ref.Commit = strings.TrimSpace(commit)
+
+	root, err := filepath.Abs(repoDir)
+	if err != nil {
+		return ref, fmt.Errorf("failed to resolve %s: %w", repoDir, err)
+	}
+
+	for _, line := range goList(ctx, repoDir, "-m", "-f", "{{.Path}}\t{{.Dir}}") {
+		modulePath, dir, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		rel, err := filepath.Rel(root, dir)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		ref.Modules[modulePath] = filepath.ToSlash(rel)
+	}
+
+	return ref, nil
+}
+
+// RepoPath converts a file name of a coverage profile to its path in the repository
+// Files outside the repository's modules are returned unchanged with ok set to false
+func (s SourceRef) RepoPath(fileName string) (string, bool) {
+	best := ""
+	for modulePath := range s.Modules {
+		if (fileName == modulePath || strings.HasPrefix(fileName, modulePath+"/")) && len(modulePath) > len(best) {
+			best = modulePath
+		}
+	}
+	if best == "" {
+		return fileName, false
+	}
+	return path.Join(s.Modules[best], strings.TrimPrefix(fileName, best+"/")), true
+}
+
+// BlobURL links a file of a coverage profile, and optionally a line range, to its GitHub host at the measured commit
+// Lines are 1-based; a zero start links the whole file
+func (s SourceRef) BlobURL(fileName string, start, end int) string {
+	if s.Commit == "" {
+		return ""
+	}
+	repoPath, ok := s.RepoPath(fileName)
+	if !ok {
+		return ""
+	}
+
+	webURL := s.WebURL
+	if webURL == "" {
+		webURL = ghauth.DefaultWebURL
+	}
+	url := fmt.Sprintf("%s/%s/blob/%s/%s", strings.TrimSuffix(webURL, "/"), s.Repo, s.Commit, repoPath)
+	switch {
+	case start == 0:
+		return url
+	case end <= start:
+		return fmt.Sprintf("%s#L%d", url, start)
+	default:
+		return fmt.Sprintf("%s#L%d-L%d", url, start, end)
+	}
+}
+
+// linkOptions links the file options of a report header to GitHub and returns the linked files by element ID
+func linkOptions(header string, ref SourceRef) (string, map[string]string) {
+	files := make(map[string]string)
+	header = reportOptionPattern.ReplaceAllStringFunc(header, func(option string) string {
+		match := reportOptionPattern.FindStringSubmatch(option)
+		url := ref.BlobURL(match[2], 0, 0)
+		if url == "" {
+			return option
+		}
+		files[match[1]] = match[2]
+		return fmt.Sprintf(`<option value="%s" data-source="%s">%s (`, match[1], url, match[2])
+	})
+	return header, files
+}
+
+// addSourceHeader adds the link to the selected file on GitHub to a report header
+func addSourceHeader(header string, ref SourceRef, files map[string]string) string {
+	link := fmt.Sprintf(`<div id="source"><a id="source-link" href="%s" target="_blank">View on GitHub at %s</a></div>`,
+		ref.BlobURL(files["file0"], 0, 0), shortCommit(ref.Commit))
+	return strings.Replace(header, `<div id="legend">`, link+`<div id="legend">`, 1)
+}
+
+// linkFile links every covered or uncovered block of a report's <pre> file section to its line range on GitHub
+// Sections of files that are not linked are returned unchanged
+func linkFile(section string, ref SourceRef, files map[string]string) string {
+	tag := reportFileTagPattern.FindStringSubmatch(section)
+	if tag == nil || !strings.HasSuffix(section, reportFileEnd) {
+		return section
+	}
+	fileName, ok := files[tag[1]]
+	if !ok {
+		return section
+	}
+	body := section[len(tag[0]) : len(section)-len(reportFileEnd)]
+	return tag[0] + linkBlocks(body, func(start, end int) string {
+		return ref.BlobURL(fileName, start, end)
+	}) + reportFileEnd
+}
+
+// shortCommit abbreviates a commit SHA as GitHub displays it
+func shortCommit(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
+
+// linkBlocks turns the coverage spans of a report file body into links to their line range
+func linkBlocks(body string, url func(start, end int) string) string {
+	var out strings.Builder
+	out.Grow(len(body) * 2)
+	line := 1
+	for {
+		block, ok := nextBlock(body)
+		if !ok {
+			break
+		}
+		before := body[:block.start]
+		line += strings.Count(before, "\n")
+		out.WriteString(before)
+
+		end := line + strings.Count(block.content, "\n")
+		fmt.Fprintf(&out, `<a class="%s" title="%s" href="%s" target="_blank">%s</a>`, block.class, block.count, url(line, end), block.content)
+
+		line = end
+		body = body[block.end:]
+	}
+	out.WriteString(body)
+	return out.String()
+}
+
+// reportBlock is a coverage span of a report file body, <span class="cov8" title="1">content</span>
+type reportBlock struct {
+	start, end            int
+	class, count, content string
+}
+
+// nextBlock finds the first coverage span of a report file body
+// Spans are scanned by hand: a regular expression dominates the rendering time of large reports
+func nextBlock(body string) (reportBlock, bool) {
+	const open, title, text, closing = `<span class="`, `" title="`, `">`, `</span>`
+	for offset := 0; ; {
+		i := strings.Index(body[offset:], open+"cov")
+		if i < 0 {
+			return reportBlock{}, false
+		}
+		block := reportBlock{start: offset + i}
+		rest := body[block.start+len(open):]
+		var ok1, ok2, ok3 bool
+		block.class, rest, ok1 = strings.Cut(rest, title)
+		block.count, rest, ok2 = strings.Cut(rest, text)
+		block.content, _, ok3 = strings.Cut(rest, closing)
+		if ok1 && ok2 && ok3 && isDigits(strings.TrimPrefix(block.class, "cov")) && isDigits(block.count) {
+			block.end = block.start + len(open) + len(block.class) + len(title) + len(block.count) + len(text) + len(block.content) + len(closing)
+			return block, true
+		}
+		offset = block.start + 1
+	}
+}
+
+// isDigits reports whether s is a non-empty string of decimal digits
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// sourceLinkScript keeps the GitHub link pointing at the file selected in the report
+var sourceLinkScript = mustReadTemplate("source-links.html")
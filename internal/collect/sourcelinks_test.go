@@ -0,0 +1,105 @@
+package collect
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/pr"
+)
+
+const sampleReport = `<html><body>
+<div id="topbar"><div id="nav"><select id="files">
+<option value="file0">github.com/org/repo/pkg/a.go (50.0%)</option>
+<option value="file1">github.com/org/repo/tools/b.go (0.0%)</option>
+</select></div><div id="legend"></div></div>
+<div id="content">
+<pre class="file" id="file0" style="display: none">package a
+
+func A() int <span class="cov8" title="1">{
+	return 1
+}</span>
+
+func B() int <span class="cov0" title="0">{ return 2 }</span>
+</pre>
+<pre class="file" id="file1" style="display: none">package b
+</pre>
+</div>
+</body>
+</html>
+`
+
+var _ = Describe("Source links", func() {
+	ref := SourceRef{
+		Repo:    "org/repo",
+		Commit:  "0123456789abcdef",
+		Modules: map[string]string{"github.com/org/repo": ".", "github.com/org/repo/tools": "tools"},
+	}
+
+	Describe("BlobURL", func() {
+		It("should link files of nested modules to their repository path", func() {
+			Expect(ref.BlobURL("github.com/org/repo/pkg/a.go", 0, 0)).To(Equal("https://github.com/org/repo/blob/0123456789abcdef/pkg/a.go"))
+			Expect(ref.BlobURL("github.com/org/repo/tools/b.go", 3, 3)).To(Equal("https://github.com/org/repo/blob/0123456789abcdef/tools/b.go#L3"))
+			Expect(ref.BlobURL("github.com/org/repo/pkg/a.go", 3, 5)).To(HaveSuffix("/pkg/a.go#L3-L5"))
+		})
+
+		It("should link to the GitHub host of the API", func() {
+			enterprise := ref
+			enterprise.WebURL = "https://github.example.com/"
+			Expect(enterprise.BlobURL("github.com/org/repo/pkg/a.go", 0, 0)).To(Equal("https://github.example.com/org/repo/blob/0123456789abcdef/pkg/a.go"))
+		})
+
+		It("should not link files outside the repository or without a commit", func() {
+			Expect(ref.BlobURL("github.com/other/repo/a.go", 0, 0)).To(BeEmpty())
+			Expect(SourceRef{Repo: "org/repo", Modules: ref.Modules}.BlobURL("github.com/org/repo/pkg/a.go", 0, 0)).To(BeEmpty())
+		})
+	})
+
+	Describe("ReportRenderer", func() {
+		render := func(report string, ref SourceRef) string {
+			var out strings.Builder
+			Expect(NewReportRenderer(2, 0).Render(&out, strings.NewReader(report), ref, nil)).To(Succeed())
+			return out.String()
+		}
+
+		It("should link files and blocks to their line ranges at the commit", func() {
+			report := render(sampleReport, ref)
+			Expect(report).To(ContainSubstring(`<option value="file0" data-source="https://github.com/org/repo/blob/0123456789abcdef/pkg/a.go">`))
+			Expect(report).To(ContainSubstring(`<a class="cov8" title="1" href="https://github.com/org/repo/blob/0123456789abcdef/pkg/a.go#L3-L5" target="_blank">{`))
+			Expect(report).To(ContainSubstring(`href="https://github.com/org/repo/blob/0123456789abcdef/pkg/a.go#L7" target="_blank">{ return 2 }</a>`))
+			Expect(report).To(ContainSubstring(`View on GitHub at 0123456`))
+			Expect(report).NotTo(ContainSubstring(`<span class="cov`))
+		})
+
+		It("should leave the report unchanged without a commit", func() {
+			Expect(render(sampleReport, SourceRef{Repo: "org/repo"})).To(Equal(sampleReport))
+		})
+	})
+
+	Describe("ResolveSourceRef", func() {
+		It("should read the commit and module directories of a clone", func() {
+			repoDir := GinkgoT().TempDir()
+			Expect(os.WriteFile(filepath.Join(repoDir, "go.mod"), []byte("module github.com/org/repo\n\ngo 1.23\n"), 0644)).To(Succeed())
+			ctx := context.Background()
+			for _, args := range [][]string{
+				{"init", "-q"},
+				{"add", "go.mod"},
+				{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "init"},
+			} {
+				_, err := pr.RunGitCommand(ctx, repoDir, args...)
+				Expect(err).NotTo(HaveOccurred())
+			}
+			head, err := pr.RunGitCommand(ctx, repoDir, "rev-parse", "HEAD")
+			Expect(err).NotTo(HaveOccurred())
+
+			resolved, err := ResolveSourceRef(ctx, repoDir, "org/repo")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resolved.Commit + "\n").To(Equal(head))
+			Expect(resolved.Modules).To(Equal(map[string]string{"github.com/org/repo": "."}))
+		})
+	})
+})
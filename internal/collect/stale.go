@@ -0,0 +1,50 @@
+package collect
+
+import (
+	"time"
+)
+
+// DefaultMaxAge is how old coverage may get before it is stale: three missed daily runs
+const DefaultMaxAge = 72 * time.Hour
+
+// trackFreshness records when a repository was last collected successfully and whether that is older than maxAge
+// Failed runs keep the time recorded by the run they replace with --retry-failed, or by the previous run
+func (m *Manifest) trackFreshness(previous *Manifest, file string, result *Result, maxAge time.Duration, now time.Time) {
+	switch {
+	case result.Status == StatusUnsupported:
+	case !collectionFailed(RepoRun{Result: *result}):
+		collected := now
+		result.LastCollected = &collected
+	default:
+		for _, earlier := range []*Manifest{m, previous} {
+			if earlier == nil {
+				continue
+			}
+			if run, found := earlier.find(file); found && run.Result.LastCollected != nil {
+				result.LastCollected = run.Result.LastCollected
+				break
+			}
+		}
+	}
+	result.Stale = maxAge > 0 && result.LastCollected != nil && now.Sub(*result.LastCollected) > maxAge
+}
+
+// evaluateStaleness returns the alerts of the repositories collected in this run whose data is older than their
+// max age, once per failure streak. It runs after evaluateFailures, which tracks the failures of stale repositories
+func (m *Manifest) evaluateStaleness(collected []RepoRun, maxAges map[string]time.Duration) []FailureAlert {
+	var alerts []FailureAlert
+	for _, run := range collected {
+		state, failing := m.Failures[run.ConfigFile]
+		if !run.Result.Stale || !failing || state.StaleAlerted {
+			continue
+		}
+		state.StaleAlerted = true
+		m.Failures[run.ConfigFile] = state
+
+		alert := failureAlert(AlertEscalated, run, state)
+		alert.LastCollected = run.Result.LastCollected
+		alert.MaxAge = maxAges[run.ConfigFile]
+		alerts = append(alerts, alert)
+	}
+	return alerts
+}
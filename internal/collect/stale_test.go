@@ -0,0 +1,97 @@
+package collect
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Stale coverage", func() {
+	var start time.Time
+
+	BeforeEach(func() {
+		start = time.Date(2026, 10, 1, 3, 0, 0, 0, time.UTC)
+	})
+
+	It("should keep the last successful collection across failed runs and mark it stale past the max age", func() {
+		coverage := 42.0
+		collected := Result{Repo: "konflux-ci/alpha", Status: StatusOK, Coverage: &coverage}
+		previous := &Manifest{}
+		previous.trackFreshness(nil, "alpha.yaml", &collected, 72*time.Hour, start)
+		previous.Record(RepoRun{ConfigFile: "alpha.yaml", Result: collected})
+		Expect(collected.LastCollected).To(Equal(&start))
+		Expect(collected.Stale).To(BeFalse())
+
+		failed := Result{Repo: "konflux-ci/alpha", Status: StatusFailed}
+		current := &Manifest{}
+		current.trackFreshness(previous, "alpha.yaml", &failed, 72*time.Hour, start.Add(48*time.Hour))
+		Expect(failed.LastCollected).To(Equal(&start))
+		Expect(failed.Stale).To(BeFalse())
+
+		current.trackFreshness(previous, "alpha.yaml", &failed, 72*time.Hour, start.Add(96*time.Hour))
+		Expect(failed.Stale).To(BeTrue())
+
+		current.trackFreshness(previous, "alpha.yaml", &failed, 0, start.Add(96*time.Hour))
+		Expect(failed.Stale).To(BeFalse())
+	})
+
+	It("should not mark repositories never collected or unsupported as stale", func() {
+		failed := Result{Repo: "konflux-ci/alpha", Status: StatusFailed}
+		(&Manifest{}).trackFreshness(nil, "alpha.yaml", &failed, time.Hour, start)
+		Expect(failed.LastCollected).To(BeNil())
+		Expect(failed.Stale).To(BeFalse())
+
+		unsupported := Result{Repo: "konflux-ci/ui", Status: StatusUnsupported}
+		(&Manifest{}).trackFreshness(nil, "ui.yaml", &unsupported, time.Hour, start)
+		Expect(unsupported.LastCollected).To(BeNil())
+	})
+
+	It("should alert once when a failing repository turns stale and resolve on the next collection", func() {
+		lastCollected := start.Add(-96 * time.Hour)
+		run := RepoRun{
+			ConfigFile: "alpha.yaml",
+			Result:     Result{Repo: "konflux-ci/alpha", Status: StatusFailed, LastCollected: &lastCollected, Stale: true},
+		}
+		maxAges := map[string]time.Duration{"alpha.yaml": 72 * time.Hour}
+
+		manifest := &Manifest{Repos: []RepoRun{run}}
+		Expect(manifest.evaluateFailures(manifest.Repos, 0, start)).To(BeEmpty())
+		alerts := manifest.evaluateStaleness(manifest.Repos, maxAges)
+		Expect(alerts).To(HaveLen(1))
+		Expect(alerts[0].Event).To(Equal(AlertEscalated))
+		Expect(alerts[0].MaxAge).To(Equal(72 * time.Hour))
+		Expect(alerts[0].LastCollected).To(Equal(&lastCollected))
+		Expect(manifest.evaluateStaleness(manifest.Repos, maxAges)).To(BeEmpty())
+
+		coverage := 42.0
+		recovered := []RepoRun{{ConfigFile: "alpha.yaml", Result: Result{Repo: "konflux-ci/alpha", Status: StatusOK, Coverage: &coverage}}}
+		resolved := manifest.evaluateFailures(recovered, 0, start.Add(24*time.Hour))
+		Expect(resolved).To(HaveLen(1))
+		Expect(resolved[0].Event).To(Equal(AlertResolved))
+	})
+
+	It("should leave stale repositories out of the group averages", func() {
+		high, low := 90.0, 30.0
+		summaries := summarizeGroups([]Result{
+			{Repo: "konflux-ci/alpha", Coverage: &high, Groups: []string{"build"}},
+			{Repo: "konflux-ci/beta", Coverage: &low, Groups: []string{"build"}, Stale: true},
+		})
+		Expect(summaries).To(HaveLen(1))
+		Expect(summaries[0].Repos).To(Equal(2))
+		Expect(summaries[0].Stale).To(Equal(1))
+		Expect(summaries[0].Measured).To(Equal(1))
+		Expect(*summaries[0].Coverage).To(Equal(90.0))
+	})
+
+	It("should grey out the widget of stale repositories", func() {
+		coverage := 85.0
+		widget := NewWidget(Result{Repo: "konflux-ci/alpha", Coverage: &coverage, LastCollected: &start, Stale: true}, nil, start)
+		Expect(widget.Color).To(Equal("grey"))
+
+		html, err := RenderWidget(widget)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(html).To(ContainSubstring("stale, last collected 2026-10-01"))
+		Expect(html).To(ContainSubstring("#f3f4f6"))
+	})
+})
@@ -0,0 +1,24 @@
+package collect
+
+import (
+	"embed"
+	"path"
+)
+
+// templates holds the HTML, CSS and JavaScript added to published pages
+//
+//go:embed templates
+var templates embed.FS
+
+// reportTheme adds the light and dark themes, their toggle and the print stylesheet to reports
+var reportTheme = "<style>\n" + mustReadTemplate("report-theme.css") + "</style>\n" +
+	"<script>\n" + mustReadTemplate("report-theme.js") + "</script>\n"
+
+// mustReadTemplate returns an embedded template file, which exists since it is embedded at build time
+func mustReadTemplate(name string) string {
+	content, err := templates.ReadFile(path.Join("templates", name))
+	if err != nil {
+		panic(err)
+	}
+	return string(content)
+}
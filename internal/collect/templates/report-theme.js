@@ -0,0 +1,26 @@
+(function() {
+	// The theme is the reader's choice, shared with the dashboard, else the browser's preference
+	var root = document.documentElement;
+	var stored = null;
+	try { stored = localStorage.getItem('coverage-theme'); } catch (e) {}
+	var prefersDark = window.matchMedia && window.matchMedia('(prefers-color-scheme: dark)').matches;
+	root.dataset.theme = stored || (prefersDark ? 'dark' : 'light');
+
+	document.addEventListener('DOMContentLoaded', function() {
+		var topbar = document.getElementById('topbar');
+		if (!topbar) return;
+		var toggle = document.createElement('button');
+		toggle.id = 'theme-toggle';
+		toggle.type = 'button';
+		function label() {
+			toggle.textContent = root.dataset.theme === 'dark' ? '☀️ Light' : '🌙 Dark';
+		}
+		toggle.addEventListener('click', function() {
+			root.dataset.theme = root.dataset.theme === 'dark' ? 'light' : 'dark';
+			try { localStorage.setItem('coverage-theme', root.dataset.theme); } catch (e) {}
+			label();
+		});
+		label();
+		topbar.insertBefore(toggle, topbar.firstChild);
+	});
+})();
@@ -0,0 +1,93 @@
+package collect
+
+import (
+	"context"
+	"path"
+	"sort"
+	"strings"
+)
+
+// testHelperNames are the conventional names of packages holding test helpers
+var testHelperNames = map[string]bool{
+	"testutil":    true,
+	"testutils":   true,
+	"testing":     true,
+	"testhelpers": true,
+	"fixtures":    true,
+}
+
+// PackageImports lists what the non-test files of a package import
+type PackageImports struct {
+	ImportPath string
+	Imports    []string
+}
+
+// TestHelperPackages returns the packages named as test helpers by convention that no production code
+// depends on, i.e. only test files import them, directly or through other test helpers. Sorted
+func TestHelperPackages(graph []PackageImports) []string {
+	candidates := make(map[string]bool)
+	imports := make(map[string][]string, len(graph))
+	for _, pkg := range graph {
+		imports[pkg.ImportPath] = pkg.Imports
+		if testHelperNames[path.Base(pkg.ImportPath)] {
+			candidates[pkg.ImportPath] = true
+		}
+	}
+
+	// Walk the non-test imports of production packages; any candidate reached is production code
+	used := make(map[string]bool)
+	var visit func(pkg string)
+	visit = func(pkg string) {
+		for _, imported := range imports[pkg] {
+			if candidates[imported] && !used[imported] {
+				used[imported] = true
+				visit(imported)
+			}
+		}
+	}
+	for _, pkg := range graph {
+		if !candidates[pkg.ImportPath] {
+			visit(pkg.ImportPath)
+		}
+	}
+
+	var helpers []string
+	for pkg := range candidates {
+		if !used[pkg] {
+			helpers = append(helpers, pkg)
+		}
+	}
+	sort.Strings(helpers)
+	return helpers
+}
+
+// listPackageImports lists the import graph of the repository's packages
+func listPackageImports(ctx context.Context, repoDir string) []PackageImports {
+	var graph []PackageImports
+	for _, line := range goList(ctx, repoDir, "-e", "-f", "{{.ImportPath}} {{join .Imports \" \"}}", "./...") {
+		fields := strings.Fields(line)
+		graph = append(graph, PackageImports{ImportPath: fields[0], Imports: fields[1:]})
+	}
+	return graph
+}
+
+// detectTestHelpers returns the test helper packages of the repository checked out in repoDir
+func detectTestHelpers(ctx context.Context, repoDir string) []string {
+	return TestHelperPackages(listPackageImports(ctx, repoDir))
+}
+
+// inPackages reports whether a coverage profile file belongs to one of the packages
+func inPackages(fileName string, packages map[string]bool) bool {
+	return packages[path.Dir(fileName)]
+}
+
+// withoutPackages returns the packages not in excluded
+func withoutPackages(packages []string, excluded map[string]bool) []string {
+	var kept []string
+	for _, pkg := range packages {
+		if !excluded[pkg] {
+			kept = append(kept, pkg)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,96 @@
+package collect
+
+import (
+	"time"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+)
+
+// Threshold is the minimum coverage a repository must keep, as published in coverage.json
+type Threshold struct {
+	// Minimum is the configured min_coverage, Ratchet the value the ratchet has risen to
+	Minimum   *float64 `json:"minimum,omitempty"`
+	Ratchet   *float64 `json:"ratchet,omitempty"`
+	Effective float64  `json:"effective"`
+	// ResetReason explains the last explicit reset of the ratchet
+	ResetReason string `json:"reset_reason,omitempty"`
+}
+
+// RatchetState is the ratchet of a repository, carried across runs
+type RatchetState struct {
+	// Value is the current ratchet threshold; it only rises, unless reset from the configuration
+	Value       float64   `json:"value"`
+	MaxCoverage float64   `json:"max_coverage"`
+	MaxCommit   string    `json:"max_commit,omitempty"`
+	Tolerance   float64   `json:"tolerance"`
+	ResetValue  *float64  `json:"reset_value,omitempty"`
+	ResetReason string    `json:"reset_reason,omitempty"`
+	ResetAt     time.Time `json:"reset_at,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// carryRatchets copies the ratchets of configured repositories from a previous manifest
+func (m *Manifest) carryRatchets(previous *Manifest, files []string) {
+	if previous == nil || len(previous.Ratchets) == 0 {
+		return
+	}
+
+	m.Ratchets = make(map[string]RatchetState)
+	for _, file := range files {
+		if state, ok := previous.Ratchets[file]; ok {
+			m.Ratchets[file] = state
+		}
+	}
+}
+
+// applyThreshold advances the repository's ratchet with a result and returns its threshold
+// Returns nil when the repository has neither min_coverage nor a ratchet
+func (m *Manifest) applyThreshold(configFile string, cfg config.RepositoryConfig, result Result, now time.Time) *Threshold {
+	var threshold *Threshold
+	if cfg.MinCoverage != nil {
+		minimum := *cfg.MinCoverage
+		threshold = &Threshold{Minimum: &minimum, Effective: minimum}
+	}
+
+	if cfg.Ratchet == nil {
+		delete(m.Ratchets, configFile)
+		return threshold
+	}
+
+	state := m.Ratchets[configFile]
+	state.Tolerance = cfg.Ratchet.Tolerance
+	if reset := cfg.Ratchet.Reset; reset != nil && (state.ResetValue == nil || *state.ResetValue != reset.Value || state.ResetReason != reset.Reason) {
+		value := reset.Value
+		state = RatchetState{
+			Value:       value,
+			Tolerance:   cfg.Ratchet.Tolerance,
+			ResetValue:  &value,
+			ResetReason: reset.Reason,
+			ResetAt:     now,
+		}
+	}
+
+	if result.Status == StatusOK && result.Coverage != nil {
+		coverage := *result.Coverage
+		if coverage > state.MaxCoverage {
+			state.MaxCoverage = coverage
+			state.MaxCommit = result.Commit
+		}
+		state.Value = max(state.Value, round1(coverage-state.Tolerance))
+		state.UpdatedAt = now
+	}
+
+	if m.Ratchets == nil {
+		m.Ratchets = make(map[string]RatchetState)
+	}
+	m.Ratchets[configFile] = state
+
+	if threshold == nil {
+		threshold = &Threshold{}
+	}
+	ratchet := state.Value
+	threshold.Ratchet = &ratchet
+	threshold.Effective = max(threshold.Effective, ratchet)
+	threshold.ResetReason = state.ResetReason
+	return threshold
+}
@@ -0,0 +1,185 @@
+package collect
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/cover"
+)
+
+const (
+	// UncoveredFile is the per-repository export of uncovered regions, published next to the HTML report
+	UncoveredFile = "uncovered.json"
+
+	// uncoveredMarkdownFile is the same export rendered as Markdown
+	uncoveredMarkdownFile = "uncovered.md"
+
+	// uncoveredExportLimit bounds how many regions are published per repository
+	uncoveredExportLimit = 50
+)
+
+// UncoveredRegion is a run of consecutive uncovered blocks in a file
+type UncoveredRegion struct {
+	File       string `json:"file"`
+	StartLine  int    `json:"start_line"`
+	EndLine    int    `json:"end_line"`
+	Statements int    `json:"statements"`
+	URL        string `json:"url,omitempty"`
+}
+
+// UncoveredReport lists the largest uncovered regions of a repository at the measured commit
+type UncoveredReport struct {
+	Repo    string            `json:"repo"`
+	Commit  string            `json:"commit,omitempty"`
+	Regions []UncoveredRegion `json:"regions"`
+}
+
+// UncoveredRegions finds the uncovered regions of a coverage profile, largest first
+// Adjacent uncovered blocks are merged; limit <= 0 returns every region
+func UncoveredRegions(profilePath string, ref SourceRef, limit int) ([]UncoveredRegion, error) {
+	profiles, err := cover.ParseProfiles(profilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse coverage profile %s: %w", profilePath, err)
+	}
+
+	regions := []UncoveredRegion{}
+	for _, profile := range profiles {
+		file, _ := ref.RepoPath(profile.FileName)
+		first := len(regions)
+		var current *UncoveredRegion
+		for _, block := range profile.Blocks {
+			if block.Count > 0 {
+				current = nil
+				continue
+			}
+			if current != nil && block.StartLine <= current.EndLine+1 {
+				current.EndLine = max(current.EndLine, block.EndLine)
+				current.Statements += block.NumStmt
+				continue
+			}
+			regions = append(regions, UncoveredRegion{
+				File:       file,
+				StartLine:  block.StartLine,
+				EndLine:    block.EndLine,
+				Statements: block.NumStmt,
+			})
+			current = &regions[len(regions)-1]
+		}
+		for i := first; i < len(regions); i++ {
+			regions[i].URL = ref.BlobURL(profile.FileName, regions[i].StartLine, regions[i].EndLine)
+		}
+	}
+
+	// Biggest regions first; ties broken by position for a stable output
+	sort.SliceStable(regions, func(i, j int) bool {
+		a, b := regions[i], regions[j]
+		if a.Statements != b.Statements {
+			return a.Statements > b.Statements
+		}
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.StartLine < b.StartLine
+	})
+	if limit > 0 && len(regions) > limit {
+		regions = regions[:limit]
+	}
+	return regions, nil
+}
+
+// Top returns the report limited to its n largest regions
+func (u UncoveredReport) Top(n int) UncoveredReport {
+	if n > 0 && len(u.Regions) > n {
+		u.Regions = u.Regions[:n]
+	}
+	return u
+}
+
+// Markdown renders the regions as a list suitable for pasting into an issue
+func (u UncoveredReport) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "### Largest uncovered regions of %s", u.Repo)
+	if u.Commit != "" {
+		fmt.Fprintf(&b, " at %s", shortCommit(u.Commit))
+	}
+	b.WriteString("\n\n")
+
+	if len(u.Regions) == 0 {
+		b.WriteString("No uncovered regions.\n")
+		return b.String()
+	}
+
+	for _, region := range u.Regions {
+		location := fmt.Sprintf("%s#L%d-L%d", region.File, region.StartLine, region.EndLine)
+		if region.URL != "" {
+			location = fmt.Sprintf("[%s](%s)", location, region.URL)
+		}
+		unit := "statements"
+		if region.Statements == 1 {
+			unit = "statement"
+		}
+		fmt.Fprintf(&b, "- [ ] %s (%d %s)\n", location, region.Statements, unit)
+	}
+	return b.String()
+}
+
+// LoadUncoveredReport reads a repository's uncovered regions from a reports directory or a published site
+// from is either a local directory or an http(s) URL of the published reports, e.g. https://konflux-ci.dev/coverage-dashboard/coverage
+func LoadUncoveredReport(ctx context.Context, from, repo string) (*UncoveredReport, error) {
+	data, err := readPublished(ctx, from, repo+"/"+UncoveredFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read uncovered regions of %s: %w", repo, err)
+	}
+
+	var report UncoveredReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse uncovered regions of %s: %w", repo, err)
+	}
+	return &report, nil
+}
+
+// writeUncovered publishes the largest uncovered regions of a repository next to its HTML report
+func writeUncovered(targetDir, profilePath string, ref SourceRef) error {
+	regions, err := UncoveredRegions(profilePath, ref, uncoveredExportLimit)
+	if err != nil {
+		return err
+	}
+	report := UncoveredReport{Repo: ref.Repo, Commit: ref.Commit, Regions: regions}
+	if err := writeJSON(filepath.Join(targetDir, UncoveredFile), report); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(targetDir, uncoveredMarkdownFile), []byte(report.Markdown()), 0644)
+}
+
+// readPublished reads a file, by its slash-separated path, from a reports directory or the URL of a published site
+func readPublished(ctx context.Context, from, name string) ([]byte, error) {
+	if strings.HasPrefix(from, "http://") || strings.HasPrefix(from, "https://") {
+		return fetch(ctx, strings.TrimSuffix(from, "/")+"/"+name)
+	}
+	return os.ReadFile(filepath.Join(from, filepath.FromSlash(name)))
+}
+
+// fetch downloads a published file
+func fetch(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
@@ -0,0 +1,105 @@
+package collect_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/collect"
+)
+
+const uncoveredProfile = `mode: set
+github.com/org/repo/pkg/a/a.go:3.20,5.2 2 1
+github.com/org/repo/pkg/a/a.go:7.20,9.2 2 0
+github.com/org/repo/pkg/a/a.go:10.2,12.3 3 0
+github.com/org/repo/pkg/a/a.go:14.2,15.3 1 1
+github.com/org/repo/pkg/a/a.go:16.2,17.3 1 0
+github.com/org/repo/pkg/b/b.go:3.20,6.2 4 0
+`
+
+var _ = Describe("Uncovered regions", func() {
+	var (
+		profilePath string
+		ref         collect.SourceRef
+	)
+
+	BeforeEach(func() {
+		profilePath = filepath.Join(GinkgoT().TempDir(), "coverage.out")
+		Expect(os.WriteFile(profilePath, []byte(uncoveredProfile), 0644)).To(Succeed())
+		ref = collect.SourceRef{Repo: "org/repo", Commit: "0123456789abcdef", Modules: map[string]string{"github.com/org/repo": "."}}
+	})
+
+	Describe("UncoveredRegions", func() {
+		It("should merge adjacent uncovered blocks and sort the largest first", func() {
+			regions, err := collect.UncoveredRegions(profilePath, ref, 0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(regions).To(Equal([]collect.UncoveredRegion{
+				{File: "pkg/a/a.go", StartLine: 7, EndLine: 12, Statements: 5, URL: "https://github.com/org/repo/blob/0123456789abcdef/pkg/a/a.go#L7-L12"},
+				{File: "pkg/b/b.go", StartLine: 3, EndLine: 6, Statements: 4, URL: "https://github.com/org/repo/blob/0123456789abcdef/pkg/b/b.go#L3-L6"},
+				{File: "pkg/a/a.go", StartLine: 16, EndLine: 17, Statements: 1, URL: "https://github.com/org/repo/blob/0123456789abcdef/pkg/a/a.go#L16-L17"},
+			}))
+		})
+
+		It("should keep only the requested number of regions", func() {
+			regions, err := collect.UncoveredRegions(profilePath, ref, 1)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(regions).To(HaveLen(1))
+			Expect(regions[0].Statements).To(Equal(5))
+		})
+	})
+
+	Describe("Markdown", func() {
+		It("should list regions with permalinks", func() {
+			regions, err := collect.UncoveredRegions(profilePath, ref, 2)
+			Expect(err).NotTo(HaveOccurred())
+			markdown := collect.UncoveredReport{Repo: "org/repo", Commit: ref.Commit, Regions: regions}.Markdown()
+			Expect(markdown).To(HavePrefix("### Largest uncovered regions of org/repo at 0123456\n\n"))
+			Expect(markdown).To(ContainSubstring("- [ ] [pkg/a/a.go#L7-L12](https://github.com/org/repo/blob/0123456789abcdef/pkg/a/a.go#L7-L12) (5 statements)\n"))
+		})
+
+		It("should list regions without links when the commit is unknown", func() {
+			report := collect.UncoveredReport{Repo: "org/repo", Regions: []collect.UncoveredRegion{{File: "a.go", StartLine: 1, EndLine: 2, Statements: 1}}}
+			Expect(report.Markdown()).To(ContainSubstring("- [ ] a.go#L1-L2 (1 statement)\n"))
+		})
+	})
+
+	Describe("LoadUncoveredReport", func() {
+		report := collect.UncoveredReport{Repo: "org/repo", Regions: []collect.UncoveredRegion{{File: "a.go", StartLine: 1, EndLine: 2, Statements: 1}}}
+
+		It("should read the export from a reports directory", func() {
+			reportsDir := GinkgoT().TempDir()
+			Expect(os.MkdirAll(filepath.Join(reportsDir, "org", "repo"), 0755)).To(Succeed())
+			data, err := json.Marshal(report)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(os.WriteFile(filepath.Join(reportsDir, "org", "repo", collect.UncoveredFile), data, 0644)).To(Succeed())
+
+			loaded, err := collect.LoadUncoveredReport(context.Background(), reportsDir, "org/repo")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*loaded).To(Equal(report))
+		})
+
+		It("should fetch the export from a published site", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/coverage/org/repo/uncovered.json" {
+					http.NotFound(w, r)
+					return
+				}
+				Expect(json.NewEncoder(w).Encode(report)).To(Succeed())
+			}))
+			defer server.Close()
+
+			loaded, err := collect.LoadUncoveredReport(context.Background(), server.URL+"/coverage/", "org/repo")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(loaded.Top(1).Regions).To(HaveLen(1))
+
+			_, err = collect.LoadUncoveredReport(context.Background(), server.URL+"/coverage", "org/missing")
+			Expect(err).To(MatchError(ContainSubstring("404")))
+		})
+	})
+})
@@ -0,0 +1,267 @@
+package collect
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/pr"
+)
+
+// velocityMonth is the length of the month velocity is expressed in
+const velocityMonth = 30 * 24 * time.Hour
+
+// WindowVelocity is the rate of change of coverage over a window ending at the report's date
+type WindowVelocity struct {
+	Days int `json:"days"`
+	// PointsPerMonth is the least-squares slope of coverage over the window, in percentage points per
+	// 30 days; nil with fewer than two measurements
+	PointsPerMonth *float64 `json:"points_per_month"`
+	// Samples counts the measurements in the window, or the repositories with a velocity for teams
+	Samples int `json:"samples"`
+}
+
+// RepoVelocity is the coverage velocity of a repository over every window
+type RepoVelocity struct {
+	Repo     string           `json:"repo"`
+	Owners   []string         `json:"owners"`
+	Coverage *float64         `json:"coverage"`
+	Windows  []WindowVelocity `json:"windows"`
+	// Declining is set when coverage fell faster than the decline threshold over any window
+	Declining bool `json:"declining"`
+}
+
+// TeamVelocity averages the velocity of the repositories of an owner
+type TeamVelocity struct {
+	Owner   string           `json:"owner"`
+	Repos   int              `json:"repos"`
+	Windows []WindowVelocity `json:"windows"`
+	// Declining lists the owner's declining repositories
+	Declining []string `json:"declining"`
+}
+
+// VelocityReport is the coverage velocity of every repository and team
+type VelocityReport struct {
+	GeneratedAt time.Time `json:"generated_at"`
+	// DeclineThreshold is the loss, in percentage points per month, from which a repository is declining
+	DeclineThreshold float64        `json:"decline_threshold"`
+	Repos            []RepoVelocity `json:"repos"`
+	Teams            []TeamVelocity `json:"teams"`
+	// MissedGoals lists the coverage milestones the repositories did not reach by their last day
+	MissedGoals []MissedGoal `json:"missed_goals"`
+}
+
+// Velocity computes the rate of change of a trend over the window of days ending at now
+func Velocity(trend []TrendPoint, days int, now time.Time) WindowVelocity {
+	start := now.Add(-time.Duration(days) * 24 * time.Hour)
+	var points []TrendPoint
+	for _, point := range trend {
+		if point.Date.After(start) && !point.Date.After(now) {
+			points = append(points, point)
+		}
+	}
+	velocity := WindowVelocity{Days: days, Samples: len(points)}
+	if len(points) < 2 {
+		return velocity
+	}
+
+	// Least squares over (months since the window start, coverage)
+	var sumX, sumY, sumXX, sumXY float64
+	for _, point := range points {
+		x := float64(point.Date.Sub(start)) / float64(velocityMonth)
+		sumX += x
+		sumY += point.Coverage
+		sumXX += x * x
+		sumXY += x * point.Coverage
+	}
+	n := float64(len(points))
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return velocity
+	}
+	slope := round1((n*sumXY - sumX*sumY) / denominator)
+	velocity.PointsPerMonth = &slope
+	return velocity
+}
+
+// NewVelocityReport computes the velocity of every result's trend over the windows, and averages it per owner
+// Repositories losing at least decline percentage points per month over any window are flagged as declining,
+// and their missed coverage milestones are listed for the teams
+func NewVelocityReport(results []Result, trends map[string][]TrendPoint, windows []int, decline float64, now time.Time) VelocityReport {
+	report := VelocityReport{GeneratedAt: now, DeclineThreshold: decline, Repos: []RepoVelocity{}, Teams: []TeamVelocity{}, MissedGoals: MissedGoals(results)}
+
+	byOwner := make(map[string]*TeamVelocity)
+	totals := make(map[string][]float64)
+	for _, result := range results {
+		repo := RepoVelocity{Repo: result.Repo, Owners: result.Owners, Coverage: result.Coverage}
+		for _, days := range windows {
+			velocity := Velocity(trends[result.Repo], days, now)
+			if velocity.PointsPerMonth != nil && *velocity.PointsPerMonth <= -decline {
+				repo.Declining = true
+			}
+			repo.Windows = append(repo.Windows, velocity)
+		}
+		report.Repos = append(report.Repos, repo)
+
+		for _, owner := range result.Owners {
+			team, ok := byOwner[owner]
+			if !ok {
+				team = &TeamVelocity{Owner: owner, Declining: []string{}}
+				for _, days := range windows {
+					team.Windows = append(team.Windows, WindowVelocity{Days: days})
+				}
+				byOwner[owner] = team
+				totals[owner] = make([]float64, len(windows))
+			}
+			team.Repos++
+			if repo.Declining {
+				team.Declining = append(team.Declining, repo.Repo)
+			}
+			for i, velocity := range repo.Windows {
+				if velocity.PointsPerMonth != nil {
+					team.Windows[i].Samples++
+					totals[owner][i] += *velocity.PointsPerMonth
+				}
+			}
+		}
+	}
+
+	for owner, team := range byOwner {
+		for i := range team.Windows {
+			if team.Windows[i].Samples > 0 {
+				average := round1(totals[owner][i] / float64(team.Windows[i].Samples))
+				team.Windows[i].PointsPerMonth = &average
+			}
+		}
+		sort.Strings(team.Declining)
+		report.Teams = append(report.Teams, *team)
+	}
+	sort.Slice(report.Repos, func(i, j int) bool { return report.Repos[i].Repo < report.Repos[j].Repo })
+	sort.Slice(report.Teams, func(i, j int) bool { return report.Teams[i].Owner < report.Teams[j].Owner })
+	return report
+}
+
+// Markdown renders the report as tables of teams, declining repositories and missed milestones, e.g. for a quarterly report
+func (v VelocityReport) Markdown() string {
+	var out strings.Builder
+	header := func(first string) {
+		fmt.Fprintf(&out, "| %s |", first)
+		if len(v.Repos) > 0 {
+			for _, window := range v.Repos[0].Windows {
+				fmt.Fprintf(&out, " %dd |", window.Days)
+			}
+		}
+		out.WriteString("\n|---|")
+		if len(v.Repos) > 0 {
+			out.WriteString(strings.Repeat("---:|", len(v.Repos[0].Windows)))
+		}
+		out.WriteString("\n")
+	}
+
+	fmt.Fprintf(&out, "## Coverage velocity (percentage points per month, %s)\n\n", v.GeneratedAt.Format("2006-01-02"))
+	out.WriteString("### Teams\n\n")
+	header("Team")
+	for _, team := range v.Teams {
+		fmt.Fprintf(&out, "| %s |%s\n", team.Owner, formatVelocities(team.Windows))
+	}
+
+	var declining []RepoVelocity
+	for _, repo := range v.Repos {
+		if repo.Declining {
+			declining = append(declining, repo)
+		}
+	}
+	fmt.Fprintf(&out, "\n### Declining repositories (losing %.1f points per month or more)\n\n", v.DeclineThreshold)
+	if len(declining) == 0 {
+		out.WriteString("None\n")
+	} else {
+		header("Repository")
+		for _, repo := range declining {
+			fmt.Fprintf(&out, "| %s |%s\n", repo.Repo, formatVelocities(repo.Windows))
+		}
+	}
+
+	if len(v.MissedGoals) > 0 {
+		out.WriteString("\n### Missed coverage milestones\n\n| Team | Repository | Goal | Coverage |\n|---|---|---:|---:|\n")
+		for _, row := range missedGoalsByTeam(v.MissedGoals) {
+			fmt.Fprintf(&out, "| %s | %s | %.1f%% by %s | %s |\n", row.team, row.goal.Repo, row.goal.Target, row.goal.By, formatCoverage(row.goal.Coverage))
+		}
+	}
+	return out.String()
+}
+
+// teamMissedGoal is a missed milestone listed under one of the repository's owners
+type teamMissedGoal struct {
+	team string
+	goal MissedGoal
+}
+
+// missedGoalsByTeam lists the missed milestones under each owner, sorted by team; unowned repositories come last
+func missedGoalsByTeam(missed []MissedGoal) []teamMissedGoal {
+	var rows []teamMissedGoal
+	for _, goal := range missed {
+		if len(goal.Owners) == 0 {
+			rows = append(rows, teamMissedGoal{team: "—", goal: goal})
+		}
+		for _, owner := range goal.Owners {
+			rows = append(rows, teamMissedGoal{team: owner, goal: goal})
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if (rows[i].team == "—") != (rows[j].team == "—") {
+			return rows[j].team == "—"
+		}
+		return rows[i].team < rows[j].team
+	})
+	return rows
+}
+
+// formatVelocities formats the velocity of each window as table cells
+func formatVelocities(windows []WindowVelocity) string {
+	var cells strings.Builder
+	for _, window := range windows {
+		if window.PointsPerMonth == nil {
+			cells.WriteString(" n/a |")
+			continue
+		}
+		fmt.Fprintf(&cells, " %+.1f |", *window.PointsPerMonth)
+	}
+	return cells.String()
+}
+
+// Trend returns the successful measurements of a repository in the snapshot history, oldest first
+func (h *SnapshotHistory) Trend(ctx context.Context, repo string) ([]TrendPoint, error) {
+	summaries, err := h.Summaries(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+	var trend []TrendPoint
+	for i := len(summaries) - 1; i >= 0; i-- {
+		summary := summaries[i]
+		if summary.Status == StatusOK && summary.Coverage != nil {
+			trend = append(trend, TrendPoint{Date: summary.GeneratedAt, Coverage: *summary.Coverage})
+		}
+	}
+	return trend, nil
+}
+
+// Snapshot returns the dashboard.json committed at the history's ref
+func (h *SnapshotHistory) Snapshot(ctx context.Context) (*Snapshot, error) {
+	content, err := pr.RunGitCommand(ctx, h.dir, "show", h.ref+":"+snapshotDashboardFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s at %s: %w", snapshotDashboardFile, h.ref, err)
+	}
+	var snapshot Snapshot
+	if err := json.Unmarshal([]byte(content), &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse %s at %s: %w", snapshotDashboardFile, h.ref, err)
+	}
+	return &snapshot, nil
+}
+
+// WriteVelocityReport writes the report as JSON, e.g. velocity.json next to coverage.json
+func WriteVelocityReport(path string, report VelocityReport) error {
+	return writeJSON(path, report)
+}
@@ -0,0 +1,92 @@
+package collect_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/collect"
+)
+
+var _ = Describe("Velocity", func() {
+	now := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	// daily returns one measurement per day over the last days, changing by perDay points a day
+	daily := func(days int, start, perDay float64) []collect.TrendPoint {
+		var trend []collect.TrendPoint
+		for i := range days {
+			trend = append(trend, collect.TrendPoint{Date: now.AddDate(0, 0, i-days+1), Coverage: start + perDay*float64(i)})
+		}
+		return trend
+	}
+
+	It("should express the slope over the window in points per month", func() {
+		velocity := collect.Velocity(daily(60, 80, -0.1), 30, now)
+		Expect(velocity.Samples).To(Equal(30))
+		Expect(*velocity.PointsPerMonth).To(Equal(-3.0))
+	})
+
+	It("should need two measurements in the window", func() {
+		trend := []collect.TrendPoint{{Date: now.AddDate(0, 0, -100), Coverage: 50}, {Date: now, Coverage: 60}}
+		velocity := collect.Velocity(trend, 30, now)
+		Expect(velocity.Samples).To(Equal(1))
+		Expect(velocity.PointsPerMonth).To(BeNil())
+	})
+
+	Describe("NewVelocityReport", func() {
+		results := []collect.Result{
+			{Repo: "org/bravo", Owners: []string{"@org/build"}},
+			{Repo: "org/alpha", Owners: []string{"@org/build", "@org/core"}},
+			{Repo: "org/charlie", Owners: []string{"@org/core"}},
+		}
+		trends := map[string][]collect.TrendPoint{
+			"org/alpha": daily(90, 70, 0.1),
+			"org/bravo": daily(90, 70, -0.05),
+		}
+
+		It("should flag repositories declining faster than the threshold and average teams", func() {
+			report := collect.NewVelocityReport(results, trends, []int{30, 90}, 1, now)
+
+			Expect(report.Repos).To(HaveLen(3))
+			Expect(report.Repos[0].Repo).To(Equal("org/alpha"))
+			Expect(*report.Repos[0].Windows[0].PointsPerMonth).To(Equal(3.0))
+			Expect(report.Repos[0].Declining).To(BeFalse())
+			Expect(*report.Repos[1].Windows[1].PointsPerMonth).To(Equal(-1.5))
+			Expect(report.Repos[1].Declining).To(BeTrue())
+			Expect(report.Repos[2].Windows[0].PointsPerMonth).To(BeNil())
+
+			Expect(report.Teams).To(HaveLen(2))
+			build := report.Teams[0]
+			Expect(build.Owner).To(Equal("@org/build"))
+			Expect(build.Repos).To(Equal(2))
+			Expect(*build.Windows[0].PointsPerMonth).To(Equal(0.8))
+			Expect(build.Declining).To(Equal([]string{"org/bravo"}))
+			core := report.Teams[1]
+			Expect(core.Windows[0].Samples).To(Equal(1))
+			Expect(core.Declining).To(BeEmpty())
+		})
+
+		It("should render teams and declining repositories as Markdown tables", func() {
+			markdown := collect.NewVelocityReport(results, trends, []int{30, 90}, 1, now).Markdown()
+			Expect(markdown).To(ContainSubstring("| Team | 30d | 90d |"))
+			Expect(markdown).To(ContainSubstring("| @org/build | +0.8 | +0.8 |"))
+			Expect(markdown).To(ContainSubstring("| org/bravo | -1.5 | -1.5 |"))
+			Expect(markdown).NotTo(ContainSubstring("| org/alpha |"))
+		})
+
+		It("should list missed coverage milestones by team", func() {
+			coverage := 52.0
+			missed := append([]collect.Result{{
+				Repo:     "org/delta",
+				Owners:   []string{"@org/core"},
+				Coverage: &coverage,
+				Goals:    []collect.GoalProgress{{Target: 60, By: "2026-06-30", Status: collect.GoalMissed}},
+			}}, results...)
+			report := collect.NewVelocityReport(missed, trends, []int{30, 90}, 1, now)
+			Expect(report.MissedGoals).To(HaveLen(1))
+			markdown := report.Markdown()
+			Expect(markdown).To(ContainSubstring("### Missed coverage milestones"))
+			Expect(markdown).To(ContainSubstring("| @org/core | org/delta |"))
+		})
+	})
+})
@@ -0,0 +1,210 @@
+package collect
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/cover"
+)
+
+// VulnerablePathsFile is the per-repository export of vulnerable call paths, published next to the HTML report
+const VulnerablePathsFile = "vulnerable-paths.json"
+
+// VulnerableFrame is a function of the repository on a call path to a vulnerable symbol
+type VulnerableFrame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	URL      string `json:"url,omitempty"`
+	// Covered is set when tests executed the code at the frame's position
+	Covered bool `json:"covered"`
+}
+
+// VulnerablePath is a call path from the repository's code to a symbol affected by a vulnerability
+type VulnerablePath struct {
+	OSV          string `json:"osv"`
+	Symbol       string `json:"symbol"`
+	FixedVersion string `json:"fixed_version,omitempty"`
+	// Frames are the repository's functions on the path, from the entry point towards the symbol
+	Frames []VulnerableFrame `json:"frames"`
+	// Covered is set when tests execute every frame of the path
+	Covered bool `json:"covered"`
+}
+
+// VulnerabilityReport lists the vulnerable call paths of a repository at the measured commit
+type VulnerabilityReport struct {
+	Repo   string           `json:"repo"`
+	Commit string           `json:"commit,omitempty"`
+	Paths  []VulnerablePath `json:"paths"`
+}
+
+// Uncovered counts the paths tests do not fully execute
+func (v VulnerabilityReport) Uncovered() int {
+	uncovered := 0
+	for _, p := range v.Paths {
+		if !p.Covered {
+			uncovered++
+		}
+	}
+	return uncovered
+}
+
+// govulncheckMessage is a message of govulncheck -json output; only findings are used
+type govulncheckMessage struct {
+	Finding *struct {
+		OSV          string             `json:"osv"`
+		FixedVersion string             `json:"fixed_version"`
+		Trace        []govulncheckFrame `json:"trace"`
+	} `json:"finding"`
+}
+
+// govulncheckFrame is a frame of a finding's trace, ordered from the vulnerable symbol to the entry point
+type govulncheckFrame struct {
+	Package  string `json:"package"`
+	Function string `json:"function"`
+	Receiver string `json:"receiver"`
+	Position *struct {
+		Filename string `json:"filename"`
+		Line     int    `json:"line"`
+	} `json:"position"`
+}
+
+// name formats the frame's function as package.Function or package.Receiver.Method, with the package
+// qualified by its full import path or only its name
+func (f govulncheckFrame) name(fullPackage bool) string {
+	name := path.Base(f.Package) + "."
+	if fullPackage {
+		name = f.Package + "."
+	}
+	if f.Receiver != "" {
+		name += strings.TrimPrefix(f.Receiver, "*") + "."
+	}
+	return name + f.Function
+}
+
+// ParseVulnerablePaths reads govulncheck -json output and checks the repository frames of every call path
+// against a coverage profile. Findings without a call path, i.e. at module or package level, are skipped
+func ParseVulnerablePaths(output io.Reader, profilePath string, ref SourceRef) ([]VulnerablePath, error) {
+	profiles, err := cover.ParseProfiles(profilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse coverage profile %s: %w", profilePath, err)
+	}
+	blocks := make(map[string][]cover.ProfileBlock, len(profiles))
+	for _, profile := range profiles {
+		blocks[profile.FileName] = profile.Blocks
+	}
+
+	paths := []VulnerablePath{}
+	seen := make(map[string]bool)
+	decoder := json.NewDecoder(output)
+	for {
+		var message govulncheckMessage
+		err := decoder.Decode(&message)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse govulncheck output: %w", err)
+		}
+		finding := message.Finding
+		if finding == nil || len(finding.Trace) == 0 || finding.Trace[0].Function == "" {
+			continue
+		}
+
+		p := VulnerablePath{OSV: finding.OSV, Symbol: finding.Trace[0].name(true), FixedVersion: finding.FixedVersion, Covered: true}
+		for i := len(finding.Trace) - 1; i > 0; i-- {
+			frame := finding.Trace[i]
+			if frame.Position == nil || frame.Function == "" {
+				continue
+			}
+			fileName := frame.Package + "/" + path.Base(filepath.ToSlash(frame.Position.Filename))
+			repoPath, inRepo := ref.RepoPath(fileName)
+			fileBlocks, measured := blocks[fileName]
+			if !inRepo && !measured {
+				continue
+			}
+			covered := lineExecuted(fileBlocks, frame.Position.Line)
+			p.Frames = append(p.Frames, VulnerableFrame{
+				Function: frame.name(false),
+				File:     repoPath,
+				Line:     frame.Position.Line,
+				URL:      ref.BlobURL(fileName, frame.Position.Line, 0),
+				Covered:  covered,
+			})
+			p.Covered = p.Covered && covered
+		}
+		if len(p.Frames) == 0 {
+			continue
+		}
+
+		key := p.OSV + " " + p.Symbol
+		for _, frame := range p.Frames {
+			key += fmt.Sprintf(" %s:%d", frame.File, frame.Line)
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		paths = append(paths, p)
+	}
+
+	// Untested paths first, as the ones to prioritize
+	sort.SliceStable(paths, func(i, j int) bool {
+		a, b := paths[i], paths[j]
+		if a.Covered != b.Covered {
+			return !a.Covered
+		}
+		if a.OSV != b.OSV {
+			return a.OSV < b.OSV
+		}
+		return a.Frames[0].Function < b.Frames[0].Function
+	})
+	return paths, nil
+}
+
+// lineExecuted reports whether a block spanning the line was executed
+func lineExecuted(blocks []cover.ProfileBlock, line int) bool {
+	for _, block := range blocks {
+		if block.StartLine <= line && line <= block.EndLine && block.Count > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// analyzeVulnerabilities runs govulncheck on the repository and checks its vulnerable call paths against the profile
+func analyzeVulnerabilities(ctx context.Context, repoDir, profilePath string, ref SourceRef) (*VulnerabilityReport, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "govulncheck", "-json", "./...")
+	cmd.Dir = repoDir
+	cmd.WaitDelay = commandWaitDelay
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("govulncheck failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	paths, err := ParseVulnerablePaths(&stdout, profilePath, ref)
+	if err != nil {
+		return nil, err
+	}
+	return &VulnerabilityReport{Repo: ref.Repo, Commit: ref.Commit, Paths: paths}, nil
+}
+
+// addVulnerablePaths links the vulnerable call paths from the header of an HTML report
+func addVulnerablePaths(report string, vulns *VulnerabilityReport) string {
+	if vulns == nil || len(vulns.Paths) == 0 {
+		return report
+	}
+	header := fmt.Sprintf(`<div id="vulnerabilities" style="float: right; margin: 12px 10px 0 0;"><a href="%s" style="color: rgb(255, 168, 168);">%d vulnerable call paths, %d not covered by tests</a></div>`,
+		VulnerablePathsFile, len(vulns.Paths), vulns.Uncovered())
+	return strings.Replace(report, `<div id="legend">`, header+`<div id="legend">`, 1)
+}
@@ -0,0 +1,70 @@
+package collect_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/collect"
+)
+
+var _ = Describe("ParseVulnerablePaths", func() {
+	const output = `{"config": {"scanner_name": "govulncheck"}}
+{"progress": {"message": "Scanning your code..."}}
+{"finding": {"osv": "GO-2024-0001", "trace": [{"module": "golang.org/x/net"}]}}
+{"finding": {"osv": "GO-2024-0001", "fixed_version": "v0.23.0", "trace": [
+  {"module": "golang.org/x/net", "package": "golang.org/x/net/html", "function": "Parse"},
+  {"module": "example.com/demo", "package": "example.com/demo/api", "function": "Render", "receiver": "*Server", "position": {"filename": "/work/demo/api/server.go", "line": 12}},
+  {"module": "example.com/demo", "package": "example.com/demo/cmd", "function": "main", "position": {"filename": "/work/demo/cmd/main.go", "line": 5}}]}}
+{"finding": {"osv": "GO-2024-0002", "trace": [
+  {"module": "golang.org/x/text", "package": "golang.org/x/text/language", "function": "Parse"},
+  {"module": "example.com/demo", "package": "example.com/demo/api", "function": "Locale", "position": {"filename": "/work/demo/api/server.go", "line": 30}}]}}
+`
+
+	var (
+		profile string
+		ref     collect.SourceRef
+	)
+
+	BeforeEach(func() {
+		profile = filepath.Join(GinkgoT().TempDir(), "coverage.out")
+		content := "mode: set\n" +
+			"example.com/demo/api/server.go:10.20,14.2 3 1\n" +
+			"example.com/demo/api/server.go:28.20,32.2 2 1\n" +
+			"example.com/demo/cmd/main.go:4.13,7.2 2 0\n"
+		Expect(os.WriteFile(profile, []byte(content), 0644)).To(Succeed())
+		ref = collect.SourceRef{Repo: "org/demo", Commit: "abc1234", Modules: map[string]string{"example.com/demo": "."}}
+	})
+
+	It("should check every call path's repository frames against the profile, untested first", func() {
+		paths, err := collect.ParseVulnerablePaths(strings.NewReader(output), profile, ref)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(paths).To(HaveLen(2))
+
+		Expect(paths[0].OSV).To(Equal("GO-2024-0001"))
+		Expect(paths[0].Symbol).To(Equal("golang.org/x/net/html.Parse"))
+		Expect(paths[0].FixedVersion).To(Equal("v0.23.0"))
+		Expect(paths[0].Covered).To(BeFalse())
+		Expect(paths[0].Frames).To(Equal([]collect.VulnerableFrame{
+			{Function: "cmd.main", File: "cmd/main.go", Line: 5, URL: "https://github.com/org/demo/blob/abc1234/cmd/main.go#L5"},
+			{Function: "api.Server.Render", File: "api/server.go", Line: 12, URL: "https://github.com/org/demo/blob/abc1234/api/server.go#L12", Covered: true},
+		}))
+
+		Expect(paths[1].OSV).To(Equal("GO-2024-0002"))
+		Expect(paths[1].Covered).To(BeTrue())
+	})
+
+	It("should skip findings without a call path", func() {
+		paths, err := collect.ParseVulnerablePaths(strings.NewReader(`{"finding": {"osv": "GO-2024-0003", "trace": [{"module": "golang.org/x/net", "package": "golang.org/x/net/html"}]}}`), profile, ref)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(paths).To(BeEmpty())
+	})
+
+	It("should count the paths not covered by tests", func() {
+		report := collect.VulnerabilityReport{Paths: []collect.VulnerablePath{{Covered: true}, {}, {}}}
+		Expect(report.Uncovered()).To(Equal(2))
+	})
+})
@@ -0,0 +1,192 @@
+package collect
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+	"github.com/konflux-ci/coverage-dashboard/internal/coverage"
+)
+
+// replayProfile is the raw profile of a module, written in its directory before the raw profiles of a
+// repository's modules are merged into RawProfileFile
+const replayProfile = "coverage_replay.out"
+
+// ReplayCoverage is the coverage of a raw profile under a configuration
+type ReplayCoverage struct {
+	Covered int
+	Total   int
+}
+
+// Percent returns the coverage, nil when the configuration leaves no statement
+func (c ReplayCoverage) Percent() *float64 {
+	if c.Total == 0 {
+		return nil
+	}
+	coverage := round1(float64(c.Covered) / float64(c.Total) * 100)
+	return &coverage
+}
+
+// add counts the statements of a file
+func (c *ReplayCoverage) add(counts coverage.Counts) {
+	c.Covered += counts.Covered
+	c.Total += counts.Total
+}
+
+// WhatIf compares the coverage of a stored raw profile under a repository's configuration and a modified one
+type WhatIf struct {
+	Repo   string
+	Commit string
+	// Recorded is the coverage published for the commit
+	Recorded *float64
+	Current  ReplayCoverage
+	Modified ReplayCoverage
+	// Removed are files counted under the current configuration that the modified one excludes
+	Removed []ExcludedItem
+	// Restored are files excluded by the current configuration that the modified one counts again
+	Restored []ExcludedItem
+	// Limitations are changes of the modified configuration the raw profile cannot replay
+	Limitations []string
+}
+
+// NewWhatIf replays a raw profile under the current configuration of its repository and a modified one
+// Packages excluded by exclude_dirs or skipped as test helpers when the profile was recorded are missing from
+// it, so only further excludes and dropped exclude_files can be evaluated. Excludes of modules are replayed
+// on the whole repository
+func NewWhatIf(profile io.Reader, current, modified config.RepositoryConfig) (*WhatIf, error) {
+	files, err := coverage.Summarize(profile, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse raw profile: %w", err)
+	}
+	excludedByCurrent, err := replayExcludes(current)
+	if err != nil {
+		return nil, err
+	}
+	excludedByModified, err := replayExcludes(modified)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	whatIf := &WhatIf{Repo: current.Name, Limitations: replayLimitations(current, modified)}
+	for _, name := range names {
+		counts := files[name]
+		inCurrent, inModified := !excludedByCurrent(name), !excludedByModified(name)
+		if inCurrent {
+			whatIf.Current.add(counts)
+		}
+		if inModified {
+			whatIf.Modified.add(counts)
+		}
+		switch {
+		case inCurrent && !inModified:
+			whatIf.Removed = append(whatIf.Removed, ExcludedItem{Path: name, Statements: counts.Total})
+		case !inCurrent && inModified:
+			whatIf.Restored = append(whatIf.Restored, ExcludedItem{Path: name, Statements: counts.Total})
+		}
+	}
+	return whatIf, nil
+}
+
+// replayExcludes returns whether a file of a raw profile is excluded by the excludes of a configuration and
+// of all its modules
+func replayExcludes(cfg config.RepositoryConfig) (func(fileName string) bool, error) {
+	var dirs, files []string
+	for _, module := range cfg.EffectiveModules() {
+		dirs = append(dirs, module.ExcludeDirs...)
+		files = append(files, module.ExcludeFiles...)
+	}
+
+	var packages *regexp.Regexp
+	if pattern := BuildExcludePattern(dirs); pattern != "" {
+		var err error
+		if packages, err = regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("invalid exclude_dirs pattern %q: %w", pattern, err)
+		}
+	}
+	fileExcludes, err := CompileFileExcludes(files)
+	if err != nil {
+		return nil, err
+	}
+	return func(fileName string) bool {
+		return (packages != nil && packages.MatchString(path.Dir(fileName))) || matchesAny(fileName, fileExcludes)
+	}, nil
+}
+
+// replayLimitations describes the changes from the current configuration to the modified one a raw profile
+// cannot replay
+func replayLimitations(current, modified config.RepositoryConfig) []string {
+	var limitations []string
+	for _, dir := range current.ExcludeDirs {
+		if !slices.Contains(modified.ExcludeDirs, dir) {
+			limitations = append(limitations, fmt.Sprintf("exclude_dirs entry %q is dropped, but the packages it excluded were not tested when the profile was recorded", dir))
+		}
+	}
+	if current.IncludeTestHelpers != modified.IncludeTestHelpers {
+		limitations = append(limitations, "include_test_helpers changes, but test helpers are only measured when collecting")
+	}
+	return limitations
+}
+
+// Print writes the comparison in a human-readable form
+func (w *WhatIf) Print() {
+	fmt.Printf("🔁 What-if for %s at %s\n", w.Repo, shortHash(w.Commit))
+	fmt.Printf("   Recorded coverage: %s\n", formatCoverage(w.Recorded))
+	fmt.Printf("   Current configuration: %d/%d statements covered, %s\n", w.Current.Covered, w.Current.Total, formatCoverage(w.Current.Percent()))
+	fmt.Printf("   Modified configuration: %d/%d statements covered, %s\n", w.Modified.Covered, w.Modified.Total, formatCoverage(w.Modified.Percent()))
+	fmt.Println()
+
+	if len(w.Removed) > 0 {
+		fmt.Println("📄 Files the modified configuration excludes:")
+		for _, item := range w.Removed {
+			fmt.Printf("   - %s (%d statements)\n", item.Path, item.Statements)
+		}
+		fmt.Println()
+	}
+	if len(w.Restored) > 0 {
+		fmt.Println("📄 Files the modified configuration counts again:")
+		for _, item := range w.Restored {
+			fmt.Printf("   + %s (%d statements)\n", item.Path, item.Statements)
+		}
+		fmt.Println()
+	}
+	if len(w.Limitations) > 0 {
+		fmt.Println("⚠️  Not replayed:")
+		for _, limitation := range w.Limitations {
+			fmt.Printf("   - %s\n", limitation)
+		}
+		fmt.Println()
+	}
+
+	before, after := w.Current.Percent(), w.Modified.Percent()
+	if before == nil || after == nil {
+		fmt.Printf("📈 Coverage: %s → %s\n", formatCoverage(before), formatCoverage(after))
+		return
+	}
+	fmt.Printf("📈 Coverage: %.1f%% → %.1f%% (%+.1f points)\n", *before, *after, *after-*before)
+}
+
+// mergeReplayProfiles merges the raw profiles of a repository's modules into its RawProfileFile
+func mergeReplayProfiles(repoDir string, runs []moduleRun) error {
+	var replays []string
+	for _, run := range runs {
+		if _, err := os.Stat(run.replay); err == nil {
+			replays = append(replays, run.replay)
+		}
+	}
+	if len(replays) == 0 {
+		return nil
+	}
+	return MergeProfiles(filepath.Join(repoDir, RawProfileFile), replays)
+}
@@ -0,0 +1,90 @@
+package collect_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/collect"
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+)
+
+var _ = Describe("WhatIf", func() {
+	const rawProfile = `mode: set
+example.com/demo/api/types.go:3.1,5.2 4 1
+example.com/demo/api/types.go:6.1,7.2 2 0
+example.com/demo/api/zz_generated.deepcopy.go:3.1,5.2 4 0
+example.com/demo/internal/legacy/legacy.go:3.1,6.2 6 0
+example.com/demo/cmd/main.go:3.1,4.2 4 4
+`
+
+	current := config.RepositoryConfig{
+		Name:         "example/demo",
+		ExcludeDirs:  []string{"hack/"},
+		ExcludeFiles: []string{"zz_generated.deepcopy.go"},
+	}
+
+	It("should compare the coverage of the current and modified configurations", func() {
+		modified := config.RepositoryConfig{
+			Name:        "example/demo",
+			ExcludeDirs: []string{"hack/", "internal/legacy/"},
+		}
+
+		whatIf, err := collect.NewWhatIf(strings.NewReader(rawProfile), current, modified)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(whatIf.Current).To(Equal(collect.ReplayCoverage{Covered: 8, Total: 16}))
+		Expect(whatIf.Modified).To(Equal(collect.ReplayCoverage{Covered: 8, Total: 14}))
+		Expect(*whatIf.Current.Percent()).To(Equal(50.0))
+		Expect(*whatIf.Modified.Percent()).To(Equal(57.1))
+		Expect(whatIf.Removed).To(Equal([]collect.ExcludedItem{{Path: "example.com/demo/internal/legacy/legacy.go", Statements: 6}}))
+		Expect(whatIf.Restored).To(Equal([]collect.ExcludedItem{{Path: "example.com/demo/api/zz_generated.deepcopy.go", Statements: 4}}))
+		Expect(whatIf.Limitations).To(BeEmpty())
+	})
+
+	It("should replay the excludes of modules on the whole repository", func() {
+		modified := current
+		modified.Modules = []config.ModuleConfig{{Path: "."}, {Path: "cmd", ExcludeDirs: []string{"cmd"}}}
+
+		whatIf, err := collect.NewWhatIf(strings.NewReader(rawProfile), current, modified)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(whatIf.Removed).To(Equal([]collect.ExcludedItem{{Path: "example.com/demo/cmd/main.go", Statements: 4}}))
+		Expect(whatIf.Modified.Percent()).To(HaveValue(Equal(33.3)))
+	})
+
+	It("should flag changes the raw profile cannot replay", func() {
+		modified := config.RepositoryConfig{Name: "example/demo", IncludeTestHelpers: true}
+
+		whatIf, err := collect.NewWhatIf(strings.NewReader(rawProfile), current, modified)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(whatIf.Limitations).To(HaveLen(2))
+		Expect(whatIf.Limitations[0]).To(ContainSubstring(`"hack/" is dropped`))
+		Expect(whatIf.Limitations[1]).To(ContainSubstring("include_test_helpers"))
+	})
+
+	It("should load the raw profile archived with a report", func() {
+		reportsDir := GinkgoT().TempDir()
+		repoDir := filepath.Join(reportsDir, "example/demo")
+		Expect(os.MkdirAll(repoDir, 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(repoDir, "index.html"), []byte("<html></html>"), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(repoDir, collect.RawProfileFile), []byte(rawProfile), 0644)).To(Succeed())
+		coverage := 50.0
+		now := time.Date(2025, 1, 15, 6, 0, 0, 0, time.UTC)
+		_, err := collect.ArchiveReport(reportsDir, collect.Result{Repo: "example/demo", Commit: "abc1234", Coverage: &coverage}, now, collect.ReportRetention{})
+		Expect(err).NotTo(HaveOccurred())
+
+		archive, err := collect.FetchReportArchive(context.Background(), reportsDir, "example/demo")
+		Expect(err).NotTo(HaveOccurred())
+		report, err := archive.Find("abc")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.Commit).To(Equal("abc1234"))
+
+		profile, err := collect.LoadProfile(context.Background(), reportsDir, "example/demo", report, collect.ProfileRaw)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(profile)).To(Equal(rawProfile))
+	})
+})
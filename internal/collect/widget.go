@@ -0,0 +1,198 @@
+package collect
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/locale"
+)
+
+const (
+	// WidgetFile is the per-repository JSON widget payload, published next to the HTML report
+	WidgetFile = "widget.json"
+	// WidgetFragmentFile is the per-repository HTML widget, meant to be embedded in an iframe
+	WidgetFragmentFile = "widget.html"
+)
+
+// trendHistorySize is the number of recent measurements kept per repository for trend sparklines
+const trendHistorySize = 30
+
+// TrendPoint is the coverage measured by one past collection of a repository
+type TrendPoint struct {
+	Date     time.Time `json:"date"`
+	Coverage float64   `json:"coverage"`
+}
+
+// Widget is the summary of a repository embedded in other pages: its badge, coverage trend and last run
+type Widget struct {
+	Repo     string   `json:"repo"`
+	Coverage *float64 `json:"coverage"`
+	Status   string   `json:"status"`
+	// Color is the badge color used by the dashboard for the coverage: green, orange or red, grey once stale
+	Color   string    `json:"color"`
+	Commit  string    `json:"commit,omitempty"`
+	LastRun time.Time `json:"last_run"`
+	// Trend lists the most recent measurements, oldest first
+	Trend     []TrendPoint `json:"trend"`
+	ReportURL string       `json:"report_url"`
+	// Stale is set when the coverage was last collected longer ago than the repository's max age
+	Stale         bool       `json:"stale,omitempty"`
+	LastCollected *time.Time `json:"last_collected,omitempty"`
+	// Locale is the BCP 47 tag of the locale the HTML widget formats numbers and dates in
+	Locale string `json:"locale,omitempty"`
+}
+
+// NewWidget builds the widget of a repository from its latest run and coverage trend
+func NewWidget(result Result, trend []TrendPoint, lastRun time.Time) Widget {
+	if trend == nil {
+		trend = []TrendPoint{}
+	}
+	widget := Widget{
+		Repo:          result.Repo,
+		Coverage:      result.Coverage,
+		Status:        result.Status,
+		Color:         CoverageColor(result.Coverage),
+		Commit:        result.Commit,
+		LastRun:       lastRun,
+		Trend:         trend,
+		ReportURL:     "index.html",
+		Stale:         result.Stale,
+		LastCollected: result.LastCollected,
+	}
+	if result.Stale {
+		widget.Color = "grey"
+	}
+	return widget
+}
+
+// CoverageColor matches the coverage bar colors of index.html
+func CoverageColor(coverage *float64) string {
+	switch {
+	case coverage == nil || *coverage < 50:
+		return "red"
+	case *coverage < 80:
+		return "orange"
+	default:
+		return "green"
+	}
+}
+
+// Sparkline returns the SVG polyline points of the trend scaled to width x height, or "" with fewer than two points
+func (w Widget) Sparkline(width, height float64) string {
+	if len(w.Trend) < 2 {
+		return ""
+	}
+	low, high := w.Trend[0].Coverage, w.Trend[0].Coverage
+	for _, point := range w.Trend {
+		low = min(low, point.Coverage)
+		high = max(high, point.Coverage)
+	}
+	// A flat trend is drawn across the middle
+	span := high - low
+	if span == 0 {
+		span = 1
+		low -= 0.5
+	}
+
+	points := make([]string, len(w.Trend))
+	for i, point := range w.Trend {
+		x := width * float64(i) / float64(len(w.Trend)-1)
+		y := height - height*(point.Coverage-low)/span
+		points[i] = fmt.Sprintf("%s,%s", formatPoint(x), formatPoint(y))
+	}
+	return strings.Join(points, " ")
+}
+
+// formatPoint formats an SVG coordinate with one decimal, without trailing zeros
+func formatPoint(value float64) string {
+	return strings.TrimSuffix(fmt.Sprintf("%.1f", round1(value)), ".0")
+}
+
+// widgetColors are the badge and sparkline colors per coverage color
+var widgetColors = map[string]struct{ Background, Text string }{
+	"green":  {"#dcfce7", "#15803d"},
+	"orange": {"#fef3c7", "#b45309"},
+	"red":    {"#fee2e2", "#b91c1c"},
+	"grey":   {"#f3f4f6", "#6b7280"},
+}
+
+// widgetTemplate renders the HTML widget of a repository
+var widgetTemplate = template.Must(template.ParseFS(templates, "templates/widget.html"))
+
+// RenderWidget renders the self-contained HTML fragment of a widget
+func RenderWidget(w Widget) (string, error) {
+	var out bytes.Buffer
+	loc := locale.Lookup(w.Locale)
+	coverage := "N/A"
+	if w.Coverage != nil {
+		coverage = loc.Percent(*w.Coverage)
+	}
+	err := widgetTemplate.Execute(&out, struct {
+		Widget    Widget
+		Colors    struct{ Background, Text string }
+		Coverage  string
+		Sparkline string
+		Locale    locale.Locale
+	}{
+		Widget:    w,
+		Colors:    widgetColors[w.Color],
+		Coverage:  coverage,
+		Sparkline: w.Sparkline(100, 24),
+		Locale:    loc,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to render widget of %s: %w", w.Repo, err)
+	}
+	return out.String(), nil
+}
+
+// writeWidget writes the JSON and HTML widgets of a repository to its reports directory
+func writeWidget(reportsDir string, w Widget) error {
+	targetDir := filepath.Join(reportsDir, w.Repo)
+	if err := os.MkdirAll(targetDir, 0755); err != nil {
+		return fmt.Errorf("failed to create report directory: %w", err)
+	}
+	if err := writeJSON(filepath.Join(targetDir, WidgetFile), w); err != nil {
+		return err
+	}
+	fragment, err := RenderWidget(w)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(targetDir, WidgetFragmentFile), []byte(fragment), 0644)
+}
+
+// recordTrend appends the coverage of a successful collection to the repository's recent trend
+func (m *Manifest) recordTrend(run RepoRun, at time.Time) {
+	if run.Result.Coverage == nil {
+		return
+	}
+	if m.Trends == nil {
+		m.Trends = make(map[string][]TrendPoint)
+	}
+
+	points := append(m.Trends[run.ConfigFile], TrendPoint{Date: at, Coverage: *run.Result.Coverage})
+	if len(points) > trendHistorySize {
+		points = points[len(points)-trendHistorySize:]
+	}
+	m.Trends[run.ConfigFile] = points
+}
+
+// carryTrends copies the coverage trends of configured repositories from a previous manifest
+func (m *Manifest) carryTrends(previous *Manifest, files []string) {
+	if previous == nil || len(previous.Trends) == 0 {
+		return
+	}
+
+	m.Trends = make(map[string][]TrendPoint)
+	for _, file := range files {
+		if points, ok := previous.Trends[file]; ok {
+			m.Trends[file] = append([]TrendPoint(nil), points...)
+		}
+	}
+}
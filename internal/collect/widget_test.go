@@ -0,0 +1,57 @@
+package collect_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/collect"
+)
+
+var _ = Describe("Widget", func() {
+	lastRun := time.Date(2024, 5, 1, 6, 30, 0, 0, time.UTC)
+	coverage := 72.5
+	trend := []collect.TrendPoint{
+		{Date: lastRun.AddDate(0, 0, -2), Coverage: 70},
+		{Date: lastRun.AddDate(0, 0, -1), Coverage: 75},
+		{Date: lastRun, Coverage: 72.5},
+	}
+
+	It("should use the dashboard's coverage colors", func() {
+		widget := collect.NewWidget(collect.Result{Repo: "org/demo", Coverage: &coverage, Status: collect.StatusOK}, trend, lastRun)
+		Expect(widget.Color).To(Equal("orange"))
+		Expect(collect.NewWidget(collect.Result{Repo: "org/demo", Status: collect.StatusFailed}, nil, lastRun).Color).To(Equal("red"))
+	})
+
+	It("should scale the trend to the sparkline's box", func() {
+		widget := collect.NewWidget(collect.Result{Repo: "org/demo", Coverage: &coverage}, trend, lastRun)
+		Expect(widget.Sparkline(100, 20)).To(Equal("0,20 50,0 100,10"))
+	})
+
+	It("should draw flat trends across the middle and skip single points", func() {
+		flat := []collect.TrendPoint{{Coverage: 50}, {Coverage: 50}}
+		Expect(collect.NewWidget(collect.Result{}, flat, lastRun).Sparkline(100, 20)).To(Equal("0,10 100,10"))
+		Expect(collect.NewWidget(collect.Result{}, flat[:1], lastRun).Sparkline(100, 20)).To(BeEmpty())
+	})
+
+	It("should render a self-contained fragment with the badge, sparkline and last run", func() {
+		html, err := collect.RenderWidget(collect.NewWidget(collect.Result{Repo: "org/demo", Coverage: &coverage, Status: collect.StatusOK}, trend, lastRun))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(html).To(ContainSubstring(`<span class="badge" title="Status: ok">72.5%</span>`))
+		Expect(html).To(ContainSubstring(`<polyline`))
+		Expect(html).To(ContainSubstring("Last run 2024-05-01 06:30 UTC"))
+		Expect(html).To(ContainSubstring(`href="index.html"`))
+		Expect(html).NotTo(ContainSubstring("<script"))
+	})
+
+	It("should format the badge and last run in the widget's locale", func() {
+		widget := collect.NewWidget(collect.Result{Repo: "org/demo", Coverage: &coverage, Status: collect.StatusOK}, trend, lastRun)
+		widget.Locale = "de-DE"
+		html, err := collect.RenderWidget(widget)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(html).To(ContainSubstring(`<html lang="de-DE">`))
+		Expect(html).To(ContainSubstring("72,5\u00a0%</span>"))
+		Expect(html).To(ContainSubstring("Last run 01.05.2024, 06:30 UTC"))
+	})
+})
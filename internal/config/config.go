@@ -5,25 +5,171 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
 var (
 	// repoNamePattern validates repository names in org/repo format
-	// Allows: alphanumerics, underscores, hyphens, and requires a single forward slash
-	repoNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+/[a-zA-Z0-9_-]+$`)
+	// Allows: alphanumerics, underscores, hyphens, dots in repository names but not first, so neither "." nor
+	// ".." pass, and requires a single forward slash
+	repoNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+/[a-zA-Z0-9_-][a-zA-Z0-9._-]*$`)
 )
 
+// RepoNameRule describes the repository names ValidRepoName accepts, for the errors refusing the others
+const RepoNameRule = "in org/repo form with only alphanumerics, underscores, hyphens and dots, the repository not starting with a dot"
+
+// ValidRepoName reports whether a repository name is in org/repo format with only alphanumerics,
+// underscores, hyphens and dots, and so safe to build GitHub URLs and paths from
+func ValidRepoName(name string) bool {
+	return repoNamePattern.MatchString(name)
+}
+
 // RepositoryConfig represents a repository configuration
 type RepositoryConfig struct {
 	Name         string   `yaml:"name"`
 	ExcludeDirs  []string `yaml:"exclude_dirs"`
 	ExcludeFiles []string `yaml:"exclude_files"`
-	Owners       []string `yaml:"-"` // Not serialized, used for CODEOWNERS
+	Timeout      string   `yaml:"timeout,omitempty"` // Coverage collection timeout, e.g. "45m"
+	// RegressionDelta overrides the coverage drop, in percentage points, reported as a regression
+	RegressionDelta *float64 `yaml:"regression_delta,omitempty"`
+	// MinCoverage is the coverage percentage the repository must not fall below
+	MinCoverage *float64       `yaml:"min_coverage,omitempty"`
+	Ratchet     *RatchetConfig `yaml:"ratchet,omitempty"`
+	// IncludeTestHelpers counts test helper packages (testutil, fixtures, ...) only imported by tests
+	IncludeTestHelpers bool `yaml:"include_test_helpers,omitempty"`
+	// OwnersOverride replaces the CODEOWNERS owners on the dashboard, e.g. for entries of the single repos file
+	OwnersOverride []string `yaml:"owners_override,omitempty"`
+	Owners         []string `yaml:"-"` // Not serialized, used for CODEOWNERS
+	// OwnersSource records how discovery determined the owners, one of the OwnersSource values
+	OwnersSource string `yaml:"owners_source,omitempty"`
+	// OwnersDetectedAt is when discovery determined the owners
+	OwnersDetectedAt *time.Time `yaml:"owners_detected_at,omitempty"`
+	// Language of the repository's code, one of the Language values; empty for Go
+	Language string `yaml:"language,omitempty"`
+	// MaxAge is how old the repository's coverage may get before the dashboard marks it stale, e.g. "72h"
+	MaxAge string `yaml:"max_age,omitempty"`
+	// Goals are the coverage milestones of the repository, e.g. "60% by 2025-06-30"
+	Goals Milestones `yaml:"goal,omitempty"`
+	// NoTests records that discovery found no test files in the repository, so it has no coverage to show yet
+	NoTests bool `yaml:"no_tests,omitempty"`
+	// Modules lists the Go modules of a repository with several, whose coverage is collected one by one
+	Modules []ModuleConfig `yaml:"modules,omitempty"`
+	// Fork records that the repository is a fork, so its owners can decide whether it belongs on the dashboard
+	Fork bool `yaml:"fork,omitempty"`
+	// Visibility of the repository on GitHub, one of the Visibility values; empty counts as public
+	Visibility string `yaml:"visibility,omitempty"`
+	// NeedsDocker marks repositories whose tests start containers, e.g. with testcontainers, run against the Docker
+	// socket of the collector when it has one
+	NeedsDocker bool `yaml:"needs_docker,omitempty"`
+}
+
+// Languages of the repositories discovery adds; coverage is only collected for Go so far
+const (
+	LanguageGo         = "go"
+	LanguagePython     = "python"
+	LanguageTypeScript = "typescript"
+)
+
+// Visibilities of repositories on GitHub; only the detailed reports of public repositories are published
+const (
+	VisibilityPublic   = "public"
+	VisibilityPrivate  = "private"
+	VisibilityInternal = "internal"
+)
+
+// IsPublic reports whether the repository's source, and so its detailed reports, may be published
+func (c RepositoryConfig) IsPublic() bool {
+	return c.Visibility == "" || c.Visibility == VisibilityPublic
+}
+
+// IsGo reports whether coverage of the repository is collected as Go code
+func (c RepositoryConfig) IsGo() bool {
+	return c.Language == "" || c.Language == LanguageGo
 }
 
+// Sources discovery determines owners from, from the most to the least trustworthy
+const (
+	OwnersSourceCodeowners    = "codeowners"
+	OwnersSourceTeams         = "teams"
+	OwnersSourceCollaborators = "collaborators"
+	OwnersSourceDefault       = "default"
+)
+
+// RatchetConfig raises a repository's threshold with its coverage, to (max observed coverage - tolerance)
+type RatchetConfig struct {
+	Tolerance float64 `yaml:"tolerance"`
+	// Reset lowers the ratchet once to an explicit value, e.g. after removing tested code
+	Reset *RatchetReset `yaml:"reset,omitempty"`
+}
+
+// RatchetReset sets the ratchet to a value, applied once per value and reason
+type RatchetReset struct {
+	Value  float64 `yaml:"value"`
+	Reason string  `yaml:"reason"`
+}
+
+// Validate checks the thresholds, max age, language, visibility, goals and modules of a configuration
+func (c RepositoryConfig) Validate() error {
+	if c.MinCoverage != nil && (*c.MinCoverage < 0 || *c.MinCoverage > 100) {
+		return fmt.Errorf("min_coverage must be between 0 and 100, got %v", *c.MinCoverage)
+	}
+	if c.RegressionDelta != nil && *c.RegressionDelta < 0 {
+		return fmt.Errorf("regression_delta must not be negative, got %v", *c.RegressionDelta)
+	}
+	if c.MaxAge != "" {
+		if maxAge, err := time.ParseDuration(c.MaxAge); err != nil || maxAge <= 0 {
+			return fmt.Errorf("max_age must be a positive duration such as 72h, got %q", c.MaxAge)
+		}
+	}
+	switch c.Language {
+	case "", LanguageGo, LanguagePython, LanguageTypeScript:
+	default:
+		return fmt.Errorf("language must be one of %s, %s or %s, got %q", LanguageGo, LanguagePython, LanguageTypeScript, c.Language)
+	}
+	switch c.Visibility {
+	case "", VisibilityPublic, VisibilityPrivate, VisibilityInternal:
+	default:
+		return fmt.Errorf("visibility must be one of %s, %s or %s, got %q", VisibilityPublic, VisibilityPrivate, VisibilityInternal, c.Visibility)
+	}
+	for _, goal := range c.Goals {
+		if goal.Coverage <= 0 || goal.Coverage > 100 {
+			return fmt.Errorf("goal coverage must be above 0 and at most 100, got %q", goal)
+		}
+	}
+	if err := validateModules(c.Modules); err != nil {
+		return err
+	}
+	if c.Ratchet == nil {
+		return nil
+	}
+	if c.Ratchet.Tolerance < 0 {
+		return fmt.Errorf("ratchet tolerance must not be negative, got %v", c.Ratchet.Tolerance)
+	}
+	if reset := c.Ratchet.Reset; reset != nil {
+		if reset.Value < 0 || reset.Value > 100 {
+			return fmt.Errorf("ratchet reset value must be between 0 and 100, got %v", reset.Value)
+		}
+		if strings.TrimSpace(reset.Reason) == "" {
+			return fmt.Errorf("ratchet reset needs a reason")
+		}
+	}
+	return nil
+}
+
+const (
+	// DiscoveredReposDir is the directory, next to the repos directory, dry runs write configurations to
+	DiscoveredReposDir = "discovered-repos"
+	// DiscoveredIndexFile summarizes the configurations of the last dry run in DiscoveredReposDir
+	DiscoveredIndexFile = "index.md"
+	// DiscoveryReportFile reports the last dry run in DiscoveredReposDir as Markdown for tracking issues and
+	// pull request comments, without links to the configurations
+	DiscoveryReportFile = "discovery-report.md"
+)
+
 // Writer writes repository configurations to disk
 type Writer struct {
 	reposDir       string
@@ -44,21 +190,24 @@ func (w *Writer) Write(cfg RepositoryConfig, dryRun bool) error {
 	if strings.TrimSpace(cfg.Name) == "" {
 		return fmt.Errorf("repository name cannot be empty")
 	}
-	// Validate using allowlist: must be in org/repo format with allowed characters
-	if !repoNamePattern.MatchString(cfg.Name) {
-		return fmt.Errorf("invalid repository name: %q (must be in org/repo format with only alphanumerics, underscores, and hyphens)", cfg.Name)
+	// Generate filename from repository name, validated against an allowlist of characters
+	filename, err := ConfigFilename(cfg.Name)
+	if err != nil {
+		return err
 	}
 
-	// Generate filename from repository name
-	filename := w.getFilename(cfg.Name)
-
-	var targetPath string
+	// Dry runs write files destined for the repos directory, which must not clash with them either
+	targetDir := w.reposDir
 	if dryRun {
-		dryRunDir := filepath.Join(filepath.Dir(w.reposDir), "discovered-repos")
-		targetPath = filepath.Join(dryRunDir, filename)
-	} else {
-		targetPath = filepath.Join(w.reposDir, filename)
+		targetDir = w.DiscoveredDir()
+		if err := checkFileConflict(w.reposDir, filename, cfg.Name); err != nil {
+			return err
+		}
 	}
+	if err := checkFileConflict(targetDir, filename, cfg.Name); err != nil {
+		return err
+	}
+	targetPath := filepath.Join(targetDir, filename)
 
 	data, err := yaml.Marshal(cfg)
 	if err != nil {
@@ -85,11 +234,47 @@ func (w *Writer) Write(cfg RepositoryConfig, dryRun bool) error {
 	return nil
 }
 
-// getFilename generates a filename from repository name
-func (w *Writer) getFilename(repoName string) string {
-	// Extract repo name from "org/repo" format
-	parts := strings.Split(repoName, "/")
-	return parts[1] + ".yaml"
+// DiscoveredDir returns the directory dry runs write configurations to
+func (w *Writer) DiscoveredDir() string {
+	return filepath.Join(filepath.Dir(w.reposDir), DiscoveredReposDir)
+}
+
+// DiscoveredFiles lists the configuration files in DiscoveredDir, sorted by name
+func (w *Writer) DiscoveredFiles() ([]string, error) {
+	entries, err := os.ReadDir(w.DiscoveredDir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", w.DiscoveredDir(), err)
+	}
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".yaml") {
+			files = append(files, entry.Name())
+		}
+	}
+	return files, nil
+}
+
+// ClearDiscovered removes the configurations, index and report earlier dry runs left in DiscoveredDir,
+// keeping any other file. Returns the number of configurations removed
+func (w *Writer) ClearDiscovered() (int, error) {
+	files, err := w.DiscoveredFiles()
+	if err != nil {
+		return 0, err
+	}
+	for _, file := range files {
+		if err := os.Remove(filepath.Join(w.DiscoveredDir(), file)); err != nil {
+			return 0, fmt.Errorf("failed to remove %s: %w", file, err)
+		}
+	}
+	for _, file := range []string{DiscoveredIndexFile, DiscoveryReportFile} {
+		if err := os.Remove(filepath.Join(w.DiscoveredDir(), file)); err != nil && !os.IsNotExist(err) {
+			return 0, fmt.Errorf("failed to remove %s: %w", file, err)
+		}
+	}
+	return len(files), nil
 }
 
 // updateCodeowners updates or adds an entry in the CODEOWNERS file
@@ -105,18 +290,18 @@ func (w *Writer) updateCodeowners(filename string, owners []string) error {
 	}
 
 	// Read existing CODEOWNERS file
-	var lines []string
-	data, err := os.ReadFile(w.codeownersFile)
-	if err != nil && !os.IsNotExist(err) {
+	lines, err := w.readCodeowners()
+	if err != nil {
 		return err
 	}
-	if err == nil {
-		lines = strings.Split(string(data), "\n")
-	}
 
 	// Pattern for matching this repository's entry
-	pattern := fmt.Sprintf("/repos/%s", filename)
-	newEntry := fmt.Sprintf("/repos/%s %s", filename, strings.Join(normalizedOwners, " "))
+	return w.setCodeownersEntry(lines, CodeownersPattern(filename), normalizedOwners)
+}
+
+// setCodeownersEntry replaces the owners of pattern in the CODEOWNERS lines, appending an entry if missing
+func (w *Writer) setCodeownersEntry(lines []string, pattern string, normalizedOwners []string) error {
+	newEntry := fmt.Sprintf("%s %s", pattern, strings.Join(normalizedOwners, " "))
 	found := false
 
 	// Look for existing entry and update it
@@ -128,8 +313,12 @@ func (w *Writer) updateCodeowners(filename string, owners []string) error {
 		}
 	}
 
-	// If not found, append new entry
+	// If not found, insert it before the first repository entry sorting after it, or append it
 	if !found {
+		if i := insertionIndex(lines, pattern); i >= 0 {
+			lines = append(lines[:i], append([]string{newEntry}, lines[i:]...)...)
+			return w.writeCodeowners(lines)
+		}
 		// Ensure there's a blank line before adding if file exists and isn't empty
 		if len(lines) > 0 && lines[len(lines)-1] != "" {
 			lines = append(lines, "")
@@ -140,6 +329,48 @@ func (w *Writer) updateCodeowners(filename string, owners []string) error {
 	return w.writeCodeowners(lines)
 }
 
+// insertionIndex returns the line of the first /repos/ entry sorting after pattern, or -1
+// Entries written in order keep CODEOWNERS sorted regardless of the order repositories were discovered in
+func insertionIndex(lines []string, pattern string) int {
+	for i, line := range lines {
+		fields := strings.Fields(strings.SplitN(line, "#", 2)[0])
+		if len(fields) > 0 && strings.HasPrefix(fields[0], "/repos/") && fields[0] > pattern {
+			return i
+		}
+	}
+	return -1
+}
+
+// CodeownersPattern returns the CODEOWNERS path pattern for a repository configuration file
+func CodeownersPattern(filename string) string {
+	return fmt.Sprintf("/repos/%s", filename)
+}
+
+// LoadCodeowners reads a CODEOWNERS file and returns the owners listed for each pattern
+// When a pattern appears more than once the last entry wins, matching GitHub's precedence rules
+func LoadCodeowners(path string) (map[string][]string, error) {
+	data, err := readLimited(path, MaxCodeownersSize)
+	if err != nil {
+		return nil, err
+	}
+	return parseCodeowners(data), nil
+}
+
+// parseCodeowners returns the owners listed for each pattern of CODEOWNERS content
+func parseCodeowners(data []byte) map[string][]string {
+	entries := make(map[string][]string)
+	for _, line := range strings.Split(string(data), "\n") {
+		// Strip inline comments and skip blank lines
+		fields := strings.Fields(strings.SplitN(line, "#", 2)[0])
+		if len(fields) == 0 {
+			continue
+		}
+		entries[fields[0]] = fields[1:]
+	}
+
+	return entries
+}
+
 // matchesPattern checks if a line matches the given CODEOWNERS pattern
 func matchesPattern(line, pattern string) bool {
 	// Strip inline comments and surrounding spaces
@@ -148,7 +379,7 @@ func matchesPattern(line, pattern string) bool {
 	return trimmed == pattern || strings.HasPrefix(trimmed, pattern+" ")
 }
 
-// normalizeOwners normalizes a list of owners: trim whitespace, ensure @ prefix, deduplicate
+// normalizeOwners normalizes a list of owners: trim whitespace, ensure @ prefix, deduplicate, sort
 func normalizeOwners(owners []string) []string {
 	seen := make(map[string]bool)
 	var result []string
@@ -167,6 +398,7 @@ func normalizeOwners(owners []string) []string {
 			result = append(result, owner)
 		}
 	}
+	sort.Strings(result)
 	return result
 }
 
@@ -183,13 +415,16 @@ func (w *Writer) writeCodeowners(lines []string) error {
 func LoadRepositoryConfig(reposDir, filename string) (RepositoryConfig, error) {
 	path := filepath.Join(reposDir, filename)
 
-	data, err := os.ReadFile(path)
+	data, err := readLimited(path, MaxConfigSize)
 	if err != nil {
 		return RepositoryConfig{}, err
 	}
 
 	var cfg RepositoryConfig
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
+	if err := unmarshalYAML(data, &cfg); err != nil {
+		return RepositoryConfig{}, err
+	}
+	if err := cfg.Validate(); err != nil {
 		return RepositoryConfig{}, err
 	}
 
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -60,6 +61,19 @@ exclude_files:
 			Expect(cfg.ExcludeDirs).To(HaveLen(3))
 			Expect(cfg.ExcludeFiles).To(HaveLen(2))
 		})
+
+		It("should validate the visibility and count unset as public", func() {
+			var cfg config.RepositoryConfig
+			Expect(yaml.Unmarshal([]byte("name: org/a\n"), &cfg)).To(Succeed())
+			Expect(cfg.IsPublic()).To(BeTrue())
+
+			Expect(yaml.Unmarshal([]byte("name: org/a\nvisibility: internal\n"), &cfg)).To(Succeed())
+			Expect(cfg.Validate()).To(Succeed())
+			Expect(cfg.IsPublic()).To(BeFalse())
+
+			cfg.Visibility = "secret"
+			Expect(cfg.Validate()).To(MatchError(ContainSubstring(`visibility must be one of public, private or internal, got "secret"`)))
+		})
 	})
 
 	Describe("Writer", func() {
@@ -133,6 +147,30 @@ exclude_files:
 				Expect(configPath).NotTo(BeAnExistingFile())
 			})
 
+			It("should clear the configurations and index of earlier dry runs only", func() {
+				Expect(writer.Write(config.RepositoryConfig{Name: "konflux-ci/test-repo", Owners: []string{"@konflux-ci/test-team"}}, true)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(writer.DiscoveredDir(), config.DiscoveredIndexFile), []byte("# Index\n"), 0644)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(writer.DiscoveredDir(), "NOTES.txt"), []byte("notes\n"), 0644)).To(Succeed())
+
+				files, err := writer.DiscoveredFiles()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(files).To(Equal([]string{"test-repo.yaml"}))
+
+				cleared, err := writer.ClearDiscovered()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(cleared).To(Equal(1))
+				entries, err := os.ReadDir(writer.DiscoveredDir())
+				Expect(err).NotTo(HaveOccurred())
+				Expect(entries).To(HaveLen(1))
+				Expect(entries[0].Name()).To(Equal("NOTES.txt"))
+			})
+
+			It("should clear nothing before the first dry run", func() {
+				cleared, err := writer.ClearDiscovered()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(cleared).To(BeZero())
+			})
+
 			It("should fail when no owners are specified", func() {
 				cfg := config.RepositoryConfig{
 					Name: "org/repo",
@@ -178,11 +216,25 @@ exclude_files:
 				}
 				Expect(entryCount).To(Equal(1))
 			})
+
+			It("should insert new entries in order regardless of the write order", func() {
+				initial := "* @konflux-ci/Vanguard\n\n/repos/alpha.yaml @team-a\n/repos/delta.yaml @team-d\n"
+				Expect(os.WriteFile(codeownersFile, []byte(initial), 0644)).To(Succeed())
+
+				Expect(writer.Write(config.RepositoryConfig{Name: "konflux-ci/charlie", Owners: []string{"@team-c"}}, false)).To(Succeed())
+				Expect(writer.Write(config.RepositoryConfig{Name: "konflux-ci/echo", Owners: []string{"@team-e"}}, false)).To(Succeed())
+				Expect(writer.Write(config.RepositoryConfig{Name: "konflux-ci/bravo", Owners: []string{"@team-b"}}, false)).To(Succeed())
+
+				content, err := os.ReadFile(codeownersFile)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(content)).To(Equal("* @konflux-ci/Vanguard\n\n/repos/alpha.yaml @team-a\n/repos/bravo.yaml @team-b\n" +
+					"/repos/charlie.yaml @team-c\n/repos/delta.yaml @team-d\n\n/repos/echo.yaml @team-e\n"))
+			})
 		})
 
 		Describe("Input validation", func() {
 			It("should accept valid repository names in org/repo format", func() {
-				validNames := []string{"konflux-ci/caching", "my-org/my-repo", "my_org/my_repo"}
+				validNames := []string{"konflux-ci/caching", "my-org/my-repo", "my_org/my_repo", "konflux-ci/docs.konflux-ci.dev"}
 				for _, name := range validNames {
 					cfg := config.RepositoryConfig{Name: name, Owners: []string{"@test-team"}}
 					err := writer.Write(cfg, false)
@@ -191,13 +243,51 @@ exclude_files:
 			})
 
 			It("should reject invalid repository names", func() {
-				invalidNames := []string{"../etc/passwd", "org\\repo", "org/repo/extra", "org.name/repo", "", "no-slash"}
+				invalidNames := []string{"../etc/passwd", "org\\repo", "org/repo/extra", "org.name/repo", "", "no-slash",
+					"org/..", "org/.hidden", "org/" + strings.Repeat("a", 101)}
 				for _, name := range invalidNames {
 					cfg := config.RepositoryConfig{Name: name, Owners: []string{"@test-team"}}
 					err := writer.Write(cfg, false)
 					Expect(err).To(HaveOccurred(), "should reject invalid name %q", name)
 				}
 			})
+
+			It("should refuse files differing only by case from existing ones", func() {
+				Expect(writer.Write(config.RepositoryConfig{Name: "konflux-ci/Caching", Owners: []string{"@test-team"}}, false)).To(Succeed())
+
+				err := writer.Write(config.RepositoryConfig{Name: "other-org/caching", Owners: []string{"@test-team"}}, false)
+				Expect(err).To(MatchError(ContainSubstring("caching.yaml conflicts with " + filepath.Join(reposDir, "Caching.yaml") + " on case-insensitive filesystems")))
+				Expect(filepath.Join(reposDir, "caching.yaml")).NotTo(BeAnExistingFile())
+
+				// Dry runs check the repos directory their files are destined for too
+				err = writer.Write(config.RepositoryConfig{Name: "other-org/caching", Owners: []string{"@test-team"}}, true)
+				Expect(err).To(MatchError(ContainSubstring("on case-insensitive filesystems")))
+			})
+
+			It("should refuse to overwrite the file of another repository", func() {
+				Expect(writer.Write(config.RepositoryConfig{Name: "konflux-ci/caching", Owners: []string{"@test-team"}}, false)).To(Succeed())
+				Expect(writer.Write(config.RepositoryConfig{Name: "konflux-ci/caching", Owners: []string{"@other-team"}}, false)).To(Succeed())
+
+				err := writer.Write(config.RepositoryConfig{Name: "other-org/caching", Owners: []string{"@test-team"}}, false)
+				Expect(err).To(MatchError(ContainSubstring("already configures konflux-ci/caching, cannot write other-org/caching to it")))
+			})
+		})
+
+		Describe("LoadCodeowners", func() {
+			It("should map patterns to owners with the last entry winning", func() {
+				content := "# Comment\n/.github/ @konflux-ci/Vanguard\n\n/repos/a.yaml @team1 @user1 # inline\n/repos/a.yaml @team2\n"
+				Expect(os.WriteFile(codeownersFile, []byte(content), 0644)).To(Succeed())
+
+				entries, err := config.LoadCodeowners(codeownersFile)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(entries).To(HaveKeyWithValue("/.github/", []string{"@konflux-ci/Vanguard"}))
+				Expect(entries).To(HaveKeyWithValue(config.CodeownersPattern("a.yaml"), []string{"@team2"}))
+			})
+
+			It("should fail when the file does not exist", func() {
+				_, err := config.LoadCodeowners(codeownersFile)
+				Expect(err).To(HaveOccurred())
+			})
 		})
 
 		Describe("Owner normalization", func() {
@@ -216,6 +306,271 @@ exclude_files:
 				// Should normalize to: @team1 @team2 (deduplicated, @ prefix added)
 				Expect(string(content)).To(ContainSubstring("@team1 @team2"))
 			})
+
+			It("should sort owners", func() {
+				cfg := config.RepositoryConfig{Name: "konflux-ci/test-repo", Owners: []string{"@zeta", "@alpha"}}
+				Expect(writer.Write(cfg, false)).To(Succeed())
+
+				content, err := os.ReadFile(codeownersFile)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(content)).To(ContainSubstring("/repos/test-repo.yaml @alpha @zeta"))
+			})
+		})
+
+		Describe("TransferOwnership", func() {
+			var reposFile string
+
+			BeforeEach(func() {
+				reposFile = filepath.Join(tempDir, "repos.yaml")
+				Expect(writer.Write(config.RepositoryConfig{Name: "konflux-ci/alpha", ExcludeDirs: []string{"vendor/"}, Owners: []string{"@konflux-ci/old-team"}}, false)).To(Succeed())
+				Expect(config.WriteReposFile(reposFile, []config.RepositoryConfig{{Name: "konflux-ci/bravo"}, {Name: "konflux-ci/charlie"}})).To(Succeed())
+				Expect(os.WriteFile(codeownersFile, []byte("/repos/alpha.yaml @konflux-ci/old-team\n/repos.yaml @konflux-ci/vanguard\n"), 0644)).To(Succeed())
+			})
+
+			It("should update the owners_override and CODEOWNERS entry of a per-repo file", func() {
+				transfer, err := writer.TransferOwnership(reposFile, "konflux-ci/alpha", []string{"konflux-ci/new-team"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(transfer.From).To(Equal([]string{"@konflux-ci/old-team"}))
+				Expect(transfer.To).To(Equal([]string{"@konflux-ci/new-team"}))
+				Expect(transfer.Files).To(Equal([]string{filepath.Join(reposDir, "alpha.yaml"), codeownersFile}))
+
+				cfg, err := config.LoadRepositoryConfig(reposDir, "alpha.yaml")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(cfg.OwnersOverride).To(Equal([]string{"@konflux-ci/new-team"}))
+				Expect(cfg.ExcludeDirs).To(Equal([]string{"vendor/"}))
+
+				entries, err := config.LoadCodeowners(codeownersFile)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(entries).To(HaveKeyWithValue("/repos/alpha.yaml", []string{"@konflux-ci/new-team"}))
+				Expect(entries).To(HaveKeyWithValue("/repos.yaml", []string{"@konflux-ci/vanguard"}))
+			})
+
+			It("should only update the owners_override of a repos file entry", func() {
+				transfer, err := writer.TransferOwnership(reposFile, "konflux-ci/bravo", []string{"@konflux-ci/new-team"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(transfer.From).To(Equal([]string{"@konflux-ci/vanguard"}))
+				Expect(transfer.Files).To(Equal([]string{reposFile}))
+
+				configs, err := config.LoadReposFile(reposFile)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(configs).To(HaveLen(2))
+				Expect(configs[0].OwnersOverride).To(Equal([]string{"@konflux-ci/new-team"}))
+				Expect(configs[1].OwnersOverride).To(BeEmpty())
+
+				entries, err := config.LoadCodeowners(codeownersFile)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(entries).To(HaveKeyWithValue("/repos.yaml", []string{"@konflux-ci/vanguard"}))
+			})
+
+			It("should reject unknown repositories and transfers to the current owners", func() {
+				_, err := writer.TransferOwnership(reposFile, "konflux-ci/delta", []string{"@konflux-ci/new-team"})
+				Expect(err).To(MatchError(ContainSubstring("not configured")))
+				_, err = writer.TransferOwnership(reposFile, "konflux-ci/alpha", []string{"@konflux-ci/old-team"})
+				Expect(err).To(MatchError(ContainSubstring("already owned")))
+			})
+		})
+
+		Describe("RemoveRepository", func() {
+			var reposFile string
+
+			BeforeEach(func() {
+				reposFile = filepath.Join(tempDir, "repos.yaml")
+				Expect(writer.Write(config.RepositoryConfig{Name: "konflux-ci/alpha", Owners: []string{"@konflux-ci/alpha-team"}}, false)).To(Succeed())
+				Expect(writer.Write(config.RepositoryConfig{Name: "konflux-ci/zulu", Owners: []string{"@konflux-ci/zulu-team"}}, false)).To(Succeed())
+				Expect(config.WriteReposFile(reposFile, []config.RepositoryConfig{{Name: "konflux-ci/bravo"}, {Name: "konflux-ci/charlie"}})).To(Succeed())
+				Expect(os.WriteFile(codeownersFile, []byte("# Repositories\n/repos/alpha.yaml @konflux-ci/alpha-team\n/repos/zulu.yaml @konflux-ci/zulu-team\n/repos.yaml @konflux-ci/vanguard\n"), 0644)).To(Succeed())
+			})
+
+			It("should delete a per-repo file and its CODEOWNERS entry", func() {
+				removal, err := writer.RemoveRepository(reposFile, "konflux-ci/alpha")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(removal.Owners).To(Equal([]string{"@konflux-ci/alpha-team"}))
+				Expect(removal.Files).To(Equal([]string{filepath.Join(reposDir, "alpha.yaml"), codeownersFile}))
+				Expect(filepath.Join(reposDir, "alpha.yaml")).NotTo(BeAnExistingFile())
+
+				data, err := os.ReadFile(codeownersFile)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(data)).To(Equal("# Repositories\n/repos/zulu.yaml @konflux-ci/zulu-team\n/repos.yaml @konflux-ci/vanguard\n"))
+			})
+
+			It("should drop an entry of the repos file and keep its CODEOWNERS entry", func() {
+				removal, err := writer.RemoveRepository(reposFile, "konflux-ci/bravo")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(removal.Owners).To(Equal([]string{"@konflux-ci/vanguard"}))
+				Expect(removal.Files).To(Equal([]string{reposFile}))
+
+				configs, err := config.LoadReposFile(reposFile)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(configs).To(HaveLen(1))
+				Expect(configs[0].Name).To(Equal("konflux-ci/charlie"))
+				entries, err := config.LoadCodeowners(codeownersFile)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(entries).To(HaveKey("/repos.yaml"))
+			})
+
+			It("should reject unknown repositories", func() {
+				_, err := writer.RemoveRepository(reposFile, "konflux-ci/delta")
+				Expect(err).To(MatchError(ContainSubstring("not configured")))
+			})
+		})
+
+		Describe("Offboard", func() {
+			var offboardedFile string
+			at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+			BeforeEach(func() {
+				offboardedFile = filepath.Join(tempDir, config.DefaultOffboardedFile)
+				Expect(writer.Write(config.RepositoryConfig{Name: "konflux-ci/alpha", Owners: []string{"@konflux-ci/alpha-team"}}, false)).To(Succeed())
+				Expect(writer.Write(config.RepositoryConfig{Name: "konflux-ci/zulu", Owners: []string{"@konflux-ci/zulu-team"}}, false)).To(Succeed())
+			})
+
+			It("should remove the repository and schedule the deletion of its data", func() {
+				retention, err := config.ParseRetention("90d")
+				Expect(err).NotTo(HaveOccurred())
+				offboarding, err := writer.Offboard("", offboardedFile, "konflux-ci/alpha", at, retention)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(offboarding.Owners).To(Equal([]string{"@konflux-ci/alpha-team"}))
+				Expect(offboarding.Files).To(ContainElements(filepath.Join(reposDir, "alpha.yaml"), offboardedFile))
+				Expect(*offboarding.DeleteAfter).To(Equal(at.AddDate(0, 0, 90)))
+				Expect(filepath.Join(reposDir, "alpha.yaml")).NotTo(BeAnExistingFile())
+
+				forever, err := config.ParseRetention(config.RetainForever)
+				Expect(err).NotTo(HaveOccurred())
+				offboarding, err = writer.Offboard("", offboardedFile, "konflux-ci/zulu", at, forever)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(offboarding.DeleteAfter).To(BeNil())
+
+				offboarded, err := config.LoadOffboarded(offboardedFile)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(offboarded).To(HaveLen(2))
+				Expect(offboarded[0].Name).To(Equal("konflux-ci/alpha"))
+				Expect(offboarded.Due(at.AddDate(0, 0, 89))).To(BeEmpty())
+				due := offboarded.Due(at.AddDate(0, 0, 90))
+				Expect(due).To(HaveLen(1))
+				Expect(due[0].Name).To(Equal("konflux-ci/alpha"))
+			})
+
+			It("should reject unknown repositories without recording them", func() {
+				_, err := writer.Offboard("", offboardedFile, "konflux-ci/delta", at, 0)
+				Expect(err).To(MatchError(ContainSubstring("not configured")))
+				Expect(offboardedFile).NotTo(BeAnExistingFile())
+			})
+
+			It("should parse retentions", func() {
+				for retention, expected := range map[string]time.Duration{"0": 0, "13w": 13 * 7 * 24 * time.Hour, "36h": 36 * time.Hour} {
+					Expect(config.ParseRetention(retention)).To(Equal(expected))
+				}
+				for _, invalid := range []string{"", "d", "-1d", "soon"} {
+					_, err := config.ParseRetention(invalid)
+					Expect(err).To(MatchError(ContainSubstring("invalid retention")))
+				}
+			})
+		})
+
+		Describe("RenameRepository", func() {
+			var reposFile string
+
+			BeforeEach(func() {
+				reposFile = filepath.Join(tempDir, "repos.yaml")
+				minCoverage := 60.0
+				Expect(writer.Write(config.RepositoryConfig{Name: "konflux-ci/alpha", MinCoverage: &minCoverage, Owners: []string{"@konflux-ci/alpha-team"}}, false)).To(Succeed())
+				Expect(writer.Write(config.RepositoryConfig{Name: "konflux-ci/zulu", Owners: []string{"@konflux-ci/zulu-team"}}, false)).To(Succeed())
+				Expect(config.WriteReposFile(reposFile, []config.RepositoryConfig{{Name: "konflux-ci/bravo"}, {Name: "konflux-ci/charlie"}})).To(Succeed())
+				Expect(os.WriteFile(codeownersFile, []byte("/repos/alpha.yaml @konflux-ci/alpha-team\n/repos/zulu.yaml @konflux-ci/zulu-team\n/repos.yaml @konflux-ci/vanguard\n"), 0644)).To(Succeed())
+			})
+
+			It("should move a per-repo file and its CODEOWNERS entry to the new name", func() {
+				rename, err := writer.RenameRepository(reposFile, "konflux-ci/alpha", "konflux-ci/omega")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(rename.Owners).To(Equal([]string{"@konflux-ci/alpha-team"}))
+				Expect(rename.Files).To(Equal([]string{filepath.Join(reposDir, "alpha.yaml"), filepath.Join(reposDir, "omega.yaml"), codeownersFile}))
+				Expect(filepath.Join(reposDir, "alpha.yaml")).NotTo(BeAnExistingFile())
+
+				cfg, err := config.LoadRepositoryConfig(reposDir, "omega.yaml")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(cfg.Name).To(Equal("konflux-ci/omega"))
+				Expect(*cfg.MinCoverage).To(Equal(60.0))
+				data, err := os.ReadFile(codeownersFile)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(data)).To(Equal("/repos/omega.yaml @konflux-ci/alpha-team\n/repos/zulu.yaml @konflux-ci/zulu-team\n/repos.yaml @konflux-ci/vanguard\n"))
+			})
+
+			It("should rename an entry of the repos file in place", func() {
+				rename, err := writer.RenameRepository(reposFile, "konflux-ci/bravo", "konflux-ci/beta")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(rename.Files).To(Equal([]string{reposFile}))
+				configs, err := config.LoadReposFile(reposFile)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(configs[0].Name).To(Equal("konflux-ci/beta"))
+			})
+
+			It("should reject unknown repositories and names already configured", func() {
+				_, err := writer.RenameRepository(reposFile, "konflux-ci/delta", "konflux-ci/echo")
+				Expect(err).To(MatchError(ContainSubstring("not configured")))
+				_, err = writer.RenameRepository(reposFile, "konflux-ci/alpha", "konflux-ci/charlie")
+				Expect(err).To(MatchError(ContainSubstring("konflux-ci/charlie is already configured")))
+			})
+		})
+
+		Describe("EditRepository", func() {
+			var reposFile string
+
+			BeforeEach(func() {
+				reposFile = filepath.Join(tempDir, "repos.yaml")
+				minCoverage := 60.0
+				Expect(writer.Write(config.RepositoryConfig{Name: "konflux-ci/alpha", ExcludeDirs: []string{"vendor/", "hack/"}, MinCoverage: &minCoverage, Owners: []string{"@konflux-ci/alpha-team"}}, false)).To(Succeed())
+				Expect(config.WriteReposFile(reposFile, []config.RepositoryConfig{{Name: "konflux-ci/bravo"}, {Name: "konflux-ci/charlie"}})).To(Succeed())
+				Expect(os.WriteFile(codeownersFile, []byte("/repos/alpha.yaml @konflux-ci/alpha-team\n/repos.yaml @konflux-ci/vanguard\n"), 0644)).To(Succeed())
+			})
+
+			It("should change the thresholds and excludes of a per-repo file", func() {
+				minCoverage := 72.5
+				result, err := writer.EditRepository(reposFile, "konflux-ci/alpha", config.Edit{
+					MinCoverage:       &minCoverage,
+					AddExcludeDirs:    []string{"test/e2e/", "vendor/"},
+					RemoveExcludeDirs: []string{"hack/"},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Owners).To(Equal([]string{"@konflux-ci/alpha-team"}))
+				Expect(result.Changes).To(Equal([]string{"min_coverage: 60 → 72.5", "exclude_dirs: removed hack/", "exclude_dirs: added test/e2e/"}))
+				Expect(result.Files).To(Equal([]string{filepath.Join(reposDir, "alpha.yaml")}))
+
+				cfg, err := config.LoadRepositoryConfig(reposDir, "alpha.yaml")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(*cfg.MinCoverage).To(Equal(72.5))
+				Expect(cfg.ExcludeDirs).To(Equal([]string{"vendor/", "test/e2e/"}))
+			})
+
+			It("should change an entry of the repos file alone", func() {
+				delta := 2.0
+				result, err := writer.EditRepository(reposFile, "konflux-ci/charlie", config.Edit{RegressionDelta: &delta, AddExcludeFiles: []string{"zz_generated.go"}})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Owners).To(Equal([]string{"@konflux-ci/vanguard"}))
+				Expect(result.Files).To(Equal([]string{reposFile}))
+
+				configs, err := config.LoadReposFile(reposFile)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(configs[0].RegressionDelta).To(BeNil())
+				Expect(*configs[1].RegressionDelta).To(Equal(2.0))
+				Expect(configs[1].ExcludeFiles).To(Equal([]string{"zz_generated.go"}))
+			})
+
+			It("should refuse edits of unknown repositories, changing nothing or leaving invalid configurations", func() {
+				_, err := writer.EditRepository(reposFile, "konflux-ci/delta", config.Edit{ClearMinCoverage: true})
+				Expect(err).To(MatchError(config.ErrInvalidEdit))
+				Expect(err).To(MatchError(ContainSubstring("not configured")))
+
+				_, err = writer.EditRepository(reposFile, "konflux-ci/bravo", config.Edit{ClearMinCoverage: true, RemoveExcludeDirs: []string{"hack/"}})
+				Expect(err).To(MatchError(ContainSubstring("does not change the configuration of konflux-ci/bravo")))
+
+				minCoverage := 170.0
+				_, err = writer.EditRepository(reposFile, "konflux-ci/alpha", config.Edit{MinCoverage: &minCoverage})
+				Expect(err).To(MatchError(config.ErrInvalidEdit))
+				Expect(err).To(MatchError(ContainSubstring("min_coverage must be between 0 and 100")))
+				cfg, err := config.LoadRepositoryConfig(reposDir, "alpha.yaml")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(*cfg.MinCoverage).To(Equal(60.0))
+			})
 		})
 	})
 })
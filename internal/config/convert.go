@@ -0,0 +1,167 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// MergeIntoReposFile moves every per-repo configuration into the single repos file
+// Their CODEOWNERS entries are replaced by one entry for the repos file owned by all of their owners
+func (w *Writer) MergeIntoReposFile(reposFile string) (int, error) {
+	set, err := LoadRepositories(w.reposDir, reposFile)
+	if err != nil {
+		return 0, err
+	}
+	if len(set.Invalid) > 0 {
+		return 0, fmt.Errorf("cannot convert with invalid configurations: %s", strings.Join(set.InvalidFiles(), ", "))
+	}
+
+	entries, err := LoadCodeowners(w.codeownersFile)
+	if err != nil && !os.IsNotExist(err) {
+		return 0, err
+	}
+
+	var configs []RepositoryConfig
+	var owners []string
+	merged := make(map[string]bool)
+	for _, entry := range set.Entries {
+		configs = append(configs, entry.Config)
+		owners = append(owners, entries[entry.CodeownersPattern()]...)
+		if entry.File != "" {
+			merged[entry.CodeownersPattern()] = true
+		}
+	}
+	if len(merged) == 0 {
+		return 0, nil
+	}
+
+	if err := WriteReposFile(reposFile, configs); err != nil {
+		return 0, err
+	}
+
+	lines, err := w.readCodeowners()
+	if err != nil {
+		return 0, err
+	}
+	var kept []string
+	for _, line := range lines {
+		fields := strings.Fields(strings.SplitN(line, "#", 2)[0])
+		if len(fields) > 0 && merged[fields[0]] {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	kept = collapseBlankLines(kept)
+	if normalized := normalizeOwners(owners); len(normalized) > 0 {
+		if err := w.setCodeownersEntry(kept, "/"+filepath.Base(reposFile), normalized); err != nil {
+			return 0, fmt.Errorf("failed to update CODEOWNERS: %w", err)
+		}
+	}
+
+	for _, entry := range set.Entries {
+		if entry.File == "" {
+			continue
+		}
+		if err := os.Remove(filepath.Join(w.reposDir, entry.File)); err != nil {
+			return 0, fmt.Errorf("failed to remove %s: %w", entry.File, err)
+		}
+	}
+	return len(merged), nil
+}
+
+// SplitReposFile moves every entry of the single repos file into its own per-repo file,
+// owned by the owners of the repos file in CODEOWNERS
+func (w *Writer) SplitReposFile(reposFile string) (int, error) {
+	if _, err := LoadRepositories(w.reposDir, reposFile); err != nil {
+		return 0, err
+	}
+	configs, err := LoadReposFile(reposFile)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	entries, err := LoadCodeowners(w.codeownersFile)
+	if err != nil && !os.IsNotExist(err) {
+		return 0, err
+	}
+	pattern := "/" + filepath.Base(reposFile)
+	owners := entries[pattern]
+	if len(owners) == 0 {
+		return 0, fmt.Errorf("no CODEOWNERS entry for %s to assign the split files to", pattern)
+	}
+
+	// Check every target first so a clash does not leave a half-split layout
+	// Entries whose files only differ by case would overwrite each other on case-insensitive filesystems
+	targets := make(map[string]string)
+	for _, cfg := range configs {
+		filename, err := ConfigFilename(cfg.Name)
+		if err != nil {
+			return 0, err
+		}
+		if other, ok := targets[strings.ToLower(filename)]; ok {
+			return 0, fmt.Errorf("%s and %s would both be split into %s", other, cfg.Name, filename)
+		}
+		targets[strings.ToLower(filename)] = cfg.Name
+		if _, err := os.Stat(filepath.Join(w.reposDir, filename)); err == nil {
+			return 0, fmt.Errorf("%s already exists, cannot split %s into it", filename, cfg.Name)
+		}
+		if err := checkFileConflict(w.reposDir, filename, cfg.Name); err != nil {
+			return 0, err
+		}
+	}
+
+	for _, cfg := range configs {
+		cfg.Owners = owners
+		if err := w.Write(cfg, false); err != nil {
+			return 0, err
+		}
+	}
+
+	lines, err := w.readCodeowners()
+	if err != nil {
+		return 0, err
+	}
+	var kept []string
+	for _, line := range lines {
+		if !matchesPattern(line, pattern) {
+			kept = append(kept, line)
+		}
+	}
+	if err := w.writeCodeowners(collapseBlankLines(kept)); err != nil {
+		return 0, fmt.Errorf("failed to update CODEOWNERS: %w", err)
+	}
+
+	if err := os.Remove(reposFile); err != nil {
+		return 0, fmt.Errorf("failed to remove %s: %w", reposFile, err)
+	}
+	return len(configs), nil
+}
+
+// readCodeowners reads the CODEOWNERS lines, or none if the file does not exist
+func (w *Writer) readCodeowners() ([]string, error) {
+	data, err := os.ReadFile(w.codeownersFile)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(string(data), "\n"), nil
+}
+
+// collapseBlankLines drops blank lines following another blank line, left behind by removed entries
+func collapseBlankLines(lines []string) []string {
+	var result []string
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" && i > 0 && strings.TrimSpace(lines[i-1]) == "" {
+			continue
+		}
+		result = append(result, line)
+	}
+	return result
+}
@@ -0,0 +1,142 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrInvalidEdit is the class of edits refused for the configuration they apply to, rather than failing to apply
+var ErrInvalidEdit = errors.New("invalid edit")
+
+// Edit changes the thresholds and excludes of a repository's configuration; unset fields are left alone
+type Edit struct {
+	// MinCoverage and RegressionDelta set the thresholds, and ClearMinCoverage and ClearRegressionDelta remove them
+	MinCoverage          *float64 `json:"min_coverage,omitempty"`
+	ClearMinCoverage     bool     `json:"clear_min_coverage,omitempty"`
+	RegressionDelta      *float64 `json:"regression_delta,omitempty"`
+	ClearRegressionDelta bool     `json:"clear_regression_delta,omitempty"`
+	// Excludes are added to or removed from those of the repository
+	AddExcludeDirs     []string `json:"add_exclude_dirs,omitempty"`
+	RemoveExcludeDirs  []string `json:"remove_exclude_dirs,omitempty"`
+	AddExcludeFiles    []string `json:"add_exclude_files,omitempty"`
+	RemoveExcludeFiles []string `json:"remove_exclude_files,omitempty"`
+}
+
+// EditResult describes an edited configuration, its changes and owners, and the files it touched
+type EditResult struct {
+	Repo    string
+	Owners  []string
+	Changes []string
+	Files   []string
+}
+
+// EditRepository applies an edit to a configured repository, in its per-repo file or its entry of the single repos
+// file. Edits changing nothing, or leaving an invalid configuration, are refused
+func (w *Writer) EditRepository(reposFile, repo string, edit Edit) (EditResult, error) {
+	result := EditResult{Repo: repo}
+
+	set, err := LoadRepositories(w.reposDir, reposFile)
+	if err != nil {
+		return result, err
+	}
+	index := slices.IndexFunc(set.Entries, func(e RepositoryEntry) bool { return e.Config.Name == repo })
+	if index < 0 {
+		return result, fmt.Errorf("%w: %s is not configured in %s or %s", ErrInvalidEdit, repo, w.reposDir, reposFile)
+	}
+	entry := set.Entries[index]
+
+	codeowners, err := LoadCodeowners(w.codeownersFile)
+	if err != nil && !os.IsNotExist(err) {
+		return result, err
+	}
+	result.Owners = normalizeOwners(entry.Owners(codeowners))
+
+	cfg := &entry.Config
+	result.Changes = append(result.Changes, editThreshold("min_coverage", &cfg.MinCoverage, edit.MinCoverage, edit.ClearMinCoverage)...)
+	result.Changes = append(result.Changes, editThreshold("regression_delta", &cfg.RegressionDelta, edit.RegressionDelta, edit.ClearRegressionDelta)...)
+	result.Changes = append(result.Changes, editList("exclude_dirs", &cfg.ExcludeDirs, edit.AddExcludeDirs, edit.RemoveExcludeDirs)...)
+	result.Changes = append(result.Changes, editList("exclude_files", &cfg.ExcludeFiles, edit.AddExcludeFiles, edit.RemoveExcludeFiles)...)
+	if len(result.Changes) == 0 {
+		return result, fmt.Errorf("%w: it does not change the configuration of %s", ErrInvalidEdit, repo)
+	}
+	if err := cfg.Validate(); err != nil {
+		return result, fmt.Errorf("%w: invalid configuration of %s after it: %w", ErrInvalidEdit, repo, err)
+	}
+
+	if entry.ReposFile != "" {
+		var configs []RepositoryConfig
+		for _, e := range set.Entries {
+			if e.ReposFile == "" {
+				continue
+			}
+			if e.Config.Name == repo {
+				e = entry
+			}
+			configs = append(configs, e.Config)
+		}
+		if err := WriteReposFile(entry.ReposFile, configs); err != nil {
+			return result, err
+		}
+		result.Files = []string{entry.ReposFile}
+		return result, nil
+	}
+
+	path := filepath.Join(w.reposDir, entry.File)
+	data, err := yaml.Marshal(entry.Config)
+	if err != nil {
+		return result, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return result, fmt.Errorf("failed to write config to %s: %w", path, err)
+	}
+	result.Files = []string{path}
+	return result, nil
+}
+
+// editThreshold sets or clears a threshold, describing the change
+func editThreshold(name string, threshold **float64, value *float64, clear bool) []string {
+	switch {
+	case value != nil && (*threshold == nil || **threshold != *value):
+		from := "unset"
+		if *threshold != nil {
+			from = formatThreshold(**threshold)
+		}
+		*threshold = value
+		return []string{fmt.Sprintf("%s: %s → %s", name, from, formatThreshold(*value))}
+	case clear && value == nil && *threshold != nil:
+		from := formatThreshold(**threshold)
+		*threshold = nil
+		return []string{fmt.Sprintf("%s: %s → unset", name, from)}
+	default:
+		return nil
+	}
+}
+
+// formatThreshold formats a threshold without trailing zeros
+func formatThreshold(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// editList adds and removes the entries of a list of excludes, describing each change
+func editList(name string, list *[]string, add, remove []string) []string {
+	var changes []string
+	for _, entry := range remove {
+		if i := slices.Index(*list, entry); i >= 0 {
+			*list = slices.Delete(*list, i, i+1)
+			changes = append(changes, fmt.Sprintf("%s: removed %s", name, entry))
+		}
+	}
+	for _, entry := range add {
+		if entry != "" && !slices.Contains(*list, entry) {
+			*list = append(*list, entry)
+			changes = append(changes, fmt.Sprintf("%s: added %s", name, entry))
+		}
+	}
+	return changes
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxRepoNameLength is the longest repository name GitHub allows, bounding the names of configuration files
+const maxRepoNameLength = 100
+
+// ConfigFilename returns the name of the file configuring a repository in the repos directory: the repository's
+// name without its organization, with a .yaml extension, e.g. "build-service.yaml" for "konflux-ci/build-service"
+// Names are validated so the file stays inside the directory
+func ConfigFilename(repoName string) (string, error) {
+	if !ValidRepoName(repoName) {
+		return "", fmt.Errorf("invalid repository name: %q (must be %s)", repoName, RepoNameRule)
+	}
+	_, name, _ := strings.Cut(repoName, "/")
+	if len(name) > maxRepoNameLength {
+		return "", fmt.Errorf("invalid repository name: %q is longer than %d characters", repoName, maxRepoNameLength)
+	}
+	return name + ".yaml", nil
+}
+
+// checkFileConflict fails when dir has a configuration file whose name only differs from filename by case,
+// which would be the same file on case-insensitive filesystems, or has filename configuring another repository
+func checkFileConflict(dir, filename, repo string) error {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to list %s: %w", dir, err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.EqualFold(entry.Name(), filename) {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		if entry.Name() != filename {
+			return fmt.Errorf("%s conflicts with %s on case-insensitive filesystems", filename, path)
+		}
+		data, err := readLimited(path, MaxConfigSize)
+		if err != nil {
+			return err
+		}
+		var existing RepositoryConfig
+		if err := unmarshalYAML(data, &existing); err == nil && existing.Name != "" && existing.Name != repo {
+			return fmt.Errorf("%s already configures %s, cannot write %s to it", path, existing.Name, repo)
+		}
+	}
+	return nil
+}
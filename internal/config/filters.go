@@ -0,0 +1,98 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// DiscoveryFilters restrict the repositories discovery proposes, e.g. to skip sandbox and demo repositories for good
+// Patterns are org/name globs; excludes win over includes
+type DiscoveryFilters struct {
+	// Include limits discovery to the matching repositories; empty includes every repository
+	Include []string `yaml:"include,omitempty"`
+	// Exclude lists the repositories discovery never proposes
+	Exclude []string `yaml:"exclude,omitempty"`
+	// ExcludeNames lists regular expressions of repository names, without the organization, discovery never
+	// proposes, e.g. sandbox-.* for every sandbox; each must match the whole name
+	ExcludeNames []string `yaml:"exclude_names,omitempty"`
+
+	excludeNames []*regexp.Regexp
+}
+
+// LoadDiscoveryFilters reads a discovery filters file; a missing file filters nothing
+func LoadDiscoveryFilters(filePath string) (DiscoveryFilters, error) {
+	var filters DiscoveryFilters
+	data, err := readLimited(filePath, MaxConfigSize)
+	if os.IsNotExist(err) {
+		return filters, nil
+	}
+	if err != nil {
+		return filters, fmt.Errorf("failed to read %s: %w", filePath, err)
+	}
+
+	if err := unmarshalYAML(data, &filters); err != nil {
+		return filters, fmt.Errorf("failed to parse %s: %w", filePath, err)
+	}
+	for _, pattern := range append(append([]string(nil), filters.Include...), filters.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return filters, fmt.Errorf("invalid pattern %q in %s: %w", pattern, filePath, err)
+		}
+	}
+	if err := filters.compileExcludeNames(); err != nil {
+		return filters, fmt.Errorf("%w in %s", err, filePath)
+	}
+	return filters, nil
+}
+
+// AddExcludeNames adds regular expressions of repository names to exclude, e.g. given on the command line
+func (f *DiscoveryFilters) AddExcludeNames(patterns ...string) error {
+	f.ExcludeNames = append(f.ExcludeNames, patterns...)
+	return f.compileExcludeNames()
+}
+
+// compileExcludeNames compiles ExcludeNames, anchored to match whole names
+func (f *DiscoveryFilters) compileExcludeNames() error {
+	compiled := make([]*regexp.Regexp, 0, len(f.ExcludeNames))
+	for _, pattern := range f.ExcludeNames {
+		expression, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			return fmt.Errorf("invalid name pattern %q: %w", pattern, err)
+		}
+		compiled = append(compiled, expression)
+	}
+	f.excludeNames = compiled
+	return nil
+}
+
+// Allows reports whether discovery may propose a repository, in org/name form, or else why not
+func (f DiscoveryFilters) Allows(repo string) (bool, string) {
+	if pattern, matched := matchAny(f.Exclude, repo); matched {
+		return false, fmt.Sprintf("excluded by %q", pattern)
+	}
+	_, name, _ := strings.Cut(repo, "/")
+	for i, expression := range f.excludeNames {
+		if expression.MatchString(name) {
+			return false, fmt.Sprintf("excluded by name pattern %q", f.ExcludeNames[i])
+		}
+	}
+	if len(f.Include) == 0 {
+		return true, ""
+	}
+	if _, matched := matchAny(f.Include, repo); matched {
+		return true, ""
+	}
+	return false, "not included"
+}
+
+// matchAny returns the first pattern matching a repository
+func matchAny(patterns []string, repo string) (string, bool) {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, repo); matched {
+			return pattern, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,80 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+)
+
+var _ = Describe("DiscoveryFilters", func() {
+	var filtersFile string
+
+	BeforeEach(func() {
+		filtersFile = filepath.Join(GinkgoT().TempDir(), "discovery-filters.yaml")
+	})
+
+	write := func(content string) {
+		Expect(os.WriteFile(filtersFile, []byte(content), 0644)).To(Succeed())
+	}
+
+	It("should let excludes win over includes", func() {
+		write(`include:
+  - konflux-ci/*-service
+  - konflux-ci/caching
+exclude:
+  - konflux-ci/*-sandbox
+  - konflux-ci/demo-*
+  - konflux-ci/demo-service
+`)
+		filters, err := config.LoadDiscoveryFilters(filtersFile)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(filters.Allows("konflux-ci/build-service")).To(BeTrue())
+		Expect(filters.Allows("konflux-ci/caching")).To(BeTrue())
+
+		allowed, reason := filters.Allows("konflux-ci/demo-service")
+		Expect(allowed).To(BeFalse())
+		Expect(reason).To(Equal(`excluded by "konflux-ci/demo-*"`))
+
+		allowed, reason = filters.Allows("konflux-ci/e2e-tests")
+		Expect(allowed).To(BeFalse())
+		Expect(reason).To(Equal("not included"))
+	})
+
+	It("should allow every repository without a filters file", func() {
+		filters, err := config.LoadDiscoveryFilters(filtersFile)
+		Expect(err).NotTo(HaveOccurred())
+		allowed, _ := filters.Allows("konflux-ci/anything")
+		Expect(allowed).To(BeTrue())
+	})
+
+	It("should exclude repositories whose whole name matches a name pattern", func() {
+		write("exclude_names:\n  - sandbox-.*\n")
+		filters, err := config.LoadDiscoveryFilters(filtersFile)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(filters.AddExcludeNames(".*-poc")).To(Succeed())
+
+		allowed, reason := filters.Allows("konflux-ci/sandbox-builds")
+		Expect(allowed).To(BeFalse())
+		Expect(reason).To(Equal(`excluded by name pattern "sandbox-.*"`))
+		allowed, reason = filters.Allows("konflux-ci/cache-poc")
+		Expect(allowed).To(BeFalse())
+		Expect(reason).To(Equal(`excluded by name pattern ".*-poc"`))
+		// Patterns match names without the organization, and whole names only
+		Expect(filters.Allows("sandbox-org/api")).To(BeTrue())
+		Expect(filters.Allows("konflux-ci/poc-tools")).To(BeTrue())
+		Expect(filters.Allows("konflux-ci/my-sandbox-builds")).To(BeTrue())
+
+		Expect(filters.AddExcludeNames("sandbox-(")).To(MatchError(ContainSubstring(`invalid name pattern "sandbox-("`)))
+	})
+
+	It("should reject invalid patterns", func() {
+		write("exclude: [\"konflux-ci/[demo\"]\n")
+		_, err := config.LoadDiscoveryFilters(filtersFile)
+		Expect(err).To(MatchError(ContainSubstring(`invalid pattern "konflux-ci/[demo"`)))
+	})
+})
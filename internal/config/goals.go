@@ -0,0 +1,86 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// milestonePattern matches milestones in "60% by 2025-06-30" form
+var milestonePattern = regexp.MustCompile(`^\s*(\d+(?:\.\d+)?)\s*%\s+by\s+(\d{4}-\d{2}-\d{2})\s*$`)
+
+// Milestone is a coverage a repository aims to reach by a date
+type Milestone struct {
+	Coverage float64
+	// By is the last day of the milestone, in UTC
+	By time.Time
+}
+
+// ParseMilestone parses a milestone in "60% by 2025-06-30" form
+func ParseMilestone(s string) (Milestone, error) {
+	match := milestonePattern.FindStringSubmatch(s)
+	if match == nil {
+		return Milestone{}, fmt.Errorf("goal must look like \"60%% by 2025-06-30\", got %q", s)
+	}
+	coverage, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return Milestone{}, fmt.Errorf("invalid goal coverage in %q: %w", s, err)
+	}
+	by, err := time.Parse("2006-01-02", match[2])
+	if err != nil {
+		return Milestone{}, fmt.Errorf("invalid goal date in %q: %w", s, err)
+	}
+	return Milestone{Coverage: coverage, By: by}, nil
+}
+
+// String formats the milestone as it is configured
+func (m Milestone) String() string {
+	return strconv.FormatFloat(m.Coverage, 'f', -1, 64) + "% by " + m.By.Format("2006-01-02")
+}
+
+// Due reports whether the milestone's last day is over at now
+func (m Milestone) Due(now time.Time) bool {
+	return !now.Before(m.By.AddDate(0, 0, 1))
+}
+
+// Milestones are the coverage goals of a repository, sorted by date
+// They are configured as a single "goal: 60% by 2025-06-30" or as a list of them
+type Milestones []Milestone
+
+// UnmarshalYAML decodes a single milestone or a list of them
+func (m *Milestones) UnmarshalYAML(node *yaml.Node) error {
+	var values []string
+	if node.Kind == yaml.ScalarNode {
+		values = []string{node.Value}
+	} else if err := node.Decode(&values); err != nil {
+		return fmt.Errorf("goal must be a milestone or a list of milestones: %w", err)
+	}
+
+	milestones := make(Milestones, 0, len(values))
+	for _, value := range values {
+		milestone, err := ParseMilestone(value)
+		if err != nil {
+			return err
+		}
+		milestones = append(milestones, milestone)
+	}
+	sort.SliceStable(milestones, func(i, j int) bool { return milestones[i].By.Before(milestones[j].By) })
+	*m = milestones
+	return nil
+}
+
+// MarshalYAML encodes a single milestone as a string and several as a list
+func (m Milestones) MarshalYAML() (any, error) {
+	if len(m) == 1 {
+		return m[0].String(), nil
+	}
+	values := make([]string, len(m))
+	for i, milestone := range m {
+		values[i] = milestone.String()
+	}
+	return values, nil
+}
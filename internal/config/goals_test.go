@@ -0,0 +1,53 @@
+package config_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v3"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+)
+
+var _ = Describe("Goals", func() {
+	It("should parse milestones", func() {
+		milestone, err := config.ParseMilestone("62.5% by 2025-06-30")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(milestone.Coverage).To(Equal(62.5))
+		Expect(milestone.By).To(Equal(time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC)))
+		Expect(milestone.String()).To(Equal("62.5% by 2025-06-30"))
+
+		_, err = config.ParseMilestone("60 by June")
+		Expect(err).To(MatchError(ContainSubstring(`goal must look like "60% by 2025-06-30"`)))
+		_, err = config.ParseMilestone("60% by 2025-02-30")
+		Expect(err).To(MatchError(ContainSubstring("invalid goal date")))
+	})
+
+	It("should be due once its last day is over", func() {
+		milestone, _ := config.ParseMilestone("60% by 2025-06-30")
+		Expect(milestone.Due(time.Date(2025, 6, 30, 23, 59, 0, 0, time.UTC))).To(BeFalse())
+		Expect(milestone.Due(time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC))).To(BeTrue())
+	})
+
+	It("should decode a single milestone or a list sorted by date", func() {
+		var single config.RepositoryConfig
+		Expect(yaml.Unmarshal([]byte("name: org/a\ngoal: 60% by 2025-06-30\n"), &single)).To(Succeed())
+		Expect(single.Goals).To(HaveLen(1))
+
+		var list config.RepositoryConfig
+		Expect(yaml.Unmarshal([]byte("name: org/a\ngoal:\n  - 80% by 2025-12-31\n  - 60% by 2025-06-30\n"), &list)).To(Succeed())
+		Expect(list.Goals).To(HaveLen(2))
+		Expect(list.Goals[0].Coverage).To(Equal(60.0))
+
+		out, err := yaml.Marshal(single)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(out)).To(ContainSubstring("goal: 60% by 2025-06-30\n"))
+	})
+
+	It("should reject goals outside 0-100%", func() {
+		var cfg config.RepositoryConfig
+		Expect(yaml.Unmarshal([]byte("name: org/a\ngoal: 120% by 2025-06-30\n"), &cfg)).To(Succeed())
+		Expect(cfg.Validate()).To(MatchError(ContainSubstring("goal")))
+	})
+})
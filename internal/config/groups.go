@@ -0,0 +1,90 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Group is a named set of repositories, e.g. the repositories of a product, aggregated and alerted on
+// together whatever teams own them
+type Group struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description,omitempty"`
+	// Repos are org/name glob patterns
+	Repos []string `yaml:"repos"`
+}
+
+// Groups are the repository groups of a groups file
+type Groups []Group
+
+// LoadGroups reads a groups file, a YAML list of groups; a missing file yields no groups
+func LoadGroups(filePath string) (Groups, error) {
+	data, err := readLimited(filePath, MaxConfigSize)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filePath, err)
+	}
+
+	var groups Groups
+	if err := unmarshalYAML(data, &groups); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filePath, err)
+	}
+	seen := make(map[string]bool)
+	for i, group := range groups {
+		if strings.TrimSpace(group.Name) == "" {
+			return nil, fmt.Errorf("group %d of %s has no name", i+1, filePath)
+		}
+		if seen[group.Name] {
+			return nil, fmt.Errorf("group %s is defined more than once in %s", group.Name, filePath)
+		}
+		seen[group.Name] = true
+		if len(group.Repos) == 0 {
+			return nil, fmt.Errorf("group %s of %s has no repos", group.Name, filePath)
+		}
+		for _, pattern := range group.Repos {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("group %s of %s: invalid repos pattern %q: %w", group.Name, filePath, pattern, err)
+			}
+		}
+	}
+	return groups, nil
+}
+
+// Of returns the names of the groups a repository belongs to, sorted
+func (g Groups) Of(repo string) []string {
+	var names []string
+	for _, group := range g {
+		for _, pattern := range group.Repos {
+			if matched, _ := path.Match(pattern, repo); matched {
+				names = append(names, group.Name)
+				break
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Unmatched returns the names of the groups none of the repositories belong to, sorted
+func (g Groups) Unmatched(repos []string) []string {
+	var names []string
+	for _, group := range g {
+		matched := false
+		for _, repo := range repos {
+			if len(Groups{group}.Of(repo)) > 0 {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			names = append(names, group.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,63 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+)
+
+var _ = Describe("Groups", func() {
+	var groupsFile string
+
+	BeforeEach(func() {
+		groupsFile = filepath.Join(GinkgoT().TempDir(), "groups.yaml")
+	})
+
+	write := func(content string) {
+		Expect(os.WriteFile(groupsFile, []byte(content), 0644)).To(Succeed())
+	}
+
+	It("should match repositories by name or glob pattern", func() {
+		write(`- name: build-service-stack
+  description: Build pipeline services
+  repos:
+    - konflux-ci/build-service
+    - konflux-ci/build-*
+- name: integration
+  repos: [konflux-ci/integration-service]
+`)
+		groups, err := config.LoadGroups(groupsFile)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(groups).To(HaveLen(2))
+		Expect(groups[0].Description).To(Equal("Build pipeline services"))
+
+		Expect(groups.Of("konflux-ci/build-service")).To(Equal([]string{"build-service-stack"}))
+		Expect(groups.Of("konflux-ci/build-definitions")).To(Equal([]string{"build-service-stack"}))
+		Expect(groups.Of("konflux-ci/caching")).To(BeEmpty())
+		Expect(groups.Unmatched([]string{"konflux-ci/build-service"})).To(Equal([]string{"integration"}))
+	})
+
+	It("should yield no groups without a groups file", func() {
+		groups, err := config.LoadGroups(groupsFile)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(groups).To(BeEmpty())
+	})
+
+	It("should reject unnamed, duplicate and empty groups", func() {
+		write("- repos: [konflux-ci/a]\n")
+		_, err := config.LoadGroups(groupsFile)
+		Expect(err).To(MatchError(ContainSubstring("has no name")))
+
+		write("- name: a\n  repos: [konflux-ci/a]\n- name: a\n  repos: [konflux-ci/b]\n")
+		_, err = config.LoadGroups(groupsFile)
+		Expect(err).To(MatchError(ContainSubstring("more than once")))
+
+		write("- name: a\n")
+		_, err = config.LoadGroups(groupsFile)
+		Expect(err).To(MatchError(ContainSubstring("has no repos")))
+	})
+})
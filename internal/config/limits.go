@@ -0,0 +1,78 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Configurations come from pull requests and other repositories; these limits keep hostile
+// inputs from exhausting the memory or time of a run
+const (
+	// MaxConfigSize bounds repository configurations, repos files and groups files
+	MaxConfigSize = 1 << 20
+	// MaxCodeownersSize is GitHub's own limit, beyond which it ignores a CODEOWNERS file
+	MaxCodeownersSize = 3 << 20
+	// maxYAMLDepth bounds the nesting of YAML documents, which configurations never need deep
+	maxYAMLDepth = 32
+	// maxYAMLNodes bounds the nodes of a YAML document once its aliases are expanded
+	maxYAMLNodes = 100_000
+)
+
+// readLimited reads a file of at most limit bytes
+// Errors opening the file are returned unwrapped, so that os.IsNotExist applies to them
+func readLimited(path string, limit int64) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%s is larger than %d bytes", path, limit)
+	}
+	return data, nil
+}
+
+// unmarshalYAML decodes a YAML document like yaml.Unmarshal, rejecting documents nested deeper than
+// maxYAMLDepth or expanding to more than maxYAMLNodes nodes, such as alias bombs
+func unmarshalYAML(data []byte, v any) error {
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return err
+	}
+	if document.Kind == 0 {
+		return nil
+	}
+	nodes := 0
+	if err := checkYAMLNode(&document, 0, &nodes); err != nil {
+		return err
+	}
+	return document.Decode(v)
+}
+
+// checkYAMLNode walks a node as decoding would, following aliases, and counts the nodes it visits
+func checkYAMLNode(node *yaml.Node, depth int, nodes *int) error {
+	if depth > maxYAMLDepth {
+		return fmt.Errorf("line %d: YAML nested deeper than %d levels", node.Line, maxYAMLDepth)
+	}
+	*nodes++
+	if *nodes > maxYAMLNodes {
+		return fmt.Errorf("line %d: YAML expands to more than %d nodes", node.Line, maxYAMLNodes)
+	}
+	if node.Kind == yaml.AliasNode {
+		return checkYAMLNode(node.Alias, depth+1, nodes)
+	}
+	for _, child := range node.Content {
+		if err := checkYAMLNode(child, depth+1, nodes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Input limits", func() {
+	It("should reject YAML alias bombs", func() {
+		bomb := "a: &a [x, x, x, x, x, x, x, x, x, x]\n"
+		for i := 'b'; i <= 'j'; i++ {
+			bomb += fmt.Sprintf("%c: &%c [*%c, *%c, *%c, *%c, *%c, *%c, *%c, *%c, *%c, *%c]\n", i, i, i-1, i-1, i-1, i-1, i-1, i-1, i-1, i-1, i-1, i-1)
+		}
+		var v map[string]any
+		Expect(unmarshalYAML([]byte(bomb), &v)).To(MatchError(ContainSubstring("more than 100000 nodes")))
+	})
+
+	It("should reject deeply nested YAML", func() {
+		nested := strings.Repeat("[", 100) + strings.Repeat("]", 100)
+		var v any
+		Expect(unmarshalYAML([]byte(nested), &v)).To(MatchError(ContainSubstring("nested deeper than 32 levels")))
+	})
+
+	It("should still decode reasonable aliases", func() {
+		var configs []RepositoryConfig
+		data := "- name: org/a\n  exclude_dirs: &shared [vendor, hack]\n- name: org/b\n  exclude_dirs: *shared\n"
+		Expect(unmarshalYAML([]byte(data), &configs)).To(Succeed())
+		Expect(configs[1].ExcludeDirs).To(Equal([]string{"vendor", "hack"}))
+	})
+
+	It("should reject oversized files, keeping missing files recognizable", func() {
+		dir := GinkgoT().TempDir()
+		reposFile := filepath.Join(dir, "repos.yaml")
+		Expect(os.WriteFile(reposFile, []byte("# "+strings.Repeat("x", MaxConfigSize)+"\n"), 0644)).To(Succeed())
+		_, err := LoadReposFile(reposFile)
+		Expect(err).To(MatchError(ContainSubstring("larger than")))
+
+		_, err = LoadCodeowners(filepath.Join(dir, "CODEOWNERS"))
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+})
+
+func FuzzRepositoryConfigs(f *testing.F) {
+	f.Add("- name: org/repo\n  exclude_dirs: [vendor]\n  min_coverage: 40\n")
+	f.Add("name: org/repo\nratchet:\n  tolerance: 1\n")
+	f.Add("- &a {name: org/a}\n- *a\n")
+	f.Add("[[[[[[[[[[]]]]]]]]]]")
+	f.Fuzz(func(t *testing.T, data string) {
+		var configs []RepositoryConfig
+		if unmarshalYAML([]byte(data), &configs) == nil {
+			for _, cfg := range configs {
+				_ = cfg.Validate()
+			}
+		}
+		var cfg RepositoryConfig
+		if unmarshalYAML([]byte(data), &cfg) == nil {
+			_ = cfg.Validate()
+		}
+	})
+}
+
+func FuzzCodeowners(f *testing.F) {
+	f.Add("/repos/a.yaml @org/team # comment\n* @someone\n")
+	f.Add("#\n\n   \t@x")
+	f.Fuzz(func(t *testing.T, data string) {
+		for pattern, owners := range parseCodeowners([]byte(data)) {
+			if pattern == "" || strings.ContainsAny(pattern, "# \t\n") {
+				t.Fatalf("invalid pattern %q", pattern)
+			}
+			for _, owner := range owners {
+				if owner == "" || strings.Contains(owner, "#") {
+					t.Fatalf("invalid owner %q of %q", owner, pattern)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// RootModule is the path of the module at the root of a repository
+const RootModule = "."
+
+// ModuleConfig is a Go module of a repository with several, whose coverage is collected on its own
+type ModuleConfig struct {
+	// Path is the directory of the module's go.mod relative to the repository root, "." for the root
+	Path string `yaml:"path"`
+	// ExcludeDirs and ExcludeFiles exclude code of the module, in addition to the repository's excludes
+	ExcludeDirs  []string `yaml:"exclude_dirs,omitempty"`
+	ExcludeFiles []string `yaml:"exclude_files,omitempty"`
+}
+
+// EffectiveModules returns the modules coverage is collected in, with the repository's excludes added to their own
+// Repositories without modules are a single module at their root
+func (c RepositoryConfig) EffectiveModules() []ModuleConfig {
+	if len(c.Modules) == 0 {
+		return []ModuleConfig{{Path: RootModule, ExcludeDirs: c.ExcludeDirs, ExcludeFiles: c.ExcludeFiles}}
+	}
+	modules := make([]ModuleConfig, 0, len(c.Modules))
+	for _, module := range c.Modules {
+		modules = append(modules, ModuleConfig{
+			Path:         module.Path,
+			ExcludeDirs:  append(append([]string(nil), c.ExcludeDirs...), module.ExcludeDirs...),
+			ExcludeFiles: append(append([]string(nil), c.ExcludeFiles...), module.ExcludeFiles...),
+		})
+	}
+	return modules
+}
+
+// validateModules checks that module paths are distinct directories inside the repository
+func validateModules(modules []ModuleConfig) error {
+	seen := make(map[string]bool)
+	for _, module := range modules {
+		if module.Path == "" || path.IsAbs(module.Path) || path.Clean(module.Path) != module.Path ||
+			module.Path == ".." || strings.HasPrefix(module.Path, "../") {
+			return fmt.Errorf("module path must be a clean directory relative to the repository root, got %q", module.Path)
+		}
+		if seen[module.Path] {
+			return fmt.Errorf("module %q is listed twice", module.Path)
+		}
+		seen[module.Path] = true
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package config_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v3"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+)
+
+var _ = Describe("Modules", func() {
+	It("should add the repository's excludes to those of every module", func() {
+		var cfg config.RepositoryConfig
+		Expect(yaml.Unmarshal([]byte(`name: konflux-ci/e2e-tests
+exclude_dirs:
+  - vendor/
+exclude_files: []
+modules:
+  - path: .
+    exclude_dirs:
+      - magefiles/
+  - path: tools/generator
+    exclude_files:
+      - "*.pb.go"
+`), &cfg)).To(Succeed())
+		Expect(cfg.Validate()).To(Succeed())
+
+		Expect(cfg.EffectiveModules()).To(Equal([]config.ModuleConfig{
+			{Path: ".", ExcludeDirs: []string{"vendor/", "magefiles/"}},
+			{Path: "tools/generator", ExcludeDirs: []string{"vendor/"}, ExcludeFiles: []string{"*.pb.go"}},
+		}))
+	})
+
+	It("should collect repositories without modules at their root", func() {
+		cfg := config.RepositoryConfig{Name: "konflux-ci/api", ExcludeDirs: []string{"vendor/"}}
+		Expect(cfg.EffectiveModules()).To(Equal([]config.ModuleConfig{{Path: config.RootModule, ExcludeDirs: []string{"vendor/"}}}))
+	})
+
+	DescribeTable("should reject module paths outside the repository or listed twice",
+		func(paths []string, message string) {
+			cfg := config.RepositoryConfig{Name: "konflux-ci/api"}
+			for _, path := range paths {
+				cfg.Modules = append(cfg.Modules, config.ModuleConfig{Path: path})
+			}
+			Expect(cfg.Validate()).To(MatchError(ContainSubstring(message)))
+		},
+		Entry("empty", []string{""}, "module path must be a clean directory"),
+		Entry("absolute", []string{"/tools"}, "module path must be a clean directory"),
+		Entry("parent", []string{"../other"}, "module path must be a clean directory"),
+		Entry("unclean", []string{"tools/"}, "module path must be a clean directory"),
+		Entry("duplicate", []string{"tools", "tools"}, `module "tools" is listed twice`),
+	)
+})
@@ -0,0 +1,145 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultOffboardedFile lists the offboarded repositories and when their coverage data is deleted
+const DefaultOffboardedFile = "offboarded.yaml"
+
+// RetainForever keeps the coverage data of an offboarded repository, given as its retention
+const RetainForever = "forever"
+
+// retentionUnits are the units of retentions beyond those of time.ParseDuration
+var retentionUnits = map[string]time.Duration{
+	"d": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour,
+}
+
+// OffboardedRepo is a repository removed from the dashboard, kept listed so its data is deleted once retained
+// long enough
+type OffboardedRepo struct {
+	Name         string    `yaml:"name"`
+	OffboardedAt time.Time `yaml:"offboarded_at"`
+	// DeleteAfter is when the coverage data of the repository is deleted; nil keeps it forever
+	DeleteAfter *time.Time `yaml:"delete_after,omitempty"`
+}
+
+// Offboarded are the repositories of an offboarded file, sorted by name
+type Offboarded []OffboardedRepo
+
+// ParseRetention parses how long the data of an offboarded repository is kept: a number of days (90d) or weeks
+// (13w), a Go duration (2160h), 0 to delete it on the next run, or forever. Forever yields a negative retention
+func ParseRetention(s string) (time.Duration, error) {
+	if s == RetainForever {
+		return -1, nil
+	}
+	invalid := fmt.Errorf("invalid retention %q: expected e.g. 90d, 13w, 2160h, 0 or %s", s, RetainForever)
+	var retention time.Duration
+	if unit, ok := retentionUnits[s[max(len(s)-1, 0):]]; ok {
+		n, err := strconv.Atoi(strings.TrimSuffix(s, s[len(s)-1:]))
+		if err != nil {
+			return 0, invalid
+		}
+		retention = time.Duration(n) * unit
+	} else if s != "0" {
+		var err error
+		if retention, err = time.ParseDuration(s); err != nil {
+			return 0, invalid
+		}
+	}
+	if retention < 0 {
+		return 0, invalid
+	}
+	return retention, nil
+}
+
+// LoadOffboarded reads an offboarded file; a missing file yields no repositories
+func LoadOffboarded(filePath string) (Offboarded, error) {
+	data, err := readLimited(filePath, MaxConfigSize)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filePath, err)
+	}
+
+	var offboarded Offboarded
+	if err := unmarshalYAML(data, &offboarded); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filePath, err)
+	}
+	for i, repo := range offboarded {
+		if !ValidRepoName(repo.Name) {
+			return nil, fmt.Errorf("repository %d of %s must be in org/name form, got %q", i+1, filePath, repo.Name)
+		}
+	}
+	return offboarded, nil
+}
+
+// WriteOffboarded writes the repositories of an offboarded file, sorted by name
+func WriteOffboarded(filePath string, offboarded Offboarded) error {
+	sorted := slices.Clone(offboarded)
+	slices.SortFunc(sorted, func(a, b OffboardedRepo) int { return strings.Compare(a.Name, b.Name) })
+	data, err := yaml.Marshal(sorted)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", filePath, err)
+	}
+	return os.WriteFile(filePath, data, 0644)
+}
+
+// Add records a repository offboarded at a time, replacing an earlier record of it, with its data deleted after
+// retention; a negative retention keeps it forever
+func (o Offboarded) Add(repo string, at time.Time, retention time.Duration) Offboarded {
+	record := OffboardedRepo{Name: repo, OffboardedAt: at.UTC().Truncate(time.Second)}
+	if retention >= 0 {
+		deleteAfter := record.OffboardedAt.Add(retention)
+		record.DeleteAfter = &deleteAfter
+	}
+	kept := slices.DeleteFunc(slices.Clone(o), func(r OffboardedRepo) bool { return r.Name == repo })
+	return append(kept, record)
+}
+
+// Due returns the repositories whose data is to be deleted at now
+func (o Offboarded) Due(now time.Time) []OffboardedRepo {
+	var due []OffboardedRepo
+	for _, repo := range o {
+		if repo.DeleteAfter != nil && !now.Before(*repo.DeleteAfter) {
+			due = append(due, repo)
+		}
+	}
+	return due
+}
+
+// Offboarding describes a repository removed from the dashboard with its data deletion scheduled
+type Offboarding struct {
+	Removal
+	// DeleteAfter is when its coverage data is deleted, nil when kept forever
+	DeleteAfter *time.Time
+}
+
+// Offboard stops tracking a configured repository like RemoveRepository, and records it in the offboarded file
+// with its data deleted after retention, a negative retention keeping it forever
+func (w *Writer) Offboard(reposFile, offboardedFile, repo string, at time.Time, retention time.Duration) (Offboarding, error) {
+	offboarded, err := LoadOffboarded(offboardedFile)
+	if err != nil {
+		return Offboarding{}, err
+	}
+	removal, err := w.RemoveRepository(reposFile, repo)
+	if err != nil {
+		return Offboarding{Removal: removal}, err
+	}
+
+	offboarded = offboarded.Add(repo, at, retention)
+	if err := WriteOffboarded(offboardedFile, offboarded); err != nil {
+		return Offboarding{Removal: removal}, err
+	}
+	removal.Files = append(removal.Files, offboardedFile)
+	return Offboarding{Removal: removal, DeleteAfter: offboarded[len(offboarded)-1].DeleteAfter}, nil
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// Removal describes a repository whose configuration was removed, its owners and the files it touched
+type Removal struct {
+	Repo   string
+	Owners []string
+	Files  []string
+}
+
+// RemoveRepository stops tracking a configured repository: a per-repo file is deleted with its CODEOWNERS
+// entry, an entry of the single repos file is dropped from it, leaving the repos file's CODEOWNERS entry
+func (w *Writer) RemoveRepository(reposFile, repo string) (Removal, error) {
+	removal := Removal{Repo: repo}
+
+	set, err := LoadRepositories(w.reposDir, reposFile)
+	if err != nil {
+		return removal, err
+	}
+	index := slices.IndexFunc(set.Entries, func(e RepositoryEntry) bool { return e.Config.Name == repo })
+	if index < 0 {
+		return removal, fmt.Errorf("%s is not configured in %s or %s", repo, w.reposDir, reposFile)
+	}
+	entry := set.Entries[index]
+
+	codeowners, err := LoadCodeowners(w.codeownersFile)
+	if err != nil && !os.IsNotExist(err) {
+		return removal, err
+	}
+	removal.Owners = normalizeOwners(entry.Owners(codeowners))
+
+	if entry.ReposFile != "" {
+		var configs []RepositoryConfig
+		for _, e := range set.Entries {
+			if e.ReposFile != "" && e.Config.Name != repo {
+				configs = append(configs, e.Config)
+			}
+		}
+		if err := WriteReposFile(entry.ReposFile, configs); err != nil {
+			return removal, err
+		}
+		removal.Files = []string{entry.ReposFile}
+		return removal, nil
+	}
+
+	path := filepath.Join(w.reposDir, entry.File)
+	if err := os.Remove(path); err != nil {
+		return removal, fmt.Errorf("failed to remove %s: %w", path, err)
+	}
+	removal.Files = []string{path}
+
+	lines, err := w.readCodeowners()
+	if err != nil {
+		return removal, fmt.Errorf("failed to read CODEOWNERS: %w", err)
+	}
+	pattern := CodeownersPattern(entry.File)
+	var kept []string
+	for _, line := range lines {
+		if !matchesPattern(line, pattern) {
+			kept = append(kept, line)
+		}
+	}
+	if len(kept) < len(lines) {
+		if err := w.writeCodeowners(collapseBlankLines(kept)); err != nil {
+			return removal, fmt.Errorf("failed to update CODEOWNERS: %w", err)
+		}
+		removal.Files = append(removal.Files, w.codeownersFile)
+	}
+	return removal, nil
+}
@@ -0,0 +1,112 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Rename describes a configured repository renamed on its forge, its owners and the files the migration touched
+type Rename struct {
+	From   string
+	To     string
+	Owners []string
+	Files  []string
+}
+
+// RenameRepository migrates the configuration of a repository renamed from one name to another: a per-repo file
+// moves to the file of the new name with its CODEOWNERS entry, an entry of the single repos file is renamed in
+// place. The rest of the configuration is kept
+func (w *Writer) RenameRepository(reposFile, from, to string) (Rename, error) {
+	rename := Rename{From: from, To: to}
+
+	set, err := LoadRepositories(w.reposDir, reposFile)
+	if err != nil {
+		return rename, err
+	}
+	index := slices.IndexFunc(set.Entries, func(e RepositoryEntry) bool { return e.Config.Name == from })
+	if index < 0 {
+		return rename, fmt.Errorf("%s is not configured in %s or %s", from, w.reposDir, reposFile)
+	}
+	if slices.ContainsFunc(set.Entries, func(e RepositoryEntry) bool { return e.Config.Name == to }) {
+		return rename, fmt.Errorf("%s is already configured", to)
+	}
+	entry := set.Entries[index]
+
+	codeowners, err := LoadCodeowners(w.codeownersFile)
+	if err != nil && !os.IsNotExist(err) {
+		return rename, err
+	}
+	rename.Owners = normalizeOwners(entry.Owners(codeowners))
+
+	entry.Config.Name = to
+	if err := entry.Config.Validate(); err != nil {
+		return rename, fmt.Errorf("invalid configuration of %s after the rename: %w", to, err)
+	}
+	if entry.ReposFile != "" {
+		var configs []RepositoryConfig
+		for _, e := range set.Entries {
+			if e.ReposFile == "" {
+				continue
+			}
+			if e.Config.Name == from {
+				e = entry
+			}
+			configs = append(configs, e.Config)
+		}
+		if err := WriteReposFile(entry.ReposFile, configs); err != nil {
+			return rename, err
+		}
+		rename.Files = []string{entry.ReposFile}
+		return rename, nil
+	}
+
+	filename, err := ConfigFilename(to)
+	if err != nil {
+		return rename, err
+	}
+	oldPath, newPath := filepath.Join(w.reposDir, entry.File), filepath.Join(w.reposDir, filename)
+	// Renames changing only the case keep the file name on case-insensitive filesystems
+	if !strings.EqualFold(entry.File, filename) {
+		if err := checkFileConflict(w.reposDir, filename, to); err != nil {
+			return rename, err
+		}
+	}
+	data, err := yaml.Marshal(entry.Config)
+	if err != nil {
+		return rename, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	if err := os.Remove(oldPath); err != nil {
+		return rename, fmt.Errorf("failed to remove %s: %w", oldPath, err)
+	}
+	if err := os.WriteFile(newPath, data, 0644); err != nil {
+		return rename, fmt.Errorf("failed to write config to %s: %w", newPath, err)
+	}
+	rename.Files = []string{oldPath, newPath}
+
+	// The entry moves to the pattern of the new file, keeping its owners and the order of CODEOWNERS
+	owners, ok := codeowners[CodeownersPattern(entry.File)]
+	if !ok || entry.File == filename {
+		return rename, nil
+	}
+	lines, err := w.readCodeowners()
+	if err != nil {
+		return rename, fmt.Errorf("failed to read CODEOWNERS: %w", err)
+	}
+	pattern := CodeownersPattern(entry.File)
+	var kept []string
+	for _, line := range lines {
+		if !matchesPattern(line, pattern) {
+			kept = append(kept, line)
+		}
+	}
+	if err := w.setCodeownersEntry(kept, CodeownersPattern(filename), owners); err != nil {
+		return rename, fmt.Errorf("failed to update CODEOWNERS: %w", err)
+	}
+	rename.Files = append(rename.Files, w.codeownersFile)
+	return rename, nil
+}
@@ -0,0 +1,164 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RepositoryEntry is a repository configuration together with where it was loaded from
+type RepositoryEntry struct {
+	Config RepositoryConfig
+	// File is the per-repo file name under the repos directory, empty for entries of the single repos file
+	File string
+	// ReposFile is the single repos file the entry was listed in, empty for per-repo files
+	ReposFile string
+}
+
+// Key identifies the entry across runs, e.g. in run manifests
+// Per-repo files keep their file name; single-file entries are "repos.yaml#org/repo"
+func (e RepositoryEntry) Key() string {
+	if e.File != "" {
+		return e.File
+	}
+	return filepath.Base(e.ReposFile) + "#" + e.Config.Name
+}
+
+// Source describes where the entry was loaded from, for messages
+func (e RepositoryEntry) Source() string {
+	if e.File != "" {
+		return e.File
+	}
+	return filepath.Base(e.ReposFile)
+}
+
+// CodeownersPattern returns the CODEOWNERS pattern owning the entry
+func (e RepositoryEntry) CodeownersPattern() string {
+	if e.File != "" {
+		return CodeownersPattern(e.File)
+	}
+	return "/" + filepath.Base(e.ReposFile)
+}
+
+// Owners returns the entry's owners_override, or its owners in the CODEOWNERS entries
+func (e RepositoryEntry) Owners(codeowners map[string][]string) []string {
+	if len(e.Config.OwnersOverride) > 0 {
+		return e.Config.OwnersOverride
+	}
+	return codeowners[e.CodeownersPattern()]
+}
+
+// RepositorySet is the merged view of the per-repo files and the single repos file
+type RepositorySet struct {
+	Entries []RepositoryEntry
+	// Invalid maps per-repo files that could not be parsed to their error; they are skipped
+	Invalid map[string]error
+}
+
+// InvalidFiles returns the per-repo files that could not be parsed, sorted
+func (s *RepositorySet) InvalidFiles() []string {
+	files := make([]string, 0, len(s.Invalid))
+	for file := range s.Invalid {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// LoadRepositories loads the configurations of both layouts: per-repo files under reposDir and
+// a single reposFile listing repositories. Either may be missing. A repository configured more
+// than once is an error
+func LoadRepositories(reposDir, reposFile string) (*RepositorySet, error) {
+	set := &RepositorySet{Invalid: make(map[string]error)}
+
+	entries, err := os.ReadDir(reposDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read repos directory: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".yaml") {
+			continue
+		}
+		cfg, err := LoadRepositoryConfig(reposDir, entry.Name())
+		if err != nil {
+			set.Invalid[entry.Name()] = err
+			continue
+		}
+		set.Entries = append(set.Entries, RepositoryEntry{Config: cfg, File: entry.Name()})
+	}
+
+	if reposFile != "" {
+		configs, err := LoadReposFile(reposFile)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		for _, cfg := range configs {
+			set.Entries = append(set.Entries, RepositoryEntry{Config: cfg, ReposFile: reposFile})
+		}
+	}
+
+	if err := set.checkConflicts(); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+// checkConflicts reports repositories configured in more than one place
+func (s *RepositorySet) checkConflicts() error {
+	sources := make(map[string][]string)
+	for _, entry := range s.Entries {
+		sources[entry.Config.Name] = append(sources[entry.Config.Name], entry.Source())
+	}
+
+	var conflicts []error
+	for name, where := range sources {
+		if len(where) > 1 {
+			conflicts = append(conflicts, fmt.Errorf("%s is configured in %s", name, strings.Join(where, " and ")))
+		}
+	}
+	if len(conflicts) == 0 {
+		return nil
+	}
+
+	sort.Slice(conflicts, func(i, j int) bool { return conflicts[i].Error() < conflicts[j].Error() })
+	return fmt.Errorf("conflicting repository configurations: %w", errors.Join(conflicts...))
+}
+
+// LoadReposFile reads a single repos file, a YAML list of repository configurations
+func LoadReposFile(path string) ([]RepositoryConfig, error) {
+	data, err := readLimited(path, MaxConfigSize)
+	if err != nil {
+		return nil, err
+	}
+
+	var configs []RepositoryConfig
+	if err := unmarshalYAML(data, &configs); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	for i, cfg := range configs {
+		if strings.TrimSpace(cfg.Name) == "" {
+			return nil, fmt.Errorf("entry %d of %s has no name", i+1, path)
+		}
+		if err := cfg.Validate(); err != nil {
+			return nil, fmt.Errorf("entry %s of %s: %w", cfg.Name, path, err)
+		}
+	}
+	return configs, nil
+}
+
+// WriteReposFile writes repository configurations as a single repos file, sorted by name
+func WriteReposFile(path string, configs []RepositoryConfig) error {
+	sorted := append([]RepositoryConfig(nil), configs...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	data, err := yaml.Marshal(sorted)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", path, err)
+	}
+	return os.WriteFile(path, data, 0644)
+}
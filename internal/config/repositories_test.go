@@ -0,0 +1,173 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+)
+
+var _ = Describe("Repository layouts", func() {
+	var (
+		tempDir        string
+		reposDir       string
+		reposFile      string
+		codeownersFile string
+	)
+
+	writeFile := func(path, content string) {
+		Expect(os.WriteFile(path, []byte(content), 0644)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		tempDir = GinkgoT().TempDir()
+		reposDir = filepath.Join(tempDir, "repos")
+		reposFile = filepath.Join(tempDir, "repos.yaml")
+		codeownersFile = filepath.Join(tempDir, "CODEOWNERS")
+		Expect(os.MkdirAll(reposDir, 0755)).To(Succeed())
+
+		writeFile(filepath.Join(reposDir, "alpha.yaml"), "name: konflux-ci/alpha\nexclude_dirs:\n  - vendor/\n")
+		writeFile(reposFile, "- name: konflux-ci/beta\n  timeout: 45m\n- name: konflux-ci/gamma\n")
+		writeFile(codeownersFile, "/repos/alpha.yaml @konflux-ci/alpha\n\n/repos.yaml @konflux-ci/vanguard\n")
+	})
+
+	Describe("LoadRepositories", func() {
+		It("should merge per-repo files with the repos file", func() {
+			set, err := config.LoadRepositories(reposDir, reposFile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(set.Entries).To(HaveLen(3))
+
+			Expect(set.Entries[0].Key()).To(Equal("alpha.yaml"))
+			Expect(set.Entries[0].CodeownersPattern()).To(Equal("/repos/alpha.yaml"))
+			Expect(set.Entries[1].Key()).To(Equal("repos.yaml#konflux-ci/beta"))
+			Expect(set.Entries[1].CodeownersPattern()).To(Equal("/repos.yaml"))
+			Expect(set.Entries[1].Config.Timeout).To(Equal("45m"))
+		})
+
+		It("should prefer owners_override over the CODEOWNERS entry", func() {
+			codeowners := map[string][]string{"/repos.yaml": {"@konflux-ci/vanguard"}}
+			entry := config.RepositoryEntry{Config: config.RepositoryConfig{Name: "konflux-ci/beta"}, ReposFile: reposFile}
+			Expect(entry.Owners(codeowners)).To(Equal([]string{"@konflux-ci/vanguard"}))
+
+			entry.Config.OwnersOverride = []string{"@konflux-ci/new-team"}
+			Expect(entry.Owners(codeowners)).To(Equal([]string{"@konflux-ci/new-team"}))
+		})
+
+		It("should report configurations with invalid thresholds or languages", func() {
+			writeFile(filepath.Join(reposDir, "delta.yaml"), "name: konflux-ci/delta\nratchet:\n  tolerance: 1\n  reset:\n    value: 40\n")
+			writeFile(filepath.Join(reposDir, "zeta.yaml"), "name: konflux-ci/zeta\nlanguage: rust\n")
+			writeFile(filepath.Join(reposDir, "epsilon.yaml"), "name: konflux-ci/epsilon\nmin_coverage: 60\nratchet:\n  tolerance: 1\n  reset:\n    value: 40\n    reason: dropped generated clients\n")
+
+			set, err := config.LoadRepositories(reposDir, reposFile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(set.Invalid).To(HaveKeyWithValue("delta.yaml", MatchError("ratchet reset needs a reason")))
+			Expect(set.Invalid).To(HaveKeyWithValue("zeta.yaml", MatchError(ContainSubstring(`got "rust"`))))
+
+			var epsilon config.RepositoryConfig
+			for _, entry := range set.Entries {
+				if entry.File == "epsilon.yaml" {
+					epsilon = entry.Config
+				}
+			}
+			Expect(*epsilon.MinCoverage).To(Equal(60.0))
+			Expect(epsilon.Ratchet.Reset).To(Equal(&config.RatchetReset{Value: 40, Reason: "dropped generated clients"}))
+
+			writeFile(reposFile, "- name: konflux-ci/beta\n  min_coverage: 120\n")
+			_, err = config.LoadRepositories(reposDir, reposFile)
+			Expect(err).To(MatchError(ContainSubstring("min_coverage must be between 0 and 100")))
+		})
+
+		It("should accept either layout missing", func() {
+			set, err := config.LoadRepositories(filepath.Join(tempDir, "missing"), reposFile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(set.Entries).To(HaveLen(2))
+
+			set, err = config.LoadRepositories(reposDir, filepath.Join(tempDir, "missing.yaml"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(set.Entries).To(HaveLen(1))
+		})
+
+		It("should skip unparsable per-repo files", func() {
+			writeFile(filepath.Join(reposDir, "broken.yaml"), "name: [\n")
+			writeFile(filepath.Join(reposDir, "another.yaml"), "name: [\n")
+			set, err := config.LoadRepositories(reposDir, reposFile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(set.Entries).To(HaveLen(3))
+			Expect(set.Invalid).To(HaveKey("broken.yaml"))
+			Expect(set.InvalidFiles()).To(Equal([]string{"another.yaml", "broken.yaml"}))
+		})
+
+		It("should detect repositories configured in both layouts", func() {
+			writeFile(filepath.Join(reposDir, "beta.yaml"), "name: konflux-ci/beta\n")
+			_, err := config.LoadRepositories(reposDir, reposFile)
+			Expect(err).To(MatchError(ContainSubstring("konflux-ci/beta is configured in beta.yaml and repos.yaml")))
+		})
+
+		It("should reject repos file entries without a name", func() {
+			writeFile(reposFile, "- name: konflux-ci/beta\n- exclude_dirs: [vendor/]\n")
+			_, err := config.LoadRepositories(reposDir, reposFile)
+			Expect(err).To(MatchError(ContainSubstring("entry 2")))
+		})
+	})
+
+	Describe("converting layouts", func() {
+		It("should merge per-repo files into the repos file", func() {
+			writer := config.NewWriter(reposDir, codeownersFile)
+			converted, err := writer.MergeIntoReposFile(reposFile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(converted).To(Equal(1))
+			Expect(filepath.Join(reposDir, "alpha.yaml")).NotTo(BeAnExistingFile())
+
+			configs, err := config.LoadReposFile(reposFile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(configs).To(HaveLen(3))
+			Expect(configs[0].Name).To(Equal("konflux-ci/alpha"))
+			Expect(configs[0].ExcludeDirs).To(Equal([]string{"vendor/"}))
+
+			owners, err := config.LoadCodeowners(codeownersFile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(owners).NotTo(HaveKey("/repos/alpha.yaml"))
+			Expect(owners["/repos.yaml"]).To(ConsistOf("@konflux-ci/alpha", "@konflux-ci/vanguard"))
+		})
+
+		It("should split the repos file into per-repo files", func() {
+			writer := config.NewWriter(reposDir, codeownersFile)
+			converted, err := writer.SplitReposFile(reposFile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(converted).To(Equal(2))
+			Expect(reposFile).NotTo(BeAnExistingFile())
+
+			cfg, err := config.LoadRepositoryConfig(reposDir, "beta.yaml")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.Timeout).To(Equal("45m"))
+
+			owners, err := config.LoadCodeowners(codeownersFile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(owners).NotTo(HaveKey("/repos.yaml"))
+			Expect(owners["/repos/gamma.yaml"]).To(Equal([]string{"@konflux-ci/vanguard"}))
+			Expect(owners["/repos/alpha.yaml"]).To(Equal([]string{"@konflux-ci/alpha"}))
+		})
+
+		It("should not split into existing files", func() {
+			writeFile(filepath.Join(reposDir, "gamma.yaml"), "name: other-org/gamma\n")
+			writer := config.NewWriter(reposDir, codeownersFile)
+			_, err := writer.SplitReposFile(reposFile)
+			Expect(err).To(MatchError(ContainSubstring("gamma.yaml already exists")))
+			Expect(filepath.Join(reposDir, "beta.yaml")).NotTo(BeAnExistingFile())
+		})
+
+		It("should not split entries into files differing only by case", func() {
+			configs, err := config.LoadReposFile(reposFile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config.WriteReposFile(reposFile, append(configs, config.RepositoryConfig{Name: "other-org/Gamma"}))).To(Succeed())
+
+			writer := config.NewWriter(reposDir, codeownersFile)
+			_, err = writer.SplitReposFile(reposFile)
+			Expect(err).To(MatchError(ContainSubstring("would both be split into Gamma.yaml")))
+			Expect(filepath.Join(reposDir, "beta.yaml")).NotTo(BeAnExistingFile())
+		})
+	})
+})
@@ -0,0 +1,158 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-github/v66/github"
+)
+
+const (
+	// SourceScheme prefixes configuration sources in a GitHub repository
+	SourceScheme = "github://"
+
+	defaultSourceRef  = "main"
+	defaultSourcePath = "repos"
+)
+
+// Source is a directory of repository configurations in a GitHub repository at a git ref
+type Source struct {
+	Owner string
+	Repo  string
+	Ref   string
+	// Path is the directory holding the per-repo files; repos.yaml, CODEOWNERS, groups.yaml and policy.yaml are read
+	// from its parent
+	Path string
+}
+
+// LocalSource holds the local paths of configurations fetched from a Source
+type LocalSource struct {
+	ReposDir       string
+	ReposFile      string
+	CodeownersFile string
+	GroupsFile     string
+	PolicyFile     string
+}
+
+// ParseSource parses github://owner/repo[@ref][/path] or https://github.com/owner/repo/tree/ref/path
+// The ref defaults to main and the path to repos; a ref cannot contain slashes
+func ParseSource(source string) (Source, error) {
+	var rest string
+	switch {
+	case strings.HasPrefix(source, SourceScheme):
+		rest = strings.TrimPrefix(source, SourceScheme)
+	case strings.HasPrefix(source, "https://github.com/"):
+		parts := strings.SplitN(strings.TrimPrefix(source, "https://github.com/"), "/", 5)
+		if len(parts) < 4 || parts[2] != "tree" {
+			return Source{}, fmt.Errorf("GitHub URL must be in https://github.com/owner/repo/tree/ref/path form, got %q", source)
+		}
+		rest = parts[0] + "/" + parts[1] + "@" + parts[3]
+		if len(parts) == 5 {
+			rest += "/" + parts[4]
+		}
+	default:
+		return Source{}, fmt.Errorf("configuration source must start with %s or https://github.com/, got %q", SourceScheme, source)
+	}
+
+	s := Source{Ref: defaultSourceRef, Path: defaultSourcePath}
+	owner, rest, ok := strings.Cut(rest, "/")
+	if !ok || owner == "" {
+		return Source{}, fmt.Errorf("configuration source must name owner/repo, got %q", source)
+	}
+	s.Owner = owner
+
+	repo, rest, hasPath := strings.Cut(rest, "/")
+	if name, ref, hasRef := strings.Cut(repo, "@"); hasRef {
+		repo, s.Ref = name, ref
+	}
+	if repo == "" || s.Ref == "" {
+		return Source{}, fmt.Errorf("configuration source must name owner/repo, got %q", source)
+	}
+	s.Repo = repo
+	if hasPath && strings.Trim(rest, "/") != "" {
+		s.Path = strings.Trim(rest, "/")
+	}
+	return s, nil
+}
+
+// String formats the source in its github:// form
+func (s Source) String() string {
+	return fmt.Sprintf("%s%s/%s@%s/%s", SourceScheme, s.Owner, s.Repo, s.Ref, s.Path)
+}
+
+// Fetch downloads the per-repo files, repos.yaml, CODEOWNERS, groups.yaml and policy.yaml of the source into dir,
+// replacing its contents
+// Files missing from the source are missing locally too, as for a local checkout
+func (s Source) Fetch(ctx context.Context, client *github.Client, dir string) (LocalSource, error) {
+	local := LocalSource{
+		ReposDir:       filepath.Join(dir, "repos"),
+		ReposFile:      filepath.Join(dir, "repos.yaml"),
+		CodeownersFile: filepath.Join(dir, "CODEOWNERS"),
+		GroupsFile:     filepath.Join(dir, "groups.yaml"),
+		PolicyFile:     filepath.Join(dir, "policy.yaml"),
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return local, fmt.Errorf("failed to clear %s: %w", dir, err)
+	}
+	if err := os.MkdirAll(local.ReposDir, 0755); err != nil {
+		return local, fmt.Errorf("failed to create %s: %w", local.ReposDir, err)
+	}
+
+	opts := &github.RepositoryContentGetOptions{Ref: s.Ref}
+	_, entries, _, err := client.Repositories.GetContents(ctx, s.Owner, s.Repo, s.Path, opts)
+	if err != nil {
+		return local, fmt.Errorf("failed to list %s: %w", s, err)
+	}
+	for _, entry := range entries {
+		if entry.GetType() != "file" || !strings.HasSuffix(entry.GetName(), ".yaml") {
+			continue
+		}
+		if err := s.download(ctx, client, entry.GetPath(), filepath.Join(local.ReposDir, entry.GetName())); err != nil {
+			return local, err
+		}
+	}
+
+	root := path.Dir(s.Path)
+	if root == "." {
+		root = ""
+	}
+	for name, target := range map[string]string{
+		"repos.yaml":  local.ReposFile,
+		"CODEOWNERS":  local.CodeownersFile,
+		"groups.yaml": local.GroupsFile,
+		"policy.yaml": local.PolicyFile,
+	} {
+		if err := s.download(ctx, client, path.Join(root, name), target); err != nil {
+			return local, err
+		}
+	}
+	return local, nil
+}
+
+// download writes a file of the source to target; a missing file is skipped
+func (s Source) download(ctx context.Context, client *github.Client, filePath, target string) error {
+	file, _, resp, err := client.Repositories.GetContents(ctx, s.Owner, s.Repo, filePath, &github.RepositoryContentGetOptions{Ref: s.Ref})
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to download %s at %s: %w", filePath, s.Ref, err)
+	}
+	if file == nil {
+		return fmt.Errorf("%s at %s is not a file", filePath, s.Ref)
+	}
+
+	content, err := file.GetContent()
+	if err != nil {
+		return fmt.Errorf("failed to decode %s: %w", filePath, err)
+	}
+	if err := os.WriteFile(target, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", target, err)
+	}
+	return nil
+}
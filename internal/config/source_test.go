@@ -0,0 +1,122 @@
+package config_test
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/google/go-github/v66/github"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+)
+
+var _ = Describe("Source", func() {
+	Describe("ParseSource", func() {
+		It("should parse github sources with defaults", func() {
+			source, err := config.ParseSource("github://konflux-ci/coverage-dashboard")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(source).To(Equal(config.Source{Owner: "konflux-ci", Repo: "coverage-dashboard", Ref: "main", Path: "repos"}))
+		})
+
+		It("should parse a ref and a nested path", func() {
+			source, err := config.ParseSource("github://konflux-ci/coverage-dashboard@v1.2/configs/repos")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(source).To(Equal(config.Source{Owner: "konflux-ci", Repo: "coverage-dashboard", Ref: "v1.2", Path: "configs/repos"}))
+			Expect(source.String()).To(Equal("github://konflux-ci/coverage-dashboard@v1.2/configs/repos"))
+		})
+
+		It("should parse GitHub tree URLs", func() {
+			source, err := config.ParseSource("https://github.com/konflux-ci/coverage-dashboard/tree/main/repos")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(source).To(Equal(config.Source{Owner: "konflux-ci", Repo: "coverage-dashboard", Ref: "main", Path: "repos"}))
+		})
+
+		It("should reject other sources", func() {
+			for _, source := range []string{"repos/", "github://konflux-ci", "github://konflux-ci/repo@", "https://github.com/konflux-ci/repo"} {
+				_, err := config.ParseSource(source)
+				Expect(err).To(HaveOccurred(), source)
+			}
+		})
+	})
+
+	Describe("Fetch", func() {
+		var (
+			server *httptest.Server
+			client *github.Client
+		)
+
+		BeforeEach(func() {
+			file := func(path, content string) string {
+				return fmt.Sprintf(`{"type": "file", "name": %q, "path": %q, "encoding": "base64", "content": %q}`,
+					filepath.Base(path), path, base64.StdEncoding.EncodeToString([]byte(content)))
+			}
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.URL.Query().Get("ref")).To(Equal("release"))
+				switch r.URL.Path {
+				case "/repos/konflux-ci/coverage-dashboard/contents/repos":
+					fmt.Fprint(w, `[
+						{"type": "file", "name": "alpha.yaml", "path": "repos/alpha.yaml"},
+						{"type": "file", "name": "README.md", "path": "repos/README.md"},
+						{"type": "dir", "name": "nested", "path": "repos/nested"}
+					]`)
+				case "/repos/konflux-ci/coverage-dashboard/contents/repos/alpha.yaml":
+					fmt.Fprint(w, file("repos/alpha.yaml", "name: konflux-ci/alpha\n"))
+				case "/repos/konflux-ci/coverage-dashboard/contents/CODEOWNERS":
+					fmt.Fprint(w, file("CODEOWNERS", "/repos/alpha.yaml @konflux-ci/alpha\n"))
+				case "/repos/konflux-ci/coverage-dashboard/contents/groups.yaml":
+					fmt.Fprint(w, file("groups.yaml", "- name: stack\n  repos: [konflux-ci/alpha]\n"))
+				case "/repos/konflux-ci/coverage-dashboard/contents/policy.yaml":
+					fmt.Fprint(w, file("policy.yaml", "overridable: [exclude_dirs]\n"))
+				default:
+					w.WriteHeader(http.StatusNotFound)
+					fmt.Fprint(w, `{"message": "Not Found"}`)
+				}
+			}))
+			client = github.NewClient(nil)
+			client.BaseURL, _ = url.Parse(server.URL + "/")
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("should download the configurations and skip missing files", func() {
+			dir := GinkgoT().TempDir()
+			Expect(os.WriteFile(filepath.Join(dir, "stale.yaml"), []byte("name: konflux-ci/stale\n"), 0644)).To(Succeed())
+
+			source := config.Source{Owner: "konflux-ci", Repo: "coverage-dashboard", Ref: "release", Path: "repos"}
+			local, err := source.Fetch(context.Background(), client, dir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(filepath.Join(dir, "stale.yaml")).NotTo(BeAnExistingFile())
+			Expect(local.ReposFile).NotTo(BeAnExistingFile())
+
+			repositories, err := config.LoadRepositories(local.ReposDir, local.ReposFile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(repositories.Entries).To(HaveLen(1))
+			Expect(repositories.Entries[0].Config.Name).To(Equal("konflux-ci/alpha"))
+
+			owners, err := config.LoadCodeowners(local.CodeownersFile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(owners[repositories.Entries[0].CodeownersPattern()]).To(Equal([]string{"@konflux-ci/alpha"}))
+
+			groups, err := config.LoadGroups(local.GroupsFile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(groups.Of("konflux-ci/alpha")).To(Equal([]string{"stack"}))
+
+			Expect(os.ReadFile(local.PolicyFile)).To(Equal([]byte("overridable: [exclude_dirs]\n")))
+		})
+
+		It("should fail when the configuration directory does not exist", func() {
+			source := config.Source{Owner: "konflux-ci", Repo: "coverage-dashboard", Ref: "release", Path: "missing"}
+			_, err := source.Fetch(context.Background(), client, GinkgoT().TempDir())
+			Expect(err).To(MatchError(ContainSubstring("failed to list github://konflux-ci/coverage-dashboard@release/missing")))
+		})
+	})
+})
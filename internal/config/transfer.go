@@ -0,0 +1,83 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// OwnershipTransfer describes a change of a repository's owners and the files it touched
+type OwnershipTransfer struct {
+	Repo  string
+	From  []string
+	To    []string
+	Files []string
+}
+
+// TransferOwnership hands a configured repository over to new owners: its owners_override is set to them
+// and, for per-repo files, its CODEOWNERS entry too. Entries of the single repos file share the repos
+// file's CODEOWNERS entry, which is left alone
+func (w *Writer) TransferOwnership(reposFile, repo string, to []string) (OwnershipTransfer, error) {
+	transfer := OwnershipTransfer{Repo: repo, To: normalizeOwners(to)}
+	if len(transfer.To) == 0 {
+		return transfer, fmt.Errorf("no owners to transfer %s to", repo)
+	}
+
+	set, err := LoadRepositories(w.reposDir, reposFile)
+	if err != nil {
+		return transfer, err
+	}
+	index := slices.IndexFunc(set.Entries, func(e RepositoryEntry) bool { return e.Config.Name == repo })
+	if index < 0 {
+		return transfer, fmt.Errorf("%s is not configured in %s or %s", repo, w.reposDir, reposFile)
+	}
+	entry := set.Entries[index]
+
+	codeowners, err := LoadCodeowners(w.codeownersFile)
+	if err != nil && !os.IsNotExist(err) {
+		return transfer, err
+	}
+	transfer.From = normalizeOwners(entry.Owners(codeowners))
+	if slices.Equal(transfer.From, transfer.To) {
+		return transfer, fmt.Errorf("%s is already owned by %s", repo, strings.Join(transfer.To, " "))
+	}
+
+	entry.Config.OwnersOverride = transfer.To
+	// The new owners were chosen, so how discovery once detected owners no longer applies
+	entry.Config.OwnersSource, entry.Config.OwnersDetectedAt = "", nil
+	if entry.ReposFile != "" {
+		var configs []RepositoryConfig
+		for _, e := range set.Entries {
+			if e.ReposFile == "" {
+				continue
+			}
+			if e.Config.Name == repo {
+				e = entry
+			}
+			configs = append(configs, e.Config)
+		}
+		if err := WriteReposFile(entry.ReposFile, configs); err != nil {
+			return transfer, err
+		}
+		transfer.Files = []string{entry.ReposFile}
+		return transfer, nil
+	}
+
+	path := filepath.Join(w.reposDir, entry.File)
+	data, err := yaml.Marshal(entry.Config)
+	if err != nil {
+		return transfer, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return transfer, fmt.Errorf("failed to write config to %s: %w", path, err)
+	}
+	if err := w.updateCodeowners(entry.File, transfer.To); err != nil {
+		return transfer, fmt.Errorf("failed to update CODEOWNERS: %w", err)
+	}
+	transfer.Files = []string{path, w.codeownersFile}
+	return transfer, nil
+}
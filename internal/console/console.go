@@ -0,0 +1,139 @@
+// Package console filters the progress output of commands for non-interactive runs, such as CI jobs
+package console
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// WarningMarker starts the lines commands print for warnings
+const WarningMarker = "⚠️"
+
+// Writer writes output line by line, counting warning lines and rendering them in a style
+type Writer struct {
+	mu       sync.Mutex
+	out      io.Writer
+	style    Style
+	partial  []byte
+	warnings int
+}
+
+// NewWriter creates a Writer writing to out in a style
+func NewWriter(out io.Writer, style Style) *Writer {
+	return &Writer{out: out, style: style}
+}
+
+// Write writes the complete lines of p, keeping the rest until the next Write or Flush
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.partial = append(w.partial, p...)
+	for {
+		i := bytes.IndexByte(w.partial, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		line := string(w.partial[:i])
+		w.partial = w.partial[i+1:]
+		if err := w.writeLine(line + "\n"); err != nil {
+			return len(p), err
+		}
+	}
+}
+
+// Flush writes the last line when it does not end with a newline
+func (w *Writer) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.partial) == 0 {
+		return nil
+	}
+	line := string(w.partial)
+	w.partial = nil
+	return w.writeLine(line)
+}
+
+// Warnings returns the number of warning lines written
+func (w *Writer) Warnings() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.warnings
+}
+
+// writeLine counts and writes a line
+func (w *Writer) writeLine(line string) error {
+	if strings.Contains(line, WarningMarker) {
+		w.warnings++
+	}
+	_, err := io.WriteString(w.out, w.style.Render(line))
+	return err
+}
+
+// StripEmoji removes the emoji of a line and the spaces following them, keeping its indentation
+func StripEmoji(line string) string {
+	var b strings.Builder
+	afterEmoji := false
+	for _, r := range line {
+		switch {
+		case isEmoji(r):
+			afterEmoji = true
+		case afterEmoji && r == ' ':
+		default:
+			afterEmoji = false
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// isEmoji reports whether r is an emoji, or a character only modifying emoji
+// Arrows and bullets stay, as terminals without emoji render them
+func isEmoji(r rune) bool {
+	switch {
+	case r >= 0x1F000 && r <= 0x1FAFF: // Pictographs, emoticons, transport and map symbols
+	case r >= 0x2600 && r <= 0x27BF: // Miscellaneous symbols and dingbats, e.g. ✅ ⚠ ❌ ✨
+	case r >= 0x2300 && r <= 0x23FF: // Technical symbols, e.g. ⏭ ⏹ ⏱
+	case r >= 0x2B00 && r <= 0x2BFF: // e.g. ⬆ ⭐
+	case r == 0x2139 || r == 0x203C || r == 0x2049: // ℹ ‼ ⁉
+	case isModifier(r):
+	default:
+		return false
+	}
+	return true
+}
+
+// isModifier reports whether r only modifies the characters around it: the emoji presentation, joiner and keycap,
+// or another variation selector
+func isModifier(r rune) bool {
+	return r == 0xFE0F || r == 0x200D || r == 0x20E3 || unicode.Is(unicode.Variation_Selector, r)
+}
+
+// Capture redirects *file, e.g. os.Stdout, to w until the returned function is called, which restores it once
+// everything written in the meantime went through w
+func Capture(file **os.File, w *Writer) (func() error, error) {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	original := *file
+	*file = writer
+
+	copied := make(chan error, 1)
+	go func() {
+		_, err := io.Copy(w, reader)
+		copied <- err
+	}()
+	return func() error {
+		*file = original
+		closeErr := writer.Close()
+		copyErr := <-copied
+		return errors.Join(closeErr, copyErr, reader.Close(), w.Flush())
+	}, nil
+}
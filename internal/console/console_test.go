@@ -0,0 +1,94 @@
+package console_test
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/console"
+)
+
+var _ = Describe("Writer", func() {
+	DescribeTable("should strip emoji, keeping indentation, arrows and bullets",
+		func(line, expected string) {
+			Expect(console.StripEmoji(line)).To(Equal(expected))
+		},
+		Entry("leading emoji", "🔍 Konflux-CI Repository Auto-Discovery", "Konflux-CI Repository Auto-Discovery"),
+		Entry("indented warning", "  ⚠️  Warning: failed to parse repos/a.yaml", "  Warning: failed to parse repos/a.yaml"),
+		Entry("arrows", "→ Fetching Go repositories", "→ Fetching Go repositories"),
+		Entry("bullets", "  • New repositories: 2", "  • New repositories: 2"),
+		Entry("trailing emoji", "Done ✨\n", "Done \n"),
+	)
+
+	DescribeTable("should render only ASCII, tagging the emoji telling outcomes",
+		func(line, expected string) {
+			Expect(console.ASCII.Render(line)).To(Equal(expected))
+		},
+		Entry("leading emoji", "🔍 Konflux-CI Repository Auto-Discovery", "Konflux-CI Repository Auto-Discovery"),
+		Entry("indented warning", "  ⚠️  Warning: failed to parse repos/a.yaml", "  [WARN] Warning: failed to parse repos/a.yaml"),
+		Entry("success", "  ✅ Created 2/2 rename pull requests\n", "  [OK] Created 2/2 rename pull requests\n"),
+		Entry("failure", "❌ api: tests failed", "[FAIL] api: tests failed"),
+		Entry("arrows and bullets", "→ Fetching • 2 ↔️ drift", "-> Fetching * 2 <-> drift"),
+		Entry("unknown characters", "Owner: José — 3 ≥ 2", "Owner: Jos? - 3 >= 2"),
+	)
+
+	DescribeTable("should detect terminals without Unicode",
+		func(env map[string]string, expected bool) {
+			Expect(console.DetectASCII(func(name string) string { return env[name] })).To(Equal(expected))
+		},
+		Entry("UTF-8 locale", map[string]string{"LANG": "en_US.UTF-8", "TERM": "xterm-256color"}, false),
+		Entry("utf8 spelling", map[string]string{"LC_CTYPE": "C.utf8"}, false),
+		Entry("LC_ALL first", map[string]string{"LC_ALL": "C", "LANG": "en_US.UTF-8"}, true),
+		Entry("ASCII locale", map[string]string{"LANG": "POSIX"}, true),
+		Entry("no locale", map[string]string{}, false),
+		Entry("no locale on a dumb terminal", map[string]string{"TERM": "dumb"}, true),
+		Entry("dumb terminal", map[string]string{"LANG": "en_US.UTF-8", "TERM": "dumb"}, true),
+	)
+
+	It("should pick ASCII over plain", func() {
+		Expect(console.StyleOf(true, true)).To(Equal(console.ASCII))
+		Expect(console.StyleOf(false, true)).To(Equal(console.Plain))
+		Expect(console.StyleOf(false, false)).To(Equal(console.Unicode))
+	})
+
+	It("should count warning lines, including those written in parts", func() {
+		var out strings.Builder
+		w := console.NewWriter(&out, console.Plain)
+		fmt.Fprint(w, "✅ Found 3 repositories\n  ⚠️  Warn")
+		fmt.Fprint(w, "ing: rate limited\nlast")
+		Expect(w.Warnings()).To(Equal(1))
+		Expect(out.String()).To(Equal("Found 3 repositories\n  Warning: rate limited\n"))
+
+		Expect(w.Flush()).To(Succeed())
+		Expect(out.String()).To(HaveSuffix("last"))
+	})
+
+	It("should keep emoji unless plain", func() {
+		var out strings.Builder
+		w := console.NewWriter(&out, console.Unicode)
+		fmt.Fprintln(w, "  ⚠️  Skipped: no Go test files")
+		Expect(out.String()).To(Equal("  ⚠️  Skipped: no Go test files\n"))
+		Expect(w.Warnings()).To(Equal(1))
+	})
+
+	It("should capture a file until restored", func() {
+		file, err := os.CreateTemp(GinkgoT().TempDir(), "out")
+		Expect(err).NotTo(HaveOccurred())
+		target := file
+		w := console.NewWriter(file, console.Plain)
+
+		restore, err := console.Capture(&target, w)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(target).NotTo(Equal(file))
+		fmt.Fprintln(target, "🎉 All 2 pull requests created successfully!")
+		Expect(restore()).To(Succeed())
+		Expect(target).To(Equal(file))
+
+		content, err := os.ReadFile(file.Name())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(content)).To(Equal("All 2 pull requests created successfully!\n"))
+	})
+})
@@ -0,0 +1,123 @@
+package console
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Style is how the lines commands print are rendered
+type Style int
+
+const (
+	// Unicode writes lines as printed, the default
+	Unicode Style = iota
+	// Plain strips emoji, for CI logs
+	Plain
+	// ASCII writes only ASCII, for terminals and log viewers without Unicode: the emoji telling outcomes become
+	// tags such as [WARN], other emoji are stripped and the remaining characters are transliterated
+	ASCII
+)
+
+// asciiTags replace the emoji telling outcomes in ASCII, so warnings and failures stand out without them
+var asciiTags = map[rune]string{
+	'✅': "[OK]",
+	'⚠': "[WARN]",
+	'❌': "[FAIL]",
+	'⏭': "[SKIP]",
+}
+
+// asciiReplacer transliterates the characters commands print besides emoji
+var asciiReplacer = strings.NewReplacer(
+	"→", "->", "←", "<-", "↔", "<->", "•", "*", "·", "-", "—", "-", "–", "-", "…", "...",
+	"≥", ">=", "≤", "<=", "×", "x", "“", `"`, "”", `"`, "‘", "'", "’", "'", "█", "#", "░", "-",
+)
+
+// lockedTerminals are the TERM values of terminals without Unicode
+var lockedTerminals = map[string]bool{"dumb": true, "linux": true, "vt100": true, "vt102": true, "vt220": true, "ansi": true}
+
+// StyleOf returns ASCII when ascii, otherwise Plain when plain and Unicode when not
+func StyleOf(ascii, plain bool) Style {
+	switch {
+	case ascii:
+		return ASCII
+	case plain:
+		return Plain
+	default:
+		return Unicode
+	}
+}
+
+// DetectASCII reports whether the terminal described by the environment lacks Unicode: TERM names a terminal
+// without it, or the locale, the first of LC_ALL, LC_CTYPE and LANG set, has no UTF-8 charset. Without any locale
+// set, as in most containers and CI runners, Unicode is assumed
+func DetectASCII(getenv func(string) string) bool {
+	if lockedTerminals[getenv("TERM")] {
+		return true
+	}
+	for _, name := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if value := getenv(name); value != "" {
+			charset := strings.ToLower(value)
+			return !strings.Contains(charset, "utf-8") && !strings.Contains(charset, "utf8")
+		}
+	}
+	return false
+}
+
+// Render renders a line in the style
+func (s Style) Render(line string) string {
+	switch s {
+	case Plain:
+		return StripEmoji(line)
+	case ASCII:
+		return toASCII(line)
+	default:
+		return line
+	}
+}
+
+// toASCII transliterates the characters it knows, tags the emoji telling outcomes, strips the others with the
+// spaces following them, and replaces the remaining characters with ?
+func toASCII(line string) string {
+	var b strings.Builder
+	afterEmoji := false
+	for _, r := range asciiReplacer.Replace(line) {
+		tag, tagged := asciiTags[r]
+		switch {
+		// Modifiers of a transliterated character, e.g. the emoji presentation of ↔️, go with it
+		case isModifier(r):
+		case tagged:
+			b.WriteString(tag)
+			b.WriteByte(' ')
+			afterEmoji = true
+		case isEmoji(r):
+			afterEmoji = true
+		case afterEmoji && r == ' ':
+		default:
+			afterEmoji = false
+			b.WriteRune(r)
+		}
+	}
+
+	stripped := b.String()
+	if isASCII(stripped) {
+		return stripped
+	}
+	b.Reset()
+	for _, r := range stripped {
+		if r >= utf8.RuneSelf {
+			r = '?'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// isASCII reports whether s only has ASCII characters
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,208 @@
+package coverage
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// MaxLineLength bounds a profile line; longer lines are rejected rather than buffered
+const MaxLineLength = 1024 * 1024
+
+const modePrefix = "mode: "
+
+// Block is one line of a coverage profile: a range of statements and how often they ran
+type Block struct {
+	FileName  string
+	StartLine int
+	StartCol  int
+	EndLine   int
+	EndCol    int
+	NumStmt   int
+	Count     int
+}
+
+// ParseError locates a malformed line of a profile
+type ParseError struct {
+	Line int
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Parser reads a coverage profile one block at a time, holding a single line in memory
+// Merged profiles repeat blocks; the parser returns every occurrence
+type Parser struct {
+	scanner *bufio.Scanner
+	mode    string
+	line    int
+	block   Block
+	err     error
+	// files interns file names, which repeat on every block of a file
+	files map[string]string
+}
+
+// NewParser creates a parser reading a profile from r
+func NewParser(r io.Reader) *Parser {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), MaxLineLength)
+	return &Parser{scanner: scanner, files: make(map[string]string)}
+}
+
+// Mode returns the profile's mode (set, count or atomic), known once Next was called
+func (p *Parser) Mode() string {
+	return p.mode
+}
+
+// Next advances to the next block, returning false at the end of the profile or on the first error
+func (p *Parser) Next() bool {
+	if p.err != nil {
+		return false
+	}
+	for p.scanner.Scan() {
+		p.line++
+		line := p.scanner.Text()
+		if p.mode == "" {
+			mode, err := parseMode(line)
+			if err != nil {
+				p.err = &ParseError{Line: p.line, Err: err}
+				return false
+			}
+			p.mode = mode
+			continue
+		}
+		if line == "" {
+			continue
+		}
+
+		block, err := ParseLine(line)
+		if err != nil {
+			p.err = &ParseError{Line: p.line, Err: err}
+			return false
+		}
+		if name, ok := p.files[block.FileName]; ok {
+			block.FileName = name
+		} else {
+			p.files[block.FileName] = block.FileName
+		}
+		p.block = block
+		return true
+	}
+
+	switch err := p.scanner.Err(); {
+	case errors.Is(err, bufio.ErrTooLong):
+		p.err = &ParseError{Line: p.line + 1, Err: fmt.Errorf("line longer than %d bytes", MaxLineLength)}
+	case err != nil:
+		p.err = err
+	case p.mode == "":
+		p.err = &ParseError{Line: p.line + 1, Err: fmt.Errorf("missing mode line")}
+	}
+	return false
+}
+
+// Block returns the block read by the last call to Next
+func (p *Parser) Block() Block {
+	return p.block
+}
+
+// Err returns the error that stopped the parser, nil at the end of a well-formed profile
+func (p *Parser) Err() error {
+	return p.err
+}
+
+// parseMode parses the "mode: set" header of a profile
+func parseMode(line string) (string, error) {
+	mode, ok := strings.CutPrefix(line, modePrefix)
+	if !ok {
+		return "", fmt.Errorf("bad mode line %q", truncate(line))
+	}
+	switch mode {
+	case "set", "count", "atomic":
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown mode %q", truncate(mode))
+}
+
+// ParseLine parses a block line, name.go:line.column,line.column numberOfStatements count
+func ParseLine(line string) (Block, error) {
+	var block Block
+	bad := func(reason string) (Block, error) {
+		return Block{}, fmt.Errorf("bad block %q: %s", truncate(line), reason)
+	}
+
+	colon := strings.LastIndexByte(line, ':')
+	if colon <= 0 {
+		return bad("missing file name")
+	}
+	block.FileName = line[:colon]
+	fields := strings.Split(line[colon+1:], " ")
+	if len(fields) != 3 {
+		return bad("expected a range, a statement count and a count")
+	}
+	start, end, ok := strings.Cut(fields[0], ",")
+	if !ok {
+		return bad("missing range end")
+	}
+
+	var err error
+	if block.StartLine, block.StartCol, err = parsePosition(start); err != nil {
+		return bad(err.Error())
+	}
+	if block.EndLine, block.EndCol, err = parsePosition(end); err != nil {
+		return bad(err.Error())
+	}
+	if block.NumStmt, err = parseCount(fields[1]); err != nil {
+		return bad("statement count: " + err.Error())
+	}
+	if block.Count, err = parseCount(fields[2]); err != nil {
+		return bad("count: " + err.Error())
+	}
+	if block.EndLine < block.StartLine || (block.EndLine == block.StartLine && block.EndCol < block.StartCol) {
+		return bad("range ends before it starts")
+	}
+	return block, nil
+}
+
+// parsePosition parses a line.column position
+func parsePosition(position string) (line, col int, err error) {
+	l, c, ok := strings.Cut(position, ".")
+	if !ok {
+		return 0, 0, fmt.Errorf("position %q is not line.column", truncate(position))
+	}
+	if line, err = parseCount(l); err != nil {
+		return 0, 0, fmt.Errorf("line: %w", err)
+	}
+	if col, err = parseCount(c); err != nil {
+		return 0, 0, fmt.Errorf("column: %w", err)
+	}
+	return line, col, nil
+}
+
+// parseCount parses a non-negative decimal number without sign, at most the uint32 counters of the Go runtime
+func parseCount(s string) (int, error) {
+	if s == "" || strings.TrimLeft(s, "0123456789") != "" {
+		return 0, fmt.Errorf("%q is not a non-negative number", truncate(s))
+	}
+	n, err := strconv.ParseUint(s, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("%q is out of range", truncate(s))
+	}
+	return int(n), nil
+}
+
+// truncate shortens malformed input quoted in errors
+func truncate(s string) string {
+	if len(s) > 80 {
+		return s[:80] + "..."
+	}
+	return s
+}
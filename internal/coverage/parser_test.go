@@ -0,0 +1,170 @@
+package coverage_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/coverage"
+)
+
+const profile = `mode: set
+github.com/org/repo/pkg/a.go:3.14,5.2 2 1
+github.com/org/repo/pkg/a.go:7.14,7.26 1 0
+
+github.com/org/repo/cmd/main.go:4.13,7.2 3 0
+`
+
+var _ = Describe("Parser", func() {
+	parse := func(input string) ([]coverage.Block, error) {
+		parser := coverage.NewParser(strings.NewReader(input))
+		var blocks []coverage.Block
+		for parser.Next() {
+			blocks = append(blocks, parser.Block())
+		}
+		return blocks, parser.Err()
+	}
+
+	It("should read every block of a profile", func() {
+		parser := coverage.NewParser(strings.NewReader(profile))
+		Expect(parser.Next()).To(BeTrue())
+		Expect(parser.Mode()).To(Equal("set"))
+		Expect(parser.Block()).To(Equal(coverage.Block{
+			FileName: "github.com/org/repo/pkg/a.go", StartLine: 3, StartCol: 14, EndLine: 5, EndCol: 2, NumStmt: 2, Count: 1,
+		}))
+
+		blocks, err := parse(profile)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(blocks).To(HaveLen(3))
+		Expect(blocks[2].FileName).To(Equal("github.com/org/repo/cmd/main.go"))
+	})
+
+	DescribeTable("should reject malformed profiles with the line at fault",
+		func(input string, line int, reason string) {
+			_, err := parse(input)
+			var parseErr *coverage.ParseError
+			Expect(errors.As(err, &parseErr)).To(BeTrue(), "error: %v", err)
+			Expect(parseErr.Line).To(Equal(line))
+			Expect(err).To(MatchError(ContainSubstring(reason)))
+		},
+		Entry("empty profile", "", 1, "missing mode line"),
+		Entry("missing mode", "a.go:1.1,2.2 1 1\n", 1, "bad mode line"),
+		Entry("unknown mode", "mode: sometimes\n", 1, "unknown mode"),
+		Entry("truncated line", "mode: set\na.go:1.1,2.2 1 1\na.go:3.1,4\n", 3, "expected a range"),
+		Entry("truncated range", "mode: set\na.go:1.1 1 1\n", 2, "missing range end"),
+		Entry("negative count", "mode: count\na.go:1.1,2.2 1 -1\n", 2, "not a non-negative number"),
+		Entry("signed count", "mode: count\na.go:1.1,2.2 +1 1\n", 2, "not a non-negative number"),
+		Entry("overflowing count", "mode: count\na.go:1.1,2.2 1 99999999999999999999\n", 2, "out of range"),
+		Entry("backwards range", "mode: set\na.go:5.1,2.2 1 1\n", 2, "ends before it starts"),
+		Entry("missing file name", "mode: set\n:1.1,2.2 1 1\n", 2, "missing file name"),
+		Entry("line too long", "mode: set\n"+strings.Repeat("a", coverage.MaxLineLength+1)+"\n", 2, "longer than"),
+	)
+
+	It("should stop at the first error", func() {
+		parser := coverage.NewParser(strings.NewReader("mode: set\nbad\na.go:1.1,2.2 1 1\n"))
+		Expect(parser.Next()).To(BeFalse())
+		Expect(parser.Next()).To(BeFalse())
+		Expect(parser.Err()).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("Summarize", func() {
+	It("should count statements per file, merging repeated blocks", func() {
+		merged := profile + "github.com/org/repo/pkg/a.go:7.14,7.26 1 1\ngithub.com/org/repo/pkg/a.go:3.14,5.2 2 0\n"
+		files, err := coverage.Summarize(strings.NewReader(merged), nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(files).To(Equal(map[string]coverage.Counts{
+			"github.com/org/repo/pkg/a.go":    {Covered: 3, Total: 3},
+			"github.com/org/repo/cmd/main.go": {Covered: 0, Total: 3},
+		}))
+	})
+
+	It("should skip files", func() {
+		files, err := coverage.Summarize(strings.NewReader(profile), func(fileName string) bool {
+			return strings.HasSuffix(fileName, "main.go")
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(files).To(HaveLen(1))
+	})
+
+	It("should reject repeated blocks with different statement counts", func() {
+		_, err := coverage.Summarize(strings.NewReader(profile+"github.com/org/repo/cmd/main.go:4.13,7.2 4 1\n"), nil)
+		Expect(err).To(MatchError(ContainSubstring("inconsistent statement count")))
+	})
+
+	It("should stream profiles larger than what it keeps in memory", func() {
+		// A merged profile of 200k lines repeating 100 blocks, generated on the fly
+		lines := 0
+		reader := io.MultiReader(strings.NewReader("mode: count\n"), readerFunc(func(p []byte) (int, error) {
+			if lines == 200000 {
+				return 0, io.EOF
+			}
+			line := fmt.Sprintf("example.com/big/file%d.go:%d.1,%d.10 1 %d\n", lines%10, lines%100, lines%100, lines%2)
+			lines++
+			return copy(p, line), nil
+		}))
+		files, err := coverage.Summarize(reader, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(files).To(HaveLen(10))
+		Expect(files["example.com/big/file1.go"]).To(Equal(coverage.Counts{Covered: 10, Total: 10}))
+	})
+})
+
+// readerFunc adapts a function to io.Reader
+type readerFunc func(p []byte) (int, error)
+
+func (f readerFunc) Read(p []byte) (int, error) {
+	return f(p)
+}
+
+func FuzzParseLine(f *testing.F) {
+	for _, seed := range []string{
+		"github.com/org/repo/pkg/a.go:3.14,5.2 2 1",
+		"C:/repo/a.go:1.1,1.1 0 4294967295",
+		"a.go:1.1,2.2 1",
+		"a.go:1.1,2.2 1 -1",
+		"a.go:1.1,2.2 99999999999999999999 1",
+		"a.go:1.1,2.2  1 1",
+		":",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, line string) {
+		block, err := coverage.ParseLine(line)
+		if err != nil {
+			return
+		}
+		if block.FileName == "" || block.NumStmt < 0 || block.Count < 0 || block.EndLine < block.StartLine {
+			t.Fatalf("invalid block %+v parsed from %q", block, line)
+		}
+		formatted := fmt.Sprintf("%s:%d.%d,%d.%d %d %d", block.FileName, block.StartLine, block.StartCol, block.EndLine, block.EndCol, block.NumStmt, block.Count)
+		again, err := coverage.ParseLine(formatted)
+		if err != nil || again != block {
+			t.Fatalf("%q parsed as %+v but its formatting %q as %+v, %v", line, block, formatted, again, err)
+		}
+	})
+}
+
+func FuzzSummarize(f *testing.F) {
+	f.Add(profile)
+	f.Add("mode: set\na.go:1.1,2.2 1 1\na.go:1.1,2.2 1 0\n")
+	f.Add("mode: count\na.go:1.1,2.2 1 1\na.go:1.1,2.2 2 0")
+	f.Add("mode: atomic\r\na.go:1.1,2.2 1 1\r\n")
+	f.Add("mode: set\na.go:1.1,2")
+	f.Fuzz(func(t *testing.T, input string) {
+		files, err := coverage.Summarize(strings.NewReader(input), nil)
+		if err != nil {
+			return
+		}
+		for fileName, counts := range files {
+			if counts.Covered < 0 || counts.Covered > counts.Total {
+				t.Fatalf("invalid counts %+v of %s", counts, fileName)
+			}
+		}
+	})
+}
@@ -0,0 +1,59 @@
+package coverage
+
+import (
+	"fmt"
+	"io"
+)
+
+// Counts are the covered and total statements of a file
+type Counts struct {
+	Covered int
+	Total   int
+}
+
+// blockKey identifies a block across the repetitions of a merged profile
+type blockKey struct {
+	file                                 string
+	startLine, startCol, endLine, endCol int
+}
+
+// blockState is the merged state of a repeated block
+type blockState struct {
+	numStmt int
+	covered bool
+}
+
+// Summarize streams a profile and counts the statements of each file, skipping files matching skip
+// Repeated blocks of merged profiles are counted once, covered when any repetition ran, so memory
+// grows with the distinct blocks rather than the size of the profile
+func Summarize(r io.Reader, skip func(fileName string) bool) (map[string]Counts, error) {
+	parser := NewParser(r)
+	blocks := make(map[blockKey]blockState)
+	for parser.Next() {
+		block := parser.Block()
+		if skip != nil && skip(block.FileName) {
+			continue
+		}
+		key := blockKey{block.FileName, block.StartLine, block.StartCol, block.EndLine, block.EndCol}
+		state, seen := blocks[key]
+		if seen && state.numStmt != block.NumStmt {
+			return nil, fmt.Errorf("inconsistent statement count of %s:%d.%d: changed from %d to %d",
+				block.FileName, block.StartLine, block.StartCol, state.numStmt, block.NumStmt)
+		}
+		blocks[key] = blockState{numStmt: block.NumStmt, covered: state.covered || block.Count > 0}
+	}
+	if err := parser.Err(); err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]Counts)
+	for key, state := range blocks {
+		counts := files[key.file]
+		counts.Total += state.numStmt
+		if state.covered {
+			counts.Covered += state.numStmt
+		}
+		files[key.file] = counts
+	}
+	return files, nil
+}
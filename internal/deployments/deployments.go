@@ -0,0 +1,105 @@
+package deployments
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-github/v66/github"
+)
+
+const (
+	// DefaultEnvironment is the environment the dashboard site is deployed to
+	DefaultEnvironment = "coverage-dashboard"
+	// DefaultRef is the branch holding the published site
+	DefaultRef = "gh-pages"
+)
+
+// Deployment states a publish can finish with
+const (
+	StateSuccess = "success"
+	StateFailure = "failure"
+	StateError   = "error"
+)
+
+// Tracker records publishes of the dashboard site as GitHub Deployments, listed in the repository's Deployments tab
+type Tracker struct {
+	client      *github.Client
+	owner       string
+	repo        string
+	environment string
+}
+
+// NewTracker creates a Tracker for the repository, in owner/name form, deploying to environment
+func NewTracker(client *github.Client, repository, environment string) (*Tracker, error) {
+	owner, repo, ok := strings.Cut(repository, "/")
+	if !ok || owner == "" || repo == "" {
+		return nil, fmt.Errorf("repository must be in owner/name form, got %q", repository)
+	}
+	if environment == "" {
+		environment = DefaultEnvironment
+	}
+	return &Tracker{client: client, owner: owner, repo: repo, environment: environment}, nil
+}
+
+// Start creates a deployment of ref and marks it in progress, linking to the run publishing it
+// Returns the deployment ID to pass to Finish
+func (t *Tracker) Start(ctx context.Context, ref, description, runURL string) (int64, error) {
+	if ref == "" {
+		ref = DefaultRef
+	}
+	// The site branch has no status checks, and publishes must not wait for those of other refs
+	noContexts := []string{}
+	deployment, _, err := t.client.Repositories.CreateDeployment(ctx, t.owner, t.repo, &github.DeploymentRequest{
+		Ref:                   github.String(ref),
+		Environment:           github.String(t.environment),
+		Description:           github.String(description),
+		AutoMerge:             github.Bool(false),
+		RequiredContexts:      &noContexts,
+		ProductionEnvironment: github.Bool(true),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to create deployment of %s: %w", ref, err)
+	}
+
+	if err := t.setStatus(ctx, deployment.GetID(), "in_progress", "", runURL, description); err != nil {
+		return 0, err
+	}
+	return deployment.GetID(), nil
+}
+
+// Finish sets the final state of a deployment; successful deployments link the environment to siteURL
+// and mark the earlier deployments of the environment inactive
+func (t *Tracker) Finish(ctx context.Context, id int64, state, siteURL, runURL, description string) error {
+	switch state {
+	case StateSuccess, StateFailure, StateError:
+	default:
+		return fmt.Errorf("deployment state must be %s, %s or %s, got %q", StateSuccess, StateFailure, StateError, state)
+	}
+	if state != StateSuccess {
+		siteURL = ""
+	}
+	return t.setStatus(ctx, id, state, siteURL, runURL, description)
+}
+
+// setStatus adds a status to a deployment
+func (t *Tracker) setStatus(ctx context.Context, id int64, state, siteURL, runURL, description string) error {
+	request := &github.DeploymentStatusRequest{
+		State:        github.String(state),
+		Environment:  github.String(t.environment),
+		AutoInactive: github.Bool(true),
+	}
+	if siteURL != "" {
+		request.EnvironmentURL = github.String(siteURL)
+	}
+	if runURL != "" {
+		request.LogURL = github.String(runURL)
+	}
+	if description != "" {
+		request.Description = github.String(description)
+	}
+	if _, _, err := t.client.Repositories.CreateDeploymentStatus(ctx, t.owner, t.repo, id, request); err != nil {
+		return fmt.Errorf("failed to set deployment %d to %s: %w", id, state, err)
+	}
+	return nil
+}
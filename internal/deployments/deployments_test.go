@@ -0,0 +1,90 @@
+package deployments_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	"github.com/google/go-github/v66/github"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/deployments"
+)
+
+var _ = Describe("Tracker", func() {
+	var (
+		server   *httptest.Server
+		tracker  *deployments.Tracker
+		requests []map[string]any
+	)
+
+	BeforeEach(func() {
+		requests = nil
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var body map[string]any
+			Expect(json.NewDecoder(r.Body).Decode(&body)).To(Succeed())
+			requests = append(requests, body)
+
+			switch r.Method + " " + r.URL.Path {
+			case "POST /repos/konflux-ci/coverage-dashboard/deployments":
+				w.WriteHeader(http.StatusCreated)
+				fmt.Fprint(w, `{"id": 42}`)
+			case "POST /repos/konflux-ci/coverage-dashboard/deployments/42/statuses":
+				w.WriteHeader(http.StatusCreated)
+				fmt.Fprint(w, `{"id": 1}`)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprint(w, `{"message": "Not Found"}`)
+			}
+		}))
+		DeferCleanup(server.Close)
+
+		client := github.NewClient(nil)
+		client.BaseURL, _ = url.Parse(server.URL + "/")
+		var err error
+		tracker, err = deployments.NewTracker(client, "konflux-ci/coverage-dashboard", "")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should create an in-progress deployment of the site branch", func() {
+		id, err := tracker.Start(context.Background(), "", "Coverage run", "https://example.com/runs/1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(id).To(Equal(int64(42)))
+
+		Expect(requests).To(HaveLen(2))
+		Expect(requests[0]).To(HaveKeyWithValue("ref", deployments.DefaultRef))
+		Expect(requests[0]).To(HaveKeyWithValue("environment", deployments.DefaultEnvironment))
+		Expect(requests[0]).To(HaveKeyWithValue("required_contexts", BeEmpty()))
+		Expect(requests[0]).To(HaveKeyWithValue("auto_merge", false))
+		Expect(requests[1]).To(HaveKeyWithValue("state", "in_progress"))
+		Expect(requests[1]).To(HaveKeyWithValue("log_url", "https://example.com/runs/1"))
+	})
+
+	It("should link successful deployments to the site", func() {
+		Expect(tracker.Finish(context.Background(), 42, deployments.StateSuccess, "https://konflux-ci.dev/coverage-dashboard/", "", "")).To(Succeed())
+		Expect(requests[0]).To(HaveKeyWithValue("state", "success"))
+		Expect(requests[0]).To(HaveKeyWithValue("environment_url", "https://konflux-ci.dev/coverage-dashboard/"))
+		Expect(requests[0]).To(HaveKeyWithValue("auto_inactive", true))
+	})
+
+	It("should not link failed deployments to the site", func() {
+		Expect(tracker.Finish(context.Background(), 42, deployments.StateFailure, "https://konflux-ci.dev/coverage-dashboard/", "", "Publish failed")).To(Succeed())
+		Expect(requests[0]).To(HaveKeyWithValue("state", "failure"))
+		Expect(requests[0]).NotTo(HaveKey("environment_url"))
+		Expect(requests[0]).To(HaveKeyWithValue("description", "Publish failed"))
+	})
+
+	It("should reject unknown states", func() {
+		Expect(tracker.Finish(context.Background(), 42, "done", "", "", "")).To(MatchError(ContainSubstring(`got "done"`)))
+		Expect(requests).To(BeEmpty())
+	})
+
+	It("should require an owner/name repository", func() {
+		_, err := deployments.NewTracker(github.NewClient(nil), "coverage-dashboard", "")
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,50 @@
+package discover
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v66/github"
+)
+
+// activityUnits are the units of activity windows beyond those of time.ParseDuration
+var activityUnits = map[string]time.Duration{
+	"d": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour,
+}
+
+// ParseActivity parses a window of activity, a number of days (180d) or weeks (26w), or a Go duration (720h)
+func ParseActivity(s string) (time.Duration, error) {
+	var window time.Duration
+	if unit, ok := activityUnits[s[max(len(s)-1, 0):]]; ok {
+		n, err := strconv.Atoi(strings.TrimSuffix(s, s[len(s)-1:]))
+		if err != nil {
+			return 0, fmt.Errorf("invalid activity window %q: expected e.g. 180d, 26w or 720h", s)
+		}
+		window = time.Duration(n) * unit
+	} else {
+		var err error
+		if window, err = time.ParseDuration(s); err != nil {
+			return 0, fmt.Errorf("invalid activity window %q: expected e.g. 180d, 26w or 720h", s)
+		}
+	}
+	if window <= 0 {
+		return 0, fmt.Errorf("activity window %q must be positive", s)
+	}
+	return window, nil
+}
+
+// activityWindow returns MinActivity in days, as it is usually given
+func (r *Runner) activityWindow() string {
+	if days := r.config.MinActivity / activityUnits["d"]; days*activityUnits["d"] == r.config.MinActivity {
+		return fmt.Sprintf("%d days", days)
+	}
+	return r.config.MinActivity.String()
+}
+
+// stale reports whether a repository was last pushed to before a time; repositories without a known push are not
+func stale(repo *github.Repository, since time.Time) bool {
+	return repo.PushedAt != nil && repo.GetPushedAt().Before(since)
+}
@@ -0,0 +1,126 @@
+package discover
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/google/go-github/v66/github"
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+)
+
+// analysis is the outcome of analyzing one repository, with the progress output it printed
+type analysis struct {
+	cfg config.RepositoryConfig
+	err error
+	// prExists is set in apply mode when a pull request adding the repository is already open
+	prExists bool
+	// optedOut is set when the repository has OptOutFile
+	optedOut bool
+	// resumed is set when the configuration is the one an interrupted run built, from the checkpoint
+	resumed bool
+	output  bytes.Buffer
+	done    chan struct{}
+}
+
+// concurrency returns the number of repositories analyzed at once
+func (r *Runner) concurrency() int {
+	return max(r.config.Concurrency, 1)
+}
+
+// analyzeAll analyzes repositories with a bounded pool of workers, each detecting the owners of one repository at
+// a time. Progress is printed and configurations are returned in the order of repos, whatever order workers finish
+// in, so runs stay reproducible. Repositories the checkpoint of an interrupted run has are not analyzed again, and
+// those analyzed are checkpointed. It returns the repositories skipped for errors or opted out, by name
+func (r *Runner) analyzeAll(ctx context.Context, repos []*github.Repository) ([]config.RepositoryConfig, map[string]string, error) {
+	if _, err := r.loadState(); err != nil {
+		return nil, nil, err
+	}
+	analyses := make([]*analysis, len(repos))
+	jobs := make(chan int, len(repos))
+	for i, repo := range repos {
+		analyses[i] = &analysis{done: make(chan struct{})}
+		if saved, ok := r.resumedAnalysis(repo.GetName()); ok {
+			analyses[i].cfg, analyses[i].resumed = saved.Config, true
+			fmt.Fprintf(&analyses[i].output, "  ♻️  Analyzed by the interrupted run\n%s", saved.Output)
+			close(analyses[i].done)
+			continue
+		}
+		jobs <- i
+	}
+	close(jobs)
+
+	var workers sync.WaitGroup
+	// Workers stop taking repositories once interrupted; wait for those in progress before returning
+	defer workers.Wait()
+	for range min(r.concurrency(), len(repos)) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for i := range jobs {
+				if ctx.Err() == nil {
+					r.analyzeInto(ctx, repos[i], analyses[i])
+				}
+				close(analyses[i].done)
+			}
+		}()
+	}
+
+	var configs []config.RepositoryConfig
+	skipped := make(map[string]string)
+	for i, repo := range repos {
+		<-analyses[i].done
+		if ctx.Err() != nil {
+			return nil, nil, fmt.Errorf("discovery interrupted: %w", ctx.Err())
+		}
+		fmt.Printf("📦 [%d/%d] %s\n", i+1, len(repos), repo.GetName())
+		os.Stdout.Write(analyses[i].output.Bytes())
+		r.config.Output.Done(repo.GetName())
+
+		switch a := analyses[i]; {
+		case a.optedOut:
+			skipped[repo.GetName()] = "opted out with " + OptOutFile
+			r.reportSkipped(repo.GetName(), SkipOptedOut, "opted out with "+OptOutFile)
+		case a.prExists:
+			r.reportSkipped(repo.GetName(), SkipPullRequestExists, "a pull request adding it is already open")
+		case a.err != nil:
+			skipped[repo.GetName()] = a.err.Error()
+			r.reportSkipped(repo.GetName(), SkipAnalysis, a.err.Error())
+		default:
+			configs = append(configs, a.cfg)
+			r.reportNew(a.cfg)
+			if !a.resumed {
+				r.recordAnalysis(repo.GetName(), a.cfg, a.output.String())
+			}
+		}
+	}
+	return configs, skipped, nil
+}
+
+// analyzeInto analyzes a repository, buffering its progress output in a
+func (r *Runner) analyzeInto(ctx context.Context, repo *github.Repository, a *analysis) {
+	// Owners opting out are never proposed a configuration; failing to tell only warns, to not block discovery
+	optedOut, err := r.optedOut(ctx, repo)
+	if err != nil {
+		fmt.Fprintf(&a.output, "  ⚠️  Could not look for %s, assuming the repository did not opt out: %v\n", OptOutFile, err)
+	}
+	if optedOut {
+		a.optedOut = true
+		fmt.Fprintf(&a.output, "  🙈 Skipped: opted out with %s\n", OptOutFile)
+		return
+	}
+
+	// Skip if PR already exists (in --apply mode); branch name format matches pr/creator.go
+	if !r.config.DryRun && r.prAlreadyExists(ctx, fmt.Sprintf("add-repo/%s", repo.GetName())) {
+		a.prExists = true
+		fmt.Fprintf(&a.output, "  ⏭️  Skipped: PR already exists\n")
+		return
+	}
+
+	a.cfg, a.err = r.analyze(ctx, repo, &a.output)
+	if a.err != nil {
+		fmt.Fprintf(&a.output, "  ⚠️  Skipped: %v\n", a.err)
+	}
+}
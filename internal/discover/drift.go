@@ -0,0 +1,276 @@
+package discover
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/google/go-github/v66/github"
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+	"github.com/konflux-ci/coverage-dashboard/internal/pr"
+)
+
+// DriftFixer opens the pull requests adding the default excludes configurations drifted from; creators of
+// PullRequestCreator that are not one cannot fix drift
+type DriftFixer interface {
+	FixDrift(ctx context.Context, configWriter *config.Writer, reposFile, repo string, edit config.Edit) (string, error)
+}
+
+var _ DriftFixer = (*pr.Creator)(nil)
+
+// Drift is how the configuration of a tracked repository differs from the one discovery would generate for it today,
+// e.g. after the default excludes of its language improved. Owners and excludes the configuration adds are no drift
+type Drift struct {
+	Name string `json:"name"`
+	// ExcludeDirs and ExcludeFiles are the default excludes missing from the configuration, or from one of its modules
+	ExcludeDirs  []string `json:"exclude_dirs,omitempty"`
+	ExcludeFiles []string `json:"exclude_files,omitempty"`
+	// Modules are the Go modules detected in the repository that the configuration does not list
+	Modules []string `json:"modules,omitempty"`
+	// Language and Visibility are those of the repository today, when the configuration records others
+	Language   string `json:"language,omitempty"`
+	Visibility string `json:"visibility,omitempty"`
+	// PullRequest is the URL of the pull request adding the missing excludes, and PullRequestError why it could not
+	// be opened; both are empty in dry runs
+	PullRequest      string `json:"pull_request,omitempty"`
+	PullRequestError string `json:"pull_request_error,omitempty"`
+}
+
+// Excludes reports whether default excludes are missing, the drift a pull request can fix
+func (d Drift) Excludes() bool {
+	return len(d.ExcludeDirs) > 0 || len(d.ExcludeFiles) > 0
+}
+
+// describe lists the differences of the drift, one per line
+func (d Drift) describe() []string {
+	var lines []string
+	if len(d.ExcludeDirs) > 0 {
+		lines = append(lines, fmt.Sprintf("exclude_dirs missing %s", strings.Join(d.ExcludeDirs, ", ")))
+	}
+	if len(d.ExcludeFiles) > 0 {
+		lines = append(lines, fmt.Sprintf("exclude_files missing %s", strings.Join(d.ExcludeFiles, ", ")))
+	}
+	if len(d.Modules) > 0 {
+		lines = append(lines, fmt.Sprintf("modules not configured: %s", strings.Join(d.Modules, ", ")))
+	}
+	if d.Language != "" {
+		lines = append(lines, fmt.Sprintf("language is %s now, excludes not compared", d.Language))
+	}
+	if d.Visibility != "" {
+		lines = append(lines, fmt.Sprintf("visibility is %s now", d.Visibility))
+	}
+	return lines
+}
+
+// compareDefaults returns the drift of a configuration from the one generated for its repository today, nil when
+// the configuration has everything it would get
+func compareDefaults(current, fresh config.RepositoryConfig) *Drift {
+	drift := Drift{Name: current.Name}
+	if language := languageKey(fresh); language != languageKey(current) {
+		drift.Language = language
+	} else {
+		// Modules are compared by path; a configuration without modules is the root module
+		configured := make(map[string]config.ModuleConfig)
+		for _, module := range current.EffectiveModules() {
+			configured[module.Path] = module
+		}
+		for _, module := range fresh.EffectiveModules() {
+			have, ok := configured[module.Path]
+			if !ok {
+				drift.Modules = append(drift.Modules, module.Path)
+				continue
+			}
+			drift.ExcludeDirs = appendMissing(drift.ExcludeDirs, module.ExcludeDirs, have.ExcludeDirs)
+			drift.ExcludeFiles = appendMissing(drift.ExcludeFiles, module.ExcludeFiles, have.ExcludeFiles)
+		}
+	}
+	if visibility := visibilityKey(fresh); visibility != visibilityKey(current) {
+		drift.Visibility = visibility
+	}
+	if len(drift.describe()) == 0 {
+		return nil
+	}
+	return &drift
+}
+
+// appendMissing appends the patterns of want that have lacks, once
+func appendMissing(missing, want, have []string) []string {
+	for _, pattern := range want {
+		if !slices.Contains(have, pattern) && !slices.Contains(missing, pattern) {
+			missing = append(missing, pattern)
+		}
+	}
+	return missing
+}
+
+// languageKey returns the language of a configuration, Go when implicit
+func languageKey(cfg config.RepositoryConfig) string {
+	if cfg.Language == "" {
+		return config.LanguageGo
+	}
+	return cfg.Language
+}
+
+// visibilityKey returns the visibility of a configuration, public when implicit
+func visibilityKey(cfg config.RepositoryConfig) string {
+	if cfg.Visibility == "" {
+		return config.VisibilityPublic
+	}
+	return cfg.Visibility
+}
+
+// CheckDrift compares the configuration of every tracked repository in the configured languages with the one
+// discovery would generate for it today, reporting the differences. In apply mode it opens a pull request per
+// repository adding the default excludes its configuration is missing; modules, languages and visibilities are
+// left to the owners
+func (r *Runner) CheckDrift(ctx context.Context) error {
+	r.running.Lock()
+	defer r.running.Unlock()
+	r.report, r.failedPullRequests = RunReport{}, 0
+
+	fmt.Println("🔍 Konflux-CI Configuration Drift Check")
+	fmt.Println("========================================")
+	if r.config.DryRun {
+		fmt.Println("📋 Mode: DRY RUN (report only)")
+		fmt.Println("   Use --apply to create PRs adding the missing excludes")
+	} else {
+		fmt.Println("🚀 Mode: APPLY (will create PRs adding the missing excludes)")
+	}
+	fmt.Println()
+
+	// Fail fast instead of on the first push when the tokens lack permissions
+	if !r.config.DryRun {
+		fmt.Println("→ Verifying token permissions...")
+		if err := r.verifyTokens(ctx); err != nil {
+			return classify(ErrCredentials, fmt.Errorf("token verification failed:\n%w", err))
+		}
+		fmt.Println("  ✅ Tokens can read the organization and create pull requests")
+		fmt.Println()
+	}
+
+	languages := languageNames(r.config.Languages)
+	fmt.Printf("→ Fetching %s repositories from %s organization...\n", languages, r.config.Organization)
+	repos, err := r.FetchRepositories(ctx)
+	if err != nil {
+		return classify(ErrFetch, fmt.Errorf("failed to fetch repositories: %w", err))
+	}
+	r.report.Repositories = len(repos)
+
+	if err := r.loadExistingRepos(); err != nil {
+		return fmt.Errorf("failed to load existing repos: %w", err)
+	}
+	repositories, err := config.LoadRepositories(r.config.ReposDir, r.config.ReposFile)
+	if err != nil {
+		return fmt.Errorf("failed to load existing repos: %w", err)
+	}
+	configured := make(map[string]config.RepositoryConfig)
+	for _, entry := range repositories.Entries {
+		configured[entry.Config.Name] = entry.Config
+	}
+	var tracked []*github.Repository
+	for _, repo := range repos {
+		if _, ok := configured[fmt.Sprintf("%s/%s", r.config.Organization, repo.GetName())]; ok {
+			tracked = append(tracked, repo)
+		}
+	}
+	fmt.Printf("  ✅ Comparing %d tracked %s repositories with the configurations discovery generates today\n", len(tracked), languages)
+	fmt.Println()
+
+	r.config.Output.Start(len(tracked))
+	for i, repo := range tracked {
+		if ctx.Err() != nil {
+			r.config.Output.Finish()
+			return fmt.Errorf("drift check interrupted: %w", ctx.Err())
+		}
+		current := configured[fmt.Sprintf("%s/%s", r.config.Organization, repo.GetName())]
+		fmt.Printf("📦 [%d/%d] %s\n", i+1, len(tracked), repo.GetName())
+		// Only the configuration matters, not how it was found
+		fresh, err := r.analyze(ctx, repo, io.Discard)
+		if err != nil {
+			fmt.Printf("  ⚠️  Warning: could not analyze, not compared: %v\n", err)
+			r.config.Output.Done(repo.GetName())
+			continue
+		}
+		if drift := compareDefaults(current, fresh); drift == nil {
+			fmt.Println("  ✅ Up to date with the defaults")
+		} else {
+			for _, line := range drift.describe() {
+				fmt.Printf("  ↔️  %s\n", line)
+			}
+			r.report.Drift = append(r.report.Drift, *drift)
+		}
+		r.config.Output.Done(repo.GetName())
+	}
+	r.config.Output.Finish()
+	fmt.Println()
+
+	if !r.config.DryRun {
+		if err := r.fixDrift(ctx); err != nil {
+			return fmt.Errorf("failed to create drift pull requests: %w", err)
+		}
+	}
+
+	r.config.Output.Summary()
+	fmt.Println("=========================================")
+	fmt.Printf("Summary: %d of %d tracked repositories drifted from the defaults\n", len(r.report.Drift), len(tracked))
+	fmt.Println("=========================================")
+	return nil
+}
+
+// fixDrift opens one pull request per drifted configuration missing default excludes, adding them to the
+// repository-level excludes, which apply to every module
+// Failures of single pull requests are reported and skipped
+func (r *Runner) fixDrift(ctx context.Context) error {
+	var fixable []int
+	for i, drift := range r.report.Drift {
+		if drift.Excludes() {
+			fixable = append(fixable, i)
+		}
+	}
+	if len(fixable) == 0 {
+		return nil
+	}
+
+	fmt.Printf("🔀 Creating %d drift pull requests...\n", len(fixable))
+
+	prCreator, err := r.pullRequestCreator(ctx)
+	if err != nil {
+		return err
+	}
+	fixer, ok := prCreator.(DriftFixer)
+	if !ok {
+		return fmt.Errorf("the pull request creator cannot fix drift")
+	}
+
+	successCount := 0
+	for n, i := range fixable {
+		if ctx.Err() != nil {
+			return fmt.Errorf("interrupted after %d/%d pull requests: %w", successCount, len(fixable), ctx.Err())
+		}
+		drift := &r.report.Drift[i]
+		fmt.Printf("  [%d/%d] %s... ", n+1, len(fixable), extractRepoNameFromConfig(drift.Name))
+		if r.prAlreadyExists(ctx, pr.DriftBranch(drift.Name)) {
+			fmt.Println("skipped (PR already exists)")
+			continue
+		}
+		url, err := fixer.FixDrift(ctx, r.configWriter, r.config.ReposFile, drift.Name, config.Edit{
+			AddExcludeDirs:  drift.ExcludeDirs,
+			AddExcludeFiles: drift.ExcludeFiles,
+		})
+		if err != nil {
+			fmt.Printf("failed (%v)\n", err)
+			drift.PullRequestError = err.Error()
+			r.failedPullRequests++
+			continue
+		}
+		fmt.Println(url)
+		drift.PullRequest = url
+		successCount++
+	}
+	fmt.Printf("  ✅ Created %d/%d drift pull requests\n", successCount, len(fixable))
+	fmt.Println()
+
+	return nil
+}
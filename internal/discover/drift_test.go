@@ -0,0 +1,92 @@
+package discover_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v3"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/discover"
+)
+
+var _ = Describe("CheckDrift", func() {
+	var (
+		server   *httptest.Server
+		reposDir string
+		runner   *discover.Runner
+	)
+
+	BeforeEach(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.URL.Path).To(Equal("/orgs/test-org/repos"))
+			fmt.Fprint(w, `[
+				{"name": "api", "language": "Go", "private": true},
+				{"name": "current", "language": "Go"},
+				{"name": "new", "language": "Go"}
+			]`)
+		}))
+		tempDir := GinkgoT().TempDir()
+		reposDir = filepath.Join(tempDir, "repos")
+		Expect(os.MkdirAll(reposDir, 0755)).To(Succeed())
+		runner = discover.NewRunnerWithDependencies(discover.Config{
+			Organization:   "test-org",
+			ReposDir:       reposDir,
+			CodeownersFile: filepath.Join(tempDir, "CODEOWNERS"),
+			DryRun:         true,
+		}, discover.Dependencies{
+			ReadClient:   githubClient(server),
+			Owners:       staticOwners{owners: []string{"@test-org/api-team"}},
+			PullRequests: &recordingCreator{},
+		})
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should report the defaults tracked configurations are missing, ignoring their own excludes", func() {
+		ctx := context.Background()
+		repos, err := runner.FetchRepositories(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		// A configuration generated today has not drifted, even with owners and excludes of its own
+		current, err := runner.Analyze(ctx, repos[1])
+		Expect(err).NotTo(HaveOccurred())
+		current.Owners = []string{"@test-org/someone-else"}
+		current.ExcludeDirs = append(current.ExcludeDirs, "generated/")
+		data, err := yaml.Marshal(current)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.WriteFile(filepath.Join(reposDir, "current.yaml"), data, 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(reposDir, "api.yaml"), []byte(`name: test-org/api
+exclude_dirs:
+  - vendor/
+  - hack/
+  - internal/legacy/
+exclude_files:
+  - zz_generated.deepcopy.go
+  - openapi_generated.go
+  - "*.pb.go"
+  - mock_*.go
+`), 0644)).To(Succeed())
+
+		Expect(runner.CheckDrift(ctx)).To(Succeed())
+
+		report := runner.Report(nil)
+		Expect(report.Tracked).To(Equal(2))
+		Expect(report.Drift).To(HaveLen(1))
+		drift := report.Drift[0]
+		Expect(drift.Name).To(Equal("test-org/api"))
+		Expect(drift.ExcludeDirs).To(ContainElements(".github/", "test/", "docs/"))
+		Expect(drift.ExcludeDirs).NotTo(ContainElements("vendor/", "hack/"))
+		Expect(drift.ExcludeFiles).To(Equal([]string{"*_mock.go"}))
+		Expect(drift.Visibility).To(Equal("private"))
+		Expect(drift.Modules).To(BeEmpty())
+		Expect(drift.PullRequest).To(BeEmpty())
+		Expect(runner.FailedPullRequests()).To(BeZero())
+	})
+})
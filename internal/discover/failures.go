@@ -0,0 +1,30 @@
+package discover
+
+import "errors"
+
+// Classes of the errors of discovery, telling automation why a run failed without parsing messages
+var (
+	// ErrCredentials is the class of errors of missing tokens and of tokens lacking permissions
+	ErrCredentials = errors.New("insufficient credentials")
+	// ErrFetch is the class of errors listing the organization's repositories
+	ErrFetch = errors.New("failed to fetch repositories")
+)
+
+// classified is an error of a class, keeping its own message
+type classified struct {
+	class error
+	err   error
+}
+
+// classify tags err with class, so errors.Is(err, class) holds
+func classify(class, err error) error {
+	return &classified{class: class, err: err}
+}
+
+func (c *classified) Error() string {
+	return c.err.Error()
+}
+
+func (c *classified) Unwrap() []error {
+	return []error{c.class, c.err}
+}
@@ -0,0 +1,515 @@
+package discover
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v66/github"
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+	"github.com/konflux-ci/coverage-dashboard/internal/httpcache"
+	"github.com/konflux-ci/coverage-dashboard/internal/ownership"
+	"github.com/konflux-ci/coverage-dashboard/internal/pr"
+)
+
+const (
+	// GitLabTokenEnv is the environment variable of the GitLab token, reading groups and opening merge requests
+	GitLabTokenEnv = "GITLAB_TOKEN"
+	// DefaultGitLabURL is the GitLab instance of ProviderGitLab unless another is configured
+	DefaultGitLabURL = "https://gitlab.com"
+)
+
+// gitLabPageSize is the number of items per page of GitLab API listings, the most GitLab allows
+const gitLabPageSize = 100
+
+// maxGitLabTreePages bounds the pages of files listed per repository; larger trees are reported truncated,
+// like GitHub does for its own
+const maxGitLabTreePages = 50
+
+// gitLabDeveloperAccess is the access level of GitLab members allowed to push branches
+const gitLabDeveloperAccess = 30
+
+// gitLabCodeownersPaths are the locations GitLab reads CODEOWNERS files from, in its priority order
+var gitLabCodeownersPaths = []string{"CODEOWNERS", "docs/CODEOWNERS", ".gitlab/CODEOWNERS"}
+
+// GitLab is the Provider of the projects of GitLab groups, through the REST API of a GitLab instance
+// Groups stand in for organizations and their projects for repositories; projects of subgroups are not listed
+type GitLab struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+// NewGitLab creates a provider for the GitLab instance at baseURL, DefaultGitLabURL when empty, authenticated
+// with token unless empty and sending its requests through transport, http.DefaultTransport when nil
+func NewGitLab(baseURL, token string, transport http.RoundTripper) *GitLab {
+	if baseURL == "" {
+		baseURL = DefaultGitLabURL
+	}
+	return &GitLab{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		token:   token,
+		client:  &http.Client{Transport: transport},
+	}
+}
+
+// newGitLabRunner creates a Runner discovering the projects of a GitLab group, with GITLAB_TOKEN
+func newGitLabRunner(cfg Config, transport http.RoundTripper, cache *httpcache.Transport) (*Runner, error) {
+	token := os.Getenv(GitLabTokenEnv)
+	switch {
+	case token == "" && !cfg.DryRun:
+		return nil, classify(ErrCredentials, fmt.Errorf("%s is required for --apply with --provider %s (needed for creating merge requests)", GitLabTokenEnv, ProviderGitLab))
+	case token == "" && !cfg.Offline:
+		fmt.Printf("⚠️  Warning: %s not set, using unauthenticated API calls\n", GitLabTokenEnv)
+		fmt.Println("   Only public projects will be discovered")
+		fmt.Println()
+	}
+
+	gitLab := NewGitLab(cfg.GitLabURL, token, transport)
+	runner := NewRunnerWithDependencies(cfg, Dependencies{
+		Provider: gitLab,
+		Owners:   gitLab.OwnerDetector(cfg.DefaultOwner),
+	})
+	runner.cache = cache
+	return runner, nil
+}
+
+// gitLabError is an error response of the GitLab API
+type gitLabError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *gitLabError) Error() string {
+	return fmt.Sprintf("GitLab API error: %d %s", e.StatusCode, e.Message)
+}
+
+// isNotFound reports whether err is a GitLab API response for a missing resource
+func isNotFound(err error) bool {
+	var apiErr *gitLabError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// do sends a request to the GitLab API at path, relative to /api/v4/, decoding its JSON response into v unless
+// nil, and returns the response's next page, zero on the last one
+func (g *GitLab) do(ctx context.Context, method, path string, query url.Values, body, v any) (int, error) {
+	endpoint := g.baseURL + "/api/v4/" + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return 0, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if g.token != "" {
+		req.Header.Set("PRIVATE-TOKEN", g.token)
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read response of %s %s: %w", method, path, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		// Messages are strings, or objects of the invalid fields
+		var message struct {
+			Message any `json:"message"`
+			Error   any `json:"error"`
+		}
+		apiErr := &gitLabError{StatusCode: resp.StatusCode, Message: http.StatusText(resp.StatusCode)}
+		if json.Unmarshal(data, &message) == nil {
+			switch {
+			case message.Message != nil:
+				apiErr.Message = fmt.Sprint(message.Message)
+			case message.Error != nil:
+				apiErr.Message = fmt.Sprint(message.Error)
+			}
+		}
+		return 0, apiErr
+	}
+
+	if v != nil {
+		if raw, ok := v.(*[]byte); ok {
+			*raw = data
+		} else if err := json.Unmarshal(data, v); err != nil {
+			return 0, fmt.Errorf("failed to parse response of %s %s: %w", method, path, err)
+		}
+	}
+	next, _ := strconv.Atoi(resp.Header.Get("X-Next-Page"))
+	return next, nil
+}
+
+// projectPath is the URL-encoded path of a project of a group, as the API takes it instead of its ID
+func projectPath(group, project string) string {
+	return url.PathEscape(group + "/" + project)
+}
+
+// gitLabProject is a project of a group listing
+type gitLabProject struct {
+	Path          string `json:"path"`
+	Archived      bool   `json:"archived"`
+	Visibility    string `json:"visibility"`
+	DefaultBranch string `json:"default_branch"`
+	// LastActivityAt is the last push, or the last activity on its issues and merge requests
+	LastActivityAt *time.Time `json:"last_activity_at"`
+	// ForkedFromProject is set for forks of projects the token can see
+	ForkedFromProject *struct {
+		ID int `json:"id"`
+	} `json:"forked_from_project"`
+}
+
+// ListRepositories lists the projects of a group, with the language of most of the code of each
+func (g *GitLab) ListRepositories(ctx context.Context, group string) ([]*github.Repository, error) {
+	query := url.Values{
+		"per_page": {strconv.Itoa(gitLabPageSize)},
+		"order_by": {"path"},
+		"sort":     {"asc"},
+	}
+	var repos []*github.Repository
+	for page := 1; page != 0; {
+		query.Set("page", strconv.Itoa(page))
+		var projects []gitLabProject
+		next, err := g.do(ctx, http.MethodGet, "groups/"+url.PathEscape(group)+"/projects", query, nil, &projects)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list projects of %s: %w", group, err)
+		}
+
+		for _, project := range projects {
+			repo := &github.Repository{
+				Name:       github.String(project.Path),
+				Archived:   github.Bool(project.Archived),
+				Fork:       github.Bool(project.ForkedFromProject != nil),
+				Visibility: github.String(project.Visibility),
+			}
+			if project.DefaultBranch != "" {
+				repo.DefaultBranch = github.String(project.DefaultBranch)
+			}
+			if project.LastActivityAt != nil {
+				repo.PushedAt = &github.Timestamp{Time: *project.LastActivityAt}
+			}
+			// The language of archived projects does not matter
+			if !project.Archived {
+				language, err := g.language(ctx, group, project.Path)
+				if err != nil {
+					return nil, err
+				}
+				repo.Language = github.String(language)
+			}
+			repos = append(repos, repo)
+		}
+		page = next
+	}
+	return repos, nil
+}
+
+// language returns the language of most of the code of a project, empty for projects without code
+// GitLab has no primary language, only the share of each
+func (g *GitLab) language(ctx context.Context, group, project string) (string, error) {
+	var shares map[string]float64
+	if _, err := g.do(ctx, http.MethodGet, "projects/"+projectPath(group, project)+"/languages", nil, nil, &shares); err != nil {
+		return "", fmt.Errorf("failed to get languages of %s: %w", project, err)
+	}
+	names := make([]string, 0, len(shares))
+	for name := range shares {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	language := ""
+	for _, name := range names {
+		if language == "" || shares[name] > shares[language] {
+			language = name
+		}
+	}
+	return language, nil
+}
+
+// gitLabTreeEntry is an entry of a repository tree listing, a blob or a tree
+type gitLabTreeEntry struct {
+	Path string `json:"path"`
+	Type string `json:"type"`
+}
+
+// Tree lists the files of the default branch of a project, truncated beyond maxGitLabTreePages pages
+func (g *GitLab) Tree(ctx context.Context, group string, repo *github.Repository) (*github.Tree, error) {
+	query := url.Values{
+		"recursive": {"true"},
+		"per_page":  {strconv.Itoa(gitLabPageSize)},
+	}
+	if branch := repo.GetDefaultBranch(); branch != "" {
+		query.Set("ref", branch)
+	}
+
+	tree := &github.Tree{Truncated: github.Bool(false)}
+	for page := 1; page != 0; {
+		if page > maxGitLabTreePages {
+			tree.Truncated = github.Bool(true)
+			break
+		}
+		query.Set("page", strconv.Itoa(page))
+		var entries []gitLabTreeEntry
+		next, err := g.do(ctx, http.MethodGet, "projects/"+projectPath(group, repo.GetName())+"/repository/tree", query, nil, &entries)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list files of %s: %w", repo.GetName(), err)
+		}
+		for _, entry := range entries {
+			tree.Entries = append(tree.Entries, &github.TreeEntry{Path: github.String(entry.Path), Type: github.String(entry.Type)})
+		}
+		page = next
+	}
+	return tree, nil
+}
+
+// File returns the content of a file of the default branch of a project
+func (g *GitLab) File(ctx context.Context, group string, repo *github.Repository, path string) ([]byte, error) {
+	ref := repo.GetDefaultBranch()
+	if ref == "" {
+		ref = "HEAD"
+	}
+	var content []byte
+	file := "projects/" + projectPath(group, repo.GetName()) + "/repository/files/" + url.PathEscape(path) + "/raw"
+	_, err := g.do(ctx, http.MethodGet, file, url.Values{"ref": {ref}}, nil, &content)
+	switch {
+	case isNotFound(err):
+		return nil, fmt.Errorf("%s of %s: %w", path, repo.GetName(), fs.ErrNotExist)
+	case err != nil:
+		return nil, fmt.Errorf("failed to fetch %s of %s: %w", path, repo.GetName(), err)
+	}
+	return content, nil
+}
+
+// Codeowners returns the CODEOWNERS files of the default branch of a project, in the priority order of GitLab,
+// skipping missing and oversized ones
+func (g *GitLab) Codeowners(ctx context.Context, group, project string) ([]string, error) {
+	var codeowners []string
+	for _, path := range gitLabCodeownersPaths {
+		var content []byte
+		file := "projects/" + projectPath(group, project) + "/repository/files/" + url.PathEscape(path) + "/raw"
+		_, err := g.do(ctx, http.MethodGet, file, url.Values{"ref": {"HEAD"}}, nil, &content)
+		switch {
+		case isNotFound(err):
+			continue
+		case err != nil:
+			return nil, fmt.Errorf("failed to fetch %s of %s: %w", path, project, err)
+		case len(content) > config.MaxCodeownersSize:
+			continue
+		}
+		codeowners = append(codeowners, string(content))
+	}
+	return codeowners, nil
+}
+
+// DefaultBranch returns the default branch of a project, main when it has none
+func (g *GitLab) DefaultBranch(ctx context.Context, group, project string) (string, error) {
+	var info gitLabProject
+	if _, err := g.do(ctx, http.MethodGet, "projects/"+projectPath(group, project), nil, nil, &info); err != nil {
+		return "", fmt.Errorf("failed to get project info: %w", err)
+	}
+	if info.DefaultBranch == "" {
+		return "main", nil
+	}
+	return info.DefaultBranch, nil
+}
+
+// HasOpenPullRequest reports whether a branch of a project has an open merge request into base
+func (g *GitLab) HasOpenPullRequest(ctx context.Context, group, project, branch, base string) (bool, error) {
+	query := url.Values{"state": {"opened"}, "source_branch": {branch}, "target_branch": {base}}
+	var requests []json.RawMessage
+	if _, err := g.do(ctx, http.MethodGet, "projects/"+projectPath(group, project)+"/merge_requests", query, nil, &requests); err != nil {
+		return false, err
+	}
+	return len(requests) > 0, nil
+}
+
+// Creator returns a creator of merge requests on a project checked out in workDir
+func (g *GitLab) Creator(group, project, workDir, baseBranch string) *pr.Creator {
+	return pr.NewCreatorWithOpener(&gitLabOpener{gitLab: g, project: projectPath(group, project)}, workDir, baseBranch)
+}
+
+// Repository returns a project of a group by path, with its group as owner; GitLab redirects the old paths of
+// renamed projects to them
+func (g *GitLab) Repository(ctx context.Context, group, project string) (*github.Repository, error) {
+	var info struct {
+		gitLabProject
+		Namespace struct {
+			FullPath string `json:"full_path"`
+		} `json:"namespace"`
+	}
+	_, err := g.do(ctx, http.MethodGet, "projects/"+projectPath(group, project), nil, nil, &info)
+	switch {
+	case isNotFound(err):
+		return nil, fmt.Errorf("%s/%s: %w", group, project, fs.ErrNotExist)
+	case err != nil:
+		return nil, fmt.Errorf("failed to get %s/%s: %w", group, project, err)
+	}
+	return &github.Repository{
+		Name:       github.String(info.Path),
+		Owner:      &github.User{Login: github.String(info.Namespace.FullPath)},
+		Archived:   github.Bool(info.Archived),
+		Visibility: github.String(info.Visibility),
+	}, nil
+}
+
+// TeamMembers lists the usernames of the members of a subgroup of a group, including those inherited from the group
+func (g *GitLab) TeamMembers(ctx context.Context, group, subgroup string) ([]string, error) {
+	query := url.Values{"per_page": {strconv.Itoa(gitLabPageSize)}}
+	var usernames []string
+	for page := 1; page != 0; {
+		query.Set("page", strconv.Itoa(page))
+		var members []struct {
+			Username string `json:"username"`
+		}
+		next, err := g.do(ctx, http.MethodGet, "groups/"+url.PathEscape(group+"/"+subgroup)+"/members/all", query, nil, &members)
+		if err != nil {
+			return nil, err
+		}
+		for _, member := range members {
+			usernames = append(usernames, member.Username)
+		}
+		page = next
+	}
+	return usernames, nil
+}
+
+// Verify checks the token can read the group and, unless project is empty, push branches to the project
+func (g *GitLab) Verify(ctx context.Context, group, project string) error {
+	var problems []error
+	if g.token == "" {
+		problems = append(problems, fmt.Errorf("  - %s is not set", GitLabTokenEnv))
+	} else if _, err := g.do(ctx, http.MethodGet, "user", nil, nil, nil); err != nil {
+		problems = append(problems, fmt.Errorf("  - %s: %w", GitLabTokenEnv, err))
+	}
+	if _, err := g.do(ctx, http.MethodGet, "groups/"+url.PathEscape(group), url.Values{"with_projects": {"false"}}, nil, nil); err != nil {
+		problems = append(problems, fmt.Errorf("  - %s: cannot read group %s: %w", GitLabTokenEnv, group, err))
+	}
+
+	if project != "" {
+		var info struct {
+			Permissions struct {
+				ProjectAccess *struct {
+					AccessLevel int `json:"access_level"`
+				} `json:"project_access"`
+				GroupAccess *struct {
+					AccessLevel int `json:"access_level"`
+				} `json:"group_access"`
+			} `json:"permissions"`
+		}
+		if _, err := g.do(ctx, http.MethodGet, "projects/"+projectPath(group, project), nil, nil, &info); err != nil {
+			problems = append(problems, fmt.Errorf("  - %s: cannot read project %s/%s: %w", GitLabTokenEnv, group, project, err))
+		} else {
+			access := 0
+			if info.Permissions.ProjectAccess != nil {
+				access = info.Permissions.ProjectAccess.AccessLevel
+			}
+			if info.Permissions.GroupAccess != nil {
+				access = max(access, info.Permissions.GroupAccess.AccessLevel)
+			}
+			if access < gitLabDeveloperAccess {
+				problems = append(problems, fmt.Errorf("  - %s: token cannot push branches to %s/%s", GitLabTokenEnv, group, project))
+			}
+		}
+	}
+	return errors.Join(problems...)
+}
+
+// OwnerDetector returns a detector of the owners of projects from their CODEOWNERS files, falling back to
+// defaultOwner, ownership.DefaultOwner when empty
+// GitLab's access levels tell maintainers rather than owners, so they are not used
+func (g *GitLab) OwnerDetector(defaultOwner string) OwnerDetector {
+	if defaultOwner == "" {
+		defaultOwner = ownership.DefaultOwner
+	}
+	return &gitLabOwners{gitLab: g, defaultOwner: defaultOwner}
+}
+
+// gitLabOwners detects the owners of GitLab projects from their CODEOWNERS files
+type gitLabOwners struct {
+	gitLab       *GitLab
+	defaultOwner string
+}
+
+// Detect detects the owners of a project from its CODEOWNERS files, falling back to the default owner
+func (d *gitLabOwners) Detect(ctx context.Context, group, project string) (ownership.Detection, error) {
+	codeowners, err := d.gitLab.Codeowners(ctx, group, project)
+	if err != nil {
+		return ownership.Detection{}, err
+	}
+	if owners := ownership.CodeownersOwners(codeowners); len(owners) > 0 {
+		return ownership.Detection{Owners: owners, Source: config.OwnersSourceCodeowners}, nil
+	}
+	return ownership.Detection{Owners: []string{d.defaultOwner}, Source: config.OwnersSourceDefault}, nil
+}
+
+// gitLabOpener opens merge requests on a GitLab project
+type gitLabOpener struct {
+	gitLab  *GitLab
+	project string
+}
+
+// Open opens a merge request on GitLab, asking the owners that are users to review it
+// Groups cannot review merge requests; they are notified through the project's CODEOWNERS instead
+func (o *gitLabOpener) Open(ctx context.Context, request pr.Request) (string, error) {
+	body := map[string]any{
+		"source_branch":        request.Branch,
+		"target_branch":        request.Base,
+		"title":                request.Title,
+		"description":          request.Body,
+		"remove_source_branch": true,
+	}
+	if reviewers := o.reviewerIDs(ctx, request.Owners); len(reviewers) > 0 {
+		body["reviewer_ids"] = reviewers
+	}
+
+	var created struct {
+		WebURL string `json:"web_url"`
+	}
+	if _, err := o.gitLab.do(ctx, http.MethodPost, "projects/"+o.project+"/merge_requests", nil, body, &created); err != nil {
+		return "", err
+	}
+	return created.WebURL, nil
+}
+
+// reviewerIDs looks up the IDs of the owners that are users, warning about those that cannot be found
+func (o *gitLabOpener) reviewerIDs(ctx context.Context, owners []string) []int {
+	var ids []int
+	for _, owner := range owners {
+		username := strings.TrimPrefix(owner, "@")
+		if username == "" || strings.Contains(username, "/") {
+			continue
+		}
+		var users []struct {
+			ID int `json:"id"`
+		}
+		if _, err := o.gitLab.do(ctx, http.MethodGet, "users", url.Values{"username": {username}}, nil, &users); err != nil || len(users) == 0 {
+			fmt.Printf("    ⚠️  Warning: failed to add reviewer %s\n", owner)
+			continue
+		}
+		ids = append(ids, users[0].ID)
+	}
+	return ids
+}
@@ -0,0 +1,73 @@
+package discover
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/pr"
+)
+
+var _ = Describe("gitLabOpener", func() {
+	var (
+		server  *httptest.Server
+		opener  *gitLabOpener
+		created map[string]any
+		exists  bool
+	)
+
+	BeforeEach(func() {
+		created, exists = nil, false
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch path := r.URL.EscapedPath(); {
+			case path == "/api/v4/users" && r.URL.Query().Get("username") == "alice":
+				fmt.Fprint(w, `[{"id": 42, "username": "alice"}]`)
+			case path == "/api/v4/users":
+				fmt.Fprint(w, `[]`)
+			case r.Method == http.MethodPost && path == "/api/v4/projects/test-group%2Fcoverage-dashboard/merge_requests":
+				if exists {
+					w.WriteHeader(http.StatusConflict)
+					fmt.Fprint(w, `{"message": ["Another open merge request already exists for this source branch: !3"]}`)
+					return
+				}
+				Expect(json.NewDecoder(r.Body).Decode(&created)).To(Succeed())
+				w.WriteHeader(http.StatusCreated)
+				fmt.Fprint(w, `{"iid": 3, "web_url": "https://gitlab.example.com/test-group/coverage-dashboard/-/merge_requests/3"}`)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		opener = &gitLabOpener{gitLab: NewGitLab(server.URL, "secret", nil), project: projectPath("test-group", "coverage-dashboard")}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	request := pr.Request{
+		Branch: "add-repo/test-group-api",
+		Base:   "main",
+		Title:  "chore: add coverage tracking for api",
+		Owners: []string{"@test-group/backend", "@alice", "@gone"},
+	}
+
+	It("should open merge requests asking the owners that are users to review", func() {
+		url, err := opener.Open(context.Background(), request)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(url).To(Equal("https://gitlab.example.com/test-group/coverage-dashboard/-/merge_requests/3"))
+		Expect(created).To(HaveKeyWithValue("source_branch", "add-repo/test-group-api"))
+		Expect(created).To(HaveKeyWithValue("target_branch", "main"))
+		Expect(created).To(HaveKeyWithValue("reviewer_ids", []any{float64(42)}))
+	})
+
+	It("should report merge requests that already exist", func() {
+		exists = true
+		_, err := opener.Open(context.Background(), request)
+		Expect(err).To(MatchError(ContainSubstring("already exists")))
+	})
+})
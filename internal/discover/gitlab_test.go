@@ -0,0 +1,129 @@
+package discover_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+	"github.com/konflux-ci/coverage-dashboard/internal/discover"
+)
+
+var _ = Describe("GitLab discovery", func() {
+	var (
+		tempDir string
+		server  *httptest.Server
+		gitLab  *discover.GitLab
+		runner  *discover.Runner
+	)
+
+	BeforeEach(func() {
+		tempDir = GinkgoT().TempDir()
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.Header.Get("PRIVATE-TOKEN")).To(Equal("secret"))
+			switch path := r.URL.EscapedPath(); {
+			case path == "/api/v4/groups/test-group/projects" && r.URL.Query().Get("page") == "1":
+				w.Header().Set("X-Next-Page", "2")
+				fmt.Fprint(w, `[
+					{"path": "api", "visibility": "internal", "default_branch": "main"},
+					{"path": "mirror", "visibility": "public", "forked_from_project": {"id": 7}}
+				]`)
+			case path == "/api/v4/groups/test-group/projects":
+				Expect(r.URL.Query().Get("page")).To(Equal("2"))
+				fmt.Fprint(w, `[
+					{"path": "old", "archived": true},
+					{"path": "ui", "visibility": "public"}
+				]`)
+			case path == "/api/v4/projects/test-group%2Fapi/languages":
+				fmt.Fprint(w, `{"Go": 81.5, "Shell": 18.5}`)
+			case path == "/api/v4/projects/test-group%2Fmirror/languages":
+				fmt.Fprint(w, `{"Go": 100}`)
+			case path == "/api/v4/projects/test-group%2Fui/languages":
+				fmt.Fprint(w, `{"Go": 10, "TypeScript": 90}`)
+			case path == "/api/v4/projects/test-group%2Fapi/repository/tree":
+				Expect(r.URL.Query().Get("ref")).To(Equal("main"))
+				fmt.Fprint(w, `[
+					{"path": "go.mod", "type": "blob"},
+					{"path": "server/server_test.go", "type": "blob"},
+					{"path": "tools", "type": "tree"},
+					{"path": "tools/go.mod", "type": "blob"}
+				]`)
+			case path == "/api/v4/projects/test-group%2Fapi/repository/files/.gitlab%2FCODEOWNERS/raw":
+				fmt.Fprint(w, "[Backend]\n* @test-group/backend @alice\n")
+			default:
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprint(w, `{"message": "404 Not Found"}`)
+			}
+		}))
+
+		reposDir := filepath.Join(tempDir, "repos")
+		Expect(os.MkdirAll(reposDir, 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(reposDir, "old.yaml"), []byte("name: test-group/old\n"), 0644)).To(Succeed())
+
+		gitLab = discover.NewGitLab(server.URL, "secret", nil)
+		runner = discover.NewRunnerWithDependencies(discover.Config{
+			Organization:  "test-group",
+			ReposDir:      reposDir,
+			Untested:      discover.UntestedFlag,
+			DetectModules: true,
+			IncludeForks:  true,
+		}, discover.Dependencies{Provider: gitLab, Owners: gitLab.OwnerDetector("")})
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should list the projects of the group in the configured languages", func() {
+		repos, err := runner.FetchRepositories(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(repos).To(HaveLen(2))
+		Expect(repos[0].GetName()).To(Equal("api"))
+		Expect(repos[0].GetLanguage()).To(Equal("Go"))
+		Expect(repos[1].GetName()).To(Equal("mirror"))
+		Expect(repos[1].GetFork()).To(BeTrue())
+
+		_, err = runner.FilterNew(repos)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(runner.FindArchived()).To(Equal([]string{"test-group/old"}))
+	})
+
+	It("should configure projects from their files and GitLab CODEOWNERS", func() {
+		repos, err := runner.FetchRepositories(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+
+		cfg, err := runner.Analyze(context.Background(), repos[0])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg.Name).To(Equal("test-group/api"))
+		Expect(cfg.Owners).To(Equal([]string{"@test-group/backend", "@alice"}))
+		Expect(cfg.OwnersSource).To(Equal(config.OwnersSourceCodeowners))
+		Expect(cfg.Visibility).To(Equal(config.VisibilityInternal))
+		Expect(cfg.NoTests).To(BeFalse())
+		Expect(cfg.Modules).To(HaveLen(2))
+	})
+
+	It("should fall back to the default owner without CODEOWNERS", func() {
+		detection, err := gitLab.OwnerDetector("@test-group/leads").Detect(context.Background(), "test-group", "mirror")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(detection.Owners).To(Equal([]string{"@test-group/leads"}))
+		Expect(detection.Source).To(Equal(config.OwnersSourceDefault))
+	})
+
+	It("should require a token to open merge requests, as a credentials failure", func() {
+		GinkgoT().Setenv(discover.GitLabTokenEnv, "")
+		_, err := discover.NewRunner(discover.Config{Provider: discover.ProviderGitLab})
+		Expect(err).To(MatchError(discover.ErrCredentials))
+		Expect(err).To(MatchError(ContainSubstring("GITLAB_TOKEN is required for --apply")))
+	})
+
+	It("should reject combining GitLab with GraphQL", func() {
+		_, err := discover.NewRunner(discover.Config{Provider: discover.ProviderGitLab, GraphQL: true})
+		Expect(err).To(MatchError(ContainSubstring("--graphql cannot be combined with --provider gitlab")))
+	})
+})
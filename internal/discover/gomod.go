@@ -0,0 +1,112 @@
+package discover
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/google/go-github/v66/github"
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+)
+
+// How discovery tells which repositories are Go repositories
+const (
+	// DetectByLanguage uses the primary language GitHub reports
+	DetectByLanguage = "language"
+	// DetectByGoMod looks for go.mod files in the default branch, finding repositories where Go is not the largest
+	// language; repositories of the other languages are still told by their primary language
+	DetectByGoMod = "gomod"
+)
+
+// scansForGoModules reports whether discovery lists the files of repositories to find their go.mod files
+func (r *Runner) scansForGoModules() bool {
+	return r.config.DetectBy == DetectByGoMod || r.config.DeepScan
+}
+
+// scans reports whether the files of a repository are listed to find its go.mod files: those of every repository
+// with DetectByGoMod, those of the repositories whose primary language is not Go with DeepScan
+func (r *Runner) scans(repo *github.Repository) bool {
+	if r.config.DetectBy == DetectByGoMod {
+		return true
+	}
+	language, ok := r.languageOf(repo)
+	return r.config.DeepScan && (!ok || language.Key != config.LanguageGo)
+}
+
+// listed reports whether a repository listed in the organization may be in the configured languages; repositories
+// whose files are scanned may be until detectGoModules looked at them
+func (r *Runner) listed(repo *github.Repository) bool {
+	if r.scans(repo) {
+		return true
+	}
+	_, ok := r.languageOf(repo)
+	return ok
+}
+
+// detectGoModules keeps the scanned repositories with go.mod files as Go repositories, and the others by their
+// primary language. Repositories whose files cannot be listed fall back to their primary language
+// Trees are fetched by as many workers as repositories are analyzed at once, and kept for the analysis
+func (r *Runner) detectGoModules(ctx context.Context, repos []*github.Repository) ([]*github.Repository, error) {
+	trees := make([]*github.Tree, len(repos))
+	errs := make([]error, len(repos))
+
+	var workers sync.WaitGroup
+	slots := make(chan struct{}, r.concurrency())
+	for i, repo := range repos {
+		if !r.scans(repo) {
+			continue
+		}
+		workers.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer func() {
+				<-slots
+				workers.Done()
+			}()
+			trees[i], errs[i] = r.provider.Tree(ctx, r.config.Organization, repo)
+		}()
+	}
+	workers.Wait()
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("discovery interrupted: %w", ctx.Err())
+	}
+
+	goLanguage, _ := languageByKey(config.LanguageGo)
+	r.detected = make(map[string]Language)
+	r.trees = make(map[string]*github.Tree)
+	var kept []*github.Repository
+	for i, repo := range repos {
+		switch {
+		case errs[i] != nil:
+			fmt.Printf("  ⚠️  Could not list files of %s, using its primary language: %v\n", repo.GetName(), errs[i])
+		case trees[i] != nil:
+			r.trees[repo.GetName()] = trees[i]
+		}
+
+		switch language, ok := r.languageOf(repo); {
+		case trees[i] != nil && len(goModules(trees[i])) > 0:
+			r.detected[repo.GetName()] = goLanguage
+			if !strings.EqualFold(repo.GetLanguage(), goLanguage.GitHubName) {
+				fmt.Printf("  🔎 %s has Go modules in %s, discovered as Go (primary language: %s)\n", repo.GetName(), strings.Join(goModules(trees[i]), ", "), repo.GetLanguage())
+			}
+		// Trees too large to list completely may hide their go.mod files
+		case ok && language.Key == config.LanguageGo && trees[i] != nil && !trees[i].GetTruncated():
+			continue
+		case ok:
+			r.detected[repo.GetName()] = language
+		default:
+			continue
+		}
+		kept = append(kept, repo)
+	}
+	return kept, nil
+}
+
+// tree lists the files of the default branch of a repository, reusing the tree detectGoModules listed
+func (r *Runner) tree(ctx context.Context, repo *github.Repository) (*github.Tree, error) {
+	if tree, ok := r.trees[repo.GetName()]; ok {
+		return tree, nil
+	}
+	return r.provider.Tree(ctx, r.config.Organization, repo)
+}
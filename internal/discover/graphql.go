@@ -0,0 +1,223 @@
+package discover
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v66/github"
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+	"github.com/konflux-ci/coverage-dashboard/internal/ownership"
+)
+
+// graphQLPageSize is the number of repositories per GraphQL query, the most GitHub allows
+const graphQLPageSize = 100
+
+// CodeownersDetector detects owners from CODEOWNERS files fetched beforehand, e.g. by the GraphQL query,
+// only calling the API for repositories without CODEOWNERS owners
+type CodeownersDetector interface {
+	DetectWithCodeowners(ctx context.Context, org, repo string, codeowners []string) (ownership.Detection, error)
+}
+
+// repositoriesQuery lists the repositories of an organization with their language, archived and fork status, visibility, default branch
+// and the CODEOWNERS files of their default branch, so discovery needs no call per repository
+var repositoriesQuery = buildRepositoriesQuery()
+
+// buildRepositoriesQuery builds repositoriesQuery, aliasing one object per CODEOWNERS path
+func buildRepositoriesQuery() string {
+	var files strings.Builder
+	for i, path := range ownership.GetCodeownersPaths() {
+		fmt.Fprintf(&files, "        codeowners%d: object(expression: %q) { ... on Blob { text byteSize } }\n", i, "HEAD:"+path)
+	}
+	return fmt.Sprintf(`query($org: String!, $cursor: String) {
+  organization(login: $org) {
+    repositories(first: %d, after: $cursor, orderBy: {field: NAME, direction: ASC}) {
+      pageInfo { hasNextPage endCursor }
+      nodes {
+        name
+        isArchived
+        isFork
+        visibility
+        pushedAt
+        primaryLanguage { name }
+        defaultBranchRef { name }
+%s      }
+    }
+  }
+}`, graphQLPageSize, files.String())
+}
+
+// graphQLBlob is a file of the default branch; Text is null for binary files
+type graphQLBlob struct {
+	Text     *string `json:"text"`
+	ByteSize int     `json:"byteSize"`
+}
+
+// graphQLRepository is a repository node of repositoriesQuery; the codeowners aliases are decoded separately
+type graphQLRepository struct {
+	Name       string `json:"name"`
+	IsArchived bool   `json:"isArchived"`
+	IsFork     bool   `json:"isFork"`
+	// Visibility is PUBLIC, PRIVATE or INTERNAL
+	Visibility string `json:"visibility"`
+	// PushedAt is null for repositories never pushed to
+	PushedAt        *time.Time `json:"pushedAt"`
+	PrimaryLanguage *struct {
+		Name string `json:"name"`
+	} `json:"primaryLanguage"`
+	DefaultBranchRef *struct {
+		Name string `json:"name"`
+	} `json:"defaultBranchRef"`
+}
+
+// graphQLRepositories is a page of the repositories of an organization
+type graphQLRepositories struct {
+	PageInfo struct {
+		HasNextPage bool   `json:"hasNextPage"`
+		EndCursor   string `json:"endCursor"`
+	} `json:"pageInfo"`
+	// Nodes are decoded one by one, as graphQLRepository and for their CODEOWNERS aliases
+	Nodes []json.RawMessage `json:"nodes"`
+}
+
+// graphQLResponse is the response of repositoriesQuery
+type graphQLResponse struct {
+	Data struct {
+		Organization *struct {
+			Repositories graphQLRepositories `json:"repositories"`
+		} `json:"organization"`
+	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// fetchRepositoriesGraphQL lists the organization's repositories with repositoriesQuery, a query per page of
+// repositories, keeping the contents of their CODEOWNERS files for Analyze
+func (r *Runner) fetchRepositoriesGraphQL(ctx context.Context) ([]*github.Repository, error) {
+	var allRepos []*github.Repository
+	r.archivedRepos = make(map[string]bool)
+	r.orgRepos = make(map[string]bool)
+	r.codeowners = make(map[string][]string)
+
+	variables := map[string]any{"org": r.config.Organization, "cursor": nil}
+	for queries := 1; ; queries++ {
+		page, err := r.queryRepositories(ctx, variables)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, raw := range page.Nodes {
+			var node graphQLRepository
+			if err := json.Unmarshal(raw, &node); err != nil {
+				return nil, fmt.Errorf("failed to parse GraphQL repository: %w", err)
+			}
+			fullName := fmt.Sprintf("%s/%s", r.config.Organization, node.Name)
+			r.orgRepos[strings.ToLower(fullName)] = true
+			if node.IsArchived {
+				r.archivedRepos[fullName] = true
+				continue
+			}
+
+			repo := &github.Repository{Name: github.String(node.Name), Archived: github.Bool(false), Fork: github.Bool(node.IsFork)}
+			if node.Visibility != "" {
+				repo.Visibility = github.String(strings.ToLower(node.Visibility))
+			}
+			if node.PushedAt != nil {
+				repo.PushedAt = &github.Timestamp{Time: *node.PushedAt}
+			}
+			if node.PrimaryLanguage != nil {
+				repo.Language = github.String(node.PrimaryLanguage.Name)
+			}
+			if node.DefaultBranchRef != nil {
+				repo.DefaultBranch = github.String(node.DefaultBranchRef.Name)
+			}
+			if !r.listed(repo) {
+				continue
+			}
+			codeowners, err := codeownersOf(raw)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse CODEOWNERS of %s: %w", fullName, err)
+			}
+			r.codeowners[fullName] = codeowners
+			allRepos = append(allRepos, repo)
+		}
+
+		if !page.PageInfo.HasNextPage {
+			fmt.Printf("  🔎 Listed %d repositories in %d GraphQL queries\n", len(allRepos)+len(r.archivedRepos), queries)
+			break
+		}
+		variables["cursor"] = page.PageInfo.EndCursor
+	}
+
+	if r.scansForGoModules() {
+		var err error
+		if allRepos, err = r.detectGoModules(ctx, allRepos); err != nil {
+			return nil, err
+		}
+	}
+
+	// Nodes come sorted by name already, but the REST path sorts too and both must agree
+	sortRepositories(allRepos)
+	return allRepos, nil
+}
+
+// graphQLEndpoint is the GraphQL API next to the client's REST API, relative to its base URL; GitHub Enterprise
+// Server serves it at /api/graphql, outside the /api/v3 of the REST API
+func graphQLEndpoint(client *github.Client) string {
+	if strings.HasSuffix(client.BaseURL.Path, "/api/v3/") {
+		return "../graphql"
+	}
+	return "graphql"
+}
+
+// queryRepositories runs repositoriesQuery for a page of repositories
+func (r *Runner) queryRepositories(ctx context.Context, variables map[string]any) (*graphQLRepositories, error) {
+	req, err := r.githubClient.NewRequest("POST", graphQLEndpoint(r.githubClient), map[string]any{"query": repositoriesQuery, "variables": variables})
+	if err != nil {
+		return nil, err
+	}
+	var resp graphQLResponse
+	if _, err := r.githubClient.Do(ctx, req, &resp); err != nil {
+		return nil, fmt.Errorf("GraphQL query failed: %w", err)
+	}
+	if len(resp.Errors) > 0 {
+		messages := make([]string, len(resp.Errors))
+		for i, e := range resp.Errors {
+			messages[i] = e.Message
+		}
+		return nil, fmt.Errorf("GraphQL query failed: %s", strings.Join(messages, "; "))
+	}
+	if resp.Data.Organization == nil {
+		return nil, fmt.Errorf("organization %s not found", r.config.Organization)
+	}
+	return &resp.Data.Organization.Repositories, nil
+}
+
+// codeownersOf returns the CODEOWNERS files of a repository node in path order, skipping missing,
+// binary and oversized ones
+func codeownersOf(raw json.RawMessage) ([]string, error) {
+	var aliases map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &aliases); err != nil {
+		return nil, err
+	}
+
+	var codeowners []string
+	for i := range ownership.GetCodeownersPaths() {
+		value, ok := aliases[fmt.Sprintf("codeowners%d", i)]
+		if !ok || string(value) == "null" {
+			continue
+		}
+		var blob graphQLBlob
+		if err := json.Unmarshal(value, &blob); err != nil {
+			return nil, err
+		}
+		if blob.Text == nil || blob.ByteSize > config.MaxCodeownersSize {
+			continue
+		}
+		codeowners = append(codeowners, *blob.Text)
+	}
+	return codeowners, nil
+}
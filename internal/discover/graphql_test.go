@@ -0,0 +1,138 @@
+package discover_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+	"github.com/konflux-ci/coverage-dashboard/internal/discover"
+	"github.com/konflux-ci/coverage-dashboard/internal/ownership"
+)
+
+var _ = Describe("GraphQL discovery", func() {
+	var (
+		tempDir  string
+		server   *httptest.Server
+		requests []string
+		runner   *discover.Runner
+	)
+
+	BeforeEach(func() {
+		tempDir = GinkgoT().TempDir()
+		requests = nil
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r.Method+" "+r.URL.Path)
+			switch {
+			case r.Method == http.MethodPost && r.URL.Path == "/graphql":
+				var body struct {
+					Query     string         `json:"query"`
+					Variables map[string]any `json:"variables"`
+				}
+				Expect(json.NewDecoder(r.Body).Decode(&body)).To(Succeed())
+				Expect(body.Query).To(ContainSubstring(`codeowners0: object(expression: "HEAD:.github/CODEOWNERS")`))
+				Expect(body.Variables["org"]).To(Equal("test-org"))
+				if body.Variables["cursor"] == nil {
+					fmt.Fprint(w, `{"data": {"organization": {"repositories": {
+						"pageInfo": {"hasNextPage": true, "endCursor": "Y3Vyc29yOjI="},
+						"nodes": [
+							{"name": "api", "isArchived": false, "visibility": "PUBLIC", "primaryLanguage": {"name": "Go"}, "defaultBranchRef": {"name": "main"},
+							 "codeowners0": null, "codeowners1": {"text": "* @test-org/api-team\n", "byteSize": 21}, "codeowners2": null},
+							{"name": "cli", "isArchived": false, "visibility": "INTERNAL", "primaryLanguage": {"name": "Go"}, "defaultBranchRef": {"name": "master"},
+							 "codeowners0": null, "codeowners1": null, "codeowners2": null}
+						]}}}}`)
+					return
+				}
+				Expect(body.Variables["cursor"]).To(Equal("Y3Vyc29yOjI="))
+				fmt.Fprint(w, `{"data": {"organization": {"repositories": {
+					"pageInfo": {"hasNextPage": false, "endCursor": "Y3Vyc29yOjQ="},
+					"nodes": [
+						{"name": "mirror", "isArchived": false, "isFork": true, "primaryLanguage": {"name": "Go"}},
+						{"name": "old", "isArchived": true, "primaryLanguage": {"name": "Go"}},
+						{"name": "ui", "isArchived": false, "primaryLanguage": {"name": "TypeScript"}}
+					]}}}}`)
+			case r.URL.Path == "/repos/test-org/cli/teams":
+				fmt.Fprint(w, `[{"slug": "cli-maintainers", "permission": "admin"}]`)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+
+		reposDir := filepath.Join(tempDir, "repos")
+		Expect(os.MkdirAll(reposDir, 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(reposDir, "old.yaml"), []byte("name: test-org/old\n"), 0644)).To(Succeed())
+
+		client := githubClient(server)
+		runner = discover.NewRunnerWithDependencies(discover.Config{
+			Organization: "test-org",
+			ReposDir:     reposDir,
+			GraphQL:      true,
+		}, discover.Dependencies{ReadClient: client, Owners: ownership.NewDetector(client, "")})
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should list repositories and detect CODEOWNERS owners without calls per repository", func() {
+		ctx := context.Background()
+		repos, err := runner.FetchRepositories(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(repos).To(HaveLen(3))
+		Expect(repos[1].GetName()).To(Equal("cli"))
+		Expect(repos[1].GetDefaultBranch()).To(Equal("master"))
+		Expect(repos[1].GetVisibility()).To(Equal("internal"))
+		Expect(repos[2].GetFork()).To(BeTrue())
+
+		newRepos, err := runner.FilterNew(repos)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(newRepos).To(HaveLen(2))
+		Expect(runner.FindArchived()).To(Equal([]string{"test-org/old"}))
+
+		cfg, err := runner.Analyze(ctx, newRepos[0])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg.Owners).To(Equal([]string{"@test-org/api-team"}))
+		Expect(cfg.OwnersSource).To(Equal(config.OwnersSourceCodeowners))
+		Expect(requests).To(Equal([]string{"POST /graphql", "POST /graphql"}))
+
+		// Repositories without CODEOWNERS owners fall back to their teams through REST
+		cfg, err = runner.Analyze(ctx, newRepos[1])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg.Owners).To(Equal([]string{"@test-org/cli-maintainers"}))
+		Expect(requests).To(Equal([]string{"POST /graphql", "POST /graphql", "GET /repos/test-org/cli/teams"}))
+	})
+
+	It("should report GraphQL errors", func() {
+		server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"data": {"organization": null}, "errors": [{"message": "Could not resolve to an Organization with the login of 'test-org'."}]}`)
+		})
+		_, err := runner.FetchRepositories(context.Background())
+		Expect(err).To(MatchError(ContainSubstring("GraphQL query failed: Could not resolve to an Organization")))
+	})
+
+	It("should query the GraphQL API next to the REST API of GitHub Enterprise Server", func() {
+		server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r.Method+" "+r.URL.Path)
+			fmt.Fprint(w, `{"data": {"organization": {"repositories": {"pageInfo": {"hasNextPage": false}, "nodes": []}}}}`)
+		})
+		client := githubClient(server)
+		client.BaseURL, _ = url.Parse(server.URL + "/api/v3/")
+		runner = discover.NewRunnerWithDependencies(discover.Config{
+			Organization: "test-org",
+			GraphQL:      true,
+		}, discover.Dependencies{ReadClient: client})
+
+		repos, err := runner.FetchRepositories(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(repos).To(BeEmpty())
+		Expect(requests).To(Equal([]string{"POST /api/graphql"}))
+	})
+})
@@ -0,0 +1,150 @@
+package discover
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+	"github.com/konflux-ci/coverage-dashboard/internal/ownership"
+)
+
+// SkipDeclined is a repository the operator skipped in Interactive runs
+const SkipDeclined = "declined"
+
+// Confirm presents each configuration to the operator, reading answers from the runner's input, and returns those
+// accepted, with the owners the operator entered. Prompts end with a newline, so they show through filtered output
+func (r *Runner) Confirm(ctx context.Context, configs []config.RepositoryConfig) ([]config.RepositoryConfig, error) {
+	if len(configs) == 0 {
+		return nil, nil
+	}
+	fmt.Printf("🙋 Confirming %d new repositories...\n", len(configs))
+
+	var accepted []config.RepositoryConfig
+	for i, cfg := range configs {
+		fmt.Println()
+		fmt.Printf("📦 [%d/%d] %s\n", i+1, len(configs), cfg.Name)
+		// The operator accepted it in the interrupted run that opened its pull request
+		if _, ok := r.openedPullRequest(cfg.Name); ok {
+			fmt.Println("  ♻️  Pull request opened by the interrupted run")
+			accepted = append(accepted, cfg)
+			continue
+		}
+		for {
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf("confirmation interrupted: %w", ctx.Err())
+			}
+			printCandidate(cfg)
+			answer, err := r.ask("  → Add it? [y]es, [e]dit owners, [s]kip, [q]uit and skip the rest")
+			if err != nil {
+				return nil, err
+			}
+			switch strings.ToLower(answer) {
+			case "y", "yes":
+				accepted = append(accepted, cfg)
+			case "e", "edit":
+				if cfg, err = r.editOwners(cfg); err != nil {
+					return nil, err
+				}
+				continue
+			case "s", "skip":
+				r.reportSkipped(extractRepoNameFromConfig(cfg.Name), SkipDeclined, "skipped by the operator")
+			case "q", "quit":
+				for _, rest := range configs[i:] {
+					r.reportSkipped(extractRepoNameFromConfig(rest.Name), SkipDeclined, "skipped by the operator")
+				}
+				fmt.Printf("  ⏭️  Skipped the remaining %d repositories\n", len(configs)-i)
+				fmt.Println()
+				return accepted, nil
+			default:
+				fmt.Printf("  ⚠️  Unknown answer %q\n", answer)
+				continue
+			}
+			break
+		}
+	}
+	fmt.Printf("  ✅ Accepted %d/%d repositories\n", len(accepted), len(configs))
+	fmt.Println()
+	return accepted, nil
+}
+
+// printCandidate prints what a configuration would add
+func printCandidate(cfg config.RepositoryConfig) {
+	source := cfg.OwnersSource
+	if source == "" {
+		source = "entered"
+	}
+	fmt.Printf("  👥 Owners: %s (%s)\n", strings.Join(cfg.Owners, " "), source)
+	if len(cfg.Modules) > 0 {
+		for _, module := range cfg.Modules {
+			fmt.Printf("  📦 Module %s excludes dirs: %s; files: %s\n", module.Path, formatExcludes(module.ExcludeDirs), formatExcludes(module.ExcludeFiles))
+		}
+		return
+	}
+	fmt.Printf("  🚫 Exclude dirs: %s\n", formatExcludes(cfg.ExcludeDirs))
+	fmt.Printf("  🚫 Exclude files: %s\n", formatExcludes(cfg.ExcludeFiles))
+}
+
+// formatExcludes joins excludes for the prompt
+func formatExcludes(excludes []string) string {
+	if len(excludes) == 0 {
+		return "none"
+	}
+	return strings.Join(excludes, ", ")
+}
+
+// editOwners replaces the owners of a configuration with those the operator enters, asking again for invalid ones
+func (r *Runner) editOwners(cfg config.RepositoryConfig) (config.RepositoryConfig, error) {
+	for {
+		answer, err := r.ask("  → Owners, as @user or @org/team separated by spaces or commas")
+		if err != nil {
+			return cfg, err
+		}
+		var owners, invalid []string
+		for _, owner := range strings.FieldsFunc(answer, func(c rune) bool { return c == ' ' || c == ',' }) {
+			if !strings.HasPrefix(owner, "@") {
+				owner = "@" + owner
+			}
+			if ownership.ValidOwner(owner) {
+				owners = append(owners, owner)
+			} else {
+				invalid = append(invalid, owner)
+			}
+		}
+		switch {
+		case len(invalid) > 0:
+			fmt.Printf("  ⚠️  Not a @user or @org/team: %s\n", strings.Join(invalid, " "))
+		case len(owners) == 0:
+			fmt.Println("  ⚠️  At least one owner is needed")
+		default:
+			cfg.Owners = owners
+			// The operator chose the owners, so how discovery detected them no longer applies
+			cfg.OwnersSource, cfg.OwnersDetectedAt = "", nil
+			return cfg, nil
+		}
+	}
+}
+
+// ask prints a prompt and reads the operator's answer, failing when the input ends
+func (r *Runner) ask(prompt string) (string, error) {
+	fmt.Println(prompt)
+	line, err := r.input.ReadString('\n')
+	if errors.Is(err, io.EOF) && line == "" {
+		return "", fmt.Errorf("input ended before every repository was confirmed")
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("failed to read the answer: %w", err)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// newInput returns the reader of the operator's answers
+func newInput(input io.Reader) *bufio.Reader {
+	if reader, ok := input.(*bufio.Reader); ok {
+		return reader
+	}
+	return bufio.NewReader(input)
+}
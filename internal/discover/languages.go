@@ -0,0 +1,162 @@
+package discover
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+)
+
+// DefaultLanguages are the languages discovered without --languages
+const DefaultLanguages = config.LanguageGo
+
+// Language is a programming language whose repositories discovery adds, with the excludes their
+// configurations start from; repository owners adjust them in the pull request
+type Language struct {
+	// Key names the language in --languages and in the language field of configurations
+	Key string
+	// GitHubName is the primary language GitHub reports for the repositories, e.g. "TypeScript"
+	GitHubName   string
+	ExcludeDirs  []string
+	ExcludeFiles []string
+}
+
+// languages are the languages discovery knows, in the order they are listed
+var languages = []Language{
+	{
+		Key:        config.LanguageGo,
+		GitHubName: "Go",
+		ExcludeDirs: []string{
+			"vendor/",
+			".github/",
+			".tekton/",
+			"hack/",
+			"proto/",
+			"test/",
+			"tests/",
+			"integration-tests/",
+			"/fake(/|$)",
+			"/mock(s)?(/|$)",
+			"/e2e(-tests)?(/|$)",
+			"docs/",
+		},
+		ExcludeFiles: []string{
+			"zz_generated.deepcopy.go",
+			"openapi_generated.go",
+			"*.pb.go",
+			"mock_*.go",
+			"*_mock.go",
+		},
+	},
+	{
+		Key:        config.LanguagePython,
+		GitHubName: "Python",
+		ExcludeDirs: []string{
+			".github/",
+			".tekton/",
+			"docs/",
+			"test/",
+			"tests/",
+			"venv/",
+			".venv/",
+			".tox/",
+			"build/",
+			"dist/",
+			"/migrations(/|$)",
+		},
+		ExcludeFiles: []string{
+			"setup.py",
+			"conftest.py",
+			"test_*.py",
+			"*_test.py",
+			"*_pb2.py",
+			"*_pb2_grpc.py",
+		},
+	},
+	{
+		Key:        config.LanguageTypeScript,
+		GitHubName: "TypeScript",
+		ExcludeDirs: []string{
+			".github/",
+			".tekton/",
+			"docs/",
+			"node_modules/",
+			"dist/",
+			"build/",
+			"coverage/",
+			"/__tests__(/|$)",
+			"/__mocks__(/|$)",
+			"/e2e(-tests)?(/|$)",
+			"cypress/",
+		},
+		ExcludeFiles: []string{
+			"*.d.ts",
+			"*.test.ts",
+			"*.test.tsx",
+			"*.spec.ts",
+			"*.spec.tsx",
+			"*.config.ts",
+			"*.config.js",
+		},
+	},
+}
+
+// ParseLanguages returns the languages of a comma-separated list of keys, e.g. "go,python"
+func ParseLanguages(list string) ([]Language, error) {
+	var parsed []Language
+	seen := make(map[string]bool)
+	for _, key := range strings.Split(list, ",") {
+		key = strings.ToLower(strings.TrimSpace(key))
+		if key == "" || seen[key] {
+			continue
+		}
+		language, ok := languageByKey(key)
+		if !ok {
+			return nil, fmt.Errorf("unknown language %q, expected one of %s", key, strings.Join(LanguageKeys(), ", "))
+		}
+		seen[key] = true
+		parsed = append(parsed, language)
+	}
+	if len(parsed) == 0 {
+		return nil, fmt.Errorf("no languages given, expected some of %s", strings.Join(LanguageKeys(), ", "))
+	}
+	return parsed, nil
+}
+
+// LanguageKeys lists the keys of the languages discovery knows
+func LanguageKeys() []string {
+	keys := make([]string, 0, len(languages))
+	for _, language := range languages {
+		keys = append(keys, language.Key)
+	}
+	return keys
+}
+
+// languageByKey returns the language of a key
+func languageByKey(key string) (Language, bool) {
+	for _, language := range languages {
+		if language.Key == key {
+			return language, true
+		}
+	}
+	return Language{}, false
+}
+
+// hasLanguage reports whether the language of a key is selected
+func hasLanguage(selected []Language, key string) bool {
+	for _, language := range selected {
+		if language.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// languageNames joins the GitHub names of languages for messages, e.g. "Go or Python"
+func languageNames(selected []Language) string {
+	names := make([]string, 0, len(selected))
+	for _, language := range selected {
+		names = append(names, language.GitHubName)
+	}
+	return strings.Join(names, " or ")
+}
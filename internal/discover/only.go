@@ -0,0 +1,76 @@
+package discover
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"github.com/google/go-github/v66/github"
+)
+
+// ParseOnly parses the comma-separated repositories of --only, given as name or org/name, into their names
+// Repositories of another organization than org are refused
+func ParseOnly(list, org string) ([]string, error) {
+	var names []string
+	seen := make(map[string]bool)
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name := entry
+		if owner, repo, ok := strings.Cut(entry, "/"); ok {
+			if !strings.EqualFold(owner, org) {
+				return nil, fmt.Errorf("--only repository %q is not in the %s organization", entry, org)
+			}
+			name = repo
+		}
+		if name == "" || strings.Contains(name, "/") {
+			return nil, fmt.Errorf("--only repositories must be name or org/name, got %q", entry)
+		}
+		if !seen[strings.ToLower(name)] {
+			seen[strings.ToLower(name)] = true
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// fetchOnly looks up the repositories of Only one by one instead of listing the organization, keeping those in the
+// configured languages that are not archived like FetchRepositories
+// Repositories missing from the organization fail the run, as they are most likely misspelled
+func (r *Runner) fetchOnly(ctx context.Context) ([]*github.Repository, error) {
+	var repos []*github.Repository
+	r.archivedRepos = make(map[string]bool)
+	r.orgRepos = make(map[string]bool)
+	for _, name := range r.config.Only {
+		repo, err := r.provider.Repository(ctx, r.config.Organization, name)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			return nil, fmt.Errorf("repository %s/%s of --only does not exist", r.config.Organization, name)
+		case err != nil:
+			return nil, err
+		}
+		fullName := fmt.Sprintf("%s/%s", r.config.Organization, repo.GetName())
+		r.orgRepos[strings.ToLower(fullName)] = true
+		switch {
+		case repo.GetArchived():
+			r.archivedRepos[fullName] = true
+		case r.listed(repo):
+			repos = append(repos, repo)
+		default:
+			fmt.Printf("  ⚠️  Warning: %s is not in %s, skipping it\n", fullName, languageNames(r.config.Languages))
+		}
+	}
+	if r.scansForGoModules() {
+		var err error
+		if repos, err = r.detectGoModules(ctx, repos); err != nil {
+			return nil, err
+		}
+	}
+
+	sortRepositories(repos)
+	return repos, nil
+}
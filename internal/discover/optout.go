@@ -0,0 +1,38 @@
+package discover
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+
+	"github.com/google/go-github/v66/github"
+)
+
+const (
+	// OptOutFile at the root of a repository opts it out of the dashboard; discovery skips it for as long as it exists
+	OptOutFile = ".coverage-dashboard-ignore"
+
+	// SkipOptedOut is a repository whose owners opted out with OptOutFile
+	SkipOptedOut = "opted_out"
+)
+
+// optedOut reports whether a repository has OptOutFile at its root, from the tree listed ahead of the analysis when
+// there is one
+func (r *Runner) optedOut(ctx context.Context, repo *github.Repository) (bool, error) {
+	if tree, ok := r.trees[repo.GetName()]; ok && !tree.GetTruncated() {
+		for _, entry := range tree.Entries {
+			if entry.GetPath() == OptOutFile {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	_, err := r.provider.File(ctx, r.config.Organization, repo, OptOutFile)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return false, nil
+	case err != nil:
+		return false, err
+	}
+	return true, nil
+}
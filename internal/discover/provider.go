@@ -0,0 +1,206 @@
+package discover
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-github/v66/github"
+	"github.com/konflux-ci/coverage-dashboard/internal/ghauth"
+	"github.com/konflux-ci/coverage-dashboard/internal/pr"
+)
+
+// Forges discovery scans for repositories
+const (
+	ProviderGitHub = "github"
+	ProviderGitLab = "gitlab"
+)
+
+// Provider is the forge hosting the organization's repositories and the dashboard repository pull requests are
+// opened on; repositories of every forge are described as GitHub repositories
+type Provider interface {
+	// ListRepositories lists the repositories of an organization, archived ones included
+	ListRepositories(ctx context.Context, org string) ([]*github.Repository, error)
+	// Tree lists the files of the default branch of a repository
+	Tree(ctx context.Context, org string, repo *github.Repository) (*github.Tree, error)
+	// File returns the content of a file of the default branch of a repository, an error wrapping fs.ErrNotExist
+	// when it does not exist
+	File(ctx context.Context, org string, repo *github.Repository, path string) ([]byte, error)
+	// DefaultBranch returns the default branch of a repository
+	DefaultBranch(ctx context.Context, org, repo string) (string, error)
+	// HasOpenPullRequest reports whether a branch of a repository has an open pull request into base
+	HasOpenPullRequest(ctx context.Context, org, repo, branch, base string) (bool, error)
+	// Creator returns a creator of pull requests on a repository checked out in workDir
+	Creator(org, repo, workDir, baseBranch string) *pr.Creator
+	// Verify checks the credentials can read the organization and, unless repo is empty, open pull requests on it
+	Verify(ctx context.Context, org, repo string) error
+	// Repository returns a repository of an organization by name, following the redirect of a renamed one to it,
+	// an error wrapping fs.ErrNotExist when there is none
+	Repository(ctx context.Context, org, name string) (*github.Repository, error)
+	// TeamMembers lists the logins of the members of a team of an organization, for review rotations
+	TeamMembers(ctx context.Context, org, team string) ([]string, error)
+}
+
+var (
+	_ Provider = (*gitHubProvider)(nil)
+	_ Provider = (*GitLab)(nil)
+)
+
+// gitHubProvider lists repositories and opens pull requests through the GitHub REST API
+type gitHubProvider struct {
+	tokens ghauth.Tokens
+	read   *github.Client // For general API calls
+	write  *github.Client // For PR creation
+}
+
+// ListRepositories lists the repositories of a GitHub organization
+func (p *gitHubProvider) ListRepositories(ctx context.Context, org string) ([]*github.Repository, error) {
+	opts := &github.RepositoryListByOrgOptions{
+		Type: "all",
+		ListOptions: github.ListOptions{
+			PerPage: 100,
+		},
+	}
+
+	var allRepos []*github.Repository
+	for {
+		repos, resp, err := p.read.Repositories.ListByOrg(ctx, org, opts)
+		if err != nil {
+			return nil, err
+		}
+		allRepos = append(allRepos, repos...)
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return allRepos, nil
+}
+
+// Tree lists the files of the default branch of a GitHub repository
+func (p *gitHubProvider) Tree(ctx context.Context, org string, repo *github.Repository) (*github.Tree, error) {
+	ref := repo.GetDefaultBranch()
+	if ref == "" {
+		ref = "HEAD"
+	}
+	tree, _, err := p.read.Git.GetTree(ctx, org, repo.GetName(), ref, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files of %s: %w", repo.GetName(), err)
+	}
+	return tree, nil
+}
+
+// File returns the content of a file of the default branch of a GitHub repository
+func (p *gitHubProvider) File(ctx context.Context, org string, repo *github.Repository, path string) ([]byte, error) {
+	file, _, resp, err := p.read.Repositories.GetContents(ctx, org, repo.GetName(), path, &github.RepositoryContentGetOptions{Ref: repo.GetDefaultBranch()})
+	switch {
+	case resp != nil && resp.StatusCode == http.StatusNotFound:
+		return nil, fmt.Errorf("%s of %s: %w", path, repo.GetName(), fs.ErrNotExist)
+	case err != nil:
+		return nil, fmt.Errorf("failed to fetch %s of %s: %w", path, repo.GetName(), err)
+	case file == nil:
+		return nil, fmt.Errorf("%s of %s is a directory", path, repo.GetName())
+	}
+	content, err := file.GetContent()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s of %s: %w", path, repo.GetName(), err)
+	}
+	return []byte(content), nil
+}
+
+// DefaultBranch returns the default branch of a GitHub repository, main when it has none
+func (p *gitHubProvider) DefaultBranch(ctx context.Context, org, repoName string) (string, error) {
+	return pr.DefaultBranch(ctx, p.write, org, repoName)
+}
+
+// HasOpenPullRequest reports whether a branch of a GitHub repository has an open pull request into base
+func (p *gitHubProvider) HasOpenPullRequest(ctx context.Context, org, repo, branch, base string) (bool, error) {
+	opts := &github.PullRequestListOptions{
+		State: "open",
+		Head:  fmt.Sprintf("%s:%s", org, branch),
+		Base:  base,
+	}
+	prs, _, err := p.write.PullRequests.List(ctx, org, repo, opts)
+	if err != nil {
+		return false, err
+	}
+	return len(prs) > 0, nil
+}
+
+// Creator returns a creator of pull requests on a GitHub repository, using the write client
+// (may have different permissions than the read client)
+func (p *gitHubProvider) Creator(org, repo, workDir, baseBranch string) *pr.Creator {
+	return pr.NewCreator(p.write, workDir, org, repo, baseBranch)
+}
+
+// Repository returns a GitHub repository by name; GitHub redirects the old names of renamed repositories to them
+func (p *gitHubProvider) Repository(ctx context.Context, org, name string) (*github.Repository, error) {
+	repo, resp, err := p.read.Repositories.Get(ctx, org, name)
+	switch {
+	case resp != nil && resp.StatusCode == http.StatusNotFound:
+		return nil, fmt.Errorf("%s/%s: %w", org, name, fs.ErrNotExist)
+	case err != nil:
+		return nil, fmt.Errorf("failed to get %s/%s: %w", org, name, err)
+	}
+	return repo, nil
+}
+
+// TeamMembers lists the logins of the members of a GitHub team, by its slug
+func (p *gitHubProvider) TeamMembers(ctx context.Context, org, team string) ([]string, error) {
+	opts := &github.TeamListTeamMembersOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	var logins []string
+	for {
+		users, resp, err := p.read.Teams.ListTeamMembersBySlug(ctx, org, team, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, user := range users {
+			logins = append(logins, user.GetLogin())
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return logins, nil
+}
+
+// Verify probes the API to check the tokens can do everything --apply needs,
+// reporting all missing scopes and permissions at once
+func (p *gitHubProvider) Verify(ctx context.Context, org, repo string) error {
+	var problems []error
+	readSource, writeSource := p.tokens.ReadSource, p.tokens.WriteSource
+
+	if p.tokens.ReadSource != "" {
+		info, err := ghauth.Inspect(ctx, p.read)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("  - %s: %w", readSource, err))
+		} else if missing := info.MissingScopes("read:org"); len(missing) > 0 {
+			problems = append(problems, fmt.Errorf("  - %s is missing scopes: %s", readSource, strings.Join(missing, ", ")))
+		}
+	} else {
+		readSource = "unauthenticated read client"
+	}
+	if err := ghauth.CheckOrgRead(ctx, p.read, org); err != nil {
+		problems = append(problems, fmt.Errorf("  - %s: %w", readSource, err))
+	}
+
+	info, err := ghauth.Inspect(ctx, p.write)
+	if err != nil {
+		problems = append(problems, fmt.Errorf("  - %s: %w", writeSource, err))
+	} else if missing := info.MissingScopes("repo"); len(missing) > 0 {
+		problems = append(problems, fmt.Errorf("  - %s is missing scopes: %s", writeSource, strings.Join(missing, ", ")))
+	}
+
+	if repo != "" {
+		if err := ghauth.CheckRepoWrite(ctx, p.write, org, repo); err != nil {
+			problems = append(problems, fmt.Errorf("  - %s: %w", writeSource, err))
+		}
+	}
+
+	return errors.Join(problems...)
+}
@@ -0,0 +1,104 @@
+package discover
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"github.com/google/go-github/v66/github"
+	"github.com/konflux-ci/coverage-dashboard/internal/pr"
+)
+
+// FindRenamed looks up the tracked repositories missing from the organization's listing, returning those renamed
+// since with their new full names, by old full name. The forge redirects the old name of a renamed repository to
+// it; deleted repositories and those transferred to another organization are left alone
+// It needs FetchRepositories and FilterNew to have run; runs of Only see too little of the organization to tell
+func (r *Runner) FindRenamed(ctx context.Context) map[string]string {
+	renamed := make(map[string]string)
+	if len(r.config.Only) > 0 {
+		return renamed
+	}
+	for _, repo := range sortedKeys(r.existingRepos) {
+		if r.orgRepos[strings.ToLower(repo)] {
+			continue
+		}
+		current, err := r.provider.Repository(ctx, r.config.Organization, extractRepoNameFromConfig(repo))
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			continue
+		case err != nil:
+			fmt.Printf("  ⚠️  Warning: could not look up %s, missing from the organization: %v\n", repo, err)
+			continue
+		}
+		// The listing of the forge's API has the organization's name in its own case
+		owner := current.GetOwner().GetLogin()
+		if owner != "" && !strings.EqualFold(owner, r.config.Organization) {
+			continue
+		}
+		if to := fmt.Sprintf("%s/%s", r.config.Organization, current.GetName()); to != repo {
+			renamed[repo] = to
+		}
+	}
+	return renamed
+}
+
+// RenameRepositories opens one pull request per renamed repository moving its configuration and CODEOWNERS entry to
+// its new name, by old full name
+// Failures of single pull requests are reported and skipped
+func (r *Runner) RenameRepositories(ctx context.Context, renamed map[string]string) error {
+	if len(renamed) == 0 {
+		return nil
+	}
+
+	fmt.Printf("✏️  Creating %d rename pull requests...\n", len(renamed))
+
+	prCreator, err := r.pullRequestCreator(ctx)
+	if err != nil {
+		return err
+	}
+
+	successCount := 0
+	for i, from := range sortedKeys(renamed) {
+		if ctx.Err() != nil {
+			return fmt.Errorf("interrupted after %d/%d pull requests: %w", successCount, len(renamed), ctx.Err())
+		}
+		to := renamed[from]
+		fmt.Printf("  [%d/%d] %s → %s... ", i+1, len(renamed), extractRepoNameFromConfig(from), extractRepoNameFromConfig(to))
+		if r.prAlreadyExists(ctx, pr.RenameBranch(from)) {
+			fmt.Println("skipped (PR already exists)")
+			r.reportRenamed(from, to, "", nil)
+			continue
+		}
+		url, err := prCreator.RenameRepository(ctx, r.configWriter, r.config.ReposFile, from, to)
+		r.reportRenamed(from, to, url, err)
+		if err != nil {
+			fmt.Printf("failed (%v)\n", err)
+			r.failedPullRequests++
+			continue
+		}
+		fmt.Println(url)
+		successCount++
+	}
+	fmt.Printf("  ✅ Created %d/%d rename pull requests\n", successCount, len(renamed))
+	fmt.Println()
+
+	return nil
+}
+
+// withoutRenamed drops the new names of renamed repositories from the new repositories, as they are tracked under
+// their old names until the rename is merged
+func (r *Runner) withoutRenamed(newRepos []*github.Repository, renamed map[string]string) []*github.Repository {
+	targets := make(map[string]bool, len(renamed))
+	for _, to := range renamed {
+		targets[to] = true
+	}
+	var kept []*github.Repository
+	for _, repo := range newRepos {
+		if !targets[fmt.Sprintf("%s/%s", r.config.Organization, repo.GetName())] {
+			kept = append(kept, repo)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,304 @@
+package discover
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+)
+
+// Why repositories were skipped, in the report of a run
+const (
+	// SkipFiltered is a repository the discovery filters, fork or visibility settings leave out
+	SkipFiltered = "filtered"
+	// SkipStale is a repository without a push within MinActivity
+	SkipStale = "stale"
+	// SkipAnalysis is a repository whose analysis failed, e.g. without Go test files under UntestedSkip
+	SkipAnalysis = "analysis"
+	// SkipPullRequestExists is a repository with a pull request adding it already open
+	SkipPullRequestExists = "pull_request_exists"
+)
+
+// RunReport is the outcome of a run, for automation post-processing it instead of the progress output
+type RunReport struct {
+	Organization string `json:"organization"`
+	DryRun       bool   `json:"dry_run"`
+	// Repositories is the number of repositories of the organization in the configured languages, and Tracked the
+	// number of configured ones
+	Repositories int `json:"repositories"`
+	Tracked      int `json:"tracked"`
+	// New are the repositories configured by the run, with their pull requests in apply mode
+	New      []NewRepository      `json:"new"`
+	Skipped  []SkippedRepository  `json:"skipped"`
+	Archived []ArchivedRepository `json:"archived"`
+	// Renamed are the tracked repositories renamed since, with the pull requests moving their configurations in
+	// apply mode
+	Renamed []RenamedRepository `json:"renamed"`
+	// FailedPullRequests counts the pull requests that could not be opened
+	FailedPullRequests int `json:"failed_pull_requests"`
+	// Error is the error the run stopped on, empty for complete runs
+	Error string `json:"error,omitempty"`
+	// Drift are the tracked repositories whose configurations differ from the defaults, in --check-drift runs
+	Drift []Drift `json:"drift,omitempty"`
+}
+
+// NewRepository is a repository configured by a run
+type NewRepository struct {
+	Name         string   `json:"name"`
+	Language     string   `json:"language"`
+	Owners       []string `json:"owners"`
+	OwnersSource string   `json:"owners_source"`
+	Modules      []string `json:"modules,omitempty"`
+	NoTests      bool     `json:"no_tests,omitempty"`
+	Fork         bool     `json:"fork,omitempty"`
+	Visibility   string   `json:"visibility,omitempty"`
+	// ExcludeDirs and ExcludeFiles are the excludes of the configuration, e.g. generated code and embedded upstream trees
+	ExcludeDirs  []string `json:"exclude_dirs,omitempty"`
+	ExcludeFiles []string `json:"exclude_files,omitempty"`
+	// PullRequest is the URL of the pull request adding the repository, and PullRequestError why it could not be
+	// opened; both are empty in dry runs
+	PullRequest      string `json:"pull_request,omitempty"`
+	PullRequestError string `json:"pull_request_error,omitempty"`
+}
+
+// SkippedRepository is a repository a run did not configure, one of the Skip values with its details
+type SkippedRepository struct {
+	Name   string `json:"name"`
+	Skip   string `json:"skip"`
+	Reason string `json:"reason"`
+	// LastPush is the last push of stale repositories
+	LastPush *time.Time `json:"last_push,omitempty"`
+}
+
+// ArchivedRepository is a tracked repository archived since, with the pull request removing it in apply mode
+type ArchivedRepository struct {
+	Name             string `json:"name"`
+	PullRequest      string `json:"pull_request,omitempty"`
+	PullRequestError string `json:"pull_request_error,omitempty"`
+}
+
+// RenamedRepository is a tracked repository renamed since, with the pull request moving its configuration in apply
+// mode
+type RenamedRepository struct {
+	From             string `json:"from"`
+	To               string `json:"to"`
+	PullRequest      string `json:"pull_request,omitempty"`
+	PullRequestError string `json:"pull_request_error,omitempty"`
+}
+
+// Report returns the outcome of the steps run so far, with the error of a failed run
+func (r *Runner) Report(err error) RunReport {
+	report := r.report
+	report.Organization = r.config.Organization
+	report.DryRun = r.config.DryRun
+	report.Tracked = len(r.existingRepos)
+	report.FailedPullRequests = r.failedPullRequests
+	// Empty lists stay lists, so consumers need not tell them from missing ones
+	report.New = append(make([]NewRepository, 0, len(r.report.New)), r.report.New...)
+	report.Archived = append(make([]ArchivedRepository, 0, len(r.report.Archived)), r.report.Archived...)
+	report.Renamed = append(make([]RenamedRepository, 0, len(r.report.Renamed)), r.report.Renamed...)
+	report.Skipped = make([]SkippedRepository, 0, len(r.filteredRepos)+len(r.staleRepos)+len(r.report.Skipped))
+	for _, repo := range sortedKeys(r.filteredRepos) {
+		report.Skipped = append(report.Skipped, SkippedRepository{Name: repo, Skip: SkipFiltered, Reason: r.filteredRepos[repo]})
+	}
+	for _, repo := range sortedKeys(r.staleRepos) {
+		lastPush := r.staleRepos[repo].UTC()
+		report.Skipped = append(report.Skipped, SkippedRepository{
+			Name:     repo,
+			Skip:     SkipStale,
+			Reason:   fmt.Sprintf("no push in %s", r.activityWindow()),
+			LastPush: &lastPush,
+		})
+	}
+	report.Skipped = append(report.Skipped, r.report.Skipped...)
+	if err != nil {
+		report.Error = err.Error()
+	}
+	return report
+}
+
+// reportSkipped records a repository skipped after its analysis started
+func (r *Runner) reportSkipped(name, skip, reason string) {
+	r.report.Skipped = append(r.report.Skipped, SkippedRepository{Name: fmt.Sprintf("%s/%s", r.config.Organization, name), Skip: skip, Reason: reason})
+}
+
+// reportPullRequest records the pull request of a configuration, adding the configuration when the run did not
+func (r *Runner) reportPullRequest(cfg config.RepositoryConfig, url string, err error) {
+	i := r.reportNew(cfg)
+	r.report.New[i].PullRequest = url
+	if err != nil {
+		r.report.New[i].PullRequestError = err.Error()
+	}
+}
+
+// reportNew records a configuration of the run and returns its index in the report
+func (r *Runner) reportNew(cfg config.RepositoryConfig) int {
+	for i, repo := range r.report.New {
+		if repo.Name == cfg.Name {
+			return i
+		}
+	}
+	language := cfg.Language
+	if language == "" {
+		language = config.LanguageGo
+	}
+	repo := NewRepository{
+		Name:         cfg.Name,
+		Language:     language,
+		Owners:       cfg.Owners,
+		OwnersSource: cfg.OwnersSource,
+		NoTests:      cfg.NoTests,
+		Fork:         cfg.Fork,
+		Visibility:   cfg.Visibility,
+		ExcludeDirs:  cfg.ExcludeDirs,
+		ExcludeFiles: cfg.ExcludeFiles,
+	}
+	for _, module := range cfg.Modules {
+		repo.Modules = append(repo.Modules, module.Path)
+	}
+	r.report.New = append(r.report.New, repo)
+	return len(r.report.New) - 1
+}
+
+// reportArchived records an archived repository, with the pull request removing it when one was attempted
+func (r *Runner) reportArchived(repo, url string, err error) {
+	archived := ArchivedRepository{Name: repo, PullRequest: url}
+	if err != nil {
+		archived.PullRequestError = err.Error()
+	}
+	r.report.Archived = append(r.report.Archived, archived)
+}
+
+// reportRenamed records a renamed repository, with the pull request moving its configuration
+func (r *Runner) reportRenamed(from, to, url string, err error) {
+	renamed := RenamedRepository{From: from, To: to, PullRequest: url}
+	if err != nil {
+		renamed.PullRequestError = err.Error()
+	}
+	r.report.Renamed = append(r.report.Renamed, renamed)
+}
+
+// skipHeadings title the sections of skipped repositories in the Markdown report, in the order they are listed
+var skipHeadings = []struct{ skip, heading string }{
+	{SkipAnalysis, "Analysis failed"},
+	{SkipPullRequestExists, "Pull request already open"},
+	{SkipDeclined, "Declined"},
+	{SkipOptedOut, "Opted out"},
+	{SkipFiltered, "Filtered"},
+	{SkipStale, "Stale"},
+}
+
+// Markdown renders the report for a tracking issue or a pull request comment: the new repositories with their
+// proposed owners and excludes, and the repositories skipped with the reason
+func (r RunReport) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## Repository Discovery of %s\n\n", r.Organization)
+	mode := "Dry run"
+	if !r.DryRun {
+		mode = "Run"
+	}
+	fmt.Fprintf(&b, "%s over %d repositories, %d already tracked: %d new, %d skipped, %d archived.\n\n",
+		mode, r.Repositories, r.Tracked, len(r.New), len(r.Skipped), len(r.Archived))
+	if r.Error != "" {
+		fmt.Fprintf(&b, "> [!WARNING]\n> The run stopped early: %s\n\n", markdownCell(r.Error))
+	}
+
+	if len(r.New) > 0 {
+		b.WriteString("### New Repositories\n\n")
+		b.WriteString("| Repository | Language | Proposed owners | Excludes |\n")
+		b.WriteString("|------------|----------|-----------------|----------|\n")
+		for _, repo := range r.New {
+			var notes []string
+			if len(repo.Modules) > 1 {
+				notes = append(notes, fmt.Sprintf("%d modules", len(repo.Modules)))
+			}
+			if repo.NoTests {
+				notes = append(notes, "no tests")
+			}
+			if repo.Fork {
+				notes = append(notes, "fork")
+			}
+			if repo.Visibility != "" && repo.Visibility != config.VisibilityPublic {
+				notes = append(notes, repo.Visibility)
+			}
+			language := repo.Language
+			if len(notes) > 0 {
+				language += " (" + strings.Join(notes, ", ") + ")"
+			}
+			owners := strings.Join(repo.Owners, " ")
+			if repo.OwnersSource != "" {
+				owners += " (" + repo.OwnersSource + ")"
+			}
+			var excludes []string
+			for _, dir := range repo.ExcludeDirs {
+				excludes = append(excludes, "`"+dir+"`")
+			}
+			for _, file := range repo.ExcludeFiles {
+				excludes = append(excludes, "`"+file+"`")
+			}
+			if len(excludes) == 0 {
+				excludes = []string{"—"}
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", repo.Name, language, markdownCell(owners), markdownCell(strings.Join(excludes, ", ")))
+		}
+		b.WriteString("\n")
+	}
+
+	if len(r.Skipped) > 0 {
+		b.WriteString("### Skipped\n\n")
+		for _, section := range skipHeadings {
+			var lines []string
+			for _, repo := range r.Skipped {
+				if repo.Skip == section.skip {
+					lines = append(lines, fmt.Sprintf("- %s: %s\n", repo.Name, repo.Reason))
+				}
+			}
+			if len(lines) == 0 {
+				continue
+			}
+			// Long lists, e.g. of filtered repositories, stay out of the way of the new ones
+			fmt.Fprintf(&b, "<details><summary>%s (%d)</summary>\n\n%s\n</details>\n\n", section.heading, len(lines), strings.Join(lines, ""))
+		}
+	}
+
+	if len(r.Archived) > 0 {
+		b.WriteString("### Archived\n\n")
+		b.WriteString("These tracked repositories were archived; `--apply` opens pull requests removing their configurations.\n\n")
+		for _, repo := range r.Archived {
+			fmt.Fprintf(&b, "- %s\n", repo.Name)
+		}
+		b.WriteString("\n")
+	}
+
+	if len(r.Renamed) > 0 {
+		b.WriteString("### Renamed\n\n")
+		b.WriteString("These tracked repositories were renamed; `--apply` opens pull requests moving their configurations to the new names.\n\n")
+		for _, repo := range r.Renamed {
+			fmt.Fprintf(&b, "- %s → %s\n", repo.From, repo.To)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// markdownCell escapes the pipes of a table cell
+func markdownCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+// writeMarkdownReport writes the report of a dry run as Markdown next to its configurations
+func (r *Runner) writeMarkdownReport() error {
+	path := filepath.Join(r.configWriter.DiscoveredDir(), config.DiscoveryReportFile)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(r.Report(nil).Markdown()), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	fmt.Printf("  📝 Reported this run in discovered-repos/%s\n", config.DiscoveryReportFile)
+	fmt.Println()
+	return nil
+}
@@ -0,0 +1,46 @@
+package discover_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/discover"
+)
+
+var _ = Describe("RunReport", func() {
+	It("should render the new repositories with their owners and excludes, and the skipped ones by reason", func() {
+		report := discover.RunReport{
+			Organization: "test-org",
+			DryRun:       true,
+			Repositories: 5,
+			Tracked:      1,
+			New: []discover.NewRepository{
+				{Name: "test-org/api", Language: "go", Owners: []string{"@test-org/api-team"}, OwnersSource: "codeowners",
+					ExcludeDirs: []string{"third_party/kube"}, ExcludeFiles: []string{`.*\.pb\.go$`}},
+				{Name: "test-org/infra", Language: "go", Owners: []string{"@konflux-ci/Vanguard"}, OwnersSource: "default",
+					Modules: []string{".", "tools"}, NoTests: true, Visibility: "internal"},
+			},
+			Skipped: []discover.SkippedRepository{
+				{Name: "test-org/old", Skip: discover.SkipStale, Reason: "no push in 180 days"},
+				{Name: "test-org/tools", Skip: discover.SkipAnalysis, Reason: "no Go test files"},
+				{Name: "test-org/sandbox", Skip: discover.SkipFiltered, Reason: `excluded by "test-org/sandbox*"`},
+			},
+			Archived: []discover.ArchivedRepository{{Name: "test-org/legacy"}},
+		}
+
+		Expect(report.Markdown()).To(Equal("## Repository Discovery of test-org\n\n" +
+			"Dry run over 5 repositories, 1 already tracked: 2 new, 3 skipped, 1 archived.\n\n" +
+			"### New Repositories\n\n" +
+			"| Repository | Language | Proposed owners | Excludes |\n" +
+			"|------------|----------|-----------------|----------|\n" +
+			"| test-org/api | go | @test-org/api-team (codeowners) | `third_party/kube`, `.*\\.pb\\.go$` |\n" +
+			"| test-org/infra | go (2 modules, no tests, internal) | @konflux-ci/Vanguard (default) | — |\n\n" +
+			"### Skipped\n\n" +
+			"<details><summary>Analysis failed (1)</summary>\n\n- test-org/tools: no Go test files\n\n</details>\n\n" +
+			"<details><summary>Filtered (1)</summary>\n\n- test-org/sandbox: excluded by \"test-org/sandbox*\"\n\n</details>\n\n" +
+			"<details><summary>Stale (1)</summary>\n\n- test-org/old: no push in 180 days\n\n</details>\n\n" +
+			"### Archived\n\n" +
+			"These tracked repositories were archived; `--apply` opens pull requests removing their configurations.\n\n" +
+			"- test-org/legacy\n\n"))
+	})
+})
@@ -1,84 +1,405 @@
 package discover
 
 import (
+	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/google/go-github/v66/github"
 	"github.com/konflux-ci/coverage-dashboard/internal/config"
+	"github.com/konflux-ci/coverage-dashboard/internal/display"
+	"github.com/konflux-ci/coverage-dashboard/internal/fixtures"
+	"github.com/konflux-ci/coverage-dashboard/internal/ghauth"
+	"github.com/konflux-ci/coverage-dashboard/internal/httpcache"
+	"github.com/konflux-ci/coverage-dashboard/internal/locale"
 	"github.com/konflux-ci/coverage-dashboard/internal/ownership"
 	"github.com/konflux-ci/coverage-dashboard/internal/pr"
-	"golang.org/x/oauth2"
+	"github.com/konflux-ci/coverage-dashboard/internal/schedule"
+	"github.com/konflux-ci/coverage-dashboard/internal/thirdparty"
 )
 
 // Config holds the configuration for the discovery process
 type Config struct {
 	Organization   string
 	ReposDir       string
+	ReposFile      string
 	CodeownersFile string
 	DryRun         bool
+	// FixturesDir holds recorded GitHub API responses for Offline and RecordFixtures
+	FixturesDir string
+	// Offline replays API responses from FixturesDir instead of calling GitHub
+	Offline bool
+	// RecordFixtures saves every API response to FixturesDir
+	RecordFixtures bool
+	// DefaultOwner owns repositories whose owners cannot be detected; defaults to ownership.DefaultOwner
+	DefaultOwner string
+	// KeepDiscovered keeps the configurations earlier dry runs wrote to discovered-repos/ instead of clearing them
+	KeepDiscovered bool
+	// Languages are the languages of the repositories to discover; defaults to Go
+	Languages []Language
+	// Filters skip repositories before analysis, e.g. those of discovery-filters.yaml
+	Filters config.DiscoveryFilters
+	// GraphQL lists repositories and their CODEOWNERS files in a query per 100 repositories instead of
+	// REST calls per repository; owners are still detected through REST for repositories without CODEOWNERS owners
+	GraphQL bool
+	// Concurrency is the number of repositories analyzed at once; one at a time when zero
+	Concurrency int
+	// CacheDir stores the API responses of the read client with their ETags, so later runs revalidate them with
+	// conditional requests; empty disables the cache, and so do Offline and RecordFixtures
+	CacheDir string
+	// Untested decides what happens to Go repositories without test files, one of the Untested values
+	Untested string
+	// DetectModules lists the modules of Go repositories with nested go.mod files in their configurations,
+	// so coverage is collected per module
+	DetectModules bool
+	// IncludeForks discovers forked repositories too, marked fork in their configurations; forks are skipped otherwise
+	IncludeForks bool
+	// Visibility discovers only public or only private repositories, one of the Visibility values; all when empty
+	Visibility string
+	// Provider is the forge hosting the organization and the dashboard repository, one of the Provider values;
+	// GitHub when empty
+	Provider string
+	// GitLabURL is the GitLab instance of ProviderGitLab; defaults to DefaultGitLabURL
+	GitLabURL string
+	// Schedule runs the dashboard, telling owners in pull requests when their repository appears; nil when unknown
+	Schedule *schedule.Schedule
+	// Locale is the BCP 47 tag of the locale the time of the next run is formatted in; empty is locale.Default
+	Locale string
+	// DetectThirdParty looks for upstream projects embedded in Go repositories outside vendor/, by their license files
+	// and module paths, excluding them from the coverage
+	DetectThirdParty bool
+	// DetectBy tells Go repositories by their primary language or by their go.mod files, one of the DetectBy values;
+	// DetectByLanguage when empty
+	DetectBy string
+	// DeepScan lists the files of the repositories whose primary language is not Go, discovering those with go.mod
+	// files, e.g. tooling under tools/ or cmd/, with configurations scoped to their modules
+	DeepScan bool
+	// MinActivity skips repositories without a push within that window, listed as stale; zero discovers them all
+	MinActivity time.Duration
+	// Output draws a progress bar over the repositories, or prints only the summary; each line by default
+	Output display.Mode
+	// Interactive asks the operator to accept, edit the owners of or skip each new repository before its pull
+	// request is opened; it needs apply mode
+	Interactive bool
+	// StateFile checkpoints the analyses and pull requests of the run, removed once it completes; none when empty
+	StateFile string
+	// Resume reuses the analyses and pull requests StateFile recorded of a run that did not complete
+	Resume bool
+	// ReviewRotation requests review of the pull requests of these teams, by owner, from their members in turn
+	// instead of the whole team
+	ReviewRotation map[string]pr.Rotation
+	// BaseBranch is the branch of the dashboard repository pull requests are opened against; its default branch
+	// when empty, e.g. master on mirrors
+	BaseBranch string
+	// GitHubBaseURL is the GitHub API of ProviderGitHub, e.g. that of a GitHub Enterprise Server; GITHUB_API_URL,
+	// or api.github.com, when empty
+	GitHubBaseURL string
+	// WritesPerMinute paces the requests opening pull requests, requesting reviews and labeling them, shared by all
+	// of them, so large runs stay below GitHub's secondary rate limits; zero does not pace them
+	WritesPerMinute int
+	// Only limits the run to these repositories of the organization, by name, looked up one by one instead of
+	// listing the organization, e.g. to open a pull request that failed again; archived and renamed tracked
+	// repositories are only found among them
+	Only []string
+}
+
+// Visibilities of the repositories discovery adds; internal repositories count as private
+const (
+	VisibilityPublic  = config.VisibilityPublic
+	VisibilityPrivate = config.VisibilityPrivate
+	VisibilityAll     = "all"
+)
+
+// What discovery does with Go repositories without test files
+const (
+	// UntestedFlag marks them no_tests in their configurations and pull requests
+	UntestedFlag = "flag"
+	// UntestedSkip leaves them out, listed under Skipped
+	UntestedSkip = "skip"
+	// UntestedIgnore does not look for test files, the default of embedded runners
+	UntestedIgnore = "ignore"
+)
+
+// Steps are the stages of discovery Run goes through, for tools embedding discovery to run
+// them individually, e.g. to analyze a single repository without scanning the organization
+type Steps interface {
+	// FetchRepositories lists the organization's repositories in the configured languages that are not archived
+	FetchRepositories(ctx context.Context) ([]*github.Repository, error)
+	// FilterNew drops the repositories already configured in either layout, those the filters skip, forks and
+	// repositories of another visibility
+	FilterNew(repos []*github.Repository) ([]*github.Repository, error)
+	// Analyze builds the configuration of a repository, with the excludes of its language and its detected owners
+	Analyze(ctx context.Context, repo *github.Repository) (config.RepositoryConfig, error)
+	// Write writes configurations and their CODEOWNERS entries; dry runs write them to discovered-repos/
+	Write(ctx context.Context, configs []config.RepositoryConfig) error
+	// Confirm asks the operator which configurations to add, returning those accepted
+	Confirm(ctx context.Context, configs []config.RepositoryConfig) ([]config.RepositoryConfig, error)
+	// OpenPullRequests opens one pull request per configuration on the dashboard repository
+	OpenPullRequests(ctx context.Context, configs []config.RepositoryConfig) error
+	// FindArchived lists the tracked repositories archived since, once FetchRepositories and FilterNew ran
+	FindArchived() []string
+	// RemoveArchived opens one pull request per archived repository removing its configuration
+	RemoveArchived(ctx context.Context, repos []string) error
+	// FindRenamed looks up the tracked repositories renamed since, once FetchRepositories and FilterNew ran
+	FindRenamed(ctx context.Context) map[string]string
+	// RenameRepositories opens one pull request per renamed repository moving its configuration to the new name
+	RenameRepositories(ctx context.Context, renamed map[string]string) error
+}
+
+var _ Steps = (*Runner)(nil)
+
+// OwnerDetector detects the owners of a repository and the source they were detected from
+type OwnerDetector interface {
+	Detect(ctx context.Context, org, repo string) (ownership.Detection, error)
+}
+
+// TreeDetector detects owners from the CODEOWNERS files of a tree of the default branch listed beforehand, only
+// fetching the files it has
+type TreeDetector interface {
+	DetectWithTree(ctx context.Context, org, repo string, tree *github.Tree) (ownership.Detection, error)
+}
+
+// PullRequestCreator opens the pull requests adding, removing and renaming repository configurations, editing them
+// with the writer
+type PullRequestCreator interface {
+	CreatePullRequest(ctx context.Context, cfg config.RepositoryConfig, configWriter *config.Writer) (string, error)
+	RemoveRepository(ctx context.Context, configWriter *config.Writer, reposFile, repo string) (string, error)
+	RenameRepository(ctx context.Context, configWriter *config.Writer, reposFile, from, to string) (string, error)
+}
+
+// Dependencies are the collaborators of a Runner; unset ones get the defaults NewRunner uses
+type Dependencies struct {
+	Tokens      ghauth.Tokens
+	ReadClient  *github.Client
+	WriteClient *github.Client
+	// Owners defaults to ownership detection through ReadClient
+	Owners OwnerDetector
+	// ConfigWriter defaults to a writer for the configured repos directory and CODEOWNERS file
+	ConfigWriter *config.Writer
+	// WorkDir is the checkout of the dashboard repository pull requests are opened from; defaults to the working directory
+	WorkDir string
+	// PullRequests defaults to a creator for the repository of WorkDir's origin remote
+	PullRequests PullRequestCreator
+	// Provider defaults to GitHub through ReadClient and WriteClient
+	Provider Provider
+	// Input holds the operator's answers of Interactive runs; defaults to os.Stdin
+	Input io.Reader
 }
 
 // Runner orchestrates the repository discovery process
 type Runner struct {
-	config         Config
-	githubClient   *github.Client // For general API calls and ownership detection
-	writeClient    *github.Client // For PR creation
-	ownerDetector  *ownership.Detector
-	configWriter   *config.Writer
-	existingRepos  map[string]bool
+	config        Config
+	provider      Provider
+	githubClient  *github.Client // For GraphQL queries, which only GitHub serves
+	ownerDetector OwnerDetector
+	configWriter  *config.Writer
+	workDir       string
+	prCreator     PullRequestCreator
+	existingRepos map[string]bool
+	archivedRepos map[string]bool      // Archived repositories of the organization, by full name
+	orgRepos      map[string]bool      // Every repository of the organization's listing, by lower-case full name
+	filteredRepos map[string]string    // Repositories the filters skipped, and forks, by full name, with the reason
+	staleRepos    map[string]time.Time // Repositories skipped for no push within MinActivity, by full name, with their last push
+	codeowners    map[string][]string  // CODEOWNERS files of the repositories listed through GraphQL, by full name
+	rateLimits    *rateLimitWaits      // Waits of the clients for GitHub rate limits, for the summary
+	cache         *httpcache.Transport // Cache of the read client's responses, nil when disabled
+	blobs         *httpcache.Blobs     // CODEOWNERS files cached by blob SHA, nil when disabled
+	// detected are the languages detectGoModules decided, and trees the files it listed, by repository name
+	detected map[string]Language
+	trees    map[string]*github.Tree
+	// failedPullRequests counts the pull requests OpenPullRequests, RemoveArchived and RenameRepositories could not open
+	failedPullRequests int
+	// report collects the outcome of the steps for Report
+	report RunReport
+	// input reads the answers of Confirm
+	input *bufio.Reader
+	// state is the checkpoint of the run, nil without a StateFile
+	state *State
+	// running serializes the runs of long-lived processes, which share the runner and the dashboard checkout
+	running sync.Mutex
+	// rotator keeps the turns of ReviewRotation across the pull requests of the runner, nil without rotations
+	rotator *pr.Rotator
+}
+
+// rateLimitWaits tallies the waits for GitHub rate limits reported by the clients' transports
+type rateLimitWaits struct {
+	mu    sync.Mutex
+	count int
+	total time.Duration
+}
+
+// report prints a wait in the progress output and counts it
+func (w *rateLimitWaits) report(wait ghauth.RateLimitWait) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.count++
+	w.total += wait.Wait
+	ghauth.PrintRateLimitWait(wait)
 }
 
 // NewRunner creates a new Runner instance
 func NewRunner(cfg Config) (*Runner, error) {
+	ctx := context.Background()
+
+	var transport http.RoundTripper
+	switch {
+	case cfg.Offline && cfg.RecordFixtures:
+		return nil, fmt.Errorf("--offline and --record cannot be combined")
+	case (cfg.Offline || cfg.RecordFixtures) && cfg.FixturesDir == "":
+		return nil, fmt.Errorf("--fixtures is required with --offline and --record")
+	case cfg.Offline && !cfg.DryRun:
+		return nil, fmt.Errorf("--offline cannot be combined with --apply")
+	case cfg.DefaultOwner != "" && !ownership.ValidOwner(cfg.DefaultOwner):
+		return nil, fmt.Errorf("default owner %q is not a @user or @org/team", cfg.DefaultOwner)
+	case cfg.Untested != "" && cfg.Untested != UntestedFlag && cfg.Untested != UntestedSkip && cfg.Untested != UntestedIgnore:
+		return nil, fmt.Errorf("--untested must be %s, %s or %s, got %q", UntestedFlag, UntestedSkip, UntestedIgnore, cfg.Untested)
+	case cfg.Visibility != "" && cfg.Visibility != VisibilityPublic && cfg.Visibility != VisibilityPrivate && cfg.Visibility != VisibilityAll:
+		return nil, fmt.Errorf("--visibility must be %s, %s or %s, got %q", VisibilityPublic, VisibilityPrivate, VisibilityAll, cfg.Visibility)
+	case cfg.Provider != "" && cfg.Provider != ProviderGitHub && cfg.Provider != ProviderGitLab:
+		return nil, fmt.Errorf("--provider must be %s or %s, got %q", ProviderGitHub, ProviderGitLab, cfg.Provider)
+	case cfg.DetectBy != "" && cfg.DetectBy != DetectByLanguage && cfg.DetectBy != DetectByGoMod:
+		return nil, fmt.Errorf("--detect-by must be %s or %s, got %q", DetectByLanguage, DetectByGoMod, cfg.DetectBy)
+	case cfg.DetectBy == DetectByGoMod && len(cfg.Languages) > 0 && !hasLanguage(cfg.Languages, config.LanguageGo):
+		return nil, fmt.Errorf("--detect-by %s needs %s among --languages", DetectByGoMod, config.LanguageGo)
+	case cfg.DeepScan && len(cfg.Languages) > 0 && !hasLanguage(cfg.Languages, config.LanguageGo):
+		return nil, fmt.Errorf("--deep-scan needs %s among --languages", config.LanguageGo)
+	case cfg.Interactive && cfg.DryRun:
+		return nil, fmt.Errorf("--interactive needs --apply")
+	case cfg.Provider == ProviderGitLab && cfg.GraphQL:
+		return nil, fmt.Errorf("--graphql cannot be combined with --provider %s", ProviderGitLab)
+	case cfg.Offline:
+		transport = fixtures.NewReplayer(cfg.FixturesDir)
+	case cfg.RecordFixtures:
+		transport = fixtures.NewRecorder(cfg.FixturesDir, nil)
+	}
+	var readTransport http.RoundTripper = transport
+	var cache *httpcache.Transport
+	if cfg.CacheDir != "" && !cfg.Offline && !cfg.RecordFixtures {
+		cache = httpcache.NewTransport(cfg.CacheDir, transport)
+		readTransport = cache
+	}
+
+	if cfg.Provider == ProviderGitLab {
+		return newGitLabRunner(cfg, readTransport, cache)
+	}
+
+	// GITHUB_TOKEN stands in for whichever dedicated token is not set
+	tokens, err := ghauth.TokensFromEnv().WithBaseURL(cfg.GitHubBaseURL)
+	if err != nil {
+		return nil, err
+	}
+	rateLimits := &rateLimitWaits{}
+	tokens.OnRateLimit = rateLimits.report
+	// Replayed responses cannot trip GitHub's abuse detection
+	if !cfg.Offline {
+		tokens.Writes = ghauth.NewWriteLimiter(cfg.WritesPerMinute)
+	}
+	if tokens.Shared() {
+		fmt.Println("⚠️  Warning: using GITHUB_TOKEN as both read and write token")
+		fmt.Println("   Set GITHUB_READ_TOKEN and GITHUB_WRITE_TOKEN to limit each token to its role")
+		fmt.Println()
+	}
+
 	// Create read client for ownership detection (teams/collaborators)
-	readToken := os.Getenv("GITHUB_READ_TOKEN")
+	if tokens.App != nil {
+		if err := tokens.App.Validate(); err != nil {
+			return nil, classify(ErrCredentials, fmt.Errorf("invalid GitHub App credentials: %w", err))
+		}
+	}
 
-	var readClient *github.Client
-	if readToken != "" {
-		ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: readToken})
-		tc := oauth2.NewClient(context.Background(), ts)
-		readClient = github.NewClient(tc)
-	} else {
-		readClient = github.NewClient(nil)
-		fmt.Println("⚠️  Warning: GITHUB_READ_TOKEN not set, using unauthenticated API calls")
+	readClient := tokens.ReadClientWithTransport(ctx, readTransport)
+	if tokens.ReadSource == "" && !cfg.Offline {
+		fmt.Println("⚠️  Warning: neither GITHUB_READ_TOKEN nor GITHUB_TOKEN set, using unauthenticated API calls")
 		fmt.Println("   Ownership detection will be limited to CODEOWNERS files only")
 		fmt.Println()
 	}
 
 	// Create write client for PR creation
-	writeToken := os.Getenv("GITHUB_WRITE_TOKEN")
+	// For dry-run, write client is not needed
+	if tokens.WriteSource == "" && !cfg.DryRun {
+		return nil, classify(ErrCredentials, fmt.Errorf("GITHUB_WRITE_TOKEN or GITHUB_TOKEN is required for --apply (needed for creating PRs), or a GitHub App configured with GITHUB_APP_ID"))
+	}
+	writeClient := tokens.WriteClientWithTransport(ctx, transport)
 
-	var writeClient *github.Client
-	if writeToken != "" {
-		ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: writeToken})
-		tc := oauth2.NewClient(context.Background(), ts)
-		writeClient = github.NewClient(tc)
-	} else {
-		if !cfg.DryRun {
-			return nil, fmt.Errorf("GITHUB_WRITE_TOKEN is required for --apply (needed for creating PRs)")
-		}
-		// For dry-run, write client is not needed
-		writeClient = github.NewClient(nil)
+	deps := Dependencies{
+		Tokens:      tokens,
+		ReadClient:  readClient,
+		WriteClient: writeClient,
+	}
+	// CODEOWNERS files rarely change, so the cache keeps their contents by blob SHA to skip fetching them again
+	var blobs *httpcache.Blobs
+	if cache != nil {
+		blobs = httpcache.NewBlobs(filepath.Join(cfg.CacheDir, httpcache.BlobsDir))
+		deps.Owners = ownership.NewCachingDetector(readClient, cfg.DefaultOwner, blobs)
+	}
+	runner := NewRunnerWithDependencies(cfg, deps)
+	runner.rateLimits = rateLimits
+	runner.cache = cache
+	runner.blobs = blobs
+	return runner, nil
+}
+
+// NewRunnerWithDependencies creates a Runner with the given collaborators instead of ones built from the environment
+func NewRunnerWithDependencies(cfg Config, deps Dependencies) *Runner {
+	if deps.ReadClient == nil {
+		deps.ReadClient = github.NewClient(nil)
+	}
+	if deps.WriteClient == nil {
+		deps.WriteClient = deps.ReadClient
+	}
+	if deps.Owners == nil {
+		deps.Owners = ownership.NewDetector(deps.ReadClient, cfg.DefaultOwner)
+	}
+	if deps.ConfigWriter == nil {
+		deps.ConfigWriter = config.NewWriter(cfg.ReposDir, cfg.CodeownersFile)
+	}
+	if deps.Provider == nil {
+		deps.Provider = &gitHubProvider{tokens: deps.Tokens, read: deps.ReadClient, write: deps.WriteClient}
+	}
+	if len(cfg.Languages) == 0 {
+		cfg.Languages, _ = ParseLanguages(DefaultLanguages)
+	}
+	if deps.Input == nil {
+		deps.Input = os.Stdin
 	}
 
 	return &Runner{
 		config:        cfg,
-		githubClient:  readClient,
-		writeClient:   writeClient,
-		ownerDetector: ownership.NewDetector(readClient, ""),
-		configWriter:  config.NewWriter(cfg.ReposDir, cfg.CodeownersFile),
-	}, nil
+		provider:      deps.Provider,
+		githubClient:  deps.ReadClient,
+		ownerDetector: deps.Owners,
+		configWriter:  deps.ConfigWriter,
+		workDir:       deps.WorkDir,
+		prCreator:     deps.PullRequests,
+		rateLimits:    &rateLimitWaits{},
+		input:         newInput(deps.Input),
+	}
 }
 
 // Run executes the discovery process
-func (r *Runner) Run(ctx context.Context) error {
+func (r *Runner) Run(ctx context.Context) (err error) {
+	r.running.Lock()
+	defer r.running.Unlock()
+	// Scheduled runs reuse the runner, so nothing of the previous run is reported again
+	r.report, r.failedPullRequests, r.state = RunReport{}, 0, nil
+
 	fmt.Println("🔍 Konflux-CI Repository Auto-Discovery")
 	fmt.Println("========================================")
 
-	if r.config.DryRun {
+	if r.config.Offline {
+		fmt.Printf("📼 Mode: OFFLINE (replaying API responses from %s)\n", r.config.FixturesDir)
+	} else if r.config.DryRun {
 		fmt.Println("📋 Mode: DRY RUN (preview only)")
 		fmt.Println("   Use --apply to create files and PRs")
 	} else {
@@ -86,28 +407,115 @@ func (r *Runner) Run(ctx context.Context) error {
 	}
 	fmt.Println()
 
-	// Step 1: Fetch all Go repositories
-	fmt.Println("→ Fetching Go repositories from", r.config.Organization, "organization...")
-	repos, err := r.fetchGoRepositories(ctx)
+	if _, err := r.loadState(); err != nil {
+		return err
+	}
+	defer r.finishState(&err)
+
+	// Files of earlier dry runs would pass for discoveries of this one
+	if r.config.DryRun && !r.config.KeepDiscovered {
+		cleared, err := r.configWriter.ClearDiscovered()
+		if err != nil {
+			return fmt.Errorf("failed to clear discovered-repos/: %w", err)
+		}
+		if cleared > 0 {
+			fmt.Printf("🧹 Cleared %d configurations of earlier dry runs from discovered-repos/\n", cleared)
+			fmt.Println()
+		}
+	}
+
+	// Fail fast instead of on the first push when the tokens lack permissions
+	if !r.config.DryRun {
+		fmt.Println("→ Verifying token permissions...")
+		if err := r.verifyTokens(ctx); err != nil {
+			return classify(ErrCredentials, fmt.Errorf("token verification failed:\n%w", err))
+		}
+		fmt.Println("  ✅ Tokens can read the organization and create pull requests")
+		fmt.Println()
+	}
+
+	// Step 1: Fetch all repositories in the configured languages
+	languages := languageNames(r.config.Languages)
+	if len(r.config.Only) > 0 {
+		fmt.Printf("→ Fetching %s of %s organization...\n", strings.Join(r.config.Only, ", "), r.config.Organization)
+	} else {
+		fmt.Printf("→ Fetching %s repositories from %s organization...\n", languages, r.config.Organization)
+	}
+	repos, err := r.FetchRepositories(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to fetch repositories: %w", err)
+		return classify(ErrFetch, fmt.Errorf("failed to fetch repositories: %w", err))
 	}
-	fmt.Printf("  ✅ Found %d Go repositories\n", len(repos))
+	r.report.Repositories = len(repos)
+	fmt.Printf("  ✅ Found %d %s repositories\n", len(repos), languages)
 	fmt.Println()
 
-	// Step 2: Load currently tracked repositories
-	fmt.Println("→ Checking currently tracked repositories...")
-	if err := r.loadExistingRepos(); err != nil {
+	// Step 2: Find new repositories
+	fmt.Println("→ Identifying new repositories to add...")
+	newRepos, err := r.FilterNew(repos)
+	if err != nil {
 		return fmt.Errorf("failed to load existing repos: %w", err)
 	}
 	fmt.Printf("  ✅ Currently tracking %d repositories\n", len(r.existingRepos))
-	fmt.Println()
+	if len(r.filteredRepos) > 0 {
+		fmt.Printf("  🚫 Skipped %d repositories by discovery filters:\n", len(r.filteredRepos))
+		for _, repo := range sortedKeys(r.filteredRepos) {
+			fmt.Printf("    → %s: %s\n", repo, r.filteredRepos[repo])
+		}
+	}
+	if len(r.staleRepos) > 0 {
+		fmt.Printf("  💤 Skipped %d stale repositories without a push in %s:\n", len(r.staleRepos), r.activityWindow())
+		for _, repo := range sortedKeys(r.staleRepos) {
+			fmt.Printf("    → %s: last push %s\n", repo, r.staleRepos[repo].UTC().Format(time.DateOnly))
+		}
+	}
+
+	// Archived repositories can no longer change, but their configurations stay until removed
+	archived := r.FindArchived()
+	if len(archived) > 0 {
+		fmt.Printf("  🗄️  %d tracked repositories were archived:\n", len(archived))
+		for _, repo := range archived {
+			fmt.Printf("    → %s\n", repo)
+		}
+		if !r.config.DryRun {
+			if err := r.RemoveArchived(ctx, archived); err != nil {
+				return fmt.Errorf("failed to create removal pull requests: %w", err)
+			}
+		} else {
+			for _, repo := range archived {
+				r.reportArchived(repo, "", nil)
+			}
+		}
+	}
+
+	// The old names of renamed repositories redirect to them, which would otherwise be discovered again
+	if renamed := r.FindRenamed(ctx); len(renamed) > 0 {
+		fmt.Printf("  ✏️  %d tracked repositories were renamed:\n", len(renamed))
+		for _, from := range sortedKeys(renamed) {
+			fmt.Printf("    → %s → %s\n", from, renamed[from])
+		}
+		newRepos = r.withoutRenamed(newRepos, renamed)
+		if !r.config.DryRun {
+			if err := r.RenameRepositories(ctx, renamed); err != nil {
+				return fmt.Errorf("failed to create rename pull requests: %w", err)
+			}
+		} else {
+			for _, from := range sortedKeys(renamed) {
+				r.reportRenamed(from, renamed[from], "", nil)
+			}
+		}
+	}
 
-	// Step 3: Find new repositories
-	fmt.Println("→ Identifying new repositories to add...")
-	newRepos := r.filterNewRepositories(repos)
 	if len(newRepos) == 0 {
-		fmt.Println("  ✅ No new repositories found. All Go repos are already tracked!")
+		if r.config.DryRun {
+			if err := r.writeIndex(len(repos), nil, nil, archived); err != nil {
+				return err
+			}
+			if err := r.writeMarkdownReport(); err != nil {
+				return err
+			}
+		}
+		r.config.Output.Summary()
+		fmt.Printf("  ✅ No new repositories found. All %s repos are already tracked!\n", languages)
 		fmt.Println()
 		fmt.Println("=========================================")
 		fmt.Println("Summary: Up to date!")
@@ -117,167 +525,396 @@ func (r *Runner) Run(ctx context.Context) error {
 	fmt.Printf("  ✅ Found %d new repositories to add\n", len(newRepos))
 	fmt.Println()
 
-	// Step 4: Analyze each repository
-	fmt.Printf("Analyzing %d new repositories...\n", len(newRepos))
+	// Step 3: Analyze each repository
+	if workers := r.concurrency(); workers > 1 {
+		fmt.Printf("Analyzing %d new repositories, %d at a time...\n", len(newRepos), workers)
+	} else {
+		fmt.Printf("Analyzing %d new repositories...\n", len(newRepos))
+	}
 	fmt.Println()
 
-	var repoConfigs []config.RepositoryConfig
-	for i, repo := range newRepos {
-		fmt.Printf("📦 [%d/%d] %s\n", i+1, len(newRepos), repo.GetName())
-
-		// Skip if PR already exists (in --apply mode)
-		if !r.config.DryRun {
-			if r.prAlreadyExists(ctx, repo.GetName()) {
-				fmt.Printf("  ⏭️  Skipped: PR already exists\n")
-				continue
-			}
-		}
-
-		cfg, err := r.analyzeRepository(ctx, repo)
-		if err != nil {
-			fmt.Printf("  ⚠️  Skipped: %v\n", err)
-			continue
-		}
-
-		repoConfigs = append(repoConfigs, cfg)
+	r.config.Output.Start(len(newRepos))
+	repoConfigs, skipped, err := r.analyzeAll(ctx, newRepos)
+	r.config.Output.Finish()
+	if err != nil {
+		return err
 	}
 	fmt.Println()
 
-	// Step 5: Write configuration files (or create PRs which will write them)
+	// Step 4: Write configuration files (or create PRs which will write them)
 	if r.config.DryRun {
 		// In dry-run mode, just write configs to discovered-repos/ directory
-		if err := r.writeConfigurations(ctx, repoConfigs); err != nil {
+		if err := r.Write(ctx, repoConfigs); err != nil {
 			return fmt.Errorf("failed to write configurations: %w", err)
 		}
+		if err := r.writeIndex(len(repos), repoConfigs, skipped, archived); err != nil {
+			return err
+		}
+		if err := r.writeMarkdownReport(); err != nil {
+			return err
+		}
 	} else {
+		// The operator has the last word on what is proposed to repository owners
+		if r.config.Interactive {
+			if repoConfigs, err = r.Confirm(ctx, repoConfigs); err != nil {
+				return err
+			}
+		}
 		// In apply mode, write configs as part of PR creation
 		// (each config is written after its branch is created to avoid git reset issues)
-		if err := r.createPullRequests(ctx, repoConfigs); err != nil {
+		if err := r.OpenPullRequests(ctx, repoConfigs); err != nil {
 			return fmt.Errorf("failed to create pull requests: %w", err)
 		}
 	}
 
 	// Print summary
+	r.config.Output.Summary()
 	r.printSummary(len(repos), len(newRepos), len(repoConfigs))
 
 	return nil
 }
 
-func (r *Runner) fetchGoRepositories(ctx context.Context) ([]*github.Repository, error) {
-	opts := &github.RepositoryListByOrgOptions{
-		Type: "all",
-		ListOptions: github.ListOptions{
-			PerPage: 100,
-		},
+// verifyTokens checks the credentials of the provider can do everything --apply needs,
+// reporting all missing scopes and permissions at once
+func (r *Runner) verifyTokens(ctx context.Context) error {
+	var problems []error
+	currentRepo, err := r.getCurrentRepoName(ctx)
+	if err != nil {
+		problems = append(problems, fmt.Errorf("  - failed to determine the dashboard repository: %w", err))
+	}
+	problems = append(problems, r.provider.Verify(ctx, r.config.Organization, currentRepo))
+	return errors.Join(problems...)
+}
+
+// FetchRepositories lists the organization's repositories in the configured languages that are not archived
+func (r *Runner) FetchRepositories(ctx context.Context) ([]*github.Repository, error) {
+	if len(r.config.Only) > 0 {
+		return r.fetchOnly(ctx)
+	}
+	if r.config.GraphQL {
+		return r.fetchRepositoriesGraphQL(ctx)
+	}
+
+	repos, err := r.provider.ListRepositories(ctx, r.config.Organization)
+	if err != nil {
+		return nil, err
 	}
 
+	// Filter for repositories in the configured languages that are not archived
 	var allRepos []*github.Repository
-	for {
-		repos, resp, err := r.githubClient.Repositories.ListByOrg(ctx, r.config.Organization, opts)
-		if err != nil {
-			return nil, err
+	r.archivedRepos = make(map[string]bool)
+	r.orgRepos = make(map[string]bool)
+	for _, repo := range repos {
+		r.orgRepos[strings.ToLower(fmt.Sprintf("%s/%s", r.config.Organization, repo.GetName()))] = true
+		if repo.GetArchived() {
+			r.archivedRepos[fmt.Sprintf("%s/%s", r.config.Organization, repo.GetName())] = true
+			continue
 		}
-
-		// Filter for Go repositories that are not archived
-		for _, repo := range repos {
-			if repo.GetLanguage() == "Go" && !repo.GetArchived() {
-				allRepos = append(allRepos, repo)
-			}
+		if r.listed(repo) {
+			allRepos = append(allRepos, repo)
 		}
-
-		if resp.NextPage == 0 {
-			break
+	}
+	if r.scansForGoModules() {
+		if allRepos, err = r.detectGoModules(ctx, allRepos); err != nil {
+			return nil, err
 		}
-		opts.Page = resp.NextPage
 	}
 
+	sortRepositories(allRepos)
 	return allRepos, nil
 }
 
+// sortRepositories sorts repositories by name
+// The API order changes with pushes; sorting keeps generated files and pull requests stable
+func sortRepositories(repos []*github.Repository) {
+	sort.Slice(repos, func(i, j int) bool { return repos[i].GetName() < repos[j].GetName() })
+}
+
 func (r *Runner) loadExistingRepos() error {
 	r.existingRepos = make(map[string]bool)
 
-	entries, err := os.ReadDir(r.config.ReposDir)
+	// Repositories count as tracked in either layout: per-repo files or the single repos file
+	repositories, err := config.LoadRepositories(r.config.ReposDir, r.config.ReposFile)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil
-		}
 		return err
 	}
 
-	for _, entry := range entries {
-		if entry.IsDir() {
+	for _, file := range repositories.InvalidFiles() {
+		fmt.Printf("  ⚠️  Warning: failed to parse %s: %v\n", file, repositories.Invalid[file])
+	}
+	for _, entry := range repositories.Entries {
+		r.existingRepos[entry.Config.Name] = true
+	}
+
+	return nil
+}
+
+// FilterNew drops the repositories already configured in either layout, those the filters skip, forks
+// unless IncludeForks is set, repositories of another visibility than Visibility and those without a push within
+// MinActivity
+// Filters never drop tracked repositories, which are removed through their configurations
+func (r *Runner) FilterNew(repos []*github.Repository) ([]*github.Repository, error) {
+	if err := r.loadExistingRepos(); err != nil {
+		return nil, err
+	}
+
+	var newRepos []*github.Repository
+	r.filteredRepos = make(map[string]string)
+	r.staleRepos = make(map[string]time.Time)
+	activeSince := time.Now().Add(-r.config.MinActivity)
+	for _, repo := range repos {
+		fullName := fmt.Sprintf("%s/%s", r.config.Organization, repo.GetName())
+		if r.existingRepos[fullName] {
 			continue
 		}
-
-		cfg, err := config.LoadRepositoryConfig(r.config.ReposDir, entry.Name())
-		if err != nil {
-			fmt.Printf("  ⚠️  Warning: failed to parse %s: %v\n", entry.Name(), err)
+		if allowed, reason := r.config.Filters.Allows(fullName); !allowed {
+			r.filteredRepos[fullName] = reason
+			continue
+		}
+		if repo.GetFork() && !r.config.IncludeForks {
+			r.filteredRepos[fullName] = "fork, set --include-forks to discover it"
 			continue
 		}
+		if visibility := visibilityOf(repo); !r.discoversVisibility(visibility) {
+			r.filteredRepos[fullName] = fmt.Sprintf("%s, set --visibility %s to discover it", visibility, VisibilityAll)
+			continue
+		}
+		if r.config.MinActivity > 0 && stale(repo, activeSince) {
+			r.staleRepos[fullName] = repo.GetPushedAt().Time
+			continue
+		}
+		newRepos = append(newRepos, repo)
+	}
+	return newRepos, nil
+}
 
-		r.existingRepos[cfg.Name] = true
+// visibilityOf returns the visibility of a repository, from its private flag when the API leaves it out
+func visibilityOf(repo *github.Repository) string {
+	switch {
+	case repo.GetVisibility() != "":
+		return strings.ToLower(repo.GetVisibility())
+	case repo.GetPrivate():
+		return config.VisibilityPrivate
+	default:
+		return config.VisibilityPublic
 	}
+}
 
-	return nil
+// discoversVisibility reports whether repositories of a visibility are discovered
+// Internal repositories are only visible inside the enterprise, so they are discovered as private ones
+func (r *Runner) discoversVisibility(visibility string) bool {
+	switch r.config.Visibility {
+	case VisibilityPublic:
+		return visibility == config.VisibilityPublic
+	case VisibilityPrivate:
+		return visibility != config.VisibilityPublic
+	default:
+		return true
+	}
 }
 
-func (r *Runner) filterNewRepositories(repos []*github.Repository) []*github.Repository {
-	var newRepos []*github.Repository
-	for _, repo := range repos {
-		fullName := fmt.Sprintf("%s/%s", r.config.Organization, repo.GetName())
-		if !r.existingRepos[fullName] {
-			newRepos = append(newRepos, repo)
+// FindArchived lists the tracked repositories FetchRepositories saw archived, whatever their language
+// It needs FetchRepositories and FilterNew to have run
+func (r *Runner) FindArchived() []string {
+	var archived []string
+	for repo := range r.existingRepos {
+		if r.archivedRepos[repo] {
+			archived = append(archived, repo)
 		}
 	}
-	return newRepos
+	sort.Strings(archived)
+	return archived
 }
 
-func (r *Runner) analyzeRepository(ctx context.Context, repo *github.Repository) (config.RepositoryConfig, error) {
+// Analyze builds the configuration of a repository with the common excludes of its language and its detected owners
+func (r *Runner) Analyze(ctx context.Context, repo *github.Repository) (config.RepositoryConfig, error) {
+	return r.analyze(ctx, repo, os.Stdout)
+}
+
+// analyze builds the configuration of a repository like Analyze, printing its progress to out
+func (r *Runner) analyze(ctx context.Context, repo *github.Repository, out io.Writer) (config.RepositoryConfig, error) {
 	fullName := fmt.Sprintf("%s/%s", r.config.Organization, repo.GetName())
+	language, ok := r.languageOf(repo)
+	if !ok {
+		return config.RepositoryConfig{}, fmt.Errorf("language %q is not one of %s", repo.GetLanguage(), languageNames(r.config.Languages))
+	}
+
+	// Repositories without tests only add noise to the dashboard, and repositories with several modules
+	// need their coverage collected in each
+	noTests := false
+	var modules []string
+	var thirdPartyTrees []thirdparty.Tree
+	// The tree also tells the owner detector which CODEOWNERS files exist
+	var tree *github.Tree
+	lookForTests := r.config.Untested == UntestedFlag || r.config.Untested == UntestedSkip
+	if language.Key == config.LanguageGo && (lookForTests || r.config.DetectModules || r.config.DetectThirdParty || r.scansForGoModules()) {
+		var err error
+		if tree, err = r.tree(ctx, repo); err != nil {
+			fmt.Fprintf(out, "  ⚠️  Could not list files, assuming tests and a single module: %v\n", err)
+		} else {
+			hasTests := !lookForTests || hasTestFiles(tree)
+			switch {
+			case !hasTests && r.config.Untested == UntestedSkip:
+				return config.RepositoryConfig{}, fmt.Errorf("no Go test files")
+			case !hasTests && r.config.Untested == UntestedFlag:
+				noTests = true
+				fmt.Fprintf(out, "  🧪 No Go test files, marked no_tests\n")
+			}
+			switch nested := goModules(tree); {
+			// go test cannot run at the root of repositories without a root module, so their Go sub-projects are
+			// collected module by module
+			case !tree.GetTruncated() && len(nested) > 0 && !slices.Contains(nested, config.RootModule):
+				modules = nested
+			case !r.config.DetectModules:
+			case tree.GetTruncated():
+				fmt.Fprintf(out, "  ⚠️  Too many files to look for nested modules, assuming a single module\n")
+			default:
+				modules = goModules(tree)
+			}
+			// Upstream projects copied into the repository would count someone else's code in its coverage
+			switch {
+			case !r.config.DetectThirdParty:
+			case tree.GetTruncated():
+				fmt.Fprintf(out, "  ⚠️  Too many files to look for embedded third-party trees\n")
+			default:
+				if thirdPartyTrees, err = r.thirdPartyTrees(ctx, repo, tree); err != nil {
+					fmt.Fprintf(out, "  ⚠️  Could not look for embedded third-party trees: %v\n", err)
+				}
+				for _, embedded := range thirdPartyTrees {
+					fmt.Fprintf(out, "  🧩 Embedded third-party tree %s (%s), excluded\n", embedded.Dir, embedded.Reason())
+				}
+				modules = withoutThirdParty(modules, thirdPartyTrees)
+			}
+		}
+	}
 
-	// Detect ownership
-	owners, err := r.ownerDetector.DetectOwners(ctx, r.config.Organization, repo.GetName())
+	// Detect ownership, from the CODEOWNERS files GraphQL fetched when the detector can use them
+	detection, err := r.detectOwners(ctx, fullName, repo.GetName(), tree)
 	if err != nil {
-		owners = []string{"@konflux-ci/Vanguard"}
-		fmt.Printf("  👥 Owners: %v (default - %s)\n", owners, err.Error())
+		detection = ownership.Detection{Owners: []string{r.defaultOwner()}, Source: config.OwnersSourceDefault}
+		fmt.Fprintf(out, "  👥 Owners: %v (default - %s)\n", detection.Owners, err.Error())
 	} else {
-		fmt.Printf("  👥 Owners: %v\n", owners)
-	}
-
-	// Apply common exclude patterns - repository owners can adjust in PR
-	excludeDirs := []string{
-		"vendor/",
-		".github/",
-		".tekton/",
-		"hack/",
-		"proto/",
-		"test/",
-		"tests/",
-		"integration-tests/",
-		"/fake(/|$)",
-		"/mock(s)?(/|$)",
-		"/e2e(-tests)?(/|$)",
-		"docs/",
-	}
-
-	excludeFiles := []string{
-		"zz_generated.deepcopy.go",
-		"openapi_generated.go",
-		"*.pb.go",
-		"mock_*.go",
-		"*_mock.go",
-	}
-
-	return config.RepositoryConfig{
-		Name:         fullName,
-		ExcludeDirs:  excludeDirs,
-		ExcludeFiles: excludeFiles,
-		Owners:       owners,
-	}, nil
-}
-
-func (r *Runner) writeConfigurations(ctx context.Context, configs []config.RepositoryConfig) error {
+		fmt.Fprintf(out, "  👥 Owners: %v (from %s)\n", detection.Owners, detection.Source)
+	}
+	// Seconds are enough to audit when owners were determined, and keep the generated YAML short
+	detectedAt := time.Now().UTC().Truncate(time.Second)
+
+	// Apply common exclude patterns of the language - repository owners can adjust in PR
+	cfg := config.RepositoryConfig{
+		Name:             fullName,
+		ExcludeDirs:      append([]string(nil), language.ExcludeDirs...),
+		ExcludeFiles:     append([]string(nil), language.ExcludeFiles...),
+		Owners:           detection.Owners,
+		OwnersSource:     detection.Source,
+		OwnersDetectedAt: &detectedAt,
+		NoTests:          noTests,
+		Fork:             repo.GetFork(),
+		Visibility:       visibilityOf(repo),
+	}
+	if cfg.Fork {
+		fmt.Fprintf(out, "  🍴 Fork of another repository, marked fork\n")
+	}
+	if !cfg.IsPublic() {
+		fmt.Fprintf(out, "  🔒 Visibility: %s, its detailed reports will not be published\n", cfg.Visibility)
+	}
+	// Each module starts from the excludes of the language, instead of the repository
+	if len(modules) > 1 || (len(modules) == 1 && modules[0] != config.RootModule) {
+		for _, module := range modules {
+			cfg.Modules = append(cfg.Modules, config.ModuleConfig{
+				Path:         module,
+				ExcludeDirs:  append([]string(nil), language.ExcludeDirs...),
+				ExcludeFiles: append([]string(nil), language.ExcludeFiles...),
+			})
+		}
+		cfg.ExcludeDirs, cfg.ExcludeFiles = nil, nil
+		fmt.Fprintf(out, "  📦 Modules: %s\n", strings.Join(modules, ", "))
+	}
+	excludeThirdParty(&cfg, thirdPartyTrees)
+	// Go stays implicit, so configurations of Go repositories read as before
+	if language.Key != config.LanguageGo {
+		cfg.Language = language.Key
+		fmt.Fprintf(out, "  🔤 Language: %s (coverage is not collected yet)\n", language.GitHubName)
+	}
+	return cfg, nil
+}
+
+// detectOwners detects the owners of a repository, reusing the CODEOWNERS files listed through GraphQL, or else the
+// tree of the repository when it was listed
+func (r *Runner) detectOwners(ctx context.Context, fullName, name string, tree *github.Tree) (ownership.Detection, error) {
+	if codeowners, ok := r.codeowners[fullName]; ok {
+		if detector, ok := r.ownerDetector.(CodeownersDetector); ok {
+			return detector.DetectWithCodeowners(ctx, r.config.Organization, name, codeowners)
+		}
+	}
+	if detector, ok := r.ownerDetector.(TreeDetector); ok && tree != nil {
+		return detector.DetectWithTree(ctx, r.config.Organization, name, tree)
+	}
+	return r.ownerDetector.Detect(ctx, r.config.Organization, name)
+}
+
+// hasTestFiles reports whether a tree has Go test files, outside vendor directories
+// Trees too large for a single response are assumed to have some
+func hasTestFiles(tree *github.Tree) bool {
+	for _, entry := range tree.Entries {
+		path := entry.GetPath()
+		if entry.GetType() == "blob" && strings.HasSuffix(path, "_test.go") && !strings.HasPrefix(path, "vendor/") && !strings.Contains(path, "/vendor/") {
+			return true
+		}
+	}
+	return tree.GetTruncated()
+}
+
+// goModules returns the directories of the go.mod files of a tree, sorted, "." for the root
+// Directories the go command ignores are skipped: vendor, testdata and those starting with "." or "_"
+func goModules(tree *github.Tree) []string {
+	var modules []string
+	for _, entry := range tree.Entries {
+		if entry.GetType() != "blob" || path.Base(entry.GetPath()) != "go.mod" {
+			continue
+		}
+		dir := path.Dir(entry.GetPath())
+		ignored := false
+		for _, element := range strings.Split(dir, "/") {
+			if element == "vendor" || element == "testdata" || (element != "." && (strings.HasPrefix(element, ".") || strings.HasPrefix(element, "_"))) {
+				ignored = true
+			}
+		}
+		if !ignored {
+			modules = append(modules, dir)
+		}
+	}
+	sort.Strings(modules)
+	return modules
+}
+
+// languageOf returns the configured language of a repository, by its primary language on GitHub unless
+// detectGoModules decided it. Repositories without one, e.g. built by tools analyzing a single repository, get the
+// first configured language
+func (r *Runner) languageOf(repo *github.Repository) (Language, bool) {
+	if language, ok := r.detected[repo.GetName()]; ok {
+		return language, true
+	}
+	if repo.GetLanguage() == "" {
+		return r.config.Languages[0], true
+	}
+	for _, language := range r.config.Languages {
+		if strings.EqualFold(repo.GetLanguage(), language.GitHubName) {
+			return language, true
+		}
+	}
+	return Language{}, false
+}
+
+// defaultOwner returns the owner of repositories whose owners cannot be detected
+func (r *Runner) defaultOwner() string {
+	if r.config.DefaultOwner != "" {
+		return r.config.DefaultOwner
+	}
+	return ownership.DefaultOwner
+}
+
+// Write writes configurations and their CODEOWNERS entries; dry runs write them to discovered-repos/
+func (r *Runner) Write(ctx context.Context, configs []config.RepositoryConfig) error {
 	if len(configs) == 0 {
 		fmt.Println("📝 No configurations to generate")
 		return nil
@@ -301,43 +938,157 @@ func (r *Runner) writeConfigurations(ctx context.Context, configs []config.Repos
 	return nil
 }
 
-func (r *Runner) createPullRequests(ctx context.Context, configs []config.RepositoryConfig) error {
-	if len(configs) == 0 {
-		return nil
+// writeIndex summarizes the discoveries of a dry run in discovered-repos/, listing the archived repositories
+// --apply would remove and the files kept from earlier runs apart
+func (r *Runner) writeIndex(totalRepos int, configs []config.RepositoryConfig, skipped map[string]string, archived []string) error {
+	files, err := r.configWriter.DiscoveredFiles()
+	if err != nil {
+		return err
+	}
+	current := make(map[string]bool)
+	for _, cfg := range configs {
+		file, _ := config.ConfigFilename(cfg.Name)
+		current[file] = true
 	}
 
-	fmt.Printf("🔀 Creating %d pull requests...\n", len(configs))
+	var b strings.Builder
+	b.WriteString("# Discovered Repositories\n\n")
+	fmt.Fprintf(&b, "Dry run of %s on the %s organization: %d %s repositories, %d already tracked, %d configurations generated.\n\n",
+		time.Now().UTC().Format(time.RFC3339), r.config.Organization, totalRepos, languageNames(r.config.Languages), len(r.existingRepos), len(configs))
+
+	if len(configs) > 0 {
+		b.WriteString("| Repository | Language | Configuration | Owners |\n")
+		b.WriteString("|------------|----------|---------------|--------|\n")
+		for _, cfg := range configs {
+			file, _ := config.ConfigFilename(cfg.Name)
+			language := cfg.Language
+			if language == "" {
+				language = config.LanguageGo
+			}
+			if cfg.NoTests {
+				language += " (no tests)"
+			}
+			if len(cfg.Modules) > 1 {
+				language += fmt.Sprintf(" (%d modules)", len(cfg.Modules))
+			}
+			if cfg.Fork {
+				language += " (fork)"
+			}
+			if !cfg.IsPublic() {
+				language += " (" + cfg.Visibility + ")"
+			}
+			fmt.Fprintf(&b, "| %s | %s | [%s](%s) | %s (%s) |\n", cfg.Name, language, file, file, strings.Join(cfg.Owners, " "), cfg.OwnersSource)
+		}
+		b.WriteString("\n")
+	}
 
-	// Get current working directory
-	workDir, err := os.Getwd()
-	if err != nil {
-		return fmt.Errorf("failed to get working directory: %w", err)
+	if len(skipped) > 0 {
+		b.WriteString("## Skipped\n\n")
+		for _, name := range sortedKeys(skipped) {
+			fmt.Fprintf(&b, "- %s: %s\n", name, skipped[name])
+		}
+		b.WriteString("\n")
 	}
 
-	// Extract repository name from git remote
-	currentRepo, err := r.getCurrentRepoName(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to get current repository name: %w", err)
+	if len(r.filteredRepos) > 0 {
+		b.WriteString("## Filtered\n\n")
+		b.WriteString("These repositories are skipped by the discovery filters.\n\n")
+		for _, repo := range sortedKeys(r.filteredRepos) {
+			fmt.Fprintf(&b, "- %s: %s\n", repo, r.filteredRepos[repo])
+		}
+		b.WriteString("\n")
 	}
 
-	// Get default branch from GitHub API
-	baseBranch, err := r.getDefaultBranch(ctx, currentRepo)
-	if err != nil {
-		return fmt.Errorf("failed to get default branch: %w", err)
+	if len(r.staleRepos) > 0 {
+		b.WriteString("## Stale\n\n")
+		fmt.Fprintf(&b, "These repositories had no push in %s; lower `--min-activity` to discover them.\n\n", r.activityWindow())
+		for _, repo := range sortedKeys(r.staleRepos) {
+			fmt.Fprintf(&b, "- %s: last push %s\n", repo, r.staleRepos[repo].UTC().Format(time.DateOnly))
+		}
+		b.WriteString("\n")
 	}
 
-	// Use writeClient for PR creation (may have different permissions than readClient)
-	prCreator := pr.NewCreator(r.writeClient, workDir, r.config.Organization, currentRepo, baseBranch)
+	if len(archived) > 0 {
+		b.WriteString("## Archived\n\n")
+		b.WriteString("These tracked repositories were archived; `--apply` opens pull requests removing their configurations.\n\n")
+		for _, repo := range archived {
+			fmt.Fprintf(&b, "- %s\n", repo)
+		}
+		b.WriteString("\n")
+	}
+
+	var kept []string
+	for _, file := range files {
+		if !current[file] {
+			kept = append(kept, file)
+		}
+	}
+	if len(kept) > 0 {
+		b.WriteString("## Kept From Earlier Runs\n\n")
+		b.WriteString("These files were not generated by this run; they may be tracked or deleted since.\n\n")
+		for _, file := range kept {
+			fmt.Fprintf(&b, "- [%s](%s)\n", file, file)
+		}
+		b.WriteString("\n")
+	}
+
+	path := filepath.Join(r.configWriter.DiscoveredDir(), config.DiscoveredIndexFile)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	fmt.Printf("  📇 Summarized this run in discovered-repos/%s\n", config.DiscoveredIndexFile)
+	fmt.Println()
+	return nil
+}
+
+// OpenPullRequests opens one pull request per configuration on the dashboard repository
+// Failures of single pull requests are reported and skipped
+func (r *Runner) OpenPullRequests(ctx context.Context, configs []config.RepositoryConfig) error {
+	if len(configs) == 0 {
+		return nil
+	}
+
+	fmt.Printf("🔀 Creating %d pull requests...\n", len(configs))
+
+	if _, err := r.loadState(); err != nil {
+		return err
+	}
+	prCreator, err := r.pullRequestCreator(ctx)
+	if err != nil {
+		return err
+	}
 
 	successCount := 0
+	r.config.Output.Start(len(configs))
+	defer r.config.Output.Finish()
 	for i, cfg := range configs {
-		fmt.Printf("  [%d/%d] %s... ", i+1, len(configs), extractRepoNameFromConfig(cfg.Name))
+		if ctx.Err() != nil {
+			return fmt.Errorf("interrupted after %d/%d pull requests: %w", successCount, len(configs), ctx.Err())
+		}
+		name := extractRepoNameFromConfig(cfg.Name)
+		fmt.Printf("  [%d/%d] %s... ", i+1, len(configs), name)
+		if url, ok := r.openedPullRequest(cfg.Name); ok {
+			r.reportPullRequest(cfg, url, nil)
+			fmt.Printf("%s (opened by the interrupted run)\n", url)
+			successCount++
+			r.config.Output.Done(name)
+			continue
+		}
 		// Pass configWriter so PR creation can write config after creating branch
-		if err := prCreator.CreatePullRequest(ctx, cfg, r.configWriter); err != nil {
+		url, err := prCreator.CreatePullRequest(ctx, cfg, r.configWriter)
+		r.reportPullRequest(cfg, url, err)
+		if err != nil {
 			fmt.Printf("failed (%v)\n", err)
-			continue
+			r.failedPullRequests++
+		} else {
+			fmt.Println(url)
+			successCount++
+			r.recordPullRequest(cfg.Name, url)
 		}
-		successCount++
+		r.config.Output.Done(name)
 	}
 
 	if successCount < len(configs) {
@@ -350,11 +1101,112 @@ func (r *Runner) createPullRequests(ctx context.Context, configs []config.Reposi
 	return nil
 }
 
-func (r *Runner) getCurrentRepoName(ctx context.Context) (string, error) {
+// RemoveArchived opens one pull request per archived repository removing its configuration and CODEOWNERS entry
+// Failures of single pull requests are reported and skipped
+func (r *Runner) RemoveArchived(ctx context.Context, repos []string) error {
+	if len(repos) == 0 {
+		return nil
+	}
+
+	fmt.Printf("🗑️  Creating %d removal pull requests...\n", len(repos))
+
+	prCreator, err := r.pullRequestCreator(ctx)
+	if err != nil {
+		return err
+	}
+
+	successCount := 0
+	for i, repo := range repos {
+		if ctx.Err() != nil {
+			return fmt.Errorf("interrupted after %d/%d pull requests: %w", successCount, len(repos), ctx.Err())
+		}
+		fmt.Printf("  [%d/%d] %s... ", i+1, len(repos), extractRepoNameFromConfig(repo))
+		if r.prAlreadyExists(ctx, pr.RemovalBranch(repo)) {
+			fmt.Println("skipped (PR already exists)")
+			r.reportArchived(repo, "", nil)
+			continue
+		}
+		url, err := prCreator.RemoveRepository(ctx, r.configWriter, r.config.ReposFile, repo)
+		r.reportArchived(repo, url, err)
+		if err != nil {
+			fmt.Printf("failed (%v)\n", err)
+			r.failedPullRequests++
+			continue
+		}
+		fmt.Println(url)
+		successCount++
+	}
+	fmt.Printf("  ✅ Created %d/%d removal pull requests\n", successCount, len(repos))
+	fmt.Println()
+
+	return nil
+}
+
+// FailedPullRequests returns the number of pull requests OpenPullRequests, RemoveArchived and RenameRepositories
+// could not open
+func (r *Runner) FailedPullRequests() int {
+	return r.failedPullRequests
+}
+
+// pullRequestCreator returns the injected creator, or one for the dashboard repository checked out in the work directory
+func (r *Runner) pullRequestCreator(ctx context.Context) (PullRequestCreator, error) {
+	if r.prCreator != nil {
+		return r.prCreator, nil
+	}
+
+	workDir, err := r.getWorkDir()
+	if err != nil {
+		return nil, err
+	}
+
+	// Extract repository name from git remote
+	currentRepo, err := r.getCurrentRepoName(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current repository name: %w", err)
+	}
+
+	baseBranch, err := r.baseBranch(ctx, currentRepo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get default branch: %w", err)
+	}
+
+	creator := r.provider.Creator(r.config.Organization, currentRepo, workDir, baseBranch)
+	creator.SetSchedule(r.config.Schedule, locale.Lookup(r.config.Locale))
+	if len(r.config.ReviewRotation) > 0 {
+		if r.rotator == nil {
+			r.rotator = pr.NewRotator(r.config.ReviewRotation, r.provider.TeamMembers)
+		}
+		creator.SetRotation(r.rotator)
+	}
+	return creator, nil
+}
+
+// baseBranch returns the branch pull requests are opened against on the dashboard repository: the configured one,
+// or the repository's default branch
+func (r *Runner) baseBranch(ctx context.Context, currentRepo string) (string, error) {
+	if r.config.BaseBranch != "" {
+		return r.config.BaseBranch, nil
+	}
+	return r.provider.DefaultBranch(ctx, r.config.Organization, currentRepo)
+}
+
+// getWorkDir returns the checkout of the dashboard repository, by default the working directory
+func (r *Runner) getWorkDir() (string, error) {
+	if r.workDir != "" {
+		return r.workDir, nil
+	}
 	workDir, err := os.Getwd()
 	if err != nil {
 		return "", fmt.Errorf("failed to get working directory: %w", err)
 	}
+	return workDir, nil
+}
+
+func (r *Runner) getCurrentRepoName(ctx context.Context) (string, error) {
+	workDir, err := r.getWorkDir()
+	if err != nil {
+		return "", err
+	}
 
 	remoteURL, err := getGitRemoteURL(ctx, workDir)
 	if err != nil {
@@ -372,31 +1224,26 @@ func (r *Runner) getCurrentRepoName(ctx context.Context) (string, error) {
 	return "", fmt.Errorf("failed to parse repository name from remote URL: %s", remoteURL)
 }
 
-func (r *Runner) getDefaultBranch(ctx context.Context, repoName string) (string, error) {
-	repo, _, err := r.writeClient.Repositories.Get(ctx, r.config.Organization, repoName)
-	if err != nil {
-		return "", fmt.Errorf("failed to get repository info: %w", err)
-	}
-
-	defaultBranch := repo.GetDefaultBranch()
-	if defaultBranch == "" {
-		// Fallback to main if default branch is not set
-		return "main", nil
-	}
-
-	return defaultBranch, nil
-}
-
 func (r *Runner) printSummary(totalRepos, newRepos, created int) {
 	fmt.Println("=========================================")
 	fmt.Println("Discovery Summary")
 	fmt.Println("=========================================")
 	fmt.Println()
 	fmt.Println("📊 Statistics:")
-	fmt.Printf("  • Total Go repositories: %d\n", totalRepos)
+	fmt.Printf("  • Total %s repositories: %d\n", languageNames(r.config.Languages), totalRepos)
 	fmt.Printf("  • Currently tracked: %d\n", len(r.existingRepos))
 	fmt.Printf("  • New repositories: %d\n", newRepos)
 	fmt.Printf("  • Configurations created: %d\n", created)
+	if r.rateLimits.count > 0 {
+		fmt.Printf("  • Waits for GitHub rate limits: %d (%s)\n", r.rateLimits.count, r.rateLimits.total.Round(time.Second))
+	}
+	if r.cache != nil {
+		stats := r.cache.Stats()
+		fmt.Printf("  • API responses unchanged since cached: %d (%d cached or refreshed)\n", stats.Revalidated, stats.Stored)
+	}
+	if r.blobs != nil {
+		fmt.Printf("  • CODEOWNERS files unchanged since cached: %d\n", r.blobs.Hits())
+	}
 	fmt.Println()
 
 	if r.config.DryRun {
@@ -409,12 +1256,24 @@ func (r *Runner) printSummary(totalRepos, newRepos, created int) {
 		fmt.Println("💡 Next Steps:")
 		fmt.Println("  • Repository owners will receive PR notifications")
 		fmt.Println("  • PRs can be reviewed and approved by teams")
-		fmt.Println("  • Dashboard will update within 24 hours")
+		if next := r.nextRun(); !next.IsZero() {
+			fmt.Printf("  • Dashboard will update on its next run, on %s\n", locale.Lookup(r.config.Locale).DateTime(next))
+		} else {
+			fmt.Println("  • Dashboard will update on its next scheduled run")
+		}
 	}
 
 	fmt.Println("\n✨ Discovery Complete!")
 }
 
+// nextRun returns the next scheduled run of the dashboard, the zero time when unknown
+func (r *Runner) nextRun() time.Time {
+	if r.config.Schedule == nil {
+		return time.Time{}
+	}
+	return r.config.Schedule.Next(time.Now())
+}
+
 // Helper functions
 
 func extractRepoNameFromConfig(fullName string) string {
@@ -425,37 +1284,35 @@ func extractRepoNameFromConfig(fullName string) string {
 	return fullName
 }
 
-// prAlreadyExists checks if a PR already exists for the given repository
-func (r *Runner) prAlreadyExists(ctx context.Context, repoName string) bool {
+// sortedKeys returns the keys of a map of repositories, sorted
+func sortedKeys[V any](repos map[string]V) []string {
+	keys := make([]string, 0, len(repos))
+	for key := range repos {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// prAlreadyExists checks if an open PR already exists from the given branch
+func (r *Runner) prAlreadyExists(ctx context.Context, branchName string) bool {
 	// Get the current repository name
 	currentRepo, err := r.getCurrentRepoName(ctx)
 	if err != nil {
 		return false
 	}
 
-	// Get default branch
-	baseBranch, err := r.getDefaultBranch(ctx, currentRepo)
+	baseBranch, err := r.baseBranch(ctx, currentRepo)
 	if err != nil {
-		// Fallback to main if we can't determine the default branch
-		baseBranch = "main"
+		return false
 	}
 
-	// Branch name format matches pr/creator.go
-	branchName := fmt.Sprintf("add-repo/%s", repoName)
-
 	// Check if PR exists with this branch as head
-	opts := &github.PullRequestListOptions{
-		State: "open",
-		Head:  fmt.Sprintf("%s:%s", r.config.Organization, branchName),
-		Base:  baseBranch,
-	}
-
-	prs, _, err := r.writeClient.PullRequests.List(ctx, r.config.Organization, currentRepo, opts)
+	exists, err := r.provider.HasOpenPullRequest(ctx, r.config.Organization, currentRepo, branchName, baseBranch)
 	if err != nil {
 		return false
 	}
-
-	return len(prs) > 0
+	return exists
 }
 
 func getGitRemoteURL(ctx context.Context, workDir string) (string, error) {
@@ -1,15 +1,96 @@
 package discover_test
 
 import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/google/go-github/v66/github"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
 	"github.com/konflux-ci/coverage-dashboard/internal/discover"
+	"github.com/konflux-ci/coverage-dashboard/internal/ownership"
 )
 
+// staticOwners detects the same owners for every repository, or fails for the listed ones
+type staticOwners struct {
+	owners  []string
+	failing map[string]bool
+}
+
+func (o staticOwners) Detect(_ context.Context, _, repo string) (ownership.Detection, error) {
+	if o.failing[repo] {
+		return ownership.Detection{}, errors.New("no owners found")
+	}
+	return ownership.Detection{Owners: o.owners, Source: config.OwnersSourceCodeowners}, nil
+}
+
+// slowOwners detects owners slower for repositories earlier in the alphabet, recording how many detections overlap
+type slowOwners struct {
+	mu      sync.Mutex
+	running int
+	peak    int
+}
+
+func (o *slowOwners) Detect(_ context.Context, _, repo string) (ownership.Detection, error) {
+	o.mu.Lock()
+	o.running++
+	o.peak = max(o.peak, o.running)
+	o.mu.Unlock()
+
+	time.Sleep(time.Duration('z'-repo[0]) * time.Millisecond)
+
+	o.mu.Lock()
+	o.running--
+	o.mu.Unlock()
+	return ownership.Detection{Owners: []string{"@test-org/" + repo}, Source: config.OwnersSourceCodeowners}, nil
+}
+
+// recordingCreator records the configurations pull requests were requested for, failing those of failing
+type recordingCreator struct {
+	created []string
+	removed []string
+	renamed []string
+	failing map[string]bool
+}
+
+func (c *recordingCreator) CreatePullRequest(_ context.Context, cfg config.RepositoryConfig, _ *config.Writer) (string, error) {
+	if c.failing[cfg.Name] {
+		return "", fmt.Errorf("push rejected")
+	}
+	c.created = append(c.created, cfg.Name)
+	return fmt.Sprintf("https://github.com/test-org/coverage-dashboard/pull/%d", len(c.created)), nil
+}
+
+func (c *recordingCreator) RemoveRepository(_ context.Context, _ *config.Writer, _, repo string) (string, error) {
+	c.removed = append(c.removed, repo)
+	return "https://github.com/test-org/coverage-dashboard/pull/1", nil
+}
+
+func (c *recordingCreator) RenameRepository(_ context.Context, _ *config.Writer, _, from, to string) (string, error) {
+	c.renamed = append(c.renamed, from+" → "+to)
+	return "https://github.com/test-org/coverage-dashboard/pull/1", nil
+}
+
+// githubClient is a client of the API served by a test server
+func githubClient(server *httptest.Server) *github.Client {
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	return client
+}
+
 var _ = Describe("Runner", func() {
 	var (
 		tempDir string
@@ -57,8 +138,19 @@ var _ = Describe("Runner", func() {
 			Expect(runner).NotTo(BeNil())
 		})
 
+		It("should reject a default owner that is not a user or team", func() {
+			_, err := discover.NewRunner(discover.Config{
+				Organization: "test-org",
+				ReposDir:     filepath.Join(tempDir, "repos"),
+				DryRun:       true,
+				DefaultOwner: "test-org/onboarding",
+			})
+			Expect(err).To(MatchError(ContainSubstring("not a @user or @org/team")))
+		})
+
 		It("should require GITHUB_WRITE_TOKEN for apply mode", func() {
 			os.Unsetenv("GITHUB_WRITE_TOKEN")
+			os.Unsetenv("GITHUB_TOKEN")
 
 			cfg := discover.Config{
 				Organization:   "test-org",
@@ -69,10 +161,27 @@ var _ = Describe("Runner", func() {
 
 			runner, err := discover.NewRunner(cfg)
 			Expect(err).To(HaveOccurred())
-			Expect(err.Error()).To(ContainSubstring("GITHUB_WRITE_TOKEN is required"))
+			Expect(err.Error()).To(ContainSubstring("GITHUB_WRITE_TOKEN or GITHUB_TOKEN is required"))
 			Expect(runner).To(BeNil())
 		})
 
+		It("should accept GITHUB_TOKEN in place of GITHUB_WRITE_TOKEN for apply mode", func() {
+			os.Unsetenv("GITHUB_WRITE_TOKEN")
+			os.Setenv("GITHUB_TOKEN", "test-token")
+			defer os.Unsetenv("GITHUB_TOKEN")
+
+			cfg := discover.Config{
+				Organization:   "test-org",
+				ReposDir:       filepath.Join(tempDir, "repos"),
+				CodeownersFile: filepath.Join(tempDir, "CODEOWNERS"),
+				DryRun:         false,
+			}
+
+			runner, err := discover.NewRunner(cfg)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(runner).NotTo(BeNil())
+		})
+
 		Context("with environment variables", func() {
 			BeforeEach(func() {
 				os.Setenv("GITHUB_READ_TOKEN", "test-read-token")
@@ -138,6 +247,899 @@ var _ = Describe("Runner", func() {
 			Expect(err).NotTo(HaveOccurred())
 			Expect(runner).NotTo(BeNil())
 		})
+
+		It("should require Go among the languages to detect it by go.mod", func() {
+			languages, err := discover.ParseLanguages("python")
+			Expect(err).NotTo(HaveOccurred())
+			_, err = discover.NewRunner(discover.Config{Organization: "test-org", DryRun: true, Languages: languages, DetectBy: discover.DetectByGoMod})
+			Expect(err).To(MatchError("--detect-by gomod needs go among --languages"))
+
+			_, err = discover.NewRunner(discover.Config{Organization: "test-org", DryRun: true, DetectBy: "topics"})
+			Expect(err).To(MatchError(ContainSubstring(`--detect-by must be language or gomod, got "topics"`)))
+		})
+	})
+
+	Describe("Offline mode", func() {
+		It("should reject --offline with --apply", func() {
+			cfg := discover.Config{
+				Organization: "test-org",
+				ReposDir:     filepath.Join(tempDir, "repos"),
+				FixturesDir:  "testdata/org-scenario",
+				Offline:      true,
+				DryRun:       false,
+			}
+
+			_, err := discover.NewRunner(cfg)
+			Expect(err).To(MatchError(ContainSubstring("--offline cannot be combined with --apply")))
+		})
+
+		It("should require a fixtures directory", func() {
+			cfg := discover.Config{
+				Organization: "test-org",
+				ReposDir:     filepath.Join(tempDir, "repos"),
+				Offline:      true,
+				DryRun:       true,
+			}
+
+			_, err := discover.NewRunner(cfg)
+			Expect(err).To(MatchError(ContainSubstring("--fixtures is required")))
+		})
+
+		It("should discover repositories from recorded API responses", func() {
+			reposDir := filepath.Join(tempDir, "repos")
+			Expect(os.MkdirAll(reposDir, 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(reposDir, "tracked.yaml"), []byte("name: test-org/tracked\n"), 0644)).To(Succeed())
+
+			cfg := discover.Config{
+				Organization:   "test-org",
+				ReposDir:       reposDir,
+				CodeownersFile: filepath.Join(tempDir, "CODEOWNERS"),
+				FixturesDir:    "testdata/org-scenario",
+				Offline:        true,
+				DryRun:         true,
+			}
+
+			runner, err := discover.NewRunner(cfg)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(runner.Run(context.Background())).To(Succeed())
+
+			discovered, err := os.ReadDir(filepath.Join(tempDir, "discovered-repos"))
+			Expect(err).NotTo(HaveOccurred())
+			var names []string
+			for _, entry := range discovered {
+				names = append(names, entry.Name())
+			}
+			Expect(names).To(Equal([]string{"api.yaml", "cli.yaml", "discovery-report.md", "index.md"}))
+
+			index, err := os.ReadFile(filepath.Join(tempDir, "discovered-repos", "index.md"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(index)).To(ContainSubstring("| test-org/api | go | [api.yaml](api.yaml) |"))
+
+			api, err := os.ReadFile(filepath.Join(tempDir, "discovered-repos", "api.yaml"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(api)).To(MatchRegexp(`owners_source: \w+\nowners_detected_at: \d{4}-\d\d-\d\dT\d\d:\d\d:\d\dZ\n`))
+			Expect(string(index)).To(ContainSubstring("1 already tracked, 2 configurations generated"))
+
+			report, err := os.ReadFile(filepath.Join(tempDir, "discovered-repos", "discovery-report.md"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(report)).To(ContainSubstring("## Repository Discovery of test-org\n\nDry run over 3 repositories, 1 already tracked: 2 new"))
+			Expect(string(report)).To(MatchRegexp(`\| test-org/api \| go \| @\S+.* \(\w+\) \| `))
+		})
+
+		It("should clear the files of earlier dry runs unless kept", func() {
+			reposDir := filepath.Join(tempDir, "repos")
+			discoveredDir := filepath.Join(tempDir, "discovered-repos")
+			Expect(os.MkdirAll(reposDir, 0755)).To(Succeed())
+			Expect(os.MkdirAll(discoveredDir, 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(reposDir, "tracked.yaml"), []byte("name: test-org/tracked\n"), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(discoveredDir, "stale.yaml"), []byte("name: test-org/stale\n"), 0644)).To(Succeed())
+
+			cfg := discover.Config{
+				Organization:   "test-org",
+				ReposDir:       reposDir,
+				CodeownersFile: filepath.Join(tempDir, "CODEOWNERS"),
+				FixturesDir:    "testdata/org-scenario",
+				Offline:        true,
+				DryRun:         true,
+				KeepDiscovered: true,
+			}
+			runner, err := discover.NewRunner(cfg)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(runner.Run(context.Background())).To(Succeed())
+
+			Expect(filepath.Join(discoveredDir, "stale.yaml")).To(BeAnExistingFile())
+			index, err := os.ReadFile(filepath.Join(discoveredDir, "index.md"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(index)).To(ContainSubstring("## Kept From Earlier Runs\n\nThese files were not generated by this run; they may be tracked or deleted since.\n\n- [stale.yaml](stale.yaml)\n"))
+
+			cfg.KeepDiscovered = false
+			runner, err = discover.NewRunner(cfg)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(runner.Run(context.Background())).To(Succeed())
+
+			Expect(filepath.Join(discoveredDir, "stale.yaml")).NotTo(BeAnExistingFile())
+			index, err = os.ReadFile(filepath.Join(discoveredDir, "index.md"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(index)).NotTo(ContainSubstring("stale.yaml"))
+		})
+	})
+
+	Describe("Embedding", func() {
+		var (
+			server *httptest.Server
+			runner *discover.Runner
+			owners staticOwners
+			prs    *recordingCreator
+			// optedOut are the repositories with the opt-out file
+			optedOut map[string]bool
+		)
+
+		BeforeEach(func() {
+			optedOut = map[string]bool{}
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if repo, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/repos/test-org/"), "/contents/"+discover.OptOutFile); ok {
+					if !optedOut[repo] {
+						http.NotFound(w, r)
+						return
+					}
+					fmt.Fprint(w, `{"type": "file", "encoding": "base64", "content": ""}`)
+					return
+				}
+				Expect(r.URL.Path).To(Equal("/orgs/test-org/repos"))
+				fmt.Fprint(w, `[
+					{"name": "api", "language": "Go"},
+					{"name": "tracked", "language": "Go"},
+					{"name": "old", "language": "Go", "archived": true},
+					{"name": "ui", "language": "TypeScript"}
+				]`)
+			}))
+			client := githubClient(server)
+
+			reposDir := filepath.Join(tempDir, "repos")
+			Expect(os.MkdirAll(reposDir, 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(reposDir, "tracked.yaml"), []byte("name: test-org/tracked\n"), 0644)).To(Succeed())
+
+			owners = staticOwners{owners: []string{"@test-org/api-team"}, failing: map[string]bool{}}
+			prs = &recordingCreator{}
+			runner = discover.NewRunnerWithDependencies(discover.Config{
+				Organization:   "test-org",
+				ReposDir:       reposDir,
+				CodeownersFile: filepath.Join(tempDir, "CODEOWNERS"),
+			}, discover.Dependencies{
+				ReadClient:   client,
+				Owners:       owners,
+				PullRequests: prs,
+			})
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("should run the discovery steps with the injected dependencies", func() {
+			ctx := context.Background()
+			repos, err := runner.FetchRepositories(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(repos).To(HaveLen(2))
+
+			newRepos, err := runner.FilterNew(repos)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(newRepos).To(HaveLen(1))
+			Expect(newRepos[0].GetName()).To(Equal("api"))
+
+			cfg, err := runner.Analyze(ctx, newRepos[0])
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.Name).To(Equal("test-org/api"))
+			Expect(cfg.Owners).To(Equal([]string{"@test-org/api-team"}))
+			Expect(cfg.OwnersSource).To(Equal(config.OwnersSourceCodeowners))
+			Expect(cfg.OwnersDetectedAt).NotTo(BeNil())
+			Expect(cfg.ExcludeDirs).To(ContainElement("vendor/"))
+
+			Expect(runner.OpenPullRequests(ctx, []config.RepositoryConfig{cfg})).To(Succeed())
+			Expect(prs.created).To(Equal([]string{"test-org/api"}))
+		})
+
+		It("should count the pull requests that could not be opened", func() {
+			prs.failing = map[string]bool{"test-org/b": true}
+			configs := []config.RepositoryConfig{{Name: "test-org/a"}, {Name: "test-org/b"}}
+			Expect(runner.OpenPullRequests(context.Background(), configs)).To(Succeed())
+			Expect(prs.created).To(Equal([]string{"test-org/a"}))
+			Expect(runner.FailedPullRequests()).To(Equal(1))
+		})
+
+		It("should open pull requests only for the repositories the operator accepts, with the owners entered", func() {
+			detectedAt := time.Now()
+			configs := []config.RepositoryConfig{
+				{Name: "test-org/a", Owners: []string{"@test-org/a-team"}, OwnersSource: config.OwnersSourceCodeowners, OwnersDetectedAt: &detectedAt},
+				{Name: "test-org/b", Owners: []string{"@test-org/b-team"}},
+				{Name: "test-org/c"},
+				{Name: "test-org/d"},
+			}
+			runner = discover.NewRunnerWithDependencies(discover.Config{Organization: "test-org", Interactive: true}, discover.Dependencies{
+				ReadClient:   githubClient(server),
+				Owners:       owners,
+				PullRequests: prs,
+				Input:        strings.NewReader("e\nnot/a/team\nalice, @test-org/new-team\nmaybe\ny\ns\nq\n"),
+			})
+			accepted, err := runner.Confirm(context.Background(), configs)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(accepted).To(HaveLen(1))
+			Expect(accepted[0].Owners).To(Equal([]string{"@alice", "@test-org/new-team"}))
+			Expect(accepted[0].OwnersSource).To(BeEmpty())
+			Expect(accepted[0].OwnersDetectedAt).To(BeNil())
+
+			skipped := runner.Report(nil).Skipped
+			Expect(skipped).To(HaveLen(3))
+			Expect(skipped[0]).To(Equal(discover.SkippedRepository{Name: "test-org/b", Skip: discover.SkipDeclined, Reason: "skipped by the operator"}))
+			Expect(skipped[2].Name).To(Equal("test-org/d"))
+
+			_, err = runner.Confirm(context.Background(), configs)
+			Expect(err).To(MatchError("input ended before every repository was confirmed"))
+		})
+
+		It("should report the new, skipped and archived repositories with their pull requests", func() {
+			Expect(os.WriteFile(filepath.Join(tempDir, "repos", "old.yaml"), []byte("name: test-org/old\n"), 0644)).To(Succeed())
+			prs.failing = map[string]bool{"test-org/b": true}
+			runner = discover.NewRunnerWithDependencies(discover.Config{
+				Organization:   "test-org",
+				ReposDir:       filepath.Join(tempDir, "repos"),
+				CodeownersFile: filepath.Join(tempDir, "CODEOWNERS"),
+				DryRun:         true,
+				Filters:        config.DiscoveryFilters{Exclude: []string{"test-org/api"}},
+			}, discover.Dependencies{ReadClient: githubClient(server), Owners: owners, PullRequests: prs})
+			Expect(runner.Run(context.Background())).To(Succeed())
+			configs := []config.RepositoryConfig{{Name: "test-org/a", Owners: []string{"@test-org/a-team"}}, {Name: "test-org/b"}}
+			Expect(runner.OpenPullRequests(context.Background(), configs)).To(Succeed())
+
+			report := runner.Report(fmt.Errorf("interrupted"))
+			Expect(report.Organization).To(Equal("test-org"))
+			Expect(report.Repositories).To(Equal(2))
+			Expect(report.Tracked).To(Equal(2))
+			Expect(report.New).To(Equal([]discover.NewRepository{
+				{Name: "test-org/a", Language: config.LanguageGo, Owners: []string{"@test-org/a-team"}, PullRequest: "https://github.com/test-org/coverage-dashboard/pull/1"},
+				{Name: "test-org/b", Language: config.LanguageGo, PullRequestError: "push rejected"},
+			}))
+			Expect(report.Skipped).To(Equal([]discover.SkippedRepository{{Name: "test-org/api", Skip: discover.SkipFiltered, Reason: `excluded by "test-org/api"`}}))
+			Expect(report.Archived).To(Equal([]discover.ArchivedRepository{{Name: "test-org/old"}}))
+			Expect(report.FailedPullRequests).To(Equal(1))
+			Expect(report.Error).To(Equal("interrupted"))
+
+			data, err := json.Marshal(discover.NewRunnerWithDependencies(discover.Config{Organization: "test-org"}, discover.Dependencies{}).Report(nil))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(data)).To(Equal(`{"organization":"test-org","dry_run":false,"repositories":0,"tracked":0,"new":[],"skipped":[],"archived":[],"renamed":[],"failed_pull_requests":0}`))
+		})
+
+		It("should resume from the checkpoint of an interrupted run without repeating its analyses and pull requests", func() {
+			stateFile := filepath.Join(tempDir, "state", discover.StateFile)
+			Expect(os.MkdirAll(filepath.Dir(stateFile), 0755)).To(Succeed())
+			Expect(os.WriteFile(stateFile, []byte(`{
+				"organization": "test-org",
+				"analyses": {"api": {"config": {"Name": "test-org/api", "Owners": ["@test-org/saved-team"]}}},
+				"pull_requests": {"test-org/a": "https://github.com/test-org/coverage-dashboard/pull/9"}
+			}`), 0644)).To(Succeed())
+			resumed := func(dryRun bool) *discover.Runner {
+				return discover.NewRunnerWithDependencies(discover.Config{
+					Organization:   "test-org",
+					ReposDir:       filepath.Join(tempDir, "repos"),
+					CodeownersFile: filepath.Join(tempDir, "CODEOWNERS"),
+					DryRun:         dryRun,
+					StateFile:      stateFile,
+					Resume:         true,
+				}, discover.Dependencies{ReadClient: githubClient(server), Owners: owners, PullRequests: prs})
+			}
+
+			runner = resumed(false)
+			configs := []config.RepositoryConfig{{Name: "test-org/a"}, {Name: "test-org/b"}}
+			Expect(runner.OpenPullRequests(context.Background(), configs)).To(Succeed())
+			Expect(prs.created).To(Equal([]string{"test-org/b"}))
+			Expect(runner.Report(nil).New[0].PullRequest).To(Equal("https://github.com/test-org/coverage-dashboard/pull/9"))
+			Expect(os.ReadFile(stateFile)).To(ContainSubstring(`"test-org/b": "https://github.com/test-org/coverage-dashboard/pull/1"`))
+
+			runner = resumed(true)
+			Expect(runner.Run(context.Background())).To(Succeed())
+			Expect(runner.Report(nil).New).To(Equal([]discover.NewRepository{
+				{Name: "test-org/api", Language: config.LanguageGo, Owners: []string{"@test-org/saved-team"}},
+			}))
+			Expect(stateFile).NotTo(BeAnExistingFile())
+
+			Expect(os.WriteFile(stateFile, []byte(`{"organization": "other-org"}`), 0644)).To(Succeed())
+			Expect(resumed(true).Run(context.Background())).To(MatchError(ContainSubstring("is of the other-org organization, not test-org")))
+		})
+
+		It("should find tracked repositories archived since and remove them", func() {
+			Expect(os.WriteFile(filepath.Join(tempDir, "repos", "old.yaml"), []byte("name: test-org/old\n"), 0644)).To(Succeed())
+			ctx := context.Background()
+			repos, err := runner.FetchRepositories(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = runner.FilterNew(repos)
+			Expect(err).NotTo(HaveOccurred())
+
+			archived := runner.FindArchived()
+			Expect(archived).To(Equal([]string{"test-org/old"}))
+			Expect(runner.RemoveArchived(ctx, archived)).To(Succeed())
+			Expect(prs.removed).To(Equal([]string{"test-org/old"}))
+		})
+
+		It("should find tracked repositories renamed since through redirects, instead of discovering them again", func() {
+			renames := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/orgs/test-org/repos":
+					fmt.Fprint(w, `[{"name": "api-server", "language": "Go"}, {"name": "tracked", "language": "Go"}]`)
+				case "/repos/test-org/api":
+					http.Redirect(w, r, "/repositories/42", http.StatusMovedPermanently)
+				case "/repositories/42":
+					fmt.Fprint(w, `{"id": 42, "name": "api-server", "owner": {"login": "test-org"}}`)
+				case "/repos/test-org/moved":
+					fmt.Fprint(w, `{"id": 43, "name": "moved", "owner": {"login": "other-org"}}`)
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer renames.Close()
+			for _, name := range []string{"api", "moved", "deleted"} {
+				Expect(os.WriteFile(filepath.Join(tempDir, "repos", name+".yaml"), []byte("name: test-org/"+name+"\n"), 0644)).To(Succeed())
+			}
+			runner = discover.NewRunnerWithDependencies(discover.Config{
+				Organization:   "test-org",
+				ReposDir:       filepath.Join(tempDir, "repos"),
+				CodeownersFile: filepath.Join(tempDir, "CODEOWNERS"),
+				DryRun:         true,
+			}, discover.Dependencies{ReadClient: githubClient(renames), Owners: owners, PullRequests: prs})
+			Expect(runner.Run(context.Background())).To(Succeed())
+
+			report := runner.Report(nil)
+			Expect(report.Renamed).To(Equal([]discover.RenamedRepository{{From: "test-org/api", To: "test-org/api-server"}}))
+			Expect(report.New).To(BeEmpty())
+			Expect(filepath.Join(tempDir, "discovered-repos", "api-server.yaml")).NotTo(BeAnExistingFile())
+			markdown, err := os.ReadFile(filepath.Join(tempDir, "discovered-repos", "discovery-report.md"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(markdown)).To(ContainSubstring("### Renamed\n\n"))
+			Expect(string(markdown)).To(ContainSubstring("- test-org/api → test-org/api-server\n"))
+
+			Expect(runner.RenameRepositories(context.Background(), runner.FindRenamed(context.Background()))).To(Succeed())
+			Expect(prs.renamed).To(Equal([]string{"test-org/api → test-org/api-server"}))
+		})
+
+		It("should look up only the given repositories instead of listing the organization", func() {
+			var requests []string
+			only := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests = append(requests, r.URL.Path)
+				switch r.URL.Path {
+				case "/repos/test-org/api":
+					fmt.Fprint(w, `{"name": "api", "language": "Go"}`)
+				case "/repos/test-org/old":
+					fmt.Fprint(w, `{"name": "old", "language": "Go", "archived": true}`)
+				case "/repos/test-org/ui":
+					fmt.Fprint(w, `{"name": "ui", "language": "TypeScript"}`)
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer only.Close()
+			// Tracked repositories missing from the run are neither archived nor renamed
+			for _, name := range []string{"old", "moved"} {
+				Expect(os.WriteFile(filepath.Join(tempDir, "repos", name+".yaml"), []byte("name: test-org/"+name+"\n"), 0644)).To(Succeed())
+			}
+			newRunner := func(names ...string) *discover.Runner {
+				return discover.NewRunnerWithDependencies(discover.Config{
+					Organization:   "test-org",
+					ReposDir:       filepath.Join(tempDir, "repos"),
+					CodeownersFile: filepath.Join(tempDir, "CODEOWNERS"),
+					DryRun:         true,
+					Only:           names,
+				}, discover.Dependencies{ReadClient: githubClient(only), Owners: owners, PullRequests: prs})
+			}
+			runner = newRunner("api", "old", "ui")
+			Expect(runner.Run(context.Background())).To(Succeed())
+
+			report := runner.Report(nil)
+			Expect(report.New).To(HaveLen(1))
+			Expect(report.New[0].Name).To(Equal("test-org/api"))
+			Expect(report.Archived).To(HaveLen(1))
+			Expect(report.Archived[0].Name).To(Equal("test-org/old"))
+			Expect(report.Renamed).To(BeEmpty())
+			Expect(requests).NotTo(ContainElement("/orgs/test-org/repos"))
+			Expect(requests).NotTo(ContainElement("/repos/test-org/moved"))
+
+			runner = newRunner("missing")
+			Expect(runner.Run(context.Background())).To(MatchError(ContainSubstring("repository test-org/missing of --only does not exist")))
+		})
+
+		It("should list archived repositories in the index of dry runs", func() {
+			Expect(os.WriteFile(filepath.Join(tempDir, "repos", "old.yaml"), []byte("name: test-org/old\n"), 0644)).To(Succeed())
+			runner = discover.NewRunnerWithDependencies(discover.Config{
+				Organization:   "test-org",
+				ReposDir:       filepath.Join(tempDir, "repos"),
+				CodeownersFile: filepath.Join(tempDir, "CODEOWNERS"),
+				DryRun:         true,
+			}, discover.Dependencies{ReadClient: githubClient(server), Owners: owners, PullRequests: prs})
+			Expect(runner.Run(context.Background())).To(Succeed())
+
+			index, err := os.ReadFile(filepath.Join(tempDir, "discovered-repos", "index.md"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(index)).To(ContainSubstring("## Archived\n\nThese tracked repositories were archived; `--apply` opens pull requests removing their configurations.\n\n- test-org/old\n"))
+			Expect(prs.removed).To(BeEmpty())
+			Expect(filepath.Join(tempDir, "repos", "old.yaml")).To(BeAnExistingFile())
+		})
+
+		It("should skip the repositories opted out with the opt-out file", func() {
+			optedOut["api"] = true
+			runner = discover.NewRunnerWithDependencies(discover.Config{
+				Organization:   "test-org",
+				ReposDir:       filepath.Join(tempDir, "repos"),
+				CodeownersFile: filepath.Join(tempDir, "CODEOWNERS"),
+				DryRun:         true,
+			}, discover.Dependencies{ReadClient: githubClient(server), Owners: owners, PullRequests: prs})
+			Expect(runner.Run(context.Background())).To(Succeed())
+
+			index, err := os.ReadFile(filepath.Join(tempDir, "discovered-repos", "index.md"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(index)).To(ContainSubstring("## Skipped\n\n- api: opted out with .coverage-dashboard-ignore\n"))
+			Expect(filepath.Join(tempDir, "discovered-repos", "api.yaml")).NotTo(BeAnExistingFile())
+			Expect(runner.Report(nil).Skipped).To(ContainElement(discover.SkippedRepository{
+				Name:   "test-org/api",
+				Skip:   discover.SkipOptedOut,
+				Reason: "opted out with .coverage-dashboard-ignore",
+			}))
+		})
+
+		It("should skip the repositories excluded or not included by the filters", func() {
+			runner = discover.NewRunnerWithDependencies(discover.Config{
+				Organization:   "test-org",
+				ReposDir:       filepath.Join(tempDir, "repos"),
+				CodeownersFile: filepath.Join(tempDir, "CODEOWNERS"),
+				DryRun:         true,
+				Filters:        config.DiscoveryFilters{Exclude: []string{"test-org/a*", "test-org/tracked"}},
+			}, discover.Dependencies{ReadClient: githubClient(server), Owners: owners, PullRequests: prs})
+			Expect(runner.Run(context.Background())).To(Succeed())
+
+			index, err := os.ReadFile(filepath.Join(tempDir, "discovered-repos", "index.md"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(index)).To(ContainSubstring("## Filtered\n\nThese repositories are skipped by the discovery filters.\n\n- test-org/api: excluded by \"test-org/a*\"\n\n"))
+			Expect(string(index)).To(ContainSubstring("1 already tracked, 0 configurations generated"))
+			Expect(filepath.Join(tempDir, "discovered-repos", "api.yaml")).NotTo(BeAnExistingFile())
+		})
+
+		It("should skip forks unless included, marking them fork", func() {
+			forks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `[{"name": "api", "language": "Go"}, {"name": "upstream-mirror", "language": "Go", "fork": true}]`)
+			}))
+			defer forks.Close()
+			newRunner := func(includeForks bool) *discover.Runner {
+				return discover.NewRunnerWithDependencies(discover.Config{
+					Organization:   "test-org",
+					ReposDir:       filepath.Join(tempDir, "repos"),
+					CodeownersFile: filepath.Join(tempDir, "CODEOWNERS"),
+					IncludeForks:   includeForks,
+				}, discover.Dependencies{ReadClient: githubClient(forks), Owners: owners, PullRequests: prs})
+			}
+			ctx := context.Background()
+
+			runner = newRunner(false)
+			repos, err := runner.FetchRepositories(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			newRepos, err := runner.FilterNew(repos)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(newRepos).To(HaveLen(1))
+			Expect(newRepos[0].GetName()).To(Equal("api"))
+
+			runner = newRunner(true)
+			repos, err = runner.FetchRepositories(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			newRepos, err = runner.FilterNew(repos)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(newRepos).To(HaveLen(2))
+			cfg, err := runner.Analyze(ctx, newRepos[1])
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.Fork).To(BeTrue())
+		})
+
+		It("should discover the repositories of the given visibility, recording it", func() {
+			visibilities := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `[{"name": "api", "language": "Go", "visibility": "public"},
+					{"name": "secrets", "language": "Go", "private": true},
+					{"name": "tools", "language": "Go", "visibility": "internal"}]`)
+			}))
+			defer visibilities.Close()
+			discovered := func(visibility string) []string {
+				runner = discover.NewRunnerWithDependencies(discover.Config{
+					Organization:   "test-org",
+					ReposDir:       filepath.Join(tempDir, "repos"),
+					CodeownersFile: filepath.Join(tempDir, "CODEOWNERS"),
+					Visibility:     visibility,
+				}, discover.Dependencies{ReadClient: githubClient(visibilities), Owners: owners, PullRequests: prs})
+				repos, err := runner.FetchRepositories(context.Background())
+				Expect(err).NotTo(HaveOccurred())
+				newRepos, err := runner.FilterNew(repos)
+				Expect(err).NotTo(HaveOccurred())
+				var names []string
+				for _, repo := range newRepos {
+					names = append(names, repo.GetName())
+				}
+				return names
+			}
+
+			Expect(discovered(discover.VisibilityAll)).To(Equal([]string{"api", "secrets", "tools"}))
+			Expect(discovered(discover.VisibilityPublic)).To(Equal([]string{"api"}))
+			Expect(discovered(discover.VisibilityPrivate)).To(Equal([]string{"secrets", "tools"}))
+
+			repos, err := runner.FetchRepositories(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			cfg, err := runner.Analyze(context.Background(), repos[1])
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.Visibility).To(Equal(config.VisibilityPrivate))
+			Expect(cfg.IsPublic()).To(BeFalse())
+		})
+
+		It("should skip repositories without a push within the activity window", func() {
+			pushes := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintf(w, `[{"name": "api", "language": "Go", "pushed_at": %q},
+					{"name": "legacy", "language": "Go", "pushed_at": "2019-05-01T00:00:00Z"},
+					{"name": "empty", "language": "Go"}]`, time.Now().Add(-24*time.Hour).Format(time.RFC3339))
+			}))
+			defer pushes.Close()
+			runner = discover.NewRunnerWithDependencies(discover.Config{
+				Organization:   "test-org",
+				ReposDir:       filepath.Join(tempDir, "repos"),
+				CodeownersFile: filepath.Join(tempDir, "CODEOWNERS"),
+				MinActivity:    180 * 24 * time.Hour,
+			}, discover.Dependencies{ReadClient: githubClient(pushes), Owners: owners, PullRequests: prs})
+
+			repos, err := runner.FetchRepositories(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			newRepos, err := runner.FilterNew(repos)
+			Expect(err).NotTo(HaveOccurred())
+			var names []string
+			for _, repo := range newRepos {
+				names = append(names, repo.GetName())
+			}
+			Expect(names).To(Equal([]string{"api", "empty"}))
+		})
+
+		It("should analyze repositories concurrently and keep their order", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `[
+					{"name": "api", "language": "Go"},
+					{"name": "build", "language": "Go"},
+					{"name": "cli", "language": "Go"},
+					{"name": "docs", "language": "Go"},
+					{"name": "events", "language": "Go"}
+				]`)
+			}))
+			DeferCleanup(server.Close)
+
+			detector := &slowOwners{}
+			runner = discover.NewRunnerWithDependencies(discover.Config{
+				Organization:   "test-org",
+				ReposDir:       filepath.Join(tempDir, "repos"),
+				CodeownersFile: filepath.Join(tempDir, "CODEOWNERS"),
+				DryRun:         true,
+				Concurrency:    3,
+			}, discover.Dependencies{ReadClient: githubClient(server), Owners: detector, PullRequests: prs})
+			Expect(runner.Run(context.Background())).To(Succeed())
+			Expect(detector.peak).To(Equal(3))
+
+			index, err := os.ReadFile(filepath.Join(tempDir, "discovered-repos", "index.md"))
+			Expect(err).NotTo(HaveOccurred())
+			var repos []string
+			for _, line := range strings.Split(string(index), "\n") {
+				if strings.HasPrefix(line, "| test-org/") {
+					repos = append(repos, strings.Fields(line)[1])
+				}
+			}
+			Expect(repos).To(Equal([]string{"test-org/api", "test-org/build", "test-org/cli", "test-org/docs", "test-org/events"}))
+		})
+
+		DescribeTable("should look for Go test files in the default branch",
+			func(untested string, expectedConfigs []string, expectedIndex string) {
+				server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					switch r.URL.Path {
+					case "/orgs/test-org/repos":
+						fmt.Fprint(w, `[{"name": "api", "language": "Go", "default_branch": "main"}, {"name": "tools", "language": "Go", "default_branch": "main"}]`)
+					case "/repos/test-org/api/git/trees/main":
+						fmt.Fprint(w, `{"tree": [{"path": "main.go", "type": "blob"}, {"path": "pkg/api_test.go", "type": "blob"}]}`)
+					case "/repos/test-org/tools/git/trees/main":
+						fmt.Fprint(w, `{"tree": [{"path": "main.go", "type": "blob"}, {"path": "vendor/x/x_test.go", "type": "blob"}]}`)
+					default:
+						http.NotFound(w, r)
+					}
+				}))
+				DeferCleanup(server.Close)
+
+				runner = discover.NewRunnerWithDependencies(discover.Config{
+					Organization:   "test-org",
+					ReposDir:       filepath.Join(tempDir, "repos"),
+					CodeownersFile: filepath.Join(tempDir, "CODEOWNERS"),
+					DryRun:         true,
+					Untested:       untested,
+				}, discover.Dependencies{ReadClient: githubClient(server), Owners: owners, PullRequests: prs})
+				Expect(runner.Run(context.Background())).To(Succeed())
+
+				discovered, err := os.ReadDir(filepath.Join(tempDir, "discovered-repos"))
+				Expect(err).NotTo(HaveOccurred())
+				var names []string
+				for _, entry := range discovered {
+					names = append(names, entry.Name())
+				}
+				Expect(names).To(ConsistOf(append(expectedConfigs, "index.md", "discovery-report.md")))
+				index, err := os.ReadFile(filepath.Join(tempDir, "discovered-repos", "index.md"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(index)).To(ContainSubstring(expectedIndex))
+			},
+			Entry("flagging repositories without tests", discover.UntestedFlag, []string{"api.yaml", "tools.yaml"}, "| test-org/tools | go (no tests) |"),
+			Entry("skipping repositories without tests", discover.UntestedSkip, []string{"api.yaml"}, "## Skipped\n\n- tools: no Go test files\n"),
+		)
+
+		It("should list the modules of repositories with nested go.mod files", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.URL.Path).To(Equal("/repos/test-org/e2e-tests/git/trees/main"))
+				fmt.Fprint(w, `{"tree": [
+					{"path": "go.mod", "type": "blob"},
+					{"path": "tests/e2e_test.go", "type": "blob"},
+					{"path": "tools/generator", "type": "tree"},
+					{"path": "tools/generator/go.mod", "type": "blob"},
+					{"path": "vendor/example.com/lib/go.mod", "type": "blob"},
+					{"path": "pkg/testdata/go.mod", "type": "blob"},
+					{"path": "_examples/go.mod", "type": "blob"}
+				]}`)
+			}))
+			DeferCleanup(server.Close)
+
+			runner = discover.NewRunnerWithDependencies(discover.Config{
+				Organization:  "test-org",
+				ReposDir:      filepath.Join(tempDir, "repos"),
+				DetectModules: true,
+			}, discover.Dependencies{ReadClient: githubClient(server), Owners: owners, PullRequests: prs})
+			cfg, err := runner.Analyze(context.Background(), &github.Repository{Name: github.String("e2e-tests"), DefaultBranch: github.String("main")})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.ExcludeDirs).To(BeEmpty())
+			Expect(cfg.Modules).To(HaveLen(2))
+			Expect(cfg.Modules[0].Path).To(Equal("."))
+			Expect(cfg.Modules[1].Path).To(Equal("tools/generator"))
+			Expect(cfg.Modules[1].ExcludeDirs).To(ContainElement("vendor/"))
+			Expect(cfg.Modules[1].ExcludeFiles).To(ContainElement("*.pb.go"))
+		})
+
+		It("should detect Go repositories by their go.mod files", func() {
+			var mu sync.Mutex
+			treeRequests := make(map[string]int)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				treeRequests[r.URL.Path]++
+				mu.Unlock()
+				switch r.URL.Path {
+				case "/orgs/test-org/repos":
+					fmt.Fprint(w, `[
+						{"name": "api", "language": "Go", "default_branch": "main"},
+						{"name": "deploy", "language": "Shell", "default_branch": "main"},
+						{"name": "gopath", "language": "Go", "default_branch": "main"},
+						{"name": "scripts", "language": "Shell", "default_branch": "main"},
+						{"name": "unlisted", "language": "Go", "default_branch": "main"}
+					]`)
+				case "/repos/test-org/api/git/trees/main":
+					fmt.Fprint(w, `{"tree": [{"path": "go.mod", "type": "blob"}, {"path": "api_test.go", "type": "blob"}]}`)
+				case "/repos/test-org/deploy/git/trees/main":
+					fmt.Fprint(w, `{"tree": [{"path": "deploy.sh", "type": "blob"}, {"path": "tools/go.mod", "type": "blob"}, {"path": "tools/main_test.go", "type": "blob"}]}`)
+				case "/repos/test-org/gopath/git/trees/main":
+					fmt.Fprint(w, `{"tree": [{"path": "main.go", "type": "blob"}]}`)
+				case "/repos/test-org/scripts/git/trees/main":
+					fmt.Fprint(w, `{"tree": [{"path": "run.sh", "type": "blob"}, {"path": "vendor/example.com/lib/go.mod", "type": "blob"}]}`)
+				default:
+					w.WriteHeader(http.StatusInternalServerError)
+				}
+			}))
+			DeferCleanup(server.Close)
+
+			runner = discover.NewRunnerWithDependencies(discover.Config{
+				Organization:  "test-org",
+				ReposDir:      filepath.Join(tempDir, "repos"),
+				Untested:      discover.UntestedFlag,
+				DetectModules: true,
+				DetectBy:      discover.DetectByGoMod,
+			}, discover.Dependencies{ReadClient: githubClient(server), Owners: owners, PullRequests: prs})
+			repos, err := runner.FetchRepositories(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			var names []string
+			for _, repo := range repos {
+				names = append(names, repo.GetName())
+			}
+			// unlisted keeps its primary language, as its files could not be listed
+			Expect(names).To(Equal([]string{"api", "deploy", "unlisted"}))
+
+			cfg, err := runner.Analyze(context.Background(), repos[1])
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.Language).To(BeEmpty())
+			Expect(cfg.NoTests).To(BeFalse())
+			Expect(cfg.Modules).To(HaveLen(1))
+			Expect(cfg.Modules[0].Path).To(Equal("tools"))
+			Expect(treeRequests["/repos/test-org/deploy/git/trees/main"]).To(Equal(1))
+		})
+
+		It("should discover Go sub-projects of repositories in other languages with a deep scan", func() {
+			var mu sync.Mutex
+			var requested []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				requested = append(requested, r.URL.Path)
+				mu.Unlock()
+				switch r.URL.Path {
+				case "/orgs/test-org/repos":
+					fmt.Fprint(w, `[
+						{"name": "api", "language": "Go", "default_branch": "main"},
+						{"name": "deploy", "language": "Shell", "default_branch": "main"},
+						{"name": "docs", "language": "Python", "default_branch": "main"}
+					]`)
+				case "/repos/test-org/deploy/git/trees/main":
+					fmt.Fprint(w, `{"tree": [{"path": "deploy.sh", "type": "blob"}, {"path": "cmd/go.mod", "type": "blob"}, {"path": "tools/lint/go.mod", "type": "blob"}]}`)
+				case "/repos/test-org/docs/git/trees/main":
+					fmt.Fprint(w, `{"tree": [{"path": "conf.py", "type": "blob"}]}`)
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			DeferCleanup(server.Close)
+
+			runner = discover.NewRunnerWithDependencies(discover.Config{
+				Organization: "test-org",
+				ReposDir:     filepath.Join(tempDir, "repos"),
+				Untested:     discover.UntestedIgnore,
+				DeepScan:     true,
+			}, discover.Dependencies{ReadClient: githubClient(server), Owners: owners, PullRequests: prs})
+			repos, err := runner.FetchRepositories(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(repos).To(HaveLen(2))
+			Expect(repos[0].GetName()).To(Equal("api"))
+			Expect(repos[1].GetName()).To(Equal("deploy"))
+			Expect(requested).NotTo(ContainElement("/repos/test-org/api/git/trees/main"))
+
+			// Without a root module, the configuration is scoped to the modules even when they are not detected
+			cfg, err := runner.Analyze(context.Background(), repos[1])
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.ExcludeDirs).To(BeEmpty())
+			Expect(cfg.Modules).To(HaveLen(2))
+			Expect(cfg.Modules[0].Path).To(Equal("cmd"))
+			Expect(cfg.Modules[1].Path).To(Equal("tools/lint"))
+		})
+
+		It("should exclude upstream projects embedded outside vendor/", func() {
+			goMods := map[string]string{
+				"go.mod":                  "module github.com/test-org/operator\n",
+				"tools/go.mod":            "module github.com/test-org/operator/tools\n",
+				"third_party/kube/go.mod": "module k8s.io/kube\n",
+			}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch name := strings.TrimPrefix(r.URL.Path, "/repos/test-org/operator/contents/"); {
+				case r.URL.Path == "/repos/test-org/operator/git/trees/main":
+					fmt.Fprint(w, `{"tree": [
+						{"path": "LICENSE", "type": "blob"},
+						{"path": "go.mod", "type": "blob"},
+						{"path": "tools/go.mod", "type": "blob"},
+						{"path": "tools/LICENSE", "type": "blob"},
+						{"path": "tools/upstream/lib/COPYING", "type": "blob"},
+						{"path": "third_party/kube/LICENSE", "type": "blob"},
+						{"path": "third_party/kube/go.mod", "type": "blob"},
+						{"path": "third_party/forked/LICENSE.md", "type": "blob"}
+					]}`)
+				case goMods[name] != "":
+					Expect(r.URL.Query().Get("ref")).To(Equal("main"))
+					fmt.Fprintf(w, `{"type": "file", "encoding": "base64", "content": %q}`, base64.StdEncoding.EncodeToString([]byte(goMods[name])))
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			DeferCleanup(server.Close)
+
+			runner = discover.NewRunnerWithDependencies(discover.Config{
+				Organization:     "test-org",
+				ReposDir:         filepath.Join(tempDir, "repos"),
+				DetectModules:    true,
+				DetectThirdParty: true,
+			}, discover.Dependencies{ReadClient: githubClient(server), Owners: owners, PullRequests: prs})
+			cfg, err := runner.Analyze(context.Background(), &github.Repository{Name: github.String("operator"), DefaultBranch: github.String("main")})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.Modules).To(HaveLen(2))
+			Expect(cfg.Modules[0].Path).To(Equal("."))
+			Expect(cfg.Modules[0].ExcludeDirs).To(ContainElement("third_party/forked/"))
+			Expect(cfg.Modules[0].ExcludeDirs).NotTo(ContainElement("third_party/kube/"))
+			Expect(cfg.Modules[1].Path).To(Equal("tools"))
+			Expect(cfg.Modules[1].ExcludeDirs).To(ContainElement("upstream/lib/"))
+		})
+
+		It("should fall back to the default owner when detection fails", func() {
+			owners.failing["api"] = true
+			cfg, err := runner.Analyze(context.Background(), &github.Repository{Name: github.String("api")})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.Owners).To(Equal([]string{"@konflux-ci/Vanguard"}))
+			Expect(cfg.OwnersSource).To(Equal(config.OwnersSourceDefault))
+		})
+
+		It("should fall back to the configured default owner", func() {
+			owners.failing["api"] = true
+			runner = discover.NewRunnerWithDependencies(discover.Config{
+				Organization: "test-org",
+				ReposDir:     filepath.Join(tempDir, "repos"),
+				DefaultOwner: "@test-org/onboarding",
+			}, discover.Dependencies{Owners: owners})
+			cfg, err := runner.Analyze(context.Background(), &github.Repository{Name: github.String("api")})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.Owners).To(Equal([]string{"@test-org/onboarding"}))
+		})
+
+		It("should discover the configured languages with their excludes", func() {
+			languages, err := discover.ParseLanguages("go, TypeScript")
+			Expect(err).NotTo(HaveOccurred())
+			runner = discover.NewRunnerWithDependencies(discover.Config{
+				Organization: "test-org",
+				ReposDir:     filepath.Join(tempDir, "repos"),
+				Languages:    languages,
+			}, discover.Dependencies{ReadClient: githubClient(server), Owners: owners})
+
+			ctx := context.Background()
+			repos, err := runner.FetchRepositories(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(repos).To(HaveLen(3))
+			Expect(repos[2].GetName()).To(Equal("ui"))
+
+			cfg, err := runner.Analyze(ctx, repos[2])
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.Language).To(Equal(config.LanguageTypeScript))
+			Expect(cfg.ExcludeDirs).To(ContainElement("node_modules/"))
+			Expect(cfg.ExcludeFiles).To(ContainElement("*.d.ts"))
+
+			cfg, err = runner.Analyze(ctx, repos[0])
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.Language).To(BeEmpty())
+			Expect(cfg.ExcludeFiles).To(ContainElement("*.pb.go"))
+
+			_, err = runner.Analyze(ctx, &github.Repository{Name: github.String("tool"), Language: github.String("Python")})
+			Expect(err).To(MatchError(ContainSubstring(`language "Python" is not one of Go or TypeScript`)))
+		})
+	})
+
+	Describe("ParseOnly", func() {
+		It("should parse names and org/names of the organization once each", func() {
+			names, err := discover.ParseOnly("api, Test-Org/cli,api,,", "test-org")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(names).To(Equal([]string{"api", "cli"}))
+		})
+
+		It("should reject repositories of other organizations and malformed names", func() {
+			_, err := discover.ParseOnly("other-org/api", "test-org")
+			Expect(err).To(MatchError(ContainSubstring("not in the test-org organization")))
+			_, err = discover.ParseOnly("test-org/", "test-org")
+			Expect(err).To(MatchError(ContainSubstring("must be name or org/name")))
+		})
+	})
+
+	Describe("ParseLanguages", func() {
+		It("should parse known languages once each", func() {
+			languages, err := discover.ParseLanguages("python,go,python")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(languages).To(HaveLen(2))
+			Expect(languages[0].GitHubName).To(Equal("Python"))
+		})
+
+		It("should reject unknown or missing languages", func() {
+			_, err := discover.ParseLanguages("go,rust")
+			Expect(err).To(MatchError(`unknown language "rust", expected one of go, python, typescript`))
+			_, err = discover.ParseLanguages(" , ")
+			Expect(err).To(MatchError(ContainSubstring("no languages given")))
+		})
+	})
+
+	Describe("ParseActivity", func() {
+		It("should parse days, weeks and Go durations", func() {
+			for s, window := range map[string]time.Duration{"180d": 180 * 24 * time.Hour, "26w": 26 * 7 * 24 * time.Hour, "720h": 720 * time.Hour} {
+				Expect(discover.ParseActivity(s)).To(Equal(window), s)
+			}
+		})
+
+		It("should reject invalid or non-positive windows", func() {
+			_, err := discover.ParseActivity("six months")
+			Expect(err).To(MatchError(`invalid activity window "six months": expected e.g. 180d, 26w or 720h`))
+			_, err = discover.ParseActivity("0d")
+			Expect(err).To(MatchError(`activity window "0d" must be positive`))
+		})
 	})
 
 	Describe("PR Management", func() {
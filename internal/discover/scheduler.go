@@ -0,0 +1,187 @@
+package discover
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/schedule"
+)
+
+// LockFile is the name of the lock file of scheduled runs, next to the cache of the GitHub API responses
+const LockFile = "discover.lock"
+
+const (
+	// lockHeartbeat is how often the holder of the lock refreshes it
+	lockHeartbeat = time.Minute
+	// lockExpiry is how long a lock lives without a heartbeat before it is taken over, e.g. after its holder was
+	// killed with the pod it ran in
+	lockExpiry = 5 * lockHeartbeat
+	// lockGuardSuffix names the file next to the lock whose OS lock is held while the lock is checked, taken, taken
+	// over or released, so processes doing so at the same time do it one after the other; it is never removed
+	lockGuardSuffix = ".guard"
+)
+
+// ErrLocked reports a run skipped because another process holds the lock
+var ErrLocked = errors.New("another run holds the lock")
+
+// Lock is the content of the lock file, telling who holds it
+type Lock struct {
+	Host      string    `json:"host"`
+	PID       int       `json:"pid"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// Scheduler runs discovery on a cron schedule in a long-lived process, one run at a time across the processes
+// sharing its lock file, e.g. the replicas of a Deployment on a shared volume
+type Scheduler struct {
+	runner   *Runner
+	schedule *schedule.Schedule
+	lockFile string
+}
+
+// NewScheduler creates a scheduler running runner at the times of s, holding lockFile during each run
+func NewScheduler(runner *Runner, s *schedule.Schedule, lockFile string) *Scheduler {
+	return &Scheduler{runner: runner, schedule: s, lockFile: lockFile}
+}
+
+// Run runs discovery at each time of the schedule until ctx is done
+// Failed and skipped runs are printed, and left to the next time
+func (s *Scheduler) Run(ctx context.Context) {
+	for {
+		next := s.schedule.Next(time.Now())
+		if next.IsZero() {
+			fmt.Printf("⏰ %v never matches, no discovery is scheduled\n", s.schedule.Expressions)
+			return
+		}
+		fmt.Printf("⏰ Next discovery at %s\n", next.Format(time.RFC3339))
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if err := s.RunOnce(ctx); errors.Is(err, ErrLocked) {
+			fmt.Printf("⏭️  Skipped the discovery of %s: %v\n", next.Format(time.RFC3339), err)
+		} else if err != nil {
+			fmt.Printf("❌ Discovery failed: %v\n", err)
+		}
+		fmt.Println()
+	}
+}
+
+// RunOnce runs discovery once if no other process holds the lock, returning ErrLocked otherwise
+func (s *Scheduler) RunOnce(ctx context.Context) error {
+	release, err := acquireLock(s.lockFile)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	// The heartbeat keeps the lock alive through long runs
+	heartbeatCtx, stop := context.WithCancel(ctx)
+	defer stop()
+	go func() {
+		ticker := time.NewTicker(lockHeartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-heartbeatCtx.Done():
+				return
+			case now := <-ticker.C:
+				if err := os.Chtimes(s.lockFile, now, now); err != nil {
+					fmt.Printf("⚠️  Warning: failed to refresh lock %s: %v\n", s.lockFile, err)
+				}
+			}
+		}
+	}()
+	return s.runner.Run(ctx)
+}
+
+// acquireLock creates the lock file, taking it over when its holder stopped refreshing it, and returns the
+// function releasing it
+func acquireLock(path string) (func(), error) {
+	host, _ := os.Hostname()
+	data, err := json.Marshal(Lock{Host: host, PID: os.Getpid(), StartedAt: time.Now().UTC()})
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create lock directory: %w", err)
+	}
+
+	unlock, err := guardLock(path)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	info, err := os.Stat(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("failed to read lock %s: %w", path, err)
+	case time.Since(info.ModTime()) < lockExpiry:
+		holder := readLock(path)
+		return nil, fmt.Errorf("%w: %s (pid %d) since %s", ErrLocked, holder.Host, holder.PID, holder.StartedAt.Format(time.RFC3339))
+	default:
+		holder := readLock(path)
+		fmt.Printf("🔓 Taking over the lock of %s (pid %d), not refreshed since %s\n", holder.Host, holder.PID, info.ModTime().UTC().Format(time.RFC3339))
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		os.Remove(path)
+		return nil, fmt.Errorf("failed to write lock %s: %w", path, err)
+	}
+
+	// The lock is only removed while it is still ours, not after another process took it over
+	release := func() {
+		unlock, err := guardLock(path)
+		if err != nil {
+			fmt.Printf("⚠️  Warning: failed to release lock %s: %v\n", path, err)
+			return
+		}
+		defer unlock()
+		if content, err := os.ReadFile(path); err != nil || !bytes.Equal(content, data) {
+			return
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			fmt.Printf("⚠️  Warning: failed to release lock %s: %v\n", path, err)
+		}
+	}
+	return release, nil
+}
+
+// guardLock holds the OS lock of the guard file of the lock at path, waiting for other processes holding it, and
+// returns the function unlocking it
+func guardLock(path string) (func(), error) {
+	guardPath := path + lockGuardSuffix
+	guard, err := os.OpenFile(guardPath, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock guard %s: %w", guardPath, err)
+	}
+	if err := syscall.Flock(int(guard.Fd()), syscall.LOCK_EX); err != nil {
+		guard.Close()
+		return nil, fmt.Errorf("failed to lock %s: %w", guardPath, err)
+	}
+	return func() {
+		syscall.Flock(int(guard.Fd()), syscall.LOCK_UN)
+		guard.Close()
+	}, nil
+}
+
+// readLock reads who holds a lock, as far as its file tells
+func readLock(path string) Lock {
+	var holder Lock
+	content, _ := os.ReadFile(path)
+	json.Unmarshal(content, &holder)
+	return holder
+}
@@ -0,0 +1,78 @@
+package discover_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/discover"
+	"github.com/konflux-ci/coverage-dashboard/internal/schedule"
+)
+
+var _ = Describe("Scheduler", func() {
+	var (
+		tempDir   string
+		lockFile  string
+		scheduler *discover.Scheduler
+	)
+
+	BeforeEach(func() {
+		tempDir = GinkgoT().TempDir()
+		reposDir := filepath.Join(tempDir, "repos")
+		Expect(os.MkdirAll(reposDir, 0755)).To(Succeed())
+		runner, err := discover.NewRunner(discover.Config{
+			Organization:   "test-org",
+			ReposDir:       reposDir,
+			CodeownersFile: filepath.Join(tempDir, "CODEOWNERS"),
+			FixturesDir:    "testdata/org-scenario",
+			Offline:        true,
+			DryRun:         true,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		daily, err := schedule.Parse("0 3 * * *")
+		Expect(err).NotTo(HaveOccurred())
+		lockFile = filepath.Join(tempDir, "cache", discover.LockFile)
+		scheduler = discover.NewScheduler(runner, daily, lockFile)
+	})
+
+	holdLock := func(refreshed time.Time) {
+		Expect(os.MkdirAll(filepath.Dir(lockFile), 0755)).To(Succeed())
+		data, err := json.Marshal(discover.Lock{Host: "other-pod", PID: 7, StartedAt: refreshed})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.WriteFile(lockFile, data, 0644)).To(Succeed())
+		Expect(os.Chtimes(lockFile, refreshed, refreshed)).To(Succeed())
+	}
+
+	It("should run discovery, releasing the lock after each run", func() {
+		Expect(scheduler.RunOnce(context.Background())).To(Succeed())
+		Expect(filepath.Join(tempDir, "discovered-repos", "api.yaml")).To(BeAnExistingFile())
+		Expect(lockFile).NotTo(BeAnExistingFile())
+
+		// Runs start over, leaving nothing of the previous one
+		Expect(scheduler.RunOnce(context.Background())).To(Succeed())
+		Expect(filepath.Join(tempDir, "discovered-repos", "api.yaml")).To(BeAnExistingFile())
+	})
+
+	It("should skip runs while another process refreshes the lock", func() {
+		holdLock(time.Now())
+		err := scheduler.RunOnce(context.Background())
+		Expect(errors.Is(err, discover.ErrLocked)).To(BeTrue())
+		Expect(err).To(MatchError(ContainSubstring("other-pod (pid 7)")))
+		Expect(filepath.Join(tempDir, "discovered-repos")).NotTo(BeADirectory())
+		Expect(lockFile).To(BeAnExistingFile())
+	})
+
+	It("should take over locks their holder stopped refreshing", func() {
+		holdLock(time.Now().Add(-time.Hour))
+		Expect(scheduler.RunOnce(context.Background())).To(Succeed())
+		Expect(filepath.Join(tempDir, "discovered-repos", "api.yaml")).To(BeAnExistingFile())
+		Expect(lockFile).NotTo(BeAnExistingFile())
+		Expect(os.ReadDir(filepath.Dir(lockFile))).To(ConsistOf(HaveField("Name()", discover.LockFile+".guard")))
+	})
+})
@@ -0,0 +1,162 @@
+package discover
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+)
+
+// StateFile is the name of the checkpoint of discovery runs, in the cache directory
+const StateFile = "discover-state.json"
+
+// State is the checkpoint of a discovery run, saved as it goes so a run that died can resume where it stopped
+type State struct {
+	Organization string    `json:"organization"`
+	StartedAt    time.Time `json:"started_at"`
+	// Analyses are the repositories analyzed, by name; those skipped for errors are analyzed again
+	Analyses map[string]AnalysisState `json:"analyses"`
+	// PullRequests are the URLs of the pull requests opened, by full name of their repository
+	PullRequests map[string]string `json:"pull_requests"`
+}
+
+// AnalysisState is the configuration an analysis built, with the progress it printed
+type AnalysisState struct {
+	Config config.RepositoryConfig `json:"config"`
+	Output string                  `json:"output,omitempty"`
+}
+
+// loadState returns the checkpoint of the run, reading it on first use when resuming; nil without a StateFile
+func (r *Runner) loadState() (*State, error) {
+	if r.config.StateFile == "" || r.state != nil {
+		return r.state, nil
+	}
+	r.state = &State{
+		Organization: r.config.Organization,
+		StartedAt:    time.Now().UTC(),
+		Analyses:     make(map[string]AnalysisState),
+		PullRequests: make(map[string]string),
+	}
+	if !r.config.Resume {
+		return r.state, nil
+	}
+
+	data, err := os.ReadFile(r.config.StateFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		fmt.Printf("♻️  No checkpoint at %s, starting from scratch\n", r.config.StateFile)
+		return r.state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+	var saved State
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint %s: %w", r.config.StateFile, err)
+	}
+	if saved.Organization != r.config.Organization {
+		return nil, fmt.Errorf("checkpoint %s is of the %s organization, not %s", r.config.StateFile, saved.Organization, r.config.Organization)
+	}
+	if saved.Analyses == nil {
+		saved.Analyses = make(map[string]AnalysisState)
+	}
+	if saved.PullRequests == nil {
+		saved.PullRequests = make(map[string]string)
+	}
+	r.state = &saved
+	fmt.Printf("♻️  Resuming the run of %s: %d repositories analyzed, %d pull requests opened\n",
+		saved.StartedAt.Format(time.RFC3339), len(saved.Analyses), len(saved.PullRequests))
+	return r.state, nil
+}
+
+// saveState writes the checkpoint, replacing the previous one at once
+// A checkpoint that cannot be written only costs the work a resumed run redoes, so failures are warnings
+func (r *Runner) saveState() {
+	if r.state == nil {
+		return
+	}
+	data, err := json.MarshalIndent(r.state, "", "  ")
+	if err == nil {
+		err = writeFileAtomic(r.config.StateFile, data)
+	}
+	if err != nil {
+		fmt.Printf("  ⚠️  Warning: failed to save checkpoint: %v\n", err)
+	}
+}
+
+// recordAnalysis checkpoints the configuration the analysis of a repository built
+func (r *Runner) recordAnalysis(name string, cfg config.RepositoryConfig, output string) {
+	if r.state == nil {
+		return
+	}
+	r.state.Analyses[name] = AnalysisState{Config: cfg, Output: output}
+	r.saveState()
+}
+
+// recordPullRequest checkpoints the pull request opened for a repository
+func (r *Runner) recordPullRequest(fullName, url string) {
+	if r.state == nil {
+		return
+	}
+	r.state.PullRequests[fullName] = url
+	r.saveState()
+}
+
+// resumedAnalysis returns the analysis of a repository an earlier run saved
+func (r *Runner) resumedAnalysis(name string) (AnalysisState, bool) {
+	if r.state == nil {
+		return AnalysisState{}, false
+	}
+	saved, ok := r.state.Analyses[name]
+	return saved, ok
+}
+
+// openedPullRequest returns the pull request an earlier run opened for a repository
+func (r *Runner) openedPullRequest(fullName string) (string, bool) {
+	if r.state == nil {
+		return "", false
+	}
+	url, ok := r.state.PullRequests[fullName]
+	return url, ok
+}
+
+// finishState removes the checkpoint of a run that completed, which has nothing left to resume, and tells how to
+// resume one that failed, or could not open every pull request
+func (r *Runner) finishState(err *error) {
+	if r.state == nil {
+		return
+	}
+	if *err != nil || r.failedPullRequests > 0 {
+		if len(r.state.Analyses) > 0 || len(r.state.PullRequests) > 0 {
+			fmt.Printf("💾 Progress saved to %s, run again with --resume to continue\n", r.config.StateFile)
+		}
+		return
+	}
+	if removeErr := os.Remove(r.config.StateFile); removeErr != nil && !errors.Is(removeErr, fs.ErrNotExist) {
+		fmt.Printf("⚠️  Warning: failed to remove checkpoint: %v\n", removeErr)
+	}
+}
+
+// writeFileAtomic writes a file through a temporary file renamed over it, so readers never see it half written
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	temp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+	if _, err := temp.Write(data); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), path)
+}
@@ -0,0 +1,69 @@
+package discover
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/go-github/v66/github"
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+	"github.com/konflux-ci/coverage-dashboard/internal/thirdparty"
+)
+
+// thirdPartyTrees detects the upstream projects embedded in a repository from the files of its tree
+func (r *Runner) thirdPartyTrees(ctx context.Context, repo *github.Repository, tree *github.Tree) ([]thirdparty.Tree, error) {
+	var files []string
+	for _, entry := range tree.Entries {
+		if entry.GetType() == "blob" {
+			files = append(files, entry.GetPath())
+		}
+	}
+	return thirdparty.Detect(files, func(name string) ([]byte, error) {
+		return r.provider.File(ctx, r.config.Organization, repo, name)
+	})
+}
+
+// withoutThirdParty removes the modules inside embedded third-party trees
+func withoutThirdParty(modules []string, trees []thirdparty.Tree) []string {
+	var kept []string
+	for _, module := range modules {
+		embedded := false
+		for _, tree := range trees {
+			if tree.Contains(module) {
+				embedded = true
+			}
+		}
+		if !embedded {
+			kept = append(kept, module)
+		}
+	}
+	return kept
+}
+
+// excludeThirdParty adds the embedded third-party trees that are no modules of their own to the exclude_dirs of the
+// module they are in; go test skips the others, being nested modules
+func excludeThirdParty(cfg *config.RepositoryConfig, trees []thirdparty.Tree) {
+	for _, tree := range trees {
+		if tree.Module != "" {
+			continue
+		}
+		excludes, dir := &cfg.ExcludeDirs, tree.Dir
+		if len(cfg.Modules) > 0 {
+			// The innermost module holding the tree excludes it, by its path within the module
+			excludes = nil
+			longest := -1
+			for i, module := range cfg.Modules {
+				switch {
+				case module.Path == config.RootModule && longest < 0:
+					excludes, dir, longest = &cfg.Modules[i].ExcludeDirs, tree.Dir, 0
+				case strings.HasPrefix(tree.Dir, module.Path+"/") && len(module.Path) > longest:
+					excludes, dir, longest = &cfg.Modules[i].ExcludeDirs, strings.TrimPrefix(tree.Dir, module.Path+"/"), len(module.Path)
+				}
+			}
+			// Trees outside every module are never collected
+			if excludes == nil {
+				continue
+			}
+		}
+		*excludes = append(*excludes, dir+"/")
+	}
+}
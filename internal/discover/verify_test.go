@@ -0,0 +1,93 @@
+package discover
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+
+	"github.com/google/go-github/v66/github"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/ghauth"
+)
+
+var _ = Describe("verifyTokens", func() {
+	var (
+		server   *httptest.Server
+		runner   *Runner
+		provider *gitHubProvider
+		scopes   map[string]string
+	)
+
+	// newClient returns a client for the test server sending token as bearer
+	newClient := func(token string) *github.Client {
+		client := github.NewClient(nil).WithAuthToken(token)
+		client.BaseURL, _ = url.Parse(server.URL + "/")
+		return client
+	}
+
+	BeforeEach(func() {
+		scopes = map[string]string{"Bearer read": "read:org", "Bearer write": "repo"}
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/user":
+				w.Header().Set("X-OAuth-Scopes", scopes[r.Header.Get("Authorization")])
+				fmt.Fprint(w, `{"login": "bot"}`)
+			case "/orgs/konflux-ci/repos":
+				fmt.Fprint(w, `[]`)
+			case "/repos/konflux-ci/coverage-dashboard":
+				push := r.Header.Get("Authorization") == "Bearer write"
+				fmt.Fprintf(w, `{"name": "coverage-dashboard", "permissions": {"push": %t}}`, push)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+
+		// The dashboard repository is taken from the origin remote of the working directory
+		workDir := GinkgoT().TempDir()
+		for _, args := range [][]string{
+			{"init", workDir},
+			{"-C", workDir, "remote", "add", "origin", "https://github.com/konflux-ci/coverage-dashboard.git"},
+		} {
+			Expect(exec.Command("git", args...).Run()).To(Succeed())
+		}
+		previousDir, err := os.Getwd()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.Chdir(workDir)).To(Succeed())
+		DeferCleanup(os.Chdir, previousDir)
+
+		provider = &gitHubProvider{
+			tokens: ghauth.Tokens{
+				Read: "read", ReadSource: ghauth.ReadTokenEnv,
+				Write: "write", WriteSource: ghauth.WriteTokenEnv,
+			},
+			read:  newClient("read"),
+			write: newClient("write"),
+		}
+		runner = &Runner{config: Config{Organization: "konflux-ci"}, provider: provider}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should accept tokens with the required scopes and permissions", func() {
+		Expect(runner.verifyTokens(context.Background())).To(Succeed())
+	})
+
+	It("should list every missing scope and permission", func() {
+		scopes["Bearer read"] = "repo"
+		provider.write = newClient("other")
+
+		err := runner.verifyTokens(context.Background())
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("GITHUB_READ_TOKEN is missing scopes: read:org"))
+		Expect(err.Error()).To(ContainSubstring("GITHUB_WRITE_TOKEN is missing scopes: repo"))
+		Expect(err.Error()).To(ContainSubstring("GITHUB_WRITE_TOKEN: token has no push permission on konflux-ci/coverage-dashboard"))
+	})
+})
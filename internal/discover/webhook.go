@@ -0,0 +1,146 @@
+package discover
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-github/v66/github"
+)
+
+// WebhookSecretEnv holds the secret GitHub signs the webhook deliveries with
+const WebhookSecretEnv = "GITHUB_WEBHOOK_SECRET"
+
+// webhookQueueSize bounds the repositories waiting for discovery; deliveries beyond it are refused for GitHub to
+// report as failed, so they can be redelivered
+const webhookQueueSize = 100
+
+// Actions of repository events announcing a repository new to the organization
+const (
+	actionCreated     = "created"
+	actionTransferred = "transferred"
+)
+
+// Webhook serves GitHub webhook deliveries, discovering the repositories created in or transferred to the
+// organization one at a time, as they share the runner and the checkout of the dashboard repository
+type Webhook struct {
+	runner *Runner
+	secret []byte
+	queue  chan *github.Repository
+}
+
+// NewWebhook creates a webhook discovering repositories with runner, accepting deliveries signed with secret
+func NewWebhook(runner *Runner, secret []byte) *Webhook {
+	return &Webhook{runner: runner, secret: secret, queue: make(chan *github.Repository, webhookQueueSize)}
+}
+
+// ServeHTTP queues the repository of a repository event for discovery, answering before it is analyzed as GitHub
+// gives up on deliveries after ten seconds
+func (w *Webhook) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	payload, err := github.ValidatePayload(req, w.secret)
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("invalid signature: %v", err), http.StatusUnauthorized)
+		return
+	}
+	event, err := github.ParseWebHook(github.WebHookType(req), payload)
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("invalid event: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	repoEvent, ok := event.(*github.RepositoryEvent)
+	if !ok {
+		fmt.Fprintf(rw, "ignored %s event\n", github.WebHookType(req))
+		return
+	}
+	repo := repoEvent.GetRepo()
+	action := repoEvent.GetAction()
+	// Transferred repositories belong to their new owner in the event
+	if (action != actionCreated && action != actionTransferred) || !strings.EqualFold(repo.GetOwner().GetLogin(), w.runner.config.Organization) {
+		fmt.Fprintf(rw, "ignored %s of %s\n", action, repo.GetFullName())
+		return
+	}
+	select {
+	case w.queue <- repo:
+		fmt.Printf("🔔 Queued %s, %s\n", repo.GetFullName(), action)
+		rw.WriteHeader(http.StatusAccepted)
+		fmt.Fprintf(rw, "queued %s\n", repo.GetFullName())
+	default:
+		http.Error(rw, "too many repositories waiting for discovery", http.StatusServiceUnavailable)
+	}
+}
+
+// Run discovers the queued repositories one at a time until ctx is done
+// Failures are printed, and leave the repository to the next scheduled run
+func (w *Webhook) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case repo := <-w.queue:
+			if err := w.runner.DiscoverRepository(ctx, repo); err != nil {
+				fmt.Printf("  ❌ Discovery of %s failed: %v\n", repo.GetFullName(), err)
+			}
+			fmt.Println()
+		}
+	}
+}
+
+// DiscoverRepository runs the analysis and pull request of Run for a single repository of the organization, e.g.
+// one a webhook announced, skipping it when it is not one Run would add
+func (r *Runner) DiscoverRepository(ctx context.Context, repo *github.Repository) error {
+	r.running.Lock()
+	defer r.running.Unlock()
+
+	fullName := fmt.Sprintf("%s/%s", r.config.Organization, repo.GetName())
+	fmt.Printf("→ Discovering %s...\n", fullName)
+	if repo.GetArchived() {
+		fmt.Println("  ⏭️  Skipped: archived")
+		return nil
+	}
+
+	var repos []*github.Repository
+	if r.listed(repo) {
+		repos = append(repos, repo)
+	}
+	if r.scansForGoModules() {
+		var err error
+		if repos, err = r.detectGoModules(ctx, repos); err != nil {
+			return fmt.Errorf("failed to look for go.mod files: %w", err)
+		}
+	}
+	if len(repos) == 0 {
+		fmt.Printf("  ⏭️  Skipped: not in %s\n", languageNames(r.config.Languages))
+		return nil
+	}
+
+	newRepos, err := r.FilterNew(repos)
+	if err != nil {
+		return fmt.Errorf("failed to load existing repos: %w", err)
+	}
+	switch {
+	case r.existingRepos[fullName]:
+		fmt.Println("  ⏭️  Skipped: already tracked")
+		return nil
+	case r.filteredRepos[fullName] != "":
+		fmt.Printf("  🚫 Skipped by discovery filters: %s\n", r.filteredRepos[fullName])
+		return nil
+	case !r.staleRepos[fullName].IsZero():
+		fmt.Printf("  💤 Skipped: no push in %s\n", r.activityWindow())
+		return nil
+	}
+
+	configs, _, err := r.analyzeAll(ctx, newRepos)
+	if err != nil {
+		return err
+	}
+	if r.config.DryRun {
+		return r.Write(ctx, configs)
+	}
+	return r.OpenPullRequests(ctx, configs)
+}
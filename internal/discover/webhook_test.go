@@ -0,0 +1,117 @@
+package discover_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-github/v66/github"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+	"github.com/konflux-ci/coverage-dashboard/internal/discover"
+)
+
+// notifyingCreator records pull requests like recordingCreator, sending the name of each on opened
+type notifyingCreator struct {
+	recordingCreator
+	opened chan string
+}
+
+func (c *notifyingCreator) CreatePullRequest(ctx context.Context, cfg config.RepositoryConfig, writer *config.Writer) (string, error) {
+	url, err := c.recordingCreator.CreatePullRequest(ctx, cfg, writer)
+	c.opened <- cfg.Name
+	return url, err
+}
+
+var _ = Describe("Webhook", func() {
+	var (
+		tempDir string
+		runner  *discover.Runner
+		prs     *notifyingCreator
+		server  *httptest.Server
+		cancel  context.CancelFunc
+	)
+
+	BeforeEach(func() {
+		tempDir = GinkgoT().TempDir()
+		reposDir := filepath.Join(tempDir, "repos")
+		Expect(os.MkdirAll(reposDir, 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(reposDir, "tracked.yaml"), []byte("name: test-org/tracked\n"), 0644)).To(Succeed())
+
+		prs = &notifyingCreator{opened: make(chan string, 10)}
+		runner = discover.NewRunnerWithDependencies(discover.Config{
+			Organization:   "test-org",
+			ReposDir:       reposDir,
+			CodeownersFile: filepath.Join(tempDir, "CODEOWNERS"),
+		}, discover.Dependencies{
+			Owners:       staticOwners{owners: []string{"@test-org/api-team"}},
+			PullRequests: prs,
+			// Not a checkout, so no pull request counts as already open
+			WorkDir: tempDir,
+		})
+
+		webhook := discover.NewWebhook(runner, []byte("secret"))
+		server = httptest.NewServer(webhook)
+		var ctx context.Context
+		ctx, cancel = context.WithCancel(context.Background())
+		go webhook.Run(ctx)
+	})
+
+	AfterEach(func() {
+		cancel()
+		server.Close()
+	})
+
+	deliver := func(event, secret, body string) int {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(body))
+		req, err := http.NewRequest(http.MethodPost, server.URL, strings.NewReader(body))
+		Expect(err).NotTo(HaveOccurred())
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-GitHub-Event", event)
+		req.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+		resp, err := http.DefaultClient.Do(req)
+		Expect(err).NotTo(HaveOccurred())
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	It("should open the pull request of repositories created in or transferred to the organization", func() {
+		Expect(deliver("repository", "secret", `{"action": "created", "repository": {"name": "api", "full_name": "test-org/api", "language": "Go", "owner": {"login": "test-org"}}}`)).To(Equal(http.StatusAccepted))
+		Eventually(prs.opened).Should(Receive(Equal("test-org/api")))
+
+		Expect(deliver("repository", "secret", `{"action": "transferred", "repository": {"name": "moved", "full_name": "test-org/moved", "language": "Go", "owner": {"login": "Test-Org"}}}`)).To(Equal(http.StatusAccepted))
+		Eventually(prs.opened).Should(Receive(Equal("test-org/moved")))
+	})
+
+	It("should ignore other events, actions and organizations", func() {
+		Expect(deliver("ping", "secret", `{"zen": "Keep it logically awesome."}`)).To(Equal(http.StatusOK))
+		Expect(deliver("repository", "secret", `{"action": "deleted", "repository": {"name": "api", "language": "Go", "owner": {"login": "test-org"}}}`)).To(Equal(http.StatusOK))
+		Expect(deliver("repository", "secret", `{"action": "created", "repository": {"name": "api", "language": "Go", "owner": {"login": "other-org"}}}`)).To(Equal(http.StatusOK))
+		Consistently(prs.opened).ShouldNot(Receive())
+	})
+
+	It("should refuse deliveries not signed with the secret", func() {
+		Expect(deliver("repository", "guess", `{"action": "created", "repository": {"name": "api", "language": "Go", "owner": {"login": "test-org"}}}`)).To(Equal(http.StatusUnauthorized))
+	})
+
+	It("should skip repositories discovery would not add", func() {
+		ctx := context.Background()
+		for _, repo := range []*github.Repository{
+			{Name: github.String("tracked"), Language: github.String("Go")},
+			{Name: github.String("scripts"), Language: github.String("Python")},
+			{Name: github.String("old"), Language: github.String("Go"), Archived: github.Bool(true)},
+		} {
+			Expect(runner.DiscoverRepository(ctx, repo)).To(Succeed())
+		}
+		Expect(prs.created).To(BeEmpty())
+	})
+})
@@ -0,0 +1,234 @@
+// Package display shapes the progress output of commands working through many repositories: a line per step by
+// default, a progress bar with --progress, or only the final summary with --quiet
+package display
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/console"
+)
+
+// Mode is how a command prints its progress
+type Mode int
+
+const (
+	// Lines prints every line, the default
+	Lines Mode = iota
+	// Progress draws a bar instead of the lines printed for each item, keeping warnings and failures
+	Progress
+	// Quiet prints nothing before the final summary
+	Quiet
+)
+
+// barWidth is the number of cells of the progress bar
+const barWidth = 30
+
+// control starts the lines the phase methods of Mode print for the Writer, which never writes them; a private use
+// character keeps them apart from any line of the commands
+const control = "\uE000output "
+
+// Failure markers of lines kept above the progress bar, besides console.WarningMarker
+var failureMarkers = []string{console.WarningMarker, "❌"}
+
+// ModeOf returns the mode of the --progress and --quiet flags
+func ModeOf(progress, quiet bool) (Mode, error) {
+	switch {
+	case progress && quiet:
+		return Lines, errors.New("--progress cannot be combined with --quiet")
+	case progress:
+		return Progress, nil
+	case quiet:
+		return Quiet, nil
+	default:
+		return Lines, nil
+	}
+}
+
+// On returns the mode to print to out in: Progress falls back to Lines when out is not a terminal, e.g. a CI log,
+// which would keep every bar drawn instead of drawing them over each other
+func (m Mode) On(out *os.File) Mode {
+	if m == Progress && !Terminal(out) {
+		return Lines
+	}
+	return m
+}
+
+// Terminal reports whether f is a terminal
+func Terminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Start marks the start of total items, e.g. the repositories to analyze
+// The phase methods print to os.Stdout, which must go through a Writer unless the mode is Lines
+func (m Mode) Start(total int) {
+	m.mark("start " + strconv.Itoa(total))
+}
+
+// Done marks an item done
+func (m Mode) Done(name string) {
+	m.mark("done " + name)
+}
+
+// Finish marks the end of the items
+func (m Mode) Finish() {
+	m.mark("finish")
+}
+
+// Summary marks the start of the final summary
+func (m Mode) Summary() {
+	m.mark("summary")
+}
+
+// mark prints a control line for the Writer, in the output stream so it stays in order with the lines around it
+func (m Mode) mark(event string) {
+	if m != Lines {
+		fmt.Fprintln(os.Stdout, control+event)
+	}
+}
+
+// Writer writes output line by line according to a mode, following the phases marked in it
+// A console.Writer in front of it still counts the warnings it hides
+type Writer struct {
+	mu      sync.Mutex
+	out     io.Writer
+	mode    Mode
+	style   console.Style
+	partial []byte
+	// items is set between Start and Finish, summary after Summary
+	items, summary bool
+	total, done    int
+	last           string // The item done last, shown next to the bar
+	bar            bool   // A bar is drawn on the last line
+	// heading is the last hidden line starting an item, printed above the first failure of the item
+	heading string
+}
+
+// NewWriter creates a Writer writing to out in a mode, rendering lines in a style
+func NewWriter(out io.Writer, mode Mode, style console.Style) *Writer {
+	return &Writer{out: out, mode: mode, style: style}
+}
+
+// Write writes the complete lines of p, keeping the rest until the next Write or Flush
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.partial = append(w.partial, p...)
+	for {
+		i := bytes.IndexByte(w.partial, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		line := string(w.partial[:i+1])
+		w.partial = w.partial[i+1:]
+		if err := w.writeLine(line); err != nil {
+			return len(p), err
+		}
+	}
+}
+
+// Flush writes the last line when it does not end with a newline, and ends a bar left drawn
+func (w *Writer) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	line := string(w.partial)
+	w.partial = nil
+	if line != "" {
+		if err := w.writeLine(line); err != nil {
+			return err
+		}
+	}
+	return w.endBar()
+}
+
+// writeLine follows a control line, or writes a line unless the mode hides it
+func (w *Writer) writeLine(line string) error {
+	if event, ok := strings.CutPrefix(line, control); ok {
+		return w.follow(strings.TrimSuffix(event, "\n"))
+	}
+	switch {
+	case w.mode == Quiet && !w.summary:
+		return nil
+	case w.mode == Progress && w.items:
+		if !failure(line) {
+			if strings.TrimLeft(line, " ") == line {
+				w.heading = line
+			}
+			return nil
+		}
+		// Failures go above the bar, under the heading of their item, and the bar is drawn again below them
+		if _, err := io.WriteString(w.out, "\r\x1b[K"+w.style.Render(w.heading+line)); err != nil {
+			return err
+		}
+		w.heading = ""
+		return w.drawBar()
+	default:
+		_, err := io.WriteString(w.out, w.style.Render(line))
+		return err
+	}
+}
+
+// follow updates the phase of the output, drawing the bar of Progress
+func (w *Writer) follow(event string) error {
+	name, arg, _ := strings.Cut(event, " ")
+	switch name {
+	case "start":
+		w.items, w.done, w.last = true, 0, ""
+		w.total, _ = strconv.Atoi(arg)
+		if w.mode == Progress {
+			return w.drawBar()
+		}
+	case "done":
+		w.done, w.last = w.done+1, arg
+		if w.mode == Progress && w.items {
+			return w.drawBar()
+		}
+	case "finish":
+		w.items = false
+		return w.endBar()
+	case "summary":
+		w.summary = true
+	}
+	return nil
+}
+
+// drawBar draws the bar over the last line, e.g. [#######-----------] 7/18 api
+func (w *Writer) drawBar() error {
+	filled := barWidth
+	if w.total > 0 {
+		filled = min(w.done*barWidth/w.total, barWidth)
+	}
+	bar := fmt.Sprintf("\r\x1b[K[%s%s] %d/%d %s", strings.Repeat("#", filled), strings.Repeat("-", barWidth-filled), w.done, w.total, w.last)
+	w.bar = true
+	_, err := io.WriteString(w.out, strings.TrimRight(bar, " "))
+	return err
+}
+
+// endBar moves past a drawn bar, keeping it as the last state of the items
+func (w *Writer) endBar() error {
+	if !w.bar {
+		return nil
+	}
+	w.bar = false
+	_, err := io.WriteString(w.out, "\n")
+	return err
+}
+
+// failure reports whether a line is a warning or a failure
+func failure(line string) bool {
+	for _, marker := range failureMarkers {
+		if strings.Contains(line, marker) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+package display_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/console"
+	"github.com/konflux-ci/coverage-dashboard/internal/display"
+)
+
+// run runs a command printing to os.Stdout in a mode and returns what the Writer wrote
+func run(mode display.Mode, style console.Style, command func()) string {
+	var out strings.Builder
+	w := display.NewWriter(&out, mode, style)
+	restore, err := console.Capture(&os.Stdout, console.NewWriter(w, console.Unicode))
+	Expect(err).NotTo(HaveOccurred())
+	command()
+	Expect(restore()).To(Succeed())
+	Expect(w.Flush()).To(Succeed())
+	return out.String()
+}
+
+// analyze prints like discovery analyzing three repositories, one of them skipped
+func analyze(mode display.Mode) func() {
+	return func() {
+		fmt.Println("→ Identifying new repositories to add...")
+		mode.Start(3)
+		for i, name := range []string{"api", "cli", "ui"} {
+			fmt.Printf("📦 [%d/3] %s\n", i+1, name)
+			if name == "cli" {
+				fmt.Println("  ⚠️  Skipped: no Go test files")
+			} else {
+				fmt.Println("  👥 Owners: [@org/team] (from codeowners)")
+			}
+			mode.Done(name)
+		}
+		mode.Finish()
+		mode.Summary()
+		fmt.Println("📊 Statistics:")
+	}
+}
+
+var _ = Describe("Writer", func() {
+	It("should print every line by default", func() {
+		Expect(run(display.Lines, console.Unicode, analyze(display.Lines))).To(Equal(strings.Join([]string{
+			"→ Identifying new repositories to add...",
+			"📦 [1/3] api",
+			"  👥 Owners: [@org/team] (from codeowners)",
+			"📦 [2/3] cli",
+			"  ⚠️  Skipped: no Go test files",
+			"📦 [3/3] ui",
+			"  👥 Owners: [@org/team] (from codeowners)",
+			"📊 Statistics:",
+		}, "\n") + "\n"))
+	})
+
+	It("should draw a bar over the items, keeping warnings under the heading of their item", func() {
+		Expect(run(display.Progress, console.Plain, analyze(display.Progress))).To(Equal("→ Identifying new repositories to add...\n" +
+			"\r\x1b[K[------------------------------] 0/3" +
+			"\r\x1b[K[##########--------------------] 1/3 api" +
+			"\r\x1b[K[2/3] cli\n  Skipped: no Go test files\n" +
+			"\r\x1b[K[##########--------------------] 1/3 api" +
+			"\r\x1b[K[####################----------] 2/3 cli" +
+			"\r\x1b[K[##############################] 3/3 ui\n" +
+			"Statistics:\n"))
+	})
+
+	It("should keep the warnings above the bar in ASCII", func() {
+		Expect(run(display.Progress, console.ASCII, analyze(display.Progress))).To(ContainSubstring("\r\x1b[K[2/3] cli\n  [WARN] Skipped: no Go test files\n"))
+	})
+
+	It("should only print the summary when quiet", func() {
+		Expect(run(display.Quiet, console.Unicode, analyze(display.Quiet))).To(Equal("📊 Statistics:\n"))
+	})
+
+	It("should print no phases without a Writer", func() {
+		Expect(run(display.Quiet, console.Unicode, analyze(display.Lines))).To(BeEmpty())
+	})
+})
+
+var _ = Describe("ModeOf", func() {
+	It("should pick the mode of the flags", func() {
+		Expect(display.ModeOf(false, false)).To(Equal(display.Lines))
+		Expect(display.ModeOf(true, false)).To(Equal(display.Progress))
+		Expect(display.ModeOf(false, true)).To(Equal(display.Quiet))
+		_, err := display.ModeOf(true, true)
+		Expect(err).To(MatchError("--progress cannot be combined with --quiet"))
+	})
+})
+
+var _ = Describe("Mode.On", func() {
+	It("should fall back to lines when the output is not a terminal", func() {
+		file, err := os.Create(filepath.Join(GinkgoT().TempDir(), "out.log"))
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(file.Close)
+
+		Expect(display.Terminal(file)).To(BeFalse())
+		Expect(display.Progress.On(file)).To(Equal(display.Lines))
+		Expect(display.Quiet.On(file)).To(Equal(display.Quiet))
+	})
+})
@@ -0,0 +1,445 @@
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v66/github"
+	"github.com/konflux-ci/coverage-dashboard/internal/collect"
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+	"github.com/konflux-ci/coverage-dashboard/internal/ghauth"
+	"github.com/konflux-ci/coverage-dashboard/internal/policy"
+	"github.com/konflux-ci/coverage-dashboard/internal/pr"
+)
+
+// Check statuses
+const (
+	StatusPass = "pass"
+	StatusWarn = "warn"
+	StatusFail = "fail"
+	StatusSkip = "skip"
+)
+
+// Config holds the environment to check
+type Config struct {
+	Organization   string
+	DashboardRepo  string
+	ReposDir       string
+	ReposFile      string
+	CodeownersFile string
+	PublishDir     string
+	PolicyFile     string
+	GroupsFile     string
+	// GitHubBaseURL is the GitHub API the tokens are checked against; GITHUB_API_URL, or api.github.com, when empty
+	GitHubBaseURL string
+}
+
+// Result is the outcome of a single check
+type Result struct {
+	Name        string
+	Status      string
+	Detail      string
+	Remediation string
+}
+
+// Doctor checks that the environment can run discovery, collection and publishing
+type Doctor struct {
+	config      Config
+	tokens      ghauth.Tokens
+	readClient  *github.Client
+	writeClient *github.Client
+}
+
+// NewDoctor creates a new Doctor using the tokens from the environment
+func NewDoctor(cfg Config) (*Doctor, error) {
+	if _, _, ok := strings.Cut(cfg.DashboardRepo, "/"); !ok {
+		return nil, fmt.Errorf("dashboard repository must be in owner/name form, got %q", cfg.DashboardRepo)
+	}
+
+	tokens, err := ghauth.TokensFromEnv().WithBaseURL(cfg.GitHubBaseURL)
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	d := &Doctor{
+		config: cfg,
+		tokens: tokens,
+	}
+	d.readClient = d.tokens.ReadClient(ctx)
+	d.writeClient = d.tokens.WriteClient(ctx)
+	return d, nil
+}
+
+// Run executes every check in order
+func (d *Doctor) Run(ctx context.Context) []Result {
+	var results []Result
+	if d.tokens.App != nil {
+		results = append(results, d.checkApp())
+	}
+	results = append(results,
+		d.checkReadToken(ctx),
+		d.checkWriteToken(ctx),
+	)
+	if d.tokens.Shared() {
+		results = append(results, Result{
+			Name:        "token roles",
+			Status:      StatusWarn,
+			Detail:      "GITHUB_TOKEN is used as both read and write token",
+			Remediation: "set GITHUB_READ_TOKEN and GITHUB_WRITE_TOKEN to limit each token to its role",
+		})
+	}
+	return append(results,
+		checkTool(ctx, "git", "version"),
+		checkTool(ctx, "go", "version"),
+		d.checkReposDir(),
+		d.checkPolicy(),
+		d.checkRego(ctx),
+		d.checkGroups(),
+		d.checkCodeowners(),
+		d.checkPublishDir(ctx),
+	)
+}
+
+// Print writes the results and a summary to stdout
+func Print(results []Result) {
+	fmt.Println("🩺 Coverage Dashboard Doctor")
+	fmt.Println("============================")
+
+	icons := map[string]string{StatusPass: "✅", StatusWarn: "⚠️ ", StatusFail: "❌", StatusSkip: "⏭️ "}
+	counts := make(map[string]int)
+	for _, result := range results {
+		counts[result.Status]++
+		fmt.Printf("%s %s: %s\n", icons[result.Status], result.Name, result.Detail)
+		if result.Remediation != "" && (result.Status == StatusFail || result.Status == StatusWarn) {
+			fmt.Printf("   → %s\n", result.Remediation)
+		}
+	}
+
+	fmt.Println()
+	fmt.Printf("Summary: %d passed, %d warnings, %d failed, %d skipped\n",
+		counts[StatusPass], counts[StatusWarn], counts[StatusFail], counts[StatusSkip])
+}
+
+// Failed reports whether any check failed
+func Failed(results []Result) bool {
+	for _, result := range results {
+		if result.Status == StatusFail {
+			return true
+		}
+	}
+	return false
+}
+
+// checkApp verifies the GitHub App credentials are complete and the private key parses
+func (d *Doctor) checkApp() Result {
+	result := Result{Name: "GitHub App"}
+	if err := d.tokens.App.Validate(); err != nil {
+		return fail(result, err, fmt.Sprintf("set %s, %s and %s to the app's ID, its installation ID and a private key generated in the app's settings",
+			ghauth.AppIDEnv, ghauth.AppInstallationIDEnv, ghauth.AppPrivateKeyEnv))
+	}
+
+	result.Status = StatusPass
+	result.Detail = fmt.Sprintf("app %s, installation %s", d.tokens.App.ID, d.tokens.App.InstallationID)
+	return result
+}
+
+// renewToken is the remediation for a token that does not authenticate
+func renewToken(source string) string {
+	if source == ghauth.AppSource {
+		return "check the app is installed on the organization and its private key was not revoked"
+	}
+	return "create a new token and export it as " + source
+}
+
+// checkReadToken verifies the read token can list the organization's repositories and teams
+func (d *Doctor) checkReadToken(ctx context.Context) Result {
+	result := Result{Name: "read token"}
+	if d.tokens.ReadSource == "" {
+		result.Status = StatusWarn
+		result.Detail = "neither GITHUB_READ_TOKEN nor GITHUB_TOKEN set, discovery uses unauthenticated API calls and only reads CODEOWNERS files"
+		result.Remediation = "export GITHUB_READ_TOKEN with a token granting read:org on " + d.config.Organization
+		return result
+	}
+	result.Name += " (" + d.tokens.ReadSource + ")"
+
+	info, err := ghauth.Inspect(ctx, d.readClient)
+	if err != nil {
+		return fail(result, err, renewToken(d.tokens.ReadSource))
+	}
+	if missing := info.MissingScopes("read:org"); len(missing) > 0 {
+		return fail(result, fmt.Errorf("missing scopes: %s", strings.Join(missing, ", ")),
+			"add the read:org scope so teams can be used for ownership detection")
+	}
+	if err := ghauth.CheckOrgRead(ctx, d.readClient, d.config.Organization); err != nil {
+		return fail(result, err, "grant the token access to the "+d.config.Organization+" organization (approve it under the org's personal access token settings)")
+	}
+
+	result.Status = StatusPass
+	result.Detail = fmt.Sprintf("authenticated as %s, can read %s", info, d.config.Organization)
+	return result
+}
+
+// checkWriteToken verifies the write token can push branches and open PRs on the dashboard repository
+func (d *Doctor) checkWriteToken(ctx context.Context) Result {
+	result := Result{Name: "write token"}
+	if d.tokens.WriteSource == "" {
+		result.Status = StatusWarn
+		result.Detail = "neither GITHUB_WRITE_TOKEN nor GITHUB_TOKEN set, discover-repos can only run without --apply"
+		result.Remediation = "export GITHUB_WRITE_TOKEN with a token that can push to and open pull requests on " + d.config.DashboardRepo
+		return result
+	}
+	result.Name += " (" + d.tokens.WriteSource + ")"
+
+	info, err := ghauth.Inspect(ctx, d.writeClient)
+	if err != nil {
+		return fail(result, err, renewToken(d.tokens.WriteSource))
+	}
+	if missing := info.MissingScopes("repo"); len(missing) > 0 {
+		return fail(result, fmt.Errorf("missing scopes: %s", strings.Join(missing, ", ")),
+			"add the repo scope so discovery can push branches and open pull requests")
+	}
+	owner, repo, _ := strings.Cut(d.config.DashboardRepo, "/")
+	if err := ghauth.CheckRepoWrite(ctx, d.writeClient, owner, repo); err != nil {
+		return fail(result, err, "grant the token Contents and Pull requests write access on "+d.config.DashboardRepo)
+	}
+
+	result.Status = StatusPass
+	result.Detail = fmt.Sprintf("authenticated as %s, can push to %s", info, d.config.DashboardRepo)
+	return result
+}
+
+// checkTool verifies an executable is installed and runs
+func checkTool(ctx context.Context, name string, args ...string) Result {
+	result := Result{Name: name}
+	if _, err := exec.LookPath(name); err != nil {
+		return fail(result, fmt.Errorf("not found in PATH"), "install "+name+" and make sure it is on PATH")
+	}
+
+	output, err := exec.CommandContext(ctx, name, args...).CombinedOutput()
+	if err != nil {
+		return fail(result, fmt.Errorf("%s %s failed: %w", name, strings.Join(args, " "), err), "reinstall "+name)
+	}
+
+	result.Status = StatusPass
+	result.Detail = strings.TrimSpace(string(output))
+	return result
+}
+
+// checkReposDir verifies every repository configuration parses, names an org/repo and is configured once
+func (d *Doctor) checkReposDir() Result {
+	result := Result{Name: "repository configurations"}
+
+	repositories, err := config.LoadRepositories(d.config.ReposDir, d.config.ReposFile)
+	if err != nil {
+		return fail(result, err, "fix the repos file or the repos directory; each repository must be configured only once")
+	}
+
+	var problems []string
+	for _, file := range repositories.InvalidFiles() {
+		problems = append(problems, fmt.Sprintf("%s: %v", file, repositories.Invalid[file]))
+	}
+	for _, entry := range repositories.Entries {
+		if !config.ValidRepoName(entry.Config.Name) {
+			problems = append(problems, fmt.Sprintf("%s: name %q is not %s", entry.Source(), entry.Config.Name, config.RepoNameRule))
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fail(result, fmt.Errorf("%d invalid configurations: %s", len(problems), strings.Join(problems, "; ")),
+			"fix the listed files; each needs at least `name: org/repo`")
+	}
+
+	result.Status = StatusPass
+	result.Detail = fmt.Sprintf("%d configurations parse", len(repositories.Entries))
+	return result
+}
+
+// checkPolicy verifies the organization policy parses and every repository configuration complies with it
+func (d *Doctor) checkPolicy() Result {
+	result := Result{Name: "policy"}
+	if d.config.PolicyFile == "" {
+		result.Status = StatusSkip
+		result.Detail = "no policy file configured"
+		return result
+	}
+	if _, err := os.Stat(d.config.PolicyFile); os.IsNotExist(err) {
+		result.Status = StatusSkip
+		result.Detail = d.config.PolicyFile + " does not exist, repositories are not restricted"
+		return result
+	}
+
+	orgPolicy, err := policy.Load(d.config.PolicyFile)
+	if err != nil {
+		return fail(result, err, "fix "+d.config.PolicyFile)
+	}
+	repositories, err := config.LoadRepositories(d.config.ReposDir, d.config.ReposFile)
+	if err != nil {
+		return fail(result, err, "fix the repository configurations first")
+	}
+
+	var problems []string
+	for _, entry := range repositories.Entries {
+		_, broken := orgPolicy.Apply(entry.Config)
+		for _, problem := range broken {
+			problems = append(problems, fmt.Sprintf("%s: %s", entry.Source(), problem))
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fail(result, fmt.Errorf("%d policy violations: %s", len(problems), strings.Join(problems, "; ")),
+			"remove the overrides the policy does not allow, or ask for the policy to be changed")
+	}
+
+	result.Status = StatusPass
+	result.Detail = fmt.Sprintf("%d configurations comply with %s", len(repositories.Entries), d.config.PolicyFile)
+	return result
+}
+
+// checkRego verifies the Rego gates of the policy compile
+func (d *Doctor) checkRego(ctx context.Context) Result {
+	result := Result{Name: "rego"}
+	orgPolicy, err := policy.Load(d.config.PolicyFile)
+	if d.config.PolicyFile == "" || err != nil || orgPolicy.Rego == nil || len(orgPolicy.Rego.Files) == 0 {
+		result.Status = StatusSkip
+		result.Detail = "the policy has no Rego gates"
+		return result
+	}
+
+	if err := orgPolicy.CheckRego(ctx); err != nil {
+		return fail(result, err, "fix the Rego gates listed under rego.files in "+d.config.PolicyFile)
+	}
+	result.Status = StatusPass
+	result.Detail = fmt.Sprintf("the Rego gates of %s compile", strings.Join(orgPolicy.Rego.Files, ", "))
+	return result
+}
+
+// checkGroups verifies the repository groups parse and every group matches a configured repository
+func (d *Doctor) checkGroups() Result {
+	result := Result{Name: "repository groups"}
+	if d.config.GroupsFile == "" {
+		result.Status = StatusSkip
+		result.Detail = "no groups file configured"
+		return result
+	}
+	if _, err := os.Stat(d.config.GroupsFile); os.IsNotExist(err) {
+		result.Status = StatusSkip
+		result.Detail = d.config.GroupsFile + " does not exist, repositories are not grouped"
+		return result
+	}
+
+	groups, err := config.LoadGroups(d.config.GroupsFile)
+	if err != nil {
+		return fail(result, err, "fix "+d.config.GroupsFile+"; each group needs a unique name and repos patterns")
+	}
+	repositories, err := config.LoadRepositories(d.config.ReposDir, d.config.ReposFile)
+	if err != nil {
+		return fail(result, err, "fix the repository configurations first")
+	}
+
+	var names []string
+	for _, entry := range repositories.Entries {
+		names = append(names, entry.Config.Name)
+	}
+	if unmatched := groups.Unmatched(names); len(unmatched) > 0 {
+		result.Status = StatusWarn
+		result.Detail = fmt.Sprintf("groups matching no configured repository: %s", strings.Join(unmatched, ", "))
+		result.Remediation = "fix the repos patterns of the listed groups or remove them from " + d.config.GroupsFile
+		return result
+	}
+
+	result.Status = StatusPass
+	result.Detail = fmt.Sprintf("%d groups in %s", len(groups), d.config.GroupsFile)
+	return result
+}
+
+// checkCodeowners verifies CODEOWNERS assigns owners to every configuration and lists no stale entries
+func (d *Doctor) checkCodeowners() Result {
+	result := Result{Name: "CODEOWNERS"}
+
+	entries, err := config.LoadCodeowners(d.config.CodeownersFile)
+	if err != nil {
+		return fail(result, err, "pass the dashboard's CODEOWNERS file with --codeowners")
+	}
+	repositories, err := config.LoadRepositories(d.config.ReposDir, d.config.ReposFile)
+	if err != nil {
+		return fail(result, err, "fix the repository configurations first")
+	}
+
+	configured := make(map[string]bool)
+	var unowned, stale []string
+	for _, entry := range repositories.Entries {
+		pattern := entry.CodeownersPattern()
+		if configured[pattern] {
+			continue
+		}
+		configured[pattern] = true
+		if len(entries[pattern]) == 0 {
+			unowned = append(unowned, entry.Source())
+		}
+	}
+	for pattern := range entries {
+		if strings.HasPrefix(pattern, "/repos/") && !configured[pattern] {
+			stale = append(stale, pattern)
+		}
+	}
+	sort.Strings(stale)
+
+	if len(unowned) > 0 {
+		return fail(result, fmt.Errorf("no owners for %s", strings.Join(unowned, ", ")),
+			"add a `/repos/<file> @org/team` (or `/repos.yaml @org/team`) line for each listed configuration")
+	}
+	if len(stale) > 0 {
+		result.Status = StatusWarn
+		result.Detail = fmt.Sprintf("entries for missing configurations: %s", strings.Join(stale, ", "))
+		result.Remediation = "remove the listed lines from " + d.config.CodeownersFile
+		return result
+	}
+
+	result.Status = StatusPass
+	result.Detail = fmt.Sprintf("all %d configurations have owners", len(repositories.Entries))
+	return result
+}
+
+// checkPublishDir verifies the published data is readable and the checkout can be pushed
+func (d *Doctor) checkPublishDir(ctx context.Context) Result {
+	result := Result{Name: "publish checkout"}
+	if d.config.PublishDir == "" {
+		result.Status = StatusSkip
+		result.Detail = "--publish-dir not given"
+		return result
+	}
+
+	if _, err := pr.RunGitCommand(ctx, d.config.PublishDir, "rev-parse", "--is-inside-work-tree"); err != nil {
+		return fail(result, fmt.Errorf("%s is not a git checkout", d.config.PublishDir),
+			"clone the gh-pages branch into "+d.config.PublishDir)
+	}
+
+	manifestPath := filepath.Join(d.config.PublishDir, "run-manifest.json")
+	if _, err := os.Stat(manifestPath); err == nil {
+		if _, err := collect.LoadManifest(manifestPath); err != nil {
+			return fail(result, err, "restore run-manifest.json from an earlier gh-pages commit")
+		}
+	}
+
+	if _, err := pr.RunGitCommand(ctx, d.config.PublishDir, "push", "--dry-run", "origin", "HEAD"); err != nil {
+		return fail(result, fmt.Errorf("cannot push to origin: %w", err),
+			"configure git credentials allowed to push to the published branch")
+	}
+
+	result.Status = StatusPass
+	result.Detail = fmt.Sprintf("%s can be published", d.config.PublishDir)
+	return result
+}
+
+// fail marks a result as failed with the error as detail
+func fail(result Result, err error, remediation string) Result {
+	result.Status = StatusFail
+	result.Detail = err.Error()
+	result.Remediation = remediation
+	return result
+}
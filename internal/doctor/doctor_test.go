@@ -0,0 +1,226 @@
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/google/go-github/v66/github"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/ghauth"
+)
+
+var _ = Describe("Doctor", func() {
+	var (
+		tempDir  string
+		reposDir string
+		d        *Doctor
+	)
+
+	writeFile := func(path, content string) {
+		Expect(os.WriteFile(path, []byte(content), 0644)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		tempDir = GinkgoT().TempDir()
+		reposDir = filepath.Join(tempDir, "repos")
+		Expect(os.MkdirAll(reposDir, 0755)).To(Succeed())
+		writeFile(filepath.Join(reposDir, "alpha.yaml"), "name: konflux-ci/alpha\n")
+		writeFile(filepath.Join(reposDir, "beta.yaml"), "name: konflux-ci/beta\n")
+		writeFile(filepath.Join(tempDir, "CODEOWNERS"), "/repos/alpha.yaml @konflux-ci/alpha\n/repos/beta.yaml @konflux-ci/beta\n")
+
+		var err error
+		d, err = NewDoctor(Config{
+			Organization:   "konflux-ci",
+			DashboardRepo:  "konflux-ci/coverage-dashboard",
+			ReposDir:       reposDir,
+			CodeownersFile: filepath.Join(tempDir, "CODEOWNERS"),
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should reject a dashboard repository without owner", func() {
+		_, err := NewDoctor(Config{DashboardRepo: "coverage-dashboard"})
+		Expect(err).To(MatchError(ContainSubstring("owner/name")))
+	})
+
+	Describe("checkReposDir", func() {
+		It("should pass when every configuration parses", func() {
+			result := d.checkReposDir()
+			Expect(result.Status).To(Equal(StatusPass))
+			Expect(result.Detail).To(Equal("2 configurations parse"))
+		})
+
+		It("should list invalid configurations", func() {
+			writeFile(filepath.Join(reposDir, "broken.yaml"), "name: [\n")
+			writeFile(filepath.Join(reposDir, "bare.yaml"), "name: bare\n")
+			writeFile(filepath.Join(reposDir, "host.yaml"), "name: evil.example.com/org\n")
+			result := d.checkReposDir()
+			Expect(result.Status).To(Equal(StatusFail))
+			Expect(result.Detail).To(ContainSubstring("3 invalid configurations"))
+			Expect(result.Detail).To(ContainSubstring(`host.yaml: name "evil.example.com/org" is not in org/repo form`))
+			Expect(result.Detail).To(ContainSubstring(`bare.yaml: name "bare" is not in org/repo form`))
+			Expect(result.Remediation).NotTo(BeEmpty())
+		})
+	})
+
+	Describe("checkPolicy", func() {
+		It("should skip when the policy file does not exist", func() {
+			d.config.PolicyFile = filepath.Join(tempDir, "policy.yaml")
+			Expect(d.checkPolicy().Status).To(Equal(StatusSkip))
+		})
+
+		It("should list overrides the policy does not allow", func() {
+			d.config.PolicyFile = filepath.Join(tempDir, "policy.yaml")
+			writeFile(d.config.PolicyFile, "overridable: [exclude_dirs]\n")
+			writeFile(filepath.Join(reposDir, "beta.yaml"), "name: konflux-ci/beta\nmin_coverage: 10\n")
+
+			result := d.checkPolicy()
+			Expect(result.Status).To(Equal(StatusFail))
+			Expect(result.Detail).To(ContainSubstring("beta.yaml: min_coverage may not be overridden by repositories"))
+		})
+
+		It("should pass when every configuration complies", func() {
+			d.config.PolicyFile = filepath.Join(tempDir, "policy.yaml")
+			writeFile(d.config.PolicyFile, "exclusions:\n  max_exclude_dirs: 2\n")
+			result := d.checkPolicy()
+			Expect(result.Status).To(Equal(StatusPass))
+			Expect(result.Detail).To(HavePrefix("2 configurations comply"))
+		})
+	})
+
+	Describe("checkRego", func() {
+		BeforeEach(func() {
+			d.config.PolicyFile = filepath.Join(tempDir, "policy.yaml")
+		})
+
+		It("should skip when the policy has no Rego gates", func() {
+			writeFile(d.config.PolicyFile, "overridable: [exclude_dirs]\n")
+			Expect(d.checkRego(context.Background()).Status).To(Equal(StatusSkip))
+		})
+
+		It("should pass when the gates compile", func() {
+			writeFile(filepath.Join(tempDir, "gates.rego"), "package coverage\n\ndeny contains \"low\" if input.data == []\n")
+			writeFile(d.config.PolicyFile, "rego:\n  files: ["+filepath.Join(tempDir, "gates.rego")+"]\n")
+			Expect(d.checkRego(context.Background()).Status).To(Equal(StatusPass))
+		})
+
+		It("should fail when the gates do not compile", func() {
+			writeFile(filepath.Join(tempDir, "gates.rego"), "package coverage\n\ndeny contains\n")
+			writeFile(d.config.PolicyFile, "rego:\n  files: ["+filepath.Join(tempDir, "gates.rego")+"]\n")
+			result := d.checkRego(context.Background())
+			Expect(result.Status).To(Equal(StatusFail))
+			Expect(result.Name).To(Equal("rego"))
+			Expect(result.Remediation).To(ContainSubstring("rego.files"))
+		})
+	})
+
+	Describe("checkGroups", func() {
+		BeforeEach(func() {
+			d.config.GroupsFile = filepath.Join(tempDir, "groups.yaml")
+		})
+
+		It("should skip when the groups file does not exist", func() {
+			Expect(d.checkGroups().Status).To(Equal(StatusSkip))
+		})
+
+		It("should warn about groups matching no repository", func() {
+			writeFile(d.config.GroupsFile, "- name: stack\n  repos: [konflux-ci/alpha]\n- name: gone\n  repos: [konflux-ci/gone-*]\n")
+			result := d.checkGroups()
+			Expect(result.Status).To(Equal(StatusWarn))
+			Expect(result.Detail).To(ContainSubstring("gone"))
+		})
+
+		It("should fail on an invalid groups file", func() {
+			writeFile(d.config.GroupsFile, "- repos: [konflux-ci/alpha]\n")
+			Expect(d.checkGroups().Status).To(Equal(StatusFail))
+		})
+
+		It("should pass when every group matches a repository", func() {
+			writeFile(d.config.GroupsFile, "- name: stack\n  repos: [konflux-ci/alpha, konflux-ci/beta]\n")
+			result := d.checkGroups()
+			Expect(result.Status).To(Equal(StatusPass))
+			Expect(result.Detail).To(HavePrefix("1 groups"))
+		})
+	})
+
+	Describe("checkCodeowners", func() {
+		It("should pass when every configuration has owners", func() {
+			Expect(d.checkCodeowners().Status).To(Equal(StatusPass))
+		})
+
+		It("should fail for configurations without owners", func() {
+			writeFile(filepath.Join(reposDir, "gamma.yaml"), "name: konflux-ci/gamma\n")
+			result := d.checkCodeowners()
+			Expect(result.Status).To(Equal(StatusFail))
+			Expect(result.Detail).To(Equal("no owners for gamma.yaml"))
+		})
+
+		It("should warn about entries for removed configurations", func() {
+			Expect(os.Remove(filepath.Join(reposDir, "beta.yaml"))).To(Succeed())
+			result := d.checkCodeowners()
+			Expect(result.Status).To(Equal(StatusWarn))
+			Expect(result.Detail).To(ContainSubstring("/repos/beta.yaml"))
+		})
+	})
+
+	Describe("token checks", func() {
+		var server *httptest.Server
+
+		BeforeEach(func() {
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/user":
+					w.Header().Set("X-OAuth-Scopes", "public_repo")
+					fmt.Fprint(w, `{"login": "dashboard-bot"}`)
+				case "/orgs/konflux-ci/repos":
+					fmt.Fprint(w, `[]`)
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			client := github.NewClient(nil)
+			client.BaseURL, _ = url.Parse(server.URL + "/")
+			d.readClient, d.writeClient = client, client
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("should warn when tokens are not set", func() {
+			d.tokens = ghauth.Tokens{}
+			Expect(d.checkReadToken(context.Background()).Status).To(Equal(StatusWarn))
+			Expect(d.checkWriteToken(context.Background()).Status).To(Equal(StatusWarn))
+		})
+
+		It("should report missing scopes with a remediation", func() {
+			d.tokens = ghauth.ResolveTokens(func(name string) string {
+				if name == ghauth.SharedTokenEnv {
+					return "shared"
+				}
+				return ""
+			})
+
+			result := d.checkReadToken(context.Background())
+			Expect(result.Status).To(Equal(StatusFail))
+			Expect(result.Name).To(Equal("read token (GITHUB_TOKEN)"))
+			Expect(result.Detail).To(Equal("missing scopes: read:org"))
+
+			result = d.checkWriteToken(context.Background())
+			Expect(result.Status).To(Equal(StatusFail))
+			Expect(result.Detail).To(Equal("missing scopes: repo"))
+			Expect(result.Remediation).To(ContainSubstring("repo scope"))
+		})
+	})
+
+	It("should skip the publish checkout when not configured", func() {
+		Expect(d.checkPublishDir(context.Background()).Status).To(Equal(StatusSkip))
+	})
+})
@@ -0,0 +1,188 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Types of events
+const (
+	// SnapshotAdded is a new dashboard.json committed to the data branch
+	SnapshotAdded = "snapshot.added"
+	// ThresholdViolated is a repository falling below its min_coverage or ratchet in a run
+	ThresholdViolated = "threshold.violated"
+	// RepoAdded and RepoRemoved are repository configurations appearing in or disappearing from the checkout
+	RepoAdded   = "repo.added"
+	RepoRemoved = "repo.removed"
+	// RunStarted and RunFinished are collection runs starting and finishing, from their manifest
+	RunStarted  = "run.started"
+	RunFinished = "run.finished"
+)
+
+const (
+	// historySize is the number of past events replayed to clients reconnecting with Last-Event-ID
+	historySize = 256
+	// subscriberBuffer is the number of events a slow client may lag behind before it is disconnected
+	subscriberBuffer = 64
+	// keepAlive is the interval of the comments keeping idle connections open through proxies
+	keepAlive = 30 * time.Second
+)
+
+// Event is a change of the dashboard, sent to clients as the data of a server-sent event of its type
+type Event struct {
+	ID   uint64    `json:"id"`
+	Type string    `json:"type"`
+	Time time.Time `json:"time"`
+	// Repo is the repository the event is about, in org/name form, if any
+	Repo   string `json:"repo,omitempty"`
+	RunURL string `json:"run_url,omitempty"`
+	// Data holds the details of the event, by type
+	Data any `json:"data,omitempty"`
+}
+
+// Broker fans events out to the clients of its event stream, keeping the recent ones for clients reconnecting
+type Broker struct {
+	mu          sync.Mutex
+	lastID      uint64
+	history     []Event
+	subscribers map[chan Event]bool
+	now         func() time.Time
+}
+
+// NewBroker creates a Broker without events
+func NewBroker() *Broker {
+	return &Broker{subscribers: make(map[chan Event]bool), now: time.Now}
+}
+
+// Publish numbers events, stamps those without a time and sends them to every client
+// Clients too slow to keep up are disconnected, and catch up with Last-Event-ID when they reconnect
+func (b *Broker) Publish(events ...Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, event := range events {
+		b.lastID++
+		event.ID = b.lastID
+		if event.Time.IsZero() {
+			event.Time = b.now().UTC()
+		}
+		b.history = append(b.history, event)
+		if len(b.history) > historySize {
+			b.history = b.history[len(b.history)-historySize:]
+		}
+		for subscriber := range b.subscribers {
+			select {
+			case subscriber <- event:
+			default:
+				delete(b.subscribers, subscriber)
+				close(subscriber)
+			}
+		}
+	}
+}
+
+// subscribe registers a client, returning its channel and the events after lastID it missed
+func (b *Broker) subscribe(lastID uint64) (chan Event, []Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var missed []Event
+	for _, event := range b.history {
+		if event.ID > lastID {
+			missed = append(missed, event)
+		}
+	}
+	subscriber := make(chan Event, subscriberBuffer)
+	b.subscribers[subscriber] = true
+	return subscriber, missed
+}
+
+// unsubscribe removes a client, unless Publish already disconnected it
+func (b *Broker) unsubscribe(subscriber chan Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.subscribers[subscriber] {
+		delete(b.subscribers, subscriber)
+		close(subscriber)
+	}
+}
+
+// ServeHTTP streams events as server-sent events, starting with those after the Last-Event-ID header, if any
+// The types query parameter, comma-separated, limits the stream to those types
+func (b *Broker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	var lastID uint64
+	if header := r.Header.Get("Last-Event-ID"); header != "" {
+		id, err := strconv.ParseUint(header, 10, 64)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid Last-Event-ID %q", header), http.StatusBadRequest)
+			return
+		}
+		lastID = id
+	}
+	var types []string
+	if query := r.URL.Query().Get("types"); query != "" {
+		types = strings.Split(query, ",")
+	}
+
+	subscriber, missed := b.subscribe(lastID)
+	defer b.unsubscribe(subscriber)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	send := func(event Event) error {
+		if len(types) > 0 && !slices.Contains(types, event.Type) {
+			return nil
+		}
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	}
+	for _, event := range missed {
+		if err := send(event); err != nil {
+			return
+		}
+	}
+
+	ticker := time.NewTicker(keepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-subscriber:
+			if !ok {
+				return
+			}
+			if err := send(event); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package events_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/collect"
+	"github.com/konflux-ci/coverage-dashboard/internal/events"
+)
+
+var _ = Describe("Broker", func() {
+	var (
+		broker *events.Broker
+		server *httptest.Server
+	)
+
+	BeforeEach(func() {
+		broker = events.NewBroker()
+		server = httptest.NewServer(broker)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	// stream connects to the event stream and returns its lines
+	stream := func(ctx context.Context, query, lastID string) <-chan string {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+query, nil)
+		Expect(err).NotTo(HaveOccurred())
+		if lastID != "" {
+			req.Header.Set("Last-Event-ID", lastID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(resp.Header.Get("Content-Type")).To(Equal("text/event-stream"))
+
+		lines := make(chan string, 100)
+		go func() {
+			defer GinkgoRecover()
+			defer resp.Body.Close()
+			scanner := bufio.NewScanner(resp.Body)
+			for scanner.Scan() {
+				if line := scanner.Text(); line != "" {
+					lines <- line
+				}
+			}
+		}()
+		return lines
+	}
+
+	It("should stream published events to connected clients", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		lines := stream(ctx, "", "")
+
+		broker.Publish(events.Event{Type: events.RepoAdded, Repo: "konflux-ci/api"})
+
+		Eventually(lines).Should(Receive(Equal("id: 1")))
+		Eventually(lines).Should(Receive(Equal("event: repo.added")))
+		var data string
+		Eventually(lines).Should(Receive(&data))
+		var event events.Event
+		Expect(json.Unmarshal([]byte(strings.TrimPrefix(data, "data: ")), &event)).To(Succeed())
+		Expect(event.ID).To(BeEquivalentTo(1))
+		Expect(event.Type).To(Equal(events.RepoAdded))
+		Expect(event.Repo).To(Equal("konflux-ci/api"))
+		Expect(event.Time).NotTo(BeZero())
+	})
+
+	It("should replay the events after Last-Event-ID and filter by type", func() {
+		broker.Publish(
+			events.Event{Type: events.RunStarted},
+			events.Event{Type: events.RepoAdded, Repo: "konflux-ci/api"},
+			events.Event{Type: events.RunFinished},
+		)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		lines := stream(ctx, "?types=run.started,run.finished", "1")
+
+		Eventually(lines).Should(Receive(Equal("id: 3")))
+		Eventually(lines).Should(Receive(Equal("event: run.finished")))
+	})
+
+	It("should reject invalid Last-Event-ID headers", func() {
+		req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+		Expect(err).NotTo(HaveOccurred())
+		req.Header.Set("Last-Event-ID", "latest")
+		resp, err := http.DefaultClient.Do(req)
+		Expect(err).NotTo(HaveOccurred())
+		resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+	})
+})
+
+var _ = Describe("Watcher", func() {
+	var (
+		dir      string
+		sources  events.Sources
+		watcher  *events.Watcher
+		started  time.Time
+		finished time.Time
+	)
+
+	writeJSON := func(path string, v any) {
+		data, err := json.Marshal(v)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+		Expect(os.WriteFile(path, data, 0644)).To(Succeed())
+	}
+	types := func(evts []events.Event) []string {
+		var names []string
+		for _, event := range evts {
+			names = append(names, event.Type+" "+event.Repo)
+		}
+		return names
+	}
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		sources = events.Sources{
+			ManifestFile: filepath.Join(dir, "run-manifest.json"),
+			SnapshotDir:  filepath.Join(dir, "data"),
+			ReposDir:     filepath.Join(dir, "repos"),
+			ReposFile:    filepath.Join(dir, "repos.yaml"),
+		}
+		Expect(os.MkdirAll(sources.ReposDir, 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(sources.ReposDir, "api.yaml"), []byte("name: konflux-ci/api\n"), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(sources.ReposDir, "cli.yaml"), []byte("name: konflux-ci/cli\n"), 0644)).To(Succeed())
+		started = time.Date(2024, 3, 4, 3, 0, 0, 0, time.UTC)
+		finished = started.Add(2 * time.Hour)
+		writeJSON(sources.ManifestFile, collect.Manifest{StartedAt: started.Add(-24 * time.Hour), FinishedAt: finished.Add(-24 * time.Hour)})
+
+		watcher = events.NewWatcher(sources)
+		evts, err := watcher.Check()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(evts).To(BeEmpty())
+	})
+
+	It("should tell nothing while nothing changes", func() {
+		evts, err := watcher.Check()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(evts).To(BeEmpty())
+	})
+
+	It("should tell added and removed repositories", func() {
+		Expect(os.Remove(filepath.Join(sources.ReposDir, "cli.yaml"))).To(Succeed())
+		Expect(os.WriteFile(sources.ReposFile, []byte("- name: konflux-ci/docs\n"), 0644)).To(Succeed())
+
+		evts, err := watcher.Check()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(types(evts)).To(ConsistOf("repo.added konflux-ci/docs", "repo.removed konflux-ci/cli"))
+	})
+
+	It("should tell runs starting and finishing, their threshold violations and the snapshot they commit", func() {
+		writeJSON(sources.ManifestFile, collect.Manifest{RunURL: "https://ci/run/2", StartedAt: started, Plan: make([]collect.PlannedRepo, 2)})
+		evts, err := watcher.Check()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(types(evts)).To(Equal([]string{"run.started "}))
+		Expect(evts[0].RunURL).To(Equal("https://ci/run/2"))
+		Expect(evts[0].Time).To(Equal(started))
+
+		writeJSON(sources.ManifestFile, collect.Manifest{
+			RunURL:     "https://ci/run/2",
+			StartedAt:  started,
+			FinishedAt: finished,
+			Repos:      []collect.RepoRun{{Result: collect.Result{Status: collect.StatusOK}}},
+			Regressions: []collect.Regression{
+				{Repo: "konflux-ci/api", ConfigFile: "api.yaml", Kind: collect.KindBelowThreshold, DetectedAt: finished},
+				{Repo: "konflux-ci/cli", ConfigFile: "cli.yaml", Kind: collect.KindDrop, DetectedAt: finished},
+			},
+		})
+		writeJSON(filepath.Join(sources.SnapshotDir, "dashboard.json"), collect.Snapshot{SchemaVersion: 1, GeneratedAt: finished, Data: make([]collect.Result, 2)})
+		evts, err = watcher.Check()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(types(evts)).To(Equal([]string{"threshold.violated konflux-ci/api", "run.finished ", "snapshot.added "}))
+		Expect(evts[1].Data).To(HaveKeyWithValue("duration_seconds", 7200.0))
+		Expect(evts[2].Data).To(HaveKeyWithValue("repos", 2))
+
+		evts, err = watcher.Check()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(evts).To(BeEmpty())
+	})
+
+	It("should return the events of the other sources when one cannot be read", func() {
+		Expect(os.WriteFile(sources.ReposFile, []byte("- name: konflux-ci/docs\n"), 0644)).To(Succeed())
+		Expect(os.WriteFile(sources.ManifestFile, []byte("{"), 0644)).To(Succeed())
+
+		evts, err := watcher.Check()
+		Expect(err).To(HaveOccurred())
+		Expect(types(evts)).To(Equal([]string{"repo.added konflux-ci/docs"}))
+
+		writeJSON(sources.ManifestFile, collect.Manifest{StartedAt: started})
+		evts, err = watcher.Check()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(types(evts)).To(Equal([]string{"run.started "}))
+	})
+})
@@ -0,0 +1,198 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/collect"
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+)
+
+// Sources are the files the dashboard's changes are read from; empty sources are not watched
+type Sources struct {
+	// ManifestFile is the run manifest collect-coverage writes as it goes, telling runs and their violations
+	ManifestFile string
+	// SnapshotDir is a checkout of the data branch, telling new snapshots
+	SnapshotDir string
+	// ReposDir and ReposFile are the repository configurations, telling added and removed repositories
+	ReposDir  string
+	ReposFile string
+}
+
+// Watcher turns changes of the sources between checks into events
+// The first check only records the current state, so restarting the watcher does not repeat past events
+type Watcher struct {
+	sources Sources
+	checked bool
+
+	startedAt  time.Time
+	finishedAt time.Time
+	snapshotAt time.Time
+	violations map[string]bool
+	repos      map[string]bool
+}
+
+// NewWatcher creates a Watcher of sources
+func NewWatcher(sources Sources) *Watcher {
+	return &Watcher{sources: sources}
+}
+
+// Check reads the sources and returns the events of their changes since the last check, in the order they happened
+// Sources that do not exist yet are skipped. A source that cannot be read fails the check, but the events of the
+// other sources, whose state the check advanced, are still returned with the error
+func (w *Watcher) Check() ([]Event, error) {
+	var (
+		events []Event
+		errs   []error
+	)
+	check := func(source func() ([]Event, error)) {
+		sourceEvents, err := source()
+		if err != nil {
+			errs = append(errs, err)
+			return
+		}
+		events = append(events, sourceEvents...)
+	}
+	if w.sources.ReposDir != "" || w.sources.ReposFile != "" {
+		check(w.checkRepos)
+	}
+	if w.sources.ManifestFile != "" {
+		check(w.checkManifest)
+	}
+	if w.sources.SnapshotDir != "" {
+		check(w.checkSnapshot)
+	}
+	err := errors.Join(errs...)
+
+	// The first check only records the state, and is checked again until every source could be read
+	if !w.checked {
+		w.checked = err == nil
+		return nil, err
+	}
+	return events, err
+}
+
+// Run checks the sources every interval until ctx is done, publishing their events to broker
+// Failed checks only produce warnings
+func (w *Watcher) Run(ctx context.Context, broker *Broker, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		events, err := w.Check()
+		if err != nil {
+			fmt.Printf("⚠️  Warning: failed to check for dashboard changes: %v\n", err)
+		}
+		broker.Publish(events...)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkRepos compares the configured repositories with those of the last check
+func (w *Watcher) checkRepos() ([]Event, error) {
+	set, err := config.LoadRepositories(w.sources.ReposDir, w.sources.ReposFile)
+	if err != nil {
+		return nil, err
+	}
+	repos := make(map[string]bool, len(set.Entries))
+	var events []Event
+	for _, entry := range set.Entries {
+		name := entry.Config.Name
+		repos[name] = true
+		if !w.repos[name] {
+			events = append(events, Event{Type: RepoAdded, Repo: name, Data: map[string]string{"config_file": entry.Key()}})
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(w.repos)) {
+		if !repos[name] {
+			events = append(events, Event{Type: RepoRemoved, Repo: name})
+		}
+	}
+	w.repos = repos
+	return events, nil
+}
+
+// checkManifest tells runs that started or finished since the last check, and the threshold violations they found
+func (w *Watcher) checkManifest() ([]Event, error) {
+	manifest, err := collect.LoadManifest(w.sources.ManifestFile)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var events []Event
+	if !manifest.StartedAt.IsZero() && !manifest.StartedAt.Equal(w.startedAt) {
+		w.startedAt = manifest.StartedAt
+		events = append(events, Event{
+			Type:   RunStarted,
+			Time:   manifest.StartedAt,
+			RunURL: manifest.RunURL,
+			Data:   map[string]int{"planned": len(manifest.Plan)},
+		})
+	}
+	if manifest.FinishedAt.IsZero() || manifest.FinishedAt.Equal(w.finishedAt) {
+		return events, nil
+	}
+	w.finishedAt = manifest.FinishedAt
+
+	violations := make(map[string]bool)
+	for _, regression := range manifest.Regressions {
+		if regression.Kind != collect.KindBelowThreshold {
+			continue
+		}
+		key := regression.ConfigFile + "@" + regression.DetectedAt.String()
+		violations[key] = true
+		if !w.violations[key] {
+			events = append(events, Event{Type: ThresholdViolated, Time: regression.DetectedAt, Repo: regression.Repo, RunURL: regression.RunURL, Data: regression})
+		}
+	}
+	w.violations = violations
+
+	statuses := make(map[string]int)
+	for _, run := range manifest.Repos {
+		statuses[run.Result.Status]++
+	}
+	events = append(events, Event{
+		Type:   RunFinished,
+		Time:   manifest.FinishedAt,
+		RunURL: manifest.RunURL,
+		Data: map[string]any{
+			"duration_seconds": manifest.FinishedAt.Sub(manifest.StartedAt).Seconds(),
+			"statuses":         statuses,
+			"regressions":      len(manifest.Regressions),
+		},
+	})
+	return events, nil
+}
+
+// checkSnapshot tells a dashboard.json generated since the last check
+func (w *Watcher) checkSnapshot() ([]Event, error) {
+	snapshot, err := collect.LoadSnapshot(w.sources.SnapshotDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if snapshot.GeneratedAt.Equal(w.snapshotAt) {
+		return nil, nil
+	}
+	w.snapshotAt = snapshot.GeneratedAt
+	return []Event{{
+		Type:   SnapshotAdded,
+		Time:   snapshot.GeneratedAt,
+		RunURL: snapshot.RunURL,
+		Data:   map[string]int{"schema_version": snapshot.SchemaVersion, "repos": len(snapshot.Data)},
+	}}, nil
+}
@@ -0,0 +1,119 @@
+// Package features decides which risky behaviors of the dashboard are enabled in a run. Each flag has a default,
+// which the features of the policy and then the Env variable override, so operators can switch a behavior
+// on or off per environment without a new build
+package features
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Env overrides flags as a comma-separated list of names, enabling them, or names prefixed with -,
+// disabling them, e.g. "-issue-creation,status-publishing"
+const Env = "COVERAGE_FEATURES"
+
+// Flags of the behaviors that can be switched
+const (
+	// IssueCreation opens and updates issues in repositories that regressed or fail collection, with
+	// --regression-issues
+	IssueCreation = "issue-creation"
+	// StatusPublishing pushes the results of a run in progress to the published site as each repository finishes,
+	// with --publish-dir
+	StatusPublishing = "status-publishing"
+)
+
+// defaults are the flags known, with whether they are enabled unless overridden
+var defaults = map[string]bool{
+	IssueCreation:    true,
+	StatusPublishing: true,
+}
+
+// Where the value of a flag comes from
+const (
+	SourceDefault = "default"
+	SourcePolicy  = "policy"
+	SourceEnv     = "env"
+)
+
+// Flag is the value of a flag in a run, recorded in its manifest
+type Flag struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+	// Source is where the value comes from, one of the Source values
+	Source string `json:"source"`
+}
+
+// Flags are the values of the flags of a run, by name
+type Flags map[string]Flag
+
+// Names returns the names of the known flags, sorted
+func Names() []string {
+	names := make([]string, 0, len(defaults))
+	for name := range defaults {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Resolve returns the flags of a run from their defaults, the features of the policy and the value of Env,
+// in increasing precedence. Unknown names are errors, so a misspelled flag does not silently keep its default
+func Resolve(policy map[string]bool, env string) (Flags, error) {
+	flags := make(Flags, len(defaults))
+	for name, enabled := range defaults {
+		flags[name] = Flag{Name: name, Enabled: enabled, Source: SourceDefault}
+	}
+	for name, enabled := range policy {
+		if _, ok := defaults[name]; !ok {
+			return nil, fmt.Errorf("unknown feature %q in the policy, expected one of %s", name, strings.Join(Names(), ", "))
+		}
+		flags[name] = Flag{Name: name, Enabled: enabled, Source: SourcePolicy}
+	}
+	for _, entry := range strings.Split(env, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, disabled := strings.CutPrefix(entry, "-")
+		if _, ok := defaults[name]; !ok {
+			return nil, fmt.Errorf("unknown feature %q in %s, expected one of %s", name, Env, strings.Join(Names(), ", "))
+		}
+		flags[name] = Flag{Name: name, Enabled: !disabled, Source: SourceEnv}
+	}
+	return flags, nil
+}
+
+// Enabled reports whether a flag is enabled; flags of an unresolved set have their defaults
+func (f Flags) Enabled(name string) bool {
+	if flag, ok := f[name]; ok {
+		return flag.Enabled
+	}
+	return defaults[name]
+}
+
+// List returns the flags sorted by name
+func (f Flags) List() []Flag {
+	list := make([]Flag, 0, len(f))
+	for _, flag := range f {
+		list = append(list, flag)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// String describes the flags in one line, e.g. "issue-creation off (env), status-publishing on"
+func (f Flags) String() string {
+	parts := make([]string, 0, len(f))
+	for _, flag := range f.List() {
+		state := "off"
+		if flag.Enabled {
+			state = "on"
+		}
+		if flag.Source != SourceDefault {
+			state += " (" + flag.Source + ")"
+		}
+		parts = append(parts, flag.Name+" "+state)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,44 @@
+package features_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/features"
+)
+
+var _ = Describe("Resolve", func() {
+	It("should enable the flags by default", func() {
+		flags, err := features.Resolve(nil, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(flags.Enabled(features.IssueCreation)).To(BeTrue())
+		Expect(flags.Enabled(features.StatusPublishing)).To(BeTrue())
+		Expect(flags.String()).To(Equal("issue-creation on, status-publishing on"))
+	})
+
+	It("should let the environment override the policy", func() {
+		flags, err := features.Resolve(map[string]bool{features.IssueCreation: false, features.StatusPublishing: false}, " status-publishing, ")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(flags.List()).To(Equal([]features.Flag{
+			{Name: features.IssueCreation, Enabled: false, Source: features.SourcePolicy},
+			{Name: features.StatusPublishing, Enabled: true, Source: features.SourceEnv},
+		}))
+
+		flags, err = features.Resolve(nil, "-issue-creation")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(flags.String()).To(Equal("issue-creation off (env), status-publishing on"))
+	})
+
+	It("should refuse unknown flags", func() {
+		_, err := features.Resolve(map[string]bool{"auto-merge": true}, "")
+		Expect(err).To(MatchError(`unknown feature "auto-merge" in the policy, expected one of issue-creation, status-publishing`))
+		_, err = features.Resolve(nil, "-status-publish")
+		Expect(err).To(MatchError(`unknown feature "status-publish" in COVERAGE_FEATURES, expected one of issue-creation, status-publishing`))
+	})
+
+	It("should have the defaults when unresolved", func() {
+		var flags features.Flags
+		Expect(flags.Enabled(features.IssueCreation)).To(BeTrue())
+		Expect(flags.Enabled("unknown")).To(BeFalse())
+	})
+})
@@ -0,0 +1,169 @@
+package fixtures
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// recordedHeaders are the response headers kept in fixtures; others (dates, request IDs,
+// rate limit counters) change on every call and would make fixtures non-deterministic
+var recordedHeaders = []string{"Content-Type", "Link", "ETag", "X-OAuth-Scopes"}
+
+// unsafeChars matches characters not allowed in fixture file names
+var unsafeChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// Fixture is a recorded API response
+type Fixture struct {
+	Status int                 `json:"status"`
+	Header map[string][]string `json:"header,omitempty"`
+	Body   json.RawMessage     `json:"body"`
+}
+
+// Recorder is an http.RoundTripper that passes requests through and saves every response as a fixture
+type Recorder struct {
+	dir  string
+	base http.RoundTripper
+}
+
+// NewRecorder creates a Recorder writing fixtures to dir, using base (or http.DefaultTransport) for requests
+func NewRecorder(dir string, base http.RoundTripper) *Recorder {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Recorder{dir: dir, base: base}
+}
+
+// RoundTrip performs the request and records its response
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := r.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response of %s: %w", Key(req), err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	fixture := Fixture{Status: resp.StatusCode, Header: make(map[string][]string)}
+	for _, name := range recordedHeaders {
+		if values := resp.Header.Values(name); len(values) > 0 {
+			fixture.Header[name] = values
+		}
+	}
+	if len(body) > 0 {
+		if !json.Valid(body) {
+			return nil, fmt.Errorf("cannot record non-JSON response of %s", Key(req))
+		}
+		fixture.Body = body
+	}
+
+	data, err := json.MarshalIndent(fixture, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal fixture for %s: %w", Key(req), err)
+	}
+	if err := os.MkdirAll(r.dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create fixtures directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(r.dir, FileName(req)), append(data, '\n'), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write fixture for %s: %w", Key(req), err)
+	}
+
+	return resp, nil
+}
+
+// Replayer is an http.RoundTripper serving recorded fixtures without any network access
+type Replayer struct {
+	dir string
+}
+
+// NewReplayer creates a Replayer reading fixtures from dir
+func NewReplayer(dir string) *Replayer {
+	return &Replayer{dir: dir}
+}
+
+// RoundTrip returns the recorded response for the request, failing if none was recorded
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	path := filepath.Join(r.dir, FileName(req))
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no recorded fixture for %s (expected %s, record it with --record)", Key(req), path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var fixture Fixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("failed to parse fixture %s: %w", path, err)
+	}
+
+	header := make(http.Header)
+	for name, values := range fixture.Header {
+		for _, value := range values {
+			header.Add(name, value)
+		}
+	}
+	body := []byte(fixture.Body)
+	if string(body) == "null" {
+		body = nil
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", fixture.Status, http.StatusText(fixture.Status)),
+		StatusCode:    fixture.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// Key identifies a request by method, path and sorted query, ignoring the host and credentials
+// Requests with a body, e.g. GraphQL queries which all go to POST /graphql, are told apart by a hash of it
+func Key(req *http.Request) string {
+	key := req.Method + " " + req.URL.Path
+	if query := req.URL.Query().Encode(); query != "" {
+		key += "?" + query
+	}
+	if digest := bodyDigest(req); digest != "" {
+		key += " " + digest
+	}
+	return key
+}
+
+// bodyDigest returns a short hash of the body of a request, or nothing for requests without a body
+// The body is read through GetBody, leaving it unread for the request
+func bodyDigest(req *http.Request) string {
+	if req.Body == nil || req.Body == http.NoBody || req.GetBody == nil {
+		return ""
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return ""
+	}
+	defer body.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, body); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:12]
+}
+
+// FileName returns the fixture file name of a request, e.g. "GET_orgs_konflux-ci_repos_per_page_100.json"
+func FileName(req *http.Request) string {
+	return unsafeChars.ReplaceAllString(Key(req), "_") + ".json"
+}
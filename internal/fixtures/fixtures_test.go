@@ -0,0 +1,99 @@
+package fixtures_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/fixtures"
+)
+
+var _ = Describe("Fixtures", func() {
+	var dir string
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+	})
+
+	get := func(transport http.RoundTripper, url string) (*http.Response, string) {
+		resp, err := (&http.Client{Transport: transport}).Get(url)
+		Expect(err).NotTo(HaveOccurred())
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		Expect(err).NotTo(HaveOccurred())
+		return resp, string(body)
+	}
+
+	It("should replay recorded responses without the server", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Link", `<https://api.github.com/orgs/test-org/repos?page=2>; rel="next"`)
+			w.Header().Set("X-Request-Id", "changes-every-time")
+			if r.URL.Path == "/missing" {
+				w.WriteHeader(http.StatusNotFound)
+			}
+			fmt.Fprintf(w, `{"path": %q}`, r.URL.Path)
+		}))
+
+		_, recorded := get(fixtures.NewRecorder(dir, nil), server.URL+"/orgs/test-org/repos?type=all&per_page=100")
+		get(fixtures.NewRecorder(dir, nil), server.URL+"/missing")
+		server.Close()
+
+		Expect(filepath.Join(dir, "GET_orgs_test-org_repos_per_page_100_type_all.json")).To(BeAnExistingFile())
+
+		// Query parameter order and host do not matter when replaying
+		resp, replayed := get(fixtures.NewReplayer(dir), "https://api.github.com/orgs/test-org/repos?per_page=100&type=all")
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(replayed).To(MatchJSON(recorded))
+		Expect(resp.Header.Get("Link")).To(ContainSubstring(`rel="next"`))
+		Expect(resp.Header.Get("X-Request-Id")).To(BeEmpty())
+
+		resp, _ = get(fixtures.NewReplayer(dir), "https://api.github.com/missing")
+		Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+	})
+
+	It("should fail for requests that were not recorded", func() {
+		_, err := (&http.Client{Transport: fixtures.NewReplayer(dir)}).Get("https://api.github.com/user")
+		Expect(err).To(MatchError(ContainSubstring("no recorded fixture for GET /user")))
+	})
+
+	It("should tell requests to the same endpoint apart by their bodies", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			fmt.Fprintf(w, `{"query": %q}`, body)
+		}))
+		post := func(transport http.RoundTripper, url, body string) string {
+			resp, err := (&http.Client{Transport: transport}).Post(url, "application/json", strings.NewReader(body))
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+			replayed, err := io.ReadAll(resp.Body)
+			Expect(err).NotTo(HaveOccurred())
+			return string(replayed)
+		}
+
+		post(fixtures.NewRecorder(dir, nil), server.URL+"/graphql", `{"cursor": null}`)
+		post(fixtures.NewRecorder(dir, nil), server.URL+"/graphql", `{"cursor": "Y3Vyc29y"}`)
+		server.Close()
+
+		Expect(filepath.Glob(filepath.Join(dir, "POST_graphql_*.json"))).To(HaveLen(2))
+		Expect(post(fixtures.NewReplayer(dir), "https://api.github.com/graphql", `{"cursor": "Y3Vyc29y"}`)).To(MatchJSON(`{"query": "{\"cursor\": \"Y3Vyc29y\"}"}`))
+	})
+
+	It("should replay hand-written fixtures", func() {
+		fixture := `{"status": 204, "body": null}`
+		Expect(os.WriteFile(filepath.Join(dir, "DELETE_repos_org_repo.json"), []byte(fixture), 0644)).To(Succeed())
+
+		req, err := http.NewRequest(http.MethodDelete, "https://api.github.com/repos/org/repo", nil)
+		Expect(err).NotTo(HaveOccurred())
+		resp, err := fixtures.NewReplayer(dir).RoundTrip(req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.StatusCode).To(Equal(http.StatusNoContent))
+		Expect(resp.ContentLength).To(BeZero())
+	})
+})
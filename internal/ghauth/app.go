@@ -0,0 +1,145 @@
+package ghauth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/go-github/v66/github"
+	"golang.org/x/oauth2"
+)
+
+// Environment variables holding the GitHub App credentials
+const (
+	AppIDEnv             = "GITHUB_APP_ID"
+	AppInstallationIDEnv = "GITHUB_APP_INSTALLATION_ID"
+	AppPrivateKeyEnv     = "GITHUB_APP_PRIVATE_KEY"
+)
+
+// AppSource is the source of the roles served by a GitHub App installation
+const AppSource = "GitHub App"
+
+const (
+	// appJWTLifetime is how long the JWT authenticating as the app is valid; GitHub allows at most 10 minutes
+	appJWTLifetime = 9 * time.Minute
+	// appClockSkew backdates the JWT in case the runner's clock is ahead of GitHub's
+	appClockSkew = time.Minute
+	// tokenRefreshMargin renews installation tokens this long before they expire, so no request uses an expiring token
+	tokenRefreshMargin = 5 * time.Minute
+)
+
+// App holds the credentials of a GitHub App installation, which issues short-lived tokens instead of a
+// personal access token; tokens are requested when first needed and renewed before they expire
+type App struct {
+	// ID is the app ID, or its client ID
+	ID             string
+	InstallationID string
+	// PrivateKey is the PEM-encoded private key of the app
+	PrivateKey string
+	// APIURL is the GitHub API the tokens are requested from, api.github.com when empty
+	APIURL string
+}
+
+// appFromEnv reads the GitHub App credentials, or returns nil when no app is configured
+func appFromEnv(getenv func(string) string) *App {
+	if getenv(AppIDEnv) == "" {
+		return nil
+	}
+	return &App{
+		ID:             getenv(AppIDEnv),
+		InstallationID: getenv(AppInstallationIDEnv),
+		// Multiline secrets are sometimes stored with escaped newlines
+		PrivateKey: strings.ReplaceAll(getenv(AppPrivateKeyEnv), `\n`, "\n"),
+	}
+}
+
+// Validate checks the credentials are complete and the private key parses, without calling the API
+func (a *App) Validate() error {
+	if _, err := a.installationID(); err != nil {
+		return err
+	}
+	_, err := a.signJWT(time.Now())
+	return err
+}
+
+// NewClient creates a GitHub client authenticated as the app installation
+func (a *App) NewClient(ctx context.Context) *github.Client {
+	return a.NewClientWithTransport(ctx, nil)
+}
+
+// NewClientWithTransport creates a GitHub client authenticated as the app installation, sending its requests,
+// including the token requests, through transport; a nil transport uses http.DefaultTransport
+func (a *App) NewClientWithTransport(ctx context.Context, transport http.RoundTripper) *github.Client {
+	base := &http.Client{Transport: transport}
+	if transport != nil {
+		ctx = context.WithValue(ctx, oauth2.HTTPClient, base)
+	}
+	source := &installationTokenSource{ctx: ctx, app: a, client: base}
+	return github.NewClient(oauth2.NewClient(ctx, oauth2.ReuseTokenSourceWithExpiry(nil, source, tokenRefreshMargin)))
+}
+
+// installationID parses the installation ID
+func (a *App) installationID() (int64, error) {
+	if a.InstallationID == "" {
+		return 0, fmt.Errorf("%s is required with %s", AppInstallationIDEnv, AppIDEnv)
+	}
+	id, err := strconv.ParseInt(a.InstallationID, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %w", AppInstallationIDEnv, a.InstallationID, err)
+	}
+	return id, nil
+}
+
+// signJWT creates the JWT authenticating as the app, used to request installation tokens
+func (a *App) signJWT(now time.Time) (string, error) {
+	if a.PrivateKey == "" {
+		return "", fmt.Errorf("%s is required with %s", AppPrivateKeyEnv, AppIDEnv)
+	}
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(a.PrivateKey))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse %s: %w", AppPrivateKeyEnv, err)
+	}
+	claims := jwt.RegisteredClaims{
+		Issuer:    a.ID,
+		IssuedAt:  jwt.NewNumericDate(now.Add(-appClockSkew)),
+		ExpiresAt: jwt.NewNumericDate(now.Add(appJWTLifetime)),
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(key)
+}
+
+// installationTokenSource requests a new installation token each time it is asked for one
+type installationTokenSource struct {
+	ctx    context.Context
+	app    *App
+	client *http.Client
+}
+
+// Token requests an installation token, valid for an hour
+func (s *installationTokenSource) Token() (*oauth2.Token, error) {
+	id, err := s.app.installationID()
+	if err != nil {
+		return nil, err
+	}
+	signed, err := s.app.signJWT(time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	client := github.NewClient(s.client).WithAuthToken(signed)
+	if s.app.APIURL != "" {
+		apiURL, err := ParseAPIURL(s.app.APIURL)
+		if err != nil {
+			return nil, err
+		}
+		client.BaseURL = apiURL
+	}
+	token, _, err := client.Apps.CreateInstallationToken(s.ctx, id, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a token for GitHub App installation %d: %w", id, err)
+	}
+	return &oauth2.Token{AccessToken: token.GetToken(), TokenType: "Bearer", Expiry: token.GetExpiresAt().Time}, nil
+}
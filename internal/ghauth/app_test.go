@@ -0,0 +1,115 @@
+package ghauth_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/go-github/v66/github"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/ghauth"
+)
+
+var _ = Describe("App", func() {
+	var (
+		ctx       context.Context
+		key       *rsa.PrivateKey
+		server    *httptest.Server
+		app       *ghauth.App
+		issued    int
+		expiresIn time.Duration
+		lastAuth  string
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		var err error
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).NotTo(HaveOccurred())
+		issued, expiresIn, lastAuth = 0, time.Hour, ""
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.Method == http.MethodPost && r.URL.Path == "/app/installations/42/access_tokens":
+				claims := &jwt.RegisteredClaims{}
+				_, err := jwt.ParseWithClaims(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), claims,
+					func(*jwt.Token) (any, error) { return &key.PublicKey, nil })
+				if err != nil || claims.Issuer != "1234" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				issued++
+				fmt.Fprintf(w, `{"token": "ghs_%d", "expires_at": %q}`, issued, time.Now().Add(expiresIn).UTC().Format(time.RFC3339))
+			case r.URL.Path == "/user":
+				lastAuth = r.Header.Get("Authorization")
+				w.WriteHeader(http.StatusForbidden)
+				fmt.Fprint(w, `{"message": "Resource not accessible by integration"}`)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+
+		pemKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+		app = &ghauth.App{ID: "1234", InstallationID: "42", PrivateKey: string(pemKey), APIURL: server.URL}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	// newClient creates a client of the app installation calling the test server
+	newClient := func() *github.Client {
+		client := app.NewClient(ctx)
+		client.BaseURL, _ = url.Parse(server.URL + "/")
+		return client
+	}
+
+	It("should authenticate requests with an installation token", func() {
+		info, err := ghauth.Inspect(ctx, newClient())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Installation).To(BeTrue())
+		Expect(lastAuth).To(Equal("Bearer ghs_1"))
+	})
+
+	It("should reuse installation tokens until they are about to expire", func() {
+		client := newClient()
+		for range 3 {
+			_, err := ghauth.Inspect(ctx, client)
+			Expect(err).NotTo(HaveOccurred())
+		}
+		Expect(issued).To(Equal(1))
+
+		// Tokens expiring within the refresh margin are renewed on every request
+		expiresIn = 2 * time.Minute
+		client = newClient()
+		for range 3 {
+			_, err := ghauth.Inspect(ctx, client)
+			Expect(err).NotTo(HaveOccurred())
+		}
+		Expect(issued).To(Equal(4))
+		Expect(lastAuth).To(Equal("Bearer ghs_4"))
+	})
+
+	It("should validate the credentials without calling the API", func() {
+		Expect(app.Validate()).To(Succeed())
+
+		app.InstallationID = ""
+		Expect(app.Validate()).To(MatchError(ContainSubstring("GITHUB_APP_INSTALLATION_ID is required")))
+
+		app.InstallationID = "42"
+		app.PrivateKey = "not a key"
+		Expect(app.Validate()).To(MatchError(ContainSubstring("failed to parse GITHUB_APP_PRIVATE_KEY")))
+		Expect(issued).To(BeZero())
+	})
+})
@@ -0,0 +1,179 @@
+package ghauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/google/go-github/v66/github"
+	"golang.org/x/oauth2"
+)
+
+// installationForbiddenMessage is the message of the 403 response of GET /user to installation tokens
+const installationForbiddenMessage = "Resource not accessible by integration"
+
+// impliedScopes lists the classic token scopes granted implicitly by a broader scope
+var impliedScopes = map[string][]string{
+	"repo":      {"public_repo", "repo:status", "repo_deployment", "repo:invite", "security_events"},
+	"admin:org": {"write:org", "read:org"},
+	"write:org": {"read:org"},
+}
+
+// TokenInfo describes the token behind a client, as reported by the API
+type TokenInfo struct {
+	Login string
+	// Installation is set for GitHub App installation tokens (including a workflow's GITHUB_TOKEN),
+	// which cannot query the authenticated user
+	Installation bool
+	// Scopes are the classic OAuth scopes of the token; nil for fine-grained and installation tokens,
+	// which must be checked by probing the endpoints they are used for
+	Scopes []string
+}
+
+// String describes the token's identity for messages
+func (t *TokenInfo) String() string {
+	if t.Installation {
+		return "GitHub App installation"
+	}
+	return t.Login
+}
+
+// NewClient creates a GitHub client authenticated with token, or an unauthenticated one if token is empty
+func NewClient(ctx context.Context, token string) *github.Client {
+	return NewClientWithTransport(ctx, token, nil)
+}
+
+// NewClientWithTransport creates a GitHub client sending its requests through transport,
+// e.g. to record or replay API fixtures; a nil transport uses http.DefaultTransport
+func NewClientWithTransport(ctx context.Context, token string, transport http.RoundTripper) *github.Client {
+	var base *http.Client
+	if transport != nil {
+		base = &http.Client{Transport: transport}
+	}
+	if token == "" {
+		return github.NewClient(base)
+	}
+	if base != nil {
+		ctx = context.WithValue(ctx, oauth2.HTTPClient, base)
+	}
+	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
+	return github.NewClient(oauth2.NewClient(ctx, ts))
+}
+
+// ParseAPIURL parses the URL of a GitHub API, e.g. https://github.example.com/api/v3 on GitHub Enterprise Server,
+// as the base URL of a client
+func ParseAPIURL(s string) (*url.URL, error) {
+	parsed, err := url.Parse(strings.TrimSuffix(s, "/") + "/")
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("GitHub API URL must be an http(s) URL, got %q", s)
+	}
+	return parsed, nil
+}
+
+// DefaultWebURL is the web host of github.com, which repositories are cloned from and sources linked to
+const DefaultWebURL = "https://github.com/"
+
+// WebURL returns the web host of the GitHub API at apiURL, which repositories are cloned from and sources linked to:
+// https://github.example.com/ for https://github.example.com/api/v3 on GitHub Enterprise Server, and the host without
+// its api. prefix for an API served at the root of its own host, e.g. https://example.ghe.com/ for
+// https://api.example.ghe.com; DefaultWebURL for an empty URL or one that does not parse
+func WebURL(apiURL string) string {
+	if apiURL == "" {
+		return DefaultWebURL
+	}
+	parsed, err := ParseAPIURL(apiURL)
+	if err != nil {
+		return DefaultWebURL
+	}
+	host := parsed.Host
+	if parsed.Path == "/" {
+		host = strings.TrimPrefix(host, "api.")
+	}
+	return parsed.Scheme + "://" + host + "/"
+}
+
+// Inspect identifies the token's user and scopes
+func Inspect(ctx context.Context, client *github.Client) (*TokenInfo, error) {
+	user, resp, err := client.Users.Get(ctx, "")
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusUnauthorized {
+			return nil, fmt.Errorf("token is invalid or expired")
+		}
+		// Other 403 responses, e.g. rate limits or SAML enforcement, are failures of the token
+		if installationForbidden(err) {
+			return &TokenInfo{Installation: true}, nil
+		}
+		return nil, fmt.Errorf("failed to get authenticated user: %w", err)
+	}
+
+	info := &TokenInfo{Login: user.GetLogin()}
+	if header, ok := resp.Header["X-Oauth-Scopes"]; ok {
+		info.Scopes = []string{}
+		for _, value := range header {
+			for _, scope := range strings.Split(value, ",") {
+				if scope = strings.TrimSpace(scope); scope != "" {
+					info.Scopes = append(info.Scopes, scope)
+				}
+			}
+		}
+	}
+	return info, nil
+}
+
+// installationForbidden reports whether an error is the 403 GitHub answers installation tokens querying the
+// authenticated user with
+func installationForbidden(err error) bool {
+	var response *github.ErrorResponse
+	return errors.As(err, &response) && response.Response != nil &&
+		response.Response.StatusCode == http.StatusForbidden &&
+		strings.Contains(response.Message, installationForbiddenMessage)
+}
+
+// MissingScopes returns the required scopes the token does not grant
+// Fine-grained tokens have no scopes and never report missing ones
+func (t *TokenInfo) MissingScopes(required ...string) []string {
+	if t.Scopes == nil {
+		return nil
+	}
+
+	granted := make(map[string]bool)
+	for _, scope := range t.Scopes {
+		granted[scope] = true
+		for _, implied := range impliedScopes[scope] {
+			granted[implied] = true
+		}
+	}
+
+	var missing []string
+	for _, scope := range required {
+		if !granted[scope] {
+			missing = append(missing, scope)
+		}
+	}
+	return missing
+}
+
+// CheckOrgRead verifies the client can list the organization's repositories
+func CheckOrgRead(ctx context.Context, client *github.Client, org string) error {
+	opts := &github.RepositoryListByOrgOptions{ListOptions: github.ListOptions{PerPage: 1}}
+	if _, _, err := client.Repositories.ListByOrg(ctx, org, opts); err != nil {
+		return fmt.Errorf("cannot list repositories of %s: %w", org, err)
+	}
+	return nil
+}
+
+// CheckRepoWrite verifies the client can push branches to and open pull requests on owner/repo
+// Installation tokens get no permissions in the response, only their access to the repository is checked
+func CheckRepoWrite(ctx context.Context, client *github.Client, owner, repo string) error {
+	repository, _, err := client.Repositories.Get(ctx, owner, repo)
+	if err != nil {
+		return fmt.Errorf("cannot read %s/%s: %w", owner, repo, err)
+	}
+	if repository.Permissions != nil && !repository.Permissions["push"] {
+		return fmt.Errorf("token has no push permission on %s/%s", owner, repo)
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+package ghauth_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestGhauth(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Ghauth Suite")
+}
@@ -0,0 +1,116 @@
+package ghauth_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	"github.com/google/go-github/v66/github"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/ghauth"
+)
+
+var _ = Describe("ghauth", func() {
+	var (
+		ctx    context.Context
+		server *httptest.Server
+		client *github.Client
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/user":
+				if r.Header.Get("Authorization") == "Bearer ghs_installation" {
+					w.WriteHeader(http.StatusForbidden)
+					fmt.Fprint(w, `{"message": "Resource not accessible by integration"}`)
+					return
+				}
+				if r.Header.Get("Authorization") == "Bearer ghp_sso" {
+					w.WriteHeader(http.StatusForbidden)
+					fmt.Fprint(w, `{"message": "Resource protected by organization SAML enforcement. You must grant your Personal Access token access to this organization."}`)
+					return
+				}
+				// Fine-grained tokens carry no X-OAuth-Scopes header
+				if r.Header.Get("Authorization") != "Bearer github_pat_fine" {
+					w.Header().Set("X-OAuth-Scopes", "repo, admin:org")
+				}
+				fmt.Fprint(w, `{"login": "dashboard-bot"}`)
+			case "/repos/konflux-ci/coverage-dashboard":
+				fmt.Fprint(w, `{"name": "coverage-dashboard", "permissions": {"push": true}}`)
+			case "/repos/konflux-ci/installation":
+				fmt.Fprint(w, `{"name": "installation"}`)
+			case "/repos/konflux-ci/read-only":
+				fmt.Fprint(w, `{"name": "read-only", "permissions": {"pull": true}}`)
+			case "/orgs/konflux-ci/repos":
+				fmt.Fprint(w, `[]`)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		client = github.NewClient(nil)
+		client.BaseURL, _ = url.Parse(server.URL + "/")
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("Inspect", func() {
+		It("should report the login and classic scopes", func() {
+			info, err := ghauth.Inspect(ctx, client)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Login).To(Equal("dashboard-bot"))
+			Expect(info.Scopes).To(Equal([]string{"repo", "admin:org"}))
+		})
+
+		It("should report no scopes for fine-grained tokens", func() {
+			info, err := ghauth.Inspect(ctx, client.WithAuthToken("github_pat_fine"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Scopes).To(BeNil())
+			Expect(info.MissingScopes("repo")).To(BeEmpty())
+		})
+		It("should recognize installation tokens", func() {
+			info, err := ghauth.Inspect(ctx, client.WithAuthToken("ghs_installation"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Installation).To(BeTrue())
+			Expect(info.String()).To(Equal("GitHub App installation"))
+			Expect(info.MissingScopes("repo")).To(BeEmpty())
+		})
+
+		It("should report other 403 responses as errors", func() {
+			_, err := ghauth.Inspect(ctx, client.WithAuthToken("ghp_sso"))
+			Expect(err).To(MatchError(ContainSubstring("SAML enforcement")))
+		})
+	})
+
+	Describe("MissingScopes", func() {
+		It("should account for scopes implied by broader ones", func() {
+			info := &ghauth.TokenInfo{Scopes: []string{"repo", "admin:org"}}
+			Expect(info.MissingScopes("public_repo", "read:org")).To(BeEmpty())
+		})
+
+		It("should list scopes the token lacks", func() {
+			info := &ghauth.TokenInfo{Scopes: []string{"public_repo"}}
+			Expect(info.MissingScopes("repo", "read:org")).To(Equal([]string{"repo", "read:org"}))
+		})
+	})
+
+	Describe("access checks", func() {
+		It("should accept a token that can read the organization", func() {
+			Expect(ghauth.CheckOrgRead(ctx, client, "konflux-ci")).To(Succeed())
+			Expect(ghauth.CheckOrgRead(ctx, client, "other-org")).To(MatchError(ContainSubstring("cannot list repositories of other-org")))
+		})
+
+		It("should require push permission on the repository", func() {
+			Expect(ghauth.CheckRepoWrite(ctx, client, "konflux-ci", "coverage-dashboard")).To(Succeed())
+			Expect(ghauth.CheckRepoWrite(ctx, client, "konflux-ci", "read-only")).To(MatchError(ContainSubstring("no push permission")))
+			Expect(ghauth.CheckRepoWrite(ctx, client, "konflux-ci", "installation")).To(Succeed())
+		})
+	})
+})
@@ -0,0 +1,229 @@
+package ghauth
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultRateLimitRetries is how many times a rate limited request is retried before its response is returned
+	DefaultRateLimitRetries = 3
+	// DefaultMaxRateLimitWait is the longest wait for a rate limit; the primary limit resets at most an hour later
+	DefaultMaxRateLimitWait = time.Hour
+	// secondaryRateLimitWait is the wait GitHub advises for secondary rate limits that do not say how long to wait
+	secondaryRateLimitWait = time.Minute
+	// resetMargin is added to waits until X-RateLimit-Reset, in case the runner's clock is behind GitHub's
+	resetMargin = time.Second
+)
+
+// Reasons of rate limit waits
+const (
+	ReasonExhausted  = "rate limit exhausted"
+	ReasonRetryAfter = "Retry-After"
+	ReasonSecondary  = "secondary rate limit"
+)
+
+// RateLimitWait describes a wait for a GitHub rate limit, reported before waiting
+type RateLimitWait struct {
+	// Request is the method and path of the request waiting, e.g. "GET /orgs/konflux-ci/repos"
+	Request string
+	Reason  string
+	Wait    time.Duration
+	// Retry is the number of the retry the wait precedes, 0 when waiting for a limit exhausted by earlier requests
+	Retry int
+}
+
+// String describes the wait for progress output
+func (w RateLimitWait) String() string {
+	if w.Retry == 0 {
+		return fmt.Sprintf("GitHub %s, waiting %s before %s", w.Reason, w.Wait.Round(time.Second), w.Request)
+	}
+	return fmt.Sprintf("GitHub %s on %s, retrying in %s (retry %d)", w.Reason, w.Request, w.Wait.Round(time.Second), w.Retry)
+}
+
+// PrintRateLimitWait prints a wait for a rate limit, the default report of RateLimitTransport
+func PrintRateLimitWait(wait RateLimitWait) {
+	fmt.Printf("  ⏳ %s\n", wait)
+}
+
+// RateLimitTransport is an http.RoundTripper that waits out GitHub rate limits instead of failing:
+// it retries requests rejected by a primary or secondary limit once the limit resets or Retry-After passes,
+// and holds requests back while a limit exhausted by earlier responses has not reset
+type RateLimitTransport struct {
+	base   http.RoundTripper
+	notify func(RateLimitWait)
+	// MaxRetries is how many times a request is retried; the last rate limited response is returned after that
+	MaxRetries int
+	// MaxWait is the longest wait; responses asking to wait longer are returned as they are
+	MaxWait time.Duration
+	// Sleep waits for a duration unless ctx is done; tests replace it to run without waiting
+	Sleep func(ctx context.Context, d time.Duration) error
+
+	mu sync.Mutex
+	// resets holds when the exhausted limits reset, by resource
+	resets map[string]time.Time
+}
+
+// NewRateLimitTransport creates a RateLimitTransport sending requests through base (or http.DefaultTransport),
+// calling notify (or PrintRateLimitWait) before every wait
+func NewRateLimitTransport(base http.RoundTripper, notify func(RateLimitWait)) *RateLimitTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if notify == nil {
+		notify = PrintRateLimitWait
+	}
+	return &RateLimitTransport{
+		base:       base,
+		notify:     notify,
+		MaxRetries: DefaultRateLimitRetries,
+		MaxWait:    DefaultMaxRateLimitWait,
+		Sleep:      sleep,
+		resets:     make(map[string]time.Time),
+	}
+}
+
+// RoundTrip performs the request, waiting and retrying while GitHub rate limits it
+func (t *RateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resource := resourceOf(req)
+	for retry := 0; ; retry++ {
+		if err := t.waitForReset(req, resource); err != nil {
+			return nil, err
+		}
+
+		resp, err := t.base.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+
+		wait, reason, limited := t.rateLimited(resp)
+		if !limited || retry >= t.MaxRetries || wait > t.MaxWait || (req.Body != nil && req.GetBody == nil) {
+			// Later requests wait for the reset instead of this one
+			t.recordReset(resp, resource)
+			return resp, nil
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		t.notify(RateLimitWait{Request: describe(req), Reason: reason, Wait: wait, Retry: retry + 1})
+		if err := t.Sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("failed to rewind the body of %s: %w", describe(req), err)
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// waitForReset holds a request back until the limit of its resource resets, when earlier responses exhausted it
+func (t *RateLimitTransport) waitForReset(req *http.Request, resource string) error {
+	t.mu.Lock()
+	wait := time.Until(t.resets[resource])
+	if wait <= 0 {
+		delete(t.resets, resource)
+	}
+	t.mu.Unlock()
+	// Requests past the longest wait go through, failing with GitHub's error
+	if wait <= 0 || wait > t.MaxWait {
+		return nil
+	}
+
+	t.notify(RateLimitWait{Request: describe(req), Reason: ReasonExhausted, Wait: wait})
+	return t.Sleep(req.Context(), wait)
+}
+
+// recordReset remembers when the limit of a resource resets once a response used up its last request
+func (t *RateLimitTransport) recordReset(resp *http.Response, resource string) {
+	if resp.Header.Get("X-RateLimit-Remaining") != "0" {
+		return
+	}
+	if reset, ok := t.resetWait(resp); ok {
+		t.mu.Lock()
+		t.resets[resource] = time.Now().Add(reset)
+		t.mu.Unlock()
+	}
+}
+
+// rateLimited reports whether GitHub rejected a response for a rate limit, and how long to wait before retrying
+func (t *RateLimitTransport) rateLimited(resp *http.Response) (time.Duration, string, bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return 0, "", false
+	}
+	if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+		if seconds, err := strconv.Atoi(retryAfter); err == nil && seconds >= 0 {
+			return time.Duration(seconds) * time.Second, ReasonRetryAfter, true
+		}
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if wait, ok := t.resetWait(resp); ok {
+			return wait, ReasonExhausted, true
+		}
+	}
+	if resp.StatusCode == http.StatusTooManyRequests || mentionsSecondaryLimit(resp) {
+		return secondaryRateLimitWait, ReasonSecondary, true
+	}
+	// Other 403 responses are permission errors
+	return 0, "", false
+}
+
+// resetWait returns the time left until the X-RateLimit-Reset of a response
+func (t *RateLimitTransport) resetWait(resp *http.Response) (time.Duration, bool) {
+	reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	wait := time.Until(time.Unix(reset, 0)) + resetMargin
+	if wait < 0 {
+		wait = 0
+	}
+	return wait, true
+}
+
+// mentionsSecondaryLimit reports whether a response's message is about a secondary rate limit, leaving its body readable
+func mentionsSecondaryLimit(resp *http.Response) bool {
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return err == nil && strings.Contains(strings.ToLower(string(body)), "secondary rate limit")
+}
+
+// resourceOf returns the rate limit resource a request counts against; each has its own limit
+func resourceOf(req *http.Request) string {
+	switch {
+	case strings.HasSuffix(req.URL.Path, "/graphql"):
+		return "graphql"
+	case strings.Contains(req.URL.Path, "/search/"):
+		return "search"
+	default:
+		return "core"
+	}
+}
+
+// describe returns the method and path of a request for messages
+func describe(req *http.Request) string {
+	return req.Method + " " + req.URL.Path
+}
+
+// sleep waits for d unless ctx is done first
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
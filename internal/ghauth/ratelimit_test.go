@@ -0,0 +1,184 @@
+package ghauth_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/ghauth"
+)
+
+var _ = Describe("RateLimitTransport", func() {
+	var (
+		server    *httptest.Server
+		responses []func(w http.ResponseWriter)
+		bodies    []string
+		waits     []ghauth.RateLimitWait
+		slept     []time.Duration
+		transport *ghauth.RateLimitTransport
+	)
+
+	BeforeEach(func() {
+		responses, bodies, waits, slept = nil, nil, nil, nil
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			bodies = append(bodies, string(body))
+			if len(responses) == 0 {
+				w.Write([]byte(`{}`))
+				return
+			}
+			respond := responses[0]
+			responses = responses[1:]
+			respond(w)
+		}))
+		DeferCleanup(server.Close)
+
+		transport = ghauth.NewRateLimitTransport(nil, func(wait ghauth.RateLimitWait) { waits = append(waits, wait) })
+		transport.Sleep = func(ctx context.Context, d time.Duration) error {
+			slept = append(slept, d)
+			return nil
+		}
+	})
+
+	exhausted := func(status int, resetIn time.Duration) func(w http.ResponseWriter) {
+		return func(w http.ResponseWriter) {
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(resetIn).Unix(), 10))
+			w.WriteHeader(status)
+			w.Write([]byte(`{}`))
+		}
+	}
+	status := func(code int, header map[string]string, body string) func(w http.ResponseWriter) {
+		return func(w http.ResponseWriter) {
+			for name, value := range header {
+				w.Header().Set(name, value)
+			}
+			w.WriteHeader(code)
+			w.Write([]byte(body))
+		}
+	}
+	get := func() *http.Response {
+		req, err := http.NewRequest(http.MethodGet, server.URL+"/orgs/konflux-ci/repos", nil)
+		Expect(err).NotTo(HaveOccurred())
+		resp, err := transport.RoundTrip(req)
+		Expect(err).NotTo(HaveOccurred())
+		return resp
+	}
+
+	It("should wait until the primary limit resets and retry", func() {
+		responses = append(responses, exhausted(http.StatusForbidden, 30*time.Second))
+		Expect(get().StatusCode).To(Equal(http.StatusOK))
+
+		Expect(slept).To(HaveLen(1))
+		Expect(slept[0]).To(BeNumerically("~", 31*time.Second, 2*time.Second))
+		Expect(waits).To(HaveLen(1))
+		Expect(waits[0].Request).To(Equal("GET /orgs/konflux-ci/repos"))
+		Expect(waits[0].Reason).To(Equal(ghauth.ReasonExhausted))
+		Expect(waits[0].Retry).To(Equal(1))
+	})
+
+	It("should honor Retry-After", func() {
+		responses = append(responses, status(http.StatusTooManyRequests, map[string]string{"Retry-After": "7"}, `{}`))
+		Expect(get().StatusCode).To(Equal(http.StatusOK))
+		Expect(slept).To(Equal([]time.Duration{7 * time.Second}))
+		Expect(waits[0].Reason).To(Equal(ghauth.ReasonRetryAfter))
+	})
+
+	It("should wait a minute for secondary limits that do not say how long", func() {
+		responses = append(responses, status(http.StatusForbidden, nil, `{"message": "You have exceeded a secondary rate limit."}`))
+		Expect(get().StatusCode).To(Equal(http.StatusOK))
+		Expect(slept).To(Equal([]time.Duration{time.Minute}))
+		Expect(waits[0].Reason).To(Equal(ghauth.ReasonSecondary))
+	})
+
+	It("should return other forbidden responses untouched", func() {
+		responses = append(responses, status(http.StatusForbidden, nil, `{"message": "Resource not accessible by integration"}`))
+		resp := get()
+		Expect(resp.StatusCode).To(Equal(http.StatusForbidden))
+		body, err := io.ReadAll(resp.Body)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(body)).To(ContainSubstring("Resource not accessible"))
+		Expect(slept).To(BeEmpty())
+	})
+
+	It("should hold the next request back once a response used up the limit", func() {
+		responses = append(responses, exhausted(http.StatusOK, 10*time.Second))
+		Expect(get().StatusCode).To(Equal(http.StatusOK))
+		Expect(slept).To(BeEmpty())
+
+		Expect(get().StatusCode).To(Equal(http.StatusOK))
+		Expect(slept).To(HaveLen(1))
+		Expect(slept[0]).To(BeNumerically("~", 11*time.Second, 2*time.Second))
+		Expect(waits[0].Retry).To(Equal(0))
+		Expect(waits[0].String()).To(HavePrefix("GitHub rate limit exhausted, waiting"))
+	})
+
+	It("should give up after the retries and return the last response", func() {
+		for i := 0; i <= ghauth.DefaultRateLimitRetries; i++ {
+			responses = append(responses, status(http.StatusTooManyRequests, map[string]string{"Retry-After": "1"}, `{}`))
+		}
+		Expect(get().StatusCode).To(Equal(http.StatusTooManyRequests))
+		Expect(slept).To(HaveLen(ghauth.DefaultRateLimitRetries))
+	})
+
+	It("should not wait longer than MaxWait", func() {
+		transport.MaxWait = time.Minute
+		responses = append(responses, status(http.StatusTooManyRequests, map[string]string{"Retry-After": "3600"}, `{}`))
+		Expect(get().StatusCode).To(Equal(http.StatusTooManyRequests))
+		Expect(slept).To(BeEmpty())
+	})
+
+	It("should send the body again when retrying", func() {
+		responses = append(responses, status(http.StatusTooManyRequests, map[string]string{"Retry-After": "1"}, `{}`))
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/graphql", strings.NewReader(`{"query": "{}"}`))
+		Expect(err).NotTo(HaveOccurred())
+		resp, err := transport.RoundTrip(req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(bodies).To(Equal([]string{`{"query": "{}"}`, `{"query": "{}"}`}))
+	})
+
+	It("should stop waiting when the request is canceled", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		transport = ghauth.NewRateLimitTransport(nil, func(ghauth.RateLimitWait) { cancel() })
+		responses = append(responses, status(http.StatusTooManyRequests, map[string]string{"Retry-After": "60"}, `{}`))
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/orgs/konflux-ci/repos", nil)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = transport.RoundTrip(req)
+		Expect(err).To(MatchError(context.Canceled))
+	})
+})
+
+var _ = Describe("Tokens clients", func() {
+	It("should retry rate limited requests and report the waits", func() {
+		calls := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			if calls == 1 {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			w.Write([]byte(`{"login": "octocat"}`))
+		}))
+		DeferCleanup(server.Close)
+
+		var waits []ghauth.RateLimitWait
+		tokens := ghauth.Tokens{Read: "read", ReadSource: ghauth.ReadTokenEnv, OnRateLimit: func(wait ghauth.RateLimitWait) { waits = append(waits, wait) }}
+		client, err := tokens.ReadClient(context.Background()).WithEnterpriseURLs(server.URL+"/", server.URL+"/")
+		Expect(err).NotTo(HaveOccurred())
+
+		user, _, err := client.Users.Get(context.Background(), "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(user.GetLogin()).To(Equal("octocat"))
+		Expect(waits).To(HaveLen(1))
+		Expect(waits[0].Request).To(Equal("GET /api/v3/user"))
+	})
+})
@@ -0,0 +1,145 @@
+package ghauth
+
+import (
+	"context"
+	"net/http"
+	"os"
+
+	"github.com/google/go-github/v66/github"
+)
+
+// Environment variables holding GitHub tokens
+const (
+	ReadTokenEnv   = "GITHUB_READ_TOKEN"
+	WriteTokenEnv  = "GITHUB_WRITE_TOKEN"
+	SharedTokenEnv = "GITHUB_TOKEN"
+)
+
+// APIURLEnv holds the URL of the GitHub API, set by GitHub Actions on GitHub Enterprise Server runners too
+const APIURLEnv = "GITHUB_API_URL"
+
+// Tokens holds the read and write tokens and the variables they were taken from
+// A role whose source is AppSource has no token and is served by the GitHub App installation
+type Tokens struct {
+	Read        string
+	ReadSource  string
+	Write       string
+	WriteSource string
+	// App is the GitHub App configured in the environment, if any
+	App *App
+	// OnRateLimit reports the waits of the clients for GitHub rate limits; defaults to PrintRateLimitWait
+	OnRateLimit func(RateLimitWait)
+	// BaseURL is the GitHub API the clients talk to, e.g. https://github.example.com/api/v3 on GitHub Enterprise
+	// Server; api.github.com when empty
+	BaseURL string
+	// Writes paces the write requests of the write clients, shared by all of them; nil does not pace them
+	Writes *WriteLimiter
+}
+
+// TokensFromEnv resolves the tokens from the process environment
+func TokensFromEnv() Tokens {
+	return ResolveTokens(os.Getenv)
+}
+
+// ResolveTokens resolves each role from its dedicated variable, falling back to the GitHub App
+// when one is configured, then to GITHUB_TOKEN, and the GitHub API from GITHUB_API_URL
+func ResolveTokens(getenv func(string) string) Tokens {
+	tokens := Tokens{App: appFromEnv(getenv), BaseURL: getenv(APIURLEnv)}
+	if tokens.App != nil {
+		tokens.App.APIURL = tokens.BaseURL
+	}
+	tokens.Read, tokens.ReadSource = tokens.resolve(getenv, ReadTokenEnv)
+	tokens.Write, tokens.WriteSource = tokens.resolve(getenv, WriteTokenEnv)
+	return tokens
+}
+
+// WithBaseURL returns the tokens with their clients talking to the GitHub API at baseURL instead of the one of
+// GITHUB_API_URL, which an empty baseURL keeps, and checks the API URL parses
+// Commands call it before creating clients, which fall back to api.github.com on URLs that do not parse
+func (t Tokens) WithBaseURL(baseURL string) (Tokens, error) {
+	if baseURL != "" {
+		t.BaseURL = baseURL
+	}
+	if t.BaseURL == "" {
+		return t, nil
+	}
+	if _, err := ParseAPIURL(t.BaseURL); err != nil {
+		return t, err
+	}
+	if t.App != nil {
+		app := *t.App
+		app.APIURL = t.BaseURL
+		t.App = &app
+	}
+	return t, nil
+}
+
+// WebURL returns the web host of the GitHub API of the tokens, which repositories are cloned from and sources linked to
+func (t Tokens) WebURL() string {
+	return WebURL(t.BaseURL)
+}
+
+// resolve returns the token and source of a role, given its dedicated variable
+func (t Tokens) resolve(getenv func(string) string, dedicated string) (string, string) {
+	if token := getenv(dedicated); token != "" {
+		return token, dedicated
+	}
+	if t.App != nil {
+		return "", AppSource
+	}
+	return firstSet(getenv, SharedTokenEnv)
+}
+
+// ReadClient creates a GitHub client for the read role, unauthenticated when no read token is set
+func (t Tokens) ReadClient(ctx context.Context) *github.Client {
+	return t.ReadClientWithTransport(ctx, nil)
+}
+
+// WriteClient creates a GitHub client for the write role, unauthenticated when no write token is set
+func (t Tokens) WriteClient(ctx context.Context) *github.Client {
+	return t.WriteClientWithTransport(ctx, nil)
+}
+
+// ReadClientWithTransport creates a GitHub client for the read role sending its requests through transport
+func (t Tokens) ReadClientWithTransport(ctx context.Context, transport http.RoundTripper) *github.Client {
+	return t.client(ctx, t.Read, t.ReadSource, transport)
+}
+
+// WriteClientWithTransport creates a GitHub client for the write role sending its requests through transport,
+// pacing its write requests with Writes
+func (t Tokens) WriteClientWithTransport(ctx context.Context, transport http.RoundTripper) *github.Client {
+	return t.client(ctx, t.Write, t.WriteSource, t.Writes.Transport(transport))
+}
+
+// client creates a GitHub client authenticated with a role's token, or as the GitHub App installation,
+// waiting out rate limits instead of failing
+func (t Tokens) client(ctx context.Context, token, source string, transport http.RoundTripper) *github.Client {
+	transport = NewRateLimitTransport(transport, t.OnRateLimit)
+	var client *github.Client
+	if source == AppSource {
+		client = t.App.NewClientWithTransport(ctx, transport)
+	} else {
+		client = NewClientWithTransport(ctx, token, transport)
+	}
+	if t.BaseURL != "" {
+		if apiURL, err := ParseAPIURL(t.BaseURL); err == nil {
+			client.BaseURL = apiURL
+		}
+	}
+	return client
+}
+
+// Shared reports whether GITHUB_TOKEN serves as both the read and the write token
+func (t Tokens) Shared() bool {
+	return t.ReadSource == SharedTokenEnv && t.WriteSource == SharedTokenEnv
+}
+
+// firstSet returns the value and name of the first non-empty variable
+func firstSet(getenv func(string) string, names ...string) (string, string) {
+	for _, name := range names {
+		if value := getenv(name); value != "" {
+			return value, name
+		}
+	}
+	return "", ""
+}
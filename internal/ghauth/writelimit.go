@@ -0,0 +1,104 @@
+package ghauth
+
+import (
+	"context"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultWritesPerMinute paces write requests well below GitHub's secondary limit of 80 content-creating requests a
+// minute, as a pull request takes several: its creation, review requests, labels and comments
+const DefaultWritesPerMinute = 20
+
+// WriteLimiter paces the write requests of every client sharing it, one per interval plus a random jitter, so runs
+// opening many pull requests or issues do not trigger GitHub's abuse detection
+// Write requests queue in the order they are sent; reads are not paced
+type WriteLimiter struct {
+	interval time.Duration
+	// Jitter is the longest random delay added to each interval, so concurrent runs do not write in lockstep
+	Jitter time.Duration
+	// Sleep waits for a duration unless ctx is done; tests replace it to run without waiting
+	Sleep func(ctx context.Context, d time.Duration) error
+	now   func() time.Time
+
+	mu sync.Mutex
+	// next is when the next write request may be sent
+	next time.Time
+}
+
+// NewWriteLimiter creates a WriteLimiter sending at most perMinute write requests a minute, with a jitter of up to a
+// quarter of the interval between them; nil, which does not pace requests, when perMinute is not positive
+func NewWriteLimiter(perMinute int) *WriteLimiter {
+	if perMinute <= 0 {
+		return nil
+	}
+	interval := time.Minute / time.Duration(perMinute)
+	return &WriteLimiter{interval: interval, Jitter: interval / 4, Sleep: sleep, now: time.Now}
+}
+
+// Wait blocks until the next write request may be sent, or ctx is done
+func (l *WriteLimiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	now := l.now()
+	at := l.next
+	if at.Before(now) {
+		at = now
+	}
+	l.next = at.Add(l.interval + l.jitter())
+	l.mu.Unlock()
+
+	if wait := at.Sub(now); wait > 0 {
+		return l.Sleep(ctx, wait)
+	}
+	return nil
+}
+
+// Transport returns an http.RoundTripper pacing the write requests sent through base (or http.DefaultTransport);
+// base itself for a nil limiter
+func (l *WriteLimiter) Transport(base http.RoundTripper) http.RoundTripper {
+	if l == nil {
+		return base
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &writeLimitTransport{base: base, limiter: l}
+}
+
+// jitter returns a random delay of up to Jitter
+func (l *WriteLimiter) jitter() time.Duration {
+	if l.Jitter <= 0 {
+		return 0
+	}
+	return rand.N(l.Jitter + 1)
+}
+
+// writeLimitTransport waits for its limiter before sending write requests
+type writeLimitTransport struct {
+	base    http.RoundTripper
+	limiter *WriteLimiter
+}
+
+// RoundTrip performs the request, after its turn when it writes
+func (t *writeLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if writes(req) {
+		if err := t.limiter.Wait(req.Context()); err != nil {
+			return nil, err
+		}
+	}
+	return t.base.RoundTrip(req)
+}
+
+// writes reports whether a request changes anything, i.e. is not a GET, HEAD or OPTIONS request
+func writes(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	return true
+}
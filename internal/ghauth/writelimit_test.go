@@ -0,0 +1,81 @@
+package ghauth_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/ghauth"
+)
+
+var _ = Describe("WriteLimiter", func() {
+	var (
+		limiter *ghauth.WriteLimiter
+		slept   []time.Duration
+	)
+
+	BeforeEach(func() {
+		slept = nil
+		limiter = ghauth.NewWriteLimiter(20)
+		limiter.Jitter = 0
+		limiter.Sleep = func(ctx context.Context, d time.Duration) error {
+			slept = append(slept, d)
+			return ctx.Err()
+		}
+	})
+
+	It("should space writes by the interval, queued in order", func() {
+		ctx := context.Background()
+		for range 3 {
+			Expect(limiter.Wait(ctx)).To(Succeed())
+		}
+		Expect(slept).To(HaveLen(2))
+		Expect(slept[0]).To(BeNumerically("~", 3*time.Second, 100*time.Millisecond))
+		Expect(slept[1]).To(BeNumerically("~", 6*time.Second, 100*time.Millisecond))
+	})
+
+	It("should add up to the jitter to each interval", func() {
+		limiter.Jitter = time.Second
+		ctx := context.Background()
+		Expect(limiter.Wait(ctx)).To(Succeed())
+		Expect(limiter.Wait(ctx)).To(Succeed())
+		Expect(slept).To(HaveLen(1))
+		Expect(slept[0]).To(BeNumerically(">", 2900*time.Millisecond))
+		Expect(slept[0]).To(BeNumerically("<=", 4*time.Second))
+	})
+
+	It("should stop waiting when the context is done", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		Expect(limiter.Wait(ctx)).To(Succeed())
+		cancel()
+		Expect(limiter.Wait(ctx)).To(MatchError(context.Canceled))
+	})
+
+	It("should pace write requests only", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{}`))
+		}))
+		DeferCleanup(server.Close)
+		client := &http.Client{Transport: limiter.Transport(nil)}
+
+		for _, method := range []string{http.MethodGet, http.MethodPost, http.MethodGet, http.MethodPatch, http.MethodDelete} {
+			req, err := http.NewRequest(method, server.URL, nil)
+			Expect(err).NotTo(HaveOccurred())
+			resp, err := client.Do(req)
+			Expect(err).NotTo(HaveOccurred())
+			resp.Body.Close()
+		}
+		Expect(slept).To(HaveLen(2))
+	})
+
+	It("should not pace without a rate", func() {
+		Expect(ghauth.NewWriteLimiter(0)).To(BeNil())
+		var none *ghauth.WriteLimiter
+		Expect(none.Wait(context.Background())).To(Succeed())
+		Expect(none.Transport(http.DefaultTransport)).To(BeIdenticalTo(http.DefaultTransport))
+	})
+})
@@ -0,0 +1,93 @@
+package httpcache
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync/atomic"
+)
+
+// BlobsDir is the directory of the blob store under a cache directory
+const BlobsDir = "blobs"
+
+// blobSHA matches the SHA-1 object names of Git blobs
+var blobSHA = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// Blobs stores the contents of Git blobs by SHA on disk
+// A blob never changes, so stored contents are served without any request, not even a conditional one
+type Blobs struct {
+	dir  string
+	hits atomic.Int64
+}
+
+// NewBlobs creates a blob store in dir
+func NewBlobs(dir string) *Blobs {
+	return &Blobs{dir: dir}
+}
+
+// Get returns the stored content of a blob; contents that do not hash to their SHA are treated as missing
+// A nil store has no blobs
+func (b *Blobs) Get(sha string) ([]byte, bool) {
+	if b == nil || !blobSHA.MatchString(sha) {
+		return nil, false
+	}
+	content, err := os.ReadFile(b.path(sha))
+	if err != nil || objectName(content) != sha {
+		return nil, false
+	}
+	b.hits.Add(1)
+	return content, true
+}
+
+// Put stores the content of a blob, refusing content that does not hash to its SHA
+// A nil store stores nothing
+func (b *Blobs) Put(sha string, content []byte) error {
+	if b == nil {
+		return nil
+	}
+	if !blobSHA.MatchString(sha) || objectName(content) != sha {
+		return fmt.Errorf("content is not blob %s", sha)
+	}
+	path := b.path(sha)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".blob-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Hits returns the number of blobs served from the store so far
+func (b *Blobs) Hits() int64 {
+	if b == nil {
+		return 0
+	}
+	return b.hits.Load()
+}
+
+// path returns the file of a blob
+func (b *Blobs) path(sha string) string {
+	return filepath.Join(b.dir, sha[:2], sha)
+}
+
+// objectName returns the SHA Git names a blob of content with
+func objectName(content []byte) string {
+	hash := sha1.New()
+	fmt.Fprintf(hash, "blob %d\x00", len(content))
+	hash.Write(content)
+	return hex.EncodeToString(hash.Sum(nil))
+}
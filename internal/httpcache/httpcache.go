@@ -0,0 +1,209 @@
+package httpcache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Dir is the directory of the cache under the user's cache directory, e.g. ~/.cache/coverage-dashboard
+const Dir = "coverage-dashboard"
+
+// cachedHeaders are the response headers kept in the cache; rate limit counters and dates come from the
+// revalidating response instead
+var cachedHeaders = []string{"Content-Type", "Link", "ETag", "Last-Modified", "X-OAuth-Scopes"}
+
+// DefaultDir returns the cache directory under the user's cache directory, e.g. ~/.cache/coverage-dashboard,
+// or "" when the user has none
+func DefaultDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, Dir)
+}
+
+// Entry is a cached response
+type Entry struct {
+	URL      string              `json:"url"`
+	Header   map[string][]string `json:"header"`
+	Body     []byte              `json:"body"`
+	StoredAt time.Time           `json:"stored_at"`
+}
+
+// Stats counts how the cache answered requests
+type Stats struct {
+	// Revalidated counts requests answered from the cache after a 304 Not Modified, which GitHub does not
+	// count against the rate limit
+	Revalidated int64
+	// Stored counts responses stored or refreshed
+	Stored int64
+}
+
+// Transport is an http.RoundTripper that stores GET responses with an ETag or Last-Modified on disk and
+// revalidates them with conditional requests, serving the stored body when the server answers 304 Not Modified
+// Responses are keyed by URL and Accept header only: servers answer 304 only when the content the credentials
+// of the request can see is unchanged, so entries are never served to credentials that would get other content
+type Transport struct {
+	dir         string
+	base        http.RoundTripper
+	revalidated atomic.Int64
+	stored      atomic.Int64
+}
+
+// NewTransport creates a Transport caching in dir, sending requests through base (or http.DefaultTransport)
+func NewTransport(dir string, base http.RoundTripper) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Transport{dir: dir, base: base}
+}
+
+// Stats returns how the cache answered requests so far
+func (t *Transport) Stats() Stats {
+	return Stats{Revalidated: t.revalidated.Load(), Stored: t.stored.Load()}
+}
+
+// RoundTrip performs the request, conditionally when its response is cached
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || req.Header.Get("Range") != "" {
+		return t.base.RoundTrip(req)
+	}
+
+	path := t.path(req)
+	entry, cached := t.load(path)
+	if cached {
+		req = req.Clone(req.Context())
+		if etag := firstValue(entry.Header, "ETag"); etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		if modified := firstValue(entry.Header, "Last-Modified"); modified != "" {
+			req.Header.Set("If-Modified-Since", modified)
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached:
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		t.revalidated.Add(1)
+		return entry.response(req, resp.Header), nil
+	case resp.StatusCode == http.StatusOK && (resp.Header.Get("ETag") != "" || resp.Header.Get("Last-Modified") != ""):
+		return t.store(path, req, resp)
+	default:
+		return resp, nil
+	}
+}
+
+// store saves a response, returning it with its body still readable; failing to save leaves it uncached
+func (t *Transport) store(path string, req *http.Request, resp *http.Response) (*http.Response, error) {
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response of %s %s: %w", req.Method, req.URL.Path, err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	entry := Entry{URL: req.URL.String(), Header: make(map[string][]string), Body: body, StoredAt: time.Now().UTC()}
+	for _, name := range cachedHeaders {
+		if values := resp.Header.Values(name); len(values) > 0 {
+			entry.Header[name] = values
+		}
+	}
+	if err := t.save(path, entry); err == nil {
+		t.stored.Add(1)
+	}
+	return resp, nil
+}
+
+// response rebuilds the cached response of a request, with the headers of the revalidating response, e.g. its
+// rate limit counters, over the cached ones
+func (e Entry) response(req *http.Request, revalidated http.Header) *http.Response {
+	header := make(http.Header)
+	for name, values := range revalidated {
+		header[name] = values
+	}
+	for name, values := range e.Header {
+		header[name] = values
+	}
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
+
+// path returns the cache file of a request, named by a hash of its URL and Accept header
+func (t *Transport) path(req *http.Request) string {
+	hash := sha256.Sum256([]byte(req.URL.String() + "\n" + req.Header.Get("Accept")))
+	name := hex.EncodeToString(hash[:])
+	return filepath.Join(t.dir, name[:2], name+".json")
+}
+
+// load reads a cache entry; unreadable entries are treated as missing and replaced
+func (t *Transport) load(path string) (Entry, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Entry{}, false
+	}
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return Entry{}, false
+	}
+	return entry, true
+}
+
+// save writes a cache entry through a temporary file, so concurrent requests never read partial entries
+func (t *Transport) save(path string, entry Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".entry-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// firstValue returns the first value of a header of an entry
+func firstValue(header map[string][]string, name string) string {
+	for key, values := range header {
+		if strings.EqualFold(key, name) && len(values) > 0 {
+			return values[0]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,137 @@
+package httpcache_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/httpcache"
+)
+
+var _ = Describe("Transport", func() {
+	var (
+		server    *httptest.Server
+		etag      string
+		body      string
+		requests  []http.Header
+		transport *httpcache.Transport
+	)
+
+	BeforeEach(func() {
+		etag, body, requests = `"v1"`, `[{"name": "api"}]`, nil
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r.Header.Clone())
+			w.Header().Set("X-RateLimit-Remaining", "4999")
+			if r.URL.Path == "/plain" {
+				w.Write([]byte(`{}`))
+				return
+			}
+			if r.Header.Get("If-None-Match") == etag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", etag)
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(body))
+		}))
+		DeferCleanup(server.Close)
+		transport = httpcache.NewTransport(GinkgoT().TempDir(), nil)
+	})
+
+	get := func(path string) (*http.Response, string) {
+		req, err := http.NewRequest(http.MethodGet, server.URL+path, nil)
+		Expect(err).NotTo(HaveOccurred())
+		resp, err := transport.RoundTrip(req)
+		Expect(err).NotTo(HaveOccurred())
+		data, err := io.ReadAll(resp.Body)
+		Expect(err).NotTo(HaveOccurred())
+		return resp, string(data)
+	}
+
+	It("should revalidate cached responses and serve them when not modified", func() {
+		_, first := get("/orgs/konflux-ci/repos")
+		Expect(first).To(Equal(body))
+		Expect(requests[0].Get("If-None-Match")).To(BeEmpty())
+
+		resp, second := get("/orgs/konflux-ci/repos")
+		Expect(requests[1].Get("If-None-Match")).To(Equal(`"v1"`))
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(second).To(Equal(body))
+		Expect(resp.Header.Get("Content-Type")).To(Equal("application/json"))
+		Expect(resp.Header.Get("X-RateLimit-Remaining")).To(Equal("4999"))
+		Expect(transport.Stats()).To(Equal(httpcache.Stats{Revalidated: 1, Stored: 1}))
+	})
+
+	It("should replace cached responses that changed", func() {
+		get("/orgs/konflux-ci/repos")
+		etag, body = `"v2"`, `[{"name": "cli"}]`
+		_, changed := get("/orgs/konflux-ci/repos")
+		Expect(changed).To(Equal(body))
+
+		_, cached := get("/orgs/konflux-ci/repos")
+		Expect(requests[2].Get("If-None-Match")).To(Equal(`"v2"`))
+		Expect(cached).To(Equal(body))
+		Expect(transport.Stats()).To(Equal(httpcache.Stats{Revalidated: 1, Stored: 2}))
+	})
+
+	It("should keep entries across transports sharing the directory", func() {
+		dir := GinkgoT().TempDir()
+		transport = httpcache.NewTransport(dir, nil)
+		get("/orgs/konflux-ci/repos")
+		transport = httpcache.NewTransport(dir, nil)
+		_, cached := get("/orgs/konflux-ci/repos")
+		Expect(cached).To(Equal(body))
+		Expect(transport.Stats().Revalidated).To(Equal(int64(1)))
+	})
+
+	It("should not cache responses without validators or requests other than GET", func() {
+		get("/plain")
+		get("/plain")
+		Expect(requests[1].Get("If-None-Match")).To(BeEmpty())
+
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/graphql", nil)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = transport.RoundTrip(req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(transport.Stats().Stored).To(BeZero())
+	})
+})
+
+var _ = Describe("Blobs", func() {
+	// git hash-object of the content
+	const (
+		content = "* @test-org/api-team\n"
+		sha     = "13666c8c28323a3b8bab192eb32290f7721b9db1"
+	)
+
+	It("should serve stored blobs across stores sharing the directory", func() {
+		dir := GinkgoT().TempDir()
+		_, ok := httpcache.NewBlobs(dir).Get(sha)
+		Expect(ok).To(BeFalse())
+
+		Expect(httpcache.NewBlobs(dir).Put(sha, []byte(content))).To(Succeed())
+		blobs := httpcache.NewBlobs(dir)
+		stored, ok := blobs.Get(sha)
+		Expect(ok).To(BeTrue())
+		Expect(string(stored)).To(Equal(content))
+		Expect(blobs.Hits()).To(Equal(int64(1)))
+	})
+
+	It("should refuse contents that are not the blob and names that are no SHA", func() {
+		blobs := httpcache.NewBlobs(GinkgoT().TempDir())
+		Expect(blobs.Put(sha, []byte("* @someone-else\n"))).To(MatchError("content is not blob " + sha))
+		Expect(blobs.Put("../../etc/passwd", []byte(content))).To(HaveOccurred())
+		_, ok := blobs.Get("../../etc/passwd")
+		Expect(ok).To(BeFalse())
+	})
+
+	It("should store nothing without a directory", func() {
+		var blobs *httpcache.Blobs
+		Expect(blobs.Put(sha, []byte(content))).To(Succeed())
+		_, ok := blobs.Get(sha)
+		Expect(ok).To(BeFalse())
+	})
+})
@@ -0,0 +1,28 @@
+package interrupt
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// Context returns a context cancelled on SIGINT or SIGTERM, so commands can stop cleanly and keep partial results
+// After the first signal the default handling is restored, so a second one terminates the process immediately
+func Context() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		defer signal.Stop(signals)
+		select {
+		case sig := <-signals:
+			fmt.Fprintf(os.Stderr, "\n⏹️  Received %s, stopping (send it again to exit immediately)\n", sig)
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
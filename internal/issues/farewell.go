@@ -0,0 +1,48 @@
+package issues
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+)
+
+const (
+	// FarewellLabel marks the issue telling the owners of an offboarded repository it is no longer tracked
+	FarewellLabel = "coverage-dashboard-offboarded"
+
+	farewellLabelDescription = "Opened by the Konflux coverage dashboard when the repository is offboarded"
+)
+
+// Farewell opens an issue in an offboarded repository telling its owners the dashboard stops tracking it and when
+// its data is deleted, linking the pull request offboarding it. Teams are notified by the mention in the body
+func (t *Tracker) Farewell(ctx context.Context, offboarding config.Offboarding, pullRequest string) error {
+	owner, repo, ok := strings.Cut(offboarding.Repo, "/")
+	if !ok {
+		return fmt.Errorf("repository name must be in owner/name form, got %q", offboarding.Repo)
+	}
+	if err := t.ensureLabel(ctx, owner, repo, FarewellLabel, farewellLabelDescription); err != nil {
+		return err
+	}
+	return t.open(ctx, owner, repo, FarewellLabel, "Coverage dashboard tracking ends", FarewellBody(offboarding, pullRequest), offboarding.Owners)
+}
+
+// FarewellBody tells the owners of an offboarded repository what happens to its coverage data, as Markdown
+func FarewellBody(offboarding config.Offboarding, pullRequest string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "👋 **%s** is being offboarded from the [coverage dashboard](%s/) in %s. ", offboarding.Repo, DashboardURL, pullRequest)
+	b.WriteString("Once it is merged, its coverage is no longer collected, and no more regression or failure issues are opened here.\n\n")
+	if offboarding.DeleteAfter == nil {
+		b.WriteString("Its archived coverage reports and history are kept.\n\n")
+	} else {
+		fmt.Fprintf(&b, "Its archived coverage reports and history are deleted on the first collection run after %s.\n\n",
+			offboarding.DeleteAfter.UTC().Format("2006-01-02"))
+	}
+	b.WriteString("Thank you for tracking your coverage with us! Repositories can be added again with a pull request adding their configuration.\n")
+
+	if len(offboarding.Owners) > 0 {
+		fmt.Fprintf(&b, "\nOwners: %s\n", strings.Join(offboarding.Owners, " "))
+	}
+	return b.String()
+}
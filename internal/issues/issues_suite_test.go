@@ -0,0 +1,13 @@
+package issues_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestIssues(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Issues Suite")
+}
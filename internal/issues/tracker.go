@@ -0,0 +1,343 @@
+package issues
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-github/v66/github"
+	"github.com/konflux-ci/coverage-dashboard/internal/collect"
+	"github.com/konflux-ci/coverage-dashboard/internal/ghauth"
+	"github.com/konflux-ci/coverage-dashboard/internal/policy"
+)
+
+const (
+	// DefaultLabel marks the issues opened for coverage regressions, so later regressions update them
+	DefaultLabel = "coverage-regression"
+
+	// FailureLabel marks the issues opened for repositories whose coverage cannot be collected
+	FailureLabel = "coverage-collection-failure"
+
+	// DashboardURL is the published dashboard the issues link to
+	DashboardURL = "https://konflux-ci.dev/coverage-dashboard"
+
+	labelColor              = "d93f0b"
+	labelDescription        = "Opened by the Konflux coverage dashboard when coverage drops"
+	failureLabelDescription = "Opened by the Konflux coverage dashboard when coverage cannot be collected"
+)
+
+// Tracker opens or updates an issue in a repository when its coverage regresses
+type Tracker struct {
+	client  *github.Client
+	label   string
+	routing *policy.Policy
+	// webURL is the GitHub web host of the client's API, which issues link commits to
+	webURL string
+}
+
+// NewTracker creates a new Tracker deduplicating issues by the given label
+// The alert routes of the routing policy, if any, can disable issues or change the label per repository
+func NewTracker(client *github.Client, label string, routing *policy.Policy) *Tracker {
+	if label == "" {
+		label = DefaultLabel
+	}
+	if routing == nil {
+		routing = &policy.Policy{}
+	}
+	webURL := ghauth.DefaultWebURL
+	if client != nil && client.BaseURL != nil {
+		webURL = ghauth.WebURL(client.BaseURL.String())
+	}
+	return &Tracker{client: client, label: label, routing: routing, webURL: webURL}
+}
+
+// NotifyAlert opens, updates or closes the issue carrying the tracker's label in the regressed repository
+// Reminders and escalations comment on the open issue, or open a new one if it was closed in the meantime
+func (t *Tracker) NotifyAlert(ctx context.Context, alert collect.Alert) error {
+	owner, repo, ok := strings.Cut(alert.Regression.Repo, "/")
+	if !ok {
+		return fmt.Errorf("repository name must be in owner/name form, got %q", alert.Regression.Repo)
+	}
+
+	label := t.label
+	route, routed := t.routing.Route(alert.Regression.Repo, alert.Regression.Owners, alert.Regression.Groups)
+	if routed {
+		if route.Issues != nil && !*route.Issues {
+			return nil
+		}
+		if route.Label != "" {
+			label = route.Label
+		}
+	}
+
+	existing, err := t.findOpenIssue(ctx, owner, repo, label)
+	if err != nil {
+		return err
+	}
+
+	if alert.Event == collect.AlertResolved {
+		if existing == nil {
+			return nil
+		}
+		return t.close(ctx, owner, repo, existing, ResolvedBody(alert, t.webURL))
+	}
+
+	body := Body(alert.Regression, t.webURL)
+	if header := alertHeader(alert, route.EscalateTo); header != "" {
+		body = header + "\n\n" + body
+	}
+	if existing != nil {
+		if err := t.comment(ctx, owner, repo, existing, body); err != nil {
+			return err
+		}
+		fmt.Printf("    📝 Updated %s\n", existing.GetHTMLURL())
+		return nil
+	}
+
+	if err := t.ensureLabel(ctx, owner, repo, label, labelDescription); err != nil {
+		return err
+	}
+	return t.open(ctx, owner, repo, label, Title(alert.Regression), body, alert.Regression.Owners)
+}
+
+// NotifyFailure opens an issue carrying FailureLabel in a repository whose coverage could not be collected
+// for several runs, and closes it once coverage is collected again
+func (t *Tracker) NotifyFailure(ctx context.Context, alert collect.FailureAlert) error {
+	owner, repo, ok := strings.Cut(alert.Repo, "/")
+	if !ok {
+		return fmt.Errorf("repository name must be in owner/name form, got %q", alert.Repo)
+	}
+	if route, routed := t.routing.Route(alert.Repo, alert.Owners, alert.Groups); routed && route.Issues != nil && !*route.Issues {
+		return nil
+	}
+
+	existing, err := t.findOpenIssue(ctx, owner, repo, FailureLabel)
+	if err != nil {
+		return err
+	}
+
+	if alert.Event == collect.AlertResolved {
+		if existing == nil {
+			return nil
+		}
+		body := fmt.Sprintf("✅ Coverage of **%s** is collected again after %d failed runs since %s. Closing.\n",
+			alert.Repo, alert.Consecutive, alert.Since.Format("2006-01-02"))
+		return t.close(ctx, owner, repo, existing, body)
+	}
+
+	if existing != nil {
+		if err := t.comment(ctx, owner, repo, existing, FailureBody(alert)); err != nil {
+			return err
+		}
+		fmt.Printf("    📝 Updated %s\n", existing.GetHTMLURL())
+		return nil
+	}
+	if err := t.ensureLabel(ctx, owner, repo, FailureLabel, failureLabelDescription); err != nil {
+		return err
+	}
+	title := "Coverage collection failing since " + alert.Since.Format("2006-01-02")
+	return t.open(ctx, owner, repo, FailureLabel, title, FailureBody(alert), alert.Owners)
+}
+
+// open opens a labelled issue assigned to the owners that are users
+func (t *Tracker) open(ctx context.Context, owner, repo, label, title, body string, owners []string) error {
+	// Teams cannot be assigned; they are notified by the mention in the body instead
+	request := &github.IssueRequest{
+		Title:     github.String(title),
+		Body:      github.String(body),
+		Labels:    &[]string{label},
+		Assignees: &[]string{},
+	}
+	for _, o := range owners {
+		if user := strings.TrimPrefix(o, "@"); user != "" && !strings.Contains(user, "/") {
+			*request.Assignees = append(*request.Assignees, user)
+		}
+	}
+
+	issue, _, err := t.client.Issues.Create(ctx, owner, repo, request)
+	if err != nil {
+		return fmt.Errorf("failed to open issue: %w", err)
+	}
+	fmt.Printf("    📝 Opened %s\n", issue.GetHTMLURL())
+	return nil
+}
+
+// close comments on an issue and closes it as completed
+func (t *Tracker) close(ctx context.Context, owner, repo string, issue *github.Issue, body string) error {
+	if err := t.comment(ctx, owner, repo, issue, body); err != nil {
+		return err
+	}
+	state := &github.IssueRequest{State: github.String("closed"), StateReason: github.String("completed")}
+	if _, _, err := t.client.Issues.Edit(ctx, owner, repo, issue.GetNumber(), state); err != nil {
+		return fmt.Errorf("failed to close issue #%d: %w", issue.GetNumber(), err)
+	}
+	fmt.Printf("    📝 Closed %s\n", issue.GetHTMLURL())
+	return nil
+}
+
+// comment adds a comment to an issue
+func (t *Tracker) comment(ctx context.Context, owner, repo string, issue *github.Issue, body string) error {
+	comment := &github.IssueComment{Body: github.String(body)}
+	if _, _, err := t.client.Issues.CreateComment(ctx, owner, repo, issue.GetNumber(), comment); err != nil {
+		return fmt.Errorf("failed to update issue #%d: %w", issue.GetNumber(), err)
+	}
+	return nil
+}
+
+// findOpenIssue returns the open issue carrying the label, if any
+func (t *Tracker) findOpenIssue(ctx context.Context, owner, repo, label string) (*github.Issue, error) {
+	opts := &github.IssueListByRepoOptions{
+		State:       "open",
+		Labels:      []string{label},
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	issues, _, err := t.client.Issues.ListByRepo(ctx, owner, repo, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list issues of %s/%s: %w", owner, repo, err)
+	}
+
+	for _, issue := range issues {
+		// Pull requests are listed as issues too
+		if !issue.IsPullRequest() {
+			return issue, nil
+		}
+	}
+	return nil, nil
+}
+
+// ensureLabel creates the label in the repository if it does not exist yet
+func (t *Tracker) ensureLabel(ctx context.Context, owner, repo, label, description string) error {
+	_, resp, err := t.client.Issues.GetLabel(ctx, owner, repo, label)
+	if err == nil {
+		return nil
+	}
+	if resp == nil || resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("failed to read label %s: %w", label, err)
+	}
+
+	request := &github.Label{
+		Name:        github.String(label),
+		Color:       github.String(labelColor),
+		Description: github.String(description),
+	}
+	if _, _, err := t.client.Issues.CreateLabel(ctx, owner, repo, request); err != nil {
+		return fmt.Errorf("failed to create label %s: %w", label, err)
+	}
+	return nil
+}
+
+// Title is the title of the issue opened for a regression
+func Title(regression collect.Regression) string {
+	return "Coverage regression on " + regression.DetectedAt.Format("2006-01-02")
+}
+
+// Body describes a regression as Markdown, linking its commits to the GitHub web host webURL
+func Body(regression collect.Regression, webURL string) string {
+	var b strings.Builder
+	if regression.Kind == collect.KindBelowThreshold {
+		fmt.Fprintf(&b, "Test coverage of **%s** is %.1f%%, %.1f percentage points below its threshold of %.1f%%.\n\n",
+			regression.Repo, regression.Current, regression.Delta, regression.Threshold)
+	} else {
+		fmt.Fprintf(&b, "Test coverage of **%s** dropped from %.1f%% to %.1f%% (-%.1f percentage points, threshold %.1f).\n\n",
+			regression.Repo, regression.Previous, regression.Current, regression.Delta, regression.Threshold)
+	}
+
+	if regression.PreviousCommit != "" || regression.Commit != "" {
+		fmt.Fprintf(&b, "Measured between %s and %s.\n\n",
+			commitLink(webURL, regression.Repo, regression.PreviousCommit), commitLink(webURL, regression.Repo, regression.Commit))
+	}
+
+	if len(regression.Packages) > 0 {
+		b.WriteString("| Package | Previous | Current | Change |\n")
+		b.WriteString("|---|---:|---:|---:|\n")
+		for _, change := range regression.Packages {
+			pkg := strings.TrimPrefix(change.Package, "github.com/"+regression.Repo+"/")
+			fmt.Fprintf(&b, "| `%s` | %s | %s | %+.1f |\n", pkg, percent(change.Previous), percent(change.Current), change.Change())
+		}
+		b.WriteString("\n")
+	}
+
+	links := []string{fmt.Sprintf("[Coverage report](%s/coverage/%s/index.html)", DashboardURL, regression.Repo)}
+	if regression.RunURL != "" {
+		links = append(links, fmt.Sprintf("[Collection run](%s)", regression.RunURL))
+	}
+	b.WriteString(strings.Join(links, " · ") + "\n")
+
+	if len(regression.Owners) > 0 {
+		fmt.Fprintf(&b, "\nOwners: %s\n", strings.Join(regression.Owners, " "))
+	}
+	return b.String()
+}
+
+// FailureBody describes the failed collections of a repository as Markdown
+func FailureBody(alert collect.FailureAlert) string {
+	var b strings.Builder
+	if alert.Event == collect.AlertEscalated && alert.LastCollected != nil {
+		fmt.Fprintf(&b, "**Stale:** the last coverage was collected on %s, longer ago than the %s maximum age. %s, please take a look.\n\n",
+			alert.LastCollected.Format("2006-01-02"), alert.MaxAge, strings.Join(alert.Owners, " "))
+	}
+	fmt.Fprintf(&b, "Test coverage of **%s** could not be collected in the last %d runs, since %s, so the dashboard shows stale data.\n\n",
+		alert.Repo, alert.Consecutive, alert.Since.Format("2006-01-02"))
+	if alert.LastError != "" {
+		fmt.Fprintf(&b, "The last run ended with status `%s`:\n\n```\n%s\n```\n\n", alert.Status, alert.LastError)
+	}
+
+	links := []string{fmt.Sprintf("[Dashboard](%s/)", DashboardURL)}
+	if alert.RunURL != "" {
+		links = append(links, fmt.Sprintf("[Collection run](%s)", alert.RunURL))
+	}
+	b.WriteString(strings.Join(links, " · ") + "\n")
+
+	if len(alert.Owners) > 0 {
+		fmt.Fprintf(&b, "\nOwners: %s\n", strings.Join(alert.Owners, " "))
+	}
+	return b.String()
+}
+
+// alertHeader introduces reminders and escalations of an alert that is already open
+// Escalations mention the owners and the routed escalation contacts
+func alertHeader(alert collect.Alert, escalateTo []string) string {
+	since := alert.Since.Format("2006-01-02")
+	switch alert.Event {
+	case collect.AlertReminder:
+		return fmt.Sprintf("**Reminder:** coverage is still regressed, %d consecutive runs since %s.", alert.Violations, since)
+	case collect.AlertEscalated:
+		mentions := append(append([]string{}, alert.Regression.Owners...), escalateTo...)
+		return fmt.Sprintf("**Escalation:** coverage has been regressed for %d consecutive runs since %s. %s, please take a look.",
+			alert.Violations, since, strings.Join(mentions, " "))
+	}
+	return ""
+}
+
+// ResolvedBody announces that a repository recovered from a regression, linking its commit to the GitHub web host webURL
+func ResolvedBody(alert collect.Alert, webURL string) string {
+	regression := alert.Regression
+	detail := fmt.Sprintf("baseline %.1f%%, threshold %.1f", regression.Previous, regression.Threshold)
+	if regression.Kind == collect.KindBelowThreshold {
+		detail = fmt.Sprintf("threshold %.1f%%", regression.Threshold)
+	}
+	return fmt.Sprintf("✅ Coverage of **%s** recovered to %.1f%% (%s) at %s after %d regressed runs since %s. Closing.\n",
+		regression.Repo, regression.Current, detail,
+		commitLink(webURL, regression.Repo, regression.Commit), alert.Violations, alert.Since.Format("2006-01-02"))
+}
+
+// commitLink links a commit of a repository on the GitHub web host webURL, or describes it as unknown
+func commitLink(webURL, repo, commit string) string {
+	if commit == "" {
+		return "an unknown commit"
+	}
+	short := commit
+	if len(short) > 7 {
+		short = short[:7]
+	}
+	return fmt.Sprintf("[%s](%s/%s/commit/%s)", short, strings.TrimSuffix(webURL, "/"), repo, commit)
+}
+
+// percent formats a package coverage, or a dash for a missing package
+func percent(coverage *float64) string {
+	if coverage == nil {
+		return "—"
+	}
+	return fmt.Sprintf("%.1f%%", *coverage)
+}
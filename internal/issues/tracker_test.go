@@ -0,0 +1,261 @@
+package issues_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"time"
+
+	"github.com/google/go-github/v66/github"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/collect"
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+	"github.com/konflux-ci/coverage-dashboard/internal/issues"
+	"github.com/konflux-ci/coverage-dashboard/internal/policy"
+)
+
+var _ = Describe("Tracker", func() {
+	var (
+		server     *httptest.Server
+		tracker    *issues.Tracker
+		openIssues string
+		labelFound bool
+		label      string
+		requests   map[string]map[string]any
+		regression collect.Regression
+	)
+
+	BeforeEach(func() {
+		openIssues = `[]`
+		labelFound = true
+		label = issues.DefaultLabel
+		requests = make(map[string]map[string]any)
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Method + " " + r.URL.Path
+			if body, _ := io.ReadAll(r.Body); len(body) > 0 {
+				var decoded map[string]any
+				Expect(json.Unmarshal(body, &decoded)).To(Succeed())
+				requests[key] = decoded
+			}
+
+			switch key {
+			case "GET /repos/konflux-ci/api/issues":
+				Expect(r.URL.Query().Get("labels")).To(Equal(label))
+				Expect(r.URL.Query().Get("state")).To(Equal("open"))
+				fmt.Fprint(w, openIssues)
+			case "GET /repos/konflux-ci/api/labels/" + label:
+				if !labelFound {
+					w.WriteHeader(http.StatusNotFound)
+					fmt.Fprint(w, `{"message": "Not Found"}`)
+					return
+				}
+				fmt.Fprintf(w, `{"name": %q}`, label)
+			case "POST /repos/konflux-ci/api/labels":
+				w.WriteHeader(http.StatusCreated)
+				fmt.Fprintf(w, `{"name": %q}`, label)
+			case "POST /repos/konflux-ci/api/issues":
+				w.WriteHeader(http.StatusCreated)
+				fmt.Fprint(w, `{"number": 7, "html_url": "https://github.com/konflux-ci/api/issues/7"}`)
+			case "PATCH /repos/konflux-ci/api/issues/3":
+				fmt.Fprint(w, `{"number": 3, "state": "closed"}`)
+			case "POST /repos/konflux-ci/api/issues/3/comments":
+				w.WriteHeader(http.StatusCreated)
+				fmt.Fprint(w, `{"id": 1}`)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		client := github.NewClient(nil)
+		client.BaseURL, _ = url.Parse(server.URL + "/")
+		tracker = issues.NewTracker(client, "", nil)
+
+		previous, current := 71.5, 60.2
+		regression = collect.Regression{
+			Repo:           "konflux-ci/api",
+			Previous:       72.4,
+			Current:        64.1,
+			Delta:          8.3,
+			Threshold:      5,
+			PreviousCommit: "1111111aaaa",
+			Commit:         "2222222bbbb",
+			Owners:         []string{"@konflux-ci/vanguard", "@alice"},
+			Packages: []collect.PackageChange{
+				{Package: "github.com/konflux-ci/api/pkg/server", Previous: &previous, Current: &current},
+			},
+			RunURL:     "https://github.com/konflux-ci/coverage-dashboard/actions/runs/1",
+			DetectedAt: time.Date(2026, 10, 16, 3, 0, 0, 0, time.UTC),
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should open a labelled issue assigned to individual owners", func() {
+		labelFound = false
+		Expect(tracker.NotifyAlert(context.Background(), collect.Alert{Event: collect.AlertOpened, Regression: regression, Violations: 1})).To(Succeed())
+
+		Expect(requests).To(HaveKey("POST /repos/konflux-ci/api/labels"))
+		created := requests["POST /repos/konflux-ci/api/issues"]
+		Expect(created["title"]).To(Equal("Coverage regression on 2026-10-16"))
+		Expect(created["labels"]).To(Equal([]any{issues.DefaultLabel}))
+		Expect(created["assignees"]).To(Equal([]any{"alice"}))
+		Expect(created["body"]).To(ContainSubstring("Owners: @konflux-ci/vanguard @alice"))
+	})
+
+	It("should comment on the open issue instead of opening another", func() {
+		openIssues = `[{"number": 2, "pull_request": {"url": "https://example.com"}}, {"number": 3, "html_url": "https://github.com/konflux-ci/api/issues/3"}]`
+		alert := collect.Alert{Event: collect.AlertReminder, Regression: regression, Violations: 4, Since: time.Date(2026, 10, 12, 3, 0, 0, 0, time.UTC)}
+		Expect(tracker.NotifyAlert(context.Background(), alert)).To(Succeed())
+
+		Expect(requests).NotTo(HaveKey("POST /repos/konflux-ci/api/issues"))
+		comment := requests["POST /repos/konflux-ci/api/issues/3/comments"]["body"]
+		Expect(comment).To(HavePrefix("**Reminder:** coverage is still regressed, 4 consecutive runs since 2026-10-12."))
+		Expect(comment).To(ContainSubstring("dropped from 72.4% to 64.1%"))
+	})
+
+	It("should mention the owners when escalating", func() {
+		openIssues = `[{"number": 3}]`
+		Expect(tracker.NotifyAlert(context.Background(), collect.Alert{Event: collect.AlertEscalated, Regression: regression, Violations: 3, Since: time.Date(2026, 10, 14, 3, 0, 0, 0, time.UTC)})).To(Succeed())
+		Expect(requests["POST /repos/konflux-ci/api/issues/3/comments"]["body"]).To(ContainSubstring("3 consecutive runs since 2026-10-14. @konflux-ci/vanguard @alice, please take a look."))
+	})
+
+	It("should mention the escalation contacts of the matching route", func() {
+		openIssues = `[{"number": 3}]`
+		routing := &policy.Policy{Alerts: policy.Alerts{Routes: []policy.Route{
+			{Owners: []string{"@konflux-ci/vanguard"}, EscalateTo: []string{"@konflux-ci/leads"}},
+		}}}
+		client := github.NewClient(nil)
+		client.BaseURL, _ = url.Parse(server.URL + "/")
+		tracker = issues.NewTracker(client, "", routing)
+
+		Expect(tracker.NotifyAlert(context.Background(), collect.Alert{Event: collect.AlertEscalated, Regression: regression, Violations: 3})).To(Succeed())
+		Expect(requests["POST /repos/konflux-ci/api/issues/3/comments"]["body"]).To(ContainSubstring("@konflux-ci/vanguard @alice @konflux-ci/leads, please take a look."))
+	})
+
+	It("should not open issues for repositories routed away from issues", func() {
+		disabled := false
+		routing := &policy.Policy{Alerts: policy.Alerts{Routes: []policy.Route{{Repos: []string{"konflux-ci/*"}, Issues: &disabled}}}}
+		client := github.NewClient(nil)
+		client.BaseURL, _ = url.Parse(server.URL + "/")
+		tracker = issues.NewTracker(client, "", routing)
+
+		Expect(tracker.NotifyAlert(context.Background(), collect.Alert{Event: collect.AlertOpened, Regression: regression, Violations: 1})).To(Succeed())
+		Expect(requests).To(BeEmpty())
+	})
+
+	It("should close the open issue once coverage recovered", func() {
+		openIssues = `[{"number": 3}]`
+		regression.Current = 70.1
+		Expect(tracker.NotifyAlert(context.Background(), collect.Alert{Event: collect.AlertResolved, Regression: regression, Violations: 5})).To(Succeed())
+
+		Expect(requests["POST /repos/konflux-ci/api/issues/3/comments"]["body"]).To(ContainSubstring("recovered to 70.1% (baseline 72.4%, threshold 5.0)"))
+		Expect(requests["PATCH /repos/konflux-ci/api/issues/3"]).To(HaveKeyWithValue("state", "closed"))
+	})
+
+	It("should not open an issue for a recovery", func() {
+		Expect(tracker.NotifyAlert(context.Background(), collect.Alert{Event: collect.AlertResolved, Regression: regression})).To(Succeed())
+		Expect(requests).To(BeEmpty())
+	})
+
+	It("should describe the regression with commits and package changes", func() {
+		body := issues.Body(regression, "https://github.com/")
+		Expect(body).To(ContainSubstring("(-8.3 percentage points, threshold 5.0)"))
+		Expect(body).To(ContainSubstring("[1111111](https://github.com/konflux-ci/api/commit/1111111aaaa) and [2222222](https://github.com/konflux-ci/api/commit/2222222bbbb)"))
+		Expect(body).To(ContainSubstring("| `pkg/server` | 71.5% | 60.2% | -11.3 |"))
+		Expect(body).To(ContainSubstring("[Collection run](https://github.com/konflux-ci/coverage-dashboard/actions/runs/1)"))
+	})
+
+	It("should link commits to the GitHub host of the client's API", func() {
+		Expect(tracker.NotifyAlert(context.Background(), collect.Alert{Event: collect.AlertOpened, Regression: regression, Violations: 1})).To(Succeed())
+		Expect(requests["POST /repos/konflux-ci/api/issues"]["body"]).To(ContainSubstring("(" + server.URL + "/konflux-ci/api/commit/2222222bbbb)"))
+		Expect(issues.Body(regression, "https://github.example.com/")).To(ContainSubstring("(https://github.example.com/konflux-ci/api/commit/2222222bbbb)"))
+	})
+
+	Describe("NotifyFailure", func() {
+		var failure collect.FailureAlert
+
+		BeforeEach(func() {
+			label = issues.FailureLabel
+			failure = collect.FailureAlert{
+				Event:       collect.AlertOpened,
+				Repo:        "konflux-ci/api",
+				Owners:      []string{"@konflux-ci/vanguard", "@alice"},
+				Consecutive: 3,
+				Since:       time.Date(2026, 10, 14, 3, 0, 0, 0, time.UTC),
+				Status:      collect.StatusTimeout,
+				LastError:   "tests timed out after 30m0s",
+				RunURL:      "https://github.com/konflux-ci/coverage-dashboard/actions/runs/2",
+			}
+		})
+
+		It("should open an issue with the last error once collection keeps failing", func() {
+			labelFound = false
+			Expect(tracker.NotifyFailure(context.Background(), failure)).To(Succeed())
+
+			Expect(requests["POST /repos/konflux-ci/api/labels"]["name"]).To(Equal(issues.FailureLabel))
+			created := requests["POST /repos/konflux-ci/api/issues"]
+			Expect(created["title"]).To(Equal("Coverage collection failing since 2026-10-14"))
+			Expect(created["labels"]).To(Equal([]any{issues.FailureLabel}))
+			Expect(created["assignees"]).To(Equal([]any{"alice"}))
+			Expect(created["body"]).To(ContainSubstring("could not be collected in the last 3 runs"))
+			Expect(created["body"]).To(ContainSubstring("status `timeout`:\n\n```\ntests timed out after 30m0s\n```"))
+			Expect(created["body"]).To(ContainSubstring("[Collection run](https://github.com/konflux-ci/coverage-dashboard/actions/runs/2)"))
+		})
+
+		It("should comment on the open failure issue instead of opening another", func() {
+			openIssues = `[{"number": 3}]`
+			Expect(tracker.NotifyFailure(context.Background(), failure)).To(Succeed())
+			Expect(requests).To(HaveKey("POST /repos/konflux-ci/api/issues/3/comments"))
+			Expect(requests).NotTo(HaveKey("POST /repos/konflux-ci/api/issues"))
+		})
+
+		It("should close the failure issue once coverage is collected again", func() {
+			openIssues = `[{"number": 3}]`
+			failure.Event = collect.AlertResolved
+			Expect(tracker.NotifyFailure(context.Background(), failure)).To(Succeed())
+
+			Expect(requests["POST /repos/konflux-ci/api/issues/3/comments"]["body"]).To(ContainSubstring("collected again after 3 failed runs since 2026-10-14"))
+			Expect(requests["PATCH /repos/konflux-ci/api/issues/3"]).To(HaveKeyWithValue("state", "closed"))
+		})
+
+		It("should mention the owners once the coverage is stale", func() {
+			openIssues = `[{"number": 3}]`
+			lastCollected := time.Date(2026, 10, 10, 3, 0, 0, 0, time.UTC)
+			failure.Event = collect.AlertEscalated
+			failure.LastCollected = &lastCollected
+			failure.MaxAge = 72 * time.Hour
+			Expect(tracker.NotifyFailure(context.Background(), failure)).To(Succeed())
+
+			body := requests["POST /repos/konflux-ci/api/issues/3/comments"]["body"]
+			Expect(body).To(HavePrefix("**Stale:** the last coverage was collected on 2026-10-10, longer ago than the 72h0m0s maximum age. @konflux-ci/vanguard @alice, please take a look."))
+		})
+	})
+
+	Describe("Farewell", func() {
+		It("should tell the owners when the data of the offboarded repository is deleted", func() {
+			label = issues.FarewellLabel
+			labelFound = false
+			deleteAfter := time.Date(2027, 1, 14, 12, 0, 0, 0, time.UTC)
+			offboarding := config.Offboarding{
+				Removal:     config.Removal{Repo: "konflux-ci/api", Owners: []string{"@konflux-ci/vanguard", "@alice"}},
+				DeleteAfter: &deleteAfter,
+			}
+			Expect(tracker.Farewell(context.Background(), offboarding, "https://github.com/konflux-ci/coverage-dashboard/pull/9")).To(Succeed())
+
+			Expect(requests["POST /repos/konflux-ci/api/labels"]["name"]).To(Equal(issues.FarewellLabel))
+			created := requests["POST /repos/konflux-ci/api/issues"]
+			Expect(created["title"]).To(Equal("Coverage dashboard tracking ends"))
+			Expect(created["assignees"]).To(Equal([]any{"alice"}))
+			Expect(created["body"]).To(ContainSubstring("offboarded from the [coverage dashboard](https://konflux-ci.dev/coverage-dashboard/) in https://github.com/konflux-ci/coverage-dashboard/pull/9"))
+			Expect(created["body"]).To(ContainSubstring("deleted on the first collection run after 2027-01-14"))
+			Expect(created["body"]).To(ContainSubstring("Owners: @konflux-ci/vanguard @alice"))
+		})
+	})
+})
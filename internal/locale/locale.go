@@ -0,0 +1,126 @@
+package locale
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultTag is the locale of the site unless configured otherwise, with ISO dates
+const DefaultTag = "en-US"
+
+// Locale formats the numbers and dates shown on the site the way readers of a region expect
+// It matches the output of the browser's Intl formatting used by index.html for the same tag
+type Locale struct {
+	// Tag is the BCP 47 tag of the locale, e.g. "de-DE"
+	Tag string
+	// Decimal separates the integer and fractional parts of numbers
+	Decimal string
+	// Group separates thousands
+	Group string
+	// PercentSuffix follows percentages, with the space some languages put before the sign
+	PercentSuffix string
+	// DateLayout and DateTimeLayout are time.Format layouts
+	DateLayout     string
+	DateTimeLayout string
+}
+
+// locales are the supported locales by tag; spaces are the non-breaking ones browsers use
+var locales = map[string]Locale{
+	"en-US": {Tag: "en-US", Decimal: ".", Group: ",", PercentSuffix: "%", DateLayout: "01/02/2006", DateTimeLayout: "01/02/2006, 3:04 PM MST"},
+	"en-GB": {Tag: "en-GB", Decimal: ".", Group: ",", PercentSuffix: "%", DateLayout: "02/01/2006", DateTimeLayout: "02/01/2006, 15:04 MST"},
+	"de-DE": {Tag: "de-DE", Decimal: ",", Group: ".", PercentSuffix: "\u00a0%", DateLayout: "02.01.2006", DateTimeLayout: "02.01.2006, 15:04 MST"},
+	"fr-FR": {Tag: "fr-FR", Decimal: ",", Group: "\u202f", PercentSuffix: "\u202f%", DateLayout: "02/01/2006", DateTimeLayout: "02/01/2006 15:04 MST"},
+	"es-ES": {Tag: "es-ES", Decimal: ",", Group: ".", PercentSuffix: "\u00a0%", DateLayout: "02/01/2006", DateTimeLayout: "02/01/2006, 15:04 MST"},
+	"cs-CZ": {Tag: "cs-CZ", Decimal: ",", Group: "\u00a0", PercentSuffix: "\u00a0%", DateLayout: "02. 01. 2006", DateTimeLayout: "02. 01. 2006 15:04 MST"},
+	"ja-JP": {Tag: "ja-JP", Decimal: ".", Group: ",", PercentSuffix: "%", DateLayout: "2006/01/02", DateTimeLayout: "2006/01/02 15:04 MST"},
+	"zh-CN": {Tag: "zh-CN", Decimal: ".", Group: ",", PercentSuffix: "%", DateLayout: "2006/01/02", DateTimeLayout: "2006/01/02 15:04 MST"},
+}
+
+// Default returns the locale of DefaultTag with ISO dates, e.g. "2024-05-01", as used before locales were configurable
+func Default() Locale {
+	l := locales[DefaultTag]
+	l.DateLayout = "2006-01-02"
+	l.DateTimeLayout = "2006-01-02 15:04 MST"
+	return l
+}
+
+// Parse returns the locale of a tag such as "de-DE", "de_DE" or "de"; a language alone picks its first region
+// An empty tag is the default locale, with ISO dates unlike en-US given explicitly
+func Parse(tag string) (Locale, error) {
+	if tag == "" {
+		return Default(), nil
+	}
+	language, region, _ := strings.Cut(strings.ReplaceAll(tag, "_", "-"), "-")
+	language = strings.ToLower(language)
+	if region != "" {
+		if l, ok := locales[language+"-"+strings.ToUpper(region)]; ok {
+			return l, nil
+		}
+		return Locale{}, fmt.Errorf("unsupported locale %q, expected one of %s", tag, strings.Join(Tags(), ", "))
+	}
+	for _, supported := range Tags() {
+		if strings.HasPrefix(supported, language+"-") {
+			return locales[supported], nil
+		}
+	}
+	return Locale{}, fmt.Errorf("unsupported locale %q, expected one of %s", tag, strings.Join(Tags(), ", "))
+}
+
+// Lookup returns the locale of a tag, or the default locale for tags that are not supported
+func Lookup(tag string) Locale {
+	l, err := Parse(tag)
+	if err != nil {
+		return Default()
+	}
+	return l
+}
+
+// Tags lists the tags of the supported locales, sorted
+func Tags() []string {
+	tags := make([]string, 0, len(locales))
+	for tag := range locales {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// Number formats a number with a fixed number of decimals, e.g. "1.234,5" in de-DE
+func (l Locale) Number(value float64, decimals int) string {
+	formatted := strconv.FormatFloat(math.Abs(value), 'f', decimals, 64)
+	integer, fraction, _ := strings.Cut(formatted, ".")
+
+	var grouped strings.Builder
+	for i, digit := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			grouped.WriteString(l.Group)
+		}
+		grouped.WriteRune(digit)
+	}
+	if fraction != "" {
+		grouped.WriteString(l.Decimal + fraction)
+	}
+	if value < 0 && strings.Trim(formatted, "0.") != "" {
+		return "-" + grouped.String()
+	}
+	return grouped.String()
+}
+
+// Percent formats a percentage with one decimal, e.g. "72,5 %" in de-DE
+func (l Locale) Percent(value float64) string {
+	return l.Number(value, 1) + l.PercentSuffix
+}
+
+// Date formats the day of a time, e.g. "01.05.2024" in de-DE
+func (l Locale) Date(t time.Time) string {
+	return t.Format(l.DateLayout)
+}
+
+// DateTime formats a time to the minute with its zone, e.g. "01.05.2024, 06:30 UTC" in de-DE
+func (l Locale) DateTime(t time.Time) string {
+	return t.Format(l.DateTimeLayout)
+}
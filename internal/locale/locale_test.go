@@ -0,0 +1,55 @@
+package locale_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/locale"
+)
+
+var _ = Describe("Locale", func() {
+	moment := time.Date(2024, 5, 1, 18, 30, 0, 0, time.UTC)
+
+	It("should parse tags in any case and with underscores", func() {
+		l, err := locale.Parse("de_de")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(l.Tag).To(Equal("de-DE"))
+
+		l, err = locale.Parse("fr")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(l.Tag).To(Equal("fr-FR"))
+
+		l, err = locale.Parse("")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(l.Tag).To(Equal(locale.DefaultTag))
+	})
+
+	It("should reject unsupported locales and fall back to the default on lookup", func() {
+		_, err := locale.Parse("xx-YY")
+		Expect(err).To(MatchError(ContainSubstring(`unsupported locale "xx-YY"`)))
+		Expect(locale.Lookup("xx-YY").Tag).To(Equal(locale.DefaultTag))
+	})
+
+	It("should group thousands and separate decimals", func() {
+		Expect(locale.Default().Number(1234567.891, 2)).To(Equal("1,234,567.89"))
+		Expect(locale.Lookup("de-DE").Number(1234567.891, 2)).To(Equal("1.234.567,89"))
+		Expect(locale.Lookup("fr-FR").Number(-1234.5, 1)).To(Equal("-1\u202f234,5"))
+		Expect(locale.Default().Number(-0.01, 1)).To(Equal("0.0"))
+	})
+
+	It("should format percentages with one decimal", func() {
+		Expect(locale.Default().Percent(72.54)).To(Equal("72.5%"))
+		Expect(locale.Lookup("de-DE").Percent(72.54)).To(Equal("72,5\u00a0%"))
+	})
+
+	It("should format dates and times", func() {
+		Expect(locale.Default().Date(moment)).To(Equal("2024-05-01"))
+		Expect(locale.Default().DateTime(moment)).To(Equal("2024-05-01 18:30 UTC"))
+		Expect(locale.Lookup("en-US").Date(moment)).To(Equal("05/01/2024"))
+		Expect(locale.Lookup("en-US").DateTime(moment)).To(Equal("05/01/2024, 6:30 PM UTC"))
+		Expect(locale.Lookup("en-GB").Date(moment)).To(Equal("01/05/2024"))
+		Expect(locale.Lookup("ja-JP").DateTime(moment)).To(Equal("2024/05/01 18:30 UTC"))
+	})
+})
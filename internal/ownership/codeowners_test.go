@@ -0,0 +1,51 @@
+package ownership
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+)
+
+var _ = Describe("extractOwnersFromCodeowners", func() {
+	It("should only read the owners of path patterns", func() {
+		content := `# Ask @someone-else before editing
+* @konflux-ci/build @alice # and @bob
+/docs/ alice@example.com @konflux-ci/docs
+@not-a-pattern-owner
+/api/ @konflux-ci/build "@quoted" @evil"><script>
+`
+		Expect(extractOwnersFromCodeowners(content)).To(Equal([]string{"@konflux-ci/build", "@alice", "@konflux-ci/docs"}))
+	})
+
+	It("should stop at five owners", func() {
+		Expect(extractOwnersFromCodeowners("* @a @b @c\n/x/ @d @e @f @g\n")).To(Equal([]string{"@a", "@b", "@c", "@d", "@e"}))
+	})
+
+	It("should ignore content beyond GitHub's size limit", func() {
+		content := strings.Repeat("#\n", config.MaxCodeownersSize/2) + "* @late\n"
+		Expect(extractOwnersFromCodeowners(content)).To(BeEmpty())
+	})
+})
+
+func FuzzExtractOwners(f *testing.F) {
+	f.Add("* @konflux-ci/build @alice\n")
+	f.Add("# @comment\n/a/ @x/y/z @ok\r\n")
+	f.Add("* @a @a @b @c @d @e @f")
+	f.Fuzz(func(t *testing.T, content string) {
+		owners := extractOwnersFromCodeowners(content)
+		if len(owners) > 5 {
+			t.Fatalf("%d owners extracted, at most 5 expected", len(owners))
+		}
+		seen := make(map[string]bool)
+		for _, owner := range owners {
+			if seen[owner] || !ownerPattern.MatchString(owner) {
+				t.Fatalf("invalid or repeated owner %q in %v", owner, owners)
+			}
+			seen[owner] = true
+		}
+	})
+}
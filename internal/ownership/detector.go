@@ -2,10 +2,15 @@ package ownership
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 	"regexp"
+	"sort"
+	"strings"
 
 	"github.com/google/go-github/v66/github"
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+	"github.com/konflux-ci/coverage-dashboard/internal/httpcache"
 )
 
 // codeownersPaths defines the list of paths to check for CODEOWNERS files
@@ -24,18 +29,23 @@ func GetCodeownersPaths() []string {
 	return paths
 }
 
+// DefaultOwner owns repositories whose owners cannot be detected, unless another default is configured
+const DefaultOwner = "@konflux-ci/Vanguard"
+
 // Detector detects repository ownership using multiple strategies
 type Detector struct {
 	client       *github.Client
 	defaultOwner string
+	// blobs keeps the contents of the CODEOWNERS files fetched, by blob SHA; nil when disabled
+	blobs *httpcache.Blobs
 }
 
 // NewDetector creates a new ownership detector
 // defaultOwner specifies the fallback owner when no owners can be detected through other means
-// If empty, defaults to "@konflux-ci/Vanguard"
+// If empty, defaults to DefaultOwner
 func NewDetector(client *github.Client, defaultOwner string) *Detector {
 	if defaultOwner == "" {
-		defaultOwner = "@konflux-ci/Vanguard"
+		defaultOwner = DefaultOwner
 	}
 	return &Detector{
 		client:       client,
@@ -43,42 +53,113 @@ func NewDetector(client *github.Client, defaultOwner string) *Detector {
 	}
 }
 
-// DetectOwners detects repository owners using a fallback chain:
+// NewCachingDetector creates an ownership detector like NewDetector that keeps the CODEOWNERS files it fetches in
+// blobs, so later runs only fetch the files that changed
+func NewCachingDetector(client *github.Client, defaultOwner string, blobs *httpcache.Blobs) *Detector {
+	detector := NewDetector(client, defaultOwner)
+	detector.blobs = blobs
+	return detector
+}
+
+// Detection is the owners of a repository and how they were determined
+type Detection struct {
+	Owners []string
+	// Source is one of the config.OwnersSource values
+	Source string
+}
+
+// DetectOwners detects repository owners using the fallback chain of Detect
+func (d *Detector) DetectOwners(ctx context.Context, org, repo string) ([]string, error) {
+	detection, err := d.Detect(ctx, org, repo)
+	return detection.Owners, err
+}
+
+// Detect detects repository owners using a fallback chain:
 // 1. CODEOWNERS file (most authoritative)
 // 2. GitHub repository teams with admin/maintain permissions
 // 3. Individual collaborators with admin/maintain permissions
-// 4. Configured default owner (@konflux-ci/Vanguard if empty was provided to constructor)
-func (d *Detector) DetectOwners(ctx context.Context, org, repo string) ([]string, error) {
+// 4. Configured default owner (DefaultOwner if empty was provided to constructor)
+func (d *Detector) Detect(ctx context.Context, org, repo string) (Detection, error) {
+	return d.DetectWithTree(ctx, org, repo, nil)
+}
+
+// DetectWithTree detects repository owners like Detect, looking for CODEOWNERS files in the recursive tree of the
+// default branch listed beforehand; a nil tree is listed
+func (d *Detector) DetectWithTree(ctx context.Context, org, repo string, tree *github.Tree) (Detection, error) {
 	// Try CODEOWNERS file first
-	owners, err := d.detectFromCodeowners(ctx, org, repo)
+	owners, err := d.detectFromCodeowners(ctx, org, repo, tree)
 	if err == nil && len(owners) > 0 {
-		return owners, nil
+		return Detection{Owners: owners, Source: config.OwnersSourceCodeowners}, nil
 	}
 
+	return d.detectFromPermissions(ctx, org, repo)
+}
+
+// DetectWithCodeowners detects repository owners like Detect, from the contents of the repository's
+// CODEOWNERS files fetched beforehand, in the order of GetCodeownersPaths, instead of fetching them
+func (d *Detector) DetectWithCodeowners(ctx context.Context, org, repo string, codeowners []string) (Detection, error) {
+	if owners := CodeownersOwners(codeowners); len(owners) > 0 {
+		return Detection{Owners: owners, Source: config.OwnersSourceCodeowners}, nil
+	}
+	return d.detectFromPermissions(ctx, org, repo)
+}
+
+// CodeownersOwners returns the owners of the first of the contents of CODEOWNERS files naming any, in priority
+// order, e.g. of files fetched from another forge than GitHub
+func CodeownersOwners(codeowners []string) []string {
+	for _, content := range codeowners {
+		if owners := extractOwnersFromCodeowners(content); len(owners) > 0 {
+			return owners
+		}
+	}
+	return nil
+}
+
+// detectFromPermissions detects the owners of a repository without CODEOWNERS owners from its teams,
+// then its collaborators, falling back to the default owner
+func (d *Detector) detectFromPermissions(ctx context.Context, org, repo string) (Detection, error) {
 	// Fallback to repository teams
-	owners, err = d.detectFromTeams(ctx, org, repo)
+	owners, err := d.detectFromTeams(ctx, org, repo)
 	if err == nil && len(owners) > 0 {
-		return owners, nil
+		return Detection{Owners: owners, Source: config.OwnersSourceTeams}, nil
 	}
 
 	// Fallback to individual collaborators
 	owners, err = d.detectFromCollaborators(ctx, org, repo)
 	if err == nil && len(owners) > 0 {
-		return owners, nil
+		return Detection{Owners: owners, Source: config.OwnersSourceCollaborators}, nil
 	}
 
 	// Final fallback to configured default owner
-	return []string{d.defaultOwner}, nil
+	return Detection{Owners: []string{d.defaultOwner}, Source: config.OwnersSourceDefault}, nil
 }
 
 // detectFromCodeowners attempts to find owners in CODEOWNERS file
 // Checks multiple standard locations in priority order
-func (d *Detector) detectFromCodeowners(ctx context.Context, org, repo string) ([]string, error) {
+// The tree of the default branch tells which locations have one, so only existing files are fetched, by blob SHA;
+// without a complete tree, every location is fetched
+func (d *Detector) detectFromCodeowners(ctx context.Context, org, repo string, tree *github.Tree) ([]string, error) {
 	var lastErr error
 
+	if tree == nil && d.client != nil {
+		// Listing fails for empty repositories, among others; fetching every location reports why
+		tree, _, _ = d.client.Git.GetTree(ctx, org, repo, "HEAD", true)
+	}
+	listed := tree != nil && !tree.GetTruncated()
+
 	// Try each CODEOWNERS path in order
 	for _, path := range codeownersPaths {
-		content, err := d.fetchFile(ctx, org, repo, path)
+		var content string
+		var err error
+		if listed {
+			entry := blobEntry(tree, path)
+			if entry == nil {
+				continue
+			}
+			content, err = d.fetchBlob(ctx, org, repo, path, entry)
+		} else {
+			content, err = d.fetchFile(ctx, org, repo, path)
+		}
 		if err != nil {
 			lastErr = err
 			continue
@@ -113,6 +194,9 @@ func (d *Detector) detectFromTeams(ctx context.Context, org, repo string) ([]str
 		return nil, fmt.Errorf("failed to list teams for %s/%s: %w", org, repo, err)
 	}
 
+	// The API does not guarantee an order; sort so the same teams are picked every run
+	sort.Slice(teams, func(i, j int) bool { return teams[i].GetSlug() < teams[j].GetSlug() })
+
 	var owners []string
 	for _, team := range teams {
 		// Only include teams with admin or maintain permissions
@@ -148,6 +232,8 @@ func (d *Detector) detectFromCollaborators(ctx context.Context, org, repo string
 		return nil, fmt.Errorf("failed to list collaborators for %s/%s: %w", org, repo, err)
 	}
 
+	sort.Slice(collaborators, func(i, j int) bool { return collaborators[i].GetLogin() < collaborators[j].GetLogin() })
+
 	var owners []string
 	for _, collab := range collaborators {
 		// Only include collaborators with admin or maintain permissions
@@ -182,6 +268,9 @@ func (d *Detector) fetchFile(ctx context.Context, org, repo, path string) (strin
 	if fileContent == nil {
 		return "", fmt.Errorf("file %s exists but content is nil", path)
 	}
+	if fileContent.GetSize() > config.MaxCodeownersSize {
+		return "", fmt.Errorf("file %s is larger than %d bytes", path, config.MaxCodeownersSize)
+	}
 
 	content, err := fileContent.GetContent()
 	if err != nil {
@@ -191,21 +280,76 @@ func (d *Detector) fetchFile(ctx context.Context, org, repo, path string) (strin
 	return content, nil
 }
 
+// blobEntry returns the file at path of a tree, nil when there is none
+func blobEntry(tree *github.Tree, path string) *github.TreeEntry {
+	for _, entry := range tree.Entries {
+		if entry.GetType() == "blob" && entry.GetPath() == path {
+			return entry
+		}
+	}
+	return nil
+}
+
+// fetchBlob fetches the file of a tree entry by its blob SHA, from the blob store when it was fetched before
+func (d *Detector) fetchBlob(ctx context.Context, org, repo, path string, entry *github.TreeEntry) (string, error) {
+	if entry.GetSize() > config.MaxCodeownersSize {
+		return "", fmt.Errorf("file %s is larger than %d bytes", path, config.MaxCodeownersSize)
+	}
+	if content, ok := d.blobs.Get(entry.GetSHA()); ok {
+		return string(content), nil
+	}
+
+	blob, _, err := d.client.Git.GetBlob(ctx, org, repo, entry.GetSHA())
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch %s: %w", path, err)
+	}
+	content := []byte(blob.GetContent())
+	if blob.GetEncoding() == "base64" {
+		// GitHub wraps base64 content in lines
+		if content, err = base64.StdEncoding.DecodeString(strings.ReplaceAll(blob.GetContent(), "\n", "")); err != nil {
+			return "", fmt.Errorf("failed to decode content from %s: %w", path, err)
+		}
+	}
+	// Failing to store only costs a fetch on the next run
+	d.blobs.Put(entry.GetSHA(), content)
+	return string(content), nil
+}
+
+// ownerPattern matches a whole CODEOWNERS owner: a user or an org/team
+var ownerPattern = regexp.MustCompile(`^@[a-zA-Z0-9_-]+(/[a-zA-Z0-9_-]+)?$`)
+
+// ValidOwner reports whether owner is a CODEOWNERS owner, @user or @org/team
+func ValidOwner(owner string) bool {
+	return ownerPattern.MatchString(owner)
+}
+
 // extractOwnersFromCodeowners parses CODEOWNERS content and extracts owner references
+// Only owners of path patterns count, not handles mentioned in comments, and content beyond the
+// size GitHub reads is ignored
 func extractOwnersFromCodeowners(content string) []string {
-	ownerPattern := regexp.MustCompile(`@[a-zA-Z0-9_-]+(/[a-zA-Z0-9_-]+)?`)
-
-	matches := ownerPattern.FindAllString(content, -1)
+	if len(content) > config.MaxCodeownersSize {
+		content = content[:config.MaxCodeownersSize]
+	}
 
 	// Deduplicate and limit to 5
 	seen := make(map[string]bool)
 	var owners []string
-	for _, match := range matches {
-		if !seen[match] {
-			seen[match] = true
-			owners = append(owners, match)
+	for content != "" {
+		var line string
+		line, content, _ = strings.Cut(content, "\n")
+		line, _, _ = strings.Cut(line, "#")
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		for _, owner := range fields[1:] {
+			if seen[owner] || !ownerPattern.MatchString(owner) {
+				continue
+			}
+			seen[owner] = true
+			owners = append(owners, owner)
 			if len(owners) >= 5 {
-				break
+				return owners
 			}
 		}
 	}
@@ -2,6 +2,8 @@ package ownership_test
 
 import (
 	"context"
+	"crypto/sha1"
+	"encoding/base64"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -11,6 +13,8 @@ import (
 	. "github.com/onsi/gomega"
 	"github.com/google/go-github/v66/github"
 
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+	"github.com/konflux-ci/coverage-dashboard/internal/httpcache"
 	"github.com/konflux-ci/coverage-dashboard/internal/ownership"
 )
 
@@ -186,6 +190,28 @@ var _ = Describe("Detector", func() {
 				Expect(err).NotTo(HaveOccurred())
 				Expect(owners).To(Equal([]string{"@konflux-ci/Vanguard"}))
 			})
+
+			It("should report which source the owners were detected from", func() {
+				for org, source := range map[string]string{
+					"org":      config.OwnersSourceTeams,
+					"no-teams": config.OwnersSourceCollaborators,
+					"no-perms": config.OwnersSourceDefault,
+				} {
+					detection, err := detector.Detect(ctx, org, "repo")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(detection.Source).To(Equal(source), org)
+				}
+			})
+
+			It("should detect owners from CODEOWNERS files fetched beforehand", func() {
+				detection, err := detector.DetectWithCodeowners(ctx, "org", "repo", []string{"# no owners\n", "* @org/prefetched-team\n"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(detection).To(Equal(ownership.Detection{Owners: []string{"@org/prefetched-team"}, Source: config.OwnersSourceCodeowners}))
+
+				detection, err = detector.DetectWithCodeowners(ctx, "org", "repo", nil)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(detection.Source).To(Equal(config.OwnersSourceTeams))
+			})
 		})
 
 		Context("when GitHub API returns errors", func() {
@@ -209,6 +235,51 @@ var _ = Describe("Detector", func() {
 			})
 		})
 
+		Context("with the tree of the repository", func() {
+			const codeowners = "* @org/tree-team\n"
+			var (
+				sha      string
+				requests []string
+			)
+
+			BeforeEach(func() {
+				sha = fmt.Sprintf("%x", sha1.Sum([]byte(fmt.Sprintf("blob %d\x00%s", len(codeowners), codeowners))))
+				requests = nil
+				server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					requests = append(requests, r.URL.Path)
+					switch r.URL.Path {
+					case "/repos/org/repo/git/trees/HEAD":
+						Expect(r.URL.Query().Get("recursive")).To(Equal("1"))
+						fmt.Fprintf(w, `{"tree": [{"path": "docs/CODEOWNERS", "type": "blob", "sha": %q, "size": %d}]}`, sha, len(codeowners))
+					case "/repos/org/repo/git/blobs/" + sha:
+						fmt.Fprintf(w, `{"content": %q, "encoding": "base64"}`, base64.StdEncoding.EncodeToString([]byte(codeowners)))
+					default:
+						http.NotFound(w, r)
+					}
+				}))
+
+				baseURL, _ := url.Parse(server.URL + "/")
+				client = github.NewClient(nil)
+				client.BaseURL = baseURL
+			})
+
+			It("should only fetch the CODEOWNERS files of the tree, once per blob", func() {
+				blobs := httpcache.NewBlobs(GinkgoT().TempDir())
+				detection, err := ownership.NewCachingDetector(client, "", blobs).Detect(ctx, "org", "repo")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(detection).To(Equal(ownership.Detection{Owners: []string{"@org/tree-team"}, Source: config.OwnersSourceCodeowners}))
+				Expect(requests).To(Equal([]string{"/repos/org/repo/git/trees/HEAD", "/repos/org/repo/git/blobs/" + sha}))
+
+				requests = nil
+				tree := &github.Tree{Entries: []*github.TreeEntry{{Path: github.String("docs/CODEOWNERS"), Type: github.String("blob"), SHA: github.String(sha)}}}
+				detection, err = ownership.NewCachingDetector(client, "", blobs).DetectWithTree(ctx, "org", "repo", tree)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(detection.Owners).To(Equal([]string{"@org/tree-team"}))
+				Expect(requests).To(BeEmpty())
+				Expect(blobs.Hits()).To(Equal(int64(1)))
+			})
+		})
+
 	})
 
 
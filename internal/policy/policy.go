@@ -0,0 +1,414 @@
+package policy
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+	"github.com/konflux-ci/coverage-dashboard/internal/features"
+	"github.com/konflux-ci/coverage-dashboard/internal/ownership"
+)
+
+// Repository configuration fields a policy can allow repositories to override
+const (
+	FieldExcludeDirs        = "exclude_dirs"
+	FieldExcludeFiles       = "exclude_files"
+	FieldTimeout            = "timeout"
+	FieldRegressionDelta    = "regression_delta"
+	FieldMinCoverage        = "min_coverage"
+	FieldRatchet            = "ratchet"
+	FieldIncludeTestHelpers = "include_test_helpers"
+	FieldMaxAge             = "max_age"
+)
+
+// knownFields lists every overridable field; a policy without an overridable list allows all of them
+var knownFields = []string{FieldExcludeDirs, FieldExcludeFiles, FieldTimeout, FieldRegressionDelta, FieldMinCoverage, FieldRatchet, FieldIncludeTestHelpers, FieldMaxAge}
+
+// Policy holds the organization-wide rules applied to every repository configuration
+type Policy struct {
+	Defaults   Defaults      `yaml:"defaults"`
+	Exclusions ExclusionCaps `yaml:"exclusions"`
+	Alerts     Alerts        `yaml:"alerts"`
+	// Overridable lists the fields repository configurations may set; other fields fall back to the defaults
+	Overridable []string `yaml:"overridable"`
+	// Rego, when set, adds gates written in Rego
+	Rego *Rego `yaml:"rego,omitempty"`
+	// Reports decides how long commit-stamped reports are kept
+	Reports Reports `yaml:"reports"`
+	// Discovery configures the configurations discover-repos proposes
+	Discovery Discovery `yaml:"discovery"`
+	// Headline decides which repositories the organization's coverage headline combines, and how
+	Headline Headline `yaml:"headline"`
+	// Features switches risky behaviors on or off by flag name, overridden by the COVERAGE_FEATURES variable
+	Features map[string]bool `yaml:"features,omitempty"`
+}
+
+// Defaults apply to repositories that do not set the field themselves
+type Defaults struct {
+	MinCoverage     *float64              `yaml:"min_coverage,omitempty"`
+	RegressionDelta *float64              `yaml:"regression_delta,omitempty"`
+	Ratchet         *config.RatchetConfig `yaml:"ratchet,omitempty"`
+	Timeout         string                `yaml:"timeout,omitempty"`
+	MaxAge          string                `yaml:"max_age,omitempty"`
+}
+
+// ExclusionCaps bound how much a repository may exclude from its coverage; zero means unlimited
+type ExclusionCaps struct {
+	MaxExcludeDirs  int `yaml:"max_exclude_dirs"`
+	MaxExcludeFiles int `yaml:"max_exclude_files"`
+}
+
+// Alerts configures how regression alerts are repeated and where they are sent
+type Alerts struct {
+	Cooldown      string  `yaml:"cooldown,omitempty"`
+	EscalateAfter *int    `yaml:"escalate_after,omitempty"`
+	Routes        []Route `yaml:"routes,omitempty"`
+	// FailureAlertAfter is the number of consecutive failed collections after which owners are alerted
+	FailureAlertAfter *int `yaml:"failure_alert_after,omitempty"`
+}
+
+// Reports configures the retention of commit-stamped reports
+type Reports struct {
+	// Retention prunes reports older than it, e.g. 2160h; empty keeps them forever
+	Retention string `yaml:"retention,omitempty"`
+	// Keep is the number of most recent reports kept regardless of their age
+	Keep *int `yaml:"keep,omitempty"`
+}
+
+// Discovery configures repository discovery
+type Discovery struct {
+	// DefaultOwner owns discovered repositories whose owners cannot be detected, e.g. @konflux-ci/vanguard
+	DefaultOwner string `yaml:"default_owner,omitempty"`
+	// ReviewRotation requests review of the pull requests of these teams, by @org/team, from their members in turn
+	// instead of the whole team
+	ReviewRotation map[string]ReviewRotation `yaml:"review_rotation,omitempty"`
+}
+
+// ReviewRotation spreads the review requests of a team over its members
+type ReviewRotation struct {
+	// Reviewers is the number of members requested per pull request; one when unset
+	Reviewers int `yaml:"reviewers,omitempty"`
+	// Skip lists members never requested, e.g. managers or bots, as @user
+	Skip []string `yaml:"skip,omitempty"`
+}
+
+// Weights of repositories in the coverage headline
+const (
+	// WeightStatements weighs repositories by their number of statements, as if they were one codebase
+	WeightStatements = "statements"
+	// WeightRepository weighs every repository the same
+	WeightRepository = "repository"
+)
+
+// Headline configures the coverage headline combining the tracked repositories into one number
+type Headline struct {
+	// Label is the label of the headline badge; defaults to "Konflux coverage"
+	Label string `yaml:"label,omitempty"`
+	// Weight is WeightStatements (the default) or WeightRepository
+	Weight string `yaml:"weight,omitempty"`
+	// Exclude lists org/name glob patterns of repositories left out, e.g. archived or paused ones
+	Exclude []string `yaml:"exclude,omitempty"`
+	// ExcludeGroups lists groups of groups.yaml whose repositories are left out
+	ExcludeGroups []string `yaml:"exclude_groups,omitempty"`
+	// IncludeStale keeps repositories whose coverage is stale, left out by default as their collection is paused or broken
+	IncludeStale bool `yaml:"include_stale,omitempty"`
+}
+
+// DefaultHeadlineLabel is the label of the headline badge unless the policy sets one
+const DefaultHeadlineLabel = "Konflux coverage"
+
+// BadgeLabel returns the label of the headline badge
+func (h Headline) BadgeLabel() string {
+	if h.Label != "" {
+		return h.Label
+	}
+	return DefaultHeadlineLabel
+}
+
+// Weighting returns how repositories weigh in the headline
+func (h Headline) Weighting() string {
+	if h.Weight != "" {
+		return h.Weight
+	}
+	return WeightStatements
+}
+
+// Excludes returns why the headline leaves a repository out, or "" when it is included
+func (h Headline) Excludes(repo string, groups []string, stale bool) string {
+	for _, pattern := range h.Exclude {
+		if matched, _ := path.Match(pattern, repo); matched {
+			return fmt.Sprintf("excluded by %q", pattern)
+		}
+	}
+	for _, group := range h.ExcludeGroups {
+		if contains(groups, group) {
+			return fmt.Sprintf("in excluded group %q", group)
+		}
+	}
+	if stale && !h.IncludeStale {
+		return "stale"
+	}
+	return ""
+}
+
+// Route sends the alerts of matching repositories; the first matching route wins
+type Route struct {
+	// Repos are org/name glob patterns, Owners CODEOWNERS owners and Groups names of groups.yaml;
+	// a route matches any of them
+	Repos  []string `yaml:"repos,omitempty"`
+	Owners []string `yaml:"owners,omitempty"`
+	Groups []string `yaml:"groups,omitempty"`
+	// Issues disables (false) issues for matching repositories
+	Issues *bool  `yaml:"issues,omitempty"`
+	Label  string `yaml:"label,omitempty"`
+	// EscalateTo is mentioned in addition to the owners when an alert escalates
+	EscalateTo []string `yaml:"escalate_to,omitempty"`
+}
+
+// Load reads a policy file; a missing file yields the empty policy
+func Load(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &Policy{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy %s: %w", path, err)
+	}
+
+	var p Policy
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse policy %s: %w", path, err)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid policy %s: %w", path, err)
+	}
+	return &p, nil
+}
+
+// Validate checks the policy's own values
+func (p *Policy) Validate() error {
+	defaults := config.RepositoryConfig{
+		MinCoverage:     p.Defaults.MinCoverage,
+		RegressionDelta: p.Defaults.RegressionDelta,
+		Ratchet:         p.Defaults.Ratchet,
+		MaxAge:          p.Defaults.MaxAge,
+	}
+	if err := defaults.Validate(); err != nil {
+		return fmt.Errorf("defaults: %w", err)
+	}
+	if p.Defaults.Ratchet != nil && p.Defaults.Ratchet.Reset != nil {
+		return fmt.Errorf("defaults: ratchet resets belong in repository configurations")
+	}
+	if p.Defaults.Timeout != "" {
+		if _, err := time.ParseDuration(p.Defaults.Timeout); err != nil {
+			return fmt.Errorf("defaults: invalid timeout: %w", err)
+		}
+	}
+	if p.Exclusions.MaxExcludeDirs < 0 || p.Exclusions.MaxExcludeFiles < 0 {
+		return fmt.Errorf("exclusions: caps must not be negative")
+	}
+	if p.Alerts.FailureAlertAfter != nil && *p.Alerts.FailureAlertAfter < 0 {
+		return fmt.Errorf("alerts: failure_alert_after must not be negative")
+	}
+	if _, err := p.Cooldown(); err != nil {
+		return err
+	}
+	if _, err := p.Retention(); err != nil {
+		return err
+	}
+	if p.Reports.Keep != nil && *p.Reports.Keep < 1 {
+		return fmt.Errorf("reports: keep must be at least 1")
+	}
+	if owner := p.Discovery.DefaultOwner; owner != "" && !ownership.ValidOwner(owner) {
+		return fmt.Errorf("discovery: default owner %q is not a @user or @org/team", owner)
+	}
+	for team, rotation := range p.Discovery.ReviewRotation {
+		if !ownership.ValidOwner(team) || !strings.Contains(team, "/") {
+			return fmt.Errorf("discovery: review rotation of %q: not an @org/team", team)
+		}
+		if rotation.Reviewers < 0 {
+			return fmt.Errorf("discovery: review rotation of %s: reviewers must not be negative", team)
+		}
+		for _, member := range rotation.Skip {
+			if !ownership.ValidOwner(member) || strings.Contains(member, "/") {
+				return fmt.Errorf("discovery: review rotation of %s: skipped member %q is not a @user", team, member)
+			}
+		}
+	}
+	for _, field := range p.Overridable {
+		if !contains(knownFields, field) {
+			return fmt.Errorf("overridable: unknown field %q (known: %s)", field, strings.Join(knownFields, ", "))
+		}
+	}
+	if p.Rego != nil {
+		for _, file := range p.Rego.Files {
+			if _, err := os.Stat(file); err != nil {
+				return fmt.Errorf("rego: %w", err)
+			}
+		}
+	}
+	if weight := p.Headline.Weighting(); weight != WeightStatements && weight != WeightRepository {
+		return fmt.Errorf("headline: weight must be %q or %q, got %q", WeightStatements, WeightRepository, weight)
+	}
+	for _, pattern := range p.Headline.Exclude {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("headline: invalid pattern %q: %w", pattern, err)
+		}
+	}
+	for i, route := range p.Alerts.Routes {
+		for _, pattern := range route.Repos {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("alerts: route %d: invalid pattern %q: %w", i+1, pattern, err)
+			}
+		}
+	}
+	if _, err := features.Resolve(p.Features, ""); err != nil {
+		return fmt.Errorf("features: %w", err)
+	}
+	return nil
+}
+
+// Cooldown returns the alert cool-down, zero when the policy does not set one
+func (p *Policy) Cooldown() (time.Duration, error) {
+	if p.Alerts.Cooldown == "" {
+		return 0, nil
+	}
+	cooldown, err := time.ParseDuration(p.Alerts.Cooldown)
+	if err != nil {
+		return 0, fmt.Errorf("alerts: invalid cooldown: %w", err)
+	}
+	return cooldown, nil
+}
+
+// Retention returns the retention of commit-stamped reports, zero when the policy does not set one
+func (p *Policy) Retention() (time.Duration, error) {
+	if p.Reports.Retention == "" {
+		return 0, nil
+	}
+	retention, err := time.ParseDuration(p.Reports.Retention)
+	if err != nil {
+		return 0, fmt.Errorf("reports: invalid retention: %w", err)
+	}
+	return retention, nil
+}
+
+// Allows reports whether repositories may override a field
+func (p *Policy) Allows(field string) bool {
+	return p.Overridable == nil || contains(p.Overridable, field)
+}
+
+// Apply returns the effective configuration of a repository under the policy, together with every
+// rule it breaks: overrides of fields that are not overridable are replaced by the defaults, and
+// exclusions beyond the caps are reported but kept
+func (p *Policy) Apply(cfg config.RepositoryConfig) (config.RepositoryConfig, []string) {
+	var problems []string
+	disallow := func(field string, set bool) bool {
+		if set && !p.Allows(field) {
+			problems = append(problems, fmt.Sprintf("%s may not be overridden by repositories", field))
+			return true
+		}
+		return false
+	}
+
+	moduleExcludeDirs, moduleExcludeFiles := false, false
+	for _, module := range cfg.Modules {
+		moduleExcludeDirs = moduleExcludeDirs || len(module.ExcludeDirs) > 0
+		moduleExcludeFiles = moduleExcludeFiles || len(module.ExcludeFiles) > 0
+	}
+	if disallow(FieldExcludeDirs, len(cfg.ExcludeDirs) > 0 || moduleExcludeDirs) {
+		cfg.ExcludeDirs = nil
+		cfg.Modules = withoutModuleExcludes(cfg.Modules, true, false)
+	}
+	if disallow(FieldExcludeFiles, len(cfg.ExcludeFiles) > 0 || moduleExcludeFiles) {
+		cfg.ExcludeFiles = nil
+		cfg.Modules = withoutModuleExcludes(cfg.Modules, false, true)
+	}
+	if disallow(FieldTimeout, cfg.Timeout != "") || cfg.Timeout == "" {
+		cfg.Timeout = p.Defaults.Timeout
+	}
+	if disallow(FieldRegressionDelta, cfg.RegressionDelta != nil) || cfg.RegressionDelta == nil {
+		cfg.RegressionDelta = p.Defaults.RegressionDelta
+	}
+	if disallow(FieldMinCoverage, cfg.MinCoverage != nil) || cfg.MinCoverage == nil {
+		cfg.MinCoverage = p.Defaults.MinCoverage
+	}
+	if disallow(FieldRatchet, cfg.Ratchet != nil) || cfg.Ratchet == nil {
+		cfg.Ratchet = p.Defaults.Ratchet
+	}
+	if disallow(FieldMaxAge, cfg.MaxAge != "") || cfg.MaxAge == "" {
+		cfg.MaxAge = p.Defaults.MaxAge
+	}
+	if disallow(FieldIncludeTestHelpers, cfg.IncludeTestHelpers) {
+		cfg.IncludeTestHelpers = false
+	}
+
+	// Modules exclude the repository's excludes and their own
+	for _, module := range cfg.EffectiveModules() {
+		in := ""
+		if len(cfg.Modules) > 0 {
+			in = " in module " + module.Path
+		}
+		if limit := p.Exclusions.MaxExcludeDirs; limit > 0 && len(module.ExcludeDirs) > limit {
+			problems = append(problems, fmt.Sprintf("%d exclude_dirs%s exceed the cap of %d", len(module.ExcludeDirs), in, limit))
+		}
+		if limit := p.Exclusions.MaxExcludeFiles; limit > 0 && len(module.ExcludeFiles) > limit {
+			problems = append(problems, fmt.Sprintf("%d exclude_files%s exceed the cap of %d", len(module.ExcludeFiles), in, limit))
+		}
+	}
+	return cfg, problems
+}
+
+// withoutModuleExcludes returns copies of modules without their exclude_dirs and/or exclude_files
+func withoutModuleExcludes(modules []config.ModuleConfig, dirs, files bool) []config.ModuleConfig {
+	if modules == nil {
+		return nil
+	}
+	cleared := make([]config.ModuleConfig, len(modules))
+	for i, module := range modules {
+		if dirs {
+			module.ExcludeDirs = nil
+		}
+		if files {
+			module.ExcludeFiles = nil
+		}
+		cleared[i] = module
+	}
+	return cleared
+}
+
+// Route returns the first alert route matching a repository, one of its owners or one of its groups
+func (p *Policy) Route(repo string, owners, groups []string) (Route, bool) {
+	for _, route := range p.Alerts.Routes {
+		for _, pattern := range route.Repos {
+			if matched, _ := path.Match(pattern, repo); matched {
+				return route, true
+			}
+		}
+		for _, owner := range route.Owners {
+			if contains(owners, owner) {
+				return route, true
+			}
+		}
+		for _, group := range route.Groups {
+			if contains(groups, group) {
+				return route, true
+			}
+		}
+	}
+	return Route{}, false
+}
+
+// contains reports whether values holds value
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,231 @@
+package policy_test
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+	"github.com/konflux-ci/coverage-dashboard/internal/policy"
+)
+
+var _ = Describe("Policy", func() {
+	var policyFile string
+
+	writePolicy := func(content string) {
+		Expect(os.WriteFile(policyFile, []byte(content), 0644)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		policyFile = filepath.Join(GinkgoT().TempDir(), "policy.yaml")
+	})
+
+	Describe("Load", func() {
+		It("should return an empty policy when the file does not exist", func() {
+			p, err := policy.Load(policyFile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(p.Allows(policy.FieldMinCoverage)).To(BeTrue())
+		})
+
+		It("should parse defaults, caps and alert settings", func() {
+			writePolicy(`defaults:
+  min_coverage: 40
+  regression_delta: 3
+  max_age: 168h
+  ratchet:
+    tolerance: 1
+exclusions:
+  max_exclude_dirs: 5
+alerts:
+  cooldown: 72h
+  escalate_after: 2
+  failure_alert_after: 4
+  routes:
+    - repos: ["konflux-ci/legacy-*"]
+      issues: false
+reports:
+  retention: 2160h
+  keep: 5
+discovery:
+  default_owner: "@konflux-ci/onboarding"
+overridable: [exclude_dirs, timeout]
+`)
+			p, err := policy.Load(policyFile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*p.Defaults.MinCoverage).To(Equal(40.0))
+			Expect(p.Defaults.Ratchet.Tolerance).To(Equal(1.0))
+			Expect(p.Defaults.MaxAge).To(Equal("168h"))
+			Expect(p.Exclusions.MaxExcludeDirs).To(Equal(5))
+			Expect(*p.Alerts.EscalateAfter).To(Equal(2))
+			Expect(*p.Alerts.FailureAlertAfter).To(Equal(4))
+			Expect(p.Cooldown()).To(Equal(72 * time.Hour))
+			Expect(p.Retention()).To(Equal(90 * 24 * time.Hour))
+			Expect(*p.Reports.Keep).To(Equal(5))
+			Expect(p.Discovery.DefaultOwner).To(Equal("@konflux-ci/onboarding"))
+			Expect(p.Allows(policy.FieldTimeout)).To(BeTrue())
+			Expect(p.Allows(policy.FieldMinCoverage)).To(BeFalse())
+		})
+
+		It("should reject unknown overridable fields", func() {
+			writePolicy("overridable: [name]\n")
+			_, err := policy.Load(policyFile)
+			Expect(err).To(MatchError(ContainSubstring(`unknown field "name"`)))
+		})
+
+		It("should reject unknown feature flags", func() {
+			writePolicy("features:\n  auto-merge: true\n")
+			_, err := policy.Load(policyFile)
+			Expect(err).To(MatchError(ContainSubstring(`features: unknown feature "auto-merge"`)))
+		})
+
+		It("should reject invalid defaults", func() {
+			writePolicy("defaults:\n  min_coverage: 120\n")
+			_, err := policy.Load(policyFile)
+			Expect(err).To(MatchError(ContainSubstring("defaults")))
+
+			writePolicy("defaults:\n  max_age: 3d\n")
+			_, err = policy.Load(policyFile)
+			Expect(err).To(MatchError(ContainSubstring("max_age")))
+		})
+
+		It("should reject invalid report retention", func() {
+			writePolicy("reports:\n  retention: 90d\n")
+			_, err := policy.Load(policyFile)
+			Expect(err).To(MatchError(ContainSubstring("reports: invalid retention")))
+
+			writePolicy("reports:\n  keep: 0\n")
+			_, err = policy.Load(policyFile)
+			Expect(err).To(MatchError(ContainSubstring("keep must be at least 1")))
+		})
+
+		It("should reject default owners that are not users or teams", func() {
+			writePolicy("discovery:\n  default_owner: konflux-ci/onboarding\n")
+			_, err := policy.Load(policyFile)
+			Expect(err).To(MatchError(ContainSubstring("discovery: default owner")))
+		})
+
+		It("should parse review rotations of teams and reject those of users", func() {
+			writePolicy("discovery:\n  review_rotation:\n    \"@konflux-ci/build\":\n      reviewers: 2\n      skip: [\"@manager\"]\n")
+			p, err := policy.Load(policyFile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(p.Discovery.ReviewRotation).To(Equal(map[string]policy.ReviewRotation{"@konflux-ci/build": {Reviewers: 2, Skip: []string{"@manager"}}}))
+
+			writePolicy("discovery:\n  review_rotation:\n    \"@alice\": {}\n")
+			_, err = policy.Load(policyFile)
+			Expect(err).To(MatchError(ContainSubstring(`review rotation of "@alice": not an @org/team`)))
+		})
+
+		It("should reject unknown headline weights", func() {
+			writePolicy("headline:\n  weight: lines\n")
+			_, err := policy.Load(policyFile)
+			Expect(err).To(MatchError(ContainSubstring(`headline: weight must be "statements" or "repository"`)))
+		})
+
+		It("should reject ratchet resets in the defaults", func() {
+			writePolicy("defaults:\n  ratchet:\n    reset:\n      value: 50\n      reason: migration\n")
+			_, err := policy.Load(policyFile)
+			Expect(err).To(MatchError(ContainSubstring("ratchet resets")))
+		})
+	})
+
+	Describe("Apply", func() {
+		var p *policy.Policy
+
+		BeforeEach(func() {
+			minimum, delta := 40.0, 3.0
+			p = &policy.Policy{
+				Defaults:    policy.Defaults{MinCoverage: &minimum, RegressionDelta: &delta, Timeout: "30m"},
+				Exclusions:  policy.ExclusionCaps{MaxExcludeDirs: 1},
+				Overridable: []string{policy.FieldExcludeDirs, policy.FieldRegressionDelta},
+			}
+		})
+
+		It("should fill unset fields with the defaults", func() {
+			cfg, problems := p.Apply(config.RepositoryConfig{Name: "konflux-ci/api"})
+			Expect(problems).To(BeEmpty())
+			Expect(*cfg.MinCoverage).To(Equal(40.0))
+			Expect(*cfg.RegressionDelta).To(Equal(3.0))
+			Expect(cfg.Timeout).To(Equal("30m"))
+		})
+
+		It("should keep overrides of overridable fields", func() {
+			delta := 8.0
+			cfg, problems := p.Apply(config.RepositoryConfig{Name: "konflux-ci/api", RegressionDelta: &delta, ExcludeDirs: []string{"test"}})
+			Expect(problems).To(BeEmpty())
+			Expect(*cfg.RegressionDelta).To(Equal(8.0))
+			Expect(cfg.ExcludeDirs).To(Equal([]string{"test"}))
+		})
+
+		It("should replace overrides of other fields with the defaults", func() {
+			minimum := 10.0
+			cfg, problems := p.Apply(config.RepositoryConfig{Name: "konflux-ci/api", MinCoverage: &minimum, Timeout: "2h"})
+			Expect(problems).To(ConsistOf(
+				"timeout may not be overridden by repositories",
+				"min_coverage may not be overridden by repositories",
+			))
+			Expect(*cfg.MinCoverage).To(Equal(40.0))
+			Expect(cfg.Timeout).To(Equal("30m"))
+		})
+
+		It("should report exclusions beyond the caps", func() {
+			_, problems := p.Apply(config.RepositoryConfig{Name: "konflux-ci/api", ExcludeDirs: []string{"test", "e2e"}})
+			Expect(problems).To(ConsistOf("2 exclude_dirs exceed the cap of 1"))
+		})
+
+		It("should apply the rules to the excludes of every module", func() {
+			cfg, problems := p.Apply(config.RepositoryConfig{Name: "konflux-ci/e2e-tests", ExcludeDirs: []string{"vendor"}, Modules: []config.ModuleConfig{
+				{Path: "."},
+				{Path: "tools", ExcludeDirs: []string{"hack"}, ExcludeFiles: []string{"*.pb.go"}},
+			}})
+			Expect(problems).To(ConsistOf(
+				"exclude_files may not be overridden by repositories",
+				"2 exclude_dirs in module tools exceed the cap of 1",
+			))
+			Expect(cfg.Modules[1].ExcludeDirs).To(Equal([]string{"hack"}))
+			Expect(cfg.Modules[1].ExcludeFiles).To(BeNil())
+		})
+	})
+
+	Describe("Route", func() {
+		It("should return the first route matching the repository or an owner", func() {
+			p := &policy.Policy{Alerts: policy.Alerts{Routes: []policy.Route{
+				{Repos: []string{"konflux-ci/legacy-*"}, Label: "legacy"},
+				{Owners: []string{"@konflux-ci/vanguard"}, Label: "vanguard"},
+				{Groups: []string{"build-service-stack"}, Label: "build"},
+			}}}
+
+			route, ok := p.Route("konflux-ci/legacy-api", []string{"@konflux-ci/vanguard"}, nil)
+			Expect(ok).To(BeTrue())
+			Expect(route.Label).To(Equal("legacy"))
+
+			route, ok = p.Route("konflux-ci/api", []string{"@konflux-ci/vanguard"}, []string{"build-service-stack"})
+			Expect(ok).To(BeTrue())
+			Expect(route.Label).To(Equal("vanguard"))
+
+			route, ok = p.Route("konflux-ci/api", nil, []string{"build-service-stack"})
+			Expect(ok).To(BeTrue())
+			Expect(route.Label).To(Equal("build"))
+
+			_, ok = p.Route("konflux-ci/api", nil, nil)
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Describe("Headline", func() {
+		It("should leave out excluded and stale repositories", func() {
+			h := policy.Headline{Exclude: []string{"konflux-ci/legacy-*"}, ExcludeGroups: []string{"experiments"}}
+			Expect(h.Excludes("konflux-ci/legacy-api", nil, false)).To(Equal(`excluded by "konflux-ci/legacy-*"`))
+			Expect(h.Excludes("konflux-ci/api", []string{"experiments"}, false)).To(Equal(`in excluded group "experiments"`))
+			Expect(h.Excludes("konflux-ci/api", nil, true)).To(Equal("stale"))
+			Expect(h.Excludes("konflux-ci/api", nil, false)).To(BeEmpty())
+
+			h.IncludeStale = true
+			Expect(h.Excludes("konflux-ci/api", nil, true)).To(BeEmpty())
+			Expect(h.BadgeLabel()).To(Equal(policy.DefaultHeadlineLabel))
+			Expect(h.Weighting()).To(Equal(policy.WeightStatements))
+		})
+	})
+})
@@ -0,0 +1,146 @@
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+
+	"github.com/open-policy-agent/opa/v1/rego"
+)
+
+// DefaultRegoQuery collects the violations of Rego policies
+const DefaultRegoQuery = "data.coverage.deny"
+
+// Rego configures gates written in Rego and evaluated against the coverage model
+type Rego struct {
+	// Files are .rego files or directories of them
+	Files []string `yaml:"files"`
+	// Query must evaluate to a set of messages, or of objects with repo and msg
+	Query string `yaml:"query,omitempty"`
+}
+
+// Violation is a message of a Rego policy, optionally about one repository
+type Violation struct {
+	Repo    string `json:"repo,omitempty"`
+	Message string `json:"message"`
+}
+
+// String formats the violation for the console
+func (v Violation) String() string {
+	if v.Repo == "" {
+		return v.Message
+	}
+	return v.Repo + ": " + v.Message
+}
+
+// query returns the query of the gates, DefaultRegoQuery unless configured
+func (r *Rego) query() string {
+	if r.Query == "" {
+		return DefaultRegoQuery
+	}
+	return r.Query
+}
+
+// prepare parses and compiles the gates and their query, in process
+func (r *Rego) prepare(ctx context.Context) (rego.PreparedEvalQuery, error) {
+	prepared, err := rego.New(rego.Query(r.query()), rego.Load(r.Files, onlyRego)).PrepareForEval(ctx)
+	if err != nil {
+		return prepared, fmt.Errorf("failed to compile the Rego gates: %w", err)
+	}
+	return prepared, nil
+}
+
+// onlyRego leaves out the files of the gates' directories that are not .rego files, e.g. their tests' data
+func onlyRego(_ string, info fs.FileInfo, _ int) bool {
+	return !info.IsDir() && filepath.Ext(info.Name()) != ".rego"
+}
+
+// CheckRego compiles the policy's Rego gates without evaluating them
+func (p *Policy) CheckRego(ctx context.Context) error {
+	if p.Rego == nil || len(p.Rego.Files) == 0 {
+		return nil
+	}
+	_, err := p.Rego.prepare(ctx)
+	return err
+}
+
+// Evaluate runs the policy's Rego gates against the coverage model, passed as their input document
+// Returns no violations when the policy has no Rego gates
+func (p *Policy) Evaluate(ctx context.Context, input any) ([]Violation, error) {
+	if p.Rego == nil || len(p.Rego.Files) == 0 {
+		return nil, nil
+	}
+	prepared, err := p.Rego.prepare(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// The gates see the model as coverage.json has it, not as Go values
+	data, err := json.Marshal(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode policy input: %w", err)
+	}
+	var document any
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("failed to decode policy input: %w", err)
+	}
+
+	query := p.Rego.query()
+	results, err := prepared.Eval(ctx, rego.EvalInput(document))
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate %s: %w", query, err)
+	}
+
+	// An undefined query has no result, which means no violations
+	var violations []Violation
+	for _, result := range results {
+		for _, expression := range result.Expressions {
+			value, err := json.Marshal(expression.Value)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode the value of %s: %w", query, err)
+			}
+			parsed, err := parseViolations(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s must evaluate to a set of messages: %w", query, err)
+			}
+			violations = append(violations, parsed...)
+		}
+	}
+
+	sort.Slice(violations, func(i, j int) bool {
+		if violations[i].Repo != violations[j].Repo {
+			return violations[i].Repo < violations[j].Repo
+		}
+		return violations[i].Message < violations[j].Message
+	})
+	return violations, nil
+}
+
+// parseViolations decodes a set of messages, or of objects with repo and msg
+func parseViolations(value json.RawMessage) ([]Violation, error) {
+	var items []json.RawMessage
+	if err := json.Unmarshal(value, &items); err != nil {
+		return nil, err
+	}
+
+	violations := make([]Violation, 0, len(items))
+	for _, item := range items {
+		var message string
+		if err := json.Unmarshal(item, &message); err == nil {
+			violations = append(violations, Violation{Message: message})
+			continue
+		}
+		var object struct {
+			Repo string `json:"repo"`
+			Msg  string `json:"msg"`
+		}
+		if err := json.Unmarshal(item, &object); err != nil || object.Msg == "" {
+			return nil, fmt.Errorf("unexpected violation %s", item)
+		}
+		violations = append(violations, Violation{Repo: object.Repo, Message: object.Msg})
+	}
+	return violations, nil
+}
@@ -0,0 +1,84 @@
+package policy_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/policy"
+)
+
+var _ = Describe("Rego", func() {
+	var (
+		tempDir string
+		p       *policy.Policy
+	)
+
+	// gates writes the Rego gates of the policy
+	gates := func(content string) {
+		Expect(os.WriteFile(filepath.Join(tempDir, "gates.rego"), []byte(content), 0644)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		tempDir = GinkgoT().TempDir()
+		p = &policy.Policy{Rego: &policy.Rego{Files: []string{tempDir}}}
+	})
+
+	It("should not evaluate anything without rego files", func() {
+		violations, err := (&policy.Policy{}).Evaluate(context.Background(), map[string]any{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(violations).To(BeEmpty())
+	})
+
+	It("should collect messages and repository violations", func() {
+		gates(`package coverage
+
+deny contains {"repo": r.repo, "msg": "tier-1 coverage below 75%"} if {
+	some r in input.data
+	r.repo == "konflux-ci/api"
+	r.coverage < 75
+}
+
+deny contains "no repository may be below 20%" if {
+	some r in input.data
+	r.coverage < 20
+}
+`)
+		// Files of the directory that are not gates are left out
+		Expect(os.WriteFile(filepath.Join(tempDir, "notes.json"), []byte("not json"), 0644)).To(Succeed())
+
+		violations, err := p.Evaluate(context.Background(), map[string]any{"data": []map[string]any{
+			{"repo": "konflux-ci/api", "coverage": 60.5},
+			{"repo": "konflux-ci/ui", "coverage": 12.0},
+		}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(violations).To(Equal([]policy.Violation{
+			{Message: "no repository may be below 20%"},
+			{Repo: "konflux-ci/api", Message: "tier-1 coverage below 75%"},
+		}))
+		Expect(violations[1].String()).To(Equal("konflux-ci/api: tier-1 coverage below 75%"))
+	})
+
+	It("should treat an undefined query as passing", func() {
+		gates("package other\n\nallow := true\n")
+		violations, err := p.Evaluate(context.Background(), map[string]any{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(violations).To(BeEmpty())
+	})
+
+	It("should report gates that do not compile", func() {
+		gates("package coverage\n\ndeny contains\n")
+		_, err := p.Evaluate(context.Background(), map[string]any{})
+		Expect(err).To(MatchError(ContainSubstring("failed to compile the Rego gates")))
+		Expect(p.CheckRego(context.Background())).To(MatchError(ContainSubstring("rego_parse_error")))
+	})
+
+	It("should reject queries that do not evaluate to a set", func() {
+		gates("package coverage\n\ndeny := true\n")
+		_, err := p.Evaluate(context.Background(), map[string]any{})
+		Expect(err).To(MatchError(ContainSubstring("must evaluate to a set of messages")))
+	})
+})
@@ -6,9 +6,12 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/google/go-github/v66/github"
 	"github.com/konflux-ci/coverage-dashboard/internal/config"
+	"github.com/konflux-ci/coverage-dashboard/internal/locale"
+	"github.com/konflux-ci/coverage-dashboard/internal/schedule"
 )
 
 const prBodyTemplate = `## Add Coverage Dashboard Tracking
@@ -29,10 +32,14 @@ The coverage dashboard automatically collects and displays test coverage metrics
 - ✅ Sets up ownership mapping so your team can manage future changes
 - ✅ Enables automatic coverage report generation from your test suite
 
+### How Owners Were Determined
+
+%s
+%s
 ### After Merge
 
 Your repository will automatically:
-1. Appear on the dashboard within 24 hours (next scheduled run)
+1. Appear on the dashboard after the next scheduled run%s
 2. Have coverage metrics updated with each dashboard run
 3. Generate detailed HTML coverage reports accessible from the dashboard
 
@@ -40,68 +47,111 @@ Your repository will automatically:
 
 - [ ] Verify exclude patterns are appropriate for your repository structure
 - [ ] Confirm ownership assignment includes the right team members
-- [ ] Repository has Go tests that will generate coverage data`
+- [ ] Repository has Go tests that will generate coverage data
+
+To see which packages and files the exclude patterns remove, and how many statements that drops, run from this branch:
+
+    go run ./cmd/preview-excludes --repo %s`
 
 const commitMsgTemplate = `chore: add coverage tracking for %s
 
 Add configuration for %s to the Konflux coverage dashboard.
 This enables automatic test coverage tracking and reporting for the repository.`
 
+// cleanupTimeout bounds restoring the checkout after a failed or cancelled pull request
+const cleanupTimeout = 30 * time.Second
+
 // Creator creates pull requests for repository configurations
 type Creator struct {
-	client      *github.Client
-	workDir     string
-	org         string
-	baseBranch  string
-	currentRepo string
+	opener     Opener
+	workDir    string
+	baseBranch string
+	// schedule runs the dashboard, telling owners when their repository appears; nil when unknown
+	schedule *schedule.Schedule
+	// locale formats the time of the next run
+	locale locale.Locale
+	// rotator requests review of rotated teams from their members in turn; nil requests the owners
+	rotator *Rotator
 }
 
-// NewCreator creates a new PR creator
+// NewCreator creates a new PR creator for a GitHub repository
 func NewCreator(client *github.Client, workDir, org, repo, baseBranch string) *Creator {
+	return NewCreatorWithOpener(&gitHubOpener{client: client, org: org, repo: repo}, workDir, baseBranch)
+}
+
+// NewCreatorWithOpener creates a PR creator opening its pull requests with opener, e.g. on another forge than GitHub
+func NewCreatorWithOpener(opener Opener, workDir, baseBranch string) *Creator {
 	return &Creator{
-		client:      client,
-		workDir:     workDir,
-		org:         org,
-		baseBranch:  baseBranch,
-		currentRepo: repo,
+		opener:     opener,
+		workDir:    workDir,
+		baseBranch: baseBranch,
+		locale:     locale.Default(),
 	}
 }
 
-// CreatePullRequest creates a pull request for a repository configuration
-func (c *Creator) CreatePullRequest(ctx context.Context, cfg config.RepositoryConfig, configWriter *config.Writer) error {
-	repoName := extractRepoName(cfg.Name)
-	branchName := fmt.Sprintf("add-repo/%s", repoName)
+// SetSchedule tells owners in the pull requests adding repositories when the dashboard runs next, formatted in loc
+func (c *Creator) SetSchedule(s *schedule.Schedule, loc locale.Locale) {
+	c.schedule = s
+	c.locale = loc
+}
+
+// SetRotation requests review of the pull requests of rotated teams from their members in turn, sharing the turns
+// of rotator with other creators using it
+func (c *Creator) SetRotation(rotator *Rotator) {
+	c.rotator = rotator
+}
+
+// open opens a pull request with the opener, requesting review from the members of rotated teams in their place
+func (c *Creator) open(ctx context.Context, request Request) (string, error) {
+	request.Owners = c.rotator.Reviewers(ctx, request.Owners)
+	return c.opener.Open(ctx, request)
+}
+
+// CreatePullRequest creates a pull request for a repository configuration and returns its URL
+// When it fails after creating the branch, including on cancellation, the checkout returns to the base branch
+func (c *Creator) CreatePullRequest(ctx context.Context, cfg config.RepositoryConfig, configWriter *config.Writer) (url string, err error) {
+	// Names that cannot be written are refused before any branch is created
+	filename, err := config.ConfigFilename(cfg.Name)
+	if err != nil {
+		return "", err
+	}
+	branchName := fmt.Sprintf("add-repo/%s", strings.TrimSuffix(filename, ".yaml"))
 
 	// 1. Create branch
 	if err := c.createBranch(ctx, branchName); err != nil {
-		return fmt.Errorf("failed to create branch: %w", err)
+		return "", fmt.Errorf("failed to create branch: %w", err)
 	}
+	defer func() {
+		if err != nil {
+			c.restoreBaseBranch(ctx)
+		}
+	}()
 
 	// 2. Write config and update CODEOWNERS on this branch
 	// IMPORTANT: Must write AFTER creating branch because createBranch resets
 	// the working directory to match remote (via checkout -B ... FETCH_HEAD)
 	if err := configWriter.Write(cfg, false); err != nil {
-		return fmt.Errorf("failed to write config: %w", err)
+		return "", fmt.Errorf("failed to write config: %w", err)
 	}
 
 	// 3. Commit changes
-	configFile := filepath.Join("repos", repoName+".yaml")
+	configFile := filepath.Join("repos", filename)
 	if err := c.commitChanges(ctx, configFile, cfg.Name); err != nil {
-		return fmt.Errorf("failed to commit changes: %w", err)
+		return "", fmt.Errorf("failed to commit changes: %w", err)
 	}
 
 	// 4. Push branch
 	if _, err := RunGitCommand(ctx, c.workDir, "push", "-u", "origin", branchName, "--force"); err != nil {
-		return fmt.Errorf("failed to push branch: %w", err)
+		return "", fmt.Errorf("failed to push branch: %w", err)
 	}
 
 	// 5. Create pull request
-	_, err := c.createGitHubPR(ctx, branchName, cfg)
+	url, err = c.open(ctx, c.addRequest(branchName, cfg))
 	if err != nil {
 		if strings.Contains(err.Error(), "already exists") {
-			return fmt.Errorf("PR already exists")
+			return "", fmt.Errorf("PR already exists")
 		}
-		return fmt.Errorf("GitHub API error: %w", err)
+		return "", err
 	}
 
 	// 6. Return to base branch for next iteration
@@ -109,7 +159,17 @@ func (c *Creator) CreatePullRequest(ctx context.Context, cfg config.RepositoryCo
 		fmt.Printf("    ⚠️  Warning: failed to checkout %s: %v\n", c.baseBranch, err)
 	}
 
-	return nil
+	return url, nil
+}
+
+// restoreBaseBranch discards the changes of a failed pull request and checks out the base branch again
+// It runs even when ctx was cancelled, bounded by its own timeout
+func (c *Creator) restoreBaseBranch(ctx context.Context) {
+	cleanupCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cleanupTimeout)
+	defer cancel()
+	if _, err := RunGitCommand(cleanupCtx, c.workDir, "checkout", "--force", c.baseBranch); err != nil {
+		fmt.Printf("    ⚠️  Warning: failed to restore %s: %v\n", c.baseBranch, err)
+	}
 }
 
 func (c *Creator) createBranch(ctx context.Context, branchName string) error {
@@ -163,77 +223,148 @@ func (c *Creator) commitChanges(ctx context.Context, configFile, repoFullName st
 		return fmt.Errorf("failed to set git user.email: %w", err)
 	}
 
-	// Stage files
-	if _, err := RunGitCommand(ctx, c.workDir, "add", configFile, "CODEOWNERS"); err != nil {
-		return err
-	}
-
 	// Create commit message
 	commitMsg := fmt.Sprintf(commitMsgTemplate, repoFullName, repoFullName)
 
-	_, err := RunGitCommand(ctx, c.workDir, "commit", "-m", commitMsg)
+	return c.commitFiles(ctx, commitMsg, configFile, "CODEOWNERS")
+}
+
+// commitFiles stages files and commits them with the checkout's git identity
+func (c *Creator) commitFiles(ctx context.Context, message string, files ...string) error {
+	if _, err := RunGitCommand(ctx, c.workDir, append([]string{"add"}, files...)...); err != nil {
+		return err
+	}
+	_, err := RunGitCommand(ctx, c.workDir, "commit", "-m", message)
 	return err
 }
 
-func (c *Creator) createGitHubPR(ctx context.Context, branchName string, cfg config.RepositoryConfig) (string, error) {
-	repoName := extractRepoName(cfg.Name)
+// configEdit is a change to the configuration files, committed and proposed in a pull request by openEdit
+type configEdit struct {
+	// Title is the title of the commit and of the pull request
+	Title string
+	Body  string
+	// Files are the files the edit changed
+	Files []string
+	// Owners are requested for review
+	Owners []string
+}
 
-	title := fmt.Sprintf("chore: add coverage tracking for %s", repoName)
-	body := c.generatePRBody(cfg)
+// openEdit opens a pull request from branchName proposing the change made by edit, and returns its URL
+// The files are edited on the pull request's branch, as creating it resets the checkout to the remote base branch;
+// on failure the checkout returns to the base branch
+func (c *Creator) openEdit(ctx context.Context, branchName string, edit func() (configEdit, error)) (url string, err error) {
+	if err := c.createBranch(ctx, branchName); err != nil {
+		return "", fmt.Errorf("failed to create branch: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			c.restoreBaseBranch(ctx)
+		}
+	}()
 
-	newPR := &github.NewPullRequest{
-		Title:               github.String(title),
-		Head:                github.String(branchName),
-		Base:                github.String(c.baseBranch),
-		Body:                github.String(body),
-		MaintainerCanModify: github.Bool(true),
+	change, err := edit()
+	if err != nil {
+		return "", err
+	}
+	if err := c.commitFiles(ctx, change.Title, change.Files...); err != nil {
+		return "", fmt.Errorf("failed to commit changes: %w", err)
+	}
+	if _, err := RunGitCommand(ctx, c.workDir, "push", "-u", "origin", branchName, "--force"); err != nil {
+		return "", fmt.Errorf("failed to push branch: %w", err)
 	}
 
-	pr, _, err := c.client.PullRequests.Create(ctx, c.org, c.currentRepo, newPR)
+	url, err = c.open(ctx, Request{
+		Branch: branchName,
+		Base:   c.baseBranch,
+		Title:  change.Title,
+		Body:   change.Body,
+		Owners: change.Owners,
+	})
 	if err != nil {
 		return "", err
 	}
 
-	// Add reviewers
-	if err := c.addReviewers(ctx, pr.GetNumber(), cfg.Owners); err != nil {
-		fmt.Printf("    ⚠️  Warning: failed to add reviewers: %v\n", err)
+	if _, err := RunGitCommand(ctx, c.workDir, "checkout", c.baseBranch); err != nil {
+		fmt.Printf("    ⚠️  Warning: failed to checkout %s: %v\n", c.baseBranch, err)
 	}
+	return url, nil
+}
 
-	return pr.GetHTMLURL(), nil
+// addRequest is the pull request adding a repository configuration, reviewed by its owners
+func (c *Creator) addRequest(branchName string, cfg config.RepositoryConfig) Request {
+	return Request{
+		Branch: branchName,
+		Base:   c.baseBranch,
+		Title:  fmt.Sprintf("chore: add coverage tracking for %s", extractRepoName(cfg.Name)),
+		Body:   c.generatePRBody(cfg),
+		Owners: cfg.Owners,
+	}
 }
 
-func (c *Creator) addReviewers(ctx context.Context, prNumber int, owners []string) error {
-	reviewers := extractReviewers(owners)
-	if len(reviewers) == 0 {
-		return nil
+func (c *Creator) generatePRBody(cfg config.RepositoryConfig) string {
+	return fmt.Sprintf(prBodyTemplate, "`"+cfg.Name+"`", ownersSummary(cfg), testsNote(cfg)+forkNote(cfg), c.nextRun(time.Now()), cfg.Name)
+}
+
+// nextRun tells when the next scheduled run after now starts, empty when the schedule is unknown
+func (c *Creator) nextRun(now time.Time) string {
+	if c.schedule == nil {
+		return ""
 	}
+	next := c.schedule.Next(now)
+	if next.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf(", on %s (%s)", c.locale.DateTime(next), untilRun(next.Sub(now)))
+}
 
-	// Separate individual reviewers from teams
-	var users []string
-	var teams []string
+// untilRun approximates the time left until a run
+func untilRun(d time.Duration) string {
+	switch hours := int(d.Round(time.Hour).Hours()); {
+	case d < time.Hour:
+		return "in less than an hour"
+	case hours == 1:
+		return "in about an hour"
+	case hours < 48:
+		return fmt.Sprintf("in about %d hours", hours)
+	default:
+		return fmt.Sprintf("in about %d days", int(d.Round(24*time.Hour).Hours()/24))
+	}
+}
 
-	for _, reviewer := range reviewers {
-		if strings.Contains(reviewer, "/") {
-			parts := strings.Split(reviewer, "/")
-			if len(parts) == 2 {
-				teams = append(teams, parts[1])
-			}
-		} else {
-			users = append(users, reviewer)
-		}
+// testsNote warns reviewers of configurations of repositories discovery found no tests in
+func testsNote(cfg config.RepositoryConfig) string {
+	if !cfg.NoTests {
+		return ""
 	}
+	return "\n### ⚠️ No Tests Found\n\nDiscovery found no `_test.go` files in this repository, so it is marked `no_tests: true` and will show no coverage until tests are added. Close this PR if the repository is not meant to be tracked.\n"
+}
 
-	reviewersRequest := github.ReviewersRequest{
-		Reviewers:     users,
-		TeamReviewers: teams,
+// forkNote asks reviewers of configurations of forked repositories whether they belong on the dashboard
+func forkNote(cfg config.RepositoryConfig) string {
+	if !cfg.Fork {
+		return ""
 	}
+	return "\n### 🍴 Fork\n\nThis repository is a fork, so it is marked `fork: true`. Its coverage mostly measures the upstream code; close this PR if the fork should not appear on the dashboard.\n"
+}
 
-	_, _, err := c.client.PullRequests.RequestReviewers(ctx, c.org, c.currentRepo, prNumber, reviewersRequest)
-	return err
+// ownersSources tells reviewers where owners were detected and how much to trust them
+var ownersSources = map[string]string{
+	config.OwnersSourceCodeowners:    "Detected from the repository's CODEOWNERS file.",
+	config.OwnersSourceTeams:         "Detected from the teams with admin or maintain access to the repository. Access does not always mean ownership, please check them.",
+	config.OwnersSourceCollaborators: "Detected from the people with admin or maintain access to the repository. Please check they still own it, or replace them with a team.",
+	config.OwnersSourceDefault:       "No owners could be detected, so the default owner was assigned. Please replace it with the team owning the repository.",
 }
 
-func (c *Creator) generatePRBody(cfg config.RepositoryConfig) string {
-	return fmt.Sprintf(prBodyTemplate, "`"+cfg.Name+"`")
+// ownersSummary describes the owners of a configuration and how they were determined
+func ownersSummary(cfg config.RepositoryConfig) string {
+	summary := fmt.Sprintf("**Owners:** %s", strings.Join(cfg.Owners, " "))
+	if source, ok := ownersSources[cfg.OwnersSource]; ok {
+		summary += "\n\n" + source
+	}
+	if cfg.OwnersDetectedAt != nil {
+		summary += fmt.Sprintf("\n\nRecorded in the configuration as `owners_source: %s`, detected at %s.", cfg.OwnersSource, cfg.OwnersDetectedAt.UTC().Format(time.RFC3339))
+	}
+	return summary
 }
 
 // Helper functions
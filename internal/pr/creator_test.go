@@ -1,8 +1,21 @@
 package pr
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"time"
+
+	"github.com/google/go-github/v66/github"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+	"github.com/konflux-ci/coverage-dashboard/internal/locale"
+	"github.com/konflux-ci/coverage-dashboard/internal/schedule"
 )
 
 var _ = Describe("Helper Functions", func() {
@@ -45,4 +58,212 @@ var _ = Describe("Helper Functions", func() {
 			Expect(result).To(Equal("None"))
 		})
 	})
+
+	Describe("transferBody", func() {
+		It("should list the previous and new owners and the changed files", func() {
+			body := transferBody(config.OwnershipTransfer{
+				Repo:  "konflux-ci/caching",
+				From:  []string{"@konflux-ci/old-team"},
+				To:    []string{"@konflux-ci/new-team"},
+				Files: []string{"repos/caching.yaml", "CODEOWNERS"},
+			})
+			Expect(body).To(ContainSubstring("**Previous owners:** @konflux-ci/old-team"))
+			Expect(body).To(ContainSubstring("**New owners:** @konflux-ci/new-team"))
+			Expect(body).To(ContainSubstring("- `repos/caching.yaml`\n- `CODEOWNERS`"))
+		})
+	})
+
+	Describe("removalBody", func() {
+		It("should explain the removal to the owners and list the changed files", func() {
+			body := removalBody(config.Removal{Repo: "konflux-ci/old", Owners: []string{"@konflux-ci/old-team"}, Files: []string{"repos/old.yaml", "CODEOWNERS"}})
+			Expect(body).To(ContainSubstring("removes `konflux-ci/old` from the coverage dashboard: the repository was archived"))
+			Expect(body).To(ContainSubstring("- **Owners:** @konflux-ci/old-team"))
+			Expect(body).To(ContainSubstring("- `repos/old.yaml`\n- `CODEOWNERS`"))
+			Expect(RemovalBranch("konflux-ci/old")).To(Equal("remove-repo/old"))
+		})
+	})
+
+	Describe("offboardBody", func() {
+		It("should tell the owners when the data is deleted and list the changed files", func() {
+			deleteAfter := time.Date(2024, 8, 1, 12, 0, 0, 0, time.UTC)
+			body := offboardBody(config.Offboarding{
+				Removal:     config.Removal{Repo: "konflux-ci/old", Owners: []string{"@konflux-ci/old-team"}, Files: []string{"repos/old.yaml", "offboarded.yaml"}},
+				DeleteAfter: &deleteAfter,
+			})
+			Expect(body).To(ContainSubstring("removes `konflux-ci/old` from the coverage dashboard at the request of its owners"))
+			Expect(body).To(ContainSubstring("- **Coverage data:** deleted on the first collection run after 2024-08-01"))
+			Expect(body).To(ContainSubstring("- `repos/old.yaml`\n- `offboarded.yaml`"))
+			Expect(offboardBody(config.Offboarding{Removal: config.Removal{Repo: "konflux-ci/old"}})).To(ContainSubstring("- **Coverage data:** kept forever"))
+			Expect(OffboardBranch("konflux-ci/old")).To(Equal("offboard-repo/old"))
+		})
+	})
+
+	Describe("renameBody", func() {
+		It("should name both names and the owners, and list the changed files", func() {
+			body := renameBody(config.Rename{From: "konflux-ci/old", To: "konflux-ci/new", Owners: []string{"@konflux-ci/team"}, Files: []string{"repos/old.yaml", "repos/new.yaml"}})
+			Expect(body).To(ContainSubstring("configuration of `konflux-ci/old` to `konflux-ci/new`: the repository was renamed"))
+			Expect(body).To(ContainSubstring("- **Owners:** @konflux-ci/team"))
+			Expect(body).To(ContainSubstring("- `repos/old.yaml`\n- `repos/new.yaml`"))
+			Expect(RenameBranch("konflux-ci/old")).To(Equal("rename-repo/old"))
+		})
+	})
+
+	Describe("editBody", func() {
+		It("should list the changes, who requested them and the changed files", func() {
+			body := editBody(config.EditResult{Repo: "konflux-ci/api", Changes: []string{"min_coverage: 60 → 70", "exclude_dirs: added hack/"}, Files: []string{"repos/api.yaml"}}, "alice")
+			Expect(body).To(ContainSubstring("configuration of `konflux-ci/api`, as requested by alice.\n\n- min_coverage: 60 → 70\n- exclude_dirs: added hack/\n"))
+			Expect(body).To(ContainSubstring("- `repos/api.yaml`"))
+		})
+
+		It("should name a branch per edit, with the organization", func() {
+			minimum := 70.0
+			branch := EditBranch("konflux-ci/api", config.Edit{MinCoverage: &minimum})
+			Expect(branch).To(MatchRegexp(`^edit-config/konflux-ci/api-[0-9a-f]{8}$`))
+			Expect(EditBranch("konflux-ci/api", config.Edit{MinCoverage: &minimum})).To(Equal(branch))
+			Expect(EditBranch("konflux-ci/api", config.Edit{AddExcludeDirs: []string{"hack/"}})).NotTo(Equal(branch))
+			Expect(EditBranch("other-org/api", config.Edit{MinCoverage: &minimum})).NotTo(Equal(branch))
+		})
+	})
+
+	Describe("driftBody", func() {
+		It("should list the default excludes added and the changed files", func() {
+			body := driftBody(config.EditResult{Repo: "konflux-ci/api", Changes: []string{"exclude_files: added *_mock.go"}, Files: []string{"repos.yaml"}})
+			Expect(body).To(ContainSubstring("configuration of `konflux-ci/api`, which was\ndiscovered before they were introduced.\n\n- exclude_files: added *_mock.go\n"))
+			Expect(body).To(ContainSubstring("- `repos.yaml`"))
+			Expect(DriftBranch("konflux-ci/api")).To(Equal("config-drift/api"))
+		})
+	})
+
+	Describe("ownersSummary", func() {
+		It("should explain how the owners were detected and when", func() {
+			detectedAt := time.Date(2025, 5, 2, 9, 30, 0, 0, time.UTC)
+			summary := ownersSummary(config.RepositoryConfig{
+				Owners:           []string{"@konflux-ci/admins"},
+				OwnersSource:     config.OwnersSourceTeams,
+				OwnersDetectedAt: &detectedAt,
+			})
+			Expect(summary).To(HavePrefix("**Owners:** @konflux-ci/admins\n\nDetected from the teams"))
+			Expect(summary).To(HaveSuffix("`owners_source: teams`, detected at 2025-05-02T09:30:00Z."))
+		})
+
+		It("should only list owners of unknown source", func() {
+			Expect(ownersSummary(config.RepositoryConfig{Owners: []string{"@user"}})).To(Equal("**Owners:** @user"))
+		})
+	})
+
+	Describe("generatePRBody", func() {
+		It("should warn reviewers of repositories without tests", func() {
+			cfg := config.RepositoryConfig{Name: "konflux-ci/tools", Owners: []string{"@konflux-ci/tools"}}
+			Expect((&Creator{}).generatePRBody(cfg)).To(ContainSubstring("**Owners:** @konflux-ci/tools\n\n### After Merge"))
+
+			cfg.NoTests = true
+			body := (&Creator{}).generatePRBody(cfg)
+			Expect(body).To(ContainSubstring("\n\n### ⚠️ No Tests Found\n\nDiscovery found no `_test.go` files"))
+			Expect(body).To(ContainSubstring("tracked.\n\n### After Merge"))
+		})
+
+		It("should ask reviewers whether forks belong on the dashboard", func() {
+			cfg := config.RepositoryConfig{Name: "konflux-ci/mirror", Owners: []string{"@konflux-ci/tools"}, Fork: true}
+			body := (&Creator{}).generatePRBody(cfg)
+			Expect(body).To(ContainSubstring("\n\n### 🍴 Fork\n\nThis repository is a fork"))
+			Expect(body).To(ContainSubstring("dashboard.\n\n### After Merge"))
+		})
+
+		It("should tell owners when the next scheduled run starts", func() {
+			cfg := config.RepositoryConfig{Name: "konflux-ci/tools", Owners: []string{"@konflux-ci/tools"}}
+			Expect((&Creator{}).generatePRBody(cfg)).To(ContainSubstring("1. Appear on the dashboard after the next scheduled run\n"))
+
+			daily, err := schedule.Parse("0 3 * * *")
+			Expect(err).NotTo(HaveOccurred())
+			creator := NewCreatorWithOpener(nil, "", "main")
+			creator.SetSchedule(daily, locale.Lookup("de-DE"))
+			now := time.Date(2024, 5, 1, 18, 30, 0, 0, time.UTC)
+			Expect(creator.nextRun(now)).To(Equal(", on 02.05.2024, 03:00 UTC (in about 9 hours)"))
+			Expect(creator.nextRun(now.Add(8*time.Hour + 15*time.Minute))).To(Equal(", on 02.05.2024, 03:00 UTC (in less than an hour)"))
+		})
+	})
+
+	Describe("untilRun", func() {
+		It("should round to hours, then days", func() {
+			Expect(untilRun(61 * time.Minute)).To(Equal("in about an hour"))
+			Expect(untilRun(47 * time.Hour)).To(Equal("in about 47 hours"))
+			Expect(untilRun(6*24*time.Hour + 13*time.Hour)).To(Equal("in about 7 days"))
+		})
+	})
+})
+
+var _ = Describe("Rotator", func() {
+	var listed map[string]int
+
+	BeforeEach(func() {
+		listed = make(map[string]int)
+	})
+
+	members := func(_ context.Context, org, team string) ([]string, error) {
+		listed[org+"/"+team]++
+		if team == "broken" {
+			return nil, errors.New("not found")
+		}
+		return []string{"carol", "alice", "manager", "bob"}, nil
+	}
+
+	It("should request the members of rotated teams in turn, keeping other owners", func() {
+		rotator := NewRotator(map[string]Rotation{"@konflux-ci/build": {Skip: []string{"@manager"}}}, members)
+		ctx := context.Background()
+		owners := []string{"@konflux-ci/build", "@konflux-ci/docs"}
+		Expect(rotator.Reviewers(ctx, owners)).To(Equal([]string{"@alice", "@konflux-ci/docs"}))
+		Expect(rotator.Reviewers(ctx, owners)).To(Equal([]string{"@bob", "@konflux-ci/docs"}))
+		Expect(rotator.Reviewers(ctx, owners)).To(Equal([]string{"@carol", "@konflux-ci/docs"}))
+		Expect(rotator.Reviewers(ctx, owners)).To(Equal([]string{"@alice", "@konflux-ci/docs"}))
+		Expect(listed).To(Equal(map[string]int{"konflux-ci/build": 1}))
+	})
+
+	It("should request several members per pull request without repeating reviewers", func() {
+		rotator := NewRotator(map[string]Rotation{"@konflux-ci/build": {Reviewers: 2}}, members)
+		ctx := context.Background()
+		Expect(rotator.Reviewers(ctx, []string{"@konflux-ci/build", "@alice"})).To(Equal([]string{"@alice", "@bob"}))
+		Expect(rotator.Reviewers(ctx, []string{"@konflux-ci/build"})).To(Equal([]string{"@carol", "@manager"}))
+	})
+
+	It("should keep teams whose members cannot be listed", func() {
+		rotator := NewRotator(map[string]Rotation{"@konflux-ci/broken": {}}, members)
+		Expect(rotator.Reviewers(context.Background(), []string{"@konflux-ci/broken"})).To(Equal([]string{"@konflux-ci/broken"}))
+		var none *Rotator
+		Expect(none.Reviewers(context.Background(), []string{"@konflux-ci/build"})).To(Equal([]string{"@konflux-ci/build"}))
+	})
+})
+
+var _ = Describe("DefaultBranch", func() {
+	var (
+		server *httptest.Server
+		body   string
+		client *github.Client
+	)
+
+	BeforeEach(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.URL.Path).To(Equal("/repos/konflux-ci/coverage-dashboard"))
+			fmt.Fprint(w, body)
+		}))
+		client = github.NewClient(nil)
+		client.BaseURL, _ = url.Parse(server.URL + "/")
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should return the default branch of the repository", func() {
+		body = `{"name": "coverage-dashboard", "default_branch": "master"}`
+		branch, err := DefaultBranch(context.Background(), client, "konflux-ci", "coverage-dashboard")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(branch).To(Equal("master"))
+	})
+
+	It("should fall back to main for repositories without one", func() {
+		body = `{"name": "coverage-dashboard"}`
+		branch, err := DefaultBranch(context.Background(), client, "konflux-ci", "coverage-dashboard")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(branch).To(Equal("main"))
+	})
 })
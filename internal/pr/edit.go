@@ -0,0 +1,108 @@
+package pr
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+)
+
+const editBodyTemplate = `## Edit Coverage Dashboard Configuration
+
+This PR changes the coverage dashboard configuration of %s, as requested by %s.
+
+%s
+
+The dashboard applies the configuration from its next run after merge; until then nothing changes.
+
+### Review Checklist
+
+- [ ] The owners agree with the new thresholds and excludes
+
+Changed files:
+
+%s`
+
+const driftBodyTemplate = `## Update Coverage Dashboard Configuration to Current Defaults
+
+This PR adds the excludes discovery now gives new repositories to the coverage dashboard configuration of %s, which was
+discovered before they were introduced.
+
+%s
+
+The dashboard applies the configuration from its next run after merge; until then nothing changes.
+
+### Review Checklist
+
+- [ ] None of the new excludes hides code the owners want covered; remove those from this PR
+
+Changed files:
+
+%s`
+
+// EditBranch is the branch of the pull request applying an edit to a repository's configuration, e.g.
+// edit-config/konflux-ci/api-1a2b3c4d
+// Each edit gets its own branch, named after a hash of the edit, so a second edit does not replace the open pull
+// request of the first
+func EditBranch(repo string, edit config.Edit) string {
+	data, _ := json.Marshal(edit)
+	sum := sha256.Sum256(data)
+	return fmt.Sprintf("edit-config/%s-%x", repo, sum[:4])
+}
+
+// EditRepository opens a pull request applying an edit to a repository's configuration, on behalf of requester,
+// requesting review from its owners. Returns the pull request's URL
+func (c *Creator) EditRepository(ctx context.Context, writer *config.Writer, reposFile, repo string, edit config.Edit, requester string) (url string, err error) {
+	title := fmt.Sprintf("chore: edit coverage configuration of %s", extractRepoName(repo))
+	return c.editRepository(ctx, writer, reposFile, repo, edit, EditBranch(repo, edit), title, func(result config.EditResult) string {
+		return editBody(result, requester)
+	})
+}
+
+// DriftBranch is the branch of the pull request adding the current default excludes to a repository's configuration
+func DriftBranch(repo string) string {
+	return fmt.Sprintf("config-drift/%s", extractRepoName(repo))
+}
+
+// FixDrift opens a pull request adding the excludes of an edit, the defaults discovery gives new repositories, to a
+// repository's configuration, requesting review from its owners. Returns the pull request's URL
+func (c *Creator) FixDrift(ctx context.Context, writer *config.Writer, reposFile, repo string, edit config.Edit) (url string, err error) {
+	title := fmt.Sprintf("chore: update coverage configuration of %s to current defaults", extractRepoName(repo))
+	return c.editRepository(ctx, writer, reposFile, repo, edit, DriftBranch(repo), title, driftBody)
+}
+
+// editRepository opens a pull request from branchName applying an edit, described by body
+func (c *Creator) editRepository(ctx context.Context, writer *config.Writer, reposFile, repo string, edit config.Edit, branchName, title string, body func(config.EditResult) string) (string, error) {
+	return c.openEdit(ctx, branchName, func() (configEdit, error) {
+		result, err := writer.EditRepository(reposFile, repo, edit)
+		if err != nil {
+			return configEdit{}, err
+		}
+		return configEdit{Title: title, Body: body(result), Files: result.Files, Owners: result.Owners}, nil
+	})
+}
+
+// editBody describes the changes of an edit for its pull request
+func editBody(result config.EditResult, requester string) string {
+	if requester == "" {
+		requester = "an administrator"
+	}
+	return fmt.Sprintf(editBodyTemplate, "`"+result.Repo+"`", requester, changeList(result), formatList(result.Files, "None"))
+}
+
+// driftBody describes the default excludes added to a configuration for its pull request
+func driftBody(result config.EditResult) string {
+	return fmt.Sprintf(driftBodyTemplate, "`"+result.Repo+"`", changeList(result), formatList(result.Files, "None"))
+}
+
+// changeList lists the changes of an edit in Markdown
+func changeList(result config.EditResult) string {
+	changes := make([]string, 0, len(result.Changes))
+	for _, change := range result.Changes {
+		changes = append(changes, "- "+change)
+	}
+	return strings.Join(changes, "\n")
+}
@@ -0,0 +1,69 @@
+package pr
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+)
+
+const offboardBodyTemplate = `## Offboard a Repository
+
+This PR removes %s from the coverage dashboard at the request of its owners.
+
+- **Owners:** %s
+- **Coverage data:** %s
+
+The dashboard will drop the repository after the next run. Its archived reports and imported history stay published until the deletion date recorded in the offboarded file, when the collection run deletes them.
+
+### Review Checklist
+
+- [ ] The owners agree to stop tracking the repository's coverage
+- [ ] The retention of the coverage data suits the owners
+
+Changed files:
+
+%s`
+
+// Offboard opens a pull request removing the configuration of a repository and scheduling the deletion of its
+// data after retention, a negative retention keeping it forever, requesting review from its owners. Returns the
+// offboarding and the pull request's URL
+func (c *Creator) Offboard(ctx context.Context, writer *config.Writer, reposFile, offboardedFile, repo string, retention time.Duration) (offboarding config.Offboarding, url string, err error) {
+	url, err = c.openEdit(ctx, OffboardBranch(repo), func() (configEdit, error) {
+		offboarding, err = writer.Offboard(reposFile, offboardedFile, repo, time.Now(), retention)
+		if err != nil {
+			return configEdit{}, err
+		}
+		return configEdit{
+			Title:  fmt.Sprintf("chore: offboard %s from coverage tracking", extractRepoName(repo)),
+			Body:   offboardBody(offboarding),
+			Files:  offboarding.Files,
+			Owners: offboarding.Owners,
+		}, nil
+	})
+	return offboarding, url, err
+}
+
+// OffboardBranch is the branch of the pull request offboarding a repository
+func OffboardBranch(repo string) string {
+	return fmt.Sprintf("offboard-repo/%s", extractRepoName(repo))
+}
+
+// offboardBody describes the offboarding of a repository for its pull request
+func offboardBody(offboarding config.Offboarding) string {
+	owners := "none"
+	if len(offboarding.Owners) > 0 {
+		owners = strings.Join(offboarding.Owners, " ")
+	}
+	return fmt.Sprintf(offboardBodyTemplate, "`"+offboarding.Repo+"`", owners, retentionNote(offboarding.DeleteAfter), formatList(offboarding.Files, "None"))
+}
+
+// retentionNote tells when the data of an offboarded repository is deleted
+func retentionNote(deleteAfter *time.Time) string {
+	if deleteAfter == nil {
+		return "kept forever"
+	}
+	return "deleted on the first collection run after " + deleteAfter.UTC().Format(time.DateOnly)
+}
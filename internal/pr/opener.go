@@ -0,0 +1,94 @@
+package pr
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-github/v66/github"
+)
+
+// Request is a pull request of a pushed branch
+type Request struct {
+	Branch string
+	Base   string
+	Title  string
+	Body   string
+	// Owners are asked to review, as @user or @org/team
+	Owners []string
+}
+
+// Opener opens pull requests on the forge hosting the dashboard repository, e.g. merge requests on GitLab
+type Opener interface {
+	// Open opens the pull request of a pushed branch and returns its URL
+	Open(ctx context.Context, request Request) (string, error)
+}
+
+// DefaultBranch returns the default branch of a GitHub repository, e.g. the dashboard repository pull requests are
+// opened on; main when it has none
+func DefaultBranch(ctx context.Context, client *github.Client, org, repo string) (string, error) {
+	repository, _, err := client.Repositories.Get(ctx, org, repo)
+	if err != nil {
+		return "", fmt.Errorf("failed to get repository info: %w", err)
+	}
+	if branch := repository.GetDefaultBranch(); branch != "" {
+		return branch, nil
+	}
+	return "main", nil
+}
+
+// gitHubOpener opens pull requests on a GitHub repository
+type gitHubOpener struct {
+	client *github.Client
+	org    string
+	repo   string
+}
+
+// Open opens a pull request on GitHub, requesting review from the owners
+func (o *gitHubOpener) Open(ctx context.Context, request Request) (string, error) {
+	pr, _, err := o.client.PullRequests.Create(ctx, o.org, o.repo, &github.NewPullRequest{
+		Title:               github.String(request.Title),
+		Head:                github.String(request.Branch),
+		Base:                github.String(request.Base),
+		Body:                github.String(request.Body),
+		MaintainerCanModify: github.Bool(true),
+	})
+	if err != nil {
+		return "", fmt.Errorf("GitHub API error: %w", err)
+	}
+
+	if err := o.addReviewers(ctx, pr.GetNumber(), request.Owners); err != nil {
+		fmt.Printf("    ⚠️  Warning: failed to add reviewers: %v\n", err)
+	}
+	return pr.GetHTMLURL(), nil
+}
+
+func (o *gitHubOpener) addReviewers(ctx context.Context, prNumber int, owners []string) error {
+	reviewers := extractReviewers(owners)
+	if len(reviewers) == 0 {
+		return nil
+	}
+
+	// Separate individual reviewers from teams
+	var users []string
+	var teams []string
+
+	for _, reviewer := range reviewers {
+		if strings.Contains(reviewer, "/") {
+			parts := strings.Split(reviewer, "/")
+			if len(parts) == 2 {
+				teams = append(teams, parts[1])
+			}
+		} else {
+			users = append(users, reviewer)
+		}
+	}
+
+	reviewersRequest := github.ReviewersRequest{
+		Reviewers:     users,
+		TeamReviewers: teams,
+	}
+
+	_, _, err := o.client.PullRequests.RequestReviewers(ctx, o.org, o.repo, prNumber, reviewersRequest)
+	return err
+}
@@ -0,0 +1,56 @@
+package pr
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+)
+
+const removalBodyTemplate = `## Stop Tracking an Archived Repository
+
+This PR removes %s from the coverage dashboard: the repository was archived, so its coverage can no longer change.
+
+- **Owners:** %s
+
+The dashboard will drop the repository after the next run. If the repository is unarchived later, discovery will propose adding it again.
+
+### Review Checklist
+
+- [ ] The repository is archived for good, and not being moved or renamed
+
+Changed files:
+
+%s`
+
+// RemoveRepository opens a pull request removing the configuration of an archived repository,
+// requesting review from its owners. Returns the pull request's URL
+func (c *Creator) RemoveRepository(ctx context.Context, writer *config.Writer, reposFile, repo string) (string, error) {
+	return c.openEdit(ctx, RemovalBranch(repo), func() (configEdit, error) {
+		removal, err := writer.RemoveRepository(reposFile, repo)
+		if err != nil {
+			return configEdit{}, err
+		}
+		return configEdit{
+			Title:  fmt.Sprintf("chore: stop coverage tracking of archived %s", extractRepoName(repo)),
+			Body:   removalBody(removal),
+			Files:  removal.Files,
+			Owners: removal.Owners,
+		}, nil
+	})
+}
+
+// RemovalBranch is the branch of the pull request removing a repository's configuration
+func RemovalBranch(repo string) string {
+	return fmt.Sprintf("remove-repo/%s", extractRepoName(repo))
+}
+
+// removalBody describes the removal of an archived repository for its pull request
+func removalBody(removal config.Removal) string {
+	owners := "none"
+	if len(removal.Owners) > 0 {
+		owners = strings.Join(removal.Owners, " ")
+	}
+	return fmt.Sprintf(removalBodyTemplate, "`"+removal.Repo+"`", owners, formatList(removal.Files, "None"))
+}
@@ -0,0 +1,56 @@
+package pr
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+)
+
+const renameBodyTemplate = `## Follow a Repository Rename
+
+This PR moves the coverage dashboard configuration of %s to %s: the repository was renamed, and its old name only redirects to it.
+
+- **Owners:** %s
+
+The configuration is otherwise unchanged. The dashboard lists the repository under its new name after the next run.
+
+### Review Checklist
+
+- [ ] The repository was renamed, and not replaced by another one of the old name
+
+Changed files:
+
+%s`
+
+// RenameRepository opens a pull request moving the configuration of a renamed repository to its new name,
+// requesting review from its owners. Returns the pull request's URL
+func (c *Creator) RenameRepository(ctx context.Context, writer *config.Writer, reposFile, from, to string) (string, error) {
+	return c.openEdit(ctx, RenameBranch(from), func() (configEdit, error) {
+		rename, err := writer.RenameRepository(reposFile, from, to)
+		if err != nil {
+			return configEdit{}, err
+		}
+		return configEdit{
+			Title:  fmt.Sprintf("chore: follow the rename of %s to %s", extractRepoName(from), extractRepoName(to)),
+			Body:   renameBody(rename),
+			Files:  rename.Files,
+			Owners: rename.Owners,
+		}, nil
+	})
+}
+
+// RenameBranch is the branch of the pull request moving a renamed repository's configuration, by its old name
+func RenameBranch(repo string) string {
+	return fmt.Sprintf("rename-repo/%s", extractRepoName(repo))
+}
+
+// renameBody describes the migration of a renamed repository for its pull request
+func renameBody(rename config.Rename) string {
+	owners := "none"
+	if len(rename.Owners) > 0 {
+		owners = strings.Join(rename.Owners, " ")
+	}
+	return fmt.Sprintf(renameBodyTemplate, "`"+rename.From+"`", "`"+rename.To+"`", owners, formatList(rename.Files, "None"))
+}
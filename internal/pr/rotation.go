@@ -0,0 +1,111 @@
+package pr
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Rotation requests review of the pull requests owned by a team from its members in turn instead of the whole team,
+// spreading the reviews of runs opening many pull requests for the same team
+type Rotation struct {
+	// Reviewers is the number of members requested per pull request; one when zero
+	Reviewers int
+	// Skip lists members never requested, e.g. managers or bots, as @user
+	Skip []string
+}
+
+// TeamMembers lists the logins of the members of a team of an organization, e.g. through the forge's API
+type TeamMembers func(ctx context.Context, org, team string) ([]string, error)
+
+// Rotator replaces the rotated teams among the owners of pull requests with their next members, round-robin
+// Members are listed once per team, and the turn carries over from one pull request to the next
+type Rotator struct {
+	rotations map[string]Rotation
+	members   TeamMembers
+
+	mu      sync.Mutex
+	rosters map[string][]string
+	next    map[string]int
+}
+
+// NewRotator creates a rotator for the teams of rotations, by owner (e.g. @konflux-ci/build-team), listing their
+// members with members
+func NewRotator(rotations map[string]Rotation, members TeamMembers) *Rotator {
+	return &Rotator{
+		rotations: rotations,
+		members:   members,
+		rosters:   make(map[string][]string),
+		next:      make(map[string]int),
+	}
+}
+
+// Reviewers returns owners with each rotated team replaced by its next members, as @user, keeping the other owners
+// Teams whose members cannot be listed, or that have none to request, are kept; a nil rotator keeps every owner
+func (r *Rotator) Reviewers(ctx context.Context, owners []string) []string {
+	if r == nil {
+		return owners
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var reviewers []string
+	for _, owner := range owners {
+		rotation, ok := r.rotations[owner]
+		if !ok {
+			reviewers = appendReviewer(reviewers, owner)
+			continue
+		}
+		roster, err := r.roster(ctx, owner, rotation)
+		if err != nil || len(roster) == 0 {
+			if err == nil {
+				err = fmt.Errorf("no members to request")
+			}
+			fmt.Printf("    ⚠️  Warning: requesting review from %s instead of its members: %v\n", owner, err)
+			reviewers = appendReviewer(reviewers, owner)
+			continue
+		}
+		count := min(max(rotation.Reviewers, 1), len(roster))
+		for i := range count {
+			reviewers = appendReviewer(reviewers, "@"+roster[(r.next[owner]+i)%len(roster)])
+		}
+		r.next[owner] = (r.next[owner] + count) % len(roster)
+	}
+	return reviewers
+}
+
+// roster returns the members of a team that may be requested, sorted so turns are reproducible, listing them on
+// first use
+func (r *Rotator) roster(ctx context.Context, team string, rotation Rotation) ([]string, error) {
+	if roster, ok := r.rosters[team]; ok {
+		return roster, nil
+	}
+	org, slug, ok := strings.Cut(strings.TrimPrefix(team, "@"), "/")
+	if !ok {
+		return nil, fmt.Errorf("%s is not an @org/team", team)
+	}
+	members, err := r.members(ctx, org, slug)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list members: %w", err)
+	}
+	var roster []string
+	for _, member := range members {
+		if !slices.Contains(rotation.Skip, "@"+member) {
+			roster = append(roster, member)
+		}
+	}
+	slices.Sort(roster)
+	r.rosters[team] = roster
+	return roster, nil
+}
+
+// appendReviewer appends a reviewer unless already requested, e.g. a member of a rotated team also owning the
+// repository on their own
+func appendReviewer(reviewers []string, reviewer string) []string {
+	if slices.Contains(reviewers, reviewer) {
+		return reviewers
+	}
+	return append(reviewers, reviewer)
+}
@@ -0,0 +1,53 @@
+package pr
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/config"
+)
+
+const transferBodyTemplate = `## Transfer Coverage Dashboard Ownership
+
+This PR hands the coverage dashboard configuration of %s over to new owners.
+
+- **Previous owners:** %s
+- **New owners:** %s
+
+The new owners will review future changes to the configuration, and the dashboard will list the repository under them after the next run.
+
+### Review Checklist
+
+- [ ] Previous owners agree to hand the repository over
+- [ ] New owners accept ownership of the repository's coverage configuration
+
+Changed files:
+
+%s`
+
+// TransferOwnership opens a pull request handing a repository's configuration over to new owners,
+// requesting review from both the previous and the new owners. Returns the pull request's URL
+func (c *Creator) TransferOwnership(ctx context.Context, writer *config.Writer, reposFile, repo string, to []string) (string, error) {
+	return c.openEdit(ctx, fmt.Sprintf("transfer-ownership/%s", extractRepoName(repo)), func() (configEdit, error) {
+		transfer, err := writer.TransferOwnership(reposFile, repo, to)
+		if err != nil {
+			return configEdit{}, err
+		}
+		return configEdit{
+			Title:  fmt.Sprintf("chore: transfer coverage tracking of %s to %s", extractRepoName(repo), strings.Join(transfer.To, " ")),
+			Body:   transferBody(transfer),
+			Files:  transfer.Files,
+			Owners: append(transfer.From, transfer.To...),
+		}, nil
+	})
+}
+
+// transferBody describes an ownership transfer for its pull request
+func transferBody(transfer config.OwnershipTransfer) string {
+	from := "none"
+	if len(transfer.From) > 0 {
+		from = strings.Join(transfer.From, " ")
+	}
+	return fmt.Sprintf(transferBodyTemplate, "`"+transfer.Repo+"`", from, strings.Join(transfer.To, " "), formatList(transfer.Files, "None"))
+}
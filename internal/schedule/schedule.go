@@ -0,0 +1,230 @@
+// Package schedule computes when the dashboard runs next from cron expressions, such as those of its workflow
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultWorkflow is the workflow whose schedule runs the dashboard
+const DefaultWorkflow = ".github/workflows/coverage.yml"
+
+// horizon bounds the search for the next run of expressions that never match, e.g. "0 0 30 2 *"
+const horizon = 5 * 366 * 24 * time.Hour
+
+// Schedule is a set of cron expressions evaluated in UTC, as GitHub Actions does
+type Schedule struct {
+	// Expressions are the cron expressions of the schedule
+	Expressions []string
+	crons       []cron
+}
+
+// cron is a parsed five-field cron expression, each field the set of values it matches
+type cron struct {
+	minutes, hours, days, months, weekdays [64]bool
+	// anyDay and anyWeekday are set for * fields; cron matches days when either restricted field does
+	anyDay, anyWeekday bool
+}
+
+// field are the bounds of a cron field
+type field struct {
+	name     string
+	min, max int
+}
+
+var fields = []field{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+// Parse parses cron expressions of five fields: minute, hour, day of month, month and day of week
+// Fields are *, numbers, ranges and lists of them, each optionally stepped with /n
+func Parse(expressions ...string) (*Schedule, error) {
+	if len(expressions) == 0 {
+		return nil, errors.New("no cron expression")
+	}
+	schedule := &Schedule{}
+	for _, expression := range expressions {
+		c, err := parseCron(expression)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %w", expression, err)
+		}
+		schedule.Expressions = append(schedule.Expressions, expression)
+		schedule.crons = append(schedule.crons, c)
+	}
+	return schedule, nil
+}
+
+// FromWorkflow parses the schedule of a GitHub Actions workflow, nil when it has none
+func FromWorkflow(path string) (*Schedule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var workflow struct {
+		On yaml.Node `yaml:"on"`
+	}
+	if err := yaml.Unmarshal(data, &workflow); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	// on may also be a single event or a list of events, neither scheduled
+	if workflow.On.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	var triggers struct {
+		Schedule []struct {
+			Cron string `yaml:"cron"`
+		} `yaml:"schedule"`
+	}
+	if err := workflow.On.Decode(&triggers); err != nil {
+		return nil, fmt.Errorf("failed to parse the triggers of %s: %w", path, err)
+	}
+	if len(triggers.Schedule) == 0 {
+		return nil, nil
+	}
+
+	var expressions []string
+	for _, trigger := range triggers.Schedule {
+		expressions = append(expressions, trigger.Cron)
+	}
+	schedule, err := Parse(expressions...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the schedule of %s: %w", path, err)
+	}
+	return schedule, nil
+}
+
+// Next returns the first run of the schedule after a time, in UTC, or the zero time when it never runs
+func (s *Schedule) Next(after time.Time) time.Time {
+	var next time.Time
+	for _, c := range s.crons {
+		if run := c.next(after); !run.IsZero() && (next.IsZero() || run.Before(next)) {
+			next = run
+		}
+	}
+	return next
+}
+
+// String returns the expressions of the schedule
+func (s *Schedule) String() string {
+	return strings.Join(s.Expressions, ", ")
+}
+
+func parseCron(expression string) (cron, error) {
+	parts := strings.Fields(expression)
+	if len(parts) != len(fields) {
+		return cron{}, fmt.Errorf("expected %d fields, got %d", len(fields), len(parts))
+	}
+
+	var c cron
+	sets := []*[64]bool{&c.minutes, &c.hours, &c.days, &c.months, &c.weekdays}
+	for i, part := range parts {
+		if err := parseField(part, fields[i], sets[i]); err != nil {
+			return cron{}, err
+		}
+	}
+	// 7 is another name of Sunday
+	if c.weekdays[7] {
+		c.weekdays[0] = true
+	}
+	c.anyDay = parts[2] == "*"
+	c.anyWeekday = parts[4] == "*"
+	return c, nil
+}
+
+// parseField marks the values a field matches in set
+func parseField(part string, f field, set *[64]bool) error {
+	for _, item := range strings.Split(part, ",") {
+		rangePart, stepPart, stepped := strings.Cut(item, "/")
+		step := 1
+		if stepped {
+			var err error
+			step, err = strconv.Atoi(stepPart)
+			if err != nil || step < 1 {
+				return fmt.Errorf("invalid step %q in %s field", stepPart, f.name)
+			}
+		}
+
+		low, high := f.min, f.max
+		if rangePart != "*" {
+			lowPart, highPart, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if low, err = parseValue(lowPart, f); err != nil {
+				return err
+			}
+			high = low
+			if isRange {
+				if high, err = parseValue(highPart, f); err != nil {
+					return err
+				}
+				if high < low {
+					return fmt.Errorf("range %q of %s field ends before it starts", rangePart, f.name)
+				}
+			} else if stepped {
+				// n/step starts at n and runs to the end of the field
+				high = f.max
+			}
+		}
+
+		for value := low; value <= high; value += step {
+			set[value] = true
+		}
+	}
+	return nil
+}
+
+func parseValue(value string, f field) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q in %s field", value, f.name)
+	}
+	if n < f.min || n > f.max {
+		return 0, fmt.Errorf("%s %d is not between %d and %d", f.name, n, f.min, f.max)
+	}
+	return n, nil
+}
+
+// next returns the first minute after a time the expression matches, or the zero time within the horizon
+func (c cron) next(after time.Time) time.Time {
+	t := after.UTC().Truncate(time.Minute).Add(time.Minute)
+	for limit := t.Add(horizon); t.Before(limit); {
+		switch {
+		case !c.months[int(t.Month())]:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		case !c.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+		case !c.hours[t.Hour()]:
+			t = t.Truncate(time.Hour).Add(time.Hour)
+		case !c.minutes[t.Minute()]:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// matchesDay matches the day of month and day of week fields; when both are restricted either may match
+func (c cron) matchesDay(t time.Time) bool {
+	day, weekday := c.days[t.Day()], c.weekdays[int(t.Weekday())]
+	switch {
+	case c.anyDay && c.anyWeekday:
+		return true
+	case c.anyDay:
+		return weekday
+	case c.anyWeekday:
+		return day
+	default:
+		return day || weekday
+	}
+}
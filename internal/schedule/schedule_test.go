@@ -0,0 +1,70 @@
+package schedule_test
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/schedule"
+)
+
+var _ = Describe("Schedule", func() {
+	// A Friday
+	moment := time.Date(2024, 5, 3, 18, 30, 0, 0, time.UTC)
+
+	next := func(expressions ...string) time.Time {
+		s, err := schedule.Parse(expressions...)
+		Expect(err).NotTo(HaveOccurred())
+		return s.Next(moment)
+	}
+
+	It("should find the next run of daily and weekly schedules", func() {
+		Expect(next("0 3 * * *")).To(Equal(time.Date(2024, 5, 4, 3, 0, 0, 0, time.UTC)))
+		Expect(next("0 2 * * 2")).To(Equal(time.Date(2024, 5, 7, 2, 0, 0, 0, time.UTC)))
+		Expect(next("45 18 * * *")).To(Equal(time.Date(2024, 5, 3, 18, 45, 0, 0, time.UTC)))
+	})
+
+	It("should support steps, ranges, lists and Sunday as 7", func() {
+		Expect(next("*/20 * * * *")).To(Equal(time.Date(2024, 5, 3, 18, 40, 0, 0, time.UTC)))
+		Expect(next("0 9-17/4 * * 1-5")).To(Equal(time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC)))
+		Expect(next("0 0 1,15 * *")).To(Equal(time.Date(2024, 5, 15, 0, 0, 0, 0, time.UTC)))
+		Expect(next("0 12 * * 7")).To(Equal(time.Date(2024, 5, 5, 12, 0, 0, 0, time.UTC)))
+	})
+
+	It("should run when either the day of month or the day of week matches", func() {
+		Expect(next("0 0 31 * 1")).To(Equal(time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC)))
+	})
+
+	It("should pick the earliest of several expressions", func() {
+		Expect(next("0 3 * * *", "0 20 * * *")).To(Equal(time.Date(2024, 5, 3, 20, 0, 0, 0, time.UTC)))
+	})
+
+	It("should return the zero time for expressions that never match", func() {
+		Expect(next("0 0 30 2 *")).To(BeZero())
+	})
+
+	It("should reject invalid expressions", func() {
+		for _, expression := range []string{"0 3 * *", "60 * * * *", "0 5-1 * * *", "*/0 * * * *", "@daily"} {
+			_, err := schedule.Parse(expression)
+			Expect(err).To(HaveOccurred(), expression)
+		}
+	})
+
+	It("should read the schedule of a workflow", func() {
+		dir := GinkgoT().TempDir()
+		scheduled := filepath.Join(dir, "scheduled.yml")
+		Expect(os.WriteFile(scheduled, []byte("on:\n  push:\n  schedule:\n    - cron: '0 3 * * *'\n"), 0644)).To(Succeed())
+		s, err := schedule.FromWorkflow(scheduled)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s.String()).To(Equal("0 3 * * *"))
+
+		unscheduled := filepath.Join(dir, "unscheduled.yml")
+		Expect(os.WriteFile(unscheduled, []byte("on: push\n"), 0644)).To(Succeed())
+		s, err = schedule.FromWorkflow(unscheduled)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s).To(BeNil())
+	})
+})
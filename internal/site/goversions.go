@@ -0,0 +1,137 @@
+package site
+
+import (
+	"bytes"
+	"fmt"
+	"go/version"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/collect"
+	"github.com/konflux-ci/coverage-dashboard/internal/locale"
+)
+
+// GoVersionsFile is the page of the Go versions of the tracked repositories, relative to the site
+const GoVersionsFile = "go-versions.html"
+
+// goVersionsTemplate renders the Go versions page
+var goVersionsTemplate = template.Must(template.ParseFS(templates, "templates/go-versions.html"))
+
+// GoVersionRow is the Go of a repository, from the go.mod files its last collection read
+type GoVersionRow struct {
+	Repo      string
+	Owners    []string
+	GoVersion string
+	Toolchain string
+	// Supported is set when the collector's Go builds the repository without downloading a newer toolchain
+	Supported bool
+	// Switches is set when the toolchain directive is newer than the collector's Go, so the go command downloads
+	// the toolchain, when allowed, and the tests run on another Go than the provenance records
+	Switches bool
+}
+
+// GoRelease counts the repositories declaring a Go release, e.g. "1.22"
+type GoRelease struct {
+	Release   string
+	Repos     int
+	Supported bool
+}
+
+// GoVersions is the Go version compatibility of the tracked Go repositories, planning upgrades of the organization
+type GoVersions struct {
+	// Collector is the Go that ran the tests, empty when the dashboard does not record it
+	Collector string
+	// Rows lists the repositories, oldest Go first, then those whose go.mod was not read
+	Rows []GoVersionRow
+	// Releases counts the repositories of each Go release, oldest first
+	Releases []GoRelease
+	// Unsupported counts the repositories declaring a newer Go than the collector's
+	Unsupported int
+}
+
+// NewGoVersions summarizes the Go versions of the dashboard's Go repositories against the collector's Go
+func NewGoVersions(dashboard collect.Dashboard) GoVersions {
+	var versions GoVersions
+	if dashboard.Provenance != nil {
+		versions.Collector = dashboard.Provenance.GoVersion
+	}
+
+	releases := make(map[string]*GoRelease)
+	for _, result := range dashboard.Data {
+		if result.Status == collect.StatusUnsupported {
+			continue
+		}
+		row := GoVersionRow{
+			Repo:      result.Repo,
+			Owners:    result.Owners,
+			GoVersion: result.GoVersion,
+			Toolchain: result.Toolchain,
+			Supported: collect.GoSupported(versions.Collector, result.GoVersion),
+			Switches:  result.Toolchain != "" && !collect.GoSupported(versions.Collector, strings.TrimPrefix(result.Toolchain, "go")),
+		}
+		versions.Rows = append(versions.Rows, row)
+		if !row.Supported {
+			versions.Unsupported++
+		}
+		if row.GoVersion == "" {
+			continue
+		}
+		release := strings.TrimPrefix(version.Lang("go"+row.GoVersion), "go")
+		if releases[release] == nil {
+			releases[release] = &GoRelease{Release: release, Supported: collect.GoSupported(versions.Collector, release)}
+		}
+		releases[release].Repos++
+	}
+
+	sort.SliceStable(versions.Rows, func(i, j int) bool {
+		a, b := versions.Rows[i], versions.Rows[j]
+		if less, decided := compareMissing(a.GoVersion == "", b.GoVersion == "", func() (bool, bool) {
+			c := version.Compare("go"+a.GoVersion, "go"+b.GoVersion)
+			return c < 0, c != 0
+		}); decided {
+			return less
+		}
+		return a.Repo < b.Repo
+	})
+	for _, release := range releases {
+		versions.Releases = append(versions.Releases, *release)
+	}
+	sort.Slice(versions.Releases, func(i, j int) bool {
+		return version.Compare("go"+versions.Releases[i].Release, "go"+versions.Releases[j].Release) < 0
+	})
+	return versions
+}
+
+// RenderGoVersions renders the Go versions page of the dashboard
+func RenderGoVersions(dashboard collect.Dashboard, versions GoVersions) (string, error) {
+	var out bytes.Buffer
+	err := goVersionsTemplate.Execute(&out, struct {
+		Dashboard collect.Dashboard
+		Locale    locale.Locale
+		GoVersions
+	}{
+		Dashboard:  dashboard,
+		Locale:     locale.Lookup(dashboard.Locale),
+		GoVersions: versions,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to render Go versions: %w", err)
+	}
+	return out.String(), nil
+}
+
+// WriteGoVersions writes the Go versions page of the dashboard to siteDir, returning its path
+func WriteGoVersions(siteDir string, dashboard collect.Dashboard, versions GoVersions) (string, error) {
+	page, err := RenderGoVersions(dashboard, versions)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(siteDir, GoVersionsFile)
+	if err := os.WriteFile(path, []byte(page), 0644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return path, nil
+}
@@ -0,0 +1,57 @@
+package site_test
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/collect"
+	"github.com/konflux-ci/coverage-dashboard/internal/site"
+)
+
+var _ = Describe("Go versions", func() {
+	dashboard := collect.Dashboard{
+		Provenance: &collect.Provenance{GoVersion: "go1.22.4"},
+		Data: []collect.Result{
+			{Repo: "org/new", Status: collect.StatusOK, GoVersion: "1.23", Owners: []string{"@org/build"}},
+			{Repo: "org/old", Status: collect.StatusOK, GoVersion: "1.20"},
+			{Repo: "org/current", Status: collect.StatusOK, GoVersion: "1.22.1", Toolchain: "go1.23.2"},
+			{Repo: "org/clone", Status: collect.StatusFailed},
+			{Repo: "org/ui", Status: collect.StatusUnsupported},
+			{Repo: "org/also-old", Status: collect.StatusOK, GoVersion: "1.20.3"},
+		},
+	}
+
+	It("should list the Go repositories oldest Go first, against the collector's Go", func() {
+		versions := site.NewGoVersions(dashboard)
+		Expect(versions.Collector).To(Equal("go1.22.4"))
+		Expect(versions.Rows).To(Equal([]site.GoVersionRow{
+			{Repo: "org/old", GoVersion: "1.20", Supported: true},
+			{Repo: "org/also-old", GoVersion: "1.20.3", Supported: true},
+			{Repo: "org/current", GoVersion: "1.22.1", Toolchain: "go1.23.2", Supported: true, Switches: true},
+			{Repo: "org/new", Owners: []string{"@org/build"}, GoVersion: "1.23"},
+			{Repo: "org/clone", Supported: true},
+		}))
+		Expect(versions.Releases).To(Equal([]site.GoRelease{
+			{Release: "1.20", Repos: 2, Supported: true},
+			{Release: "1.22", Repos: 1, Supported: true},
+			{Release: "1.23", Repos: 1},
+		}))
+		Expect(versions.Unsupported).To(Equal(1))
+	})
+
+	It("should write the page to the site", func() {
+		siteDir := GinkgoT().TempDir()
+		path, err := site.WriteGoVersions(siteDir, dashboard, site.NewGoVersions(dashboard))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(path).To(Equal(filepath.Join(siteDir, site.GoVersionsFile)))
+		page, err := os.ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(page)).To(ContainSubstring("<strong>go1.22.4</strong>"))
+		Expect(string(page)).To(ContainSubstring("❌ Needs Go 1.23"))
+		Expect(string(page)).To(ContainSubstring("go1.23.2 <span class=\"muted\">(downloaded)</span>"))
+		Expect(string(page)).NotTo(ContainSubstring("org/ui"))
+	})
+})
@@ -0,0 +1,144 @@
+package site
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"math"
+	"os"
+	"path/filepath"
+	"unicode/utf8"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/collect"
+	"github.com/konflux-ci/coverage-dashboard/internal/locale"
+	"github.com/konflux-ci/coverage-dashboard/internal/policy"
+)
+
+const (
+	// HeadlineFile is the headline's JSON endpoint, relative to the site
+	HeadlineFile = "api/headline.json"
+	// HeadlineBadgeFile is the headline's SVG badge, relative to the site, e.g. for the organization's README
+	HeadlineBadgeFile = "badges/headline.svg"
+)
+
+// badgeColors are the badge colors of the dashboard's coverage colors
+var badgeColors = map[string]string{
+	"red":    "#e05d44",
+	"orange": "#fe7d37",
+	"green":  "#44cc11",
+	"grey":   "#9f9f9f",
+}
+
+// badgeTemplate renders a flat badge in the style of shields.io
+var badgeTemplate = template.Must(template.ParseFS(templates, "templates/badge.svg"))
+
+// Headline is the coverage of the organization, combining the tracked repositories the policy includes
+type Headline struct {
+	Label string `json:"label"`
+	// Coverage is the combined coverage, nil when no included repository was measured
+	Coverage *float64 `json:"coverage"`
+	// Weight is how repositories weigh in the coverage, one of the policy.Weight values
+	Weight string `json:"weight"`
+	// Repos counts the repositories combined; Statements sums their statements
+	Repos      int `json:"repos"`
+	Statements int `json:"statements,omitempty"`
+	// Unmeasured counts the included repositories left out for lack of coverage or, weighing by statements,
+	// of a statement count, e.g. repositories failing to collect or in languages not collected yet
+	Unmeasured int `json:"unmeasured"`
+	// Excluded lists the repositories the policy leaves out, with the reason
+	Excluded map[string]string `json:"excluded"`
+	// Color is the dashboard's color of the coverage: green, orange or red
+	Color  string `json:"color"`
+	RunURL string `json:"run_url,omitempty"`
+}
+
+// NewHeadline combines the coverage of the dashboard's repositories under the policy's headline rules
+func NewHeadline(dashboard collect.Dashboard, rules policy.Headline) Headline {
+	headline := Headline{Label: rules.BadgeLabel(), Weight: rules.Weighting(), Excluded: map[string]string{}, RunURL: dashboard.RunURL}
+
+	var sum, weights float64
+	for _, result := range dashboard.Data {
+		if reason := rules.Excludes(result.Repo, result.Groups, result.Stale); reason != "" {
+			headline.Excluded[result.Repo] = reason
+			continue
+		}
+		weight := 1.0
+		if headline.Weight == policy.WeightStatements {
+			weight = float64(result.Statements)
+		}
+		if result.Coverage == nil || weight == 0 {
+			headline.Unmeasured++
+			continue
+		}
+		headline.Repos++
+		headline.Statements += result.Statements
+		sum += *result.Coverage * weight
+		weights += weight
+	}
+
+	if weights > 0 {
+		coverage := math.Round(sum/weights*10) / 10
+		headline.Coverage = &coverage
+	}
+	headline.Color = collect.CoverageColor(headline.Coverage)
+	return headline
+}
+
+// Badge renders the headline as an SVG badge, its coverage formatted for loc
+func (h Headline) Badge(loc locale.Locale) (string, error) {
+	message, color := "unknown", badgeColors["grey"]
+	if h.Coverage != nil {
+		message, color = loc.Percent(*h.Coverage), badgeColors[h.Color]
+	}
+	// Verdana at 11px averages about 7px per character
+	labelWidth := 10 + 7*utf8.RuneCountInString(h.Label)
+	messageWidth := 10 + 7*utf8.RuneCountInString(message)
+
+	var out bytes.Buffer
+	err := badgeTemplate.Execute(&out, map[string]any{
+		"Label":        h.Label,
+		"Message":      message,
+		"Color":        color,
+		"Width":        labelWidth + messageWidth,
+		"LabelWidth":   labelWidth,
+		"MessageWidth": messageWidth,
+		"LabelX":       float64(labelWidth) / 2,
+		"MessageX":     float64(labelWidth) + float64(messageWidth)/2,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to render headline badge: %w", err)
+	}
+	return out.String(), nil
+}
+
+// WriteHeadline writes the headline's JSON endpoint and badge to siteDir, returning their paths
+func WriteHeadline(siteDir string, h Headline, loc locale.Locale) ([]string, error) {
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal headline: %w", err)
+	}
+	badge, err := h.Badge(loc)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []struct {
+		path    string
+		content []byte
+	}{
+		{filepath.Join(siteDir, HeadlineFile), append(data, '\n')},
+		{filepath.Join(siteDir, HeadlineBadgeFile), []byte(badge)},
+	}
+	var paths []string
+	for _, file := range files {
+		if err := os.MkdirAll(filepath.Dir(file.path), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create %s: %w", filepath.Dir(file.path), err)
+		}
+		if err := os.WriteFile(file.path, file.content, 0644); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", file.path, err)
+		}
+		paths = append(paths, file.path)
+	}
+	return paths, nil
+}
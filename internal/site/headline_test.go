@@ -0,0 +1,77 @@
+package site_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/collect"
+	"github.com/konflux-ci/coverage-dashboard/internal/locale"
+	"github.com/konflux-ci/coverage-dashboard/internal/policy"
+	"github.com/konflux-ci/coverage-dashboard/internal/site"
+)
+
+var _ = Describe("Headline", func() {
+	large, small, legacy := 80.0, 40.0, 10.0
+	dashboard := collect.Dashboard{
+		RunURL: "https://github.com/konflux-ci/coverage-dashboard/actions/runs/1",
+		Data: []collect.Result{
+			{Repo: "konflux-ci/build-service", Coverage: &large, Statements: 3000},
+			{Repo: "konflux-ci/cli", Coverage: &small, Statements: 1000},
+			{Repo: "konflux-ci/legacy-api", Coverage: &legacy, Statements: 5000},
+			{Repo: "konflux-ci/paused", Coverage: &legacy, Statements: 5000, Stale: true},
+			{Repo: "konflux-ci/ui", Status: collect.StatusUnsupported},
+		},
+	}
+	rules := policy.Headline{Exclude: []string{"konflux-ci/legacy-*"}}
+
+	It("should weigh the included repositories by their statements", func() {
+		headline := site.NewHeadline(dashboard, rules)
+		Expect(*headline.Coverage).To(Equal(70.0))
+		Expect(headline.Label).To(Equal("Konflux coverage"))
+		Expect(headline.Repos).To(Equal(2))
+		Expect(headline.Statements).To(Equal(4000))
+		Expect(headline.Unmeasured).To(Equal(1))
+		Expect(headline.Color).To(Equal("orange"))
+		Expect(headline.Excluded).To(Equal(map[string]string{
+			"konflux-ci/legacy-api": `excluded by "konflux-ci/legacy-*"`,
+			"konflux-ci/paused":     "stale",
+		}))
+	})
+
+	It("should weigh every repository the same when configured", func() {
+		rules := rules
+		rules.Weight = policy.WeightRepository
+		Expect(*site.NewHeadline(dashboard, rules).Coverage).To(Equal(60.0))
+	})
+
+	It("should write the JSON endpoint and the badge", func() {
+		dir := GinkgoT().TempDir()
+		headline := site.NewHeadline(dashboard, rules)
+		paths, err := site.WriteHeadline(dir, headline, locale.Lookup("de-DE"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(paths).To(Equal([]string{filepath.Join(dir, site.HeadlineFile), filepath.Join(dir, site.HeadlineBadgeFile)}))
+
+		data, err := os.ReadFile(filepath.Join(dir, site.HeadlineFile))
+		Expect(err).NotTo(HaveOccurred())
+		var endpoint map[string]any
+		Expect(json.Unmarshal(data, &endpoint)).To(Succeed())
+		Expect(endpoint["coverage"]).To(Equal(70.0))
+
+		badge, err := os.ReadFile(filepath.Join(dir, site.HeadlineBadgeFile))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(badge)).To(HavePrefix(`<svg xmlns="http://www.w3.org/2000/svg"`))
+		Expect(string(badge)).To(ContainSubstring(`aria-label="Konflux coverage: 70,0`))
+		Expect(string(badge)).To(ContainSubstring(`fill="#fe7d37"`))
+	})
+
+	It("should render an unknown badge without measured repositories", func() {
+		badge, err := site.NewHeadline(collect.Dashboard{}, policy.Headline{Label: "Org <coverage>"}).Badge(locale.Lookup(""))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(badge).To(ContainSubstring("Org &lt;coverage&gt;: unknown"))
+		Expect(badge).To(ContainSubstring(`fill="#9f9f9f"`))
+	})
+})
@@ -0,0 +1,255 @@
+package site
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html/template"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/collect"
+	"github.com/konflux-ci/coverage-dashboard/internal/locale"
+)
+
+// TableDir is the directory of the site holding the sorted tables, next to index.html
+const TableDir = "table"
+
+// Sort keys of the tables, each published as by-<key>.html
+const (
+	SortCoverage = "coverage"
+	SortDelta    = "delta"
+	SortTeam     = "team"
+	SortName     = "name"
+)
+
+// SortKeys lists the sort keys in the order of the table's columns
+var SortKeys = []string{SortName, SortTeam, SortCoverage, SortDelta}
+
+// columns describes the sortable column of each sort key
+var columns = map[string]struct {
+	Label string
+	// Descending is set for the keys whose most useful order is highest first
+	Descending bool
+	// AccessKey jumps to the table sorted by the column, e.g. Alt+Shift+C in most browsers
+	AccessKey string
+}{
+	SortName:     {Label: "Repository", AccessKey: "n"},
+	SortTeam:     {Label: "Team", AccessKey: "t"},
+	SortCoverage: {Label: "Coverage", Descending: true, AccessKey: "c"},
+	SortDelta:    {Label: "Change", Descending: true, AccessKey: "d"},
+}
+
+//go:embed templates
+var templates embed.FS
+
+// tableTemplate renders a table of the repositories sorted by one key
+var tableTemplate = template.Must(template.ParseFS(templates, "templates/table.html"))
+
+// Row is a repository of the table
+type Row struct {
+	Repo     string
+	Coverage *float64
+	// Delta is the change of coverage since the previous measurement, in percentage points
+	Delta  *float64
+	Owners []string
+	Status string
+	Stale  bool
+	// Private is set for repositories whose detailed reports are not published
+	Private bool
+}
+
+// Team is the first owner of the repository, which the team sort orders by
+func (r Row) Team() string {
+	if len(r.Owners) == 0 {
+		return ""
+	}
+	return r.Owners[0]
+}
+
+// FileName returns the name of the page of the table sorted by key
+func FileName(key string) string {
+	return "by-" + key + ".html"
+}
+
+// Deltas returns the change of coverage of each repository between its last two measurements, from the trends of a
+// run manifest; repositories measured less than twice have none
+func Deltas(m *collect.Manifest) map[string]float64 {
+	deltas := make(map[string]float64)
+	for _, run := range m.Repos {
+		points := m.Trends[run.ConfigFile]
+		if len(points) < 2 {
+			continue
+		}
+		delta := points[len(points)-1].Coverage - points[len(points)-2].Coverage
+		deltas[run.Result.Repo] = math.Round(delta*10) / 10
+	}
+	return deltas
+}
+
+// NewRows builds the rows of the dashboard's results, with their deltas
+func NewRows(results []collect.Result, deltas map[string]float64) []Row {
+	rows := make([]Row, 0, len(results))
+	for _, result := range results {
+		row := Row{Repo: result.Repo, Coverage: result.Coverage, Owners: result.Owners, Status: result.Status, Stale: result.Stale, Private: result.Private}
+		if delta, ok := deltas[result.Repo]; ok {
+			row.Delta = &delta
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// Sort sorts rows by a key, breaking ties by repository name
+// Rows without a value for the key, e.g. unmeasured repositories sorted by coverage, come last
+func Sort(rows []Row, key string) error {
+	var less func(a, b Row) (bool, bool)
+	switch key {
+	case SortName:
+		less = func(a, b Row) (bool, bool) { return false, false }
+	case SortTeam:
+		less = func(a, b Row) (bool, bool) {
+			return compareMissing(a.Team() == "", b.Team() == "", func() (bool, bool) {
+				return a.Team() < b.Team(), a.Team() != b.Team()
+			})
+		}
+	case SortCoverage:
+		less = func(a, b Row) (bool, bool) {
+			return compareMissing(a.Coverage == nil, b.Coverage == nil, func() (bool, bool) {
+				return *a.Coverage > *b.Coverage, *a.Coverage != *b.Coverage
+			})
+		}
+	case SortDelta:
+		less = func(a, b Row) (bool, bool) {
+			return compareMissing(a.Delta == nil, b.Delta == nil, func() (bool, bool) {
+				return *a.Delta > *b.Delta, *a.Delta != *b.Delta
+			})
+		}
+	default:
+		return fmt.Errorf("unknown sort key %q, expected one of %s", key, strings.Join(SortKeys, ", "))
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		if result, decided := less(rows[i], rows[j]); decided {
+			return result
+		}
+		return rows[i].Repo < rows[j].Repo
+	})
+	return nil
+}
+
+// compareMissing orders rows missing a value after the others, comparing the values when both have one
+func compareMissing(aMissing, bMissing bool, compare func() (less, decided bool)) (bool, bool) {
+	switch {
+	case aMissing && bMissing:
+		return false, false
+	case aMissing != bMissing:
+		return bMissing, true
+	default:
+		return compare()
+	}
+}
+
+// header is a column header of a table page
+type header struct {
+	Label     string
+	Href      string
+	AccessKey string
+	// AriaSort is the aria-sort attribute of the column, empty for columns the page is not sorted by
+	AriaSort string
+	Current  bool
+}
+
+// cell is a row of a table page, formatted for the dashboard's locale
+type cell struct {
+	Row
+	Coverage string
+	Delta    string
+	// Trend is "up", "down" or empty, styling the delta; the template adds the plus sign of increases
+	Trend string
+}
+
+// Render renders the page of the dashboard's rows sorted by key
+func Render(dashboard collect.Dashboard, rows []Row, key string) (string, error) {
+	sorted := append([]Row(nil), rows...)
+	if err := Sort(sorted, key); err != nil {
+		return "", err
+	}
+	loc := locale.Lookup(dashboard.Locale)
+
+	headers := make([]header, 0, len(SortKeys))
+	for _, k := range SortKeys {
+		h := header{Label: columns[k].Label, Href: FileName(k), AccessKey: columns[k].AccessKey, Current: k == key}
+		if h.Current {
+			h.AriaSort = "ascending"
+			if columns[k].Descending {
+				h.AriaSort = "descending"
+			}
+		}
+		headers = append(headers, h)
+	}
+
+	cells := make([]cell, 0, len(sorted))
+	for _, row := range sorted {
+		c := cell{Row: row, Coverage: "N/A", Delta: "—"}
+		if row.Coverage != nil {
+			c.Coverage = loc.Percent(*row.Coverage)
+		}
+		if row.Delta != nil {
+			c.Delta = loc.Number(*row.Delta, 1)
+			switch {
+			case *row.Delta > 0:
+				c.Trend = "up"
+			case *row.Delta < 0:
+				c.Trend = "down"
+			}
+		}
+		cells = append(cells, c)
+	}
+
+	var out bytes.Buffer
+	err := tableTemplate.Execute(&out, struct {
+		Dashboard collect.Dashboard
+		Locale    locale.Locale
+		Label     string
+		Headers   []header
+		Rows      []cell
+	}{
+		Dashboard: dashboard,
+		Locale:    loc,
+		Label:     columns[key].Label,
+		Headers:   headers,
+		Rows:      cells,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to render table sorted by %s: %w", key, err)
+	}
+	return out.String(), nil
+}
+
+// Generate writes the table of the dashboard sorted by every key to the table directory of siteDir, so sorting
+// works by following links, without JavaScript. It returns the paths of the pages written
+func Generate(siteDir string, dashboard collect.Dashboard, deltas map[string]float64) ([]string, error) {
+	dir := filepath.Join(siteDir, TableDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create table directory: %w", err)
+	}
+
+	rows := NewRows(dashboard.Data, deltas)
+	var paths []string
+	for _, key := range SortKeys {
+		page, err := Render(dashboard, rows, key)
+		if err != nil {
+			return nil, err
+		}
+		path := filepath.Join(dir, FileName(key))
+		if err := os.WriteFile(path, []byte(page), 0644); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
@@ -0,0 +1,98 @@
+package site_test
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/collect"
+	"github.com/konflux-ci/coverage-dashboard/internal/site"
+)
+
+var _ = Describe("Sorted tables", func() {
+	high, low := 81.4, 42.5
+	rows := func() []site.Row {
+		up, down := 2.5, -1.0
+		return []site.Row{
+			{Repo: "org/c", Coverage: &low, Delta: &up, Owners: []string{"@org/build"}, Status: collect.StatusOK},
+			{Repo: "org/a", Status: collect.StatusFailed},
+			{Repo: "org/b", Coverage: &high, Delta: &down, Owners: []string{"@org/alpha", "@org/build"}, Status: collect.StatusOK},
+		}
+	}
+	repos := func(rows []site.Row) []string {
+		var names []string
+		for _, row := range rows {
+			names = append(names, row.Repo)
+		}
+		return names
+	}
+
+	DescribeTable("should sort by each key, leaving rows without a value last",
+		func(key string, expected []string) {
+			sorted := rows()
+			Expect(site.Sort(sorted, key)).To(Succeed())
+			Expect(repos(sorted)).To(Equal(expected))
+		},
+		Entry("name", site.SortName, []string{"org/a", "org/b", "org/c"}),
+		Entry("team", site.SortTeam, []string{"org/b", "org/c", "org/a"}),
+		Entry("coverage", site.SortCoverage, []string{"org/b", "org/c", "org/a"}),
+		Entry("delta", site.SortDelta, []string{"org/c", "org/b", "org/a"}),
+	)
+
+	It("should reject unknown sort keys", func() {
+		Expect(site.Sort(rows(), "stars")).To(MatchError(ContainSubstring(`unknown sort key "stars"`)))
+	})
+
+	It("should compute the change between the last two measurements of each repository", func() {
+		day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+		manifest := &collect.Manifest{
+			Repos: []collect.RepoRun{
+				{ConfigFile: "repos/b.yaml", Result: collect.Result{Repo: "org/b"}},
+				{ConfigFile: "repos/c.yaml", Result: collect.Result{Repo: "org/c"}},
+			},
+			Trends: map[string][]collect.TrendPoint{
+				"repos/b.yaml": {{Date: day, Coverage: 60}, {Date: day.AddDate(0, 0, 1), Coverage: 70.1}, {Date: day.AddDate(0, 0, 2), Coverage: 68.2}},
+				"repos/c.yaml": {{Date: day, Coverage: 50}},
+			},
+		}
+		Expect(site.Deltas(manifest)).To(Equal(map[string]float64{"org/b": -1.9}))
+	})
+
+	It("should render sort links with the sorted column announced", func() {
+		html, err := site.Render(collect.Dashboard{Locale: "de-DE"}, rows(), site.SortCoverage)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(html).To(ContainSubstring(`<html lang="de-DE">`))
+		Expect(html).To(ContainSubstring(`<a class="skip" href="#repositories">`))
+		Expect(html).To(ContainSubstring(`<th scope="col" aria-sort="descending"><a href="by-coverage.html" accesskey="c" aria-current="page">Coverage</a></th>`))
+		Expect(html).To(ContainSubstring(`<th scope="col"><a href="by-delta.html" accesskey="d">Change</a></th>`))
+		Expect(html).To(ContainSubstring(`<a href="../coverage/org/b/index.html">81,4` + " %</a>"))
+		Expect(html).To(ContainSubstring(`<td class="number up">+2,5</td>`))
+		Expect(html).To(ContainSubstring(`<td class="number down">-1,0</td>`))
+		Expect(html).To(ContainSubstring(`N/A <span class="muted">(failed)</span>`))
+		Expect(html).NotTo(ContainSubstring("<script"))
+	})
+
+	It("should not link the unpublished reports of private repositories", func() {
+		private := []site.Row{{Repo: "org/secret", Coverage: &high, Status: collect.StatusOK, Private: true}}
+		html, err := site.Render(collect.Dashboard{}, private, site.SortName)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(html).To(ContainSubstring("81.4"))
+		Expect(html).NotTo(ContainSubstring("../coverage/org/secret/index.html"))
+	})
+
+	It("should write a page per sort key to the table directory", func() {
+		dir := GinkgoT().TempDir()
+		dashboard := collect.Dashboard{Data: []collect.Result{{Repo: "org/b", Coverage: &high, Status: collect.StatusOK}}}
+		paths, err := site.Generate(dir, dashboard, map[string]float64{"org/b": 1.5})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(paths).To(HaveLen(len(site.SortKeys)))
+
+		content, err := os.ReadFile(filepath.Join(dir, site.TableDir, "by-delta.html"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(content)).To(ContainSubstring(`<td class="number up">+1.5</td>`))
+		Expect(string(content)).To(ContainSubstring("1 repositories sorted by Change"))
+	})
+})
@@ -0,0 +1,105 @@
+package sonarqube
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultURL is the API of SonarCloud
+	DefaultURL = "https://sonarcloud.io"
+	// DefaultKeyFormat names SonarCloud projects after their GitHub repository, e.g. konflux-ci_build-service
+	DefaultKeyFormat = "{org}_{name}"
+	// TokenEnv names the environment variable holding the SonarQube token
+	TokenEnv = "SONAR_TOKEN"
+)
+
+// Client reads the coverage of projects from the SonarQube web API
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+	token      string
+	keyFormat  string
+}
+
+// NewClient creates a Client for the SonarQube server at baseURL, authenticated with token
+// keyFormat maps repositories to project keys, replacing {org} and {name}; it defaults to DefaultKeyFormat
+func NewClient(httpClient *http.Client, baseURL, token, keyFormat string) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	if baseURL == "" {
+		baseURL = DefaultURL
+	}
+	if keyFormat == "" {
+		keyFormat = DefaultKeyFormat
+	}
+	return &Client{httpClient: httpClient, baseURL: strings.TrimSuffix(baseURL, "/"), token: token, keyFormat: keyFormat}
+}
+
+// ProjectKey returns the key of the project of a repository, in org/name form
+func (c *Client) ProjectKey(repo string) string {
+	org, name, _ := strings.Cut(repo, "/")
+	return strings.NewReplacer("{org}", org, "{name}", name).Replace(c.keyFormat)
+}
+
+// measures is the response of api/measures/component
+type measures struct {
+	Component struct {
+		Measures []struct {
+			Metric string `json:"metric"`
+			Value  string `json:"value"`
+		} `json:"measures"`
+	} `json:"component"`
+}
+
+// Coverage returns the coverage SonarQube measured on the main branch of a repository's project,
+// or nil when the project does not exist or has no coverage
+func (c *Client) Coverage(ctx context.Context, repo string) (*float64, error) {
+	key := c.ProjectKey(repo)
+	query := url.Values{"component": {key}, "metricKeys": {"coverage"}}
+	endpoint := fmt.Sprintf("%s/api/measures/component?%s", c.baseURL, query.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SonarQube coverage of %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to read SonarQube coverage of %s: GET %s: %s", key, endpoint, resp.Status)
+	}
+
+	var m measures
+	if err := json.NewDecoder(resp.Body).Decode(&m); err != nil {
+		return nil, fmt.Errorf("failed to parse SonarQube coverage of %s: %w", key, err)
+	}
+	for _, measure := range m.Component.Measures {
+		if measure.Metric != "coverage" {
+			continue
+		}
+		coverage, err := strconv.ParseFloat(measure.Value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse SonarQube coverage of %s: %w", key, err)
+		}
+		return &coverage, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,13 @@
+package sonarqube_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestSonarQube(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "SonarQube Suite")
+}
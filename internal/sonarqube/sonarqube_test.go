@@ -0,0 +1,61 @@
+package sonarqube_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/sonarqube"
+)
+
+var _ = Describe("Client", func() {
+	var (
+		server        *httptest.Server
+		authorization string
+	)
+
+	BeforeEach(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.URL.Path).To(Equal("/api/measures/component"))
+			Expect(r.URL.Query().Get("metricKeys")).To(Equal("coverage"))
+			authorization = r.Header.Get("Authorization")
+			switch r.URL.Query().Get("component") {
+			case "konflux-ci_api":
+				fmt.Fprint(w, `{"component": {"key": "konflux-ci_api", "measures": [{"metric": "coverage", "value": "67.4"}]}}`)
+			case "konflux-ci_empty":
+				fmt.Fprint(w, `{"component": {"key": "konflux-ci_empty", "measures": []}}`)
+			case "broken":
+				w.WriteHeader(http.StatusInternalServerError)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		DeferCleanup(server.Close)
+	})
+
+	It("should read the coverage of the project named after the repository", func() {
+		client := sonarqube.NewClient(server.Client(), server.URL, "secret", "")
+		coverage, err := client.Coverage(context.Background(), "konflux-ci/api")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*coverage).To(Equal(67.4))
+		Expect(authorization).To(Equal("Bearer secret"))
+	})
+
+	It("should return no coverage for unknown projects and projects without coverage", func() {
+		client := sonarqube.NewClient(server.Client(), server.URL, "", "")
+		Expect(client.Coverage(context.Background(), "konflux-ci/unknown")).To(BeNil())
+		Expect(client.Coverage(context.Background(), "konflux-ci/empty")).To(BeNil())
+	})
+
+	It("should map repositories to project keys with the key format", func() {
+		client := sonarqube.NewClient(server.Client(), server.URL, "", "broken")
+		Expect(client.ProjectKey("konflux-ci/api")).To(Equal("broken"))
+		_, err := client.Coverage(context.Background(), "konflux-ci/api")
+		Expect(err).To(MatchError(ContainSubstring("failed to read SonarQube coverage of broken")))
+		Expect(sonarqube.NewClient(nil, "", "", "{org}:{name}").ProjectKey("konflux-ci/api")).To(Equal("konflux-ci:api"))
+	})
+})
@@ -0,0 +1,305 @@
+package store
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Environment variables configuring S3, named as the AWS CLI names them
+const (
+	AccessKeyIDEnv     = "AWS_ACCESS_KEY_ID"
+	SecretAccessKeyEnv = "AWS_SECRET_ACCESS_KEY"
+	SessionTokenEnv    = "AWS_SESSION_TOKEN"
+	RegionEnv          = "AWS_REGION"
+	// EndpointEnv points at S3-compatible storage, e.g. MinIO or Ceph, addressed with path-style URLs
+	EndpointEnv = "AWS_ENDPOINT_URL_S3"
+)
+
+// DefaultRegion is the region of buckets when RegionEnv is not set
+const DefaultRegion = "us-east-1"
+
+// emptyPayloadHash is the SHA-256 of an empty request body
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// Credentials sign requests to S3
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	// SessionToken is set for temporary credentials
+	SessionToken string
+}
+
+// S3 is a prefix of an S3 bucket holding backups, reached with requests signed with AWS Signature Version 4
+type S3 struct {
+	httpClient  *http.Client
+	credentials Credentials
+	region      string
+	// endpoint is the S3-compatible storage of the bucket, empty for AWS
+	endpoint string
+	bucket   string
+	prefix   string
+	// now is the time requests are signed at
+	now func() time.Time
+}
+
+// NewS3 creates an S3 target for the prefix of a bucket in a region, on endpoint or AWS when empty
+func NewS3(httpClient *http.Client, credentials Credentials, region, endpoint, bucket, prefix string) *S3 {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	if region == "" {
+		region = DefaultRegion
+	}
+	return &S3{
+		httpClient:  httpClient,
+		credentials: credentials,
+		region:      region,
+		endpoint:    strings.TrimSuffix(endpoint, "/"),
+		bucket:      bucket,
+		prefix:      strings.Trim(prefix, "/"),
+		now:         time.Now,
+	}
+}
+
+// NewS3FromEnv creates an S3 target for the prefix of a bucket configured by the AWS environment variables
+func NewS3FromEnv(bucket, prefix string) (*S3, error) {
+	credentials := Credentials{
+		AccessKeyID:     os.Getenv(AccessKeyIDEnv),
+		SecretAccessKey: os.Getenv(SecretAccessKeyEnv),
+		SessionToken:    os.Getenv(SessionTokenEnv),
+	}
+	if credentials.AccessKeyID == "" || credentials.SecretAccessKey == "" {
+		return nil, fmt.Errorf("%s and %s are required for S3 backups", AccessKeyIDEnv, SecretAccessKeyEnv)
+	}
+	return NewS3(nil, credentials, os.Getenv(RegionEnv), os.Getenv(EndpointEnv), bucket, prefix), nil
+}
+
+// Put uploads a backup to the bucket
+func (s *S3) Put(ctx context.Context, name string, content io.ReadSeeker) error {
+	hash := sha256.New()
+	size, err := io.Copy(hash, content)
+	if err != nil {
+		return err
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.url(s.key(name), nil), io.NopCloser(content))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	resp, err := s.do(req, hex.EncodeToString(hash.Sum(nil)))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Get downloads a backup of the bucket
+func (s *S3) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url(s.key(name), nil), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.do(req, emptyPayloadHash)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// listResult is a page of ListObjectsV2
+type listResult struct {
+	Contents []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// List lists the backups of the bucket's prefix starting with prefix
+func (s *S3) List(ctx context.Context, prefix string) ([]string, error) {
+	query := url.Values{"list-type": {"2"}, "prefix": {s.key(prefix)}}
+	var names []string
+	for {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url("", query), nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := s.do(req, emptyPayloadHash)
+		if err != nil {
+			return nil, err
+		}
+		var page listResult
+		err = xml.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the objects of %s: %w", s, err)
+		}
+		for _, object := range page.Contents {
+			names = append(names, strings.TrimPrefix(object.Key, s.key("")))
+		}
+		if !page.IsTruncated {
+			return names, nil
+		}
+		query.Set("continuation-token", page.NextContinuationToken)
+	}
+}
+
+// String returns the location of the target, as Open parses it
+func (s *S3) String() string {
+	return "s3://" + s.bucket + "/" + s.prefix
+}
+
+// key returns the object key of a name
+func (s *S3) key(name string) string {
+	if s.prefix == "" {
+		return name
+	}
+	return s.prefix + "/" + name
+}
+
+// url returns the URL of an object of the bucket, or of the bucket itself for an empty key
+// AWS is addressed with virtual-hosted URLs, other endpoints with path-style ones
+func (s *S3) url(key string, query url.Values) string {
+	u := "https://" + s.bucket + ".s3." + s.region + ".amazonaws.com/" + escapePath(key)
+	if s.endpoint != "" {
+		u = s.endpoint + "/" + s.bucket
+		if key != "" {
+			u += "/" + escapePath(key)
+		}
+	}
+	if len(query) > 0 {
+		u += "?" + canonicalQuery(query)
+	}
+	return u
+}
+
+// do signs and sends a request, failing for responses other than 200 OK
+func (s *S3) do(req *http.Request, payloadHash string) (*http.Response, error) {
+	sign(req, s.credentials, s.region, payloadHash, s.now())
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("%s %s: %s: %s", req.Method, req.URL.Path, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return resp, nil
+}
+
+// sign signs a request with AWS Signature Version 4 for S3, covering its host and every header set on it
+func sign(req *http.Request, credentials Credentials, region, payloadHash string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if credentials.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", credentials.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, hexSHA256(canonicalRequest)}, "\n")
+	key := []byte("AWS4" + credentials.SecretAccessKey)
+	for _, part := range []string{date, region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		credentials.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// canonicalQuery encodes a query with its parameters sorted, as signatures expect
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, key := range keys {
+		values := append([]string(nil), query[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			parts = append(parts, escape(key, true)+"="+escape(value, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// escapePath escapes an object key for a URL path, keeping its slashes
+func escapePath(key string) string {
+	return escape(key, false)
+}
+
+// escape percent-encodes everything but the unreserved characters of RFC 3986, and slashes unless escapeSlash
+func escape(s string, escapeSlash bool) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !escapeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func hexSHA256(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,382 @@
+// Package store backs up the history of the dashboard, the commits of the data branch and the run manifest carrying
+// trends, timings, ratchets and alerts across runs, to a directory or an S3 bucket, and restores it
+package store
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/collect"
+	"github.com/konflux-ci/coverage-dashboard/internal/pr"
+)
+
+// SchemaVersion is increased on incompatible changes to backups
+const SchemaVersion = 1
+
+const (
+	// backupPrefix and backupSuffix frame the names of backups, which sort by their time in between
+	backupPrefix = "coverage-dashboard-"
+	backupSuffix = ".tar.gz"
+	// backupTimeFormat is the time of a backup in its name
+	backupTimeFormat = "20060102T150405Z"
+
+	indexFile    = "backup.json"
+	bundleFile   = "data.bundle"
+	manifestFile = "run-manifest.json"
+	// snapshotFile is the dashboard.json of the last complete run, at the root of the data branch
+	snapshotFile = "dashboard.json"
+)
+
+// Index describes a backup and checksums its files
+type Index struct {
+	SchemaVersion int       `json:"schema_version"`
+	CreatedAt     time.Time `json:"created_at"`
+	// Branch is the data branch, and Head the commit of it backed up
+	Branch string `json:"branch"`
+	Head   string `json:"head"`
+	// ManifestFinishedAt is the end of the run the manifest records
+	ManifestFinishedAt time.Time `json:"manifest_finished_at"`
+	// Files are the SHA-256 checksums of the files of the backup, by name
+	Files map[string]string `json:"files"`
+}
+
+// Backup archives the data branch checked out in dataDir and the run manifest at manifestPath to target, and
+// returns the name of the backup
+// The checkout must hold the full history of the branch, and its last snapshot must not be newer than the manifest
+func Backup(ctx context.Context, dataDir, manifestPath string, target Target, now time.Time) (string, error) {
+	manifest, err := collect.LoadManifest(manifestPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to load the run manifest: %w", err)
+	}
+	index := Index{SchemaVersion: SchemaVersion, CreatedAt: now.UTC(), ManifestFinishedAt: manifest.FinishedAt, Files: make(map[string]string)}
+
+	if shallow, err := git(ctx, dataDir, "rev-parse", "--is-shallow-repository"); err != nil {
+		return "", err
+	} else if shallow == "true" {
+		return "", fmt.Errorf("%s is a shallow clone, fetch the full history of the data branch first (git fetch --unshallow)", dataDir)
+	}
+	if index.Branch, err = git(ctx, dataDir, "symbolic-ref", "--short", "HEAD"); err != nil {
+		return "", fmt.Errorf("%s is not on a branch: %w", dataDir, err)
+	}
+	if index.Head, err = git(ctx, dataDir, "rev-parse", "HEAD"); err != nil {
+		return "", err
+	}
+	if err := checkConsistent(dataDir, manifest); err != nil {
+		return "", err
+	}
+
+	work, err := os.MkdirTemp("", "coverage-backup-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create a temporary directory: %w", err)
+	}
+	defer os.RemoveAll(work)
+
+	bundle := filepath.Join(work, bundleFile)
+	if _, err := git(ctx, dataDir, "bundle", "create", bundle, "HEAD", "refs/heads/"+index.Branch); err != nil {
+		return "", err
+	}
+	if _, err := git(ctx, dataDir, "bundle", "verify", "--quiet", bundle); err != nil {
+		return "", fmt.Errorf("failed to verify the bundle of the data branch: %w", err)
+	}
+
+	archive := filepath.Join(work, "backup"+backupSuffix)
+	if err := writeArchive(archive, &index, map[string]string{bundleFile: bundle, manifestFile: manifestPath}); err != nil {
+		return "", err
+	}
+	file, err := os.Open(archive)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	name := backupPrefix + index.CreatedAt.Format(backupTimeFormat) + backupSuffix
+	if err := target.Put(ctx, name, file); err != nil {
+		return "", fmt.Errorf("failed to upload %s to %s: %w", name, target, err)
+	}
+	return name, nil
+}
+
+// Periodic backs up the data branch checked out in dataDir and the run manifest at manifestPath to target every
+// interval, until ctx is done
+// Failures only produce warnings; the next backup is attempted after the next interval
+func Periodic(ctx context.Context, dataDir, manifestPath string, target Target, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			name, err := Backup(ctx, dataDir, manifestPath, target, now)
+			if err != nil {
+				fmt.Printf("⚠️  Warning: failed to back up %s and %s: %v\n", dataDir, manifestPath, err)
+				continue
+			}
+			fmt.Printf("💾 Backed up %s and %s to %s/%s\n", dataDir, manifestPath, strings.TrimSuffix(target.String(), "/"), name)
+		}
+	}
+}
+
+// Latest returns the name of the newest backup of target
+func Latest(ctx context.Context, target Target) (string, error) {
+	names, err := target.List(ctx, backupPrefix)
+	if err != nil {
+		return "", fmt.Errorf("failed to list the backups of %s: %w", target, err)
+	}
+	var backups []string
+	for _, name := range names {
+		if strings.HasSuffix(name, backupSuffix) {
+			backups = append(backups, name)
+		}
+	}
+	if len(backups) == 0 {
+		return "", fmt.Errorf("no backups in %s", target)
+	}
+	sort.Strings(backups)
+	return backups[len(backups)-1], nil
+}
+
+// Restore checks the backup name of target and restores it: the data branch is cloned into dataDir, which must not
+// exist or be empty, and the run manifest is written to manifestPath
+func Restore(ctx context.Context, target Target, name, dataDir, manifestPath string) (*Index, error) {
+	if entries, err := os.ReadDir(dataDir); err == nil && len(entries) > 0 {
+		return nil, fmt.Errorf("%s is not empty", dataDir)
+	} else if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	work, err := os.MkdirTemp("", "coverage-restore-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a temporary directory: %w", err)
+	}
+	defer os.RemoveAll(work)
+
+	archive, err := target.Get(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s from %s: %w", name, target, err)
+	}
+	index, err := readArchive(archive, work)
+	archive.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+
+	manifest, err := collect.LoadManifest(filepath.Join(work, manifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("backup %s has an invalid run manifest: %w", name, err)
+	}
+	if !manifest.FinishedAt.Equal(index.ManifestFinishedAt) {
+		return nil, fmt.Errorf("backup %s has the manifest of the run finished at %s, its index records %s", name,
+			manifest.FinishedAt.Format(time.RFC3339), index.ManifestFinishedAt.Format(time.RFC3339))
+	}
+
+	dataDir, err = filepath.Abs(dataDir)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := git(ctx, work, "clone", "--quiet", "--branch", index.Branch, filepath.Join(work, bundleFile), dataDir); err != nil {
+		return nil, fmt.Errorf("failed to clone the data branch from backup %s: %w", name, err)
+	}
+	// The bundle is gone once restored; the remote of the branch is added back by whoever pushes it
+	if _, err := git(ctx, dataDir, "remote", "remove", "origin"); err != nil {
+		return nil, err
+	}
+	if head, err := git(ctx, dataDir, "rev-parse", "HEAD"); err != nil {
+		return nil, err
+	} else if head != index.Head {
+		return nil, fmt.Errorf("data branch of backup %s is at %s, its index records %s", name, head, index.Head)
+	}
+	if err := checkConsistent(dataDir, manifest); err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(manifestPath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := copyFile(filepath.Join(work, manifestFile), manifestPath); err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+// checkConsistent checks the last snapshot of a data branch checkout is not newer than the run manifest
+// Runs write their manifest even when interrupted, but only complete runs commit a snapshot, so a newer
+// snapshot means the manifest belongs to an older run
+func checkConsistent(dataDir string, manifest *collect.Manifest) error {
+	data, err := os.ReadFile(filepath.Join(dataDir, snapshotFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read the last snapshot: %w", err)
+	}
+	var snapshot collect.Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return fmt.Errorf("failed to parse the last snapshot: %w", err)
+	}
+	if snapshot.GeneratedAt.After(manifest.FinishedAt) {
+		return fmt.Errorf("the last snapshot of the data branch, of %s, is newer than the run manifest, of %s",
+			snapshot.GeneratedAt.Format(time.RFC3339), manifest.FinishedAt.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// writeArchive writes the index and files, by their name in the archive, to a gzipped tar archive at path,
+// checksumming the files into the index
+func writeArchive(path string, index *Index, files map[string]string) error {
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	compressed := gzip.NewWriter(out)
+	archive := tar.NewWriter(compressed)
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		sum, err := addFile(archive, name, files[name])
+		if err != nil {
+			return fmt.Errorf("failed to archive %s: %w", name, err)
+		}
+		index.Files[name] = sum
+	}
+
+	// The index comes last, as it checksums the files before it
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := archive.WriteHeader(&tar.Header{Name: indexFile, Mode: 0644, Size: int64(len(data)), ModTime: index.CreatedAt}); err != nil {
+		return err
+	}
+	if _, err := archive.Write(data); err != nil {
+		return err
+	}
+	if err := archive.Close(); err != nil {
+		return err
+	}
+	if err := compressed.Close(); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// addFile adds a file to an archive and returns its checksum
+func addFile(archive *tar.Writer, name, path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+	if err := archive.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: info.Size(), ModTime: info.ModTime()}); err != nil {
+		return "", err
+	}
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(archive, hash), file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// readArchive extracts a backup into dir and returns its index, once the checksums of its files match
+func readArchive(r io.Reader, dir string) (*Index, error) {
+	compressed, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	archive := tar.NewReader(compressed)
+
+	sums := make(map[string]string)
+	var index *Index
+	for {
+		header, err := archive.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch header.Name {
+		case indexFile:
+			index = &Index{}
+			if err := json.NewDecoder(archive).Decode(index); err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %w", indexFile, err)
+			}
+		case bundleFile, manifestFile:
+			if sums[header.Name], err = extract(archive, filepath.Join(dir, header.Name)); err != nil {
+				return nil, fmt.Errorf("failed to extract %s: %w", header.Name, err)
+			}
+		default:
+			return nil, fmt.Errorf("unexpected file %s", header.Name)
+		}
+	}
+
+	if index == nil {
+		return nil, fmt.Errorf("no %s", indexFile)
+	}
+	if index.SchemaVersion != SchemaVersion {
+		return nil, fmt.Errorf("unsupported schema version %d, expected %d", index.SchemaVersion, SchemaVersion)
+	}
+	for _, name := range []string{bundleFile, manifestFile} {
+		switch sum, ok := sums[name]; {
+		case !ok:
+			return nil, fmt.Errorf("no %s", name)
+		case sum != index.Files[name]:
+			return nil, fmt.Errorf("checksum of %s does not match the index, the backup is corrupt", name)
+		}
+	}
+	return index, nil
+}
+
+// extract writes a file of an archive to path and returns its checksum
+func extract(r io.Reader, path string) (string, error) {
+	out, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer out.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(out, hash), r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), out.Close()
+}
+
+// copyFile copies the file at from to path
+func copyFile(from, path string) error {
+	data, err := os.ReadFile(from)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// git runs a git command in dir and returns its output without the trailing newline
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	output, err := pr.RunGitCommand(ctx, dir, args...)
+	return strings.TrimSpace(output), err
+}
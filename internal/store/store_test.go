@@ -0,0 +1,128 @@
+package store_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/collect"
+	"github.com/konflux-ci/coverage-dashboard/internal/store"
+)
+
+var _ = Describe("Backup and Restore", func() {
+	var (
+		ctx      context.Context
+		tempDir  string
+		checkout string
+		manifest string
+		target   store.Dir
+		finished time.Time
+	)
+
+	git := func(args ...string) string {
+		out, err := exec.Command("git", args...).CombinedOutput()
+		Expect(err).NotTo(HaveOccurred(), string(out))
+		return strings.TrimSpace(string(out))
+	}
+
+	writeManifest := func(path string, finishedAt time.Time) {
+		data, err := json.Marshal(collect.Manifest{StartedAt: finishedAt.Add(-time.Hour), FinishedAt: finishedAt})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.WriteFile(path, data, 0644)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		tempDir = GinkgoT().TempDir()
+		remote := filepath.Join(tempDir, "remote.git")
+		checkout = filepath.Join(tempDir, "data")
+		git("init", "--bare", remote)
+		git("clone", remote, checkout)
+		git("-C", checkout, "checkout", "--orphan", "data")
+		git("-C", checkout, "config", "user.name", "test")
+		git("-C", checkout, "config", "user.email", "test@example.com")
+
+		coverage := 61.5
+		dashboard := collect.Dashboard{Data: []collect.Result{{Repo: "konflux-ci/api", Coverage: &coverage, Status: collect.StatusOK}}}
+		finished = time.Date(2026, 10, 15, 5, 0, 0, 0, time.UTC)
+		publisher := collect.NewSnapshotPublisher(checkout)
+		Expect(publisher.Publish(ctx, dashboard, finished.Add(-24*time.Hour))).To(Succeed())
+		Expect(publisher.Publish(ctx, dashboard, finished)).To(Succeed())
+
+		manifest = filepath.Join(tempDir, "run-manifest.json")
+		writeManifest(manifest, finished)
+		target = store.Dir(filepath.Join(tempDir, "backups"))
+	})
+
+	It("should restore the data branch and the manifest of the latest backup", func() {
+		first, err := store.Backup(ctx, checkout, manifest, target, finished.Add(time.Minute))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(first).To(Equal("coverage-dashboard-20261015T050100Z.tar.gz"))
+		latest, err := store.Backup(ctx, checkout, manifest, target, finished.Add(time.Hour))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(store.Latest(ctx, target)).To(Equal(latest))
+
+		restored := filepath.Join(tempDir, "restored")
+		restoredManifest := filepath.Join(tempDir, "restored", "..", "restored-manifest.json")
+		index, err := store.Restore(ctx, target, latest, restored, restoredManifest)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(index.Branch).To(Equal("data"))
+		Expect(index.Head).To(Equal(git("-C", checkout, "rev-parse", "HEAD")))
+		Expect(git("-C", restored, "rev-parse", "HEAD")).To(Equal(index.Head))
+		Expect(git("-C", restored, "rev-list", "--count", "HEAD")).To(Equal("2"))
+		Expect(git("-C", restored, "remote")).To(BeEmpty())
+
+		original, err := os.ReadFile(manifest)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.ReadFile(restoredManifest)).To(Equal(original))
+	})
+
+	It("should back up every interval until stopped", func() {
+		periodicCtx, cancel := context.WithCancel(ctx)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			store.Periodic(periodicCtx, checkout, manifest, target, 10*time.Millisecond)
+		}()
+
+		Eventually(func() (string, error) { return store.Latest(ctx, target) }).Should(HavePrefix("coverage-dashboard-"))
+		cancel()
+		Eventually(done).Should(BeClosed())
+	})
+
+	It("should refuse a manifest older than the last snapshot", func() {
+		writeManifest(manifest, finished.Add(-time.Hour))
+		_, err := store.Backup(ctx, checkout, manifest, target, finished)
+		Expect(err).To(MatchError(ContainSubstring("the last snapshot of the data branch, of 2026-10-15T05:00:00Z, is newer than the run manifest")))
+	})
+
+	It("should refuse shallow checkouts, which miss history", func() {
+		shallow := filepath.Join(tempDir, "shallow")
+		git("clone", "--depth", "1", "--branch", "data", "file://"+checkout, shallow)
+		_, err := store.Backup(ctx, shallow, manifest, target, finished)
+		Expect(err).To(MatchError(ContainSubstring("is a shallow clone")))
+	})
+
+	It("should refuse corrupt backups and non-empty directories", func() {
+		name, err := store.Backup(ctx, checkout, manifest, target, finished)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = store.Restore(ctx, target, name, checkout, filepath.Join(tempDir, "restored.json"))
+		Expect(err).To(MatchError(ContainSubstring("is not empty")))
+
+		path := filepath.Join(string(target), name)
+		data, err := os.ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.WriteFile(path, data[:len(data)/2], 0644)).To(Succeed())
+		_, err = store.Restore(ctx, target, name, filepath.Join(tempDir, "restored"), filepath.Join(tempDir, "restored.json"))
+		Expect(err).To(HaveOccurred())
+		Expect(filepath.Join(tempDir, "restored.json")).NotTo(BeAnExistingFile())
+	})
+})
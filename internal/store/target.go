@@ -0,0 +1,84 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Target is where backups are kept
+type Target interface {
+	// Put stores a backup under a name
+	Put(ctx context.Context, name string, content io.ReadSeeker) error
+	// Get reads the backup of a name
+	Get(ctx context.Context, name string) (io.ReadCloser, error)
+	// List returns the names of the backups starting with prefix
+	List(ctx context.Context, prefix string) ([]string, error)
+	fmt.Stringer
+}
+
+// Open returns the target of a location, an S3 bucket as s3://bucket/prefix or else a directory
+func Open(location string) (Target, error) {
+	if rest, ok := strings.CutPrefix(location, "s3://"); ok {
+		bucket, prefix, _ := strings.Cut(rest, "/")
+		if bucket == "" {
+			return nil, fmt.Errorf("invalid location %q, expected s3://bucket/prefix", location)
+		}
+		return NewS3FromEnv(bucket, prefix)
+	}
+	if location == "" {
+		return nil, fmt.Errorf("no backup location")
+	}
+	return Dir(location), nil
+}
+
+// Dir is a directory of backups, e.g. on a volume mounted from other storage
+type Dir string
+
+// Put writes a backup to the directory, replacing it at once
+func (d Dir) Put(ctx context.Context, name string, content io.ReadSeeker) error {
+	if err := os.MkdirAll(string(d), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	temp, err := os.CreateTemp(string(d), ".backup-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+	if _, err := io.Copy(temp, content); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), filepath.Join(string(d), name))
+}
+
+// Get opens a backup of the directory
+func (d Dir) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(string(d), name))
+}
+
+// List lists the backups of the directory starting with prefix
+func (d Dir) List(ctx context.Context, prefix string) ([]string, error) {
+	entries, err := os.ReadDir(string(d))
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && strings.HasPrefix(entry.Name(), prefix) {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
+
+// String returns the path of the directory
+func (d Dir) String() string {
+	return string(d)
+}
@@ -0,0 +1,135 @@
+// Package thirdparty detects copies of upstream projects embedded in a repository outside vendor/, e.g. forks kept in
+// third_party/ or upstream/, whose coverage measures someone else's code
+package thirdparty
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// licenseFile matches the names of license files, e.g. LICENSE, LICENSE.md, LICENCE or COPYING
+var licenseFile = regexp.MustCompile(`(?i)^(licen[cs]e|copying)([.-].*)?$`)
+
+// Tree is a directory detected as an embedded third-party project
+type Tree struct {
+	// Dir is the directory of the tree, relative to the repository root
+	Dir string
+	// License is the license file of the tree
+	License string
+	// Module is the module path of the tree's go.mod, empty when it has none
+	Module string
+}
+
+// Reason describes why a tree was detected
+func (t Tree) Reason() string {
+	if t.Module != "" {
+		return fmt.Sprintf("%s and module %s", t.License, t.Module)
+	}
+	return t.License + " outside any module of its own"
+}
+
+// Contains reports whether a directory relative to the repository root is the tree or one of its subdirectories
+func (t Tree) Contains(dir string) bool {
+	return dir == t.Dir || strings.HasPrefix(dir, t.Dir+"/")
+}
+
+// Detect finds the embedded third-party trees among the files of a repository, given by their slash-separated paths
+// relative to its root. A tree is a directory with a license file of its own that either is no module of its own,
+// or is a module whose path is not within the repository's module; readFile reads the go.mod files this needs
+// Directories the go command ignores are skipped, and so are trees nested in other trees
+func Detect(files []string, readFile func(name string) ([]byte, error)) ([]Tree, error) {
+	licenses := make(map[string]string)
+	goMods := make(map[string]bool)
+	for _, file := range files {
+		dir, name := path.Split(file)
+		dir = path.Clean(dir)
+		if dir == "." || ignored(dir) {
+			continue
+		}
+		switch {
+		case name == "go.mod":
+			goMods[dir] = true
+		case licenseFile.MatchString(name) && path.Ext(name) != ".go":
+			if _, ok := licenses[dir]; !ok || name < licenses[dir] {
+				licenses[dir] = name
+			}
+		}
+	}
+
+	dirs := make([]string, 0, len(licenses))
+	for dir := range licenses {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	repoModule, repoModuleRead := "", false
+	var trees []Tree
+	for _, dir := range dirs {
+		if within(trees, dir) {
+			continue
+		}
+		tree := Tree{Dir: dir, License: licenses[dir]}
+		if goMods[dir] {
+			if !repoModuleRead {
+				var err error
+				if repoModule, err = modulePath(readFile, "go.mod"); err != nil {
+					return nil, err
+				}
+				repoModuleRead = true
+			}
+			// Without a root module, nested modules cannot be told apart from the repository's own
+			if repoModule == "" {
+				continue
+			}
+			module, err := modulePath(readFile, path.Join(dir, "go.mod"))
+			if err != nil {
+				return nil, err
+			}
+			if module == "" || module == repoModule || strings.HasPrefix(module, repoModule+"/") {
+				continue
+			}
+			tree.Module = module
+		}
+		trees = append(trees, tree)
+	}
+	return trees, nil
+}
+
+// within reports whether a directory is in one of the trees
+func within(trees []Tree, dir string) bool {
+	for _, tree := range trees {
+		if tree.Contains(dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// ignored reports whether the go command ignores a directory: vendor, testdata and those starting with "." or "_"
+func ignored(dir string) bool {
+	for _, element := range strings.Split(dir, "/") {
+		if element == "vendor" || element == "testdata" || strings.HasPrefix(element, ".") || strings.HasPrefix(element, "_") {
+			return true
+		}
+	}
+	return false
+}
+
+// modulePath returns the module path of a go.mod file, empty when it does not exist or declares none
+func modulePath(readFile func(name string) ([]byte, error), name string) (string, error) {
+	data, err := readFile(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	return modfile.ModulePath(data), nil
+}
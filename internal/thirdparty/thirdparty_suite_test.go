@@ -0,0 +1,13 @@
+package thirdparty_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestThirdParty(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Third Party Suite")
+}
@@ -0,0 +1,73 @@
+package thirdparty_test
+
+import (
+	"fmt"
+	"io/fs"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/thirdparty"
+)
+
+var _ = Describe("Detect", func() {
+	goMods := map[string]string{
+		"go.mod":                        "module github.com/konflux-ci/operator\n\ngo 1.22\n",
+		"sdk/go.mod":                    "module github.com/konflux-ci/operator/sdk\n",
+		"third_party/kube/go.mod":       "module k8s.io/kube\n",
+		"third_party/kube/api/go.mod":   "module k8s.io/kube/api\n",
+		"upstream/controller/README.md": "",
+	}
+	readFile := func(name string) ([]byte, error) {
+		content, ok := goMods[name]
+		if !ok {
+			return nil, fmt.Errorf("open %s: %w", name, fs.ErrNotExist)
+		}
+		return []byte(content), nil
+	}
+
+	It("should detect license files outside the repository's modules", func() {
+		trees, err := thirdparty.Detect([]string{
+			"LICENSE",
+			"go.mod",
+			"sdk/LICENSE",
+			"sdk/go.mod",
+			"third_party/kube/LICENSE",
+			"third_party/kube/go.mod",
+			"third_party/kube/api/LICENSE",
+			"third_party/kube/api/go.mod",
+			"upstream/controller/COPYING.txt",
+			"upstream/controller/LICENSE.md",
+			"vendor/example.com/lib/LICENSE",
+			"pkg/testdata/LICENSE",
+			"pkg/license.go",
+		}, readFile)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(trees).To(Equal([]thirdparty.Tree{
+			{Dir: "third_party/kube", License: "LICENSE", Module: "k8s.io/kube"},
+			{Dir: "upstream/controller", License: "COPYING.txt"},
+		}))
+		Expect(trees[0].Reason()).To(Equal("LICENSE and module k8s.io/kube"))
+		Expect(trees[1].Reason()).To(Equal("COPYING.txt outside any module of its own"))
+		Expect(trees[0].Contains("third_party/kube/api")).To(BeTrue())
+		Expect(trees[0].Contains("third_party/kubelet")).To(BeFalse())
+	})
+
+	It("should not judge nested modules of repositories without a root module", func() {
+		trees, err := thirdparty.Detect([]string{"third_party/kube/LICENSE", "third_party/kube/go.mod"}, func(name string) ([]byte, error) {
+			if name == "go.mod" {
+				return nil, fs.ErrNotExist
+			}
+			return readFile(name)
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(trees).To(BeEmpty())
+	})
+
+	It("should fail when go.mod files cannot be read", func() {
+		_, err := thirdparty.Detect([]string{"third_party/kube/LICENSE", "third_party/kube/go.mod"}, func(name string) ([]byte, error) {
+			return nil, fmt.Errorf("rate limited")
+		})
+		Expect(err).To(MatchError(ContainSubstring("failed to read go.mod: rate limited")))
+	})
+})
@@ -0,0 +1,178 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/collect"
+)
+
+const (
+	// SchemaVersion is increased on incompatible changes to the payloads, so consumers can reject those they
+	// cannot parse
+	SchemaVersion = 1
+
+	// SecretEnv holds the secret payloads are signed with
+	SecretEnv = "COVERAGE_WEBHOOK_SECRET"
+
+	// SignatureHeader carries the HMAC-SHA256 of the body, keyed with the secret, as sha256=<hex>
+	SignatureHeader = "X-Coverage-Dashboard-Signature"
+	// EventHeader carries the type of the payload, so consumers can route it before parsing the body
+	EventHeader = "X-Coverage-Dashboard-Event"
+
+	signaturePrefix = "sha256="
+	maxErrorBody    = 512
+	// defaultTimeout bounds each delivery, so an unresponsive receiver does not hold up the run
+	defaultTimeout = 10 * time.Second
+)
+
+// Types of payloads
+const (
+	TypeRegression        = "regression"
+	TypeCollectionFailure = "collection_failure"
+)
+
+// Payload is the JSON body of every webhook delivery; exactly one of Regression and Failure is set, by Type
+type Payload struct {
+	SchemaVersion int    `json:"schema_version"`
+	Type          string `json:"type"`
+	// Event is the step of the alert: opened, reminder, escalated or resolved
+	Event      string             `json:"event"`
+	SentAt     time.Time          `json:"sent_at"`
+	Regression *Regression        `json:"regression,omitempty"`
+	Failure    *CollectionFailure `json:"failure,omitempty"`
+}
+
+// Regression is the payload of a regression alert
+type Regression struct {
+	collect.Regression
+	// Violations counts the consecutive runs the repository has been regressed, including this one
+	Violations int       `json:"consecutive_violations"`
+	Since      time.Time `json:"since"`
+}
+
+// CollectionFailure is the payload of a collection failure alert
+type CollectionFailure struct {
+	Repo        string    `json:"repo"`
+	ConfigFile  string    `json:"config_file"`
+	Owners      []string  `json:"owners"`
+	Groups      []string  `json:"groups,omitempty"`
+	Consecutive int       `json:"consecutive_failures"`
+	Since       time.Time `json:"since"`
+	Status      string    `json:"status"`
+	LastError   string    `json:"last_error,omitempty"`
+	RunURL      string    `json:"run_url,omitempty"`
+	// LastCollected and MaxAgeSeconds are set on escalated events, once the repository's data got stale
+	LastCollected *time.Time `json:"last_collected,omitempty"`
+	MaxAgeSeconds int64      `json:"max_age_seconds,omitempty"`
+}
+
+// Notifier posts regression and collection failure alerts as signed JSON to a webhook
+type Notifier struct {
+	client *http.Client
+	url    string
+	secret []byte
+	now    func() time.Time
+}
+
+// NewNotifier creates a Notifier posting to the http(s) URL, signing payloads with secret
+// A nil client uses a client timing out after 10 seconds
+func NewNotifier(client *http.Client, endpoint, secret string) (*Notifier, error) {
+	parsed, err := url.Parse(endpoint)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("webhook URL must be an http(s) URL, got %q", endpoint)
+	}
+	if secret == "" {
+		return nil, fmt.Errorf("a secret is required to sign webhook payloads")
+	}
+	if client == nil {
+		client = &http.Client{Timeout: defaultTimeout}
+	}
+	return &Notifier{client: client, url: endpoint, secret: []byte(secret), now: time.Now}, nil
+}
+
+// NotifyAlert posts a regression alert
+func (n *Notifier) NotifyAlert(ctx context.Context, alert collect.Alert) error {
+	return n.post(ctx, Payload{
+		Type:  TypeRegression,
+		Event: alert.Event,
+		Regression: &Regression{
+			Regression: alert.Regression,
+			Violations: alert.Violations,
+			Since:      alert.Since,
+		},
+	})
+}
+
+// NotifyFailure posts a collection failure alert
+func (n *Notifier) NotifyFailure(ctx context.Context, alert collect.FailureAlert) error {
+	return n.post(ctx, Payload{
+		Type:  TypeCollectionFailure,
+		Event: alert.Event,
+		Failure: &CollectionFailure{
+			Repo:          alert.Repo,
+			ConfigFile:    alert.ConfigFile,
+			Owners:        alert.Owners,
+			Groups:        alert.Groups,
+			Consecutive:   alert.Consecutive,
+			Since:         alert.Since,
+			Status:        alert.Status,
+			LastError:     alert.LastError,
+			RunURL:        alert.RunURL,
+			LastCollected: alert.LastCollected,
+			MaxAgeSeconds: int64(alert.MaxAge / time.Second),
+		},
+	})
+}
+
+// post signs and sends a payload, failing on any response but a 2xx
+func (n *Notifier) post(ctx context.Context, payload Payload) error {
+	payload.SchemaVersion = SchemaVersion
+	payload.SentAt = n.now().UTC()
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(EventHeader, payload.Type)
+	request.Header.Set(SignatureHeader, Sign(body, n.secret))
+
+	response, err := n.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("failed to post %s webhook: %w", payload.Type, err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		message, _ := io.ReadAll(io.LimitReader(response.Body, maxErrorBody))
+		return fmt.Errorf("webhook responded %s to %s: %s", response.Status, payload.Type, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
+
+// Sign returns the signature header of body keyed with secret, as sha256=<hex>
+func Sign(body, secret []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature, the value of SignatureHeader, signs body with secret
+// Comparisons take constant time, for consumers verifying deliveries
+func Verify(body, secret []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(body, secret)), []byte(signature))
+}
@@ -0,0 +1,115 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/coverage-dashboard/internal/collect"
+	"github.com/konflux-ci/coverage-dashboard/internal/webhook"
+)
+
+var _ = Describe("Notifier", func() {
+	const secret = "s3cret"
+
+	var (
+		server   *httptest.Server
+		status   int
+		body     []byte
+		header   http.Header
+		notifier *webhook.Notifier
+	)
+
+	BeforeEach(func() {
+		status = http.StatusNoContent
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.Method).To(Equal(http.MethodPost))
+			body, _ = io.ReadAll(r.Body)
+			header = r.Header
+			w.WriteHeader(status)
+			if status >= 300 {
+				_, _ = w.Write([]byte("unknown schema\n"))
+			}
+		}))
+		var err error
+		notifier, err = webhook.NewNotifier(server.Client(), server.URL+"/events", secret)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should post regression alerts signed with the secret", func() {
+		since := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+		Expect(notifier.NotifyAlert(context.Background(), collect.Alert{
+			Event:      collect.AlertEscalated,
+			Regression: collect.Regression{Repo: "konflux-ci/api", Kind: collect.KindDrop, Previous: 72.4, Current: 60.2, Owners: []string{"@konflux-ci/api-team"}},
+			Violations: 3,
+			Since:      since,
+		})).To(Succeed())
+
+		Expect(header.Get("Content-Type")).To(Equal("application/json"))
+		Expect(header.Get(webhook.EventHeader)).To(Equal(webhook.TypeRegression))
+		Expect(header.Get(webhook.SignatureHeader)).To(HavePrefix("sha256="))
+		Expect(webhook.Verify(body, []byte(secret), header.Get(webhook.SignatureHeader))).To(BeTrue())
+		Expect(webhook.Verify(body, []byte("other"), header.Get(webhook.SignatureHeader))).To(BeFalse())
+
+		var payload map[string]any
+		Expect(json.Unmarshal(body, &payload)).To(Succeed())
+		Expect(payload).To(HaveKeyWithValue("schema_version", BeEquivalentTo(webhook.SchemaVersion)))
+		Expect(payload).To(HaveKeyWithValue("type", "regression"))
+		Expect(payload).To(HaveKeyWithValue("event", "escalated"))
+		Expect(payload).To(HaveKey("sent_at"))
+		Expect(payload).NotTo(HaveKey("failure"))
+		regression := payload["regression"].(map[string]any)
+		Expect(regression).To(HaveKeyWithValue("repo", "konflux-ci/api"))
+		Expect(regression).To(HaveKeyWithValue("kind", "drop"))
+		Expect(regression).To(HaveKeyWithValue("current", 60.2))
+		Expect(regression).To(HaveKeyWithValue("consecutive_violations", BeEquivalentTo(3)))
+		Expect(regression).To(HaveKeyWithValue("since", "2024-03-01T00:00:00Z"))
+	})
+
+	It("should post collection failure alerts", func() {
+		Expect(notifier.NotifyFailure(context.Background(), collect.FailureAlert{
+			Event:       collect.AlertOpened,
+			Repo:        "konflux-ci/cli",
+			ConfigFile:  "cli.yaml",
+			Consecutive: 3,
+			Status:      "failed",
+			LastError:   "go test: exit status 1",
+			MaxAge:      48 * time.Hour,
+		})).To(Succeed())
+
+		Expect(header.Get(webhook.EventHeader)).To(Equal(webhook.TypeCollectionFailure))
+		Expect(webhook.Verify(body, []byte(secret), header.Get(webhook.SignatureHeader))).To(BeTrue())
+		var payload webhook.Payload
+		Expect(json.Unmarshal(body, &payload)).To(Succeed())
+		Expect(payload.SchemaVersion).To(Equal(webhook.SchemaVersion))
+		Expect(payload.Event).To(Equal(collect.AlertOpened))
+		Expect(payload.Regression).To(BeNil())
+		Expect(payload.Failure.Repo).To(Equal("konflux-ci/cli"))
+		Expect(payload.Failure.Consecutive).To(Equal(3))
+		Expect(payload.Failure.MaxAgeSeconds).To(BeEquivalentTo(48 * 3600))
+	})
+
+	It("should fail on responses other than 2xx", func() {
+		status = http.StatusBadRequest
+		err := notifier.NotifyFailure(context.Background(), collect.FailureAlert{Event: collect.AlertResolved, Repo: "konflux-ci/cli"})
+		Expect(err).To(MatchError(ContainSubstring("400 Bad Request")))
+		Expect(err).To(MatchError(ContainSubstring("unknown schema")))
+	})
+
+	It("should require an http(s) URL and a secret", func() {
+		_, err := webhook.NewNotifier(nil, "ftp://example.com/hook", secret)
+		Expect(err).To(HaveOccurred())
+		_, err = webhook.NewNotifier(nil, "https://example.com/hook", "")
+		Expect(err).To(HaveOccurred())
+	})
+})